@@ -0,0 +1,116 @@
+// Package main provides the command-line tool upgrading JSON result files to the
+// current schema version.
+//
+// Result files carry a schema_version field (files written before it was introduced
+// are version 0). When the layout of the result files changes, this tool converts
+// archived result files, so that they remain consumable by current tools.
+//
+// Usage:
+//
+//	convert_results -input <results.json|results/> [-output upgraded.json] [-check]
+//
+// Flags:
+//   - input: Path of a JSON result file, or of a directory searched recursively for
+//     .json and .json.gz files (required). Compressed files are written back
+//     compressed.
+//   - output: Path of the upgraded file, for a single input file (optional, defaults
+//     to upgrading the input files in place)
+//   - check: Only report the files that need an upgrade, without writing them; the
+//     program exits with status 1 if any file needs one
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// main is the entry point for the result conversion application.
+// It parses command-line flags, collects the result files and upgrades the files
+// of an earlier schema version.
+//
+// The program accepts the following flags:
+//   - input: Path of a JSON result file or of a directory of result files (required)
+//   - output: Path of the upgraded file, for a single input file (optional)
+//   - check: Only report the files that need an upgrade (optional)
+//
+// If the input is missing or if a file cannot be upgraded, the program will
+// terminate with a fatal error message.
+func main() {
+	inputPath := flag.String("input", "", "Path of a JSON result file or of a directory of result files (required)")
+	outputPath := flag.String("output", "", "Path of the upgraded file, for a single input file (default: in place)")
+	check := flag.Bool("check", false, "Only report the files that need an upgrade, without writing them")
+	flag.Parse()
+
+	if *inputPath == "" {
+		log.Fatal("Error: You must provide a result file or directory using -input")
+	}
+
+	files := []string{*inputPath}
+	if !strings.HasSuffix(*inputPath, ".json") && !strings.HasSuffix(*inputPath, ".json"+critical_speed.CompressedSuffix) {
+		found, err := storage.List(*inputPath, ".json")
+		if err != nil {
+			log.Fatalf("Error listing result files: %v", err)
+		}
+		compressed, err := storage.List(*inputPath, ".json"+critical_speed.CompressedSuffix)
+		if err != nil {
+			log.Fatalf("Error listing result files: %v", err)
+		}
+		found = append(found, compressed...)
+		if len(found) == 0 {
+			log.Fatalf("Error: no JSON result files found in %s", *inputPath)
+		}
+		files = found
+	}
+	if *outputPath != "" && len(files) > 1 {
+		log.Fatal("Error: -output can only be used with a single input file")
+	}
+
+	outdated := 0
+	for _, file := range files {
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading %s: %v", file, err)
+		}
+		upgraded, version, err := critical_speed.UpgradeResults(data)
+		if err != nil {
+			log.Fatalf("Error upgrading %s: %v", file, err)
+		}
+
+		destination := file
+		if *outputPath != "" {
+			destination = *outputPath
+		}
+		if version == critical_speed.SchemaVersion && destination == file {
+			continue
+		}
+		outdated++
+		if *check {
+			fmt.Printf("%s: schema_version %d\n", file, version)
+			continue
+		}
+		if critical_speed.IsCompressed(destination) {
+			if upgraded, err = critical_speed.CompressResults(upgraded); err != nil {
+				log.Fatalf("Error compressing %s: %v", destination, err)
+			}
+		}
+		if err := storage.WriteFile(destination, upgraded); err != nil {
+			log.Fatalf("Error writing %s: %v", destination, err)
+		}
+		fmt.Printf("%s: schema_version %d -> %d\n", destination, version, critical_speed.SchemaVersion)
+	}
+
+	if *check {
+		fmt.Printf("%d of %d files need an upgrade to schema_version %d\n", outdated, len(files), critical_speed.SchemaVersion)
+		if outdated > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Printf("%d of %d files upgraded to schema_version %d\n", outdated, len(files), critical_speed.SchemaVersion)
+}
@@ -0,0 +1,122 @@
+// Package main provides the command-line tool cross-validating GoTrain against a
+// reference result of the Python TrainCritSpeed.
+//
+// The tool computes a configuration file, compares its dispersion curves and
+// critical speed with the reference result file, and prints the differences. The
+// program exits with status 1 when the critical speed or the phase velocities
+// deviate from the reference by more than the tolerance, so that the validation of
+// a migration is a single command.
+//
+// Usage:
+//
+//	crossval -config <path/to/config.yaml> -reference <path/to/reference.json> [-tolerance 0.01] [-output differences.csv]
+//
+// Flags:
+//   - config: Path to the YAML configuration file (required)
+//   - reference: Path to the reference result JSON file of TrainCritSpeed (required)
+//   - tolerance: Largest relative difference accepted (optional, default 0.01)
+//   - output: Path of the CSV file with the reference and computed phase velocities
+//     at every frequency of the reference (optional)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	crossval "github.com/PlatypusBytes/GoTrain/internal/crossval"
+)
+
+// main is the entry point for the cross-validation application.
+// It parses command-line flags, loads the configuration and the reference result,
+// computes the configuration and prints the differences from the reference.
+//
+// The program accepts the following flags:
+//   - config: Path to the YAML configuration file (required)
+//   - reference: Path to the reference result JSON file (required)
+//   - tolerance: Largest relative difference accepted (optional)
+//   - output: Path of the differences CSV file (optional)
+//
+// If a required flag is missing or if an error occurs during execution, the
+// program will terminate with a fatal error message. If the comparison fails, it
+// exits with status 1.
+func main() {
+	configPath := flag.String("config", "", "Path to configuration YAML file (required)")
+	referencePath := flag.String("reference", "", "Path to the reference result JSON file of TrainCritSpeed (required)")
+	tolerance := flag.Float64("tolerance", 0.01, "Largest relative difference of the critical speed and phase velocities accepted")
+	outputPath := flag.String("output", "", "Path of the differences CSV file (optional)")
+	flag.Parse()
+
+	if *configPath == "" || *referencePath == "" {
+		log.Fatal("Error: You must provide a configuration file using -config and a reference result using -reference")
+	}
+
+	config, err := critical_speed.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reference, err := crossval.LoadReference(*referencePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := critical_speed.Compute(context.Background(), config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := crossval.Compare(reference, result, *tolerance)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Critical speed [m/s]\t%.2f\t(reference %.2f)\n", report.CriticalVelocity, report.ReferenceCriticalVelocity)
+	fmt.Fprintf(tw, "Critical omega [rad/s]\t%.2f\t(reference %.2f)\n", report.CriticalOmega, report.ReferenceCriticalOmega)
+	fmt.Fprintf(tw, "Critical speed deviation [%%]\t%.3f\n", 100*report.Deviation)
+	for _, c := range []struct {
+		name   string
+		report crossval.CurveReport
+	}{{"Track", report.Track}, {"Soil", report.Soil}} {
+		fmt.Fprintf(tw, "%s points compared\t%d\n", c.name, c.report.Compared)
+		fmt.Fprintf(tw, "%s max deviation [%%]\t%.3f\t(at %.2f Hz)\n", c.name, 100*c.report.MaxDeviation, c.report.WorstOmega/(2*math.Pi))
+	}
+	fmt.Fprintf(tw, "Tolerance [%%]\t%.3f\n", 100*report.Tolerance)
+	tw.Flush()
+	for _, c := range []struct {
+		name   string
+		report crossval.CurveReport
+	}{{"track", report.Track}, {"soil", report.Soil}} {
+		for _, w := range c.report.Missing {
+			fmt.Printf("Root of the %s curve in only one result at %.2f Hz\n", c.name, w/(2*math.Pi))
+		}
+		for _, w := range c.report.Skipped {
+			fmt.Printf("Skipped %.2f Hz: outside the computed %s curve\n", w/(2*math.Pi), c.name)
+		}
+	}
+
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			log.Fatalf("Error creating differences file: %v", err)
+		}
+		if err := crossval.WriteCSV(file, report); err != nil {
+			file.Close()
+			log.Fatalf("Error writing differences file: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			log.Fatalf("Error writing differences file: %v", err)
+		}
+		fmt.Printf("Differences written to %s\n", *outputPath)
+	}
+
+	if !report.Passed {
+		fmt.Println("FAIL: the result deviates from the reference by more than the tolerance")
+		os.Exit(1)
+	}
+	fmt.Println("PASS: the result agrees with the reference within the tolerance")
+}
@@ -0,0 +1,64 @@
+// Package main provides the interactive terminal explorer of result files.
+//
+// The explorer loads one or more JSON result files, or directories of result files
+// (e.g. the output of a batch), and lets the user browse them with the keyboard:
+// the list of critical velocities with the batch statistics, the dispersion curves
+// of each result and the histogram of the critical velocities. It is built on
+// Bubble Tea and runs in any terminal, e.g. over SSH on a compute cluster without
+// a display.
+//
+// Usage:
+//
+//	explore [-print] <results.json|results/> ...
+//
+// Flags:
+//   - print: Print the table of the results and the batch statistics instead of
+//     starting the interactive explorer (also used when stdin is not a terminal)
+//
+// Keys: up/down select a result, Enter shows its curves, left/right move along the
+// frequencies, s sorts by critical velocity, H shows the histogram, b returns to
+// the list and q quits.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+
+	explorer "github.com/PlatypusBytes/GoTrain/internal/explorer"
+)
+
+// main is the entry point for the result explorer application.
+// It parses command-line flags, loads the result files and runs the explorer.
+//
+// The program accepts the following flags:
+//   - print: Print the summary instead of starting the interactive explorer (optional)
+//
+// If no result file is given or a file cannot be loaded, the program will
+// terminate with a fatal error message.
+func main() {
+	printSummary := flag.Bool("print", false, "Print the table of the results and the batch statistics, without interaction")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("Error: You must provide one or more result files or directories")
+	}
+	entries, err := explorer.Load(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	e := explorer.New(entries)
+
+	if *printSummary || !term.IsTerminal(os.Stdin.Fd()) {
+		// Not interactive, e.g. in a batch job: print the summary instead
+		fmt.Print(e.Summary())
+		return
+	}
+	if _, err := tea.NewProgram(e, tea.WithAltScreen()).Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	runner "github.com/PlatypusBytes/GoTrain/internal/runner"
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+// runBatch implements the batch command, which processes multiple YAML configuration
+// files in parallel, executing critical speed analysis on each file concurrently. It
+// is designed for high-throughput processing of multiple configurations, utilizing
+// worker goroutines to maximize efficiency.
+//
+// Usage:
+//
+//	gotrain batch -dir <path/to/config/directory> [flags]
+//
+// The configuration directory must be provided via the -dir flag and should contain
+// one or more YAML configuration files. The command will recursively search for all
+// .yaml files in the specified directory and process them concurrently.
+//
+// Alternatively, the jobs can be listed in a manifest file with -manifest, one
+// configuration path per line, optionally followed by the path of its result file.
+// The jobs are then dispatched in the order in which they are listed.
+//
+// A parameter study can be run without writing its configurations to disk, from a
+// template configuration and a sweep specification listing the parameter values:
+//
+//	gotrain batch -template configs/sample_config.yaml -sweep configs/sample_sweep.yaml [flags]
+//
+// The critical speed along a track alignment is computed from a template configuration
+// and an alignment listing the soil of each section by chainage, and exported as a
+// CSV profile and GeoJSON features for GIS:
+//
+//	gotrain batch -template configs/sample_config.yaml -alignment configs/sample_alignment.yaml -geojson route.geojson [flags]
+//
+// To spread a large batch over several machines, one producer pushes the configurations
+// to a shared Redis queue and any number of workers process them:
+//
+//	gotrain batch -dir <path/to/config/directory> -queue redis://host:6379/gotrain:jobs -role producer
+//	gotrain batch -queue redis://host:6379/gotrain:jobs -role worker [flags]
+//
+// The command accepts the following flags:
+//   - dir: Directory containing YAML configuration files (required unless -manifest is given)
+//   - manifest: File listing the configuration files to process, instead of -dir (optional)
+//   - template: Template configuration file of a parameter sweep, instead of -dir (optional)
+//   - sweep: Sweep specification expanded with -template (required with -template, unless -alignment is given)
+//   - alignment: Track alignment computed with -template, section by section (optional)
+//   - profile: CSV file of the critical speed along the alignment (optional, printed by default)
+//   - geojson: GeoJSON file of the alignment sections and their critical speeds (optional)
+//   - workers: Number of worker goroutines (optional, defaults to number of CPU cores)
+//   - job-logs: Write a log file next to each result file (optional)
+//   - order: Dispatch order: as-found, shuffled or largest-profile-first (optional, defaults to as-found)
+//   - job-timeout: Maximum duration of a single job, e.g. 10m (optional, defaults to no limit)
+//   - sqlite: Write all results to a single SQLite database instead of JSON files (optional)
+//   - queue: URL of a shared job queue, e.g. redis://host:6379/gotrain:jobs (optional)
+//   - role: Role in queue mode: producer or worker (required with -queue)
+//   - queue-idle: Time a worker waits for new jobs before stopping (optional, defaults to 30s)
+//   - quiet: Print nothing but failures (optional)
+//   - on-collision: Policy when configurations share a result file: fail or rename (optional, defaults to fail)
+//   - on-existing: Policy when a result file exists: overwrite, error, skip or version-suffix (optional, defaults to overwrite)
+//   - dry-run (or list): List the jobs and their result files without processing them (optional)
+//   - soil-cache: Compute the soil dispersion curve once per unique soil profile (optional)
+//   - fast: Use the fast approximate mode for every job (optional)
+//   - dedup: Compute configurations with identical content only once (optional)
+//   - report: Self-contained HTML report of the batch (optional)
+//   - report-plots: Glob pattern of the configurations plotted in the report (optional, defaults to all)
+//   - progress-file: JSON file to which the progress of the batch is written periodically (optional)
+//   - cpuprofile: Write a CPU profile of the batch to a file (optional)
+//   - memprofile: Write a memory profile of the batch to a file (optional)
+//
+// The command displays a progress bar showing the percentage of completed files,
+// the throughput, the number of failed jobs and the estimated time remaining, and
+// provides summary statistics upon completion. When the output is not a terminal
+// (e.g. in CI logs), plain progress lines are printed periodically instead. With
+// -progress-file, the number of completed, failed and remaining jobs and the
+// estimated time remaining are also written to a JSON file, which monitoring tools
+// and batch schedulers can poll.
+//
+// Parameters:
+//   - args: Command-line arguments after "batch"
+//
+// Returns:
+//   - error: An error if the flags are inconsistent or the batch cannot be run
+func runBatch(args []string) error {
+	flags := newFlagSet("batch", "-dir <directory> | -manifest <jobs.txt> | -template <config.yaml> (-sweep | -alignment) <file> [flags]")
+	configDir := flags.String("dir", "", "Directory containing YAML files (required)")
+	manifest := flags.String("manifest", "", "File listing the configuration files to process, one per line (instead of -dir)")
+	template := flags.String("template", "", "Template configuration file of a parameter sweep (with -sweep)")
+	sweep := flags.String("sweep", "", "Sweep specification listing the parameter values applied to -template")
+	alignment := flags.String("alignment", "", "Track alignment with the soil per chainage, computed with -template")
+	profile := flags.String("profile", "", "CSV file of the critical speed along the alignment (printed when omitted)")
+	geojson := flags.String("geojson", "", "GeoJSON file of the alignment sections and their critical speeds")
+	workers := flags.Int("workers", runtime.NumCPU(), "Number of worker goroutines")
+	jobLogs := flags.Bool("job-logs", false, "Write a log file next to each result file")
+	order := flags.String("order", runner.OrderAsFound, "Job dispatch order: as-found, shuffled or largest-profile-first")
+	jobTimeout := flags.Duration("job-timeout", 0, "Maximum duration of a single job, e.g. 10m (0 means no limit)")
+	sqlitePath := flags.String("sqlite", "", "Write all results to this SQLite database instead of JSON files")
+	queueURL := flags.String("queue", "", "URL of a shared job queue, e.g. redis://host:6379/gotrain:jobs")
+	role := flags.String("role", "", "Role in queue mode: producer or worker")
+	queueIdle := flags.Duration("queue-idle", 30*time.Second, "Time a worker waits for new jobs before stopping")
+	onCollision := flags.String("on-collision", runner.CollisionFail, "Policy when configurations share a result file: fail or rename")
+	onExisting := flags.String("on-existing", critical_speed.ExistingOverwrite, "Policy when a result file exists: overwrite, error, skip or version-suffix")
+	var dryRun bool
+	flags.BoolVar(&dryRun, "dry-run", false, "List the jobs and their result files without processing them")
+	flags.BoolVar(&dryRun, "list", false, "Alias of -dry-run")
+	soilCache := flags.Bool("soil-cache", false, "Compute the soil dispersion curve once per unique soil profile and frequencies")
+	fast := flags.Bool("fast", false, "Fast approximate mode for screening studies: critical velocities within 1%")
+	quiet := flags.Bool("quiet", false, "Print nothing but failures")
+	dedup := flags.Bool("dedup", false, "Compute configurations with identical content (apart from their output section) only once")
+	report := flags.String("report", "", "Write a self-contained HTML report of the batch to this file, e.g. report.html")
+	reportPlots := flags.String("report-plots", "", "Glob pattern of the configurations whose curves are plotted in the report, e.g. 'soft_*' (default: all)")
+	progressFile := flags.String("progress-file", "", "Write the progress of the batch periodically to this JSON file, e.g. progress.json")
+	startProfiles := profileFlags(flags)
+	flags.Parse(args)
+
+	stopProfiles, err := startProfiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stopProfiles(); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	opts := runner.Options{
+		Workers:      *workers,
+		JobLogs:      *jobLogs,
+		Order:        *order,
+		JobTimeout:   *jobTimeout,
+		SQLitePath:   *sqlitePath,
+		Quiet:        *quiet,
+		OnCollision:  *onCollision,
+		DryRun:       dryRun,
+		Fast:         *fast,
+		ProgressFile: *progressFile,
+		Dedup:        *dedup,
+		OnExisting:   *onExisting,
+		Report:       *report,
+		ReportPlots:  *reportPlots,
+	}
+	if *soilCache {
+		opts.SoilCache = soil_dispersion.NewCache()
+		defer func() {
+			if hits, misses := opts.SoilCache.Stats(); misses > 0 && !*quiet {
+				fmt.Printf("Soil dispersion cache: %d unique profiles computed, %d reused\n", misses, hits)
+			}
+		}()
+	}
+
+	if *queueURL != "" {
+		switch *role {
+		case "producer":
+			if *configDir == "" {
+				return errors.New("You must provide -dir path/to/configs")
+			}
+			count, err := runner.Produce(*configDir, *queueURL, *order)
+			if err != nil {
+				return err
+			}
+			if !*quiet {
+				fmt.Printf("Pushed %d jobs to %s\n", count, *queueURL)
+			}
+		case "worker":
+			return runner.Consume(*queueURL, opts, *queueIdle)
+		default:
+			return errors.New("You must provide -role producer or -role worker with -queue")
+		}
+		return nil
+	}
+
+	if *alignment != "" {
+		if *template == "" || *sweep != "" {
+			return errors.New("You must provide -template, and not -sweep, with -alignment")
+		}
+		outputs := runner.AlignmentOutputs{Profile: *profile, GeoJSON: *geojson}
+		return runner.RunAlignment(*template, *alignment, outputs, opts)
+	}
+
+	if *template != "" || *sweep != "" {
+		if *template == "" || *sweep == "" {
+			return errors.New("You must provide both -template and -sweep")
+		}
+		return runner.RunSweep(*template, *sweep, opts)
+	}
+
+	if *manifest != "" {
+		if *configDir != "" {
+			return errors.New("You must provide either -dir or -manifest, not both")
+		}
+		return runner.RunManifest(*manifest, opts)
+	}
+
+	if *configDir == "" {
+		return errors.New("You must provide -dir path/to/configs or -manifest path/to/jobs.txt")
+	}
+
+	return runner.RunWithOptions(*configDir, opts)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	crossval "github.com/PlatypusBytes/GoTrain/internal/crossval"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// runCompare implements the compare command, which compares a JSON result file with
+// a reference result file, e.g. the result of the same configuration computed by an
+// earlier version, and prints the differences of the critical speed and of the
+// dispersion curves (see crossval.Compare):
+//
+//	gotrain compare [-tolerance 0.01] [-output differences.csv] <reference.json> <results.json>
+//
+// The command exits with code 1 when the critical speed or the phase velocities
+// deviate from the reference by more than the tolerance, so that regression checks
+// are a single command.
+//
+// The command accepts the following flags:
+//   - tolerance: Largest relative difference accepted (optional, default 0.01)
+//   - output: Path of the CSV file with the reference and compared phase velocities
+//     at every frequency of the reference (optional)
+//
+// Parameters:
+//   - args: Command-line arguments after "compare"
+//
+// Returns:
+//   - error: An error if a result file cannot be read or the comparison fails
+func runCompare(args []string) error {
+	flags := newFlagSet("compare", "[-tolerance 0.01] [-output differences.csv] <reference.json> <results.json>")
+	tolerance := flags.Float64("tolerance", 0.01, "Largest relative difference of the critical speed and phase velocities accepted")
+	outputPath := flags.String("output", "", "Path of the differences CSV file (optional)")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	var results [2]critical_speed.Result
+	for i, file := range flags.Args() {
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read result file: %v", err)
+		}
+		if results[i], err = critical_speed.UnmarshalResultJSON(data); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	reference, err := crossval.ResultReference(results[0])
+	if err != nil {
+		return fmt.Errorf("invalid reference result %s: %v", flags.Arg(0), err)
+	}
+	report, err := crossval.Compare(reference, results[1], *tolerance)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Critical speed [m/s]\t%.2f\t(reference %.2f)\n", report.CriticalVelocity, report.ReferenceCriticalVelocity)
+	fmt.Fprintf(tw, "Critical omega [rad/s]\t%.2f\t(reference %.2f)\n", report.CriticalOmega, report.ReferenceCriticalOmega)
+	fmt.Fprintf(tw, "Critical speed deviation [%%]\t%.3f\n", 100*report.Deviation)
+	for _, c := range []struct {
+		name   string
+		report crossval.CurveReport
+	}{{"Track", report.Track}, {"Soil", report.Soil}} {
+		fmt.Fprintf(tw, "%s points compared\t%d\n", c.name, c.report.Compared)
+		fmt.Fprintf(tw, "%s max deviation [%%]\t%.3f\t(at %.2f Hz)\n", c.name, 100*c.report.MaxDeviation, c.report.WorstOmega/(2*math.Pi))
+	}
+	fmt.Fprintf(tw, "Tolerance [%%]\t%.3f\n", 100*report.Tolerance)
+	tw.Flush()
+	for _, c := range []struct {
+		name   string
+		report crossval.CurveReport
+	}{{"track", report.Track}, {"soil", report.Soil}} {
+		for _, w := range c.report.Missing {
+			fmt.Printf("Root of the %s curve in only one result at %.2f Hz\n", c.name, w/(2*math.Pi))
+		}
+		for _, w := range c.report.Skipped {
+			fmt.Printf("Skipped %.2f Hz: outside the compared %s curve\n", w/(2*math.Pi), c.name)
+		}
+	}
+
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			return fmt.Errorf("error creating differences file: %v", err)
+		}
+		if err := crossval.WriteCSV(file, report); err != nil {
+			file.Close()
+			return fmt.Errorf("error writing differences file: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("error writing differences file: %v", err)
+		}
+		fmt.Printf("Differences written to %s\n", *outputPath)
+	}
+
+	if !report.Passed {
+		fmt.Println("FAIL: the result deviates from the reference by more than the tolerance")
+		os.Exit(exitFailure)
+	}
+	fmt.Println("PASS: the result agrees with the reference within the tolerance")
+	return nil
+}
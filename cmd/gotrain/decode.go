@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// runDecode implements the decode command, which converts binary result files (see
+// critical_speed.FormatGob and critical_speed.FormatProtobuf) to JSON result files,
+// to inspect them or to feed them to tools reading JSON:
+//
+//	gotrain decode [-format gob] [-output results.json] <results.gob> ...
+//
+// Without -output, a single file is printed to stdout and several files are written
+// next to them with the extension .json. Compressed files (.gz) are decompressed.
+//
+// The command accepts the following flags:
+//   - format: Format of the result files: "gob" or "protobuf" (optional, default
+//     "protobuf" for .pb files and "gob" otherwise)
+//   - output: Path of the JSON result file, for a single input file (optional)
+//
+// Parameters:
+//   - args: Command-line arguments after "decode"
+//
+// Returns:
+//   - error: An error if a result file cannot be read, decoded or written
+func runDecode(args []string) error {
+	flags := newFlagSet("decode", "[-format gob] [-output results.json] <results.gob> ...")
+	format := flags.String("format", "", "Format of the result files: gob or protobuf (default: protobuf for .pb files, gob otherwise)")
+	output := flags.String("output", "", "Path of the JSON result file, for a single input file (default: stdout)")
+	flags.Parse(args)
+	if flags.NArg() == 0 || (*output != "" && flags.NArg() > 1) {
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	for _, file := range flags.Args() {
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read result file: %v", err)
+		}
+		fileFormat := *format
+		if fileFormat == "" {
+			fileFormat = critical_speed.FormatGob
+			if strings.TrimSuffix(critical_speed.OutputExt(file), critical_speed.CompressedSuffix) == ".pb" {
+				fileFormat = critical_speed.FormatProtobuf
+			}
+		}
+		result, err := critical_speed.UnmarshalResults(data, fileFormat)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		destination := *output
+		if destination == "" && flags.NArg() > 1 {
+			destination = strings.TrimSuffix(file, critical_speed.OutputExt(file)) + ".json"
+		}
+		if destination == "" {
+			if err := result.WriteJSON(os.Stdout); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			continue
+		}
+		var buf bytes.Buffer
+		if err := result.WriteJSON(&buf); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if err := storage.WriteFile(destination, buf.Bytes()); err != nil {
+			return fmt.Errorf("error writing %s: %v", destination, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// runInit implements the init command, which writes a starter configuration.
+// Without -track, the track type and the other settings are asked on stdin; an
+// empty answer keeps the default shown in brackets.
+//
+// The command accepts the following flags:
+//   - track: Track type, ballast, slabtrack or piledslab (optional, asked when missing)
+//   - output: Path of the configuration file (optional, defaults to config.yaml)
+//   - result: Name of the result file of the configuration (optional)
+//   - force: Overwrite an existing configuration file (optional)
+//
+// Parameters:
+//   - args: Command-line arguments after "init"
+//
+// Returns:
+//   - error: An error if the answers are invalid or the file cannot be written
+func runInit(args []string) error {
+	flags := newFlagSet("init", "[flags]")
+	trackType := flags.String("track", "", "Track type: ballast, slabtrack or piledslab (asked when missing)")
+	outputPath := flags.String("output", "config.yaml", "Path of the configuration file")
+	resultFile := flags.String("result", "dispersion_results.json", "Name of the result file of the configuration")
+	force := flags.Bool("force", false, "Overwrite an existing configuration file")
+	flags.Parse(args)
+
+	opts := critical_speed.ScaffoldOptions{TrackType: *trackType, ResultFile: *resultFile}
+	if *trackType == "" {
+		if err := askScaffoldOptions(os.Stdin, os.Stdout, &opts); err != nil {
+			return err
+		}
+	}
+	data, err := critical_speed.Scaffold(opts)
+	if err != nil {
+		return err
+	}
+
+	if !*force {
+		if _, err := os.Stat(*outputPath); err == nil {
+			return fmt.Errorf("%s already exists; use -force to overwrite it", *outputPath)
+		}
+	}
+	if err := os.WriteFile(*outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write configuration: %v", err)
+	}
+	fmt.Printf("Configuration written to %s\n", *outputPath)
+	fmt.Printf("Adapt the parameters, then run: gotrain run -config %s\n", *outputPath)
+	return nil
+}
+
+// askScaffoldOptions asks the settings of a starter configuration, one per line.
+//
+// Parameters:
+//   - in: Source of the answers
+//   - out: Destination of the questions
+//   - opts: The options, whose values are the defaults and are replaced by the answers
+//
+// Returns:
+//   - error: An error if an answer is invalid
+func askScaffoldOptions(in io.Reader, out io.Writer, opts *critical_speed.ScaffoldOptions) error {
+	reader := bufio.NewReader(in)
+	ask := func(question string, defaultValue string) string {
+		fmt.Fprintf(out, "%s [%s]: ", question, defaultValue)
+		line, _ := reader.ReadString('\n')
+		if answer := strings.TrimSpace(line); answer != "" {
+			return answer
+		}
+		return defaultValue
+	}
+	askFloat := func(question string, defaultValue float64) (float64, error) {
+		answer := ask(question, strconv.FormatFloat(defaultValue, 'g', -1, 64))
+		value, err := strconv.ParseFloat(answer, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number: %s", answer)
+		}
+		return value, nil
+	}
+
+	opts.TrackType = ask("Track type (ballast, slabtrack or piledslab)", "ballast")
+	if opts.TrackType != "ballast" && opts.TrackType != "slabtrack" && opts.TrackType != "piledslab" {
+		return fmt.Errorf("invalid track type: %s. Supported types are 'ballast', 'slabtrack' or 'piledslab'", opts.TrackType)
+	}
+	var err error
+	if opts.FrequencyMin, err = askFloat("Minimum angular frequency [rad/s]", 1); err != nil {
+		return err
+	}
+	if opts.FrequencyMax, err = askFloat("Maximum angular frequency [rad/s]", 400); err != nil {
+		return err
+	}
+	answer := ask("Number of frequencies", "100")
+	if opts.Points, err = strconv.Atoi(answer); err != nil {
+		return fmt.Errorf("invalid number of frequencies: %s", answer)
+	}
+	opts.ResultFile = ask("Result file", opts.ResultFile)
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package main provides gotrain, the command-line interface of GoTrain, which groups
+// the analysis of a single configuration, batch processing and the tools around them
+// as subcommands of one binary.
+//
+// Usage:
+//
+//	gotrain <command> [flags] [arguments]
+//
+// Commands:
+//   - run: Compute the critical speed of a configuration file
+//   - batch: Compute the configuration files of a directory, manifest, sweep or alignment in parallel
+//   - validate: Check configuration files without computing them
+//   - plot: Plot the dispersion curves of result files as SVG
+//   - init: Write a starter configuration
+//   - compare: Compare a result file with a reference result file
+//   - decode: Convert binary result files to JSON
+//   - version: Print the version of gotrain
+//
+// Every command prints its flags with -h, e.g. gotrain run -h, or gotrain help run.
+// Invalid command-line flags exit with code 2.
+//
+// The version is set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0" ./cmd/gotrain
+//
+// and otherwise taken from the module version of go install.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	profiling "github.com/PlatypusBytes/GoTrain/internal/profiling"
+)
+
+// version is the version of gotrain, set at build time with -ldflags "-X main.version=...".
+var version = ""
+
+// command is a subcommand of gotrain.
+type command struct {
+	name    string                    // Name of the command
+	summary string                    // One-line description, for the usage
+	run     func(args []string) error // Runs the command with the arguments following its name
+}
+
+// commands are the subcommands of gotrain, in the order of the usage.
+var commands = []command{
+	{"run", "Compute the critical speed of a configuration file", runAnalysis},
+	{"batch", "Compute many configuration files in parallel", runBatch},
+	{"validate", "Check configuration files without computing them", runValidate},
+	{"plot", "Plot the dispersion curves of result files as SVG", runPlot},
+	{"init", "Write a starter configuration", runInit},
+	{"compare", "Compare a result file with a reference result file", runCompare},
+	{"decode", "Convert binary result files to JSON", runDecode},
+}
+
+// main is the entry point for gotrain. It runs the command named by the first
+// argument with the remaining arguments.
+//
+// If the command is missing or unknown, the program prints the usage and exits with
+// code 2; if the command fails, it prints the error and exits with code 1, unless the
+// command exits with a code of its own (see the commands).
+func main() {
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) == 0 {
+			printUsage(os.Stdout)
+			return
+		}
+		name, args = args[0], []string{"-h"}
+	case "version", "-version", "--version":
+		fmt.Printf("gotrain %s (%s %s/%s)\n", versionString(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		return
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				log.Print(err)
+				os.Exit(exitFailure)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "gotrain: unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(exitUsage)
+}
+
+// printUsage prints the commands of gotrain.
+//
+// Parameters:
+//   - w: Destination of the usage
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gotrain <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "  %-10s %s\n", "version", "Print the version of gotrain")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gotrain <command> -h' for the flags of a command.")
+}
+
+// versionString returns the version of gotrain: the version set at build time, or
+// the module version of go install, or "dev" for development builds.
+//
+// Returns:
+//   - string: The version
+func versionString() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// newFlagSet creates the flags of a command, which print the usage of the command
+// with -h and exit with code 2 on invalid flags.
+//
+// Parameters:
+//   - name: Name of the command
+//   - usage: Arguments of the command, e.g. "-config <config.yaml> [flags]"
+//
+// Returns:
+//   - *flag.FlagSet: The flags of the command
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: gotrain %s %s\n\nFlags:\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// profileFlags defines the -cpuprofile and -memprofile flags of the commands running
+// analyses.
+//
+// Parameters:
+//   - flags: The flags of the command
+//
+// Returns:
+//   - func() (func() error, error): Function starting the requested profiles once the
+//     flags are parsed (see profiling.Start)
+func profileFlags(flags *flag.FlagSet) func() (func() error, error) {
+	cpuProfile := flags.String("cpuprofile", "", "Write a CPU profile to this file (optional)")
+	memProfile := flags.String("memprofile", "", "Write a memory profile to this file on exit (optional)")
+	return func() (func() error, error) {
+		return profiling.Start(*cpuProfile, *memProfile)
+	}
+}
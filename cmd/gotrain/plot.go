@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	runner "github.com/PlatypusBytes/GoTrain/internal/runner"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// runPlot implements the plot command, which plots the track and soil dispersion
+// curves of JSON result files, with the critical point, as SVG images that open in
+// any browser or can be included in a report:
+//
+//	gotrain plot [-output curves.svg] <results.json> ...
+//
+// The plot of every result file is written next to it, with the .svg extension,
+// unless -output is given for a single result file.
+//
+// The command accepts the following flags:
+//   - output: Path of the SVG file (optional, only with a single result file)
+//
+// Parameters:
+//   - args: Command-line arguments after "plot"
+//
+// Returns:
+//   - error: An error if a result file cannot be read or a plot cannot be written
+func runPlot(args []string) error {
+	flags := newFlagSet("plot", "[-output curves.svg] <results.json> ...")
+	output := flags.String("output", "", "Path of the SVG file (default: next to the result file; only with a single result file)")
+	flags.Parse(args)
+	if flags.NArg() == 0 || (*output != "" && flags.NArg() > 1) {
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	for _, file := range flags.Args() {
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read result file: %v", err)
+		}
+		result, err := critical_speed.UnmarshalResultJSON(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		destination := *output
+		if destination == "" {
+			destination = strings.TrimSuffix(file, critical_speed.OutputExt(file)) + ".svg"
+		}
+		if err := runner.WriteDispersionSVG(destination, result); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		fmt.Printf("Plot written to %s\n", destination)
+	}
+	return nil
+}
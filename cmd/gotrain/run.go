@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// Summary formats supported by the -format flag.
+const (
+	formatJSON  = "json"  // One-line JSON summary
+	formatTable = "table" // Human-readable table
+	formatValue = "value" // Critical velocity only
+)
+
+// Exit codes of the failures (see runAnalysis).
+const (
+	exitFailure        = 1 // Other failure
+	exitUsage          = 2 // Invalid command-line flags
+	exitConfig         = 3 // critical_speed.KindConfig
+	exitSolver         = 4 // critical_speed.KindSolver
+	exitNoIntersection = 5 // critical_speed.KindNoIntersection
+	exitIO             = 6 // critical_speed.KindIO
+)
+
+// stopProfiles stops the profiles of -cpuprofile and -memprofile of the run command;
+// it is called before the program exits.
+var stopProfiles = func() error { return nil }
+
+// failure is the structured error written with -error-json.
+type failure struct {
+	Kind     string `json:"kind"`      // Kind of failure (critical_speed.Kind constants, "usage" or "other")
+	ExitCode int    `json:"exit_code"` // Exit code of the program
+	Message  string `json:"message"`   // Error message
+	Config   string `json:"config"`    // Path of the configuration file
+}
+
+// summary is the one-line JSON summary printed with -format json.
+type summary struct {
+	CriticalOmega     *float64 `json:"critical_omega,omitempty"`     // Critical angular frequency [rad/s] (with frequency.unit rad/s)
+	CriticalFrequency *float64 `json:"critical_frequency,omitempty"` // Critical frequency [Hz] (with frequency.unit Hz)
+	CriticalVelocity  float64  `json:"critical_velocity"`            // Critical train speed [m/s]
+	ResultFile        string   `json:"result_file"`                  // Path of the full result file
+}
+
+// overrides are the repeatable -set key=value flags of the run command.
+type overrides []string
+
+// String returns the overrides, for the usage of the flag.
+func (o *overrides) String() string {
+	return strings.Join(*o, ", ")
+}
+
+// Set adds an override; it is called for every -set flag.
+//
+// Parameters:
+//   - value: The override, as key=value
+//
+// Returns:
+//   - error: An error if the override has no key
+func (o *overrides) Set(value string) error {
+	if key, _, ok := strings.Cut(value, "="); !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// apply overrides the values of a configuration (see critical_speed.SetValue), in
+// the order of the flags.
+//
+// Parameters:
+//   - config: The configuration structure, modified in place
+//
+// Returns:
+//   - error: A critical_speed.KindConfig error if an override does not fit the
+//     configuration
+func (o overrides) apply(config *critical_speed.Config) error {
+	for _, override := range o {
+		key, value, _ := strings.Cut(override, "=")
+		if err := critical_speed.SetValue(config, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAnalysis implements the run command, which computes the critical speed of a
+// configuration file and writes its result file:
+//
+//	gotrain run -config <path/to/config.yaml> [flags]
+//
+// With -format, a summary of the result is printed to stdout for shell scripts,
+// in addition to writing the full result file:
+//   - json: One-line JSON object with the critical omega and velocity and the result file
+//   - table: Human-readable table of the same fields
+//   - value: Only the critical velocity [m/s], e.g. speed=$(gotrain run -config c.yaml -format value)
+//
+// Solver warnings and progress messages are not printed with -format, so stdout
+// contains only the summary.
+//
+// The exit code tells the type of a failure, so that pipelines can branch on it:
+//   - 0: Success
+//   - 1: Other failure
+//   - 2: Invalid command-line flags
+//   - 3: Invalid configuration (config)
+//   - 4: Dispersion solver failure (solver)
+//   - 5: The track and soil dispersion curves do not intersect (no_intersection)
+//   - 6: Configuration, result or log file cannot be read or written (io)
+//
+// With -error-json, a failure is also described in a JSON file, e.g.
+// {"kind":"no_intersection","exit_code":5,"message":"...","config":"c.yaml"}.
+//
+// With -fast, the fast approximate mode is used for screening studies: a coarse scan
+// of the soil phase velocities with interpolated roots, and the closed-form track
+// solution. The critical velocity is within 1% of the default solution (see
+// solver.fast in the configuration).
+//
+// With -set key=value, repeated as needed, a value of the configuration is overridden
+// for a quick what-if run without editing the file, e.g.
+// -set ballast_track.k_rail_pad=6e8 -set soil_layers.0.young_modulus=5e7. The key is
+// the dot-separated path of the value (see critical_speed.SetValue), and the value is
+// written as in YAML. The -set flags are applied in order, after the GOTRAIN_
+// environment variables, so they take precedence. The ${name} references to the
+// variables section are substituted when the file is read, before the overrides, so
+// -set overrides configuration values, not variables: a -set of variables.name is
+// an unknown key. The overridden configuration is validated (see
+// critical_speed.Validate), and an invalid value exits with code 3 (config).
+//
+// With -on-existing, an existing result file is not silently overwritten:
+//   - overwrite: Replace the existing result file (default)
+//   - error: Fail with exit code 6 (io) without running the analysis
+//   - skip: Keep the existing result file and exit with code 0 without running the analysis
+//   - version-suffix: Write to the first free versioned name, e.g. results_v2.json,
+//     then results_v3.json (the tables and log file follow the name)
+//
+// The command accepts the following flags:
+//   - config: Path to the YAML configuration file (required)
+//   - format: Summary printed to stdout: json, table or value (optional, defaults to none)
+//   - error-json: Path of the JSON file describing a failure (optional)
+//   - fast: Use the fast approximate mode (optional)
+//   - on-existing: Policy when the result file exists: overwrite, error, skip or version-suffix (optional, defaults to overwrite)
+//   - set: Override of a configuration value as key=value, repeatable (optional)
+//   - cpuprofile: Path of a CPU profile of the analysis, for go tool pprof (optional)
+//   - memprofile: Path of a memory profile of the analysis (optional)
+//
+// Parameters:
+//   - args: Command-line arguments after "run"
+//
+// Returns:
+//   - error: Always nil: failures exit with the exit code of their kind
+func runAnalysis(args []string) error {
+	flags := newFlagSet("run", "-config <config.yaml> [flags]")
+	configPath := flags.String("config", "", "Path to configuration YAML file (required)")
+	format := flags.String("format", "", "Summary printed to stdout: json, table or value (default: none)")
+	errorJSON := flags.String("error-json", "", "Path of the JSON file describing a failure (optional)")
+	fast := flags.Bool("fast", false, "Fast approximate mode for screening: critical velocity within 1% (optional)")
+	onExisting := flags.String("on-existing", critical_speed.ExistingOverwrite, "Policy when the result file exists: overwrite, error, skip or version-suffix")
+	var set overrides
+	flags.Var(&set, "set", "Override a configuration value as key=value, e.g. ballast_track.k_rail_pad=6e8 (repeatable)")
+	startProfiles := profileFlags(flags)
+	flags.Parse(args)
+
+	stop, err := startProfiles()
+	if err != nil {
+		fail(*errorJSON, *configPath, "usage", exitUsage, err)
+	}
+	stopProfiles = stop
+	defer func() {
+		if err := stopProfiles(); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	if *configPath == "" {
+		fail(*errorJSON, *configPath, "usage", exitUsage, fmt.Errorf("Error: You must provide a configuration file path using -config"))
+	}
+	if *format != "" && *format != formatJSON && *format != formatTable && *format != formatValue {
+		fail(*errorJSON, *configPath, "usage", exitUsage, fmt.Errorf("Error: invalid format: %s. Supported formats are '%s', '%s' or '%s'",
+			*format, formatJSON, formatTable, formatValue))
+	}
+
+	if err := critical_speed.CheckExistingPolicy(*onExisting); err != nil {
+		fail(*errorJSON, *configPath, "usage", exitUsage, err)
+	}
+
+	config, err := critical_speed.LoadConfig(*configPath)
+	if err != nil {
+		failAnalysis(*errorJSON, *configPath, fmt.Errorf("error loading configuration: %w", err))
+	}
+	if err := set.apply(&config); err != nil {
+		failAnalysis(*errorJSON, *configPath, err)
+	}
+	if len(set) > 0 {
+		if err := critical_speed.Validate(context.Background(), config); err != nil {
+			failAnalysis(*errorJSON, *configPath, err)
+		}
+	}
+	output, skip, err := critical_speed.ResolveOutput(config.Output.FileName, *onExisting)
+	if err != nil {
+		failAnalysis(*errorJSON, *configPath, err)
+	}
+	if skip {
+		log.Printf("Result file %s already exists: analysis skipped", output)
+		return nil
+	}
+	config.Output.FileName = output
+
+	// Keep stdout free of messages other than the summary with -format
+	opts := critical_speed.Options{Verbose: *format == "", Fast: *fast}
+	result, err := critical_speed.RunConfig(context.Background(), config, *configPath, opts)
+	if err != nil {
+		failAnalysis(*errorJSON, *configPath, err)
+	}
+	if *format == "" {
+		return nil
+	}
+	if err := printSummary(os.Stdout, *format, result, config.Output.FileName); err != nil {
+		fail(*errorJSON, *configPath, critical_speed.KindIO, exitIO, err)
+	}
+	return nil
+}
+
+// failAnalysis terminates the program after a failed analysis, with the exit code
+// of the kind of failure (see fail).
+//
+// Parameters:
+//   - errorJSON: Path of the error JSON file (empty for none)
+//   - configPath: Path of the configuration file
+//   - err: The error of the analysis
+func failAnalysis(errorJSON string, configPath string, err error) {
+	kind := critical_speed.ErrorKind(err)
+	switch kind {
+	case critical_speed.KindConfig:
+		fail(errorJSON, configPath, kind, exitConfig, err)
+	case critical_speed.KindSolver:
+		fail(errorJSON, configPath, kind, exitSolver, err)
+	case critical_speed.KindNoIntersection:
+		fail(errorJSON, configPath, kind, exitNoIntersection, err)
+	case critical_speed.KindIO:
+		fail(errorJSON, configPath, kind, exitIO, err)
+	default:
+		fail(errorJSON, configPath, "other", exitFailure, err)
+	}
+}
+
+// fail prints an error, writes the error JSON file if requested and terminates
+// the program with an exit code.
+//
+// Parameters:
+//   - errorJSON: Path of the error JSON file (empty for none)
+//   - configPath: Path of the configuration file
+//   - kind: Kind of failure
+//   - code: Exit code
+//   - err: The error
+func fail(errorJSON string, configPath string, kind string, code int, err error) {
+	log.Print(err)
+	if err := stopProfiles(); err != nil {
+		log.Print(err)
+	}
+	if errorJSON != "" {
+		data, jsonErr := json.Marshal(failure{Kind: kind, ExitCode: code, Message: err.Error(), Config: configPath})
+		if jsonErr == nil {
+			jsonErr = storage.WriteFile(errorJSON, append(data, '\n'))
+		}
+		if jsonErr != nil {
+			log.Printf("Error writing error JSON file: %v", jsonErr)
+		}
+	}
+	os.Exit(code)
+}
+
+// printSummary prints the summary of a result in a format of the -format flag.
+//
+// Parameters:
+//   - w: Destination of the summary
+//   - format: One of formatJSON, formatTable or formatValue
+//   - result: The result of the analysis
+//   - resultFile: Path of the result file
+//
+// Returns:
+//   - error: An error if the summary cannot be written
+func printSummary(w io.Writer, format string, result critical_speed.Result, resultFile string) error {
+	name, unit, scale := result.FrequencyAxis()
+	critical := result.CriticalOmega * scale
+	switch format {
+	case formatJSON:
+		s := summary{CriticalVelocity: result.CriticalVelocity, ResultFile: resultFile}
+		if unit == critical_speed.UnitHertz {
+			s.CriticalFrequency = &critical
+		} else {
+			s.CriticalOmega = &critical
+		}
+		return json.NewEncoder(w).Encode(s)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Critical velocity [m/s]\t%.3f\n", result.CriticalVelocity)
+		fmt.Fprintf(tw, "Critical %s [%s]\t%.3f\n", name, unit, critical)
+		fmt.Fprintf(tw, "Result file\t%s\n", resultFile)
+		return tw.Flush()
+	default:
+		_, err := fmt.Fprintln(w, strconv.FormatFloat(result.CriticalVelocity, 'g', -1, 64))
+		return err
+	}
+}
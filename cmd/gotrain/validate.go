@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// runValidate implements the validate command, which checks configuration files
+// without computing them (see critical_speed.Validate), so that mistakes are found
+// before a long batch is started:
+//
+//	gotrain validate [-quiet] <config.yaml | directory> ...
+//
+// Directories (and s3:// or gs:// prefixes) are searched recursively for .yaml
+// files. Every configuration is reported as OK, with the fields upgraded from an
+// older config_version, or with its first problem, and the command exits with code 3
+// when a configuration is invalid or cannot be read.
+//
+// The command accepts the following flags:
+//   - quiet: Only report the invalid configurations (optional)
+//
+// Parameters:
+//   - args: Command-line arguments after "validate"
+//
+// Returns:
+//   - error: An error if no configuration is given or a directory cannot be listed
+func runValidate(args []string) error {
+	flags := newFlagSet("validate", "[-quiet] <config.yaml | directory> ...")
+	quiet := flags.Bool("quiet", false, "Only report the invalid configurations")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	var files []string
+	for _, path := range flags.Args() {
+		if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+			files = append(files, path)
+			continue
+		}
+		found, err := storage.List(path, ".yaml")
+		if err != nil {
+			return fmt.Errorf("error listing configuration files: %v", err)
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no YAML configuration files found")
+	}
+
+	invalid := 0
+	for _, file := range files {
+		config, err := critical_speed.LoadConfig(file)
+		if err == nil {
+			err = critical_speed.Validate(context.Background(), config)
+		}
+		if err != nil {
+			invalid++
+			fmt.Printf("FAIL %s: %v\n", file, err)
+			continue
+		}
+		if !*quiet {
+			fmt.Printf("OK   %s\n", file)
+			for _, change := range config.Migrated {
+				fmt.Printf("     upgraded: %s\n", change)
+			}
+		}
+	}
+	if !*quiet || invalid > 0 {
+		fmt.Printf("%d of %d configurations valid\n", len(files)-invalid, len(files))
+	}
+	if invalid > 0 {
+		os.Exit(exitConfig)
+	}
+	return nil
+}
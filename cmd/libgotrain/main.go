@@ -0,0 +1,143 @@
+// Package main provides the C shared library of GoTrain, so that Python (ctypes,
+// cffi) and Matlab (loadlibrary) users, e.g. of the original TrainCritSpeed, can
+// call the Go core directly through a C ABI.
+//
+// Build (see the lib target of the Makefile), which also writes the C header:
+//
+//	go build -buildmode=c-shared -o bin/libgotrain.so ./cmd/libgotrain
+//
+// Exported functions:
+//
+//	char *ComputeCriticalSpeed(char *config);
+//	int SoilDispersion(double *density, double *youngModulus, double *poissonRatio,
+//	                   double *thickness, int nLayers, double *omega, int nOmega,
+//	                   double *phaseVelocity);
+//	void FreeString(char *s);
+//
+// ComputeCriticalSpeed takes a configuration document (JSON or YAML, same fields as
+// the configuration files) and returns the result JSON, in the same format as the
+// result files, or {"error": "..."}. The returned string must be released with
+// FreeString. No file is written.
+//
+// SoilDispersion computes the soil dispersion curve of a profile at the angular
+// frequencies omega into phaseVelocity (NaN where no root is found). It returns
+// 0 on success and -1 if the arguments are invalid.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"unsafe"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+// main is required by the c-shared build mode; it is not called.
+func main() {}
+
+// ComputeCriticalSpeed computes the critical speed of a configuration.
+//
+// Parameters:
+//   - config: The configuration document (JSON or YAML), as a C string
+//
+// Returns:
+//   - *C.char: The result JSON, or an error JSON, to be released with FreeString
+//
+//export ComputeCriticalSpeed
+func ComputeCriticalSpeed(config *C.char) *C.char {
+	if config == nil {
+		return C.CString(errorJSON("config must not be NULL"))
+	}
+
+	parsed, err := critical_speed.ParseConfig([]byte(C.GoString(config)))
+	if err != nil {
+		return C.CString(errorJSON(err.Error()))
+	}
+	result, err := critical_speed.Compute(context.Background(), parsed)
+	if err != nil {
+		return C.CString(errorJSON(err.Error()))
+	}
+
+	data, err := json.Marshal(result.DispersionResults())
+	if err != nil {
+		return C.CString(errorJSON(err.Error()))
+	}
+	return C.CString(string(data))
+}
+
+// SoilDispersion computes the soil dispersion curve of a layered profile.
+//
+// Parameters:
+//   - density: Densities of the layers [kg/m³]
+//   - youngModulus: Young's moduli of the layers [Pa]
+//   - poissonRatio: Poisson's ratios of the layers
+//   - thickness: Thicknesses of the layers [m] (the last layer is a half-space, e.g. INFINITY)
+//   - nLayers: Number of layers
+//   - omega: Angular frequencies [rad/s]
+//   - nOmega: Number of frequencies
+//   - phaseVelocity: Output array of nOmega phase velocities [m/s] (NaN where no root is found)
+//
+// Returns:
+//   - C.int: 0 on success, -1 if the arguments are invalid
+//
+//export SoilDispersion
+func SoilDispersion(density, youngModulus, poissonRatio, thickness *C.double, nLayers C.int,
+	omega *C.double, nOmega C.int, phaseVelocity *C.double) C.int {
+	if nLayers < 1 || nOmega < 0 || density == nil || youngModulus == nil || poissonRatio == nil || thickness == nil {
+		return -1
+	}
+	if nOmega > 0 && (omega == nil || phaseVelocity == nil) {
+		return -1
+	}
+
+	layers := make([]soil_dispersion.Layer, nLayers)
+	densities := unsafe.Slice((*float64)(unsafe.Pointer(density)), nLayers)
+	moduli := unsafe.Slice((*float64)(unsafe.Pointer(youngModulus)), nLayers)
+	ratios := unsafe.Slice((*float64)(unsafe.Pointer(poissonRatio)), nLayers)
+	thicknesses := unsafe.Slice((*float64)(unsafe.Pointer(thickness)), nLayers)
+	for i := range layers {
+		layers[i] = soil_dispersion.Layer{
+			Density:       densities[i],
+			YoungsModulus: moduli[i],
+			PoissonRatio:  ratios[i],
+			Thickness:     thicknesses[i],
+		}
+		layers[i].WaveSpeed()
+	}
+
+	if nOmega == 0 {
+		return 0
+	}
+	frequencies := unsafe.Slice((*float64)(unsafe.Pointer(omega)), nOmega)
+	curve := soil_dispersion.SoilDispersion(layers, frequencies)
+	copy(unsafe.Slice((*float64)(unsafe.Pointer(phaseVelocity)), nOmega), curve)
+	return 0
+}
+
+// FreeString releases a string returned by ComputeCriticalSpeed.
+//
+// Parameters:
+//   - s: The string (NULL is ignored)
+//
+//export FreeString
+func FreeString(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+// errorJSON encodes an error message as the JSON object {"error": message}.
+//
+// Parameters:
+//   - message: The error message
+//
+// Returns:
+//   - string: The error JSON
+func errorJSON(message string) string {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return string(data)
+}
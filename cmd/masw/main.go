@@ -0,0 +1,98 @@
+// Package main provides the command-line tool comparing a measured dispersion curve
+// with the soil dispersion curve of a configuration.
+//
+// The tool computes the dispersion curve of the soil profile of a configuration file
+// (soil_layers, soil_profile or soil_cpt) at the frequencies of a measured curve, e.g. from a
+// MASW field survey, and prints the misfit statistics, to validate the soil model.
+//
+// Usage:
+//
+//	masw -config <path/to/config.yaml> -measured <path/to/curve.csv> [-output overlay.csv]
+//
+// Flags:
+//   - config: Path to the YAML configuration file (required)
+//   - measured: Path to the measured dispersion curve CSV file (required)
+//   - output: Path of the overlay CSV file, with the measured and computed phase
+//     velocities at every measured frequency (optional)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	masw "github.com/PlatypusBytes/GoTrain/internal/masw"
+)
+
+// main is the entry point for the dispersion comparison application.
+// It parses command-line flags, loads the configuration and the measured curve,
+// and prints the misfit between the measured and computed curves.
+//
+// The program accepts the following flags:
+//   - config: Path to the YAML configuration file (required)
+//   - measured: Path to the measured dispersion curve CSV file (required)
+//   - output: Path of the overlay CSV file (optional)
+//
+// If a required flag is missing or if an error occurs during execution, the
+// program will terminate with a fatal error message.
+func main() {
+	configPath := flag.String("config", "", "Path to configuration YAML file (required)")
+	measuredPath := flag.String("measured", "", "Path to the measured dispersion curve CSV file (required)")
+	outputPath := flag.String("output", "", "Path of the overlay CSV file (optional)")
+	flag.Parse()
+
+	if *configPath == "" || *measuredPath == "" {
+		log.Fatal("Error: You must provide a configuration file using -config and a measured curve using -measured")
+	}
+
+	config, err := critical_speed.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	layers, err := critical_speed.SoilLayers(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	measured, err := masw.LoadCurve(*measuredPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	misfit, err := masw.CompareSoil(context.Background(), layers, measured)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Points compared\t%d\n", misfit.Points)
+	fmt.Fprintf(tw, "Points skipped\t%d\n", len(misfit.Skipped))
+	fmt.Fprintf(tw, "Bias [m/s]\t%.2f\n", misfit.Bias)
+	fmt.Fprintf(tw, "MAE [m/s]\t%.2f\n", misfit.MAE)
+	fmt.Fprintf(tw, "RMSE [m/s]\t%.2f\n", misfit.RMSE)
+	fmt.Fprintf(tw, "Max error [m/s]\t%.2f\n", misfit.MaxError)
+	fmt.Fprintf(tw, "MAPE [%%]\t%.2f\n", misfit.MAPE)
+	tw.Flush()
+	for _, w := range misfit.Skipped {
+		fmt.Printf("Skipped %.2f Hz: no computed phase velocity\n", w/(2*math.Pi))
+	}
+
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			log.Fatalf("Error creating overlay file: %v", err)
+		}
+		if err := masw.WriteOverlay(file, misfit); err != nil {
+			file.Close()
+			log.Fatalf("Error writing overlay file: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			log.Fatalf("Error writing overlay file: %v", err)
+		}
+		fmt.Printf("Overlay written to %s\n", *outputPath)
+	}
+}
@@ -0,0 +1,89 @@
+// Package main provides the HTTP server for submitting critical speed analyses.
+//
+// The server accepts configurations (YAML or JSON, with the same fields as the
+// configuration files) over HTTP, processes them on a pool of workers and serves
+// the results, so GoTrain can be used from web tools without running the
+// command-line tools.
+//
+// Usage:
+//
+//	server [flags]
+//
+// Endpoints:
+//   - POST /jobs: Submit a configuration
+//   - GET /jobs/{id}: Status of a job (pending, done or failed)
+//   - GET /jobs/{id}/result: Result of a finished job
+//   - POST /compute: Compute a configuration synchronously and return its result
+//   - GET /openapi.json: OpenAPI specification of the endpoints
+//
+// With -grpc-addr, the gRPC CriticalSpeed service defined in proto/gotrain.proto
+// is served as well, on a separate address.
+//
+// Flags:
+//   - addr: Address to listen on (optional, defaults to :8080)
+//   - workers: Number of worker goroutines (optional, defaults to number of CPU cores)
+//   - job-timeout: Maximum duration of a single job, e.g. 10m (optional, defaults to no limit)
+//   - grpc-addr: Address to serve the gRPC service on, e.g. :9090 (optional, defaults to disabled)
+//   - openapi: Print the OpenAPI specification and exit (optional)
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+
+	grpc_service "github.com/PlatypusBytes/GoTrain/internal/grpc_service"
+	runner "github.com/PlatypusBytes/GoTrain/internal/runner"
+	server "github.com/PlatypusBytes/GoTrain/internal/server"
+)
+
+// main is the entry point for the server application.
+// It parses command-line flags, starts the worker pool and serves the API.
+//
+// The program accepts the following flags:
+//   - addr: Address to listen on (optional, defaults to :8080)
+//   - workers: Number of concurrent worker goroutines (optional, defaults to runtime.NumCPU())
+//   - job-timeout: Maximum duration of a single job; slower jobs are cancelled and marked failed (optional)
+//   - grpc-addr: Address to serve the gRPC CriticalSpeed service on (optional)
+//   - openapi: Print the OpenAPI specification of the API to stdout and exit (optional)
+//
+// If the server cannot listen on the given address, the program will terminate
+// with a fatal error message.
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of worker goroutines")
+	jobTimeout := flag.Duration("job-timeout", 0, "Maximum duration of a single job, e.g. 10m (0 means no limit)")
+	grpcAddr := flag.String("grpc-addr", "", "Address to serve the gRPC service on, e.g. :9090 (disabled if empty)")
+	openAPI := flag.Bool("openapi", false, "Print the OpenAPI specification and exit")
+	flag.Parse()
+
+	if *openAPI {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(server.OpenAPISpec()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	srv := server.New(runner.Options{
+		Workers:    *workers,
+		JobTimeout: *jobTimeout,
+	})
+	defer srv.Close()
+
+	if *grpcAddr != "" {
+		go func() {
+			log.Printf("Serving gRPC on %s\n", *grpcAddr)
+			log.Fatal(grpc_service.ListenAndServe(*grpcAddr))
+		}()
+	}
+
+	log.Printf("Listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, srv.Handler()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build js && wasm
+
+// Package main provides the WebAssembly build of GoTrain, to compute critical
+// speeds client-side in a browser tool (quick what-if studies without a server).
+//
+// The module registers a global JavaScript function:
+//
+//	ComputeCriticalSpeed(configJSON) -> resultJSON
+//
+// configJSON is a configuration document, in JSON or YAML, with the same fields
+// as the configuration files. The function returns the result JSON, with the same
+// structure as the result files, or {"error": "..."} if the computation fails.
+// No file is written.
+//
+// Build (see the wasm target of the Makefile):
+//
+//	GOOS=js GOARCH=wasm go build -o bin/gotrain.wasm ./cmd/wasm
+//
+// and load it with the wasm_exec.js support file of the Go distribution:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("gotrain.wasm"), go.importObject);
+//	go.run(instance);
+//	const result = JSON.parse(ComputeCriticalSpeed(JSON.stringify(config)));
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// main is the entry point of the WebAssembly module.
+// It registers the JavaScript API and keeps the module running.
+func main() {
+	js.Global().Set("ComputeCriticalSpeed", js.FuncOf(computeCriticalSpeed))
+
+	// The exported function must stay callable
+	select {}
+}
+
+// computeCriticalSpeed implements the ComputeCriticalSpeed JavaScript function.
+//
+// Parameters:
+//   - this: The JavaScript this value (unused)
+//   - args: The configuration document, as a string
+//
+// Returns:
+//   - any: The result JSON, or an error JSON, as a string
+func computeCriticalSpeed(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return errorJSON("ComputeCriticalSpeed expects a single configuration string")
+	}
+
+	config, err := critical_speed.ParseConfig([]byte(args[0].String()))
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	result, err := critical_speed.Compute(context.Background(), config)
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+
+	data, err := json.Marshal(result.DispersionResults())
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	return string(data)
+}
+
+// errorJSON encodes an error message as the JSON object {"error": message}.
+//
+// Parameters:
+//   - message: The error message
+//
+// Returns:
+//   - string: The error JSON
+func errorJSON(message string) string {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return string(data)
+}
@@ -10,6 +10,8 @@
 // # Key Features
 //
 //   - Critical speed calculation for railway track-soil systems
+//   - Rail deflection, bending moment and stress versus train speed under a moving load
+//   - Free-field ground vibration estimate at distances from the track
 //   - Support for both ballast and slab track configurations
 //   - Multi-layered soil profile modelling with elastic properties
 //   - High-performance parallel batch processing capabilities
@@ -57,36 +59,130 @@
 // The package is organized into several key components:
 //
 //   - internal/critical_speed: Core critical speed analysis engine
+//   - internal/cpt: Soil layers derived from cone penetration tests (GEF files)
+//   - internal/crossval: Cross-validation of computed results against TrainCritSpeed reference results
+//   - internal/explorer: Browsing of result files in the terminal (tables, curve plots, histogram)
+//   - internal/geodata: CPTs fetched from geo-databases (Dutch BRO) for the soil profile of a site
+//   - internal/grpc_service: gRPC service computing critical speeds (see proto/gotrain.proto)
+//   - internal/masw: Comparison of measured (MASW) dispersion curves with computed soil curves
+//   - internal/moving_load: Rail deflection and bending moment versus train speed, and ground vibration, from the coupled track-soil model
+//   - internal/profiling: CPU and memory profiles of the command-line tools (-cpuprofile, -memprofile)
+//   - internal/protobuf: Protocol buffer wire format primitives (gRPC messages, protobuf result files)
+//   - internal/queue: Shared job queue (Redis) for distributing batches over several machines
 //   - internal/runner: Parallel batch processor for multiple configurations
+//   - internal/server: HTTP API for submitting configurations and fetching results
 //   - internal/soil_dispersion: Soil dispersion curve computation (Fast Delta Matrix)
+//   - internal/sqlite: Minimal SQLite database writer used for batch results
+//   - internal/storage: Local files and s3:// or gs:// objects, with streaming uploads
 //   - internal/track_dispersion: Track dispersion curve computation (ballast & slab tracks)
 //   - pkg/utils: Mathematical utilities (Brent's method, linear interpolation, etc.)
 //
 // # Commands
 //
-// GoTrain provides two main command-line tools:
+// GoTrain provides the gotrain command-line tool (cmd/gotrain), whose subcommands
+// compute single configurations and batches, and check, plot and compare them, next
+// to the server and specialised tools below. Every subcommand prints its flags with
+// -h, and gotrain version prints the version of the build.
 //
-// Critical Speed Calculator (cmd/critical_speed):
+// Critical Speed Calculator (gotrain run):
 //
 // Analyzes a single railway configuration and computes dispersion curves and critical speed.
 //
 //	# Single configuration analysis
-//	./critical_speed -config configs/sample_config.yaml
+//	./gotrain run -config configs/sample_config.yaml
+//
+//	# Starter configuration, asked interactively unless -track is given
+//	./gotrain init -track ballast -output my_project.yaml
 //
 // The output is a JSON file containing omega values, track phase velocities, soil phase
-// velocities, critical omega, and critical velocity.
+// velocities, critical omega, and critical velocity. With -format json, table or value,
+// a summary (or just the critical velocity) is also printed to stdout for shell scripts.
+// Failures exit with a code per kind of failure (3 configuration, 4 solver, 5 no
+// intersection, 6 I/O), and -error-json writes them to a JSON file for pipelines.
+// With -fast, a fast approximate mode (critical velocity within 1%) is used for
+// screening studies; the runner accepts the same flag for whole batches.
 //
-// Batch Runner (cmd/runner):
+// Batch Runner (gotrain batch):
 //
 // Processes multiple YAML configuration files in parallel with configurable worker pools.
 // Automatically discovers all .yaml files in a directory tree and processes them concurrently.
 //
 //	# Process multiple configurations with 4 workers
-//	./runner -dir testdata/batch -workers 4
+//	./gotrain batch -dir testdata/batch -workers 4
+//
+// With -template and -alignment, the runner computes the critical speed along a track
+// alignment section by section, and writes the profile and GeoJSON features for GIS.
 //
 // The runner displays a real-time progress bar and processes files concurrently for
 // maximum throughput.
 //
+// Checks, Plots and Comparisons (gotrain validate, plot and compare):
+//
+// Configurations are checked without computing them, the dispersion curves of result
+// files are plotted as SVG, and a result file is compared with a reference result
+// file, exiting with status 1 when they deviate by more than the tolerance.
+//
+//	./gotrain validate configs/
+//	./gotrain plot dispersion_results.json
+//	./gotrain compare baseline/dispersion_results.json dispersion_results.json
+//
+// Job Submission Server (cmd/server):
+//
+// Serves an HTTP API to submit configurations (YAML or JSON), poll their status and
+// fetch their results, backed by the runner worker pool. POST /compute returns the
+// result synchronously, and GET /openapi.json serves the OpenAPI specification.
+//
+//	# Listen on port 8080 with 4 workers
+//	./server -addr :8080 -workers 4
+//
+// With -grpc-addr, the server also exposes the gRPC CriticalSpeed service defined in
+// proto/gotrain.proto, for clients generated with protoc.
+//
+// Measured Dispersion Comparison (cmd/masw):
+//
+// Compares a measured dispersion curve (CSV, e.g. from a MASW survey) with the soil
+// dispersion curve of a configuration and reports the misfit statistics.
+//
+//	./masw -config configs/sample_config.yaml -measured testdata/masw/measured.csv
+//
+// Result Conversion (cmd/convert_results):
+//
+// Upgrades JSON result files of an earlier schema_version to the current one, in
+// place or to a new file, so that archived batch results remain consumable.
+//
+//	./convert_results -input results/
+//
+// Cross-Validation (cmd/crossval):
+//
+// Computes a configuration and compares its dispersion curves and critical speed
+// with a reference result of TrainCritSpeed, exiting with status 1 when they
+// deviate by more than the tolerance.
+//
+//	./crossval -config configs/sample_config.yaml -reference reference.json
+//
+// Result Explorer (cmd/explore):
+//
+// Browses result files interactively in the terminal: the critical velocities with
+// the batch statistics, the dispersion curves of each result and a histogram.
+//
+//	./explore results/
+//
+// WebAssembly Module (cmd/wasm):
+//
+// Exposes the computation to JavaScript as ComputeCriticalSpeed(configJSON), to run
+// it client-side in a browser tool.
+//
+//	# Build bin/gotrain.wasm and its support file bin/wasm_exec.js
+//	make wasm
+//
+// C Shared Library (cmd/libgotrain):
+//
+// Exposes ComputeCriticalSpeed and SoilDispersion through a C ABI, for Python
+// (ctypes) and Matlab users.
+//
+//	# Build bin/libgotrain.so and its header bin/libgotrain.h
+//	make lib
+//
 // # Library Usage
 //
 // GoTrain can be used as a library in your Go applications:
@@ -117,7 +213,7 @@
 // # Configuration
 //
 // Configuration files use YAML format and must specify:
-//   - Track type: "ballast" or "slabtrack"
+//   - Track type: "ballast", "slabtrack" or "piledslab"
 //   - Frequency range: min, max, and number of points
 //   - Track parameters: rail, sleeper/slab, railpad properties
 //   - Soil layers: multi-layer profile with elastic properties
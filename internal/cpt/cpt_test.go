@@ -0,0 +1,142 @@
+package cpt
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const sampleGEF = "../../testdata/cpt/CPT-01.gef"
+
+// Test parsing the sample GEF file.
+func TestLoadGEF(t *testing.T) {
+	cpt, err := LoadGEF(sampleGEF)
+	if err != nil {
+		t.Fatalf("LoadGEF failed: %v", err)
+	}
+	if cpt.TestID != "CPT-01" {
+		t.Errorf("unexpected test id: %q", cpt.TestID)
+	}
+
+	// 101 rows, one of which has a void cone resistance
+	if len(cpt.Depth) != 100 {
+		t.Fatalf("unexpected number of measurements: got %d, want 100", len(cpt.Depth))
+	}
+
+	// The corrected depth is used rather than the penetration length
+	if math.Abs(cpt.Depth[10]-0.999) > 1e-9 {
+		t.Errorf("unexpected depth: got %v, want 0.999", cpt.Depth[10])
+	}
+	if cpt.ConeResistance[0] != 0.6 || cpt.SleeveFriction[0] != 0.024 || cpt.FrictionRatio[0] != 4 {
+		t.Errorf("unexpected first measurement: qc %v, fs %v, Rf %v",
+			cpt.ConeResistance[0], cpt.SleeveFriction[0], cpt.FrictionRatio[0])
+	}
+}
+
+// Test that units are converted and the friction ratio is derived from the sleeve friction.
+func TestParseGEFUnits(t *testing.T) {
+	data := `#COLUMNINFO= 1, cm, penetration length, 1
+#COLUMNINFO= 2, kPa, cone resistance, 2
+#COLUMNINFO= 3, kPa, sleeve friction, 3
+#EOH=
+50 2000 40
+100 4000 40
+`
+	cpt, err := ParseGEF(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseGEF failed: %v", err)
+	}
+	if cpt.Depth[1] != 1 || cpt.ConeResistance[1] != 4 || cpt.SleeveFriction[1] != 0.04 {
+		t.Errorf("unexpected converted measurement: z %v, qc %v, fs %v", cpt.Depth[1], cpt.ConeResistance[1], cpt.SleeveFriction[1])
+	}
+	if math.Abs(cpt.FrictionRatio[1]-1) > 1e-12 {
+		t.Errorf("unexpected friction ratio: got %v, want 1", cpt.FrictionRatio[1])
+	}
+}
+
+// Test that invalid GEF files are rejected.
+func TestParseGEFErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing end of header": "#COLUMNINFO= 1, m, penetration length, 1\n0 1 0.01\n",
+		"missing cone resistance": "#COLUMNINFO= 1, m, penetration length, 1\n" +
+			"#COLUMNINFO= 2, MPa, sleeve friction, 3\n#EOH=\n0 0.01\n",
+		"missing friction": "#COLUMNINFO= 1, m, penetration length, 1\n" +
+			"#COLUMNINFO= 2, MPa, cone resistance, 2\n#EOH=\n0 1\n",
+		"unsupported unit": "#COLUMNINFO= 1, ft, penetration length, 1\n#EOH=\n",
+		"decreasing depth": "#COLUMNINFO= 1, m, penetration length, 1\n#COLUMNINFO= 2, MPa, cone resistance, 2\n" +
+			"#COLUMNINFO= 3, %, friction ratio, 4\n#EOH=\n1 1 1\n0.5 1 1\n",
+		"invalid value": "#COLUMNINFO= 1, m, penetration length, 1\n#COLUMNINFO= 2, MPa, cone resistance, 2\n" +
+			"#COLUMNINFO= 3, %, friction ratio, 4\n#EOH=\n1 x 1\n",
+		"no measurements": "#COLUMNINFO= 1, m, penetration length, 1\n#COLUMNINFO= 2, MPa, cone resistance, 2\n" +
+			"#COLUMNINFO= 3, %, friction ratio, 4\n#EOH=\n",
+	}
+	for name, data := range cases {
+		if _, err := ParseGEF(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// Test converting the sample CPT (clay on sand) into soil layers.
+func TestLayers(t *testing.T) {
+	cpt, err := LoadGEF(sampleGEF)
+	if err != nil {
+		t.Fatalf("LoadGEF failed: %v", err)
+	}
+
+	for _, correlation := range []string{CorrelationRobertson, CorrelationMayne} {
+		layers, err := Layers(cpt, LayerOptions{Correlation: correlation, LayerThickness: 2, GroundwaterDepth: 1})
+		if err != nil {
+			t.Fatalf("%s: Layers failed: %v", correlation, err)
+		}
+
+		// Depths up to 9.99 m in layers of 2 m, the last one being the halfspace
+		if len(layers) != 5 {
+			t.Fatalf("%s: unexpected number of layers: got %d, want 5", correlation, len(layers))
+		}
+		if !math.IsInf(layers[4].Thickness, 1) || layers[0].Thickness != 2 {
+			t.Errorf("%s: unexpected thicknesses: %v and %v", correlation, layers[0].Thickness, layers[4].Thickness)
+		}
+		for i, l := range layers {
+			if l.PoissonRatio != DefaultPoissonRatio || l.ShearWaveSpeed <= 0 || l.Density < 1000 || l.Density > 2300 {
+				t.Errorf("%s: unexpected layer %d: %+v", correlation, i, l)
+			}
+			if math.Abs(l.ShearWaveSpeed*l.ShearWaveSpeed*l.Density*2*(1+l.PoissonRatio)-l.YoungsModulus) > 1e-6*l.YoungsModulus {
+				t.Errorf("%s: Young's modulus of layer %d does not match its shear wave speed", correlation, i)
+			}
+		}
+
+		// The sand is stiffer than the clay
+		if layers[0].ShearWaveSpeed >= layers[3].ShearWaveSpeed {
+			t.Errorf("%s: expected the sand to be stiffer than the clay: %v and %v",
+				correlation, layers[0].ShearWaveSpeed, layers[3].ShearWaveSpeed)
+		}
+	}
+
+	if _, err := Layers(cpt, LayerOptions{Correlation: "andrus"}); err == nil {
+		t.Error("expected an error for an unsupported correlation")
+	}
+	if _, err := Layers(cpt, LayerOptions{PoissonRatio: 0.5}); err == nil {
+		t.Error("expected an error for a Poisson's ratio of 0.5")
+	}
+}
+
+// Test the correlations against hand calculations.
+func TestCorrelations(t *testing.T) {
+	// Mayne (2006): fs = 100 kPa gives 118.8 * 2 + 18.5
+	if vs := Mayne(Point{SleeveFriction: 100}); math.Abs(vs-256.1) > 1e-9 {
+		t.Errorf("unexpected Mayne velocity: got %v, want 256.1", vs)
+	}
+
+	// Robertson (2015) at 1 atm effective stress, where Q does not depend on n
+	p := Point{ConeResistance: 1100, SleeveFriction: 20, TotalStress: 100, EffectiveStress: 100}
+	Q, F := 10.0, 2.0
+	ic := math.Sqrt(math.Pow(3.47-math.Log10(Q), 2) + math.Pow(math.Log10(F)+1.22, 2))
+	expected := math.Sqrt(math.Pow(10, 0.55*ic+1.68) * Q)
+	if vs := Robertson(p); math.Abs(vs-expected) > 1e-9 {
+		t.Errorf("unexpected Robertson velocity: got %v, want %v", vs, expected)
+	}
+	if vs := Robertson(Point{ConeResistance: 50, SleeveFriction: 1, TotalStress: 100, EffectiveStress: 100}); !math.IsNaN(vs) {
+		t.Errorf("expected NaN for a negative net cone resistance, got %v", vs)
+	}
+}
@@ -0,0 +1,59 @@
+// Package cpt derives soil profiles from cone penetration tests (CPT), so that the
+// critical speed can be computed directly from site investigation data.
+//
+// CPTs are read from GEF files (Geotechnical Exchange Format), the standard
+// exchange format of CPT data in the Netherlands. The cone resistance and sleeve
+// friction profiles are converted into soil layers with empirical correlations:
+//
+//   - Unit weight: Robertson & Cabal (2010), from the cone resistance and friction ratio
+//   - Shear wave velocity: Robertson & Cabal (2015), from the net cone resistance and
+//     the soil behaviour type index Ic (Robertson, 2009), or Mayne (2006), from the
+//     sleeve friction only
+//
+// The profile is divided into layers of constant thickness; Young's modulus of each
+// layer follows from its small-strain shear modulus G0 = ρ Vs² and a Poisson's ratio
+// chosen by the user. The last layer is the halfspace.
+//
+// References:
+//   - Robertson, P. K. (2009). "Interpretation of cone penetration tests - a unified
+//     approach". Canadian Geotechnical Journal, 46(11), 1337–1355.
+//   - Robertson, P. K., & Cabal, K. L. (2010). "Estimating soil unit weight from CPT".
+//     2nd International Symposium on Cone Penetration Testing, Huntington Beach.
+//   - Robertson, P. K., & Cabal, K. L. (2015). "Guide to Cone Penetration Testing for
+//     Geotechnical Engineering", 6th edition. Gregg Drilling & Testing.
+//   - Mayne, P. W. (2006). "In-situ test calibrations for evaluating soil parameters".
+//     Characterization and Engineering Properties of Natural Soils, 1601–1652.
+//
+// # GEF Files
+//
+// The columns are identified by their #COLUMNINFO quantity number: penetration
+// length (1) or corrected depth (11), cone resistance (2), and sleeve friction (3)
+// or friction ratio (4). #COLUMNVOID, #COLUMNSEPARATOR and #RECORDSEPARATOR are
+// supported; other header lines are ignored. The pore pressure correction of the
+// cone resistance is neglected (qt = qc).
+//
+// # Usage Example
+//
+//	test, err := cpt.LoadGEF("testdata/cpt/CPT-01.gef")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	layers, err := cpt.Layers(test, cpt.LayerOptions{
+//		Correlation:      cpt.CorrelationRobertson,
+//		LayerThickness:   1,
+//		PoissonRatio:     0.35,
+//		GroundwaterDepth: 1,
+//	})
+//
+// In a configuration file, the soil_cpt section replaces the soil_layers:
+//
+//	soil_cpt:
+//	  file: "testdata/cpt/CPT-01.gef"
+//	  correlation: "robertson"
+//	  layer_thickness: 1
+//	  poisson_ratio: 0.35
+//	  groundwater_depth: 1
+//
+// The CPT can also be fetched from a geo-database such as the Dutch BRO, by the
+// coordinates of the site (see the geodata package).
+package cpt
@@ -0,0 +1,291 @@
+package cpt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GEF quantity numbers of the measured columns (see the GEF-CPT-Report standard).
+const (
+	quantityPenetrationLength = 1  // Penetration length [m]
+	quantityConeResistance    = 2  // Cone resistance qc [MPa]
+	quantitySleeveFriction    = 3  // Sleeve friction fs [MPa]
+	quantityFrictionRatio     = 4  // Friction ratio Rf [%]
+	quantityCorrectedDepth    = 11 // Corrected depth [m]
+)
+
+// CPT is a cone penetration test, with its measurements sorted by depth.
+// Measurements missing in the file are NaN.
+type CPT struct {
+	TestID         string    // Identifier of the test (#TESTID)
+	Depth          []float64 // Depth below the surface [m]
+	ConeResistance []float64 // Cone resistance qc [MPa]
+	SleeveFriction []float64 // Sleeve friction fs [MPa]
+	FrictionRatio  []float64 // Friction ratio Rf = fs / qc [%]
+}
+
+// gefColumn describes a data column of a GEF file.
+type gefColumn struct {
+	index int     // Zero-based index of the column in a data row
+	scale float64 // Factor converting the values to the units of CPT
+	void  float64 // Value marking a missing measurement (NaN if none)
+}
+
+// LoadGEF reads a CPT from a GEF file.
+//
+// Parameters:
+//   - path: Path to the GEF file
+//
+// Returns:
+//   - CPT: The cone penetration test
+//   - error: An error if the file cannot be read or is not a valid GEF CPT file
+func LoadGEF(path string) (CPT, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return CPT{}, fmt.Errorf("failed to read GEF file: %v", err)
+	}
+	defer file.Close()
+
+	cpt, err := ParseGEF(file)
+	if err != nil {
+		return CPT{}, fmt.Errorf("invalid GEF file %s: %v", path, err)
+	}
+	return cpt, nil
+}
+
+// ParseGEF parses a CPT in the GEF format: a header of "#KEYWORD= value" lines up
+// to "#EOH=", followed by one row of values per measurement. The columns are
+// identified by their quantity number in #COLUMNINFO, so their order is free.
+// The depth is the corrected depth if available, and the penetration length
+// otherwise. Either the sleeve friction or the friction ratio must be present;
+// the other one is derived from it. Rows without depth or cone resistance
+// (#COLUMNVOID values) are skipped.
+//
+// Parameters:
+//   - r: Reader of the GEF file content
+//
+// Returns:
+//   - CPT: The cone penetration test
+//   - error: An error if the content is not a valid GEF CPT file
+func ParseGEF(r io.Reader) (CPT, error) {
+	var cpt CPT
+	columns := make(map[int]*gefColumn) // By quantity number
+	byIndex := make(map[int]*gefColumn) // By one-based column number
+	voids := make(map[int]float64)      // By one-based column number
+	columnSeparator, recordSeparator := "", ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	header := true
+	for header && scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		keyword, value, ok := strings.Cut(text, "=")
+		if !ok || !strings.HasPrefix(keyword, "#") {
+			return cpt, fmt.Errorf("line %d: invalid header line", line)
+		}
+		keyword = strings.ToUpper(strings.TrimSpace(keyword[1:]))
+		value = strings.TrimSpace(value)
+		fields := splitHeaderValue(value)
+
+		switch keyword {
+		case "EOH":
+			header = false
+		case "TESTID":
+			cpt.TestID = value
+		case "COLUMNSEPARATOR":
+			columnSeparator = value
+		case "RECORDSEPARATOR":
+			recordSeparator = value
+		case "COLUMNINFO":
+			if len(fields) < 4 {
+				return cpt, fmt.Errorf("line %d: #COLUMNINFO needs a column number, unit, name and quantity number", line)
+			}
+			number, err1 := strconv.Atoi(fields[0])
+			quantity, err2 := strconv.Atoi(fields[3])
+			if err1 != nil || err2 != nil || number < 1 {
+				return cpt, fmt.Errorf("line %d: invalid #COLUMNINFO", line)
+			}
+			scale, err := unitScale(quantity, fields[1])
+			if err != nil {
+				return cpt, fmt.Errorf("line %d: %v", line, err)
+			}
+			column := &gefColumn{index: number - 1, scale: scale, void: math.NaN()}
+			columns[quantity] = column
+			byIndex[number] = column
+		case "COLUMNVOID":
+			if len(fields) < 2 {
+				return cpt, fmt.Errorf("line %d: #COLUMNVOID needs a column number and a value", line)
+			}
+			number, err1 := strconv.Atoi(fields[0])
+			void, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil {
+				return cpt, fmt.Errorf("line %d: invalid #COLUMNVOID", line)
+			}
+			voids[number] = void
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cpt, fmt.Errorf("failed to read GEF data: %v", err)
+	}
+	if header {
+		return cpt, fmt.Errorf("missing #EOH (end of header)")
+	}
+	for number, void := range voids {
+		if column, ok := byIndex[number]; ok {
+			column.void = void
+		}
+	}
+
+	depth, ok := columns[quantityCorrectedDepth]
+	if !ok {
+		depth, ok = columns[quantityPenetrationLength]
+	}
+	if !ok {
+		return cpt, fmt.Errorf("no penetration length or depth column")
+	}
+	qc, ok := columns[quantityConeResistance]
+	if !ok {
+		return cpt, fmt.Errorf("no cone resistance column")
+	}
+	fs, hasFs := columns[quantitySleeveFriction]
+	rf, hasRf := columns[quantityFrictionRatio]
+	if !hasFs && !hasRf {
+		return cpt, fmt.Errorf("no sleeve friction or friction ratio column")
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if recordSeparator != "" {
+			text = strings.TrimSpace(strings.TrimSuffix(text, recordSeparator))
+		}
+		if text == "" {
+			continue
+		}
+
+		var fields []string
+		if columnSeparator != "" {
+			fields = strings.Split(text, columnSeparator)
+		} else {
+			fields = strings.Fields(text)
+		}
+		value := func(column *gefColumn) (float64, error) {
+			if column.index >= len(fields) {
+				return 0, fmt.Errorf("line %d: missing column %d", line, column.index+1)
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[column.index]), 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid value in column %d", line, column.index+1)
+			}
+			if v == column.void {
+				return math.NaN(), nil
+			}
+			return v * column.scale, nil
+		}
+
+		z, err := value(depth)
+		if err != nil {
+			return cpt, err
+		}
+		coneResistance, err := value(qc)
+		if err != nil {
+			return cpt, err
+		}
+		if math.IsNaN(z) || math.IsNaN(coneResistance) {
+			continue
+		}
+		sleeveFriction, frictionRatio := math.NaN(), math.NaN()
+		if hasFs {
+			if sleeveFriction, err = value(fs); err != nil {
+				return cpt, err
+			}
+		}
+		if hasRf {
+			if frictionRatio, err = value(rf); err != nil {
+				return cpt, err
+			}
+		}
+		if math.IsNaN(sleeveFriction) {
+			sleeveFriction = frictionRatio / 100 * coneResistance
+		}
+		if math.IsNaN(frictionRatio) && coneResistance > 0 {
+			frictionRatio = 100 * sleeveFriction / coneResistance
+		}
+
+		if n := len(cpt.Depth); n > 0 && z < cpt.Depth[n-1] {
+			return cpt, fmt.Errorf("line %d: depths must be increasing", line)
+		}
+		cpt.Depth = append(cpt.Depth, z)
+		cpt.ConeResistance = append(cpt.ConeResistance, coneResistance)
+		cpt.SleeveFriction = append(cpt.SleeveFriction, sleeveFriction)
+		cpt.FrictionRatio = append(cpt.FrictionRatio, frictionRatio)
+	}
+	if err := scanner.Err(); err != nil {
+		return cpt, fmt.Errorf("failed to read GEF data: %v", err)
+	}
+	if len(cpt.Depth) == 0 {
+		return cpt, fmt.Errorf("no measurements")
+	}
+	return cpt, nil
+}
+
+// splitHeaderValue splits the comma-separated value of a header line.
+//
+// Parameters:
+//   - value: The value of the header line
+//
+// Returns:
+//   - []string: The trimmed fields
+func splitHeaderValue(value string) []string {
+	fields := strings.Split(value, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// unitScale returns the factor converting a column to the units of CPT.
+// Columns with quantities that are not used are accepted in any unit.
+//
+// Parameters:
+//   - quantity: GEF quantity number of the column
+//   - unit: Unit of the column
+//
+// Returns:
+//   - float64: The conversion factor
+//   - error: An error if the unit of a used quantity is not supported
+func unitScale(quantity int, unit string) (float64, error) {
+	switch quantity {
+	case quantityPenetrationLength, quantityCorrectedDepth:
+		switch strings.ToLower(unit) {
+		case "m":
+			return 1, nil
+		case "cm":
+			return 1e-2, nil
+		}
+	case quantityConeResistance, quantitySleeveFriction:
+		switch strings.ToLower(unit) {
+		case "mpa":
+			return 1, nil
+		case "kpa":
+			return 1e-3, nil
+		}
+	case quantityFrictionRatio:
+		if unit == "%" {
+			return 1, nil
+		}
+	default:
+		return 1, nil
+	}
+	return 0, fmt.Errorf("unsupported unit %s for quantity %d", unit, quantity)
+}
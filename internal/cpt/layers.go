@@ -0,0 +1,244 @@
+package cpt
+
+import (
+	"fmt"
+	"math"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+// Physical constants of the correlations.
+const (
+	atmosphericPressure = 100.0 // Atmospheric pressure pa [kPa]
+	waterUnitWeight     = 9.81  // Unit weight of water γw [kN/m³]
+	gravity             = 9.81  // Gravitational acceleration [m/s²]
+)
+
+// Names of the shear wave velocity correlations supported by CorrelationByName.
+const (
+	CorrelationRobertson = "robertson" // Robertson & Cabal (2015), from qt, fs and the stresses (default)
+	CorrelationMayne     = "mayne"     // Mayne (2006), from fs only
+)
+
+// Default values of the LayerOptions.
+const (
+	DefaultLayerThickness = 1.0  // Thickness of the layers [m]
+	DefaultPoissonRatio   = 0.35 // Poisson's ratio of the layers
+)
+
+// Point is a CPT measurement with the in-situ stresses at its depth: the input
+// of the shear wave velocity correlations.
+type Point struct {
+	Depth           float64 // Depth below the surface [m]
+	ConeResistance  float64 // Cone resistance qt [kPa] (the pore pressure correction is neglected: qt = qc)
+	SleeveFriction  float64 // Sleeve friction fs [kPa]
+	FrictionRatio   float64 // Friction ratio Rf [%]
+	TotalStress     float64 // Total vertical stress σv0 [kPa]
+	EffectiveStress float64 // Effective vertical stress σ'v0 [kPa]
+}
+
+// Correlation estimates the shear wave velocity [m/s] of the soil at a CPT
+// measurement. It returns NaN where the correlation does not apply.
+type Correlation func(p Point) float64
+
+// CorrelationByName returns the shear wave velocity correlation with the given name.
+//
+// Parameters:
+//   - name: CorrelationRobertson or CorrelationMayne (empty means CorrelationRobertson)
+//
+// Returns:
+//   - Correlation: The correlation
+//   - error: An error if the name is not supported
+func CorrelationByName(name string) (Correlation, error) {
+	switch name {
+	case "", CorrelationRobertson:
+		return Robertson, nil
+	case CorrelationMayne:
+		return Mayne, nil
+	default:
+		return nil, fmt.Errorf("invalid CPT correlation: %s. Supported correlations are '%s' or '%s'",
+			name, CorrelationRobertson, CorrelationMayne)
+	}
+}
+
+// Robertson estimates the shear wave velocity with the correlation of
+// Robertson & Cabal (2015): Vs = [αvs (qt - σv0) / pa]^0.5, with
+// αvs = 10^(0.55 Ic + 1.68), where Ic is the soil behaviour type index of
+// Robertson (2009), computed iteratively with the stress exponent n.
+//
+// Parameters:
+//   - p: The CPT measurement
+//
+// Returns:
+//   - float64: The shear wave velocity [m/s] (NaN if qt <= σv0 or σ'v0 <= 0)
+func Robertson(p Point) float64 {
+	netResistance := p.ConeResistance - p.TotalStress
+	if !(netResistance > 0) || !(p.EffectiveStress > 0) || !(p.SleeveFriction > 0) {
+		return math.NaN()
+	}
+
+	// Soil behaviour type index, iterating on the stress exponent
+	F := 100 * p.SleeveFriction / netResistance
+	n, ic := 1.0, 0.0
+	for range 100 {
+		Q := netResistance / atmosphericPressure * math.Pow(atmosphericPressure/p.EffectiveStress, n)
+		ic = math.Sqrt(math.Pow(3.47-math.Log10(Q), 2) + math.Pow(math.Log10(F)+1.22, 2))
+		next := math.Min(0.381*ic+0.05*p.EffectiveStress/atmosphericPressure-0.15, 1)
+		if math.Abs(next-n) < 1e-3 {
+			break
+		}
+		n = next
+	}
+
+	alpha := math.Pow(10, 0.55*ic+1.68)
+	return math.Sqrt(alpha * netResistance / atmosphericPressure)
+}
+
+// Mayne estimates the shear wave velocity with the correlation of Mayne (2006):
+// Vs = 118.8 log10(fs) + 18.5, with fs in kPa.
+//
+// Parameters:
+//   - p: The CPT measurement
+//
+// Returns:
+//   - float64: The shear wave velocity [m/s] (NaN if it is not positive)
+func Mayne(p Point) float64 {
+	vs := 118.8*math.Log10(p.SleeveFriction) + 18.5
+	if !(vs > 0) {
+		return math.NaN()
+	}
+	return vs
+}
+
+// unitWeight estimates the unit weight of the soil with the correlation of
+// Robertson & Cabal (2010): γ/γw = 0.27 log10(Rf) + 0.36 log10(qt/pa) + 1.236.
+// The friction ratio and cone resistance are bounded below (0.1 % and pa), and
+// the unit weight is at least the unit weight of water.
+//
+// Parameters:
+//   - coneResistance: Cone resistance qt [kPa]
+//   - frictionRatio: Friction ratio Rf [%]
+//
+// Returns:
+//   - float64: The unit weight [kN/m³]
+func unitWeight(coneResistance float64, frictionRatio float64) float64 {
+	rf := math.Max(frictionRatio, 0.1)
+	qt := math.Max(coneResistance, atmosphericPressure)
+	return waterUnitWeight * math.Max(0.27*math.Log10(rf)+0.36*math.Log10(qt/atmosphericPressure)+1.236, 1)
+}
+
+// LayerOptions controls the conversion of a CPT into soil layers.
+type LayerOptions struct {
+	Correlation      string  // Shear wave velocity correlation (see CorrelationByName)
+	LayerThickness   float64 // Thickness of the layers [m] (DefaultLayerThickness when <= 0)
+	PoissonRatio     float64 // Poisson's ratio of the layers (DefaultPoissonRatio when <= 0)
+	GroundwaterDepth float64 // Depth of the groundwater table below the surface [m]
+}
+
+// Layers converts a CPT into a soil profile. The unit weight (Robertson & Cabal,
+// 2010) and the shear wave velocity (see Correlation) are estimated at every
+// measurement, with the vertical stresses integrated from the unit weights and
+// a hydrostatic pore pressure below the groundwater table. The profile is then
+// divided into layers of the given thickness, starting at the surface: the shear
+// wave velocity of a layer is the harmonic mean of its measurements (the average
+// travel time) and its density the arithmetic mean. Young's modulus follows from
+// the small-strain shear modulus: E = 2 (1 + ν) ρ Vs².
+//
+// The first layer extends up to the surface and intervals without valid
+// measurements are merged into the layer above. The last layer is the halfspace
+// (infinite thickness). The wave speeds of the layers are computed.
+//
+// Parameters:
+//   - cpt: The cone penetration test
+//   - opts: Options controlling the conversion
+//
+// Returns:
+//   - []soil_dispersion.Layer: The soil layers, from the surface down
+//   - error: An error if the correlation is not supported or no layer can be derived
+func Layers(cpt CPT, opts LayerOptions) ([]soil_dispersion.Layer, error) {
+	correlation, err := CorrelationByName(opts.Correlation)
+	if err != nil {
+		return nil, err
+	}
+	thickness := opts.LayerThickness
+	if thickness <= 0 {
+		thickness = DefaultLayerThickness
+	}
+	poissonRatio := opts.PoissonRatio
+	if poissonRatio <= 0 {
+		poissonRatio = DefaultPoissonRatio
+	}
+	if poissonRatio >= 0.5 {
+		return nil, fmt.Errorf("poisson ratio must be smaller than 0.5")
+	}
+
+	type interval struct {
+		top          float64 // Top of the layer [m]
+		slowness     float64 // Sum of 1/Vs of the measurements [s/m]
+		density      float64 // Sum of the densities of the measurements [kg/m³]
+		measurements int     // Number of valid measurements
+	}
+	var intervals []interval
+
+	totalStress := 0.0
+	previousDepth := 0.0
+	for i, z := range cpt.Depth {
+		qt := 1000 * cpt.ConeResistance[i]
+		fs := 1000 * cpt.SleeveFriction[i]
+		gamma := unitWeight(qt, cpt.FrictionRatio[i])
+		if math.IsNaN(gamma) {
+			continue
+		}
+		totalStress += gamma * (z - previousDepth)
+		previousDepth = z
+
+		porePressure := waterUnitWeight * math.Max(z-opts.GroundwaterDepth, 0)
+		vs := correlation(Point{
+			Depth:           z,
+			ConeResistance:  qt,
+			SleeveFriction:  fs,
+			FrictionRatio:   cpt.FrictionRatio[i],
+			TotalStress:     totalStress,
+			EffectiveStress: totalStress - porePressure,
+		})
+		if math.IsNaN(vs) || vs <= 0 {
+			continue
+		}
+
+		top := math.Floor(z/thickness) * thickness
+		if n := len(intervals); n == 0 || intervals[n-1].top != top {
+			intervals = append(intervals, interval{top: top})
+		}
+		last := &intervals[len(intervals)-1]
+		last.slowness += 1 / vs
+		last.density += 1000 * gamma / gravity
+		last.measurements++
+	}
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("no valid CPT measurements to derive soil layers from")
+	}
+
+	layers := make([]soil_dispersion.Layer, len(intervals))
+	for i, in := range intervals {
+		vs := float64(in.measurements) / in.slowness
+		density := in.density / float64(in.measurements)
+
+		layerThickness := math.Inf(1)
+		if i+1 < len(intervals) {
+			top := in.top
+			if i == 0 {
+				top = 0
+			}
+			layerThickness = intervals[i+1].top - top
+		}
+
+		layers[i] = soil_dispersion.Layer{
+			Density:       density,
+			YoungsModulus: 2 * (1 + poissonRatio) * density * vs * vs,
+			PoissonRatio:  poissonRatio,
+			Thickness:     layerThickness,
+		}
+		layers[i].WaveSpeed()
+	}
+	return layers, nil
+}
@@ -0,0 +1,82 @@
+package critical_speed
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// checkAttenuation checks that the complex wave numbers of the track can be computed:
+// they are computed in closed form, so that solver.attenuation excludes the
+// bracketing root finders.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - error: An error if solver.attenuation is combined with a bracketing root finder
+func checkAttenuation(config Config) error {
+	if !config.Solver.Attenuation || config.Solver.RootFinder == "" || config.Solver.RootFinder == math_utils.SolverPolynomial {
+		return nil
+	}
+	return classify(KindConfig, fieldError("solver.attenuation", fmt.Errorf(
+		"invalid solver: attenuation requires the root finder '%s', not %q", math_utils.SolverPolynomial, config.Solver.RootFinder)))
+}
+
+// decayRateAt interpolates the spatial decay rate of the track waves linearly at a
+// frequency, e.g. at the critical frequency to report how far the critical-speed
+// effects extend along the track.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s]
+//   - decayRate: Spatial decay rates of the track waves [1/m]
+//   - at: Angular frequency to interpolate at [rad/s]
+//
+// Returns:
+//   - float64: The decay rate [1/m]
+//   - error: An error if the curve cannot be interpolated
+func decayRateAt(omega []float64, decayRate []float64, at float64) (float64, error) {
+	interp, err := math_utils.NewInterp1D(omega, decayRate, math_utils.InterpLinear, math_utils.ExtrapolateClamp)
+	if err != nil {
+		return 0, err
+	}
+	return interp.At(at)
+}
+
+// attenuationColumns are the columns of the table written by WriteAttenuationCSV.
+var attenuationColumns = []string{"omega", "track_phase_velocity", "decay_rate", "decay_length"}
+
+// WriteAttenuationCSV writes the waves of the damped track as CSV, one row per
+// frequency, with the columns omega [rad/s], track_phase_velocity [m/s], decay_rate
+// [1/m] and decay_length [m], the distance over which the amplitude of the waves drops
+// by a factor e (empty where the waves do not decay).
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - omega: Angular frequencies [rad/s]
+//   - phaseVelocity: Phase velocities of the track [m/s]
+//   - decayRate: Spatial decay rates of the track waves [1/m]
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteAttenuationCSV(w io.Writer, omega []float64, phaseVelocity []float64, decayRate []float64) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(attenuationColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for i := range omega {
+		length := ""
+		if decayRate[i] > 0 {
+			length = format(1 / decayRate[i])
+		}
+		if err := writer.Write([]string{format(omega[i]), format(phaseVelocity[i]), format(decayRate[i]), length}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,104 @@
+package critical_speed
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// CriticalBand is the band of frequencies around the critical frequency where the
+// phase velocities of the track and the soil are within a tolerance of each other,
+// with the range of phase velocities, i.e. of train speeds, over it: the track
+// response is amplified over the whole band, not only at the critical speed.
+type CriticalBand struct {
+	OmegaMin    float64 // Lowest angular frequency of the band [rad/s]
+	OmegaMax    float64 // Highest angular frequency of the band [rad/s]
+	VelocityMin float64 // Lowest phase velocity of the track or the soil over the band [m/s]
+	VelocityMax float64 // Highest phase velocity of the track or the soil over the band [m/s]
+}
+
+// CriticalBandOf computes the critical band of the track and soil dispersion curves:
+// the frequencies around the critical frequency where the phase velocities differ by
+// at most a tolerance, relative to the soil phase velocity. The band extends from the
+// critical frequency in both directions until the difference exceeds the tolerance,
+// at a frequency interpolated linearly between the frequencies of the curves, or
+// until a frequency where the track or the soil has no root. The speed band is the
+// range of the phase velocities of both curves over the band.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s], in increasing order
+//   - track: Phase velocities of the track [m/s] (zero where no root is found)
+//   - soil: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - omegaCrit: Critical angular frequency [rad/s] (see criticalPoint)
+//   - tolerance: Largest relative difference of the phase velocities, e.g. 0.1 for 10%
+//
+// Returns:
+//   - CriticalBand: The critical band
+//   - error: An error if the curves differ by more than the tolerance at the critical frequency
+func CriticalBandOf(omega []float64, track []float64, soil []float64, omegaCrit float64, tolerance float64) (CriticalBand, error) {
+	roots := trackRoots(track)
+
+	// The critical frequency is a node of the band, with the velocities of both curves
+	p, found := slices.BinarySearch(omega, omegaCrit)
+	if !found {
+		if p == 0 || p == len(omega) {
+			return CriticalBand{}, fmt.Errorf("the critical frequency %g rad/s is outside the frequency range", omegaCrit)
+		}
+		t := (omegaCrit - omega[p-1]) / (omega[p] - omega[p-1])
+		at := func(values []float64) float64 { return values[p-1] + t*(values[p]-values[p-1]) }
+		omega = slices.Insert(slices.Clone(omega), p, omegaCrit)
+		roots = slices.Insert(roots, p, at(roots))
+		soil = slices.Insert(slices.Clone(soil), p, at(soil))
+	}
+	difference := func(i int) float64 {
+		return math.Abs(roots[i]-soil[i]) / soil[i]
+	}
+	if !(difference(p) <= tolerance) {
+		return CriticalBand{}, fmt.Errorf("the track and soil phase velocities differ by more than %g%% at the critical frequency", 100*tolerance)
+	}
+
+	band := CriticalBand{VelocityMin: math.Inf(1), VelocityMax: math.Inf(-1)}
+	include := func(velocities ...float64) {
+		for _, v := range velocities {
+			band.VelocityMin = math.Min(band.VelocityMin, v)
+			band.VelocityMax = math.Max(band.VelocityMax, v)
+		}
+	}
+
+	// Walk away from the critical frequency while the curves are within the tolerance
+	// (NaN differences, without roots, end the band)
+	edge := func(step int) float64 {
+		i := p
+		for i+step >= 0 && i+step < len(omega) && difference(i+step) <= tolerance {
+			i += step
+			include(roots[i], soil[i])
+		}
+		next := i + step
+		if next < 0 || next == len(omega) || math.IsNaN(difference(next)) {
+			return omega[i]
+		}
+		t := (tolerance - difference(i)) / (difference(next) - difference(i))
+		include(roots[i]+t*(roots[next]-roots[i]), soil[i]+t*(soil[next]-soil[i]))
+		return omega[i] + t*(omega[next]-omega[i])
+	}
+	include(roots[p], soil[p])
+	band.OmegaMin = edge(-1)
+	band.OmegaMax = edge(1)
+	return band, nil
+}
+
+// checkCriticalBand checks the tolerance of the critical band of the assessment
+// section of a configuration.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - error: An error if assessment.band_tolerance is not within [0, 100)
+func checkCriticalBand(config Config) error {
+	if tolerance := config.Assessment.BandTolerance; !(tolerance >= 0 && tolerance < 100) {
+		return classify(KindConfig, fieldError("assessment.band_tolerance",
+			fmt.Errorf("invalid band tolerance: %g%%. Give a percentage within [0, 100), or 0 for no critical band", tolerance)))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package critical_speed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// CompressedSuffix is the suffix of result files written compressed with gzip, e.g.
+// results.json.gz.
+const CompressedSuffix = ".gz"
+
+// gzipMagic are the first bytes of gzip data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsCompressed reports whether a result file is written compressed with gzip,
+// which is detected from its name.
+//
+// Parameters:
+//   - fileName: Path of the result file
+//
+// Returns:
+//   - bool: True if the name ends with CompressedSuffix
+func IsCompressed(fileName string) bool {
+	return strings.HasSuffix(fileName, CompressedSuffix)
+}
+
+// OutputExt returns the extension of a result file, including CompressedSuffix for
+// a compressed file, e.g. ".json.gz" for results.json.gz, so that the files derived
+// from its name keep both.
+//
+// Parameters:
+//   - fileName: Path of the result file
+//
+// Returns:
+//   - string: The extension, empty if the name has none
+func OutputExt(fileName string) string {
+	if !IsCompressed(fileName) {
+		return filepath.Ext(fileName)
+	}
+	base := strings.TrimSuffix(fileName, CompressedSuffix)
+	return filepath.Ext(base) + CompressedSuffix
+}
+
+// compressWriter returns the writer of the content of a result file: a gzip writer
+// on the file for a compressed file, or the file itself.
+//
+// Parameters:
+//   - w: The file
+//   - fileName: Path of the file
+//
+// Returns:
+//   - io.Writer: Writer receiving the content
+//   - func() error: Flushes the compressed data (does nothing for an uncompressed file)
+func compressWriter(w io.Writer, fileName string) (io.Writer, func() error) {
+	if !IsCompressed(fileName) {
+		return w, func() error { return nil }
+	}
+	zw := gzip.NewWriter(w)
+	return zw, zw.Close
+}
+
+// CompressResults compresses the content of a result file with gzip, e.g. to write
+// an upgraded result file back to a compressed file.
+//
+// Parameters:
+//   - data: Content of the result file
+//
+// Returns:
+//   - []byte: The compressed content
+//   - error: An error if the data cannot be compressed
+func CompressResults(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressResults returns the content of a result file, decompressed if the file
+// is compressed with gzip, which is detected from its first bytes.
+//
+// Parameters:
+//   - data: Content of the result file, compressed or not
+//
+// Returns:
+//   - []byte: The uncompressed content
+//   - error: An error if the compressed data is invalid
+func decompressResults(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed result file: %v", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed result file: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,155 @@
+package critical_speed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the version of the layout of the configuration files read by this
+// version of GoTrain. It is incremented whenever a field of the configuration is
+// renamed or moved, with a migration from the previous version added to
+// configMigrations, so that archived configuration files keep working.
+const ConfigVersion = 1
+
+// configVersionKey is the key of the version of a configuration file.
+const configVersionKey = "config_version"
+
+// configMigrations upgrade the layout of a configuration file by one version:
+// configMigrations[v] converts the root mapping of a file of version v into version
+// v+1 and returns a description of every change, reported as a warning. Files
+// written before the config_version field was introduced are version 0.
+var configMigrations = []func(root *yaml.Node) ([]string, error){
+	// 0 -> 1: the config_version field is added, the other fields are unchanged
+	func(root *yaml.Node) ([]string, error) { return nil, nil },
+}
+
+// migrateConfig upgrades a parsed configuration file of an older config_version to
+// the layout of ConfigVersion, and sets its config_version to ConfigVersion.
+//
+// Parameters:
+//   - document: The parsed YAML document of the configuration, modified in place
+//
+// Returns:
+//   - []string: Description of the changes made, e.g. a renamed field
+//   - error: An error if the version is invalid or newer than ConfigVersion, or a
+//     migration fails
+func migrateConfig(document *yaml.Node) ([]string, error) {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	root := document.Content[0]
+
+	version := 0
+	versionNode := mappingValue(root, configVersionKey)
+	if versionNode != nil {
+		parsed, err := strconv.Atoi(versionNode.Value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("line %d: invalid config_version %q", versionNode.Line, versionNode.Value)
+		}
+		version = parsed
+	}
+	if version > ConfigVersion {
+		return nil, fmt.Errorf("config_version %d is newer than the version %d supported by this version of GoTrain", version, ConfigVersion)
+	}
+
+	var changes []string
+	for v := version; v < ConfigVersion; v++ {
+		migrated, err := configMigrations[v](root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade config_version %d: %v", v, err)
+		}
+		for _, change := range migrated {
+			changes = append(changes, fmt.Sprintf("config_version %d: %s", v, change))
+		}
+	}
+
+	if versionNode == nil {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: configVersionKey},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int"})
+		versionNode = root.Content[len(root.Content)-1]
+	}
+	versionNode.Value, versionNode.Tag, versionNode.Style = strconv.Itoa(ConfigVersion), "!!int", 0
+	return changes, nil
+}
+
+// renameKey moves a field of a configuration file to a new key, for the migrations
+// of configMigrations. The paths are the dot-separated keys of the field, e.g.
+// "ballast_track.k_rail_pad"; the field is moved within its section, or to another
+// existing section. Nothing is changed when the file does not have the field.
+//
+// Parameters:
+//   - root: The root mapping of the configuration file
+//   - from: Path of the field in the older layout
+//   - to: Path of the field in the newer layout
+//
+// Returns:
+//   - []string: Description of the change, or nil when the file does not have the field
+//   - error: An error if the file has the field under both paths, or the section of
+//     the new path does not exist
+func renameKey(root *yaml.Node, from string, to string) ([]string, error) {
+	fromSection, fromKey := splitPath(root, from)
+	if fromSection == nil || mappingValue(fromSection, fromKey) == nil {
+		return nil, nil
+	}
+	toSection, toKey := splitPath(root, to)
+	if toSection == nil {
+		return nil, fmt.Errorf("cannot move %s: the section of %s does not exist", from, to)
+	}
+	if mappingValue(toSection, toKey) != nil {
+		return nil, fmt.Errorf("both %s and its new name %s are given", from, to)
+	}
+
+	for i := 0; i+1 < len(fromSection.Content); i += 2 {
+		if fromSection.Content[i].Value != fromKey {
+			continue
+		}
+		key, value := fromSection.Content[i], fromSection.Content[i+1]
+		fromSection.Content = append(fromSection.Content[:i], fromSection.Content[i+2:]...)
+		key.Value = toKey
+		toSection.Content = append(toSection.Content, key, value)
+		return []string{fmt.Sprintf("%s (line %d) is renamed to %s", from, key.Line, to)}, nil
+	}
+	return nil, nil
+}
+
+// splitPath finds the section of a field of a configuration file.
+//
+// Parameters:
+//   - root: The root mapping of the configuration file
+//   - path: Dot-separated keys of the field
+//
+// Returns:
+//   - *yaml.Node: The mapping of the section of the field, or nil if it does not exist
+//   - string: The key of the field in its section
+func splitPath(root *yaml.Node, path string) (*yaml.Node, string) {
+	keys := strings.Split(path, ".")
+	section := root
+	for _, key := range keys[:len(keys)-1] {
+		section = mappingValue(section, key)
+		if section == nil || section.Kind != yaml.MappingNode {
+			return nil, ""
+		}
+	}
+	return section, keys[len(keys)-1]
+}
+
+// mappingValue returns the value of a key of a YAML mapping.
+//
+// Parameters:
+//   - mapping: The mapping node
+//   - key: The key
+//
+// Returns:
+//   - *yaml.Node: The value, or nil if the mapping does not have the key
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
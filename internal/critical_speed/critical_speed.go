@@ -1,14 +1,21 @@
 package critical_speed
 
 import (
-	"encoding/json"
+	"cmp"
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"math"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
+	cpt "github.com/PlatypusBytes/GoTrain/internal/cpt"
+	geodata "github.com/PlatypusBytes/GoTrain/internal/geodata"
+	moving_load "github.com/PlatypusBytes/GoTrain/internal/moving_load"
 	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
 	track_dispersion "github.com/PlatypusBytes/GoTrain/internal/track_dispersion"
 	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
 	"gopkg.in/yaml.v3"
@@ -18,11 +25,14 @@ import (
 // It contains all necessary parameters to define track type, frequency range,
 // and physical properties of either ballast or slab tracks.
 type Config struct {
-	TrackType string `yaml:"track_type"` // Type of track: "ballast" or "slabtrack"
-	Frequency struct {
-		Min    float64 `yaml:"min"`    // Minimum angular frequency for calculation [rad/s]
-		Max    float64 `yaml:"max"`    // Maximum angular frequency for calculation [rad/s]
-		Points int     `yaml:"points"` // Number of angular frequency points to calculate
+	ConfigVersion int    `yaml:"config_version"` // Version of the layout of the file (see ConfigVersion; older files are upgraded)
+	TrackType     string `yaml:"track_type"`     // Type of track: "ballast", "slabtrack" or "piledslab"
+	Frequency     struct {
+		Min      float64            `yaml:"min"`      // Minimum frequency for calculation [unit]
+		Max      float64            `yaml:"max"`      // Maximum frequency for calculation [unit]
+		Points   int                `yaml:"points"`   // Number of frequency points to calculate
+		Unit     string             `yaml:"unit"`     // Unit of min and max and of the frequencies of the results: "rad/s" (default) or "Hz"
+		Segments []FrequencySegment `yaml:"segments"` // Ranges with their own number of points, concatenated (replace min, max and points)
 	} `yaml:"frequency"`
 	BallastTrack struct {
 		EIRail        float64 `yaml:"EI_rail"`        // Rail bending stiffness [N·m²]
@@ -36,6 +46,11 @@ type Config struct {
 		RhoBallast    float64 `yaml:"rho_ballast"`    // Ballast density [kg/m³]
 		SoilStiffness float64 `yaml:"soil_stiffness"` // Soil spring stiffness [N/m]
 	} `yaml:"ballast_track"`
+	BallastReinforcement struct {
+		Depths          []float64 `yaml:"depths"`           // Depths of the geogrids below the base of the sleepers [m] (the ballast is reinforced when given)
+		StiffnessFactor float64   `yaml:"stiffness_factor"` // Stiffness increase of the ballast in the interlocking zone of a geogrid (default 1.5)
+		ZoneThickness   float64   `yaml:"zone_thickness"`   // Thickness of the interlocking zone, centred on a geogrid [m] (default 0.15)
+	} `yaml:"ballast_reinforcement"`
 	SlabTrack struct {
 		EIRail        float64 `yaml:"EI_rail"`        // Rail bending stiffness [N·m²]
 		MRail         float64 `yaml:"m_rail"`         // Rail mass per unit length [kg/m]
@@ -45,19 +60,162 @@ type Config struct {
 		CRailPad      float64 `yaml:"c_rail_pad"`     // Railpad damping [N·s/m]
 		SoilStiffness float64 `yaml:"soil_stiffness"` // Soil spring stiffness [N/m]
 	} `yaml:"slab_track"`
-	SoilLayers []SoilLayer `yaml:"soil_layers"` // Array of soil layers
-	Output     struct {
-		FileName string `yaml:"file_name"` // Name of the output JSON file
+	Piles struct {
+		Spacing      float64 `yaml:"spacing"`       // Distance between the pile rows along the track [m]
+		Stiffness    float64 `yaml:"stiffness"`     // Vertical stiffness of the head of a pile row [N/m] (default from the properties of the piles)
+		Count        int     `yaml:"count"`         // Number of piles in a row (default 1)
+		YoungModulus float64 `yaml:"young_modulus"` // Young's modulus of the piles [Pa]
+		Diameter     float64 `yaml:"diameter"`      // Diameter of the piles [m]
+		Length       float64 `yaml:"length"`        // Length of the piles, down to the bearing layer [m]
+	} `yaml:"piles"`
+	SoilLayers   []SoilLayer        `yaml:"soil_layers"`   // Array of soil layers
+	SoilCPT      SoilCPT            `yaml:"soil_cpt"`      // CPT the soil layers are derived from (replaces soil_layers)
+	SoilProfile  SoilProfileFile    `yaml:"soil_profile"`  // Plain-text file the soil layers are read from (replaces soil_layers)
+	SoilProfiles []NamedSoilProfile `yaml:"soil_profiles"` // Soil profiles computed against the same track, e.g. of several boreholes (replaces the other soil sections)
+	Columns      struct {
+		YoungModulus float64 `yaml:"young_modulus"` // Young's modulus of the column material [Pa] (the ground is improved when it is not zero)
+		Density      float64 `yaml:"density"`       // Density of the column material [kg/m^3] (default the density of the soil)
+		PoissonRatio float64 `yaml:"poisson_ratio"` // Poisson's ratio of the column material (default that of the soil)
+		AreaRatio    float64 `yaml:"area_ratio"`    // Area replacement ratio: cross-section of a column over its tributary area
+		Depth        float64 `yaml:"depth"`         // Treated depth below the surface [m]
+	} `yaml:"columns"`
+	Embankment struct {
+		SideSlope    float64             `yaml:"side_slope"`    // Slope of the sides: horizontal over vertical distance (default 1.5)
+		YoungModulus float64             `yaml:"young_modulus"` // Young's modulus of the fill [Pa], unless given by a variant
+		Density      float64             `yaml:"density"`       // Density of the fill [kg/m^3] (default 2000)
+		PoissonRatio float64             `yaml:"poisson_ratio"` // Poisson's ratio of the fill (default 0.3)
+		Variants     []EmbankmentVariant `yaml:"variants"`      // Embankments built on the soil profile, whose critical speeds are compared
+		FileName     string              `yaml:"file_name"`     // CSV file of the critical speeds (default next to the result file, with suffix _embankment.csv)
+	} `yaml:"embankment"`
+	StressDependence struct {
+		AxleLoad         float64 `yaml:"axle_load"`         // Static axle load [N] (the moduli of the soil are adjusted for its stress when it is not zero)
+		Exponent         float64 `yaml:"exponent"`          // Exponent of the power law of the stiffness in the vertical stress (default 0.5)
+		LoadWidth        float64 `yaml:"load_width"`        // Width of the loaded area at the surface, across the track [m] (default twice width_sleeper, or 2.5 for slab tracks)
+		LoadLength       float64 `yaml:"load_length"`       // Length of the loaded area at the surface, along the track [m] (default 3)
+		GroundwaterDepth float64 `yaml:"groundwater_depth"` // Depth of the groundwater table below the surface [m]
+	} `yaml:"stress_dependence"`
+	SoilDiscretization struct {
+		MaxThickness       float64 `yaml:"max_thickness"`       // Largest thickness of the soil layers [m]; thicker layers but the halfspace are split
+		WavelengthFraction float64 `yaml:"wavelength_fraction"` // Largest thickness as a fraction of the shortest wavelength at the highest frequency
+	} `yaml:"soil_discretization"`
+	Solver struct {
+		RootFinder     string `yaml:"root_finder"`      // Root finder of the track dispersion: "polynomial" (closed form, default), "brent", "ridders" or "chandrupatla" (bracketing)
+		SoilRootFinder string `yaml:"soil_root_finder"` // Root finder refining the roots of the soil dispersion within their brackets: "brent", "ridders" or "chandrupatla" (default: none, the middle of the bracket or interpolated in fast mode)
+		Fast           bool   `yaml:"fast"`             // Fast approximate mode: coarse soil scan with interpolated roots (critical velocity within 1%)
+		Attenuation    bool   `yaml:"attenuation"`      // Complex wave numbers of the track with the railpad damping: phase velocity and spatial decay rate (written as CSV next to the result file, with suffix _attenuation.csv)
+		LeakyModes     bool   `yaml:"leaky_modes"`      // Leaky soil modes above the shear wave speed of the halfspace (written as CSV next to the result file, with suffix _leaky.csv)
+	} `yaml:"solver"`
+	MovingLoad struct {
+		Load               float64 `yaml:"load"`                 // Moving load on each rail [N] (the curve is computed when it is not zero)
+		SpeedMin           float64 `yaml:"speed_min"`            // Minimum speed of the load [m/s]
+		SpeedMax           float64 `yaml:"speed_max"`            // Maximum speed of the load [m/s]
+		SpeedPoints        int     `yaml:"speed_points"`         // Number of speeds
+		Damping            float64 `yaml:"damping"`              // Hysteretic damping ratio of the soil (default 0.05)
+		TrackWidth         float64 `yaml:"track_width"`          // Width of the track on the soil [m] (default twice width_sleeper, or 2.5 for slab tracks)
+		RailSectionModulus float64 `yaml:"rail_section_modulus"` // Section modulus of the rail [m³], for the rail stress (e.g. 3.77e-4 for the foot of a UIC60 rail)
+		FileName           string  `yaml:"file_name"`            // CSV file of the speed-amplification table (default next to the result file, with suffix _moving_load.csv)
+	} `yaml:"moving_load"`
+	GroundVibration struct {
+		Load      float64   `yaml:"load"`      // Load on each rail [N]
+		Speed     float64   `yaml:"speed"`     // Operating speed of the train [m/s]
+		Distances []float64 `yaml:"distances"` // Distances from the centre line of the track [m] (the vibration is estimated when given)
+		FileName  string    `yaml:"file_name"` // CSV file of the vibration levels (default next to the result file, with suffix _ground_vibration.csv)
+	} `yaml:"ground_vibration"`
+	Assessment struct {
+		Speeds          []float64 `yaml:"speeds"`            // Operating speeds of the trains to assess [m/s]
+		FileName        string    `yaml:"file_name"`         // CSV file of the Mach cones (default next to the result file, with suffix _mach.csv)
+		Spectrum        string    `yaml:"spectrum"`          // CSV file of a nominal excitation spectrum (frequency [Hz], amplitude), Doppler shifted for a stationary receiver
+		DopplerFileName string    `yaml:"doppler_file_name"` // CSV file of the received spectrum (default next to the result file, with suffix _doppler.csv)
+		BandTolerance   float64   `yaml:"band_tolerance"`    // Largest difference of the track and soil phase velocities in the critical band [%] (the band is reported when it is not zero)
+	} `yaml:"assessment"`
+	Irregularity struct {
+		WheelLoad        float64 `yaml:"wheel_load"`        // Static wheel load [N] (the wheel-rail force is computed when it is not zero)
+		PSD              string  `yaml:"psd"`               // Spectrum of the vertical irregularity of the rail: "german_low" (default) or "german_high"
+		File             string  `yaml:"file"`              // CSV file of the spectrum, with the columns wavenumber [rad/m] and psd [m²/(rad/m)] (instead of psd)
+		MinWavelength    float64 `yaml:"min_wavelength"`    // Shortest wavelength of the German spectra [m] (default 1)
+		MaxWavelength    float64 `yaml:"max_wavelength"`    // Longest wavelength of the German spectra [m] (default 80)
+		UnsprungMass     float64 `yaml:"unsprung_mass"`     // Unsprung mass on a rail, half of a wheelset [kg] (default 900)
+		ContactStiffness float64 `yaml:"contact_stiffness"` // Linearized Hertzian stiffness of the wheel-rail contact [N/m] (default 1.4e9)
+		SpeedMin         float64 `yaml:"speed_min"`         // Minimum speed [m/s]
+		SpeedMax         float64 `yaml:"speed_max"`         // Maximum speed [m/s]
+		SpeedPoints      int     `yaml:"speed_points"`      // Number of speeds
+		RegimeRatio      float64 `yaml:"regime_ratio"`      // Speeds from regime_ratio to 1 / regime_ratio times the critical speed are in the critical regime (default 0.7)
+		FileName         string  `yaml:"file_name"`         // CSV file of the force versus speed (default next to the result file, with suffix _irregularity.csv)
+	} `yaml:"irregularity"`
+	Train struct {
+		Vehicles      int     `yaml:"vehicles"`        // Number of vehicles (the load spectrum is assessed when it is not zero)
+		VehicleLength float64 `yaml:"vehicle_length"`  // Length of a vehicle over the couplings [m]
+		BogieSpacing  float64 `yaml:"bogie_spacing"`   // Distance between the bogie centres of a vehicle [m]
+		AxleSpacing   float64 `yaml:"axle_spacing"`    // Distance between the axles of a bogie [m]
+		AxleLoad      float64 `yaml:"axle_load"`       // Load of an axle [N]
+		MaxSpeedRatio float64 `yaml:"max_speed_ratio"` // Ratio of the speed to the phase velocity above which a load harmonic is flagged (default 0.7)
+		FileName      string  `yaml:"file_name"`       // CSV file of the load harmonics (default next to the result file, with suffix _train.csv)
+	} `yaml:"train"`
+	Improvement struct {
+		TargetSpeed     float64 `yaml:"target_speed"`     // Target critical speed [m/s] (the soil improvement is designed when it is not zero)
+		Parameter       string  `yaml:"parameter"`        // Solved parameter: "stiffness" (default), the factor on the Young's modulus of the layers, or "depth", the depth of the improved ground
+		Layers          []int   `yaml:"layers"`           // Improved layers with parameter stiffness, numbered from 1 at the surface (default all but the halfspace)
+		StiffnessFactor float64 `yaml:"stiffness_factor"` // Factor on the Young's modulus of the improved ground with parameter depth (default 2)
+		MaxFactor       float64 `yaml:"max_factor"`       // Largest stiffness factor searched (default 10)
+		MaxDepth        float64 `yaml:"max_depth"`        // Largest depth searched [m] (default the top of the halfspace)
+		FileName        string  `yaml:"file_name"`        // CSV file of the improved soil profile (default next to the result file, with suffix _improvement.csv)
+	} `yaml:"improvement"`
+	Output struct {
+		FileName  string `yaml:"file_name"` // Name of the output file (local path, or s3:// or gs:// URL), compressed with gzip when it ends with .gz
+		Format    string `yaml:"format"`    // Format of the output file: "json" (default), "traincritspeed", "protobuf", "xlsx" or "gob"
+		Precision int    `yaml:"precision"` // Significant digits of the numbers of the JSON result files and CSV tables (default all digits)
 	} `yaml:"output"`
+	Migrated  []string            `yaml:"-"` // Changes made upgrading the file from an older config_version (reported as warnings)
+	Positions map[string]Position `yaml:"-"` // Positions of the values in the YAML file by path, reported with the problems found
 }
 
-// DispersionResults defines the structure for storing calculation results
+// DispersionResults defines the structure for storing calculation results.
+// SchemaVersion identifies the layout of the JSON result files (see UpgradeResults).
+// The frequencies are angular frequencies [rad/s]; result files written in Hz name
+// them frequency and critical_frequency instead (see Result.WriteJSON).
 type DispersionResults struct {
-	Omega              []float64     `json:"omega"`
-	TrackPhaseVelocity []float64     `json:"track_phase_velocity"`
-	SoilPhaseVelocity  []interface{} `json:"soil_phase_velocity"`
-	CriticalOmega      float64       `json:"critical_omega"`
-	CriticalVelocity   float64       `json:"critical_velocity"`
+	SchemaVersion       int           `json:"schema_version"`
+	Omega               []float64     `json:"omega"`
+	TrackPhaseVelocity  []float64     `json:"track_phase_velocity"`
+	SoilPhaseVelocity   []interface{} `json:"soil_phase_velocity"`
+	CriticalOmega       float64       `json:"critical_omega"`
+	CriticalVelocity    float64       `json:"critical_velocity"`
+	CriticalOmegaMin    float64       `json:"critical_omega_min,omitempty"`
+	CriticalOmegaMax    float64       `json:"critical_omega_max,omitempty"`
+	CriticalVelocityMin float64       `json:"critical_velocity_min,omitempty"`
+	CriticalVelocityMax float64       `json:"critical_velocity_max,omitempty"`
+	SitePeriod          float64       `json:"site_period"`
+	ResonanceFrequency  float64       `json:"resonance_frequency"`
+}
+
+// Result holds the outcome of a critical speed analysis.
+type Result struct {
+	Omega              []float64    // Angular frequencies [rad/s]
+	TrackPhaseVelocity []float64    // Phase velocities of the track [m/s] (zero where no root is found)
+	SoilPhaseVelocity  []float64    // Phase velocities of the soil layers [m/s] (NaN where no root is found)
+	TrackDecayRate     []float64    // Spatial decay rates of the track waves [1/m] (zero where no root is found, nil without solver.attenuation)
+	SoilLeakyVelocity  []float64    // Phase velocities of the leaky soil mode [m/s] (NaN where none is found, nil without solver.leaky_modes)
+	CriticalOmega      float64      // Critical angular frequency [rad/s]
+	CriticalVelocity   float64      // Critical train speed [m/s]
+	CriticalBand       CriticalBand // Frequencies and speeds around the critical speed where the track and soil curves are close (zero without assessment.band_tolerance)
+	SitePeriod         float64      // Fundamental period of the soil layers above the halfspace [s] (zero for a homogeneous halfspace)
+	ResonanceFrequency float64      // Resonance frequency of the site, the inverse of SitePeriod [Hz] (zero for a homogeneous halfspace)
+	FrequencyUnit      string       // Unit of the frequencies in the result files: UnitRadPerSecond (also when empty) or UnitHertz
+	Precision          int          // Significant digits of the numbers in the JSON result files and CSV tables (0 for all digits)
+	UntreatedOmega     float64      // Critical angular frequency of the soil layers without the columns [rad/s] (zero without a columns section)
+	UntreatedVelocity  float64      // Critical train speed of the soil layers without the columns [m/s] (zero without a columns section)
+	UnloadedOmega      float64      // Critical angular frequency of the soil layers without the stress of the axle load [rad/s] (zero without a stress_dependence section)
+	UnloadedVelocity   float64      // Critical train speed of the soil layers without the stress of the axle load [m/s] (zero without a stress_dependence section)
+
+	MovingLoad      []moving_load.Point          // Deflection and bending moment of the rail versus the speed of the moving load (nil without a moving_load section)
+	GroundVibration []moving_load.VibrationPoint // Free-field ground vibration at distances from the track (nil without a ground_vibration section)
+	MachCones       []MachCone                   // Mach cones of the operating speeds (nil without an assessment section)
+	Doppler         []DopplerLine                // Excitation spectrum received next to the track at the operating speeds (nil without a spectrum)
+	TrainExcitation []LoadHarmonic               // Dominant load harmonics of the train at the operating speeds (nil without a train section)
+	Irregularity    []IrregularitySpeed          // Dynamic wheel-rail force of the irregularity of the rail versus speed (nil without an irregularity section)
+	Improvement     *ImprovementDesign           // Soil improvement required for the target critical speed (nil without an improvement section)
+	Embankments     []EmbankmentCase             // Critical speeds of the embankment variants (nil without an embankment section)
+	Profiles        []ProfileCase                // Soil curves and critical speeds of the soil profiles, the first being that of the other results (nil without a soil_profiles section)
 }
 
 // SoilLayer defines the structure for a soil layer
@@ -68,6 +226,25 @@ type SoilLayer struct {
 	PoissonRatio float64 `yaml:"poisson_ratio"` // Poisson's ratio of the soil layer
 }
 
+// SoilCPT defines the cone penetration test the soil layers are derived from.
+type SoilCPT struct {
+	File             string  `yaml:"file"`              // GEF CPT file the soil layers are derived from (replaces soil_layers)
+	Provider         string  `yaml:"provider"`          // Geo-database the CPT closest to the location is fetched from, e.g. "bro" (instead of file)
+	Latitude         float64 `yaml:"latitude"`          // Latitude (WGS84) of the site, with provider [degrees]
+	Longitude        float64 `yaml:"longitude"`         // Longitude (WGS84) of the site, with provider [degrees]
+	Radius           float64 `yaml:"radius"`            // Search radius around the site, with provider [m] (default 500)
+	Correlation      string  `yaml:"correlation"`       // Shear wave velocity correlation: "robertson" (default) or "mayne"
+	LayerThickness   float64 `yaml:"layer_thickness"`   // Thickness of the derived layers [m] (default 1)
+	PoissonRatio     float64 `yaml:"poisson_ratio"`     // Poisson's ratio of the derived layers (default 0.35)
+	GroundwaterDepth float64 `yaml:"groundwater_depth"` // Depth of the groundwater table below the surface [m]
+}
+
+// SoilProfileFile defines the plain-text file the soil layers are read from (see
+// ParseSoilProfile).
+type SoilProfileFile struct {
+	File string `yaml:"file"` // Plain-text file of the soil layers: thickness, Vs, Vp or Poisson's ratio, and density per line
+}
+
 // createBallastTrackParams creates ballast track parameters from config.
 //
 // Parameters:
@@ -90,6 +267,41 @@ func createBallastTrackParams(config Config) track_dispersion.BallastTrackParame
 	}
 }
 
+// Defaults of the ballast_reinforcement section of a configuration.
+const (
+	defaultGeogridStiffnessFactor = 1.5  // Stiffness increase of the ballast in the interlocking zone of a geogrid
+	defaultGeogridZoneThickness   = 0.15 // Thickness of the interlocking zone of a geogrid [m]
+)
+
+// reinforceBallastTrack applies the geogrids of the ballast_reinforcement section of a
+// configuration to the parameters of a ballast track (see
+// track_dispersion.BallastTrackParameters.Reinforce).
+//
+// Parameters:
+//   - config: The configuration structure
+//   - params: The parameters of the ballast track
+//
+// Returns:
+//   - track_dispersion.BallastTrackParameters: The parameters of the reinforced track (params without geogrids)
+//   - float64: The factor on the Young's modulus of the ballast (1 without geogrids)
+//   - error: An error if the section is invalid
+func reinforceBallastTrack(config Config, params track_dispersion.BallastTrackParameters) (track_dispersion.BallastTrackParameters, float64, error) {
+	section := config.BallastReinforcement
+	if len(section.Depths) == 0 {
+		return params, 1, nil
+	}
+	reinforcement := track_dispersion.BallastReinforcement{
+		Depths:          section.Depths,
+		StiffnessFactor: cmp.Or(section.StiffnessFactor, defaultGeogridStiffnessFactor),
+		ZoneThickness:   cmp.Or(section.ZoneThickness, defaultGeogridZoneThickness),
+	}
+	reinforced, err := params.Reinforce(reinforcement)
+	if err != nil {
+		return params, 0, classify(KindConfig, err)
+	}
+	return reinforced, reinforced.EBallast / params.EBallast, nil
+}
+
 // createSlabTrackParams creates slab track parameters from config.
 //
 // Parameters:
@@ -109,6 +321,37 @@ func createSlabTrackParams(config Config) track_dispersion.SlabTrackParameters {
 	}
 }
 
+// createPiledSlabTrackParams creates the parameters of a slab track on piles from
+// config: the slab_track parameters, with the pile rows of the piles section. Without
+// the stiffness of a pile row, it is the axial stiffness of its end-bearing piles (see
+// track_dispersion.PileRowStiffness).
+//
+// Parameters:
+//   - config: The configuration structure containing slab track and pile parameters
+//
+// Returns:
+//   - track_dispersion.PiledSlabTrackParameters: A struct with parameters for piled slab track dispersion calculations
+//   - error: An error if the piles section is invalid
+func createPiledSlabTrackParams(config Config) (track_dispersion.PiledSlabTrackParameters, error) {
+	piles := config.Piles
+	stiffness := piles.Stiffness
+	if stiffness == 0 {
+		if !(piles.YoungModulus > 0) || !(piles.Diameter > 0) || !(piles.Length > 0) || piles.Count < 0 {
+			return track_dispersion.PiledSlabTrackParameters{}, classify(KindConfig, fieldError("piles", fmt.Errorf("invalid piles: give the stiffness of a pile row, "+
+				"or the young_modulus, diameter and length of the piles")))
+		}
+		stiffness = track_dispersion.PileRowStiffness(max(piles.Count, 1), piles.YoungModulus, piles.Diameter, piles.Length)
+	}
+	if !(stiffness > 0) || !(piles.Spacing > 0) {
+		return track_dispersion.PiledSlabTrackParameters{}, classify(KindConfig, fieldError("piles", fmt.Errorf("invalid piles: the stiffness and spacing must be positive")))
+	}
+	return track_dispersion.PiledSlabTrackParameters{
+		SlabTrackParameters: createSlabTrackParams(config),
+		PileStiffness:       stiffness,
+		PileSpacing:         piles.Spacing,
+	}, nil
+}
+
 // createSoilLayers converts the soil layers from the config to soil_dispersion.Layer format
 //
 // Parameters:
@@ -116,10 +359,14 @@ func createSlabTrackParams(config Config) track_dispersion.SlabTrackParameters {
 //
 // Returns:
 //   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
-func createSoilLayers(config Config) []soil_dispersion.Layer {
+//   - error: An error if a layer is invalid (see checkSoilLayer)
+func createSoilLayers(config Config) ([]soil_dispersion.Layer, error) {
 	layers := make([]soil_dispersion.Layer, len(config.SoilLayers))
 
 	for i, soilLayer := range config.SoilLayers {
+		if err := checkSoilLayer(soilLayer, i, i == len(config.SoilLayers)-1); err != nil {
+			return nil, err
+		}
 		layer := soil_dispersion.Layer{
 			Thickness:     soilLayer.Thickness,
 			Density:       soilLayer.Density,
@@ -130,28 +377,276 @@ func createSoilLayers(config Config) []soil_dispersion.Layer {
 		layers[i] = layer
 	}
 
-	return layers
+	return layers, nil
+}
+
+// checkSoilLayer checks the properties of a layer of the soil_layers section of a
+// configuration: a positive, finite thickness (except for the halfspace, whose
+// thickness is ignored), density and Young's modulus, and a Poisson's ratio within
+// (-1, 0.5], the range of stable isotropic materials.
+//
+// Parameters:
+//   - layer: The soil layer
+//   - index: Index of the layer in soil_layers, from 0 at the surface
+//   - halfspace: Whether the layer is the last one, the halfspace
+//
+// Returns:
+//   - error: An error naming the first invalid property of the layer
+func checkSoilLayer(layer SoilLayer, index int, halfspace bool) error {
+	invalid := func(key string, format string, value float64) error {
+		return classify(KindConfig, fieldError(fmt.Sprintf("soil_layers.%d.%s", index, key),
+			fmt.Errorf("invalid soil layer %d: "+format, index+1, value)))
+	}
+	switch {
+	case !halfspace && !(layer.Thickness > 0 && !math.IsInf(layer.Thickness, 1)):
+		return invalid("thickness", "the thickness must be positive, got %g m", layer.Thickness)
+	case !(layer.Density > 0 && !math.IsInf(layer.Density, 1)):
+		return invalid("density", "the density must be positive, got %g kg/m³", layer.Density)
+	case !(layer.YoungModulus > 0 && !math.IsInf(layer.YoungModulus, 1)):
+		return invalid("young_modulus", "the Young's modulus must be positive, got %g Pa", layer.YoungModulus)
+	case !(layer.PoissonRatio > -1 && layer.PoissonRatio <= 0.5):
+		return invalid("poisson_ratio", "the Poisson's ratio must be within (-1, 0.5], got %g", layer.PoissonRatio)
+	}
+	return nil
+}
+
+// createCPTSoilLayers derives the soil layers from the CPT of the soil_cpt section
+// of the config (see cpt.Layers): the GEF file, or the CPT closest to the location
+// fetched from the provider (see geodata.FetchCPT).
+//
+// Parameters:
+//   - ctx: Context cancelling the requests to the provider
+//   - config: The configuration structure with the soil_cpt section
+//
+// Returns:
+//   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
+//   - string: Description of the CPT, e.g. its file
+//   - error: An error if soil_layers are given as well, or the CPT cannot be read or converted
+func createCPTSoilLayers(ctx context.Context, config Config) ([]soil_dispersion.Layer, string, error) {
+	if len(config.SoilLayers) > 0 {
+		return nil, "", classify(KindConfig, fieldError("soil_cpt", fmt.Errorf("soil_layers and soil_cpt cannot be used together")))
+	}
+
+	var test cpt.CPT
+	source := config.SoilCPT.File
+	if config.SoilCPT.Provider != "" {
+		if config.SoilCPT.File != "" {
+			return nil, "", classify(KindConfig, fieldError("soil_cpt", fmt.Errorf("soil_cpt file and provider cannot be used together")))
+		}
+		if _, err := geodata.ProviderByName(config.SoilCPT.Provider); err != nil {
+			return nil, "", classify(KindConfig, err)
+		}
+		location := geodata.Location{Latitude: config.SoilCPT.Latitude, Longitude: config.SoilCPT.Longitude}
+		sounding, err := geodata.FetchCPT(ctx, config.SoilCPT.Provider, location, config.SoilCPT.Radius)
+		if err != nil {
+			return nil, "", classify(KindIO, fmt.Errorf("error fetching CPT from %s: %w", config.SoilCPT.Provider, err))
+		}
+		test = sounding.CPT
+		source = fmt.Sprintf("%s %s (%.0f m from the site)", config.SoilCPT.Provider, sounding.ID, sounding.Distance)
+	} else {
+		var err error
+		if test, err = cpt.LoadGEF(config.SoilCPT.File); err != nil {
+			return nil, "", classify(KindConfig, err)
+		}
+	}
+
+	layers, err := cpt.Layers(test, cpt.LayerOptions{
+		Correlation:      config.SoilCPT.Correlation,
+		LayerThickness:   config.SoilCPT.LayerThickness,
+		PoissonRatio:     config.SoilCPT.PoissonRatio,
+		GroundwaterDepth: config.SoilCPT.GroundwaterDepth,
+	})
+	if err != nil {
+		return nil, "", classify(KindConfig, fmt.Errorf("error deriving soil layers from CPT %s: %v", source, err))
+	}
+	return layers, source, nil
+}
+
+// SoilLayers returns the soil profile of a configuration: the soil_layers, the layers
+// read from the file of the soil_profile section, or the layers derived from the CPT
+// of the soil_cpt section, with the ground improved by the columns of the columns
+// section, split as given by the soil_discretization section. The wave speeds of the
+// layers are computed.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
+//   - error: An error if a layer is invalid, or the layers cannot be read or derived from the CPT
+func SoilLayers(config Config) ([]soil_dispersion.Layer, error) {
+	layers, _, err := loadSoilLayers(context.Background(), config)
+	return layers, err
+}
+
+// loadSoilLayers returns the soil profile of a configuration, as SoilLayers does, with
+// the description of the CPT it is derived from. The columns are inserted before the
+// profile is discretized, and the profile is stiffened under the axle load after.
+//
+// Parameters:
+//   - ctx: Context cancelling the requests to a soil_cpt provider
+//   - config: The configuration structure
+//
+// Returns:
+//   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
+//   - string: Description of the CPT (empty for soil_layers)
+//   - error: An error if the layers cannot be derived from the CPT
+func loadSoilLayers(ctx context.Context, config Config) ([]soil_dispersion.Layer, string, error) {
+	layers, source, err := loadSoilProfile(ctx, config)
+	if err != nil {
+		return nil, "", err
+	}
+	if layers, err = improveSoilLayers(config, layers); err != nil {
+		return nil, "", err
+	}
+	if layers, err = discretizeSoilLayers(config, layers); err != nil {
+		return nil, "", err
+	}
+	if layers, err = stiffenSoilLayers(config, layers); err != nil {
+		return nil, "", err
+	}
+	return layers, source, nil
+}
+
+// improveSoilLayers inserts the ground improved by the columns of the columns section
+// of a configuration into the soil profile (see soil_dispersion.ImproveWithColumns).
+//
+// Parameters:
+//   - config: The configuration structure
+//   - layers: The soil profile, with its wave speeds computed
+//
+// Returns:
+//   - []soil_dispersion.Layer: The improved profile (the layers without a columns section)
+//   - error: An error if the section is invalid
+func improveSoilLayers(config Config, layers []soil_dispersion.Layer) ([]soil_dispersion.Layer, error) {
+	section := config.Columns
+	if section.YoungModulus == 0 {
+		return layers, nil
+	}
+	improved, err := soil_dispersion.ImproveWithColumns(layers, soil_dispersion.Columns{
+		YoungsModulus: section.YoungModulus,
+		Density:       section.Density,
+		PoissonRatio:  section.PoissonRatio,
+		AreaRatio:     section.AreaRatio,
+		Depth:         section.Depth,
+	})
+	if err != nil {
+		return nil, classify(KindConfig, err)
+	}
+	return improved, nil
+}
+
+// loadSoilProfile returns the soil layers of soil_layers, read from the file of
+// soil_profile, or derived from the CPT of soil_cpt, before discretization.
+//
+// Parameters:
+//   - ctx: Context cancelling the requests to a soil_cpt provider
+//   - config: The configuration structure
+//
+// Returns:
+//   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
+//   - string: Description of the CPT (empty for soil_layers and soil_profile)
+//   - error: An error if a layer is invalid, or the layers cannot be read or derived from the CPT
+func loadSoilProfile(ctx context.Context, config Config) ([]soil_dispersion.Layer, string, error) {
+	if config.SoilProfile.File != "" {
+		layers, err := createProfileSoilLayers(config)
+		return layers, "", err
+	}
+	if config.SoilCPT.File != "" || config.SoilCPT.Provider != "" {
+		return createCPTSoilLayers(ctx, config)
+	}
+	layers, err := createSoilLayers(config)
+	return layers, "", err
+}
+
+// discretizeSoilLayers splits the soil layers thicker than the largest thickness of
+// the soil_discretization section of a configuration (see soil_dispersion.Discretize).
+// With a wavelength fraction, the largest thickness is that fraction of the shortest
+// wavelength at the highest frequency (see soil_dispersion.ShortestWavelength); with
+// both, the smaller thickness is used.
+//
+// Parameters:
+//   - config: The configuration structure
+//   - layers: The soil profile, with its wave speeds computed
+//
+// Returns:
+//   - []soil_dispersion.Layer: The discretized profile (the layers without a soil_discretization section)
+//   - error: An error if the section is invalid
+func discretizeSoilLayers(config Config, layers []soil_dispersion.Layer) ([]soil_dispersion.Layer, error) {
+	section := config.SoilDiscretization
+	if section.MaxThickness < 0 || section.WavelengthFraction < 0 {
+		return nil, classify(KindConfig, fieldError("soil_discretization", fmt.Errorf("invalid soil_discretization: max_thickness and wavelength_fraction must not be negative")))
+	}
+	maxThickness := math.Inf(1)
+	if section.MaxThickness > 0 {
+		maxThickness = section.MaxThickness
+	}
+	if section.WavelengthFraction > 0 {
+		omega, err := Frequencies(config)
+		if err != nil {
+			return nil, err
+		}
+		if len(omega) == 0 {
+			return nil, classify(KindConfig, fieldError("soil_discretization", fmt.Errorf("invalid soil_discretization: wavelength_fraction requires a frequency range")))
+		}
+		maxThickness = math.Min(maxThickness, section.WavelengthFraction*soil_dispersion.ShortestWavelength(layers, math.Max(omega[0], omega[len(omega)-1])))
+	}
+	if math.IsInf(maxThickness, 1) {
+		return layers, nil
+	}
+	discretized, err := soil_dispersion.Discretize(layers, maxThickness)
+	if err != nil {
+		return nil, classify(KindConfig, err)
+	}
+	return discretized, nil
 }
 
-// saveResults saves the calculation results to a JSON file.
-// The function creates directories as needed and writes the results
-// in a structured JSON format.
+// Defaults of the stress_dependence section of a configuration.
+const (
+	defaultStressExponent = 0.5 // Exponent of the power law of the stiffness in the vertical stress
+	defaultLoadLength     = 3   // Length of track over which the rail spreads an axle load [m]
+)
+
+// stiffenSoilLayers adjusts the moduli of the soil layers for the stress of the axle
+// load of the stress_dependence section of a configuration (see
+// soil_dispersion.StiffenUnderLoad). The load is spread over the width of the track.
 //
 // Parameters:
-//   - omega: Array of angular frequencies [rad/s]
-//   - trackPhaseVelocity: Array of phase velocities for the track [m/s]
-//   - soilPhaseVelocity: Array of phase velocities for the soil layers [m/s], can contain NaN values
-//   - criticalOmega: Critical angular frequency [rad/s]
-//   - criticalSpeed: Critical train speed [m/s]
-//   - fileName: Path and name of the output JSON file
+//   - config: The configuration structure
+//   - layers: The soil profile, with its wave speeds computed
 //
 // Returns:
-//   - error: An error if the file cannot be written
-func saveResults(omega []float64, trackPhaseVelocity []float64, soilPhaseVelocity []float64, criticalOmega float64, criticalSpeed float64, fileName string) error {
+//   - []soil_dispersion.Layer: The stiffened profile (the layers without an axle load)
+//   - error: An error if the section is invalid
+func stiffenSoilLayers(config Config, layers []soil_dispersion.Layer) ([]soil_dispersion.Layer, error) {
+	section := config.StressDependence
+	if section.AxleLoad == 0 {
+		return layers, nil
+	}
+	stiffened, err := soil_dispersion.StiffenUnderLoad(layers, soil_dispersion.AxleLoad{
+		Load:             section.AxleLoad,
+		Width:            cmp.Or(section.LoadWidth, trackWidth(config)),
+		Length:           cmp.Or(section.LoadLength, defaultLoadLength),
+		Exponent:         cmp.Or(section.Exponent, defaultStressExponent),
+		GroundwaterDepth: section.GroundwaterDepth,
+	})
+	if err != nil {
+		return nil, classify(KindConfig, err)
+	}
+	return stiffened, nil
+}
+
+// DispersionResults converts the result to the structure written to JSON result files.
+// NaN values in the soil phase velocity are replaced by the string "NaN", since JSON
+// has no representation for them.
+//
+// Returns:
+//   - DispersionResults: The result in JSON output format
+func (r Result) DispersionResults() DispersionResults {
 
 	// Deal with math.NaN in soilPhaseVelocity
 	var safeValues []interface{}
-	for _, v := range soilPhaseVelocity {
+	for _, v := range r.SoilPhaseVelocity {
 		if math.IsNaN(v) {
 			safeValues = append(safeValues, "NaN")
 		} else {
@@ -159,63 +654,393 @@ func saveResults(omega []float64, trackPhaseVelocity []float64, soilPhaseVelocit
 		}
 	}
 
-	// Prepare results struct
-	results := DispersionResults{
-		Omega:              omega,
-		TrackPhaseVelocity: trackPhaseVelocity,
-		SoilPhaseVelocity:  safeValues,
-		CriticalOmega:      criticalOmega,
-		CriticalVelocity:   criticalSpeed,
+	return DispersionResults{
+		SchemaVersion:       SchemaVersion,
+		Omega:               r.Omega,
+		TrackPhaseVelocity:  r.TrackPhaseVelocity,
+		SoilPhaseVelocity:   safeValues,
+		CriticalOmega:       r.CriticalOmega,
+		CriticalVelocity:    r.CriticalVelocity,
+		CriticalOmegaMin:    r.CriticalBand.OmegaMin,
+		CriticalOmegaMax:    r.CriticalBand.OmegaMax,
+		CriticalVelocityMin: r.CriticalBand.VelocityMin,
+		CriticalVelocityMax: r.CriticalBand.VelocityMax,
+		SitePeriod:          r.SitePeriod,
+		ResonanceFrequency:  r.ResonanceFrequency,
 	}
+}
 
-	jsonData, err := json.MarshalIndent(results, "", "\t")
+// Units of the frequencies, supported by the frequency.unit configuration field. The
+// analysis uses angular frequencies; in Hz, the frequency range is converted to them
+// and the frequencies of the JSON and Excel result files are converted back.
+const (
+	UnitRadPerSecond = "rad/s" // Angular frequency ω [rad/s]
+	UnitHertz        = "Hz"    // Frequency f = ω / 2π [Hz]
+)
+
+// FrequencyAxis returns how the frequencies of the result are presented in its result
+// files and displays: the name of the field, its unit and the factor converting the
+// angular frequencies of the result to it.
+//
+// Returns:
+//   - string: Name of the frequencies ("omega", or "frequency" in Hz)
+//   - string: Unit of the frequencies (UnitRadPerSecond or UnitHertz)
+//   - float64: Factor converting angular frequencies [rad/s] to the unit
+func (r Result) FrequencyAxis() (string, string, float64) {
+	if r.FrequencyUnit == UnitHertz {
+		return "frequency", UnitHertz, 1 / (2 * math.Pi)
+	}
+	return "omega", UnitRadPerSecond, 1
+}
+
+// FrequencySegment defines a range of frequencies of the segments of the frequency
+// section of a configuration, with its own density of points.
+type FrequencySegment struct {
+	Min    float64 `yaml:"min"`    // Lowest frequency of the segment [unit]
+	Max    float64 `yaml:"max"`    // Highest frequency of the segment [unit]
+	Points int     `yaml:"points"` // Number of frequencies of the segment, including min and max
+}
+
+// frequencySegments returns the ranges of frequencies of the frequency section of a
+// configuration: its segments, or the single range of min, max and points.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - []FrequencySegment: The ranges, in increasing order [unit]
+//   - error: An error if the segments are combined with min, max and points, or a
+//     segment is invalid or overlaps the previous one
+func frequencySegments(config Config) ([]FrequencySegment, error) {
+	section := config.Frequency
+	if len(section.Segments) == 0 {
+		return []FrequencySegment{{Min: section.Min, Max: section.Max, Points: section.Points}}, nil
+	}
+	if section.Min != 0 || section.Max != 0 || section.Points != 0 {
+		return nil, classify(KindConfig, fieldError("frequency", fmt.Errorf("invalid frequency range: segments cannot be used together with min, max and points")))
+	}
+	for i, segment := range section.Segments {
+		if segment.Points < 2 || !(segment.Min >= 0) || !(segment.Max > segment.Min) {
+			return nil, classify(KindConfig, fieldError(fmt.Sprintf("frequency.segments.%d", i),
+				fmt.Errorf("invalid frequency segment %d: at least two points and 0 <= min < max are required", i+1)))
+		}
+		if i > 0 && segment.Min < section.Segments[i-1].Max {
+			return nil, classify(KindConfig, fieldError(fmt.Sprintf("frequency.segments.%d", i),
+				fmt.Errorf("invalid frequency segment %d: segments must follow each other in increasing order without overlap", i+1)))
+		}
+	}
+	return section.Segments, nil
+}
+
+// Frequencies returns the angular frequencies of the analysis from the frequency
+// section of a configuration: min, max and points, or the concatenated segments. A
+// segment starting at the end of the previous one does not repeat that frequency.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - []float64: The angular frequencies [rad/s]
+//   - error: An error if the unit is not supported or the segments are invalid
+func Frequencies(config Config) ([]float64, error) {
+	scale := 1.0
+	switch config.Frequency.Unit {
+	case "", UnitRadPerSecond:
+	case UnitHertz:
+		scale = 2 * math.Pi
+	default:
+		return nil, classify(KindConfig, fieldError("frequency.unit", fmt.Errorf("invalid frequency unit: %s. Supported units are '%s' or '%s'",
+			config.Frequency.Unit, UnitRadPerSecond, UnitHertz)))
+	}
+	segments, err := frequencySegments(config)
 	if err != nil {
-		log.Fatalf("Error marshaling to JSON: %v", err)
+		return nil, err
+	}
+	omega := []float64{}
+	for _, segment := range segments {
+		points := math_utils.Linspace(segment.Min, segment.Max, segment.Points)
+		if len(omega) > 0 && len(points) > 0 && points[0]*scale == omega[len(omega)-1] {
+			points = points[1:]
+		}
+		for _, f := range points {
+			omega = append(omega, f*scale)
+		}
+	}
+	return omega, nil
+}
+
+// Formats of the result file, supported by the output.format configuration field.
+const (
+	FormatJSON           = "json"           // JSON (see DispersionResults)
+	FormatTrainCritSpeed = "traincritspeed" // JSON in the layout of the Python TrainCritSpeed (see Result.WriteTrainCritSpeed)
+	FormatProtobuf       = "protobuf"       // Protocol buffer gotrain.v1.Result message (see Result.MarshalProto)
+	FormatXLSX           = "xlsx"           // Excel workbook with curves and summary sheets (see Result.MarshalXLSX)
+	FormatGob            = "gob"            // Compact binary encoding of the curves for very large sweeps (see Result.WriteGob)
+)
+
+// checkFormat checks that a result file format is supported.
+//
+// Parameters:
+//   - format: The format (empty means FormatJSON)
+//
+// Returns:
+//   - error: An error if the format is not supported
+func checkFormat(format string) error {
+	if format != "" && format != FormatJSON && format != FormatTrainCritSpeed && format != FormatProtobuf && format != FormatXLSX && format != FormatGob {
+		return classify(KindConfig, fieldError("output.format", fmt.Errorf("invalid output format: %s. Supported formats are '%s', '%s', '%s', '%s' or '%s'",
+			format, FormatJSON, FormatTrainCritSpeed, FormatProtobuf, FormatXLSX, FormatGob)))
+	}
+	return nil
+}
+
+// checkPrecision checks the number of significant digits of the result files.
+//
+// Parameters:
+//   - precision: The number of significant digits (zero means all digits)
+//
+// Returns:
+//   - error: An error if the number is negative or exceeds the digits of a float64
+func checkPrecision(precision int) error {
+	if precision < 0 || precision > maxPrecision {
+		return classify(KindConfig, fieldError("output.precision", fmt.Errorf("invalid output precision: %d. Give 1 to %d significant digits, or 0 for all digits",
+			precision, maxPrecision)))
+	}
+	return nil
+}
+
+// checkSoilRootFinder checks the root finder refining the roots of the soil
+// dispersion, which must be a bracketing root finder.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - error: An error if solver.soil_root_finder is not a supported bracketing root finder
+func checkSoilRootFinder(config Config) error {
+	if config.Solver.SoilRootFinder == "" {
+		return nil
+	}
+	if _, err := math_utils.RootFinderByName(config.Solver.SoilRootFinder); err != nil {
+		return classify(KindConfig, fieldError("solver.soil_root_finder", err))
+	}
+	return nil
+}
+
+// saveResults saves the calculation results to a file.
+// The function creates directories as needed, or uploads the file when its name is an
+// s3:// or gs:// URL, and writes the results in a structured JSON format, in the JSON
+// layout of TrainCritSpeed, as a protocol buffer message, as an Excel workbook or as a
+// gob stream. The JSON files and the gob stream are encoded directly into the file
+// (see Result.WriteJSON, Result.WriteTrainCritSpeed and Result.WriteGob), so that
+// they are written (or uploaded in parts) while they are being serialized, with
+// bounded memory for very large frequency grids.
+//
+// Parameters:
+//   - result: The computed dispersion curves and critical speed
+//   - config: The configuration, with the output file name and format (empty means FormatJSON)
+//
+// Returns:
+//   - error: An error if the file cannot be written or the format is not supported
+func saveResults(result Result, config Config) error {
+	fileName, format := config.Output.FileName, config.Output.Format
+	if err := checkFormat(format); err != nil {
+		return err
 	}
 
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(fileName)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("Error creating directory: %v", err)
+	// The curves are written value by value, except in the message and the workbook
+	write := result.WriteJSON
+	switch format {
+	case FormatTrainCritSpeed:
+		write = result.WriteTrainCritSpeed
+	case FormatProtobuf:
+		write = result.WriteProto
+	case FormatGob:
+		write = result.WriteGob
+	case FormatXLSX:
+		data, err := result.MarshalXLSX(config)
+		if err != nil {
+			return classify(KindIO, err)
+		}
+		write = func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
 		}
 	}
 
-	// Write to file
-	err = os.WriteFile(fileName, jsonData, 0644)
+	// Write to the file, creating its directory if it doesn't exist, or to the bucket
+	file, err := storage.Create(fileName)
+	if err != nil {
+		return classify(KindIO, fmt.Errorf("error writing results to file: %v", err))
+	}
+	out, flush := compressWriter(file, fileName)
+	err = write(out)
+	if flushErr := flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		log.Fatalf("Error writing JSON to file: %v", err)
+		return classify(KindIO, fmt.Errorf("error writing results to file: %v", err))
 	}
 	return nil
 }
 
-// loadConfig loads the configuration from a YAML file.
+// SaveResults writes the result file of a configuration, the result files of its soil
+// profiles and the CSV tables next to it, as RunConfig does after the analysis. It lets callers that computed a result
+// once write it for other configurations giving the same result, e.g. the
+// duplicate configurations of a batch.
 //
 // Parameters:
-//   - configPath: Path to the YAML configuration file
+//   - result: The computed result
+//   - config: The configuration, with the output file name and format and the file names of the tables
+//
+// Returns:
+//   - error: An error if a file cannot be written or the format is not supported
+func SaveResults(result Result, config Config) error {
+	result.Precision = config.Output.Precision
+	if err := saveResults(result, config); err != nil {
+		return fmt.Errorf("error saving results: %w", err)
+	}
+	if err := saveProfileResults(result, config); err != nil {
+		return fmt.Errorf("error saving results: %w", err)
+	}
+	for _, table := range resultTables(result, config) {
+		if err := saveTable(table.fileName, table.write); err != nil {
+			return fmt.Errorf("error saving results: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig loads the configuration from a YAML file. Values given by environment
+// variables prefixed with GOTRAIN_ override those of the file (see ApplyEnv).
+//
+// Parameters:
+//   - configPath: Path to the YAML configuration file, or s3:// or gs:// URL
 //
 // Returns:
 //   - Config: The loaded configuration structure
-//   - error: An error if the file cannot be read or parsed
-func loadConfig(configPath string) (Config, error) {
+//   - error: An error if the file cannot be read or parsed, or an environment variable
+//     does not override a value of the configuration
+func LoadConfig(configPath string) (Config, error) {
 
 	var config Config
 
 	// Read the configuration file
-	data, err := os.ReadFile(configPath)
+	data, err := storage.ReadFile(configPath)
+	if err != nil {
+		return config, classify(KindIO, fmt.Errorf("failed to read config file: %v", err))
+	}
+
+	config, err = ParseConfig(data)
 	if err != nil {
-		return config, fmt.Errorf("failed to read config file: %v", err)
+		return config, err
 	}
 
+	// Override the values given by GOTRAIN_ environment variables
+	if _, err := ApplyEnv(&config, os.Environ()); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// ParseConfig parses a configuration from YAML data. Files of an older
+// config_version are upgraded to the layout of ConfigVersion, with the changes listed
+// in Config.Migrated, and references ${name} to the variables of a variables section
+// are replaced by their values (see substituteVariables). Values that do not fit their
+// fields are reported with their line, column and path, and the positions of the
+// values are kept in Config.Positions to locate the problems found later.
+//
+// Parameters:
+//   - data: Content of a YAML configuration file
+//
+// Returns:
+//   - Config: The parsed configuration structure
+//   - error: An error if the data cannot be parsed
+func ParseConfig(data []byte) (Config, error) {
+
+	var config Config
+
 	// Parse YAML data
-	err = yaml.Unmarshal(data, &config)
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return config, classify(KindConfig, fmt.Errorf("failed to parse YAML: %v", err))
+	}
+	if document.Kind == 0 {
+		return config, nil
+	}
+
+	// Upgrade the layout of older files, then replace the references to the variables
+	migrated, err := migrateConfig(&document)
 	if err != nil {
-		return config, fmt.Errorf("failed to parse YAML: %v", err)
+		return config, classify(KindConfig, err)
 	}
+	if err := substituteVariables(&document); err != nil {
+		return config, classify(KindConfig, fmt.Errorf("invalid variables: %v", err))
+	}
+	if err := document.Decode(&config); err != nil {
+		if located := decodeErrors(&document); located != nil {
+			err = located
+		}
+		return config, classify(KindConfig, fmt.Errorf("failed to parse YAML: %v", err))
+	}
+	config.Migrated = migrated
+	config.Positions = configPositions(&document)
 
 	return config, nil
 }
 
+// Options controls optional behaviour of RunWithOptions.
+type Options struct {
+	Verbose        bool                   // If true, prints the result location and solver warnings to stdout
+	LogFile        bool                   // If true, writes a log (solver warnings, timings) next to the result file
+	SkipResultFile bool                   // If true, the result JSON file is not written (the Result is still returned)
+	SoilCache      *soil_dispersion.Cache // If set, soil dispersion curves are shared through this cache
+	Fast           bool                   // If true, the fast approximate mode is used, whatever solver.fast of the configuration
+}
+
+// logFileName returns the path of the log file written next to a result file.
+// The extension of the result file is replaced by ".log".
+//
+// Parameters:
+//   - resultFile: Path of the result JSON file
+//
+// Returns:
+//   - string: Path of the log file
+func logFileName(resultFile string) string {
+	return strings.TrimSuffix(resultFile, OutputExt(resultFile)) + ".log"
+}
+
+// newLogger creates the structured logger used during a single analysis.
+// When logFile is true, all messages are written to a log file next to the result file.
+// Otherwise, when verbose is true, warnings are written to stdout; else messages are discarded.
+//
+// Parameters:
+//   - resultFile: Path of the result JSON file
+//   - verbose: If true, warnings are printed to stdout when no log file is written
+//   - logFile: If true, messages are written to a log file next to the result file
+//
+// Returns:
+//   - *slog.Logger: The logger
+//   - func() error: Function closing the log file (no-op if no file was opened)
+//   - error: An error if the log file cannot be created
+func newLogger(resultFile string, verbose bool, logFile bool) (*slog.Logger, func() error, error) {
+	if logFile {
+		file, err := storage.Create(logFileName(resultFile))
+		if err != nil {
+			return nil, nil, classify(KindIO, fmt.Errorf("failed to create log file: %v", err))
+		}
+		return slog.New(slog.NewTextHandler(file, nil)), file.Close, nil
+	}
+
+	noClose := func() error { return nil }
+	if verbose {
+		return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn})), noClose, nil
+	}
+	return slog.New(slog.NewTextHandler(io.Discard, nil)), noClose, nil
+}
+
 // Run executes the critical speed analysis for a railway track based on configuration.
 // The function:
 //   - Loads the track parameters from a YAML configuration file
@@ -231,53 +1056,783 @@ func loadConfig(configPath string) (Config, error) {
 // Returns:
 //   - error: An error if any step of the process fails
 func Run(configPath string, verbose bool) error {
+	_, err := RunWithOptions(configPath, Options{Verbose: verbose})
+	return err
+}
+
+// RunWithOptions executes the critical speed analysis in the same way as Run,
+// with additional control over logging. When opts.LogFile is set, solver warnings
+// (frequencies without a root), stage timings and errors are written to a log file
+// next to the result file, so that failed configurations can be diagnosed afterwards.
+//
+// Parameters:
+//   - configPath: Path to the YAML configuration file
+//   - opts: Options controlling console output and log files
+//
+// Returns:
+//   - Result: The computed dispersion curves and critical speed
+//   - error: An error if any step of the process fails
+func RunWithOptions(configPath string, opts Options) (Result, error) {
+	return RunContext(context.Background(), configPath, opts)
+}
+
+// RunContext executes the critical speed analysis in the same way as RunWithOptions,
+// but stops early when the context is cancelled or its deadline is exceeded.
+// In that case no result file is written and the context error is returned.
+//
+// Parameters:
+//   - ctx: Context used to cancel the analysis
+//   - configPath: Path to the YAML configuration file
+//   - opts: Options controlling console output and log files
+//
+// Returns:
+//   - Result: The computed dispersion curves and critical speed
+//   - error: An error if any step of the process fails or the context is cancelled
+func RunContext(ctx context.Context, configPath string, opts Options) (Result, error) {
 
 	// Load configuration
-	config, err := loadConfig(configPath)
+	config, err := LoadConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("error loading configuration: %v", err)
+		return Result{}, fmt.Errorf("error loading configuration: %w", err)
 	}
 
-	// Create omega values based on configuration file
-	omega := math_utils.Linspace(
-		config.Frequency.Min,
-		config.Frequency.Max,
-		config.Frequency.Points,
-	)
+	return RunConfig(ctx, config, configPath, opts)
+}
 
-	var params track_dispersion.TrackParameters
+// RunConfig executes the critical speed analysis for an already loaded configuration,
+// in the same way as RunContext. It allows configurations that do not originate from
+// a local file (e.g. received from a job queue) to be processed.
+//
+// Parameters:
+//   - ctx: Context used to cancel the analysis
+//   - config: The configuration structure
+//   - source: Description of where the configuration comes from (used in logs)
+//   - opts: Options controlling console output and log files
+//
+// Returns:
+//   - Result: The computed dispersion curves and critical speed
+//   - error: An error if any step of the process fails or the context is cancelled
+func RunConfig(ctx context.Context, config Config, source string, opts Options) (Result, error) {
 
-	switch config.TrackType {
-	case "ballast":
-		params = createBallastTrackParams(config)
-	case "slabtrack":
-		params = createSlabTrackParams(config)
-	default:
-		return fmt.Errorf("invalid track type: %s. Supported types are 'ballast' or 'slabtrack'", config.TrackType)
+	if opts.Fast {
+		config.Solver.Fast = true
 	}
 
-	// Calculate the dispersion curve for the track
-	phaseVelocity := track_dispersion.RailTrackDispersion(params, omega)
+	logger, closeLog, err := newLogger(config.Output.FileName, opts.Verbose, opts.LogFile)
+	if err != nil {
+		return Result{}, err
+	}
+	defer closeLog()
 
-	// Process soil layers if provided
-	soilLayers := createSoilLayers(config)
+	omega, _ := Frequencies(config)
+	logger.Info("starting analysis", "config", source, "track_type", config.TrackType,
+		"soil_layers", len(config.SoilLayers), "frequencies", len(omega))
+	for _, change := range config.Migrated {
+		logger.Warn("configuration upgraded", "change", change)
+	}
 
-	// Calculate the dispersion curve for the soil layers
-	soilPhaseVelocity := soil_dispersion.SoilDispersion(soilLayers, omega)
+	// Reject an unsupported format before the analysis
+	if !opts.SkipResultFile {
+		if err := checkFormat(config.Output.Format); err != nil {
+			err = locate(config, err)
+			logger.Error("analysis failed", "error", err)
+			return Result{}, err
+		}
+		if err := checkPrecision(config.Output.Precision); err != nil {
+			err = locate(config, err)
+			logger.Error("analysis failed", "error", err)
+			return Result{}, err
+		}
+	}
 
-	// Compute the critical train speed
-	omegaCrit, phaseVelocityCrit, err := math_utils.InterceptLines(omega, phaseVelocity, soilPhaseVelocity)
+	start := time.Now()
+	result, err := compute(ctx, config, logger, opts.SoilCache)
 	if err != nil {
-		return fmt.Errorf("error calculating critical speed. %v", err)
+		err = locate(config, err)
+		logger.Error("analysis failed", "error", err)
+		return Result{}, err
+	}
+
+	if opts.SkipResultFile {
+		logger.Info("analysis completed", "duration", time.Since(start))
+		return result, nil
 	}
 
 	// Save results to file
-	err = saveResults(omega, phaseVelocity, soilPhaseVelocity, omegaCrit, phaseVelocityCrit, config.Output.FileName)
+	err = saveResults(result, config)
 	if err != nil {
-		return fmt.Errorf("error saving results: %v", err)
+		logger.Error("analysis failed", "error", err)
+		return Result{}, fmt.Errorf("error saving results: %w", err)
 	}
-	if verbose {
+	logger.Info("results saved", "file", config.Output.FileName, "duration", time.Since(start))
+
+	if err := saveProfileResults(result, config); err != nil {
+		logger.Error("analysis failed", "error", err)
+		return Result{}, fmt.Errorf("error saving results: %w", err)
+	}
+	if result.Profiles != nil {
+		logger.Info("soil profile results saved", "profiles", len(result.Profiles))
+	}
+
+	for _, table := range resultTables(result, config) {
+		if err := saveTable(table.fileName, table.write); err != nil {
+			logger.Error("analysis failed", "error", err)
+			return Result{}, fmt.Errorf("error saving results: %w", err)
+		}
+		logger.Info(table.name+" saved", "file", table.fileName)
+	}
+
+	if opts.Verbose {
 		fmt.Printf("Results written successfully to %s\n", config.Output.FileName)
 	}
-	return nil
+	return result, nil
+}
+
+// Compute performs the analysis described by a configuration without any side
+// effects: no result or log file is written and nothing is printed. It is intended
+// for services embedding GoTrain, which deliver the result themselves.
+//
+// Parameters:
+//   - ctx: Context used to cancel the analysis
+//   - config: The configuration structure (the output section is ignored)
+//
+// Returns:
+//   - Result: The computed dispersion curves and critical speed
+//   - error: An error if any step of the process fails or the context is cancelled
+func Compute(ctx context.Context, config Config) (Result, error) {
+	result, err := compute(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	return result, locate(config, err)
+}
+
+// compute performs the analysis described by a configuration.
+//
+// Parameters:
+//   - ctx: Context used to cancel the analysis
+//   - config: The loaded configuration structure
+//   - logger: Logger receiving solver warnings and timings
+//   - soilCache: Cache of soil dispersion curves (nil to always compute the curve)
+//
+// Returns:
+//   - Result: The computed dispersion curves and critical speed
+//   - error: An error if any step of the process fails or the context is cancelled
+func compute(ctx context.Context, config Config, logger *slog.Logger, soilCache *soil_dispersion.Cache) (Result, error) {
+
+	// Create omega values based on configuration file
+	omega, err := Frequencies(config)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// With soil_profiles, the other results describe the first profile
+	profileNames, profileConfigs, err := soilProfiles(config)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(profileConfigs) > 0 {
+		config = profileConfigs[0]
+	}
+
+	params, factor, err := trackParameters(config)
+	if err != nil {
+		return Result{}, err
+	}
+	switch track := params.(type) {
+	case track_dispersion.BallastTrackParameters:
+		if factor != 1 {
+			logger.Info("ballast reinforced by geogrids", "geogrids", len(config.BallastReinforcement.Depths),
+				"factor", factor, "E_ballast", track.EBallast)
+		}
+	case track_dispersion.PiledSlabTrackParameters:
+		logger.Info("slab supported by piles", "support_stiffness", track.SupportStiffness(),
+			"cutoff_omega", math.Sqrt(track.SupportStiffness()/(track.MRail+track.MSlab)))
+	}
+
+	// The wave numbers of the track are computed in closed form from its determinant
+	// polynomial, unless a bracketing root finder is configured, and are complex with
+	// the damping of the railpads with solver.attenuation. The fast approximate mode
+	// scans the phase velocities of the soil coarsely, and the roots of the soil are
+	// refined within their brackets with solver.soil_root_finder
+	rootFinder, scan := config.Solver.RootFinder, soil_dispersion.ScanOptions{}
+	if _, ok := params.(track_dispersion.PolynomialTrack); ok && rootFinder == "" {
+		rootFinder = math_utils.SolverPolynomial
+	}
+	if config.Solver.Fast {
+		scan = soil_dispersion.FastScan
+		logger.Info("fast approximate mode", "step", scan.Step)
+	}
+	if err := checkSoilRootFinder(config); err != nil {
+		return Result{}, err
+	}
+	if err := checkCriticalBand(config); err != nil {
+		return Result{}, err
+	}
+	scan.Refine = config.Solver.SoilRootFinder
+
+	if err := checkAttenuation(config); err != nil {
+		return Result{}, err
+	}
+	var findRoot math_utils.RootFinder
+	if rootFinder != math_utils.SolverPolynomial {
+		var err error
+		if findRoot, err = math_utils.RootFinderByName(rootFinder); err != nil {
+			return Result{}, classify(KindConfig, err)
+		}
+	}
+	movingLoad, err := movingLoadParameters(config)
+	if err != nil {
+		return Result{}, err
+	}
+	vibration, err := groundVibrationParameters(config)
+	if err != nil {
+		return Result{}, err
+	}
+	irregularity, err := irregularityParameters(config)
+	if err != nil {
+		return Result{}, err
+	}
+	operatingSpeeds, err := assessmentSpeeds(config)
+	if err != nil {
+		return Result{}, err
+	}
+	train, maxSpeedRatio, err := trainParameters(config)
+	if err != nil {
+		return Result{}, err
+	}
+	embankments, err := embankmentVariants(config)
+	if err != nil {
+		return Result{}, err
+	}
+	var spectrum []SpectrumLine
+	if config.Assessment.Spectrum != "" {
+		if len(operatingSpeeds) == 0 {
+			return Result{}, classify(KindConfig, fmt.Errorf("invalid assessment: the excitation spectrum requires operating speeds"))
+		}
+		if spectrum, err = LoadSpectrum(config.Assessment.Spectrum); err != nil {
+			return Result{}, err
+		}
+	}
+
+	// The track and soil dispersion curves are independent until their intersection:
+	// the track curve is computed concurrently with the soil curve, and the soil
+	// computation is cancelled when the track computation fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var phaseVelocity, decayRate []float64
+	var trackErr error
+	var trackDuration time.Duration
+	trackDone := make(chan struct{})
+	go func() {
+		defer close(trackDone)
+		stageStart := time.Now()
+		switch {
+		case config.Solver.Attenuation:
+			phaseVelocity, decayRate, trackErr = track_dispersion.RailTrackAttenuation(ctx, params, omega)
+		case findRoot == nil:
+			phaseVelocity, trackErr = track_dispersion.RailTrackDispersionPolynomial(ctx, params, omega)
+		default:
+			phaseVelocity, trackErr = track_dispersion.RailTrackDispersionSolver(ctx, params, omega, findRoot)
+		}
+		trackDuration = time.Since(stageStart)
+		if trackErr != nil {
+			cancel()
+		}
+	}()
+
+	soilPhaseVelocity, soilStage, soilErr := computeSoilDispersion(ctx, config, omega, scan, soilCache)
+	<-trackDone
+
+	// The stages are reported in a fixed order, whichever finished first
+	if trackErr != nil {
+		return Result{}, solverError(fmt.Errorf("error calculating track dispersion: %w", trackErr))
+	}
+	for i, v := range phaseVelocity {
+		if v == 0 {
+			logger.Warn("track dispersion: no root found", "omega", omega[i])
+		}
+	}
+	logger.Info("track dispersion computed", "duration", trackDuration)
+
+	if soilStage.source != "" {
+		logger.Info("soil layers derived from CPT", "cpt", soilStage.source, "layers", soilStage.layers)
+	}
+	if soilErr != nil {
+		return Result{}, soilErr
+	}
+	for i, v := range soilPhaseVelocity {
+		if math.IsNaN(v) {
+			logger.Warn("soil dispersion: no root found", "omega", omega[i])
+		}
+	}
+	logger.Info("soil dispersion computed", "duration", soilStage.duration, "cached", soilStage.cached)
+	if layers := soil_dispersion.NearlyIncompressible(soilStage.original); layers != nil {
+		logger.Warn("nearly incompressible soil layers: their Poisson's ratio is capped in the soil dispersion",
+			"layers", layers, "max_poisson_ratio", soil_dispersion.MaxPoissonRatio)
+	}
+
+	// The improved layers are validated against the soil profile
+	var improvement Improvement
+	if config.Improvement.TargetSpeed != 0 {
+		if improvement, err = improvementParameters(config, soilStage.original); err != nil {
+			return Result{}, err
+		}
+	}
+
+	// Compute the critical train speed
+	piled := config.TrackType == "piledslab"
+	omegaCrit, phaseVelocityCrit, err := criticalPoint(omega, phaseVelocity, soilPhaseVelocity, piled)
+	if err != nil {
+		return Result{}, err
+	}
+	logger.Info("critical speed computed", "critical_omega", omegaCrit, "critical_velocity", phaseVelocityCrit)
+	if omegaCrit == omega[0] || omegaCrit == omega[len(omega)-1] {
+		logger.Warn("critical speed at the end of the frequency range; widen the range", "critical_omega", omegaCrit)
+	}
+	if decayRate != nil {
+		if rate, err := decayRateAt(omega, decayRate, omegaCrit); err == nil {
+			logger.Info("track attenuation at the critical speed", "decay_rate", rate, "decay_length", 1/rate)
+		}
+	}
+
+	// Report the band around the critical speed where the track and soil curves are
+	// close: the response is amplified over the band, not only at the critical speed
+	var band CriticalBand
+	if tolerance := config.Assessment.BandTolerance; tolerance != 0 {
+		if band, err = CriticalBandOf(omega, phaseVelocity, soilPhaseVelocity, omegaCrit, tolerance/100); err != nil {
+			logger.Warn("no critical band", "reason", err, "band_tolerance", tolerance)
+		} else {
+			logger.Info("critical band computed", "band_tolerance", tolerance, "omega_min", band.OmegaMin, "omega_max", band.OmegaMax,
+				"velocity_min", band.VelocityMin, "velocity_max", band.VelocityMax)
+		}
+	}
+
+	// Estimate the resonance of the soil layers, reported next to the dispersion curve
+	var resonanceFrequency float64
+	sitePeriod := soil_dispersion.SitePeriod(soilStage.profile)
+	if sitePeriod > 0 {
+		resonanceFrequency = 1 / sitePeriod
+		logger.Info("site period computed", "site_period", sitePeriod, "resonance_frequency", resonanceFrequency)
+	}
+
+	// Report the leaky soil modes, above the shear wave speed of the halfspace
+	var leakyVelocity []float64
+	if config.Solver.LeakyModes {
+		if leakyVelocity, err = soil_dispersion.LeakyDispersionScan(ctx, soilStage.profile, omega, scan); err != nil {
+			return Result{}, fmt.Errorf("error calculating the leaky soil modes: %w", err)
+		}
+		logger.Info("leaky soil modes computed", "frequencies", len(omega)-math_utils.CountNaN(leakyVelocity))
+		if omegaLeaky, velocityLeaky, err := math_utils.InterceptLines(omega, trackRoots(phaseVelocity), leakyVelocity); err == nil {
+			logger.Warn("track dispersion curve intersects a leaky soil mode", "omega", omegaLeaky, "velocity", velocityLeaky)
+		}
+	}
+
+	// Report further intersections: only the first one is the critical speed
+	if omegas, velocities, err := math_utils.InterceptLinesAll(omega, trackRoots(phaseVelocity), soilPhaseVelocity); err == nil && len(omegas) > 1 {
+		logger.Warn("track and soil dispersion curves intersect several times; the first intersection is used",
+			"intersections", len(omegas), "omega", omegas, "velocity", velocities)
+	}
+
+	// The soil curve and critical speed of another soil profile: the track curve is
+	// independent of the soil, so only the soil curve of the profile is computed
+	soilCurve := func(ctx context.Context, layers []soil_dispersion.Layer) ([]float64, float64, float64, error) {
+		layers, err := discretizeSoilLayers(config, layers)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if layers, err = stiffenSoilLayers(config, layers); err != nil {
+			return nil, 0, 0, err
+		}
+		soilPhaseVelocity, _, err := soilDispersionCurve(ctx, layers, omega, scan, soilCache)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		omegaCrit, velocityCrit, err := criticalPoint(omega, phaseVelocity, soilPhaseVelocity, piled)
+		return soilPhaseVelocity, omegaCrit, velocityCrit, err
+	}
+	critical := func(ctx context.Context, layers []soil_dispersion.Layer) (float64, float64, error) {
+		_, omegaCrit, velocityCrit, err := soilCurve(ctx, layers)
+		return omegaCrit, velocityCrit, err
+	}
+
+	// Compare the critical speed with that of the ground without the columns
+	var untreatedOmega, untreatedVelocity float64
+	if config.Columns.YoungModulus != 0 {
+		if untreatedOmega, untreatedVelocity, err = critical(ctx, soilStage.untreated); err != nil {
+			return Result{}, fmt.Errorf("error calculating the critical speed without columns: %w", err)
+		}
+		logger.Info("critical speed without columns computed", "critical_omega", untreatedOmega, "critical_velocity", untreatedVelocity,
+			"increase", phaseVelocityCrit-untreatedVelocity)
+	}
+
+	// Compare the critical speed with that of the soil without the stress of the axle load
+	var unloadedOmega, unloadedVelocity float64
+	if config.StressDependence.AxleLoad != 0 {
+		factors := make([]float64, len(soilStage.profile))
+		for i, layer := range soilStage.profile {
+			factors[i] = layer.YoungsModulus / soilStage.unloaded[i].YoungsModulus
+		}
+		logger.Info("soil stiffened under the axle load", "axle_load", config.StressDependence.AxleLoad, "factors", factors)
+		unloadedPhaseVelocity, _, err := soilDispersionCurve(ctx, soilStage.unloaded, omega, scan, soilCache)
+		if err == nil {
+			unloadedOmega, unloadedVelocity, err = criticalPoint(omega, phaseVelocity, unloadedPhaseVelocity, piled)
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("error calculating the critical speed without the axle load: %w", err)
+		}
+		logger.Info("critical speed without the axle load computed", "critical_omega", unloadedOmega, "critical_velocity", unloadedVelocity,
+			"increase", phaseVelocityCrit-unloadedVelocity)
+	}
+
+	// Compare the critical speeds of the embankment variants built on the soil profile
+	for i, c := range embankments {
+		profile, err := soil_dispersion.AddEmbankment(soilStage.original, c.Embankment)
+		if err != nil {
+			return Result{}, classify(KindConfig, fmt.Errorf("embankment %s: %w", c.Name, err))
+		}
+		if embankments[i].CriticalOmega, embankments[i].CriticalVelocity, err = critical(ctx, profile); err != nil {
+			return Result{}, fmt.Errorf("error calculating the critical speed of embankment %s: %w", c.Name, err)
+		}
+		logger.Info("embankment critical speed computed", "embankment", c.Name, "height", c.Embankment.Height,
+			"crest_width", c.Embankment.CrestWidth, "critical_velocity", embankments[i].CriticalVelocity)
+	}
+
+	// Compute the critical speeds of the other soil profiles of soil_profiles
+	var profiles []ProfileCase
+	if len(profileNames) > 0 {
+		profiles = append(profiles, ProfileCase{Name: profileNames[0], SoilPhaseVelocity: soilPhaseVelocity,
+			CriticalOmega: omegaCrit, CriticalVelocity: phaseVelocityCrit})
+		lowest := profiles[0]
+		for i := 1; i < len(profileNames); i++ {
+			untreated, _, err := loadSoilProfile(ctx, profileConfigs[i])
+			if err == nil {
+				untreated, err = improveSoilLayers(profileConfigs[i], untreated)
+			}
+			if err != nil {
+				return Result{}, fmt.Errorf("soil profile %s: %w", profileNames[i], err)
+			}
+			p := ProfileCase{Name: profileNames[i]}
+			if p.SoilPhaseVelocity, p.CriticalOmega, p.CriticalVelocity, err = soilCurve(ctx, untreated); err != nil {
+				return Result{}, fmt.Errorf("error calculating the critical speed of soil profile %s: %w", p.Name, err)
+			}
+			logger.Info("soil profile critical speed computed", "profile", p.Name, "critical_omega", p.CriticalOmega,
+				"critical_velocity", p.CriticalVelocity)
+			profiles = append(profiles, p)
+			if p.CriticalVelocity < lowest.CriticalVelocity {
+				lowest = p
+			}
+		}
+		logger.Info("soil profiles compared", "profiles", len(profiles), "lowest", lowest.Name,
+			"critical_velocity", lowest.CriticalVelocity)
+	}
+
+	// Report the operating speeds radiating a Mach cone
+	var machCones []MachCone
+	if len(operatingSpeeds) > 0 {
+		machCones = MachCones(omega, soilPhaseVelocity, operatingSpeeds)
+		for _, m := range machCones {
+			if m.Supersonic() {
+				logger.Warn("operating speed exceeds the soil phase velocity", "speed", m.Speed,
+					"omega_min", m.OmegaMin, "omega_max", m.OmegaMax, "mach_angle_min", m.AngleMin, "mach_angle_max", m.AngleMax)
+			}
+		}
+	}
+
+	// Shift the excitation spectrum to the frequencies received next to the track
+	var doppler []DopplerLine
+	if spectrum != nil {
+		if doppler, err = DopplerShift(omega, soilPhaseVelocity, spectrum, operatingSpeeds); err != nil {
+			return Result{}, solverError(fmt.Errorf("error calculating Doppler shift: %w", err))
+		}
+		logger.Info("Doppler-shifted spectrum computed", "lines", len(spectrum), "speeds", len(operatingSpeeds))
+	}
+
+	// Flag the dominant load harmonics of the train near the phase velocity of the system
+	var trainExcitation []LoadHarmonic
+	if train.Vehicles > 0 {
+		trainExcitation = TrainExcitation(train, omega, phaseVelocity, soilPhaseVelocity, operatingSpeeds, maxSpeedRatio)
+		for _, h := range trainExcitation {
+			if h.Flagged {
+				logger.Warn("train load harmonic approaches the phase velocity", "speed", h.Speed, "harmonic", h.Harmonic,
+					"frequency", h.Frequency, "phase_velocity", h.PhaseVelocity, "speed_ratio", h.SpeedRatio)
+			}
+		}
+	}
+
+	// Compute the deflection and bending moment versus the speed of the moving load
+	var movingLoadPoints []moving_load.Point
+	if movingLoad.Load != 0 {
+		stageStart := time.Now()
+		movingLoadPoints, err = moving_load.SpeedResponse(ctx, params.(track_dispersion.ReceptanceTrack),
+			soilStage.profile, omega, soilPhaseVelocity, movingLoad)
+		if err != nil {
+			return Result{}, solverError(fmt.Errorf("error calculating moving load response: %w", err))
+		}
+		logger.Info("moving load response computed", "speeds", len(movingLoadPoints), "duration", time.Since(stageStart))
+	}
+
+	// Estimate the free-field ground vibration at the operating speed
+	var vibrationPoints []moving_load.VibrationPoint
+	if len(vibration.distances) > 0 {
+		stageStart := time.Now()
+		vibrationPoints, err = moving_load.GroundVibration(ctx, params.(track_dispersion.ReceptanceTrack),
+			soilStage.profile, omega, soilPhaseVelocity, vibration.load, vibration.speed, vibration.distances)
+		if err != nil {
+			return Result{}, solverError(fmt.Errorf("error calculating ground vibration: %w", err))
+		}
+		logger.Info("ground vibration computed", "distances", len(vibrationPoints), "duration", time.Since(stageStart))
+	}
+
+	// Compute the dynamic wheel-rail force of the irregularity of the rail versus speed
+	var irregularitySpeeds []IrregularitySpeed
+	if irregularity.load.Load != 0 {
+		stageStart := time.Now()
+		points, err := moving_load.IrregularityResponse(ctx, params.(track_dispersion.ReceptanceTrack),
+			soilStage.profile, omega, soilPhaseVelocity, irregularity.load, irregularity.wheel, irregularity.irregularity)
+		if err != nil {
+			return Result{}, solverError(fmt.Errorf("error calculating irregularity response: %w", err))
+		}
+		irregularitySpeeds = FlagIrregularity(points, phaseVelocityCrit, irregularity.regimeRatio)
+		for _, s := range irregularitySpeeds {
+			if s.Flagged {
+				logger.Warn("wheel-rail force peaks in the critical regime", "speed", s.Speed, "speed_ratio", s.SpeedRatio,
+					"rms_force", s.RMSForce, "dynamic_factor", s.DynamicFactor, "peak_omega", s.PeakOmega)
+			}
+		}
+		logger.Info("irregularity response computed", "speeds", len(irregularitySpeeds), "duration", time.Since(stageStart))
+	}
+
+	// Design the soil improvement for the target critical speed
+	var design *ImprovementDesign
+	if config.Improvement.TargetSpeed != 0 {
+		stageStart := time.Now()
+		improved, err := DesignImprovement(ctx, soilStage.original, config.Improvement.TargetSpeed, improvement, critical)
+		if err != nil {
+			return Result{}, err
+		}
+		design = &improved
+		logger.Info("soil improvement designed", "parameter", improved.Parameter, "value", improved.Value,
+			"critical_velocity", improved.CriticalVelocity, "evaluations", improved.Evaluations, "duration", time.Since(stageStart))
+	}
+
+	return Result{
+		Omega:              omega,
+		TrackPhaseVelocity: phaseVelocity,
+		SoilPhaseVelocity:  soilPhaseVelocity,
+		TrackDecayRate:     decayRate,
+		SoilLeakyVelocity:  leakyVelocity,
+		CriticalOmega:      omegaCrit,
+		CriticalVelocity:   phaseVelocityCrit,
+		CriticalBand:       band,
+		SitePeriod:         sitePeriod,
+		ResonanceFrequency: resonanceFrequency,
+		FrequencyUnit:      config.Frequency.Unit,
+		Precision:          config.Output.Precision,
+		UntreatedOmega:     untreatedOmega,
+		UntreatedVelocity:  untreatedVelocity,
+		UnloadedOmega:      unloadedOmega,
+		UnloadedVelocity:   unloadedVelocity,
+		MovingLoad:         movingLoadPoints,
+		GroundVibration:    vibrationPoints,
+		MachCones:          machCones,
+		Doppler:            doppler,
+		TrainExcitation:    trainExcitation,
+		Irregularity:       irregularitySpeeds,
+		Improvement:        design,
+		Embankments:        embankments,
+		Profiles:           profiles,
+	}, nil
+}
+
+// criticalPoint returns the critical angular frequency and speed of the track and soil
+// dispersion curves: their first intersection, at the frequencies where the track has
+// a root. Piles make the track faster than the soil at all frequencies; the critical
+// speed of a piled track without intersection is then the lowest phase velocity of
+// the track, its own resonance, interpolated by a parabola through the lowest point
+// and its neighbours.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s]
+//   - track: Phase velocities of the track [m/s] (zero where no root is found)
+//   - soil: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - piled: Whether the track is supported by piles
+//
+// Returns:
+//   - float64: The critical angular frequency [rad/s]
+//   - float64: The critical speed [m/s]
+//   - error: An error if the curves do not intersect
+func criticalPoint(omega, track, soil []float64, piled bool) (float64, float64, error) {
+	roots := trackRoots(track)
+	omegaCrit, velocityCrit, err := math_utils.InterceptLines(omega, roots, soil)
+	if err == nil {
+		return omegaCrit, velocityCrit, nil
+	}
+	if !piled {
+		return 0, 0, classify(KindNoIntersection, fmt.Errorf("error calculating critical speed. %v", err))
+	}
+
+	lowest := -1
+	for i, v := range roots {
+		if math.IsNaN(v) {
+			continue
+		}
+		if v < soil[i] {
+			return 0, 0, classify(KindNoIntersection, fmt.Errorf("error calculating critical speed. %v", err))
+		}
+		if lowest < 0 || v < roots[lowest] {
+			lowest = i
+		}
+	}
+	if lowest < 0 {
+		return 0, 0, classify(KindNoIntersection, fmt.Errorf("error calculating critical speed: the track has no root in the frequency range"))
+	}
+	if lowest == 0 || lowest == len(roots)-1 || math.IsNaN(roots[lowest-1]) || math.IsNaN(roots[lowest+1]) {
+		return omega[lowest], roots[lowest], nil
+	}
+
+	// Vertex of the parabola through the lowest point and its neighbours
+	x0, x1, x2 := omega[lowest-1], omega[lowest], omega[lowest+1]
+	y0, y1, y2 := roots[lowest-1], roots[lowest], roots[lowest+1]
+	denominator := (x0 - x1) * (x0 - x2) * (x1 - x2)
+	a := (x2*(y1-y0) + x1*(y0-y2) + x0*(y2-y1)) / denominator
+	b := (x2*x2*(y0-y1) + x1*x1*(y2-y0) + x0*x0*(y1-y2)) / denominator
+	if !(a > 0) {
+		return x1, y1, nil
+	}
+	vertex := -b / (2 * a)
+	c := y1 - a*x1*x1 - b*x1
+	return vertex, c - b*b/(4*a), nil
+}
+
+// trackRoots returns the phase velocities of the track with NaN where no root is
+// found, so that the gaps of the curve do not intersect the soil curve.
+//
+// Parameters:
+//   - track: Phase velocities of the track [m/s] (zero where no root is found)
+//
+// Returns:
+//   - []float64: The phase velocities (a copy)
+func trackRoots(track []float64) []float64 {
+	roots := make([]float64, len(track))
+	for i, v := range track {
+		roots[i] = v
+		if v == 0 {
+			roots[i] = math.NaN()
+		}
+	}
+	return roots
+}
+
+// soilStage describes the computation of the soil dispersion curve, for the logs.
+type soilStage struct {
+	source   string        // CPT the soil layers are derived from (empty for soil_layers)
+	layers   int           // Number of soil layers
+	duration time.Duration // Duration of the dispersion computation
+	cached   bool          // Whether the curve was served from the cache
+
+	profile   []soil_dispersion.Layer // Soil layers of the curve
+	original  []soil_dispersion.Layer // Soil layers with the columns, before discretization (see discretizeSoilLayers)
+	untreated []soil_dispersion.Layer // Soil layers without the columns (see improveSoilLayers)
+	unloaded  []soil_dispersion.Layer // Soil layers without the stress of the axle load (see stiffenSoilLayers)
+}
+
+// trackParameters creates the track parameters of the track type of a configuration.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - track_dispersion.TrackParameters: The parameters of the ballast, slab or piled slab track
+//   - float64: Stiffness factor of the ballast reinforced by geogrids (1 without reinforcement)
+//   - error: An error if the track type or its parameters are invalid
+func trackParameters(config Config) (track_dispersion.TrackParameters, float64, error) {
+	switch config.TrackType {
+	case "ballast":
+		return reinforceBallastTrack(config, createBallastTrackParams(config))
+	case "slabtrack", "piledslab":
+		if len(config.BallastReinforcement.Depths) > 0 {
+			return nil, 0, classify(KindConfig, fieldError("ballast_reinforcement", fmt.Errorf("ballast_reinforcement requires a ballast track")))
+		}
+		if config.TrackType == "slabtrack" {
+			return createSlabTrackParams(config), 1, nil
+		}
+		piled, err := createPiledSlabTrackParams(config)
+		if err != nil {
+			return nil, 0, err
+		}
+		return piled, 1, nil
+	default:
+		return nil, 0, classify(KindConfig, fieldError("track_type", fmt.Errorf("invalid track type: %s. Supported types are 'ballast', 'slabtrack' or 'piledslab'", config.TrackType)))
+	}
+}
+
+// computeSoilDispersion loads the soil layers of a configuration, or derives them
+// from a CPT, and computes their dispersion curve.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - config: The loaded configuration structure
+//   - omega: Angular frequencies [rad/s]
+//   - scan: Scan of the phase velocities (see soil_dispersion.SoilDispersionScan)
+//   - soilCache: Cache of soil dispersion curves (nil to always compute the curve)
+//
+// Returns:
+//   - []float64: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - soilStage: Description of the computation, for the logs
+//   - error: An error if the soil layers cannot be loaded or the computation fails
+func computeSoilDispersion(ctx context.Context, config Config, omega []float64, scan soil_dispersion.ScanOptions, soilCache *soil_dispersion.Cache) ([]float64, soilStage, error) {
+	untreated, source, err := loadSoilProfile(ctx, config)
+	if err != nil {
+		return nil, soilStage{}, err
+	}
+	original, err := improveSoilLayers(config, untreated)
+	if err != nil {
+		return nil, soilStage{}, err
+	}
+	unloaded, err := discretizeSoilLayers(config, original)
+	if err != nil {
+		return nil, soilStage{}, err
+	}
+	soilLayers, err := stiffenSoilLayers(config, unloaded)
+	if err != nil {
+		return nil, soilStage{}, err
+	}
+	stage := soilStage{source: source, layers: len(soilLayers), profile: soilLayers, original: original, untreated: untreated, unloaded: unloaded}
+
+	stageStart := time.Now()
+	soilPhaseVelocity, cached, err := soilDispersionCurve(ctx, soilLayers, omega, scan, soilCache)
+	stage.duration, stage.cached = time.Since(stageStart), cached
+	if err != nil {
+		return nil, stage, err
+	}
+	return soilPhaseVelocity, stage, nil
+}
+
+// soilDispersionCurve computes the dispersion curve of a soil profile, served from
+// the cache when one is given.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - layers: The soil profile, with its wave speeds computed
+//   - omega: Angular frequencies [rad/s]
+//   - scan: Scan of the phase velocities (see soil_dispersion.SoilDispersionScan)
+//   - soilCache: Cache of soil dispersion curves (nil to always compute the curve)
+//
+// Returns:
+//   - []float64: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - bool: Whether the curve was served from the cache
+//   - error: An error if the dispersion solver fails
+func soilDispersionCurve(ctx context.Context, layers []soil_dispersion.Layer, omega []float64, scan soil_dispersion.ScanOptions,
+	soilCache *soil_dispersion.Cache) ([]float64, bool, error) {
+	var soilPhaseVelocity []float64
+	var cached bool
+	var err error
+	if soilCache != nil {
+		soilPhaseVelocity, cached, err = soilCache.SoilDispersionScan(ctx, layers, omega, scan)
+	} else {
+		soilPhaseVelocity, err = soil_dispersion.SoilDispersionScan(ctx, layers, omega, scan)
+	}
+	if err != nil {
+		return nil, cached, solverError(fmt.Errorf("error calculating soil dispersion: %w", err))
+	}
+	return soilPhaseVelocity, cached, nil
 }
@@ -1,10 +1,24 @@
 package critical_speed
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+
+	cpt "github.com/PlatypusBytes/GoTrain/internal/cpt"
+	geodata "github.com/PlatypusBytes/GoTrain/internal/geodata"
+	moving_load "github.com/PlatypusBytes/GoTrain/internal/moving_load"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
 )
 
 const TOL = 1e-3
@@ -57,3 +71,1979 @@ func TestRunWithSampleConfig(t *testing.T) {
 	os.Remove(tmpFile)
 
 }
+
+// Test that RunWithOptions writes a log file next to the result file.
+func TestRunWithOptionsLogFile(t *testing.T) {
+	dir := t.TempDir()
+	resultFile := filepath.Join(dir, "results.json")
+
+	data, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+	content := strings.Replace(string(data), `file_name: "dispersion_results.json"`, "file_name: "+strconv.Quote(resultFile), 1)
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := RunWithOptions(configPath, Options{LogFile: true}); err != nil {
+		t.Fatalf("RunWithOptions failed: %v", err)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(dir, "results.log"))
+	if err != nil {
+		t.Fatalf("expected log file to be written: %v", err)
+	}
+	for _, msg := range []string{"starting analysis", "soil dispersion computed", "results saved"} {
+		if !strings.Contains(string(logData), msg) {
+			t.Errorf("expected log to contain %q", msg)
+		}
+	}
+}
+
+// Test that the root finder selected in the solver options gives the same critical speed.
+func TestComputeRootFinder(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	for _, rootFinder := range []string{"ridders", "polynomial"} {
+		config.Solver.RootFinder = rootFinder
+		result, err := Compute(context.Background(), config)
+		if err != nil {
+			t.Fatalf("Compute with %s failed: %v", rootFinder, err)
+		}
+		expected_speed := 78.231
+		if diff := result.CriticalVelocity - expected_speed; diff < -TOL || diff > TOL {
+			t.Errorf("unexpected critical speed with %s: got %v, want %v (tolerance %v)", rootFinder, result.CriticalVelocity, expected_speed, TOL)
+		}
+	}
+
+	config.Solver.RootFinder = "newton"
+	if _, err := Compute(context.Background(), config); err == nil {
+		t.Error("expected an error for an unsupported root finder")
+	}
+}
+
+// Test that results are written as a protocol buffer Result message with output.format protobuf.
+func TestRunConfigProtobufOutput(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.pb")
+	config.Output.Format = FormatProtobuf
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("expected output file to be written: %v", err)
+	}
+	decoded, err := UnmarshalResultProto(data)
+	if err != nil {
+		t.Fatalf("UnmarshalResultProto failed: %v", err)
+	}
+	if decoded.CriticalVelocity != result.CriticalVelocity || decoded.CriticalOmega != result.CriticalOmega {
+		t.Errorf("unexpected critical speed in the result file: got %v at %v, want %v at %v",
+			decoded.CriticalVelocity, decoded.CriticalOmega, result.CriticalVelocity, result.CriticalOmega)
+	}
+	if decoded.SitePeriod != result.SitePeriod || decoded.ResonanceFrequency != result.ResonanceFrequency {
+		t.Errorf("unexpected site period in the result file: got %v s, want %v s", decoded.SitePeriod, result.SitePeriod)
+	}
+	if len(decoded.Omega) != len(result.Omega) || len(decoded.SoilPhaseVelocity) != len(result.SoilPhaseVelocity) {
+		t.Errorf("unexpected curve lengths in the result file")
+	}
+
+	config.Output.Format = "xml"
+	if _, err := RunConfig(context.Background(), config, "sample", Options{}); err == nil {
+		t.Error("expected an error for an unsupported output format")
+	}
+}
+
+// Test that result files ending with .gz are compressed and read back.
+func TestRunConfigCompressedOutput(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json.gz")
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("expected output file to be written: %v", err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatal("expected a gzip compressed result file")
+	}
+	decoded, err := UnmarshalResultJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalResultJSON failed: %v", err)
+	}
+	if decoded.CriticalVelocity != result.CriticalVelocity || len(decoded.Omega) != len(result.Omega) {
+		t.Errorf("unexpected decoded result: %v with %d frequencies", decoded.CriticalVelocity, len(decoded.Omega))
+	}
+	if !(result.SitePeriod > 0) || math.Abs(result.SitePeriod*result.ResonanceFrequency-1) > 1e-12 ||
+		decoded.SitePeriod != result.SitePeriod || decoded.ResonanceFrequency != result.ResonanceFrequency {
+		t.Errorf("unexpected site period %v s and resonance frequency %v Hz, decoded as %v s and %v Hz",
+			result.SitePeriod, result.ResonanceFrequency, decoded.SitePeriod, decoded.ResonanceFrequency)
+	}
+	if version, err := ResultsVersion(data); err != nil || version != SchemaVersion {
+		t.Errorf("expected schema_version %d, got %d (%v)", SchemaVersion, version, err)
+	}
+
+	if ext := OutputExt(config.Output.FileName); ext != ".json.gz" {
+		t.Errorf("expected the extension .json.gz, got %q", ext)
+	}
+	if name := ProfileFileName("results.json.gz", "BH 01"); name != "results_BH_01.json.gz" {
+		t.Errorf("unexpected profile result file: %s", name)
+	}
+}
+
+// Test that solver.attenuation reports the decay of the track waves with the damping
+// of the railpads, in the result and in a table next to the result file.
+func TestRunConfigAttenuation(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Solver.Attenuation = true
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if len(result.TrackDecayRate) != len(result.Omega) {
+		t.Fatalf("expected a decay rate per frequency, got %d for %d frequencies", len(result.TrackDecayRate), len(result.Omega))
+	}
+	decaying := 0
+	for i, rate := range result.TrackDecayRate {
+		if rate < 0 || (rate > 0 && result.TrackPhaseVelocity[i] == 0) {
+			t.Errorf("unexpected decay rate %g at omega %f", rate, result.Omega[i])
+		}
+		if rate > 0 {
+			decaying++
+		}
+	}
+	if decaying == 0 {
+		t.Error("expected the track waves to decay with the damping of the railpads")
+	}
+	table, err := os.ReadFile(filepath.Join(filepath.Dir(config.Output.FileName), "results_attenuation.csv"))
+	if err != nil {
+		t.Fatalf("expected the attenuation table to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(table)), "\n"); lines[0] != "omega,track_phase_velocity,decay_rate,decay_length" || len(lines) != len(result.Omega)+1 {
+		t.Errorf("unexpected attenuation table: %d lines, header %q", len(lines), lines[0])
+	}
+
+	config.Solver.RootFinder = "brent"
+	if err := Validate(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error for attenuation with a bracketing root finder, got %v", err)
+	}
+}
+
+// Test that solver.leaky_modes reports the leaky soil modes above the shear wave speed
+// of the halfspace, in the result and in a table next to the result file.
+func TestRunConfigLeakyModes(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Solver.Fast = true
+	config.Solver.LeakyModes = true
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if len(result.SoilLeakyVelocity) != len(result.Omega) {
+		t.Fatalf("expected one leaky phase velocity per frequency, got %d", len(result.SoilLeakyVelocity))
+	}
+	layers, err := createSoilLayers(config)
+	if err != nil {
+		t.Fatalf("createSoilLayers failed: %v", err)
+	}
+	halfspace := layers[len(layers)-1].ShearWaveSpeed
+	for i, v := range result.SoilLeakyVelocity {
+		if !math.IsNaN(v) && !(v > halfspace) {
+			t.Errorf("omega %f: expected the leaky mode above the halfspace shear wave speed %f, got %f", result.Omega[i], halfspace, v)
+		}
+	}
+	table, err := os.ReadFile(filepath.Join(filepath.Dir(config.Output.FileName), "results_leaky.csv"))
+	if err != nil {
+		t.Fatalf("expected the leaky modes table to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(table)), "\n"); lines[0] != "omega,soil_phase_velocity,leaky_phase_velocity" || len(lines) != len(result.Omega)+1 {
+		t.Errorf("unexpected leaky modes table: %d lines, header %q", len(lines), lines[0])
+	}
+}
+
+// Test that assessment.band_tolerance reports the critical band around the critical
+// speed, in the result and in the JSON result file, and rejects invalid tolerances.
+func TestRunConfigCriticalBand(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Solver.Fast = true
+	config.Assessment.BandTolerance = 10
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	band := result.CriticalBand
+	if !(band.OmegaMin < result.CriticalOmega && result.CriticalOmega < band.OmegaMax) ||
+		!(band.VelocityMin < result.CriticalVelocity && result.CriticalVelocity < band.VelocityMax) {
+		t.Errorf("expected the critical band around the critical point (%f, %f), got %+v", result.CriticalOmega, result.CriticalVelocity, band)
+	}
+	for i, w := range result.Omega {
+		if w > band.OmegaMin && w < band.OmegaMax {
+			if d := math.Abs(result.TrackPhaseVelocity[i]-result.SoilPhaseVelocity[i]) / result.SoilPhaseVelocity[i]; d > 0.1 {
+				t.Errorf("omega %f within the band: expected the curves within 10%%, got %f", w, d)
+			}
+		}
+	}
+	data, err := os.ReadFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("failed to read the result file: %v", err)
+	}
+	decoded, err := UnmarshalResultJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalResultJSON failed: %v", err)
+	}
+	if decoded.CriticalBand != band {
+		t.Errorf("expected the critical band %+v in the result file, got %+v", band, decoded.CriticalBand)
+	}
+
+	config.Assessment.BandTolerance = -5
+	if err := Validate(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error for a negative band tolerance, got %v", err)
+	}
+}
+
+// Test that gob result files keep the curves, including NaN values, and are decoded to JSON.
+func TestResultGob(t *testing.T) {
+	result := Result{
+		Omega:              []float64{1, 2, 3},
+		TrackPhaseVelocity: []float64{0, 150, 160},
+		SoilPhaseVelocity:  []float64{math.NaN(), 120, 130},
+		CriticalOmega:      2.5,
+		CriticalVelocity:   140,
+		FrequencyUnit:      UnitHertz,
+	}
+	var buf strings.Builder
+	if err := result.WriteGob(&buf); err != nil {
+		t.Fatalf("WriteGob failed: %v", err)
+	}
+	compressed, err := CompressResults([]byte(buf.String()))
+	if err != nil {
+		t.Fatalf("CompressResults failed: %v", err)
+	}
+	for name, data := range map[string][]byte{"plain": []byte(buf.String()), "compressed": compressed} {
+		decoded, err := UnmarshalResults(data, FormatGob)
+		if err != nil {
+			t.Fatalf("%s: UnmarshalResults failed: %v", name, err)
+		}
+		if !slices.Equal(decoded.Omega, result.Omega) || !math.IsNaN(decoded.SoilPhaseVelocity[0]) || decoded.SoilPhaseVelocity[2] != 130 ||
+			decoded.CriticalVelocity != 140 || decoded.FrequencyUnit != UnitHertz {
+			t.Errorf("%s: unexpected decoded result %+v", name, decoded)
+		}
+	}
+
+	if _, err := UnmarshalResults([]byte("not gob"), FormatGob); err == nil {
+		t.Error("expected an error for an invalid gob result file")
+	}
+	if _, err := UnmarshalResults(nil, FormatXLSX); err == nil {
+		t.Error("expected an error for a format that cannot be decoded")
+	}
+}
+
+// Test the policies for existing result files.
+func TestResolveOutput(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "results.json")
+
+	// Without a result file, every policy writes to it
+	for _, policy := range []string{"", ExistingError, ExistingSkip, ExistingOverwrite, ExistingVersion} {
+		output, skip, err := ResolveOutput(fileName, policy)
+		if err != nil || skip || output != fileName {
+			t.Errorf("policy %q without result file: got %s, %v, %v", policy, output, skip, err)
+		}
+	}
+
+	for _, name := range []string{"results.json", "results_v2.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatalf("failed to write result file: %v", err)
+		}
+	}
+	if output, skip, err := ResolveOutput(fileName, ExistingOverwrite); err != nil || skip || output != fileName {
+		t.Errorf("overwrite: got %s, %v, %v", output, skip, err)
+	}
+	if _, _, err := ResolveOutput(fileName, ExistingError); !errors.Is(err, ErrResultExists) || ErrorKind(err) != KindIO {
+		t.Errorf("error: expected ErrResultExists of kind %s, got %q (%v)", KindIO, ErrorKind(err), err)
+	}
+	if output, skip, err := ResolveOutput(fileName, ExistingSkip); err != nil || !skip || output != fileName {
+		t.Errorf("skip: got %s, %v, %v", output, skip, err)
+	}
+	if output, skip, err := ResolveOutput(fileName, ExistingVersion); err != nil || skip || output != filepath.Join(dir, "results_v3.json") {
+		t.Errorf("version-suffix: got %s, %v, %v", output, skip, err)
+	}
+	if _, _, err := ResolveOutput(fileName, "append"); ErrorKind(err) != KindConfig {
+		t.Errorf("expected an error of kind %s for an unsupported policy, got %v", KindConfig, err)
+	}
+}
+
+// Test that Validate reports the configuration errors of the analysis without computing it.
+func TestValidate(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if err := Validate(context.Background(), config); err != nil {
+		t.Fatalf("expected the sample configuration to be valid, got: %v", err)
+	}
+
+	invalid := config
+	invalid.TrackType = "monorail"
+	if err := Validate(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid track type: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	invalid = config
+	invalid.Frequency.Points = 0
+	if err := Validate(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("no frequencies: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	invalid = config
+	invalid.Assessment.Spectrum = "spectrum.csv"
+	if err := Validate(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("spectrum without operating speeds: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	invalid = config
+	invalid.Solver.SoilRootFinder = math_utils.SolverPolynomial
+	if err := Validate(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("polynomial soil root finder: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+
+}
+
+// Test that invalid soil layers are rejected by Validate and RunConfig as configuration
+// errors located at the invalid value.
+func TestValidateSoilLayers(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+
+	tests := []struct {
+		name   string
+		path   string
+		modify func(layer *SoilLayer)
+	}{
+		{"zero thickness", "soil_layers.0.thickness", func(layer *SoilLayer) { layer.Thickness = 0 }},
+		{"negative density", "soil_layers.0.density", func(layer *SoilLayer) { layer.Density = -5 }},
+		{"zero Young's modulus", "soil_layers.0.young_modulus", func(layer *SoilLayer) { layer.YoungModulus = 0 }},
+		{"Poisson's ratio above 0.5", "soil_layers.0.poisson_ratio", func(layer *SoilLayer) { layer.PoissonRatio = 0.7 }},
+		{"Poisson's ratio of -1", "soil_layers.0.poisson_ratio", func(layer *SoilLayer) { layer.PoissonRatio = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := config
+			invalid.SoilLayers = slices.Clone(config.SoilLayers)
+			tt.modify(&invalid.SoilLayers[0])
+			position := config.Positions[tt.path]
+			prefix := "line " + strconv.Itoa(position.Line) + ", column " + strconv.Itoa(position.Column) + " (" + tt.path + "): "
+
+			err := Validate(context.Background(), invalid)
+			if position.Line == 0 || ErrorKind(err) != KindConfig || !strings.HasPrefix(err.Error(), prefix) {
+				t.Errorf("Validate: expected a configuration error at %s, got %q (%v)", tt.path, ErrorKind(err), err)
+			}
+			_, err = RunConfig(context.Background(), invalid, "invalid", Options{})
+			if ErrorKind(err) != KindConfig || !strings.HasPrefix(err.Error(), prefix) {
+				t.Errorf("RunConfig: expected a configuration error at %s, got %q (%v)", tt.path, ErrorKind(err), err)
+			}
+		})
+	}
+
+	// The thickness of the halfspace is ignored
+	halfspace := config
+	halfspace.SoilLayers = slices.Clone(config.SoilLayers)
+	halfspace.SoilLayers[len(halfspace.SoilLayers)-1].Thickness = 0
+	if err := Validate(context.Background(), halfspace); err != nil {
+		t.Errorf("expected the thickness of the halfspace to be ignored, got %v", err)
+	}
+}
+
+// Test that configuration values are overridden by dot-paths and environment variables.
+func TestOverrides(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if err := SetValue(&config, "soil_layers.0.young_modulus", "5e7"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	if config.SoilLayers[0].YoungModulus != 5e7 {
+		t.Errorf("expected the Young's modulus of the first layer to be 5e7, got %g", config.SoilLayers[0].YoungModulus)
+	}
+	if err := SetValue(&config, "ground_vibration.distances", "[10, 20]"); err != nil || len(config.GroundVibration.Distances) != 2 {
+		t.Errorf("expected two distances, got %v (%v)", config.GroundVibration.Distances, err)
+	}
+	for _, path := range []string{"ballast_track.stiffness", "soil_layers.99.density", "frequency.points.0"} {
+		if err := SetValue(&config, path, "1"); ErrorKind(err) != KindConfig {
+			t.Errorf("%s: expected kind %s, got %q (%v)", path, KindConfig, ErrorKind(err), err)
+		}
+	}
+	if err := SetValue(&config, "frequency.points", "many"); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid value: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+
+	t.Setenv("GOTRAIN_TRACK_KRAILPAD", "6e8")
+	t.Setenv("GOTRAIN_SOIL_LAYERS_1_POISSON_RATIO", "0.3")
+	t.Setenv("GOTRAIN_FREQUENCY_UNIT", "Hz")
+	config, err = LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config with overrides: %v", err)
+	}
+	if config.TrackType != "ballast" || config.BallastTrack.KRailPad != 6e8 {
+		t.Errorf("expected the railpad stiffness of the ballast track to be 6e8, got %g", config.BallastTrack.KRailPad)
+	}
+	if config.SoilLayers[1].PoissonRatio != 0.3 || config.Frequency.Unit != "Hz" {
+		t.Errorf("expected the overridden Poisson's ratio and unit, got %g and %q", config.SoilLayers[1].PoissonRatio, config.Frequency.Unit)
+	}
+
+	t.Setenv("GOTRAIN_BALLAST_TRACK_RAILPAD", "6e8")
+	if _, err := LoadConfig("../../testdata/sample_config.yaml"); ErrorKind(err) != KindConfig {
+		t.Errorf("unknown variable: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the references to the variables of a configuration are replaced by their values.
+func TestVariables(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+variables:
+  pad: 6e8
+  name: stiff
+track_type: ballast
+ballast_track:
+  k_rail_pad: ${pad}
+ground_vibration:
+  distances: ["${pad}", 10]
+output:
+  file_name: results_${name}.json
+  format: "${name}"
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if config.BallastTrack.KRailPad != 6e8 || config.GroundVibration.Distances[0] != 6e8 {
+		t.Errorf("expected the railpad stiffness and first distance 6e8, got %g and %v", config.BallastTrack.KRailPad, config.GroundVibration.Distances)
+	}
+	if config.Output.FileName != "results_stiff.json" || config.Output.Format != "stiff" {
+		t.Errorf("expected the substituted file name and format, got %q and %q", config.Output.FileName, config.Output.Format)
+	}
+
+	for name, data := range map[string]string{
+		"undefined variable": "variables:\n  pad: 6e8\nballast_track:\n  k_rail_pad: ${stiffness}\n",
+		"list variable":      "variables:\n  pads: [1, 2]\n",
+		"string for number":  "variables:\n  pad: stiff\nballast_track:\n  k_rail_pad: ${pad}\n",
+	} {
+		if _, err := ParseConfig([]byte(data)); ErrorKind(err) != KindConfig {
+			t.Errorf("%s: expected kind %s, got %q (%v)", name, KindConfig, ErrorKind(err), err)
+		}
+	}
+}
+
+// Test that configuration files of older versions are upgraded and newer versions rejected.
+func TestConfigVersion(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if config.ConfigVersion != ConfigVersion || len(config.Migrated) != 0 {
+		t.Errorf("expected config_version %d without changes, got %d and %v", ConfigVersion, config.ConfigVersion, config.Migrated)
+	}
+	if _, err := ParseConfig([]byte("config_version: 99\ntrack_type: ballast\n")); ErrorKind(err) != KindConfig {
+		t.Errorf("newer version: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+
+	// A migration renaming a field of the ballast track
+	defer func(migrations []func(*yaml.Node) ([]string, error)) { configMigrations = migrations }(configMigrations)
+	configMigrations = []func(*yaml.Node) ([]string, error){
+		func(root *yaml.Node) ([]string, error) {
+			return renameKey(root, "ballast_track.railpad_stiffness", "ballast_track.k_rail_pad")
+		},
+	}
+	config, err = ParseConfig([]byte("ballast_track:\n  railpad_stiffness: 6e8\n"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if config.BallastTrack.KRailPad != 6e8 || len(config.Migrated) != 1 {
+		t.Errorf("expected the renamed railpad stiffness 6e8 and one change, got %g and %v", config.BallastTrack.KRailPad, config.Migrated)
+	}
+	config, err = ParseConfig([]byte("config_version: 1\nballast_track:\n  railpad_stiffness: 6e8\n"))
+	if err != nil || config.BallastTrack.KRailPad != 0 || len(config.Migrated) != 0 {
+		t.Errorf("expected a current file not to be upgraded, got %g and %v (%v)", config.BallastTrack.KRailPad, config.Migrated, err)
+	}
+	if _, err := ParseConfig([]byte("ballast_track:\n  railpad_stiffness: 6e8\n  k_rail_pad: 5e8\n")); ErrorKind(err) != KindConfig {
+		t.Errorf("old and new name: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that problems of configuration values are reported with their line, column and path.
+func TestConfigPositions(t *testing.T) {
+	_, err := ParseConfig([]byte("track_type: ballast\nfrequency:\n  min: 1\n  points: many\n"))
+	if ErrorKind(err) != KindConfig || !strings.Contains(err.Error(), "line 4, column 11 (frequency.points): cannot unmarshal") {
+		t.Errorf("expected the position and path of the invalid value, got %v", err)
+	}
+
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	position := config.Positions["track_type"]
+	config.TrackType = "monorail"
+	err = Validate(context.Background(), config)
+	prefix := "line " + strconv.Itoa(position.Line) + ", column " + strconv.Itoa(position.Column) + " (track_type): "
+	if position.Line == 0 || ErrorKind(err) != KindConfig || !strings.HasPrefix(err.Error(), prefix) {
+		t.Errorf("expected the position of track_type, got %v", err)
+	}
+	config.Positions = nil
+	if err := Validate(context.Background(), config); err == nil || strings.HasPrefix(err.Error(), "line") {
+		t.Errorf("expected the problem without a position, got %v", err)
+	}
+}
+
+// Test that results are written in the layout of TrainCritSpeed with output.format traincritspeed.
+func TestRunConfigTrainCritSpeedOutput(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Output.Format = FormatTrainCritSpeed
+	config.Frequency.Unit = UnitHertz
+	config.Frequency.Min, config.Frequency.Max = 0.2, 60
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("expected output file to be written: %v", err)
+	}
+
+	// The NaN literals of Python are not JSON, so they are decoded as null
+	type curve struct {
+		Omega         []float64  `json:"omega"`
+		PhaseVelocity []*float64 `json:"phase_velocity"`
+	}
+	var decoded struct {
+		SchemaVersion *int      `json:"schema_version"`
+		Omega         []float64 `json:"omega"`
+		Track         curve     `json:"track"`
+		Soil          curve     `json:"soil"`
+		Frequency     float64   `json:"frequency"`
+		CriticalSpeed float64   `json:"critical_speed"`
+	}
+	if err := json.Unmarshal([]byte(strings.ReplaceAll(string(data), "NaN", "null")), &decoded); err != nil {
+		t.Fatalf("invalid result file: %v", err)
+	}
+	if decoded.SchemaVersion != nil {
+		t.Error("unexpected schema_version in the result file")
+	}
+	if decoded.CriticalSpeed != result.CriticalVelocity || decoded.Frequency != result.CriticalOmega {
+		t.Errorf("unexpected critical speed in the result file: got %v at %v, want %v at %v",
+			decoded.CriticalSpeed, decoded.Frequency, result.CriticalVelocity, result.CriticalOmega)
+	}
+	// The frequencies are angular frequencies, as in TrainCritSpeed
+	if !slices.Equal(decoded.Omega, result.Omega) || !slices.Equal(decoded.Track.Omega, result.Omega) || !slices.Equal(decoded.Soil.Omega, result.Omega) {
+		t.Error("unexpected frequencies in the result file")
+	}
+	for i, v := range decoded.Soil.PhaseVelocity {
+		if expected := result.SoilPhaseVelocity[i]; (v == nil) != math.IsNaN(expected) || (v != nil && *v != expected) {
+			t.Errorf("soil phase velocity %d: got %v, want %v", i, v, expected)
+		}
+	}
+	if len(decoded.Track.PhaseVelocity) != len(result.TrackPhaseVelocity) {
+		t.Error("unexpected length of the track curve in the result file")
+	}
+
+	// NaN and infinite values are written as the literals of the Python json module
+	var streamed strings.Builder
+	r := Result{Omega: []float64{1, 2}, TrackPhaseVelocity: []float64{math.Inf(1), 3}, SoilPhaseVelocity: []float64{math.NaN(), math.Inf(-1)}}
+	if err := r.WriteTrainCritSpeed(&streamed); err != nil {
+		t.Fatalf("WriteTrainCritSpeed failed: %v", err)
+	}
+	for _, literal := range []string{"\t\t\tInfinity,", "\t\t\tNaN,", "\t\t\t-Infinity\n"} {
+		if !strings.Contains(streamed.String(), literal) {
+			t.Errorf("expected %q in the result file:\n%s", literal, streamed.String())
+		}
+	}
+}
+
+// Test that the streamed result files are identical to the in-memory encodings.
+func TestWriteResults(t *testing.T) {
+	n := 10000
+	result := Result{
+		Omega:              make([]float64, n),
+		TrackPhaseVelocity: make([]float64, n),
+		SoilPhaseVelocity:  make([]float64, n),
+		CriticalOmega:      1e-7,
+		CriticalVelocity:   -3.25e21,
+	}
+	for i := range n {
+		result.Omega[i] = float64(i) * 0.1
+		result.TrackPhaseVelocity[i] = 1 / (float64(i) + 1e-9)
+		result.SoilPhaseVelocity[i] = math.Sqrt(float64(i))
+	}
+	result.SoilPhaseVelocity[3] = math.NaN()
+
+	for name, r := range map[string]Result{"curves": result, "empty": {Omega: []float64{}}} {
+		var streamed strings.Builder
+		if err := r.WriteJSON(&streamed); err != nil {
+			t.Fatalf("%s: WriteJSON failed: %v", name, err)
+		}
+		expected, err := json.MarshalIndent(r.DispersionResults(), "", "\t")
+		if err != nil {
+			t.Fatalf("%s: MarshalIndent failed: %v", name, err)
+		}
+		if streamed.String() != string(expected)+"\n" {
+			t.Errorf("%s: streamed JSON differs from the marshaled results", name)
+		}
+
+		var proto strings.Builder
+		if err := r.WriteProto(&proto); err != nil {
+			t.Fatalf("%s: WriteProto failed: %v", name, err)
+		}
+		marshaled, err := r.MarshalProto()
+		if err != nil {
+			t.Fatalf("%s: MarshalProto failed: %v", name, err)
+		}
+		if proto.String() != string(marshaled) {
+			t.Errorf("%s: written protobuf differs from the marshaled message", name)
+		}
+	}
+
+	// Values without JSON representation are rejected before anything is written
+	result.Omega[5] = math.Inf(1)
+	var streamed strings.Builder
+	if err := result.WriteJSON(&streamed); err == nil || streamed.Len() != 0 {
+		t.Errorf("expected an error and no output for an infinite frequency, got %v", err)
+	}
+}
+
+// Test that the numbers of the result files and tables are rounded to the output precision.
+func TestOutputPrecision(t *testing.T) {
+	result := Result{
+		Omega:              []float64{1, 2.0 / 3},
+		TrackPhaseVelocity: []float64{0, 123.456789},
+		SoilPhaseVelocity:  []float64{math.NaN(), 1e-7 / 3},
+		CriticalOmega:      math.Pi,
+		CriticalVelocity:   200.0 / 3,
+		Precision:          4,
+	}
+	var streamed strings.Builder
+	if err := result.WriteJSON(&streamed); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	for _, expected := range []string{"0.6667", "123.5", `"NaN"`, "3.333e-8", `"critical_omega": 3.142`, `"critical_velocity": 66.67`} {
+		if !strings.Contains(streamed.String(), expected) {
+			t.Errorf("expected %s in the rounded JSON, got %s", expected, streamed.String())
+		}
+	}
+
+	var table strings.Builder
+	write := roundCSV(func(w io.Writer) error {
+		return WriteProfilesCSV(w, []ProfileCase{{Name: "soft", CriticalOmega: math.Pi, CriticalVelocity: 200.0 / 3}})
+	}, 3)
+	if err := write(&table); err != nil {
+		t.Fatalf("roundCSV failed: %v", err)
+	}
+	if table.String() != "name,critical_omega,critical_velocity\nsoft,3.14,66.7\n" {
+		t.Errorf("unexpected rounded table: %q", table.String())
+	}
+
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	config.Output.Precision = -1
+	if err := Validate(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error for a negative precision, got %v", err)
+	}
+}
+
+// Test that the critical velocity of the fast approximate mode is within 1% of the
+// default solution, for both track types and soft to stiff layered profiles.
+func TestComputeFast(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	worst := 0.0
+	for _, track := range []string{"ballast", "slabtrack"} {
+		for _, profile := range [][2]float64{{10e6, 1}, {10e6, 5}, {30e6, 2}, {80e6, 0.5}} {
+			config.TrackType = track
+			config.SoilLayers = []SoilLayer{
+				{Thickness: 2, Density: 1900, YoungModulus: profile[0], PoissonRatio: 0.35},
+				{Thickness: 4, Density: 2000, YoungModulus: profile[0] * profile[1], PoissonRatio: 0.3},
+				{Thickness: math.Inf(1), Density: 2100, YoungModulus: 2 * profile[0] * profile[1], PoissonRatio: 0.4},
+			}
+			config.Solver.Fast = false
+			expected, err := Compute(context.Background(), config)
+			if err != nil {
+				t.Fatalf("%s %v: Compute failed: %v", track, profile, err)
+			}
+			config.Solver.Fast = true
+			fast, err := Compute(context.Background(), config)
+			if err != nil {
+				t.Fatalf("%s %v: Compute in fast mode failed: %v", track, profile, err)
+			}
+
+			relative := math.Abs(fast.CriticalVelocity-expected.CriticalVelocity) / expected.CriticalVelocity
+			if relative > 0.01 {
+				t.Errorf("%s %v: fast critical velocity %v differs by %.2f%% from %v",
+					track, profile, fast.CriticalVelocity, 100*relative, expected.CriticalVelocity)
+			}
+			worst = math.Max(worst, relative)
+		}
+	}
+	t.Logf("largest difference of the critical velocity: %.3f%%", 100*worst)
+}
+
+// Test that Chandrupatla's method, for the track and to refine the roots of the fast
+// soil scan, gives the critical velocity of the default solution.
+func TestComputeChandrupatla(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	expected, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	config.Solver.RootFinder = math_utils.SolverChandrupatla
+	config.Solver.SoilRootFinder = math_utils.SolverChandrupatla
+	config.Solver.Fast = true
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute with Chandrupatla's method failed: %v", err)
+	}
+	if relative := math.Abs(result.CriticalVelocity-expected.CriticalVelocity) / expected.CriticalVelocity; relative > 1e-3 {
+		t.Errorf("expected the critical velocity %v, got %v", expected.CriticalVelocity, result.CriticalVelocity)
+	}
+}
+
+// Test that results are written as an Excel workbook with output.format xlsx.
+func TestRunConfigXLSXOutput(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.xlsx")
+	config.Output.Format = FormatXLSX
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+
+	workbook, err := excelize.OpenFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("result file is not a workbook: %v", err)
+	}
+	defer workbook.Close()
+	if sheets := workbook.GetSheetList(); !slices.Equal(sheets, []string{"Curves", "Summary"}) {
+		t.Errorf("unexpected sheets: %v", sheets)
+	}
+
+	// One header row and one row per frequency
+	curves, err := workbook.GetRows("Curves")
+	if err != nil {
+		t.Fatalf("failed to read the curves sheet: %v", err)
+	}
+	if len(curves) != len(result.Omega)+1 {
+		t.Errorf("unexpected number of rows in the curves sheet: got %d, want %d", len(curves), len(result.Omega)+1)
+	}
+	if v, err := strconv.ParseFloat(curves[1][0], 64); err != nil || math.Abs(v-result.Omega[0]) > 1e-9 {
+		t.Errorf("expected the frequency %v in the curves sheet, got %q", result.Omega[0], curves[1][0])
+	}
+
+	summary, err := workbook.GetRows("Summary")
+	if err != nil {
+		t.Fatalf("failed to read the summary sheet: %v", err)
+	}
+	values := make(map[string]string)
+	for _, row := range summary {
+		if len(row) == 2 {
+			values[row[0]] = row[1]
+		}
+	}
+	if v, err := strconv.ParseFloat(values["critical_velocity [m/s]"], 64); err != nil || math.Abs(v-result.CriticalVelocity) > 1e-9 {
+		t.Errorf("expected the critical velocity %v in the summary sheet, got %q", result.CriticalVelocity, values["critical_velocity [m/s]"])
+	}
+	if values["track_type"] != "ballast" || values["soil_layers.0.young_modulus"] == "" {
+		t.Errorf("expected the configuration in the summary sheet, got %v", values)
+	}
+}
+
+// Test that the moving_load section computes the deflection and bending moment versus
+// speed, with the resonance near the critical speed, and writes the speed-amplification
+// table next to the result file.
+func TestRunConfigMovingLoad(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.MovingLoad.Load = 1e5
+	config.MovingLoad.SpeedMin = 10
+	config.MovingLoad.SpeedMax = 150
+	config.MovingLoad.SpeedPoints = 29
+	config.MovingLoad.RailSectionModulus = 3.77e-4
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if len(result.MovingLoad) != config.MovingLoad.SpeedPoints {
+		t.Fatalf("expected %d moving load points, got %d", config.MovingLoad.SpeedPoints, len(result.MovingLoad))
+	}
+	peak := result.MovingLoad[0]
+	for _, p := range result.MovingLoad {
+		if p.Deflection > peak.Deflection {
+			peak = p
+		}
+	}
+	if math.Abs(peak.Speed-result.CriticalVelocity) > 0.15*result.CriticalVelocity {
+		t.Errorf("expected the resonance near the critical speed %g m/s, got %g m/s", result.CriticalVelocity, peak.Speed)
+	}
+
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_moving_load.csv")
+	if err != nil {
+		t.Fatalf("expected moving load file to be written: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "speed,speed_ratio,deflection,amplification,bending_moment,moment_amplification,stress" ||
+		len(lines) != config.MovingLoad.SpeedPoints+1 {
+		t.Errorf("unexpected moving load file: header %q, %d lines", lines[0], len(lines))
+	}
+	if peak.MomentAmplification <= 1 || peak.Stress != peak.BendingMoment/config.MovingLoad.RailSectionModulus {
+		t.Errorf("unexpected bending moment at resonance: amplification %g, stress %g", peak.MomentAmplification, peak.Stress)
+	}
+
+	config.MovingLoad.SpeedPoints = 1
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid moving load speeds: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the ground_vibration section estimates the vibration at the distances,
+// decreasing away from the track, and writes it next to the result file.
+func TestRunConfigGroundVibration(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.GroundVibration.Load = 1e5
+	config.GroundVibration.Speed = 60
+	config.GroundVibration.Distances = []float64{5, 20, 50}
+
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if result.MovingLoad != nil {
+		t.Errorf("expected no moving load response without a moving_load section")
+	}
+	if len(result.GroundVibration) != len(config.GroundVibration.Distances) {
+		t.Fatalf("expected %d vibration points, got %d", len(config.GroundVibration.Distances), len(result.GroundVibration))
+	}
+	for i := 1; i < len(result.GroundVibration); i++ {
+		if result.GroundVibration[i].VelocityLevel >= result.GroundVibration[i-1].VelocityLevel {
+			t.Errorf("expected the vibration to decrease with distance: %+v", result.GroundVibration)
+		}
+	}
+
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_ground_vibration.csv")
+	if err != nil {
+		t.Fatalf("expected ground vibration file to be written: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "distance,peak_velocity,velocity_level" || len(lines) != len(config.GroundVibration.Distances)+1 {
+		t.Errorf("unexpected ground vibration file: header %q, %d lines", lines[0], len(lines))
+	}
+
+	config.GroundVibration.Speed = 0
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid ground vibration speed: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test the frequency band and Mach angles of operating speeds above the soil phase velocity.
+func TestMachCones(t *testing.T) {
+	omega := []float64{1, 2, 3, 4, 5, 6}
+	soil := []float64{200, 150, 100, 80, 90, math.NaN()}
+
+	cones := MachCones(omega, soil, []float64{120, 50})
+	cone := cones[0]
+	if !cone.Supersonic() || math.Abs(cone.OmegaMin-2.6) > 1e-12 || cone.OmegaMax != 5 {
+		t.Errorf("unexpected band at 120 m/s: %+v", cone)
+	}
+	if minAngle := math.Asin(80.0/120) * 180 / math.Pi; math.Abs(cone.AngleMin-minAngle) > 1e-12 {
+		t.Errorf("expected smallest Mach angle %g, got %g", minAngle, cone.AngleMin)
+	}
+	if maxAngle := math.Asin(100.0/120) * 180 / math.Pi; math.Abs(cone.AngleMax-maxAngle) > 1e-12 {
+		t.Errorf("expected largest Mach angle %g, got %g", maxAngle, cone.AngleMax)
+	}
+	if cones[1].Supersonic() || cones[1] != (MachCone{Speed: 50}) {
+		t.Errorf("expected no Mach cone at 50 m/s, got %+v", cones[1])
+	}
+
+	// The assessment section writes the cones next to the result file
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Assessment.Speeds = []float64{40, 150}
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if result.MachCones[0].Supersonic() || !result.MachCones[1].Supersonic() {
+		t.Errorf("expected a Mach cone at 150 m/s only, got %+v", result.MachCones)
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_mach.csv")
+	if err != nil {
+		t.Fatalf("expected Mach cone file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[2], "150,true,") {
+		t.Errorf("unexpected Mach cone file:\n%s", data)
+	}
+
+	config.Assessment.Speeds = []float64{-1}
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid assessment speed: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test the Doppler shift of an excitation spectrum against the classical relations for
+// a non-dispersive soil, and the spectrum received next to the sample configuration.
+func TestDopplerShift(t *testing.T) {
+	omega := []float64{1, 1000}
+	soil := []float64{100, 100}
+	spectrum, err := ParseSpectrum(strings.NewReader("# axle passing\nfrequency,amplitude\n4,1\n20,0.5\n"))
+	if err != nil {
+		t.Fatalf("ParseSpectrum failed: %v", err)
+	}
+
+	lines, err := DopplerShift(omega, soil, spectrum, []float64{20, 150})
+	if err != nil {
+		t.Fatalf("DopplerShift failed: %v", err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(lines))
+	}
+	for _, l := range lines[:2] {
+		if math.Abs(l.Approaching-l.Frequency/0.8) > 1e-9 || math.Abs(l.Receding-l.Frequency/1.2) > 1e-9 {
+			t.Errorf("unexpected Doppler shift at 20 m/s: %+v", l)
+		}
+	}
+	if l := lines[2]; !math.IsNaN(l.Approaching) || math.Abs(l.Receding-l.Frequency/2.5) > 1e-9 {
+		t.Errorf("unexpected Doppler shift above the soil velocity: %+v", l)
+	}
+
+	for _, invalid := range []string{"frequency\n4\n", "frequency,amplitude\n-4,1\n", "frequency,amplitude\n"} {
+		if _, err := ParseSpectrum(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for spectrum %q", invalid)
+		}
+	}
+
+	// The assessment section writes the received spectrum next to the result file
+	dir := t.TempDir()
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(dir, "results.json")
+	config.Assessment.Spectrum = filepath.Join(dir, "spectrum.csv")
+	if err := os.WriteFile(config.Assessment.Spectrum, []byte("frequency,amplitude\n4,1\n20,0.5\n"), 0644); err != nil {
+		t.Fatalf("failed to write spectrum: %v", err)
+	}
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("spectrum without speeds: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	config.Assessment.Speeds = []float64{40}
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	for _, l := range result.Doppler {
+		if !(l.Approaching > l.Frequency) || !(l.Receding < l.Frequency) {
+			t.Errorf("expected the approaching frequency above and the receding below the nominal one: %+v", l)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "results_doppler.csv"))
+	if err != nil {
+		t.Fatalf("expected Doppler file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || lines[0] != "speed,frequency,amplitude,approaching,receding" {
+		t.Errorf("unexpected Doppler file:\n%s", data)
+	}
+}
+
+// Test the flagging of the peaks of the wheel-rail force in the critical regime, the
+// parsing of an irregularity spectrum, and the irregularity section of the sample
+// configuration, whose force peaks beyond its critical speed (78.2 m/s).
+func TestIrregularity(t *testing.T) {
+	points := []moving_load.IrregularityPoint{{Speed: 40, RMSForce: 1}, {Speed: 50, RMSForce: 2}, {Speed: 60, RMSForce: 1},
+		{Speed: 80, RMSForce: 3}, {Speed: 100, RMSForce: 2}, {Speed: 120, RMSForce: 4}}
+	speeds := FlagIrregularity(points, 80, 0.7)
+	for i, expected := range []bool{false, false, false, true, false, false} {
+		if speeds[i].Flagged != expected || speeds[i].SpeedRatio != points[i].Speed/80 {
+			t.Errorf("speed %g: expected flagged %v, got %+v", points[i].Speed, expected, speeds[i])
+		}
+	}
+	if !speeds[1].Peak || speeds[5].Peak {
+		t.Errorf("expected a peak below the critical regime and none at the last speed, got %+v", speeds)
+	}
+
+	irregularity, err := ParseIrregularity(strings.NewReader("# measured\nwavenumber,psd\n0.1,1e-5\n1,1e-7\n"))
+	if err != nil || len(irregularity.Wavenumbers) != 2 || irregularity.PSD[1] != 1e-7 {
+		t.Fatalf("unexpected irregularity %+v (%v)", irregularity, err)
+	}
+	for _, invalid := range []string{"wavenumber\n1\n", "wavenumber,psd\n1,1e-7\n0.5,1e-7\n", "wavenumber,psd\n1,-1\n2,1\n", "wavenumber,psd\n1,1e-7\n"} {
+		if _, err := ParseIrregularity(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for irregularity %q", invalid)
+		}
+	}
+
+	dir := t.TempDir()
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(dir, "results.json")
+	config.Irregularity.WheelLoad = 1e5
+	config.Irregularity.SpeedMin = 20
+	config.Irregularity.SpeedMax = 140
+	config.Irregularity.SpeedPoints = 13
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	flagged := 0
+	for _, s := range result.Irregularity {
+		if s.Flagged {
+			flagged++
+			if s.SpeedRatio < 1 || s.SpeedRatio > 1/0.7 {
+				t.Errorf("expected the flagged peak beyond the critical speed, got %+v", s)
+			}
+		}
+	}
+	if flagged == 0 || !(result.Irregularity[12].RMSForce > 5*result.Irregularity[2].RMSForce) {
+		t.Errorf("expected a flagged peak and a growing force, got %+v", result.Irregularity)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "results_irregularity.csv"))
+	if err != nil {
+		t.Fatalf("expected irregularity file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 14 ||
+		lines[0] != "speed,speed_ratio,rms_force,dynamic_factor,peak_omega,peak_psd,peak,flagged" {
+		t.Errorf("unexpected irregularity file:\n%s", data)
+	}
+
+	config.Irregularity.PSD = "smooth"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("unknown spectrum: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test the load spectrum of a train against the closed form of two symmetric bogies,
+// the flagging of the harmonics near the phase velocity, and the train section of the
+// sample configuration.
+func TestTrainExcitation(t *testing.T) {
+	train := Train{Vehicles: 3, VehicleLength: 26.4, BogieSpacing: 19, AxleSpacing: 3, AxleLoad: 170e3}
+	if positions := train.AxlePositions(); len(positions) != 12 || math.Abs(positions[0]-2.2) > 1e-12 || math.Abs(positions[11]-77) > 1e-12 {
+		t.Errorf("unexpected axle positions: %v", positions)
+	}
+	spectrum := train.LoadSpectrum()
+	if len(spectrum) != 17 {
+		t.Fatalf("expected 17 harmonics, got %d", len(spectrum))
+	}
+	for i, q := range spectrum {
+		n := float64(i + 1)
+		expected := 4 * train.AxleLoad / train.VehicleLength *
+			math.Abs(math.Cos(math.Pi*n*train.BogieSpacing/train.VehicleLength)*math.Cos(math.Pi*n*train.AxleSpacing/train.VehicleLength))
+		if math.Abs(q-expected) > 1e-9*expected+1e-6 {
+			t.Errorf("harmonic %d: expected %g N/m, got %g", i+1, expected, q)
+		}
+	}
+
+	// The soil is faster than the track below 3 rad/s, and neither curve has a root at 6 rad/s
+	omega := []float64{1, 5, 6, 100}
+	track := []float64{50, 150, 0, 150}
+	soil := []float64{200, 100, math.NaN(), 100}
+	if c := systemPhaseVelocity(omega, track, soil, 2); math.Abs(c-175) > 1e-12 {
+		t.Errorf("expected the soil phase velocity at 2 rad/s, got %g", c)
+	}
+	if c := systemPhaseVelocity(omega, track, soil, 200); c != 150 {
+		t.Errorf("expected the clamped track phase velocity at 200 rad/s, got %g", c)
+	}
+	largest := 0.0
+	for _, q := range spectrum {
+		largest = math.Max(largest, q)
+	}
+	harmonics := TrainExcitation(train, omega, track, soil, []float64{50, 80}, 0.7)
+	for _, h := range harmonics {
+		if h.Amplitude < dominantHarmonicFraction*largest {
+			t.Errorf("expected dominant harmonics only, got %+v", h)
+		}
+		if h.Flagged != (h.Speed/h.PhaseVelocity > 0.7) || math.Abs(h.Frequency-float64(h.Harmonic)*h.Speed/train.VehicleLength) > 1e-12 {
+			t.Errorf("unexpected harmonic: %+v", h)
+		}
+	}
+	if len(harmonics) == 0 || harmonics[0].Speed != 50 || harmonics[len(harmonics)-1].Speed != 80 {
+		t.Errorf("expected the harmonics of both speeds, got %+v", harmonics)
+	}
+
+	// The train section writes the harmonics next to the result file
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Train.Vehicles = 8
+	config.Train.VehicleLength = 26.4
+	config.Train.BogieSpacing = 19
+	config.Train.AxleSpacing = 3
+	config.Train.AxleLoad = 170e3
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("train without speeds: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	config.Assessment.Speeds = []float64{20, 70}
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	flagged := map[float64]bool{}
+	for _, h := range result.TrainExcitation {
+		flagged[h.Speed] = flagged[h.Speed] || h.Flagged
+	}
+	if flagged[20] || !flagged[70] {
+		t.Errorf("expected flagged harmonics at 70 m/s only, got %+v", result.TrainExcitation)
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_train.csv")
+	if err != nil {
+		t.Fatalf("expected train file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != len(result.TrainExcitation)+1 ||
+		lines[0] != "speed,harmonic,wavelength,frequency,amplitude,phase_velocity,speed_ratio,flagged" {
+		t.Errorf("unexpected train file:\n%s", data)
+	}
+
+	config.Train.BogieSpacing = 2
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid train: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test the inverse design of the soil improvement of the sample configuration for a
+// target critical speed, against the forward analysis of the improved profile.
+func TestSoilImprovement(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+
+	// The layers above the halfspace are stiffened by the same factor
+	config.Improvement.TargetSpeed = 90
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	design := result.Improvement
+	if design == nil || !(design.Value > 1) || math.Abs(design.CriticalVelocity-90) > 0.05 || design.Factors[2] != 1 {
+		t.Fatalf("unexpected stiffness design: %+v", design)
+	}
+	if math.Abs(result.CriticalVelocity-78.231) > 1e-3 {
+		t.Errorf("expected the critical velocity of the original profile, got %v", result.CriticalVelocity)
+	}
+	improved := config
+	improved.Improvement.TargetSpeed = 0
+	improved.SoilLayers = slices.Clone(config.SoilLayers)
+	for i := range 2 {
+		improved.SoilLayers[i].YoungModulus *= design.Value
+	}
+	reference, err := Compute(context.Background(), improved)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if math.Abs(reference.CriticalVelocity-design.CriticalVelocity) > 1e-9 {
+		t.Errorf("expected critical velocity %v of the improved profile, got %v", reference.CriticalVelocity, design.CriticalVelocity)
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_improvement.csv")
+	if err != nil {
+		t.Fatalf("expected improvement file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 4 ||
+		lines[0] != "layer,top,thickness,density,young_modulus,poisson_ratio,shear_wave_speed,stiffness_factor" {
+		t.Errorf("unexpected improvement file:\n%s", data)
+	}
+
+	// A target below the critical speed requires no improvement
+	config.Improvement.TargetSpeed = 70
+	if result, err = Compute(context.Background(), config); err != nil || result.Improvement.Value != 1 || result.Improvement.Evaluations != 1 {
+		t.Errorf("expected no improvement, got %+v (%v)", result.Improvement, err)
+	}
+
+	// The ground is improved from the surface, and the layer at that depth is split
+	config.Improvement.TargetSpeed = 85
+	config.Improvement.Parameter = ImproveDepth
+	if result, err = Compute(context.Background(), config); err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	design = result.Improvement
+	if !(design.Value > 0 && design.Value < 6) || math.Abs(design.CriticalVelocity-85) > 0.05 || len(design.Profile) != 4 {
+		t.Fatalf("unexpected depth design: %+v", design)
+	}
+	top := 0.0
+	for i, layer := range design.Profile[:3] {
+		if improvedLayer := top < design.Value; improvedLayer != (design.Factors[i] == defaultImprovementFactor) {
+			t.Errorf("layer %d at %g m: unexpected stiffness factor %g for the depth %g m", i+1, top, design.Factors[i], design.Value)
+		}
+		top += layer.Thickness
+	}
+
+	config.Improvement.Parameter = ImproveStiffness
+	config.Improvement.Layers = []int{1}
+	config.Improvement.MaxFactor = 1.1
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("unreachable target: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	config.Improvement.Layers = []int{4}
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid layer: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that geogrids in the ballast of the sample configuration stiffen the track and
+// raise the critical speed.
+func TestBallastReinforcement(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	config.BallastReinforcement.Depths = []float64{0.2}
+	params, factor, err := reinforceBallastTrack(config, createBallastTrackParams(config))
+	if err != nil {
+		t.Fatalf("reinforceBallastTrack failed: %v", err)
+	}
+	if expected := 1 / (1 - 0.15/0.35/3); math.Abs(factor-expected) > 1e-12 || math.Abs(params.EBallast-130e6*expected) > 1e-3 {
+		t.Errorf("expected factor %g on E_ballast, got %g (%g Pa)", expected, factor, params.EBallast)
+	}
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !(result.CriticalVelocity > reference.CriticalVelocity) {
+		t.Errorf("expected a higher critical speed with geogrids, got %v (%v without)", result.CriticalVelocity, reference.CriticalVelocity)
+	}
+
+	config.BallastReinforcement.Depths = []float64{0.5}
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("geogrid below the ballast: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	config.BallastReinforcement.Depths = []float64{0.2}
+	config.TrackType = "slabtrack"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("geogrids in a slab track: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that piles under the slab raise the critical speed of the slab track of the
+// sample configuration, to the resonance of the track when it no longer meets the soil
+// dispersion curve.
+func TestPiledSlabTrack(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.TrackType = "slabtrack"
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	config.TrackType = "piledslab"
+	config.Piles.Spacing = 3
+	config.Piles.Count = 2
+	config.Piles.YoungModulus = 30e9
+	config.Piles.Diameter = 0.1
+	config.Piles.Length = 20
+	params, err := createPiledSlabTrackParams(config)
+	if err != nil {
+		t.Fatalf("createPiledSlabTrackParams failed: %v", err)
+	}
+	if expected := 2 * 30e9 * math.Pi * 0.01 / 4 / 20 / 3; math.Abs(params.SupportStiffness()-expected) > 1e-3 {
+		t.Errorf("expected support stiffness %g, got %g", expected, params.SupportStiffness())
+	}
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !(result.CriticalVelocity > 1.5*reference.CriticalVelocity) {
+		t.Errorf("expected a much higher critical speed on piles, got %v (%v without)", result.CriticalVelocity, reference.CriticalVelocity)
+	}
+	if !(result.CriticalOmega > config.Frequency.Min && result.CriticalOmega < config.Frequency.Max) {
+		t.Errorf("expected the resonance of the track within the frequency range, got omega %v", result.CriticalOmega)
+	}
+
+	config.Piles.Length = 0
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("piles without length: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the axle load of the stress_dependence section stiffens the soil of the
+// sample configuration and raises its critical speed, reported with the critical
+// speed without the load.
+func TestStressDependence(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	config.StressDependence.AxleLoad = 225e3
+	config.StressDependence.GroundwaterDepth = 1
+	layers, err := SoilLayers(config)
+	if err != nil {
+		t.Fatalf("SoilLayers failed: %v", err)
+	}
+	// Load spread over twice the half-track width and 3 m, at the middle of the first
+	// layer, on the groundwater table
+	overburden := 2000 * 9.81 * 1.0
+	expected := 30e6 * math.Sqrt(1+225e3/((2.5+1)*(3+1))/overburden)
+	if math.Abs(layers[0].YoungsModulus-expected) > 1 || !(layers[1].YoungsModulus > 40e6) || !(layers[1].YoungsModulus < expected/30e6*40e6) {
+		t.Errorf("expected the first layer stiffened to %g, and the second less, got %+v", expected, layers[:2])
+	}
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !(result.CriticalVelocity > reference.CriticalVelocity) || math.Abs(result.UnloadedVelocity-reference.CriticalVelocity) > 1e-9 {
+		t.Errorf("expected a higher critical speed under the axle load, got %v (%v without, %v reference)",
+			result.CriticalVelocity, result.UnloadedVelocity, reference.CriticalVelocity)
+	}
+
+	config.StressDependence.Exponent = 2
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("exponent above 1: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the columns section improves the ground of the sample configuration and
+// reports the critical speed without the columns for comparison.
+func TestColumns(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// Stone columns down to 4 m split the second layer
+	config.Columns.YoungModulus = 100e6
+	config.Columns.Density = 2100
+	config.Columns.AreaRatio = 0.2
+	config.Columns.Depth = 4
+	layers, err := SoilLayers(config)
+	if err != nil {
+		t.Fatalf("SoilLayers failed: %v", err)
+	}
+	if len(layers) != 4 || layers[1].Thickness != 2 || math.Abs(layers[0].YoungsModulus-44e6) > 1 || layers[2].YoungsModulus != 40e6 {
+		t.Fatalf("unexpected improved layers: %+v", layers)
+	}
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if result.UntreatedVelocity != reference.CriticalVelocity || result.UntreatedOmega != reference.CriticalOmega {
+		t.Errorf("expected the critical speed %v without columns, got %v", reference.CriticalVelocity, result.UntreatedVelocity)
+	}
+	if !(result.CriticalVelocity > reference.CriticalVelocity) {
+		t.Errorf("expected a higher critical speed with columns, got %v (%v without)", result.CriticalVelocity, reference.CriticalVelocity)
+	}
+
+	config.Columns.AreaRatio = 1.2
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid columns: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the embankment variants of the sample configuration raise the critical
+// Test that the soil profiles of soil_profiles are computed against the same track.
+func TestSoilProfiles(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	soft := slices.Clone(config.SoilLayers)
+	soft[0].YoungModulus /= 2
+	profiled := config
+	profiled.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	profiled.SoilProfiles = []NamedSoilProfile{{Name: "BH 1", SoilLayers: config.SoilLayers}, {SoilLayers: soft}}
+	profiled.SoilLayers = nil
+	result, err := RunConfig(context.Background(), profiled, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+
+	profiles := result.Profiles
+	if len(profiles) != 2 || profiles[0].Name != "BH 1" || profiles[1].Name != "profile 2" {
+		t.Fatalf("unexpected soil profiles: %+v", profiles)
+	}
+	if profiles[0].CriticalVelocity != result.CriticalVelocity {
+		t.Errorf("expected the critical speed %v of the first profile, got %v", result.CriticalVelocity, profiles[0].CriticalVelocity)
+	}
+	config.SoilLayers = soft
+	expected, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if math.Abs(profiles[1].CriticalVelocity-expected.CriticalVelocity) > TOL {
+		t.Errorf("expected the critical speed %v of the soft profile, got %v", expected.CriticalVelocity, profiles[1].CriticalVelocity)
+	}
+
+	for _, name := range []string{"results_BH_1.json", "results_profile_2.json"} {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(profiled.Output.FileName), name))
+		if err != nil {
+			t.Fatalf("expected the result file of the profile to be written: %v", err)
+		}
+		if _, err := UnmarshalResultJSON(data); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(profiled.Output.FileName, ".json") + "_profiles.csv")
+	if err != nil {
+		t.Fatalf("expected the soil profiles file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || lines[0] != "name,critical_omega,critical_velocity" {
+		t.Errorf("unexpected soil profiles file:\n%s", data)
+	}
+
+	invalid := profiled
+	invalid.SoilLayers = soft
+	if _, err := Compute(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("soil_profiles with soil_layers: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+	invalid = profiled
+	invalid.SoilProfiles = []NamedSoilProfile{{Name: "BH/1", SoilLayers: soft}, {Name: "BH 1", SoilLayers: soft}}
+	if err := Validate(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("same file names: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// speed with their height, crest width and fill stiffness.
+func TestEmbankmentVariants(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	config.Embankment.YoungModulus = 80e6
+	config.Embankment.Variants = []EmbankmentVariant{
+		{Name: "existing"},
+		{Name: "raised", Height: 2, CrestWidth: 8},
+		{Height: 3, CrestWidth: 8},
+		{Name: "widened", Height: 2, CrestWidth: 14},
+		{Name: "stiffer fill", Height: 2, CrestWidth: 8, YoungModulus: 150e6},
+	}
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	cases := result.Embankments
+	if len(cases) != 5 || cases[2].Name != "variant 3" || cases[1].Embankment.SideSlope != defaultEmbankmentSideSlope {
+		t.Fatalf("unexpected embankment variants: %+v", cases)
+	}
+	if cases[0].CriticalVelocity != result.CriticalVelocity {
+		t.Errorf("expected the critical speed %v without embankment, got %v", result.CriticalVelocity, cases[0].CriticalVelocity)
+	}
+	for _, c := range cases[2:] {
+		if !(c.CriticalVelocity > cases[1].CriticalVelocity) {
+			t.Errorf("%s: expected a higher critical speed than the raised embankment (%v), got %v", c.Name, cases[1].CriticalVelocity, c.CriticalVelocity)
+		}
+	}
+	if !(cases[1].CriticalVelocity > cases[0].CriticalVelocity) {
+		t.Errorf("expected a higher critical speed on the embankment, got %v (%v without)", cases[1].CriticalVelocity, cases[0].CriticalVelocity)
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(config.Output.FileName, ".json") + "_embankment.csv")
+	if err != nil {
+		t.Fatalf("expected embankment file to be written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 6 ||
+		lines[0] != "name,height,crest_width,side_slope,young_modulus,fill_fraction,critical_omega,critical_velocity" {
+		t.Errorf("unexpected embankment file:\n%s", data)
+	}
+
+	config.Embankment.Variants = []EmbankmentVariant{{Name: "no fill stiffness", Height: 2, CrestWidth: 8, YoungModulus: -1}}
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid embankment: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that the soil_discretization section splits the soil layers without changing
+// the critical speed.
+func TestSoilDiscretization(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// The layers of 2 and 4 m are split in sublayers of 1 m; the halfspace is kept
+	config.SoilDiscretization.MaxThickness = 1
+	layers, err := SoilLayers(config)
+	if err != nil {
+		t.Fatalf("SoilLayers failed: %v", err)
+	}
+	if len(layers) != 7 || layers[2].Thickness != 1 || !math.IsInf(layers[6].Thickness, 1) {
+		t.Errorf("unexpected discretized layers: %+v", layers)
+	}
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if math.Abs(result.CriticalVelocity-reference.CriticalVelocity) > 1e-6 {
+		t.Errorf("expected critical velocity %v, got %v", reference.CriticalVelocity, result.CriticalVelocity)
+	}
+
+	// A quarter of the shortest wavelength at 400 rad/s is below 1 m
+	config.SoilDiscretization.WavelengthFraction = 0.25
+	if layers, err = SoilLayers(config); err != nil || len(layers) <= 7 {
+		t.Errorf("expected thinner sublayers with wavelength_fraction, got %d layers (%v)", len(layers), err)
+	}
+
+	config.SoilDiscretization.MaxThickness = -1
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid discretization: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test computing the critical speed with soil layers derived from a CPT.
+func TestComputeSoilCPT(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.SoilCPT.File = "../../testdata/cpt/CPT-01.gef"
+	config.SoilCPT.GroundwaterDepth = 1
+
+	// The soil layers of the sample must not be given as well
+	if _, err := Compute(context.Background(), config); err == nil {
+		t.Error("expected an error with both soil_layers and soil_cpt")
+	}
+
+	config.SoilLayers = nil
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !(result.CriticalVelocity > 0) {
+		t.Errorf("unexpected critical velocity: %v", result.CriticalVelocity)
+	}
+
+	config.SoilCPT.File = "missing.gef"
+	if _, err := Compute(context.Background(), config); err == nil {
+		t.Error("expected an error for a missing CPT file")
+	}
+}
+
+// Test computing the critical speed with the soil layers of a profile file.
+func TestComputeSoilProfile(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// The soil layers of the sample must not be given as well
+	config.SoilProfile.File = "../../testdata/profile/sample.model"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error with both soil_layers and soil_profile, got %v", err)
+	}
+
+	// The profile file holds the layers of the sample
+	config.SoilLayers = nil
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if math.Abs(result.CriticalVelocity-reference.CriticalVelocity) > 1e-3*reference.CriticalVelocity {
+		t.Errorf("critical velocity %v, want %v as with soil_layers", result.CriticalVelocity, reference.CriticalVelocity)
+	}
+
+	config.SoilProfile.File = "missing.model"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindIO {
+		t.Errorf("expected an io error for a missing profile file, got %v", err)
+	}
+}
+
+// Test parsing plain-text soil profiles.
+func TestParseSoilProfile(t *testing.T) {
+	// Vs and Poisson's ratio, comma-separated, with a halfspace of infinite thickness
+	layers, err := ParseSoilProfile(strings.NewReader("# h, Vs, nu, rho\n2, 100, 0.25, 1800\n\ninf, 200, 0.3, 2000\n"))
+	if err != nil {
+		t.Fatalf("ParseSoilProfile failed: %v", err)
+	}
+	if len(layers) != 2 || layers[0].Thickness != 2 || !math.IsInf(layers[1].Thickness, 1) {
+		t.Fatalf("unexpected layers: %+v", layers)
+	}
+	if math.Abs(layers[0].ShearWaveSpeed-100) > 1e-9 || math.Abs(layers[0].YoungsModulus-2*1.25*1800*100*100) > 1e-3 {
+		t.Errorf("unexpected first layer: %+v", layers[0])
+	}
+
+	// Vs before Vp gives the same layer as Vp before Vs
+	vsFirst, err := ParseSoilProfile(strings.NewReader("5 150 300 1900\n0 250 500 2000\n"))
+	if err != nil {
+		t.Fatalf("ParseSoilProfile failed: %v", err)
+	}
+	vpFirst, err := ParseSoilProfile(strings.NewReader("2\n5\t300\t150\t1900\n0\t500\t250\t2000\n"))
+	if err != nil {
+		t.Fatalf("ParseSoilProfile failed: %v", err)
+	}
+	for i := range vsFirst {
+		if vsFirst[i] != vpFirst[i] {
+			t.Errorf("layer %d: %+v with Vs first, %+v with Vp first", i, vsFirst[i], vpFirst[i])
+		}
+	}
+	if math.Abs(vsFirst[0].CompressionalWaveSpeed-300) > 1e-9 || math.Abs(vsFirst[0].PoissonRatio-1.0/3) > 1e-12 {
+		t.Errorf("unexpected first layer: %+v", vsFirst[0])
+	}
+
+	for _, data := range []string{
+		"",                                    // No layers
+		"3\n5 150 300 1900\n0 250 500 2000\n", // Wrong number of layers
+		"5 150 1900\n",                        // Missing column
+		"5 150 0.3 x\n",                       // Invalid density
+		"0 150 0.3 1900\n0 250 0.3 2000\n",    // Zero thickness above the halfspace
+		"5 150 200 1900\n",                    // Vp below √2 Vs
+		"5 0 0.3 1900\n",                      // Zero Vs
+	} {
+		if _, err := ParseSoilProfile(strings.NewReader(data)); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
+
+// siteProvider returns the sample CPT for every location.
+type siteProvider struct{}
+
+func (siteProvider) NearestCPT(ctx context.Context, location geodata.Location, radius float64) (geodata.Sounding, error) {
+	test, err := cpt.LoadGEF("../../testdata/cpt/CPT-01.gef")
+	return geodata.Sounding{ID: "SITE-1", Location: location, CPT: test}, err
+}
+
+// Test computing the critical speed with the CPT of a soil_cpt provider.
+func TestComputeSoilCPTProvider(t *testing.T) {
+	geodata.Register("site", siteProvider{})
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.SoilLayers = nil
+	config.SoilCPT.Provider = "site"
+	config.SoilCPT.Latitude, config.SoilCPT.Longitude = 52, 4.36
+	config.SoilCPT.GroundwaterDepth = 1
+
+	result, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	config.SoilCPT.Provider = ""
+	config.SoilCPT.File = "../../testdata/cpt/CPT-01.gef"
+	expected, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if result.CriticalVelocity != expected.CriticalVelocity {
+		t.Errorf("expected the critical velocity of the GEF file %v, got %v", expected.CriticalVelocity, result.CriticalVelocity)
+	}
+
+	config.SoilCPT.Provider = "site"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a config error with both file and provider, got: %v", err)
+	}
+	config.SoilCPT.File, config.SoilCPT.Provider = "", "unknown"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a config error for an unknown provider, got: %v", err)
+	}
+}
+
+// Test that failures are classified by kind.
+func TestErrorKind(t *testing.T) {
+	if _, err := LoadConfig("missing.yaml"); ErrorKind(err) != KindIO {
+		t.Errorf("missing config: expected kind %s, got %q (%v)", KindIO, ErrorKind(err), err)
+	}
+	if _, err := ParseConfig([]byte("track_type: [")); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid YAML: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	invalid := config
+	invalid.TrackType = "maglev"
+	if _, err := Compute(context.Background(), invalid); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid track type: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+
+	// Below the critical frequency, the curves do not intersect
+	narrow := config
+	narrow.Frequency.Max = 2
+	if _, err := Compute(context.Background(), narrow); ErrorKind(err) != KindNoIntersection {
+		t.Errorf("narrow frequency range: expected kind %s, got %q (%v)", KindNoIntersection, ErrorKind(err), err)
+	}
+
+	// A cancelled analysis is not a solver failure
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Compute(ctx, config); err == nil || ErrorKind(err) != "" {
+		t.Errorf("cancelled analysis: expected an unclassified error, got %q (%v)", ErrorKind(err), err)
+	}
+
+	output := config
+	output.Output.FileName = filepath.Join(t.TempDir(), "result.json")
+	output.Output.Format = "csv"
+	if _, err := RunConfig(context.Background(), output, "test", Options{}); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid format: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+// Test that result files without schema_version are upgraded to the current version.
+func TestUpgradeResults(t *testing.T) {
+	legacy := []byte(`{"omega":[1,2],"track_phase_velocity":[80,70],"soil_phase_velocity":["NaN",75],"critical_omega":1.5,"critical_velocity":72.5}`)
+
+	version, err := ResultsVersion(legacy)
+	if err != nil || version != 0 {
+		t.Fatalf("ResultsVersion: expected 0, got %d (%v)", version, err)
+	}
+	upgraded, from, err := UpgradeResults(legacy)
+	if err != nil {
+		t.Fatalf("UpgradeResults failed: %v", err)
+	}
+	if from != 0 {
+		t.Errorf("expected original version 0, got %d", from)
+	}
+	var results DispersionResults
+	if err := json.Unmarshal(upgraded, &results); err != nil {
+		t.Fatalf("invalid upgraded file: %v", err)
+	}
+	if results.SchemaVersion != SchemaVersion || results.CriticalVelocity != 72.5 || results.SoilPhaseVelocity[0] != "NaN" {
+		t.Errorf("unexpected upgraded file: %s", upgraded)
+	}
+
+	// Current files are unchanged by an upgrade
+	again, from, err := UpgradeResults(upgraded)
+	if err != nil || from != SchemaVersion || string(again) != string(upgraded) {
+		t.Errorf("expected an unchanged current file, got version %d (%v): %s", from, err, again)
+	}
+
+	result, err := UnmarshalResultJSON(legacy)
+	if err != nil {
+		t.Fatalf("UnmarshalResultJSON failed: %v", err)
+	}
+	if !math.IsNaN(result.SoilPhaseVelocity[0]) || result.SoilPhaseVelocity[1] != 75 || result.CriticalOmega != 1.5 {
+		t.Errorf("unexpected decoded result: %+v", result)
+	}
+
+	for _, data := range []string{`{"schema_version":99,"omega":[1]}`, `{"schema_version":"one"}`, `[1,2]`, `{"schema_version":0}`} {
+		if _, _, err := UpgradeResults([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
+
+// Test that a frequency range in Hz gives the critical speed of the same range in
+// rad/s, with the frequencies of the result files in Hz.
+func TestFrequencyUnitHertz(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	reference, err := Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	config.Frequency.Unit = UnitHertz
+	config.Frequency.Min /= 2 * math.Pi
+	config.Frequency.Max /= 2 * math.Pi
+	config.Output.FileName = filepath.Join(t.TempDir(), "results.json")
+	result, err := RunConfig(context.Background(), config, "sample", Options{})
+	if err != nil {
+		t.Fatalf("RunConfig failed: %v", err)
+	}
+	if math.Abs(result.CriticalVelocity-reference.CriticalVelocity) > 1e-9 || math.Abs(result.CriticalOmega-reference.CriticalOmega) > 1e-9 {
+		t.Errorf("expected the critical speed of the rad/s range, got %v at %v rad/s", result.CriticalVelocity, result.CriticalOmega)
+	}
+
+	data, err := os.ReadFile(config.Output.FileName)
+	if err != nil {
+		t.Fatalf("expected output file to be written: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if _, ok := fields["omega"]; ok {
+		t.Error("expected no omega field in a result file in Hz")
+	}
+	if f, ok := fields["critical_frequency"].(float64); !ok || math.Abs(f-reference.CriticalOmega/(2*math.Pi)) > 1e-9 {
+		t.Errorf("unexpected critical_frequency: %v", fields["critical_frequency"])
+	}
+	if f := fields["frequency"].([]any); f[0].(float64) != config.Frequency.Min {
+		t.Errorf("expected the first frequency %v Hz, got %v", config.Frequency.Min, f[0])
+	}
+
+	// Result files in Hz are read back in rad/s and upgraded in Hz
+	decoded, err := UnmarshalResultJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalResultJSON failed: %v", err)
+	}
+	if decoded.FrequencyUnit != UnitHertz || math.Abs(decoded.CriticalOmega-reference.CriticalOmega) > 1e-9 {
+		t.Errorf("unexpected decoded result: %v at %v rad/s", decoded.FrequencyUnit, decoded.CriticalOmega)
+	}
+	if upgraded, _, err := UpgradeResults(data); err != nil || !strings.Contains(string(upgraded), `"critical_frequency": `) {
+		t.Errorf("expected the upgraded file in Hz, got %v: %.200s", err, upgraded)
+	}
+
+	config.Frequency.Unit = "rpm"
+	if _, err := Compute(context.Background(), config); ErrorKind(err) != KindConfig {
+		t.Errorf("invalid unit: expected kind %s, got %q (%v)", KindConfig, ErrorKind(err), err)
+	}
+}
+
+func TestFrequencySegments(t *testing.T) {
+	config, err := LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	config.Frequency.Min, config.Frequency.Max, config.Frequency.Points = 0, 0, 0
+	config.Frequency.Segments = []FrequencySegment{{Min: 1, Max: 20, Points: 20}, {Min: 20, Max: 50, Points: 4}}
+
+	omega, err := Frequencies(config)
+	if err != nil {
+		t.Fatalf("Frequencies failed: %v", err)
+	}
+	if len(omega) != 23 || omega[0] != 1 || omega[19] != 20 || omega[20] != 30 || omega[22] != 50 {
+		t.Errorf("unexpected concatenated frequencies: %v", omega)
+	}
+	if err := Validate(context.Background(), config); err != nil {
+		t.Errorf("expected segments to be valid, got %v", err)
+	}
+
+	config.Frequency.Segments[1].Min = 10
+	if _, err := Frequencies(config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error for overlapping segments, got %v", err)
+	}
+	config.Frequency.Segments[1].Min = 20
+	config.Frequency.Points = 10
+	if _, err := Frequencies(config); ErrorKind(err) != KindConfig {
+		t.Errorf("expected a configuration error for segments combined with points, got %v", err)
+	}
+}
+
+// Test that the starter configurations are valid and can be computed.
+func TestScaffold(t *testing.T) {
+	for _, trackType := range []string{"ballast", "slabtrack", "piledslab"} {
+		data, err := Scaffold(ScaffoldOptions{TrackType: trackType, ResultFile: "results/run.json"})
+		if err != nil {
+			t.Fatalf("Scaffold(%s) failed: %v", trackType, err)
+		}
+		config, err := ParseConfig(data)
+		if err != nil {
+			t.Fatalf("Scaffold(%s) is not valid YAML: %v", trackType, err)
+		}
+		if config.TrackType != trackType || config.Output.FileName != "results/run.json" || config.Frequency.Points != 100 {
+			t.Errorf("Scaffold(%s): unexpected configuration %+v", trackType, config)
+		}
+		result, err := Compute(context.Background(), config)
+		if err != nil {
+			t.Fatalf("Compute of the %s scaffold failed: %v", trackType, err)
+		}
+		if !(result.CriticalVelocity > 0) {
+			t.Errorf("Scaffold(%s): unexpected critical speed %v", trackType, result.CriticalVelocity)
+		}
+	}
+
+	if _, err := Scaffold(ScaffoldOptions{TrackType: "maglev"}); err == nil {
+		t.Error("expected an error for an invalid track type")
+	}
+	if _, err := Scaffold(ScaffoldOptions{TrackType: "ballast", FrequencyMin: 500}); err == nil {
+		t.Error("expected an error for an invalid frequency range")
+	}
+}
+
+// BenchmarkRun measures a complete analysis of the sample configuration: loading the
+// configuration, the track and soil dispersion curves, the critical speed and the
+// result file.
+func BenchmarkRun(b *testing.B) {
+	data, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		b.Fatalf("failed to read sample config: %v", err)
+	}
+	dir := b.TempDir()
+	output := filepath.ToSlash(filepath.Join(dir, "dispersion_results.json"))
+	data = []byte(strings.Replace(string(data), `"dispersion_results.json"`, `"`+output+`"`, 1))
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		b.Fatalf("failed to write config: %v", err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := Run(configPath, false); err != nil {
+			b.Fatalf("Run failed: %v", err)
+		}
+	}
+}
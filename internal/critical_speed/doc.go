@@ -18,17 +18,53 @@
 // curves, where the phase velocity of waves in the track matches the phase velocity
 // of surface waves in the soil.
 //
+// Piles under a slab track ("piledslab") make the track faster than the soil at all
+// frequencies; the critical speed is then the lowest phase velocity of the track, the
+// resonance of the slab on the piles.
+//
+// The two curves are independent until their intersection, so the track dispersion
+// is computed concurrently with the soil dispersion; when either fails, the errors
+// are reported in the same order as if they were computed one after the other.
+// JSON result files are written while they are being encoded.
+//
 // # Configuration
 //
 // The package reads YAML configuration files that specify:
-//   - Track type (ballast or slab)
-//   - Frequency range for analysis
+//   - Track type (ballast, slab, or slab on piles)
+//   - Frequency range for analysis, in rad/s or Hz
 //   - Track-specific parameters (rail properties, sleeper/slab properties, etc.)
-//   - Soil layer profile (thickness, density, elastic properties)
+//   - Optional geogrids in the ballast layer, stiffening the ballast
+//   - Soil layer profile (thickness, density, elastic properties), or a plain-text
+//     profile file of the shear wave velocity (see ParseSoilProfile)
+//   - Optional named soil profiles, e.g. of several boreholes, computed against the
+//     same track instead of a single soil profile
+//   - Optional ground improvement by columns, compared with the untreated ground
+//   - Optional embankment variants, whose critical speeds are compared
+//   - Optional discretization of thick soil layers into thinner sublayers
+//   - Optional static axle load, stiffening the soil with its stress
+//   - Optional moving load, for the rail deflection versus train speed
+//   - Optional distances, for the ground vibration at an operating speed
+//   - Optional operating speeds to assess, for their Mach cones
+//   - Optional irregularity of the rail, for the wheel-rail force versus train speed
+//   - Optional train of identical vehicles, for its load harmonics at the operating speeds
+//   - Optional target critical speed, for the soil improvement that reaches it
 //   - Output file location for results
 //
 // See configs/sample_config.yaml for a complete configuration example.
 //
+// The config_version of a file gives the version of its layout (see ConfigVersion);
+// files of older versions are upgraded when parsed, with a warning for every renamed
+// field, so that archived configuration files keep working.
+//
+// A variables section defines values that are referenced as ${name} in the other
+// sections, so that a family of configurations is written as one parameterized file
+// (see ParseConfig).
+//
+// Values of a configuration file are overridden by environment variables named
+// GOTRAIN_ and the path of the value, e.g. GOTRAIN_TRACK_KRAILPAD=6e8 for the railpad
+// stiffness of the track (see ApplyEnv and SetValue), so that containers and batch
+// schedulers vary parameters without templating the file.
+//
 // # Results
 //
 // The analysis produces a JSON output file containing:
@@ -37,6 +73,59 @@
 //   - Soil phase velocity dispersion curve
 //   - Critical angular frequency (critical_omega)
 //   - Critical velocity (critical_velocity)
+//   - With assessment.band_tolerance, the critical band around the critical speed
+//     where the track and soil phase velocities are within the tolerance
+//     (critical_omega_min, critical_omega_max, critical_velocity_min,
+//     critical_velocity_max, see CriticalBandOf)
+//   - Site period and resonance frequency of the soil layers above the halfspace
+//     (site_period, resonance_frequency), by the quarter-wavelength method
+//
+// With output.format, the result file is instead a JSON file in the layout of the
+// Python TrainCritSpeed ("traincritspeed", see Result.WriteTrainCritSpeed), a
+// gotrain.v1.Result protocol buffer message ("protobuf", see Result.MarshalProto) or
+// an Excel workbook with a curves sheet and a summary sheet echoing the input
+// ("xlsx", see Result.MarshalXLSX).
+// With frequency.unit Hz, the JSON and Excel files give the frequencies in Hz,
+// under the names frequency and critical_frequency (see Result.FrequencyAxis).
+//
+// With a columns section, the soil layers above the treated depth are replaced by
+// their composite with the columns (see soil_dispersion.ImproveWithColumns), and the
+// critical speed of the untreated ground is stored in Result.UntreatedVelocity. With
+// the variants of an embankment section, the critical speed of each embankment built
+// on the soil profile is computed (see soil_dispersion.AddEmbankment), stored in
+// Result.Embankments and written as CSV. With the axle load of a stress_dependence
+// section, the moduli of the soil are raised for its stress (see
+// soil_dispersion.StiffenUnderLoad), and the critical speed of the soil without the
+// load is stored in Result.UnloadedVelocity.
+//
+// With a soil_profiles section, the soil dispersion curve and critical speed of every
+// profile are computed against the same track curve and stored in Result.Profiles;
+// every profile is written to a result file of its own (see ProfileFileName), and the
+// critical speeds as CSV. The other results describe the first profile.
+//
+// With a moving_load section, the steady-state deflection, bending moment and stress
+// of the rail versus the speed of the load are computed as well (see
+// moving_load.SpeedResponse), stored in Result.MovingLoad and written as a CSV
+// speed-amplification table next to the result file. With a ground_vibration
+// section, the free-field vibration of the ground at distances from the track is
+// estimated for an operating speed (see moving_load.GroundVibration), stored in
+// Result.GroundVibration and written as CSV next to the result file. With the
+// operating speeds of an assessment section, the frequency band in which each speed
+// exceeds the soil phase velocity and the angles of the Mach cone radiated there are
+// reported (see MachCones), stored in Result.MachCones and written as CSV. With an
+// excitation spectrum as well, the frequencies received by a stationary receiver are
+// computed with the dispersive soil (see DopplerShift), stored in Result.Doppler and
+// written as CSV. With a train section, the dominant harmonics of the load of its
+// axles are compared with the phase velocity of the track-soil system at the
+// operating speeds (see TrainExcitation), stored in Result.TrainExcitation and
+// written as CSV. With the wheel load of an irregularity section, the dynamic
+// wheel-rail force of the irregularity of the rail is computed versus speed (see
+// moving_load.IrregularityResponse), the speeds at which it peaks in the critical
+// regime flagged (see FlagIrregularity), stored in Result.Irregularity and written
+// as CSV. With an improvement section, the stiffness factor of selected
+// layers, or the depth of improved ground, for which the critical speed reaches a
+// target is solved around the forward model (see DesignImprovement), stored in
+// Result.Improvement and the improved profile written as CSV.
 //
 // # Usage
 //
@@ -51,11 +140,17 @@
 //
 // Or via the command-line interface:
 //
-//	go run cmd/critical_speed/main.go -config configs/sample_config.yaml
+//	go run ./cmd/gotrain run -config configs/sample_config.yaml
 //
 // Using the compiled binary:
 //
-//	./bin/critical_speed -config configs/sample_config.yaml
+//	./bin/gotrain run -config configs/sample_config.yaml
+//
+// Validate checks a configuration without computing its dispersion curves, as
+// gotrain validate does, so that the mistakes of a batch are found before it runs.
+//
+// Failures are classified by ErrorKind: KindConfig, KindSolver, KindNoIntersection
+// or KindIO, so callers can react to the type of a failure without parsing messages.
 //
 // # Example
 //
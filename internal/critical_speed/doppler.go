@@ -0,0 +1,206 @@
+package critical_speed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// SpectrumLine is a line of a nominal excitation spectrum, in the frame of the train
+// (e.g. the passing frequency of the axles or of the sleepers, or a wheel out-of-roundness).
+type SpectrumLine struct {
+	Frequency float64 // Frequency of the excitation [Hz]
+	Amplitude float64 // Amplitude of the excitation (in the unit of the spectrum)
+}
+
+// DopplerLine is a line of the excitation spectrum as seen by a stationary receiver
+// next to the track, while the train approaches and while it recedes.
+type DopplerLine struct {
+	Speed       float64 // Speed of the train [m/s]
+	Frequency   float64 // Nominal frequency of the excitation [Hz]
+	Amplitude   float64 // Amplitude of the excitation
+	Approaching float64 // Frequency received while the train approaches [Hz] (NaN when the train is faster than the soil waves)
+	Receding    float64 // Frequency received while the train recedes [Hz]
+}
+
+// LoadSpectrum reads a nominal excitation spectrum from a CSV file (see ParseSpectrum).
+//
+// Parameters:
+//   - path: Path to the CSV file, or s3:// or gs:// URL
+//
+// Returns:
+//   - []SpectrumLine: The lines of the spectrum
+//   - error: An error if the file cannot be read (KindIO) or is invalid (KindConfig)
+func LoadSpectrum(path string) ([]SpectrumLine, error) {
+	data, err := storage.ReadFile(path)
+	if err != nil {
+		return nil, classify(KindIO, fmt.Errorf("failed to read excitation spectrum: %v", err))
+	}
+	spectrum, err := ParseSpectrum(bytes.NewReader(data))
+	if err != nil {
+		return nil, classify(KindConfig, fmt.Errorf("invalid excitation spectrum %s: %v", path, err))
+	}
+	return spectrum, nil
+}
+
+// ParseSpectrum parses a nominal excitation spectrum from CSV data. The first row is a
+// header naming the columns "frequency" [Hz] and "amplitude"; other columns are
+// ignored. Lines starting with # are comments.
+//
+// Parameters:
+//   - r: Reader of the CSV data
+//
+// Returns:
+//   - []SpectrumLine: The lines of the spectrum, in the order of the file
+//   - error: An error if the data is invalid
+func ParseSpectrum(r io.Reader) ([]SpectrumLine, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	frequencyColumn, amplitudeColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "frequency":
+			frequencyColumn = i
+		case "amplitude":
+			amplitudeColumn = i
+		}
+	}
+	if frequencyColumn < 0 || amplitudeColumn < 0 {
+		return nil, fmt.Errorf("no frequency or amplitude column")
+	}
+
+	var spectrum []SpectrumLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		frequency, err := strconv.ParseFloat(strings.TrimSpace(record[frequencyColumn]), 64)
+		if err != nil || !(frequency > 0) {
+			return nil, fmt.Errorf("line %d: invalid frequency", line)
+		}
+		amplitude, err := strconv.ParseFloat(strings.TrimSpace(record[amplitudeColumn]), 64)
+		if err != nil || math.IsNaN(amplitude) || math.IsInf(amplitude, 0) {
+			return nil, fmt.Errorf("line %d: invalid amplitude", line)
+		}
+		spectrum = append(spectrum, SpectrumLine{Frequency: frequency, Amplitude: amplitude})
+	}
+	if len(spectrum) == 0 {
+		return nil, fmt.Errorf("no spectrum lines")
+	}
+	return spectrum, nil
+}
+
+// DopplerShift computes the frequencies of an excitation spectrum received by a
+// stationary receiver next to the track. The waves travel through the soil at the
+// phase velocity c of the soil dispersion curve, which depends on the received
+// frequency f, so the Doppler relations are solved for f:
+//
+//	approaching: f (1 - v / c(f)) = f₀
+//	receding:    f (1 + v / c(f)) = f₀
+//
+// The approaching frequency is NaN where the train outruns the soil waves (see
+// MachCones), as no wave reaches the receiver ahead of the train.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s]
+//   - soilPhaseVelocity: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - spectrum: The nominal excitation spectrum, in the frame of the train
+//   - speeds: Speeds of the train [m/s]
+//
+// Returns:
+//   - []DopplerLine: The received lines, for every speed and spectrum line
+//   - error: An error if the soil dispersion curve has fewer than two points
+func DopplerShift(omega []float64, soilPhaseVelocity []float64, spectrum []SpectrumLine, speeds []float64) ([]DopplerLine, error) {
+	var frequencies, velocities []float64
+	for i, c := range soilPhaseVelocity {
+		if !math.IsNaN(c) {
+			frequencies = append(frequencies, omega[i]/(2*math.Pi))
+			velocities = append(velocities, c)
+		}
+	}
+	phaseVelocity, err := math_utils.NewInterp1D(frequencies, velocities, math_utils.InterpLinear, math_utils.ExtrapolateClamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid soil dispersion curve for the Doppler shift: %v", err)
+	}
+	c := func(f float64) float64 {
+		v, _ := phaseVelocity.At(f)
+		return v
+	}
+
+	lines := make([]DopplerLine, 0, len(speeds)*len(spectrum))
+	for _, v := range speeds {
+		for _, s := range spectrum {
+			f0 := s.Frequency
+			tol := 1e-12 * f0
+
+			// Receding: the received frequency is below the nominal one
+			receding, err := math_utils.Brent(func(f float64) float64 { return f*(1+v/c(f)) - f0 }, 0, f0, tol)
+			if err != nil {
+				receding = math.NaN()
+			}
+
+			// Approaching: the received frequency is above the nominal one, bracketed
+			// by doubling while the train is slower than the soil waves
+			approaching := math.NaN()
+			approach := func(f float64) float64 { return f*(1-v/c(f)) - f0 }
+			for high := 2 * f0; high < 1e3*f0; high *= 2 {
+				if approach(high) > 0 {
+					if f, err := math_utils.Brent(approach, f0, high, tol); err == nil {
+						approaching = f
+					}
+					break
+				}
+			}
+
+			lines = append(lines, DopplerLine{Speed: v, Frequency: f0, Amplitude: s.Amplitude, Approaching: approaching, Receding: receding})
+		}
+	}
+	return lines, nil
+}
+
+// WriteDopplerCSV writes the received excitation spectrum as CSV, one row per speed
+// and spectrum line, with the columns speed [m/s], frequency [Hz], amplitude, and
+// approaching and receding [Hz], the frequencies received while the train approaches
+// and recedes. The approaching frequency is empty where the train outruns the soil waves.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - lines: The received lines
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteDopplerCSV(w io.Writer, lines []DopplerLine) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"speed", "frequency", "amplitude", "approaching", "receding"})
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, l := range lines {
+		writer.Write([]string{format(l.Speed), format(l.Frequency), format(l.Amplitude), format(l.Approaching), format(l.Receding)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
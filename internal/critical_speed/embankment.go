@@ -0,0 +1,102 @@
+package critical_speed
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+// Defaults of the embankment section of a configuration.
+const (
+	defaultEmbankmentSideSlope    = 1.5  // Slope of the sides, horizontal over vertical distance
+	defaultEmbankmentDensity      = 2000 // Density of the fill [kg/m^3]
+	defaultEmbankmentPoissonRatio = 0.3  // Poisson's ratio of the fill
+)
+
+// EmbankmentVariant defines an embankment compared in the embankment section of a
+// configuration.
+type EmbankmentVariant struct {
+	Name         string  `yaml:"name"`          // Name of the variant (default "variant" and its number)
+	Height       float64 `yaml:"height"`        // Height of the embankment [m] (0 for the soil profile without embankment)
+	CrestWidth   float64 `yaml:"crest_width"`   // Width of the crest [m]
+	YoungModulus float64 `yaml:"young_modulus"` // Young's modulus of the fill [Pa] (default that of the section)
+}
+
+// EmbankmentCase is the critical speed of the track on an embankment variant.
+type EmbankmentCase struct {
+	Name             string                     // Name of the variant
+	Embankment       soil_dispersion.Embankment // The embankment
+	CriticalOmega    float64                    // Critical angular frequency [rad/s]
+	CriticalVelocity float64                    // Critical train speed [m/s]
+}
+
+// embankmentVariants validates the embankment section of a configuration and returns
+// its variants, with the defaults of the section.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - []EmbankmentCase: The variants, without their critical speeds (nil without variants)
+//   - error: An error if a variant is invalid
+func embankmentVariants(config Config) ([]EmbankmentCase, error) {
+	section := config.Embankment
+	cases := make([]EmbankmentCase, 0, len(section.Variants))
+	for i, variant := range section.Variants {
+		c := EmbankmentCase{
+			Name: cmp.Or(variant.Name, fmt.Sprintf("variant %d", i+1)),
+			Embankment: soil_dispersion.Embankment{
+				Height:        variant.Height,
+				CrestWidth:    variant.CrestWidth,
+				SideSlope:     cmp.Or(section.SideSlope, defaultEmbankmentSideSlope),
+				YoungsModulus: cmp.Or(variant.YoungModulus, section.YoungModulus),
+				Density:       cmp.Or(section.Density, defaultEmbankmentDensity),
+				PoissonRatio:  cmp.Or(section.PoissonRatio, defaultEmbankmentPoissonRatio),
+			},
+		}
+		if _, err := soil_dispersion.AddEmbankment(nil, c.Embankment); err != nil {
+			return nil, classify(KindConfig, fmt.Errorf("embankment %s: %w", c.Name, err))
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, nil
+	}
+	return cases, nil
+}
+
+// embankmentColumns are the columns of the table written by WriteEmbankmentCSV.
+var embankmentColumns = []string{"name", "height", "crest_width", "side_slope", "young_modulus", "fill_fraction", "critical_omega", "critical_velocity"}
+
+// WriteEmbankmentCSV writes the critical speeds of embankment variants as CSV, one row
+// per variant, with the columns name, height [m], crest_width [m], side_slope,
+// young_modulus [Pa], fill_fraction, critical_omega [rad/s] and critical_velocity
+// [m/s].
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - cases: The embankment variants
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteEmbankmentCSV(w io.Writer, cases []EmbankmentCase) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(embankmentColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, c := range cases {
+		e := c.Embankment
+		row := []string{c.Name, format(e.Height), format(e.CrestWidth), format(e.SideSlope), format(e.YoungsModulus),
+			format(e.FillFraction()), format(c.CriticalOmega), format(c.CriticalVelocity)}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,77 @@
+package critical_speed
+
+import (
+	"context"
+	"errors"
+)
+
+// Kinds of analysis failures, reported by ErrorKind, so that callers can react to
+// the type of a failure without parsing error messages.
+const (
+	KindConfig         = "config"          // Invalid configuration (YAML syntax, parameters, CPT file)
+	KindSolver         = "solver"          // Failure of the track or soil dispersion solver
+	KindNoIntersection = "no_intersection" // The track and soil dispersion curves do not intersect
+	KindIO             = "io"              // Failure reading the configuration or writing the result or log file
+)
+
+// Error is an analysis failure of a known kind.
+type Error struct {
+	Kind string // One of the Kind constants
+	Err  error  // The underlying error
+}
+
+// Error returns the message of the underlying error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// classify attaches a kind to an error.
+//
+// Parameters:
+//   - kind: One of the Kind constants
+//   - err: The error (may be nil)
+//
+// Returns:
+//   - error: The classified error, or nil if err is nil
+func classify(kind string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// ErrorKind returns the kind of an error returned by the analysis functions.
+//
+// Parameters:
+//   - err: The error
+//
+// Returns:
+//   - string: One of the Kind constants, or an empty string for other failures
+//     (e.g. a cancelled context)
+func ErrorKind(err error) string {
+	var analysisErr *Error
+	if errors.As(err, &analysisErr) {
+		return analysisErr.Kind
+	}
+	return ""
+}
+
+// solverError classifies an error of a dispersion solver as KindSolver, unless it
+// is caused by the cancellation of the analysis.
+//
+// Parameters:
+//   - err: The error of the solver
+//
+// Returns:
+//   - error: The classified error
+func solverError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return classify(KindSolver, err)
+}
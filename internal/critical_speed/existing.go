@@ -0,0 +1,87 @@
+package critical_speed
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// Policies for a result file that already exists, supported by ResolveOutput.
+const (
+	ExistingError     = "error"          // Refuse to run the analysis
+	ExistingSkip      = "skip"           // Keep the existing result file, without running the analysis
+	ExistingOverwrite = "overwrite"      // Replace the existing result file
+	ExistingVersion   = "version-suffix" // Write to the first free name with a version suffix, e.g. results_v2.json
+)
+
+// ErrResultExists is reported (wrapped, as KindIO) by ResolveOutput with ExistingError
+// when the result file already exists.
+var ErrResultExists = errors.New("result file already exists")
+
+// CheckExistingPolicy checks that a policy for existing result files is supported.
+//
+// Parameters:
+//   - policy: The policy (empty means ExistingOverwrite)
+//
+// Returns:
+//   - error: An error if the policy is not supported
+func CheckExistingPolicy(policy string) error {
+	switch policy {
+	case "", ExistingError, ExistingSkip, ExistingOverwrite, ExistingVersion:
+		return nil
+	}
+	return classify(KindConfig, fmt.Errorf("invalid policy for existing result files: %s. Supported policies are '%s', '%s', '%s' or '%s'",
+		policy, ExistingError, ExistingSkip, ExistingOverwrite, ExistingVersion))
+}
+
+// ResolveOutput applies a policy for an existing result file before an analysis, so
+// that earlier results are not silently overwritten. The tables and the log file
+// written next to the result file follow its name.
+//
+// Parameters:
+//   - fileName: The result file of the configuration (local path, or s3:// or gs:// URL)
+//   - policy: One of the Existing constants (empty means ExistingOverwrite)
+//
+// Returns:
+//   - string: The result file to write: fileName, or its first free versioned name
+//     with ExistingVersion, e.g. results_v2.json, then results_v3.json
+//   - bool: True if the analysis is skipped (ExistingSkip and the file exists)
+//   - error: An error if the policy is not supported, if the file exists with
+//     ExistingError (wrapping ErrResultExists), or if its existence cannot be checked
+func ResolveOutput(fileName string, policy string) (string, bool, error) {
+	if err := CheckExistingPolicy(policy); err != nil {
+		return "", false, err
+	}
+	if policy == "" || policy == ExistingOverwrite {
+		return fileName, false, nil
+	}
+
+	exists, err := storage.Exists(fileName)
+	if err != nil {
+		return "", false, classify(KindIO, fmt.Errorf("error checking result file: %v", err))
+	}
+	if !exists {
+		return fileName, false, nil
+	}
+	switch policy {
+	case ExistingError:
+		return "", false, classify(KindIO, fmt.Errorf("%w: %s", ErrResultExists, fileName))
+	case ExistingSkip:
+		return fileName, true, nil
+	}
+
+	extension := OutputExt(fileName)
+	base := strings.TrimSuffix(fileName, extension)
+	for version := 2; ; version++ {
+		versioned := fmt.Sprintf("%s_v%d%s", base, version, extension)
+		exists, err := storage.Exists(versioned)
+		if err != nil {
+			return "", false, classify(KindIO, fmt.Errorf("error checking result file: %v", err))
+		}
+		if !exists {
+			return versioned, false, nil
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package critical_speed
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// Parameters of the soil improvement solved by DesignImprovement.
+const (
+	ImproveStiffness = "stiffness" // Factor on the Young's modulus of the improved layers
+	ImproveDepth     = "depth"     // Depth of the improved ground below the surface [m]
+)
+
+// Defaults of the improvement section of a configuration.
+const (
+	defaultImprovementFactor    = 2  // Stiffness factor of the improved ground, with parameter depth
+	defaultImprovementMaxFactor = 10 // Largest stiffness factor searched
+)
+
+// improvementTolerance is the absolute tolerance on the solved stiffness factor, or
+// depth [m].
+const improvementTolerance = 1e-4
+
+// Improvement describes a soil improvement whose extent is solved for a target
+// critical speed (see DesignImprovement).
+type Improvement struct {
+	Parameter       string  // Solved parameter: ImproveStiffness or ImproveDepth
+	Layers          []int   // Improved layers with ImproveStiffness, numbered from 1 at the surface
+	StiffnessFactor float64 // Factor on the Young's modulus of the improved ground with ImproveDepth
+	MaxFactor       float64 // Largest stiffness factor searched with ImproveStiffness
+	MaxDepth        float64 // Largest depth searched with ImproveDepth [m]
+}
+
+// ImprovementDesign is the soil improvement required for a target critical speed.
+type ImprovementDesign struct {
+	Parameter        string                  // Solved parameter: ImproveStiffness or ImproveDepth
+	Value            float64                 // Required stiffness factor, or depth of the improved ground [m]
+	TargetSpeed      float64                 // Target critical speed [m/s]
+	CriticalOmega    float64                 // Critical angular frequency of the improved profile [rad/s]
+	CriticalVelocity float64                 // Critical speed of the improved profile [m/s]
+	Evaluations      int                     // Number of critical speeds computed
+	Profile          []soil_dispersion.Layer // The improved soil profile
+	Factors          []float64               // Stiffness factor of each layer of the improved profile
+}
+
+// CriticalSpeedFunc computes the critical speed of the track on a soil profile, the
+// forward model of DesignImprovement.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - layers: The soil profile, with its wave speeds computed
+//
+// Returns:
+//   - float64: The critical angular frequency [rad/s]
+//   - float64: The critical speed [m/s]
+//   - error: An error if the critical speed cannot be computed
+type CriticalSpeedFunc func(ctx context.Context, layers []soil_dispersion.Layer) (float64, float64, error)
+
+// DesignImprovement solves the soil improvement required for a target critical
+// speed: the factor on the Young's modulus of the improved layers, or the depth
+// below the surface down to which the ground is improved by a stiffness factor. The
+// extent is bracketed by doubling the stiffness factor, or deepening the improvement
+// by a quarter of the largest depth, from no improvement until the target is reached,
+// and then solved by Brent's method. When the profile meets the target without
+// improvement, the design is no improvement.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - layers: The soil profile, with its wave speeds computed
+//   - target: Target critical speed [m/s]
+//   - improvement: The improvement, and the range of its extent
+//   - critical: Critical speed of a soil profile
+//
+// Returns:
+//   - ImprovementDesign: The improvement and the critical speed it gives
+//   - error: An error if the improvement is invalid, the target is not reached at the
+//     largest factor or depth, or a critical speed cannot be computed
+func DesignImprovement(ctx context.Context, layers []soil_dispersion.Layer, target float64, improvement Improvement,
+	critical CriticalSpeedFunc) (ImprovementDesign, error) {
+	lower, upper, err := improvement.bounds(layers)
+	if err != nil {
+		return ImprovementDesign{}, classify(KindConfig, err)
+	}
+	if !(target > 0) {
+		return ImprovementDesign{}, classify(KindConfig, fmt.Errorf("invalid improvement: target_speed must be positive"))
+	}
+
+	design := ImprovementDesign{Parameter: improvement.Parameter, TargetSpeed: target}
+	evaluate := func(value float64) error {
+		profile, factors := improvement.apply(layers, value)
+		omega, velocity, err := critical(ctx, profile)
+		design.Evaluations++
+		if err != nil {
+			return fmt.Errorf("error designing soil improvement (%s %g): %w", improvement.Parameter, value, err)
+		}
+		design.Value, design.CriticalOmega, design.CriticalVelocity = value, omega, velocity
+		design.Profile, design.Factors = profile, factors
+		return nil
+	}
+
+	// The bracket grows from no improvement until the target is reached, so that the
+	// curves are not evaluated far beyond the required improvement
+	if err := evaluate(lower); err != nil {
+		return ImprovementDesign{}, err
+	}
+	if design.CriticalVelocity >= target {
+		return design, nil
+	}
+	for design.CriticalVelocity < target {
+		if design.Value == upper {
+			return ImprovementDesign{}, classify(KindConfig, fmt.Errorf("invalid improvement: the target speed of %g m/s is not reached, "+
+				"the critical speed is %g m/s at %s %g", target, design.CriticalVelocity, improvement.Parameter, upper))
+		}
+		lower = design.Value
+		if err := evaluate(improvement.step(lower, upper)); err != nil {
+			return ImprovementDesign{}, err
+		}
+	}
+	upper = design.Value
+
+	// A failed evaluation stops the root finder at once
+	var evaluationErr error
+	value, err := math_utils.Brent(func(value float64) float64 {
+		if evaluationErr != nil {
+			return 0
+		}
+		if evaluationErr = evaluate(value); evaluationErr != nil {
+			return 0
+		}
+		return design.CriticalVelocity - target
+	}, lower, upper, improvementTolerance)
+	if evaluationErr != nil {
+		return ImprovementDesign{}, evaluationErr
+	}
+	if err != nil {
+		return ImprovementDesign{}, solverError(fmt.Errorf("error designing soil improvement: %w", err))
+	}
+	if value != design.Value {
+		if err := evaluate(value); err != nil {
+			return ImprovementDesign{}, err
+		}
+	}
+	return design, nil
+}
+
+// bounds returns the range of the extent of an improvement of a soil profile.
+//
+// Parameters:
+//   - layers: The soil profile
+//
+// Returns:
+//   - float64: No improvement: a factor of 1, or a depth of 0
+//   - float64: The largest factor or depth
+//   - error: An error if the improvement is invalid
+func (i Improvement) bounds(layers []soil_dispersion.Layer) (float64, float64, error) {
+	switch i.Parameter {
+	case ImproveStiffness:
+		if len(i.Layers) == 0 {
+			return 0, 0, fmt.Errorf("invalid improvement: no layers to improve")
+		}
+		for _, layer := range i.Layers {
+			if layer < 1 || layer > len(layers) {
+				return 0, 0, fmt.Errorf("invalid improvement: layer %d is not in the soil profile of %d layers", layer, len(layers))
+			}
+		}
+		if !(i.MaxFactor > 1) {
+			return 0, 0, fmt.Errorf("invalid improvement: max_factor must be larger than 1")
+		}
+		return 1, i.MaxFactor, nil
+	case ImproveDepth:
+		if !(i.StiffnessFactor > 0) {
+			return 0, 0, fmt.Errorf("invalid improvement: stiffness_factor must be positive")
+		}
+		if !(i.MaxDepth > 0) || math.IsInf(i.MaxDepth, 1) {
+			return 0, 0, fmt.Errorf("invalid improvement: max_depth must be positive and finite")
+		}
+		return 0, i.MaxDepth, nil
+	default:
+		return 0, 0, fmt.Errorf("invalid improvement parameter: %s. Supported parameters are '%s' or '%s'", i.Parameter, ImproveStiffness, ImproveDepth)
+	}
+}
+
+// step returns the next extent of an improvement when its bracket grows: twice the
+// stiffness factor, or a quarter of the largest depth deeper.
+//
+// Parameters:
+//   - value: The current stiffness factor or depth
+//   - upper: The largest factor or depth
+//
+// Returns:
+//   - float64: The next factor or depth, at most upper
+func (i Improvement) step(value, upper float64) float64 {
+	if i.Parameter == ImproveStiffness {
+		return math.Min(2*value, upper)
+	}
+	return math.Min(value+upper/4, upper)
+}
+
+// apply improves a soil profile. With ImproveDepth, the layer at the depth of the
+// improvement is split at that depth (see soil_dispersion.SplitAt).
+//
+// Parameters:
+//   - layers: The soil profile
+//   - value: The stiffness factor, or the depth of the improved ground [m]
+//
+// Returns:
+//   - []soil_dispersion.Layer: The improved profile (a copy), with its wave speeds computed
+//   - []float64: The stiffness factor of each layer of the improved profile
+func (i Improvement) apply(layers []soil_dispersion.Layer, value float64) ([]soil_dispersion.Layer, []float64) {
+	profile := make([]soil_dispersion.Layer, 0, len(layers)+1)
+	factors := make([]float64, 0, len(layers)+1)
+	add := func(layer soil_dispersion.Layer, factor float64) {
+		layer.YoungsModulus *= factor
+		layer.WaveSpeed()
+		profile = append(profile, layer)
+		factors = append(factors, factor)
+	}
+
+	if i.Parameter == ImproveStiffness {
+		for n, layer := range layers {
+			factor := 1.0
+			for _, improved := range i.Layers {
+				if improved == n+1 {
+					factor = value
+				}
+			}
+			add(layer, factor)
+		}
+		return profile, factors
+	}
+
+	top := 0.0
+	for _, layer := range soil_dispersion.SplitAt(layers, value) {
+		factor := 1.0
+		if top < value {
+			factor = i.StiffnessFactor
+		}
+		add(layer, factor)
+		top += layer.Thickness
+	}
+	return profile, factors
+}
+
+// improvementParameters validates the improvement section of a configuration, with
+// its defaults: the layers above the halfspace with parameter stiffness, and the top
+// of the halfspace as largest depth. The layers of the profile are those of
+// soil_layers, or of the CPT, with the columns and before discretization.
+//
+// Parameters:
+//   - config: The configuration structure
+//   - layers: The soil profile
+//
+// Returns:
+//   - Improvement: The improvement
+//   - error: An error if the section is invalid
+func improvementParameters(config Config, layers []soil_dispersion.Layer) (Improvement, error) {
+	section := config.Improvement
+	improvement := Improvement{
+		Parameter:       cmp.Or(section.Parameter, ImproveStiffness),
+		Layers:          section.Layers,
+		StiffnessFactor: cmp.Or(section.StiffnessFactor, defaultImprovementFactor),
+		MaxFactor:       cmp.Or(section.MaxFactor, defaultImprovementMaxFactor),
+		MaxDepth:        section.MaxDepth,
+	}
+	if improvement.Parameter == ImproveStiffness && len(improvement.Layers) == 0 {
+		for n := 1; n < len(layers); n++ {
+			improvement.Layers = append(improvement.Layers, n)
+		}
+	}
+	if improvement.Parameter == ImproveDepth && improvement.MaxDepth == 0 {
+		for _, layer := range layers[:max(len(layers)-1, 0)] {
+			improvement.MaxDepth += layer.Thickness
+		}
+	}
+	if _, _, err := improvement.bounds(layers); err != nil {
+		return Improvement{}, classify(KindConfig, err)
+	}
+	return improvement, nil
+}
+
+// improvementColumns are the columns of the table written by WriteImprovementCSV.
+var improvementColumns = []string{"layer", "top", "thickness", "density", "young_modulus", "poisson_ratio", "shear_wave_speed", "stiffness_factor"}
+
+// WriteImprovementCSV writes the improved soil profile of a design as CSV, one row
+// per layer, with the columns layer (from 1 at the surface), top [m], thickness [m],
+// density [kg/m^3], young_modulus [Pa], poisson_ratio, shear_wave_speed [m/s] and
+// stiffness_factor.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - design: The soil improvement
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteImprovementCSV(w io.Writer, design ImprovementDesign) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(improvementColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	top := 0.0
+	for n, layer := range design.Profile {
+		row := []string{strconv.Itoa(n + 1), format(top), format(layer.Thickness), format(layer.Density), format(layer.YoungsModulus),
+			format(layer.PoissonRatio), format(layer.ShearWaveSpeed), format(design.Factors[n])}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		top += layer.Thickness
+	}
+	writer.Flush()
+	return writer.Error()
+}
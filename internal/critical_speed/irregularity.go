@@ -0,0 +1,259 @@
+package critical_speed
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	moving_load "github.com/PlatypusBytes/GoTrain/internal/moving_load"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// Defaults of the irregularity section of a configuration.
+const (
+	defaultIrregularityMinWavelength = 1     // Shortest wavelength of the German spectra [m]
+	defaultIrregularityMaxWavelength = 80    // Longest wavelength of the German spectra [m]
+	defaultUnsprungMass              = 900   // Unsprung mass on a rail: half of a wheelset [kg]
+	defaultContactStiffness          = 1.4e9 // Linearized Hertzian stiffness of the wheel-rail contact [N/m]
+	defaultRegimeRatio               = 0.7   // Lowest ratio of the speed to the critical speed in the critical regime
+)
+
+// irregularityPoints is the number of wavenumbers of the German spectra.
+const irregularityPoints = 60
+
+// Spectra of the vertical irregularity of the rail of the psd field of the irregularity section.
+const (
+	PSDGermanLow  = "german_low"  // German spectrum of low disturbance (see moving_load.GermanLowDisturbance)
+	PSDGermanHigh = "german_high" // German spectrum of high disturbance (see moving_load.GermanHighDisturbance)
+)
+
+// IrregularitySpeed is the dynamic wheel-rail force of the irregularity of the rail at
+// a speed, with the flag of the critical regime.
+type IrregularitySpeed struct {
+	moving_load.IrregularityPoint
+	SpeedRatio float64 // Speed relative to the critical speed
+	Peak       bool    // Whether the RMS force is larger than at the neighbouring speeds
+	Flagged    bool    // Whether the RMS force peaks in the critical regime
+}
+
+// irregularityInput holds the parameters of the irregularity section.
+type irregularityInput struct {
+	load         moving_load.Parameters   // Static wheel load, speeds, track width and damping of the soil
+	wheel        moving_load.Wheel        // The wheel
+	irregularity moving_load.Irregularity // Spectrum of the irregularity of the rail
+	regimeRatio  float64                  // Lowest ratio of the speed to the critical speed in the critical regime
+}
+
+// irregularityParameters returns the parameters of the irregularity section of a
+// configuration. The width of the track and the damping of the soil are those of the
+// moving_load section.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - irregularityInput: The parameters (zero load without an irregularity section)
+//   - error: An error if the section is invalid (KindConfig) or the spectrum file
+//     cannot be read (KindIO)
+func irregularityParameters(config Config) (irregularityInput, error) {
+	section := config.Irregularity
+	if section.WheelLoad == 0 {
+		return irregularityInput{}, nil
+	}
+	if !(section.SpeedMin > 0) || !(section.SpeedMax > section.SpeedMin) || section.SpeedPoints < 2 {
+		return irregularityInput{}, classify(KindConfig, fmt.Errorf("invalid irregularity speeds: speed_min must be positive, "+
+			"speed_max larger than speed_min and speed_points at least 2"))
+	}
+	wheel := moving_load.Wheel{
+		UnsprungMass:     cmp.Or(section.UnsprungMass, defaultUnsprungMass),
+		ContactStiffness: cmp.Or(section.ContactStiffness, defaultContactStiffness),
+	}
+	if !(wheel.UnsprungMass > 0) || !(wheel.ContactStiffness > 0) {
+		return irregularityInput{}, classify(KindConfig, fmt.Errorf("invalid irregularity: unsprung_mass and contact_stiffness must be positive"))
+	}
+	regimeRatio := cmp.Or(section.RegimeRatio, defaultRegimeRatio)
+	if !(regimeRatio > 0 && regimeRatio < 1) {
+		return irregularityInput{}, classify(KindConfig, fmt.Errorf("invalid irregularity: regime_ratio must be in (0, 1)"))
+	}
+
+	var irregularity moving_load.Irregularity
+	if section.File != "" {
+		var err error
+		if irregularity, err = LoadIrregularity(section.File); err != nil {
+			return irregularityInput{}, err
+		}
+	} else {
+		var roughness float64
+		switch cmp.Or(section.PSD, PSDGermanLow) {
+		case PSDGermanLow:
+			roughness = moving_load.GermanLowDisturbance
+		case PSDGermanHigh:
+			roughness = moving_load.GermanHighDisturbance
+		default:
+			return irregularityInput{}, classify(KindConfig, fmt.Errorf("invalid irregularity psd: %s. Supported spectra are '%s' or '%s'",
+				section.PSD, PSDGermanLow, PSDGermanHigh))
+		}
+		minWavelength := cmp.Or(section.MinWavelength, defaultIrregularityMinWavelength)
+		maxWavelength := cmp.Or(section.MaxWavelength, defaultIrregularityMaxWavelength)
+		if !(minWavelength > 0) || !(maxWavelength > minWavelength) {
+			return irregularityInput{}, classify(KindConfig, fmt.Errorf("invalid irregularity: min_wavelength must be positive and smaller than max_wavelength"))
+		}
+		irregularity = moving_load.GermanIrregularity(roughness, minWavelength, maxWavelength, irregularityPoints)
+	}
+
+	params, err := trackSoilParameters(config, section.WheelLoad)
+	if err != nil {
+		return irregularityInput{}, err
+	}
+	params.Speeds = math_utils.Linspace(section.SpeedMin, section.SpeedMax, section.SpeedPoints)
+	return irregularityInput{load: params, wheel: wheel, irregularity: irregularity, regimeRatio: regimeRatio}, nil
+}
+
+// LoadIrregularity reads the spectrum of the irregularity of the rail from a CSV file
+// (see ParseIrregularity).
+//
+// Parameters:
+//   - path: Path to the CSV file, or s3:// or gs:// URL
+//
+// Returns:
+//   - moving_load.Irregularity: The spectrum
+//   - error: An error if the file cannot be read (KindIO) or is invalid (KindConfig)
+func LoadIrregularity(path string) (moving_load.Irregularity, error) {
+	data, err := storage.ReadFile(path)
+	if err != nil {
+		return moving_load.Irregularity{}, classify(KindIO, fmt.Errorf("failed to read irregularity spectrum: %v", err))
+	}
+	irregularity, err := ParseIrregularity(bytes.NewReader(data))
+	if err != nil {
+		return moving_load.Irregularity{}, classify(KindConfig, fmt.Errorf("invalid irregularity spectrum %s: %v", path, err))
+	}
+	return irregularity, nil
+}
+
+// ParseIrregularity parses the spectrum of the irregularity of the rail from CSV data.
+// The first row is a header naming the columns "wavenumber" [rad/m] and "psd", the
+// one-sided power spectral density [m²/(rad/m)]; other columns are ignored. Lines
+// starting with # are comments.
+//
+// Parameters:
+//   - r: Reader of the CSV data
+//
+// Returns:
+//   - moving_load.Irregularity: The spectrum, in the order of the file
+//   - error: An error if the data is invalid, or has fewer than two increasing wavenumbers
+func ParseIrregularity(r io.Reader) (moving_load.Irregularity, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return moving_load.Irregularity{}, fmt.Errorf("failed to read header: %v", err)
+	}
+	wavenumberColumn, psdColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "wavenumber":
+			wavenumberColumn = i
+		case "psd":
+			psdColumn = i
+		}
+	}
+	if wavenumberColumn < 0 || psdColumn < 0 {
+		return moving_load.Irregularity{}, fmt.Errorf("no wavenumber or psd column")
+	}
+
+	var irregularity moving_load.Irregularity
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return moving_load.Irregularity{}, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		wavenumber, err := strconv.ParseFloat(strings.TrimSpace(record[wavenumberColumn]), 64)
+		if err != nil || !(wavenumber > 0) || math.IsInf(wavenumber, 1) {
+			return moving_load.Irregularity{}, fmt.Errorf("line %d: invalid wavenumber", line)
+		}
+		if n := len(irregularity.Wavenumbers); n > 0 && !(wavenumber > irregularity.Wavenumbers[n-1]) {
+			return moving_load.Irregularity{}, fmt.Errorf("line %d: wavenumbers must be increasing", line)
+		}
+		psd, err := strconv.ParseFloat(strings.TrimSpace(record[psdColumn]), 64)
+		if err != nil || !(psd >= 0) || math.IsInf(psd, 1) {
+			return moving_load.Irregularity{}, fmt.Errorf("line %d: invalid psd", line)
+		}
+		irregularity.Wavenumbers = append(irregularity.Wavenumbers, wavenumber)
+		irregularity.PSD = append(irregularity.PSD, psd)
+	}
+	if len(irregularity.Wavenumbers) < 2 {
+		return moving_load.Irregularity{}, fmt.Errorf("at least two wavenumbers are required")
+	}
+	return irregularity, nil
+}
+
+// FlagIrregularity flags the speeds at which the dynamic wheel-rail force peaks in the
+// critical regime. The RMS force peaks at a speed where it is larger than at the
+// neighbouring speeds, e.g. where the softening of the track and soil near the
+// critical speed tunes the wheel on the track to the irregularity. The critical
+// regime spans the speeds from regimeRatio to 1 / regimeRatio times the critical
+// speed.
+//
+// Parameters:
+//   - points: The dynamic wheel-rail force at every speed, in increasing order of speed
+//   - criticalVelocity: The critical speed [m/s]
+//   - regimeRatio: Lowest ratio of the speed to the critical speed in the critical regime
+//
+// Returns:
+//   - []IrregularitySpeed: The force and its flag at every speed, in the order of points
+func FlagIrregularity(points []moving_load.IrregularityPoint, criticalVelocity float64, regimeRatio float64) []IrregularitySpeed {
+	speeds := make([]IrregularitySpeed, len(points))
+	for i, p := range points {
+		s := IrregularitySpeed{IrregularityPoint: p, SpeedRatio: p.Speed / criticalVelocity}
+		s.Peak = i > 0 && i < len(points)-1 && p.RMSForce > points[i-1].RMSForce && p.RMSForce > points[i+1].RMSForce
+		s.Flagged = s.Peak && s.SpeedRatio >= regimeRatio && s.SpeedRatio <= 1/regimeRatio
+		speeds[i] = s
+	}
+	return speeds
+}
+
+// irregularityColumns are the columns of the table written by WriteIrregularityCSV.
+var irregularityColumns = []string{"speed", "speed_ratio", "rms_force", "dynamic_factor", "peak_omega", "peak_psd", "peak", "flagged"}
+
+// WriteIrregularityCSV writes the dynamic wheel-rail force of the irregularity of the
+// rail as CSV, one row per speed, with the columns speed [m/s], speed_ratio (speed
+// over the critical speed), rms_force [N], dynamic_factor (RMS force over the static
+// wheel load), peak_omega [rad/s] and peak_psd [N²/(rad/s)] of the spectrum of the
+// force, peak and flagged.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - speeds: The force at every speed
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteIrregularityCSV(w io.Writer, speeds []IrregularitySpeed) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(irregularityColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, s := range speeds {
+		row := []string{format(s.Speed), format(s.SpeedRatio), format(s.RMSForce), format(s.DynamicFactor), format(s.PeakOmega),
+			format(s.PeakPSD), strconv.FormatBool(s.Peak), strconv.FormatBool(s.Flagged)}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
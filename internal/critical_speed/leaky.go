@@ -0,0 +1,45 @@
+package critical_speed
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+// leakyColumns are the columns of the table written by WriteLeakyCSV.
+var leakyColumns = []string{"omega", "soil_phase_velocity", "leaky_phase_velocity"}
+
+// WriteLeakyCSV writes the guided and leaky soil modes as CSV, one row per frequency,
+// with the columns omega [rad/s], soil_phase_velocity [m/s], the guided mode of the
+// analysis, and leaky_phase_velocity [m/s], the leaky mode above the shear wave speed
+// of the halfspace (see soil_dispersion.LeakyDispersionScan). The phase velocities
+// are empty where no mode is found.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - omega: Angular frequencies [rad/s]
+//   - soilPhaseVelocity: Phase velocities of the guided soil mode [m/s] (NaN where no root is found)
+//   - leakyVelocity: Phase velocities of the leaky soil mode [m/s] (NaN where none is found)
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteLeakyCSV(w io.Writer, omega []float64, soilPhaseVelocity []float64, leakyVelocity []float64) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(leakyColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for i := range omega {
+		if err := writer.Write([]string{format(omega[i]), format(soilPhaseVelocity[i]), format(leakyVelocity[i])}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
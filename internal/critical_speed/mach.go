@@ -0,0 +1,130 @@
+package critical_speed
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MachCone describes the ground-borne waves radiated at an operating speed. Where the
+// speed exceeds the soil phase velocity c, the waves of the moving load form a Mach
+// cone behind the train, with the half-angle θ between the wave fronts and the track
+// given by sin θ = c / v; the waves radiate away at 90° - θ from the track.
+type MachCone struct {
+	Speed    float64 // Operating speed [m/s]
+	OmegaMin float64 // Lowest angular frequency at which the speed exceeds the soil phase velocity [rad/s]
+	OmegaMax float64 // Highest angular frequency at which the speed exceeds the soil phase velocity [rad/s]
+	AngleMin float64 // Smallest Mach angle in the band [degrees]
+	AngleMax float64 // Largest Mach angle in the band [degrees]
+}
+
+// Supersonic reports whether the operating speed exceeds the soil phase velocity at
+// some frequencies, i.e. whether a Mach cone is formed.
+func (m MachCone) Supersonic() bool {
+	return m.OmegaMax > 0
+}
+
+// MachCones computes the Mach cone of every operating speed from the soil dispersion
+// curve. The edges of the affected frequency band are interpolated linearly where the
+// soil phase velocity crosses the speed, and the Mach angles are those of the
+// frequencies of the curve in the band.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s]
+//   - soilPhaseVelocity: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - speeds: Operating speeds [m/s]
+//
+// Returns:
+//   - []MachCone: The Mach cone of every speed (zero band and angles when the speed
+//     does not exceed the soil phase velocity)
+func MachCones(omega []float64, soilPhaseVelocity []float64, speeds []float64) []MachCone {
+	cones := make([]MachCone, len(speeds))
+	for j, v := range speeds {
+		cone := MachCone{Speed: v, AngleMin: math.Inf(1), AngleMax: math.Inf(-1)}
+		for i, c := range soilPhaseVelocity {
+			if math.IsNaN(c) || c >= v {
+				continue
+			}
+			// Band edges, at the crossing with a neighbouring frequency above the speed
+			low, high := omega[i], omega[i]
+			if i > 0 && soilPhaseVelocity[i-1] >= v {
+				low = crossing(omega[i-1], omega[i], soilPhaseVelocity[i-1], c, v)
+			}
+			if i+1 < len(soilPhaseVelocity) && soilPhaseVelocity[i+1] >= v {
+				high = crossing(omega[i], omega[i+1], c, soilPhaseVelocity[i+1], v)
+			}
+			if cone.OmegaMax == 0 {
+				cone.OmegaMin = low
+			}
+			cone.OmegaMax = high
+
+			angle := math.Asin(c/v) * 180 / math.Pi
+			cone.AngleMin = math.Min(cone.AngleMin, angle)
+			cone.AngleMax = math.Max(cone.AngleMax, angle)
+		}
+		if !cone.Supersonic() {
+			cone.AngleMin, cone.AngleMax = 0, 0
+		}
+		cones[j] = cone
+	}
+	return cones
+}
+
+// crossing returns the angular frequency at which a linear segment of the dispersion
+// curve crosses a speed.
+func crossing(omega0, omega1, c0, c1, v float64) float64 {
+	return omega0 + (v-c0)/(c1-c0)*(omega1-omega0)
+}
+
+// machColumns are the columns of the table written by WriteMachCSV.
+var machColumns = []string{"speed", "supersonic", "omega_min", "omega_max", "frequency_min", "frequency_max", "angle_min", "angle_max"}
+
+// WriteMachCSV writes the Mach cones as CSV, one row per operating speed, with the
+// columns speed [m/s], supersonic, the affected band in angular frequency [rad/s] and
+// frequency [Hz], and the Mach angles [degrees]. The band and the angles are empty
+// when the speed does not exceed the soil phase velocity.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - cones: The Mach cones
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteMachCSV(w io.Writer, cones []MachCone) error {
+	writer := csv.NewWriter(w)
+	writer.Write(machColumns)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, m := range cones {
+		row := []string{format(m.Speed), strconv.FormatBool(m.Supersonic()), "", "", "", "", "", ""}
+		if m.Supersonic() {
+			row[2], row[3] = format(m.OmegaMin), format(m.OmegaMax)
+			row[4], row[5] = format(m.OmegaMin/(2*math.Pi)), format(m.OmegaMax/(2*math.Pi))
+			row[6], row[7] = format(m.AngleMin), format(m.AngleMax)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// assessmentSpeeds returns the operating speeds of the assessment section of a
+// configuration.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - []float64: The operating speeds [m/s] (nil without an assessment section)
+//   - error: An error if a speed is not positive
+func assessmentSpeeds(config Config) ([]float64, error) {
+	for _, v := range config.Assessment.Speeds {
+		if !(v > 0) {
+			return nil, classify(KindConfig, fmt.Errorf("invalid assessment speed: speeds must be positive, got %g", v))
+		}
+	}
+	return config.Assessment.Speeds, nil
+}
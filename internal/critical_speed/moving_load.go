@@ -0,0 +1,110 @@
+package critical_speed
+
+import (
+	"cmp"
+	"fmt"
+
+	moving_load "github.com/PlatypusBytes/GoTrain/internal/moving_load"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// defaultSlabTrackWidth is the width of a slab track on the soil when none is configured [m].
+const defaultSlabTrackWidth = 2.5
+
+// movingLoadParameters returns the parameters of the moving load of the moving_load
+// section of a configuration, with the default track width of its track type.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - moving_load.Parameters: The parameters (zero load without a moving_load section)
+//   - error: An error if the section is invalid
+func movingLoadParameters(config Config) (moving_load.Parameters, error) {
+	section := config.MovingLoad
+	if section.Load == 0 {
+		return moving_load.Parameters{}, nil
+	}
+	if !(section.SpeedMin > 0) || !(section.SpeedMax > section.SpeedMin) || section.SpeedPoints < 2 {
+		return moving_load.Parameters{}, classify(KindConfig, fmt.Errorf("invalid moving load speeds: speed_min must be positive, "+
+			"speed_max larger than speed_min and speed_points at least 2"))
+	}
+	params, err := trackSoilParameters(config, section.Load)
+	if err != nil {
+		return moving_load.Parameters{}, err
+	}
+	params.Speeds = math_utils.Linspace(section.SpeedMin, section.SpeedMax, section.SpeedPoints)
+	params.RailSectionModulus = section.RailSectionModulus
+	return params, nil
+}
+
+// groundVibration holds the parameters of the ground_vibration section.
+type groundVibration struct {
+	load      moving_load.Parameters // Load, track width and damping of the soil
+	speed     float64                // Operating speed [m/s]
+	distances []float64              // Distances from the centre line of the track [m]
+}
+
+// groundVibrationParameters returns the parameters of the ground_vibration section
+// of a configuration. The width of the track and the damping of the soil are those
+// of the moving_load section.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - groundVibration: The parameters (no distances without a ground_vibration section)
+//   - error: An error if the section is invalid
+func groundVibrationParameters(config Config) (groundVibration, error) {
+	section := config.GroundVibration
+	if len(section.Distances) == 0 {
+		return groundVibration{}, nil
+	}
+	if section.Load == 0 || !(section.Speed > 0) {
+		return groundVibration{}, classify(KindConfig, fmt.Errorf("invalid ground vibration: load must not be zero and speed must be positive"))
+	}
+	for _, r := range section.Distances {
+		if !(r >= 0) {
+			return groundVibration{}, classify(KindConfig, fmt.Errorf("invalid ground vibration: distances must not be negative, got %g", r))
+		}
+	}
+	params, err := trackSoilParameters(config, section.Load)
+	if err != nil {
+		return groundVibration{}, err
+	}
+	return groundVibration{load: params, speed: section.Speed, distances: section.Distances}, nil
+}
+
+// trackSoilParameters returns the parameters of the coupled track-soil model of the
+// moving_load section, for a load, with the default track width of the track type.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//   - load: The load on each rail [N]
+//
+// Returns:
+//   - moving_load.Parameters: The load, track width and damping of the soil
+//   - error: An error if the damping, track width or rail section modulus is negative
+func trackSoilParameters(config Config, load float64) (moving_load.Parameters, error) {
+	section := config.MovingLoad
+	if section.Damping < 0 || section.TrackWidth < 0 || section.RailSectionModulus < 0 {
+		return moving_load.Parameters{}, classify(KindConfig, fmt.Errorf("invalid moving load: damping, track_width and rail_section_modulus must not be negative"))
+	}
+
+	return moving_load.Parameters{Load: load, TrackWidth: cmp.Or(section.TrackWidth, trackWidth(config)), Damping: section.Damping}, nil
+}
+
+// trackWidth returns the width of the track on the soil of a configuration: twice
+// width_sleeper for a ballast track, or defaultSlabTrackWidth for a slab track.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - float64: The width of the track [m]
+func trackWidth(config Config) float64 {
+	if config.TrackType == "ballast" {
+		return 2 * config.BallastTrack.WidthSleeper
+	}
+	return defaultSlabTrackWidth
+}
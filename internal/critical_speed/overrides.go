@@ -0,0 +1,231 @@
+package critical_speed
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configuration
+// values (see ApplyEnv).
+const EnvPrefix = "GOTRAIN_"
+
+// SetValue overrides a value of a configuration, e.g. for a command-line option or an
+// environment variable, without editing its YAML file.
+//
+// The path names the value by the YAML keys of its sections, separated by dots, with
+// the index of list items, e.g. "ballast_track.k_rail_pad" or
+// "soil_layers.0.young_modulus". The section "track" names the track section of the
+// track type of the configuration (ballast_track for "ballast", slab_track otherwise).
+// The value is parsed as YAML, so that numbers, strings and lists ("[10, 20]") are
+// written as in the configuration file.
+//
+// Parameters:
+//   - config: The configuration structure, modified in place
+//   - path: Dot-separated path of the value
+//   - value: The new value, in YAML
+//
+// Returns:
+//   - error: A KindConfig error if the path does not name a value of the
+//     configuration, or the value does not fit it
+func SetValue(config *Config, path string, value string) error {
+	field, err := configField(reflect.ValueOf(config).Elem(), trackAlias(path, config.TrackType))
+	if err != nil {
+		return classify(KindConfig, fmt.Errorf("invalid override %s: %v", path, err))
+	}
+	parsed := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+		return classify(KindConfig, fmt.Errorf("invalid value of %s: %v", path, err))
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
+
+// ApplyEnv overrides the values of a configuration given by environment variables,
+// so that containers and batch schedulers can vary the parameters of a configuration
+// file without templating it.
+//
+// The name of a variable is EnvPrefix followed by the path of the value (see
+// SetValue), case-insensitive and with underscores between or within the keys
+// optional, e.g. GOTRAIN_BALLAST_TRACK_K_RAIL_PAD or GOTRAIN_TRACK_KRAILPAD for
+// ballast_track.k_rail_pad, and GOTRAIN_SOIL_LAYERS_0_YOUNG_MODULUS for
+// soil_layers.0.young_modulus. The section "track" follows the track type of the
+// configuration before the overrides. The variables are applied in the order of their
+// names.
+//
+// Parameters:
+//   - config: The configuration structure, modified in place
+//   - environ: Environment variables as "NAME=value", e.g. os.Environ()
+//
+// Returns:
+//   - []string: Paths of the overridden values, in the order they were applied
+//   - error: A KindConfig error if a variable with the prefix does not name a value of
+//     the configuration, or its value does not fit it
+func ApplyEnv(config *Config, environ []string) ([]string, error) {
+	type override struct{ name, path, value string }
+	var overrides []override
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if len(name) <= len(EnvPrefix) || !strings.EqualFold(name[:len(EnvPrefix)], EnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(name[len(EnvPrefix):], "_", ""))
+		path, ok := envPath(reflect.TypeOf(*config), key)
+		if !ok {
+			return nil, classify(KindConfig, fmt.Errorf("environment variable %s does not name a configuration value", name))
+		}
+		overrides = append(overrides, override{name, trackAlias(path, config.TrackType), value})
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].name < overrides[j].name })
+
+	paths := make([]string, len(overrides))
+	for i, o := range overrides {
+		if err := SetValue(config, o.path, o.value); err != nil {
+			return nil, classify(KindConfig, fmt.Errorf("environment variable %s: %v", o.name, err))
+		}
+		paths[i] = o.path
+	}
+	return paths, nil
+}
+
+// trackAlias replaces the section "track" of a path by the track section of a track
+// type.
+//
+// Parameters:
+//   - path: Dot-separated path of a value
+//   - trackType: Track type of the configuration
+//
+// Returns:
+//   - string: The path with the track section
+func trackAlias(path string, trackType string) string {
+	rest, ok := strings.CutPrefix(path, "track.")
+	if !ok {
+		return path
+	}
+	if trackType == "ballast" {
+		return "ballast_track." + rest
+	}
+	return "slab_track." + rest
+}
+
+// configField finds the value of a configuration named by a path (see SetValue).
+//
+// Parameters:
+//   - v: The configuration structure, or a section of it
+//   - path: Dot-separated path of the value within v
+//
+// Returns:
+//   - reflect.Value: The settable value
+//   - error: An error if the path does not name a value
+func configField(v reflect.Value, path string) (reflect.Value, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch v.Kind() {
+		case reflect.Struct:
+			index := -1
+			for i := 0; i < v.NumField(); i++ {
+				if yamlKey(v.Type().Field(i)) == key {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+			}
+			v = v.Field(index)
+		case reflect.Slice:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= v.Len() {
+				return reflect.Value{}, fmt.Errorf("invalid index %q of a list of %d items", key, v.Len())
+			}
+			v = v.Index(index)
+		default:
+			return reflect.Value{}, fmt.Errorf("%q is not a section or list", key)
+		}
+	}
+	return v, nil
+}
+
+// envPath finds the path of the value named by an environment variable, matching
+// the YAML keys without their underscores (see ApplyEnv).
+//
+// Parameters:
+//   - t: Type of the configuration structure, or of a section of it
+//   - name: Name of the variable after the prefix, lower case and without underscores
+//
+// Returns:
+//   - string: Dot-separated path of the value within t
+//   - bool: False if the name does not name a value
+func envPath(t reflect.Type, name string) (string, bool) {
+	if name == "" {
+		return "", true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		type candidate struct {
+			key     string
+			section reflect.Type
+		}
+		candidates := make([]candidate, 0, t.NumField()+2)
+		for i := 0; i < t.NumField(); i++ {
+			if key := yamlKey(t.Field(i)); key != "" {
+				candidates = append(candidates, candidate{key, t.Field(i).Type})
+			}
+		}
+		if t == reflect.TypeOf(Config{}) {
+			// The track section is resolved by SetValue, for the track type
+			candidates = append(candidates, candidate{"track", reflect.TypeOf(Config{}.BallastTrack)}, candidate{"track", reflect.TypeOf(Config{}.SlabTrack)})
+		}
+		for _, c := range candidates {
+			rest, ok := strings.CutPrefix(name, strings.ToLower(strings.ReplaceAll(c.key, "_", "")))
+			if !ok {
+				continue
+			}
+			if path, ok := envPath(c.section, rest); ok {
+				return joinPath(c.key, path), true
+			}
+		}
+	case reflect.Slice:
+		digits := len(name) - len(strings.TrimLeft(name, "0123456789"))
+		if digits == 0 {
+			return "", false
+		}
+		if path, ok := envPath(t.Elem(), name[digits:]); ok {
+			return joinPath(name[:digits], path), true
+		}
+	}
+	return "", false
+}
+
+// yamlKey returns the YAML key of a field of a configuration section.
+//
+// Parameters:
+//   - field: The field
+//
+// Returns:
+//   - string: The key, or an empty string for fields without a key
+func yamlKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if key == "-" {
+		return ""
+	}
+	return key
+}
+
+// joinPath joins a key and the path below it.
+//
+// Parameters:
+//   - key: Key of a section or index of a list item
+//   - path: Path within the section or item (may be empty)
+//
+// Returns:
+//   - string: The dot-separated path
+func joinPath(key string, path string) string {
+	if path == "" {
+		return key
+	}
+	return key + "." + path
+}
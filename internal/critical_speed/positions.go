@@ -0,0 +1,182 @@
+package critical_speed
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is the location of a value in a YAML configuration file.
+type Position struct {
+	Line   int // Line of the value, starting at 1
+	Column int // Column of the value, starting at 1
+}
+
+// FieldError is a problem of a configuration value, named by its path (see
+// SetValue), so that it can be reported with the position of the value in the file.
+type FieldError struct {
+	Path string // Dot-separated path of the value, e.g. "frequency.unit"
+	Err  error  // The underlying error
+}
+
+// Error returns the message of the underlying error.
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError attaches the path of a configuration value to an error.
+//
+// Parameters:
+//   - path: Dot-separated path of the value
+//   - err: The error
+//
+// Returns:
+//   - error: The error with the path of the value
+func fieldError(path string, err error) error {
+	return &FieldError{Path: path, Err: err}
+}
+
+// locate prefixes an error about a value of a configuration with the position of the
+// value in its file and its path, e.g. "line 12, column 9 (frequency.unit): ...".
+// Errors of configurations that were not parsed from YAML, or that do not name a
+// value, are returned as they are.
+//
+// Parameters:
+//   - config: The configuration structure
+//   - err: The error (may be nil)
+//
+// Returns:
+//   - error: The located error, wrapping err
+func locate(config Config, err error) error {
+	var field *FieldError
+	if !errors.As(err, &field) {
+		return err
+	}
+	position, ok := config.Positions[trackAlias(field.Path, config.TrackType)]
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("line %d, column %d (%s): %w", position.Line, position.Column, field.Path, err)
+}
+
+// configPositions records the positions of the values of a parsed configuration by
+// their paths. The position of a section is that of its key. Values added by the
+// upgrade of an older layout have no position.
+//
+// Parameters:
+//   - document: The parsed YAML document of the configuration
+//
+// Returns:
+//   - map[string]Position: The positions by dot-separated path
+func configPositions(document *yaml.Node) map[string]Position {
+	positions := map[string]Position{}
+	var record func(node *yaml.Node, path string)
+	record = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				keyPath := childPath(path, key.Value)
+				if key.Line > 0 {
+					positions[keyPath] = Position{Line: key.Line, Column: key.Column}
+				}
+				record(value, keyPath)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				itemPath := childPath(path, strconv.Itoa(i))
+				if item.Line > 0 {
+					positions[itemPath] = Position{Line: item.Line, Column: item.Column}
+				}
+				record(item, itemPath)
+			}
+		}
+	}
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		record(document.Content[0], "")
+	}
+	return positions
+}
+
+// decodeLine matches the line prefix of the messages of the YAML decoder.
+var decodeLine = regexp.MustCompile(`^line \d+: `)
+
+// decodeErrors finds the values of a configuration that cannot be decoded into their
+// fields, so that a failed decoding is reported with the position and the path of
+// every offending value instead of the generic message of the YAML decoder.
+//
+// Parameters:
+//   - document: The parsed YAML document of the configuration
+//
+// Returns:
+//   - error: The offending values, one per line, or nil if none is found
+func decodeErrors(document *yaml.Node) error {
+	var problems []string
+	var check func(node *yaml.Node, t reflect.Type, path string)
+	check = func(node *yaml.Node, t reflect.Type, path string) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch {
+		case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				for j := 0; j < t.NumField(); j++ {
+					if yamlKey(t.Field(j)) == key.Value {
+						check(value, t.Field(j).Type, childPath(path, key.Value))
+						break
+					}
+				}
+			}
+		case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+			for i, item := range node.Content {
+				check(item, t.Elem(), childPath(path, strconv.Itoa(i)))
+			}
+		default:
+			if err := node.Decode(reflect.New(t).Interface()); err != nil {
+				var typeErr *yaml.TypeError
+				messages := []string{err.Error()}
+				if errors.As(err, &typeErr) {
+					messages = typeErr.Errors
+				}
+				for _, message := range messages {
+					problems = append(problems, fmt.Sprintf("line %d, column %d (%s): %s",
+						node.Line, node.Column, path, decodeLine.ReplaceAllString(message, "")))
+				}
+			}
+		}
+	}
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		check(document.Content[0], reflect.TypeOf(Config{}), "")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "\n"))
+}
+
+// childPath joins the path of a section and the key of a value or the index of an
+// item within it.
+//
+// Parameters:
+//   - path: Path of the section (empty for the top level)
+//   - key: Key of the value or index of the item
+//
+// Returns:
+//   - string: The dot-separated path
+func childPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,147 @@
+package critical_speed
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// createProfileSoilLayers reads the soil layers from the file of the soil_profile
+// section of the config (see LoadSoilProfile).
+//
+// Parameters:
+//   - config: The configuration structure with the soil_profile section
+//
+// Returns:
+//   - []soil_dispersion.Layer: A slice of soil_dispersion.Layer objects
+//   - error: An error if soil_layers or soil_cpt are given as well, or the file cannot be read
+func createProfileSoilLayers(config Config) ([]soil_dispersion.Layer, error) {
+	if len(config.SoilLayers) > 0 {
+		return nil, classify(KindConfig, fmt.Errorf("soil_layers and soil_profile cannot be used together"))
+	}
+	if config.SoilCPT.File != "" || config.SoilCPT.Provider != "" {
+		return nil, classify(KindConfig, fmt.Errorf("soil_cpt and soil_profile cannot be used together"))
+	}
+	return LoadSoilProfile(config.SoilProfile.File)
+}
+
+// LoadSoilProfile reads the soil layers from a plain-text profile file (see
+// ParseSoilProfile).
+//
+// Parameters:
+//   - path: Path to the profile file, or s3:// or gs:// URL
+//
+// Returns:
+//   - []soil_dispersion.Layer: The soil layers, with their wave speeds computed
+//   - error: An error if the file cannot be read (KindIO) or is invalid (KindConfig)
+func LoadSoilProfile(path string) ([]soil_dispersion.Layer, error) {
+	data, err := storage.ReadFile(path)
+	if err != nil {
+		return nil, classify(KindIO, fmt.Errorf("failed to read soil profile: %v", err))
+	}
+	layers, err := ParseSoilProfile(bytes.NewReader(data))
+	if err != nil {
+		return nil, classify(KindConfig, fmt.Errorf("invalid soil profile %s: %v", path, err))
+	}
+	return layers, nil
+}
+
+// ParseSoilProfile parses the soil layers from a plain-text profile, as exported by
+// inversion software. Every line is a layer, from the surface down, with four columns
+// separated by spaces, tabs or commas:
+//
+//	thickness [m]   Vs [m/s]   Vp [m/s] or ν   density [kg/m^3]
+//
+// A third column smaller than 0.5 is Poisson's ratio; otherwise it is the
+// compressional wave speed, and Poisson's ratio follows from Vp / Vs. As Vp is the
+// larger speed, the two speeds may be given in either order, so Geopsy .model files
+// (thickness, Vp, Vs, density) are read as well, including their first line with the
+// number of layers. The last layer is the halfspace: its thickness is ignored (0 in
+// Geopsy files). Young's modulus follows from E = 2 (1 + ν) ρ Vs². Blank lines and
+// lines starting with # are skipped.
+//
+// Parameters:
+//   - r: Reader of the profile
+//
+// Returns:
+//   - []soil_dispersion.Layer: The soil layers, with their wave speeds computed
+//   - error: An error if the profile is invalid or has no layers
+func ParseSoilProfile(r io.Reader) ([]soil_dispersion.Layer, error) {
+	scanner := bufio.NewScanner(r)
+	count := -1
+	var layers []soil_dispersion.Layer
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+
+		// Number of layers of a Geopsy .model file
+		if len(fields) == 1 && count < 0 && len(layers) == 0 {
+			n, err := strconv.Atoi(fields[0])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("line %d: invalid number of layers", line)
+			}
+			count = n
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: expected thickness, Vs, Vp or Poisson's ratio, and density", line)
+		}
+		var values [4]float64
+		for i := range values {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil || math.IsNaN(v) {
+				return nil, fmt.Errorf("line %d: invalid value %q", line, fields[i])
+			}
+			values[i] = v
+		}
+		thickness, vs, third, density := values[0], values[1], values[2], values[3]
+
+		poissonRatio := third
+		if third >= 0.5 {
+			vp := max(vs, third)
+			vs = min(vs, third)
+			poissonRatio = (vp*vp - 2*vs*vs) / (2 * (vp*vp - vs*vs))
+		}
+		if !(thickness >= 0) || !(vs > 0) || math.IsInf(vs, 1) || !(density > 0) || math.IsInf(density, 1) {
+			return nil, fmt.Errorf("line %d: the thickness must not be negative, and Vs and the density must be positive", line)
+		}
+		if !(poissonRatio >= 0) {
+			return nil, fmt.Errorf("line %d: Poisson's ratio must not be negative: Vp must be at least √2 Vs", line)
+		}
+		layers = append(layers, soil_dispersion.Layer{
+			Thickness:     thickness,
+			Density:       density,
+			YoungsModulus: 2 * (1 + poissonRatio) * density * vs * vs,
+			PoissonRatio:  poissonRatio,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no soil layers")
+	}
+	if count >= 0 && count != len(layers) {
+		return nil, fmt.Errorf("expected %d layers, got %d", count, len(layers))
+	}
+	for i, layer := range layers[:len(layers)-1] {
+		if !(layer.Thickness > 0) || math.IsInf(layer.Thickness, 1) {
+			return nil, fmt.Errorf("layer %d: only the halfspace may have zero or infinite thickness", i+1)
+		}
+	}
+	layers[len(layers)-1].Thickness = math.Inf(1)
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	return layers, nil
+}
@@ -0,0 +1,208 @@
+package critical_speed
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NamedSoilProfile defines a soil profile of the soil_profiles section of a
+// configuration, e.g. of one borehole along the track. The soil is given as in a
+// configuration, by soil_layers, soil_profile or soil_cpt.
+type NamedSoilProfile struct {
+	Name        string          `yaml:"name"`         // Name of the profile, e.g. of its borehole (default "profile" and its number)
+	SoilLayers  []SoilLayer     `yaml:"soil_layers"`  // Soil layers of the profile
+	SoilProfile SoilProfileFile `yaml:"soil_profile"` // Plain-text file the soil layers of the profile are read from
+	SoilCPT     SoilCPT         `yaml:"soil_cpt"`     // CPT the soil layers of the profile are derived from
+}
+
+// ProfileCase is the critical speed of the track on a soil profile of the
+// soil_profiles section of a configuration.
+type ProfileCase struct {
+	Name              string    // Name of the profile
+	SoilPhaseVelocity []float64 // Phase velocities of the soil profile [m/s] (NaN where no root is found)
+	CriticalOmega     float64   // Critical angular frequency [rad/s]
+	CriticalVelocity  float64   // Critical train speed [m/s]
+}
+
+// unsafeFileCharacters matches the characters of profile names replaced in the names
+// of their result files.
+var unsafeFileCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// soilProfiles validates the soil_profiles section of a configuration and returns
+// the configuration of every profile: the configuration with the soil of the profile
+// instead of the soil_profiles section.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - []string: Names of the profiles (nil without a soil_profiles section)
+//   - []Config: Configurations of the profiles, in the same order
+//   - error: An error if the section is combined with other soil sections, or a
+//     profile has no soil or a name already used
+func soilProfiles(config Config) ([]string, []Config, error) {
+	if len(config.SoilProfiles) == 0 {
+		return nil, nil, nil
+	}
+	if len(config.SoilLayers) > 0 || config.SoilProfile != (SoilProfileFile{}) || config.SoilCPT != (SoilCPT{}) {
+		return nil, nil, classify(KindConfig, fmt.Errorf("soil_profiles cannot be used together with soil_layers, soil_profile or soil_cpt"))
+	}
+
+	names := make([]string, len(config.SoilProfiles))
+	configs := make([]Config, len(config.SoilProfiles))
+	files := make(map[string]string, len(config.SoilProfiles))
+	for i, profile := range config.SoilProfiles {
+		name := cmp.Or(profile.Name, fmt.Sprintf("profile %d", i+1))
+		if len(profile.SoilLayers) == 0 && profile.SoilProfile.File == "" && profile.SoilCPT.File == "" && profile.SoilCPT.Provider == "" {
+			return nil, nil, classify(KindConfig, fmt.Errorf("soil profile %s: soil_layers, soil_profile or soil_cpt is required", name))
+		}
+		if other, ok := files[profileFileSuffix(name)]; ok {
+			return nil, nil, classify(KindConfig, fmt.Errorf("soil profiles %s and %s have the same result file name", other, name))
+		}
+		files[profileFileSuffix(name)] = name
+
+		names[i] = name
+		configs[i] = config
+		configs[i].SoilLayers = profile.SoilLayers
+		configs[i].SoilProfile = profile.SoilProfile
+		configs[i].SoilCPT = profile.SoilCPT
+		configs[i].SoilProfiles = nil
+	}
+	return names, configs, nil
+}
+
+// SoilLayerCounts returns the number of layers of the soil of a configuration, as
+// SoilLayers returns them: one count for the soil_layers, soil_profile or soil_cpt
+// section, or one count per profile of the soil_profiles section. The CPT of a
+// soil_cpt provider is fetched.
+//
+// Parameters:
+//   - config: The configuration structure
+//
+// Returns:
+//   - []int: Number of layers of every soil profile, in the order of soil_profiles
+//   - error: An error if the soil sections are invalid, or a profile cannot be
+//     read or derived from its CPT
+func SoilLayerCounts(config Config) ([]int, error) {
+	names, configs, err := soilProfiles(config)
+	if err != nil {
+		return nil, err
+	}
+	if configs == nil {
+		layers, _, err := loadSoilLayers(context.Background(), config)
+		if err != nil {
+			return nil, err
+		}
+		return []int{len(layers)}, nil
+	}
+	counts := make([]int, len(configs))
+	for i, profile := range configs {
+		layers, _, err := loadSoilLayers(context.Background(), profile)
+		if err != nil {
+			return nil, fmt.Errorf("soil profile %s: %w", names[i], err)
+		}
+		counts[i] = len(layers)
+	}
+	return counts, nil
+}
+
+// profileFileSuffix returns the suffix of the result file of a soil profile: its
+// name, with the characters other than letters, digits, '_', '.' and '-' replaced.
+//
+// Parameters:
+//   - name: Name of the profile
+//
+// Returns:
+//   - string: The suffix, e.g. "_BH_01" for the profile "BH 01"
+func profileFileSuffix(name string) string {
+	return "_" + unsafeFileCharacters.ReplaceAllString(name, "_")
+}
+
+// ProfileFileName returns the path of the result file of a soil profile: next to
+// the result file, with the name of the profile as suffix, e.g. results_BH_01.json
+// for the profile "BH 01".
+//
+// Parameters:
+//   - resultFile: Path of the result file of the configuration
+//   - name: Name of the profile
+//
+// Returns:
+//   - string: Path of the result file of the profile
+func ProfileFileName(resultFile string, name string) string {
+	ext := OutputExt(resultFile)
+	return strings.TrimSuffix(resultFile, ext) + profileFileSuffix(name) + ext
+}
+
+// ProfileResult returns the result of the track on a soil profile of Result.Profiles,
+// with the curves and critical speed of the profile; the results of the other
+// sections, which describe the first profile, are left out.
+//
+// Parameters:
+//   - profile: The soil profile
+//
+// Returns:
+//   - Result: The result of the profile
+func (r Result) ProfileResult(profile ProfileCase) Result {
+	return Result{
+		Omega:              r.Omega,
+		TrackPhaseVelocity: r.TrackPhaseVelocity,
+		SoilPhaseVelocity:  profile.SoilPhaseVelocity,
+		CriticalOmega:      profile.CriticalOmega,
+		CriticalVelocity:   profile.CriticalVelocity,
+		FrequencyUnit:      r.FrequencyUnit,
+		Precision:          r.Precision,
+	}
+}
+
+// saveProfileResults writes the result file of every soil profile of a result (see
+// ProfileFileName), in the format of the result file of the configuration.
+//
+// Parameters:
+//   - result: The computed result
+//   - config: The configuration, with the output file name and format
+//
+// Returns:
+//   - error: An error if a file cannot be written
+func saveProfileResults(result Result, config Config) error {
+	for _, profile := range result.Profiles {
+		profileConfig := config
+		profileConfig.Output.FileName = ProfileFileName(config.Output.FileName, profile.Name)
+		if err := saveResults(result.ProfileResult(profile), profileConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileColumns are the columns of the table written by WriteProfilesCSV.
+var profileColumns = []string{"name", "critical_omega", "critical_velocity"}
+
+// WriteProfilesCSV writes the critical speeds of soil profiles as CSV, one row per
+// profile, with the columns name, critical_omega [rad/s] and critical_velocity [m/s].
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - profiles: The soil profiles
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteProfilesCSV(w io.Writer, profiles []ProfileCase) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(profileColumns); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, p := range profiles {
+		if err := writer.Write([]string{p.Name, format(p.CriticalOmega), format(p.CriticalVelocity)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,168 @@
+package critical_speed
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// jsonBufferSize is the size of the buffer of WriteJSON [bytes].
+const jsonBufferSize = 64 << 10
+
+// maxPrecision is the number of significant digits that represents every float64.
+const maxPrecision = 17
+
+// WriteJSON writes the result as an indented JSON result file, with the same content
+// as the indented encoding of DispersionResults followed by a newline. The curves are
+// written value by value through a fixed-size buffer, so that results with very many
+// frequencies are written without holding their encoding (or a copy of the curves)
+// in memory. NaN values in the soil phase velocity are written as "NaN".
+//
+// With FrequencyUnit UnitHertz, the fields omega and critical_omega are replaced by
+// frequency and critical_frequency, in Hz. With a Precision, the numbers are rounded
+// to that number of significant digits. The critical band is written only when it is
+// computed, with the fields critical_omega_min, critical_omega_max (or their
+// frequency counterparts), critical_velocity_min and critical_velocity_max.
+//
+// Parameters:
+//   - w: Writer receiving the JSON document
+//
+// Returns:
+//   - error: An error if a value cannot be represented in JSON (NaN or infinite
+//     frequencies, track phase velocities, critical values, critical band or site period) or
+//     writing fails.
+//     Nothing is written when a value cannot be represented.
+func (r Result) WriteJSON(w io.Writer) error {
+	for _, values := range [][]float64{r.Omega, r.TrackPhaseVelocity, {r.CriticalOmega, r.CriticalVelocity, r.SitePeriod, r.ResonanceFrequency},
+		{r.CriticalBand.OmegaMin, r.CriticalBand.OmegaMax, r.CriticalBand.VelocityMin, r.CriticalBand.VelocityMax}} {
+		for _, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("error marshaling to JSON: unsupported value: %v", v)
+			}
+		}
+	}
+
+	bw := bufio.NewWriterSize(w, jsonBufferSize)
+	b := make([]byte, 0, 32)
+	name, _, scale := r.FrequencyAxis()
+
+	bw.WriteString("{\n\t\"schema_version\": ")
+	bw.Write(strconv.AppendInt(b, SchemaVersion, 10))
+	writeJSONArray(bw, name, r.Omega, scale, r.Precision, b)
+	writeJSONArray(bw, "track_phase_velocity", r.TrackPhaseVelocity, 1, r.Precision, b)
+	// DispersionResults holds no soil values (null) for an empty curve
+	soilPhaseVelocity := r.SoilPhaseVelocity
+	if len(soilPhaseVelocity) == 0 {
+		soilPhaseVelocity = nil
+	}
+	writeJSONArray(bw, "soil_phase_velocity", soilPhaseVelocity, 1, r.Precision, b)
+	bw.WriteString(",\n\t\"critical_" + name + "\": ")
+	bw.Write(appendJSONFloat(b, roundDigits(r.CriticalOmega*scale, r.Precision)))
+	bw.WriteString(",\n\t\"critical_velocity\": ")
+	bw.Write(appendJSONFloat(b, roundDigits(r.CriticalVelocity, r.Precision)))
+	// DispersionResults omits the zero fields of a band that is not computed
+	for _, field := range []struct {
+		key   string
+		value float64
+	}{
+		{"critical_" + name + "_min", r.CriticalBand.OmegaMin * scale},
+		{"critical_" + name + "_max", r.CriticalBand.OmegaMax * scale},
+		{"critical_velocity_min", r.CriticalBand.VelocityMin},
+		{"critical_velocity_max", r.CriticalBand.VelocityMax},
+	} {
+		if field.value != 0 {
+			bw.WriteString(",\n\t\"" + field.key + "\": ")
+			bw.Write(appendJSONFloat(b, roundDigits(field.value, r.Precision)))
+		}
+	}
+	bw.WriteString(",\n\t\"site_period\": ")
+	bw.Write(appendJSONFloat(b, roundDigits(r.SitePeriod, r.Precision)))
+	bw.WriteString(",\n\t\"resonance_frequency\": ")
+	bw.Write(appendJSONFloat(b, roundDigits(r.ResonanceFrequency, r.Precision)))
+	bw.WriteString("\n}\n")
+	return bw.Flush()
+}
+
+// writeJSONArray writes a field of the result object holding an array of numbers,
+// preceded by the separator of the previous field. NaN values are written as "NaN".
+// The errors of the writer are reported by its Flush.
+//
+// Parameters:
+//   - bw: The buffered writer
+//   - name: Name of the field
+//   - values: The values (null when nil or empty, as encoding/json does for nil slices)
+//   - scale: Factor applied to the values, e.g. to convert angular frequencies to Hz
+//   - digits: Significant digits of the values (0 for all digits)
+//   - b: Scratch buffer for the encoding of the values
+func writeJSONArray(bw *bufio.Writer, name string, values []float64, scale float64, digits int, b []byte) {
+	bw.WriteString(",\n\t\"" + name + "\": ")
+	if values == nil {
+		bw.WriteString("null")
+		return
+	}
+	if len(values) == 0 {
+		bw.WriteString("[]")
+		return
+	}
+	bw.WriteString("[")
+	for i, v := range values {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString("\n\t\t")
+		if math.IsNaN(v) {
+			bw.WriteString(`"NaN"`)
+		} else {
+			bw.Write(appendJSONFloat(b[:0], roundDigits(v*scale, digits)))
+		}
+	}
+	bw.WriteString("\n\t]")
+}
+
+// appendJSONFloat appends a number formatted as encoding/json formats float64 values:
+// the shortest representation, with an exponent only for very small or large values.
+//
+// Parameters:
+//   - b: Buffer the number is appended to
+//   - v: The number (finite)
+//
+// Returns:
+//   - []byte: The buffer with the number
+func appendJSONFloat(b []byte, v float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, v, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// roundDigits rounds a number to a number of significant digits, so that it is
+// written with at most that many digits by the shortest representation.
+//
+// Parameters:
+//   - v: The number
+//   - digits: Significant digits (0 or maxPrecision and above for the number itself)
+//
+// Returns:
+//   - float64: The rounded number
+func roundDigits(v float64, digits int) float64 {
+	if digits <= 0 || digits >= maxPrecision || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+	if err != nil {
+		return v
+	}
+	return rounded
+}
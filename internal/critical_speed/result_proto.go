@@ -0,0 +1,88 @@
+package critical_speed
+
+import (
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	protobuf "github.com/PlatypusBytes/GoTrain/internal/protobuf"
+)
+
+// Proto returns the result as a gotrain.v1.Result protocol buffer message (see
+// proto/gotrain.proto). The curves are shared with the result, not copied.
+//
+// Returns:
+//   - *protobuf.Result: The Result message
+func (r Result) Proto() *protobuf.Result {
+	return &protobuf.Result{
+		Omega:               r.Omega,
+		TrackPhaseVelocity:  r.TrackPhaseVelocity,
+		SoilPhaseVelocity:   r.SoilPhaseVelocity,
+		CriticalOmega:       r.CriticalOmega,
+		CriticalVelocity:    r.CriticalVelocity,
+		SitePeriod:          r.SitePeriod,
+		ResonanceFrequency:  r.ResonanceFrequency,
+		CriticalOmegaMin:    r.CriticalBand.OmegaMin,
+		CriticalOmegaMax:    r.CriticalBand.OmegaMax,
+		CriticalVelocityMin: r.CriticalBand.VelocityMin,
+		CriticalVelocityMax: r.CriticalBand.VelocityMax,
+	}
+}
+
+// MarshalProto encodes the result as a gotrain.v1.Result protocol buffer message
+// (see Proto). Unlike the JSON format, NaN values are kept as is.
+//
+// Returns:
+//   - []byte: The encoded Result message
+//   - error: An error if the message cannot be encoded
+func (r Result) MarshalProto() ([]byte, error) {
+	return proto.Marshal(r.Proto())
+}
+
+// WriteProto writes the result encoded as by MarshalProto.
+//
+// Parameters:
+//   - w: Writer receiving the encoded Result message
+//
+// Returns:
+//   - error: An error if the message cannot be encoded or writing fails
+func (r Result) WriteProto(w io.Writer) error {
+	data, err := r.MarshalProto()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// UnmarshalResultProto decodes a gotrain.v1.Result protocol buffer message
+// (see proto/gotrain.proto).
+//
+// Parameters:
+//   - data: The encoded Result message
+//
+// Returns:
+//   - Result: The decoded result
+//   - error: An error if the message is malformed
+func UnmarshalResultProto(data []byte) (Result, error) {
+	var message protobuf.Result
+	if err := proto.Unmarshal(data, &message); err != nil {
+		return Result{}, fmt.Errorf("error decoding Result message: %v", err)
+	}
+	return Result{
+		Omega:              message.GetOmega(),
+		TrackPhaseVelocity: message.GetTrackPhaseVelocity(),
+		SoilPhaseVelocity:  message.GetSoilPhaseVelocity(),
+		CriticalOmega:      message.GetCriticalOmega(),
+		CriticalVelocity:   message.GetCriticalVelocity(),
+		SitePeriod:         message.GetSitePeriod(),
+		ResonanceFrequency: message.GetResonanceFrequency(),
+		CriticalBand: CriticalBand{
+			OmegaMin:    message.GetCriticalOmegaMin(),
+			OmegaMax:    message.GetCriticalOmegaMax(),
+			VelocityMin: message.GetCriticalVelocityMin(),
+			VelocityMax: message.GetCriticalVelocityMax(),
+		},
+	}, nil
+}
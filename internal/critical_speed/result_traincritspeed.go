@@ -0,0 +1,103 @@
+package critical_speed
+
+import (
+	"bufio"
+	"io"
+	"math"
+)
+
+// WriteTrainCritSpeed writes the result as a JSON result file in the layout of the
+// Python TrainCritSpeed, so that post-processing written for it reads GoTrain results
+// unchanged. The fields follow the attributes of its CriticalSpeed object:
+//
+//	{
+//		"omega": [...],
+//		"track": {"omega": [...], "phase_velocity": [...]},
+//		"soil": {"omega": [...], "phase_velocity": [...]},
+//		"frequency": 125.66,
+//		"critical_speed": 198.45
+//	}
+//
+// where frequency is the critical angular frequency [rad/s], as in TrainCritSpeed.
+// The frequencies are always angular frequencies, whatever the FrequencyUnit, and the
+// file has no schema_version. NaN and infinite values are written as the NaN,
+// Infinity and -Infinity literals of the Python json module, which reads them back
+// as floats. The curves are written value by value, and rounded to the Precision, as
+// in WriteJSON.
+//
+// Parameters:
+//   - w: Writer receiving the JSON document
+//
+// Returns:
+//   - error: An error if writing fails
+func (r Result) WriteTrainCritSpeed(w io.Writer) error {
+	bw := bufio.NewWriterSize(w, jsonBufferSize)
+	b := make([]byte, 0, 32)
+
+	bw.WriteString("{\n\t\"omega\": ")
+	writePythonJSONArray(bw, r.Omega, "\t", r.Precision, b)
+	for _, curve := range []struct {
+		name          string
+		phaseVelocity []float64
+	}{{"track", r.TrackPhaseVelocity}, {"soil", r.SoilPhaseVelocity}} {
+		bw.WriteString(",\n\t\"" + curve.name + "\": {\n\t\t\"omega\": ")
+		writePythonJSONArray(bw, r.Omega, "\t\t", r.Precision, b)
+		bw.WriteString(",\n\t\t\"phase_velocity\": ")
+		writePythonJSONArray(bw, curve.phaseVelocity, "\t\t", r.Precision, b)
+		bw.WriteString("\n\t}")
+	}
+	bw.WriteString(",\n\t\"frequency\": ")
+	bw.Write(appendPythonJSONFloat(b, roundDigits(r.CriticalOmega, r.Precision)))
+	bw.WriteString(",\n\t\"critical_speed\": ")
+	bw.Write(appendPythonJSONFloat(b, roundDigits(r.CriticalVelocity, r.Precision)))
+	bw.WriteString("\n}\n")
+	return bw.Flush()
+}
+
+// writePythonJSONArray writes an array of numbers as the Python json module does,
+// with NaN and infinite values as literals. The errors of the writer are reported by
+// its Flush.
+//
+// Parameters:
+//   - bw: The buffered writer
+//   - values: The values (an empty array when nil)
+//   - indent: Indentation of the field holding the array
+//   - digits: Significant digits of the values (0 for all digits)
+//   - b: Scratch buffer for the encoding of the values
+func writePythonJSONArray(bw *bufio.Writer, values []float64, indent string, digits int, b []byte) {
+	if len(values) == 0 {
+		bw.WriteString("[]")
+		return
+	}
+	bw.WriteString("[")
+	for i, v := range values {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString("\n\t" + indent)
+		bw.Write(appendPythonJSONFloat(b[:0], roundDigits(v, digits)))
+	}
+	bw.WriteString("\n" + indent + "]")
+}
+
+// appendPythonJSONFloat appends a number as appendJSONFloat does, with NaN and
+// infinite values as the NaN, Infinity and -Infinity literals of the Python json
+// module.
+//
+// Parameters:
+//   - b: Buffer the number is appended to
+//   - v: The number
+//
+// Returns:
+//   - []byte: The buffer with the number
+func appendPythonJSONFloat(b []byte, v float64) []byte {
+	switch {
+	case math.IsNaN(v):
+		return append(b, "NaN"...)
+	case math.IsInf(v, 1):
+		return append(b, "Infinity"...)
+	case math.IsInf(v, -1):
+		return append(b, "-Infinity"...)
+	}
+	return appendJSONFloat(b, v)
+}
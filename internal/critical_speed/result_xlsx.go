@@ -0,0 +1,171 @@
+package critical_speed
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalXLSX encodes the result as an Excel workbook with two sheets: "Curves",
+// with the dispersion curves (one row per frequency, empty cells where no root is
+// found), and "Summary", with the critical values followed by the configuration
+// that produced them (one row per parameter, e.g. soil_layers.0.young_modulus).
+// The frequencies are given in the unit of the result (see FrequencyAxis).
+//
+// Parameters:
+//   - config: The configuration of the analysis, echoed in the summary sheet
+//
+// Returns:
+//   - []byte: The content of the .xlsx file
+//   - error: An error if the workbook cannot be encoded, e.g. when the curves
+//     exceed the rows of a worksheet
+func (r Result) MarshalXLSX(config Config) ([]byte, error) {
+	name, unit, scale := r.FrequencyAxis()
+	curves := [][]any{{
+		name + " [" + unit + "]",
+		"track_phase_velocity [m/s]",
+		"soil_phase_velocity [m/s]",
+	}}
+	for i, w := range r.Omega {
+		row := []any{w * scale, nil, nil}
+		if i < len(r.TrackPhaseVelocity) {
+			row[1] = numberCell(r.TrackPhaseVelocity[i])
+		}
+		if i < len(r.SoilPhaseVelocity) {
+			row[2] = numberCell(r.SoilPhaseVelocity[i])
+		}
+		curves = append(curves, row)
+	}
+
+	summary := [][]any{
+		{"critical_velocity [m/s]", r.CriticalVelocity},
+		{"critical_" + name + " [" + unit + "]", r.CriticalOmega * scale},
+		{"site_period [s]", r.SitePeriod},
+		{"resonance_frequency [Hz]", r.ResonanceFrequency},
+	}
+	if band := r.CriticalBand; band.OmegaMax != 0 {
+		summary = append(summary,
+			[]any{"critical_" + name + "_min [" + unit + "]", band.OmegaMin * scale},
+			[]any{"critical_" + name + "_max [" + unit + "]", band.OmegaMax * scale},
+			[]any{"critical_velocity_min [m/s]", band.VelocityMin},
+			[]any{"critical_velocity_max [m/s]", band.VelocityMax})
+	}
+	summary = append(summary,
+		[]any{"schema_version", SchemaVersion},
+		[]any{},
+		[]any{"Input", "Value"})
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding configuration: %v", err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error encoding configuration: %v", err)
+	}
+	summary = appendConfigRows(summary, &document, "")
+
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	if err := workbook.SetSheetName(workbook.GetSheetName(0), "Curves"); err != nil {
+		return nil, fmt.Errorf("error writing workbook: %v", err)
+	}
+	if _, err := workbook.NewSheet("Summary"); err != nil {
+		return nil, fmt.Errorf("error writing workbook: %v", err)
+	}
+	for _, sheet := range []struct {
+		name string
+		rows [][]any
+	}{{"Curves", curves}, {"Summary", summary}} {
+		if err := writeSheet(workbook, sheet.name, sheet.rows); err != nil {
+			return nil, fmt.Errorf("error writing workbook sheet %s: %v", sheet.name, err)
+		}
+	}
+	buf, err := workbook.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing workbook: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// numberCell returns the value of a numeric cell: the number, or nil (an empty cell)
+// for NaN and infinite values, which have no Excel representation.
+func numberCell(v float64) any {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return v
+}
+
+// writeSheet writes the rows of a worksheet with a stream writer, which writes the
+// rows as they are set instead of holding their cells in the workbook. Rows beyond
+// the last row of a worksheet (excelize.TotalRows) are rejected.
+//
+// Parameters:
+//   - workbook: The workbook
+//   - sheet: Name of the worksheet
+//   - rows: The cells of the worksheet, row by row (nil for an empty cell)
+//
+// Returns:
+//   - error: An error if the rows exceed the limits of a worksheet or cannot be written
+func writeSheet(workbook *excelize.File, sheet string, rows [][]any) error {
+	stream, err := workbook.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	return stream.Flush()
+}
+
+// appendConfigRows appends one row per scalar of a YAML document, in document
+// order, with its dot-separated path (list elements are addressed by their index).
+//
+// Parameters:
+//   - rows: The rows to append to
+//   - node: The YAML node
+//   - path: Path of the node ("" for the document)
+//
+// Returns:
+//   - [][]any: The rows with the scalars of the node appended
+func appendConfigRows(rows [][]any, node *yaml.Node, path string) [][]any {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			rows = appendConfigRows(rows, child, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			rows = appendConfigRows(rows, node.Content[i+1], join(node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			rows = appendConfigRows(rows, child, join(strconv.Itoa(i)))
+		}
+	case yaml.ScalarNode:
+		var value any = node.Value
+		if node.Tag == "!!int" || node.Tag == "!!float" {
+			if v, err := strconv.ParseFloat(node.Value, 64); err == nil {
+				value = numberCell(v)
+			}
+		}
+		rows = append(rows, []any{path, value})
+	}
+	return rows
+}
@@ -0,0 +1,219 @@
+package critical_speed
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// ScaffoldOptions selects the content of a starter configuration (see Scaffold).
+type ScaffoldOptions struct {
+	TrackType    string  // Type of track: "ballast", "slabtrack" or "piledslab"
+	FrequencyMin float64 // Minimum angular frequency [rad/s] (default 1)
+	FrequencyMax float64 // Maximum angular frequency [rad/s] (default 400)
+	Points       int     // Number of frequencies (default 100)
+	ResultFile   string  // Name of the result file (default "dispersion_results.json")
+}
+
+// scaffoldTemplate is the starter configuration, with typical parameters of a
+// railway line on soft soil and a comment describing every field.
+var scaffoldTemplate = template.Must(template.New("config").Parse(`# GoTrain configuration
+# Generated by "gotrain init". Replace the parameters with those of your
+# track and site, then run: gotrain run -config <this file>
+
+# Version of the layout of this file: files of older versions are upgraded when read
+config_version: {{.ConfigVersion}}
+
+# Track type: can be "ballast", "slabtrack" or "piledslab" (slab track on piles)
+track_type: {{.TrackType}}
+
+# Frequency range of the dispersion curves [rad/s, or Hz with unit]. The range must include the
+# frequency at which the track and soil curves intersect.
+frequency:
+  min: {{.FrequencyMin}}
+  max: {{.FrequencyMax}}
+  points: {{.Points}}
+  # unit: "Hz"            # Unit of min and max and of the result frequencies: "rad/s" (default) or "Hz"
+  # segments:             # Ranges with their own number of points, instead of min, max and points
+  #   - {min: 1, max: 120, points: 200}
+  #   - {min: 120, max: 400, points: 50}
+{{if eq .TrackType "ballast"}}
+# Ballast track parameters
+ballast_track:
+  EI_rail: 6.4e6         # Rail bending stiffness [N·m^2] (UIC54: 4.8e6, UIC60: 6.4e6)
+  m_rail: 60.21          # Rail mass per unit length [kg/m]
+  k_rail_pad: 6e8        # Railpad stiffness [N/m]
+  c_rail_pad: 2.5e5      # Railpad damping [N·s/m]
+  m_sleeper: 238.5       # Sleeper (distributed) mass [kg/m]: sleeper mass / sleeper spacing
+  E_ballast: 100e6       # Young's modulus of ballast [Pa]
+  h_ballast: 0.3         # Ballast (layer) thickness [m]
+  width_sleeper: 1.25    # Half-track width [m]
+  rho_ballast: 2000      # Ballast density [kg/m^3]
+  soil_stiffness: 0.0    # Soil (spring) stiffness [N/m]; 0 when the soil is modelled by soil_layers
+
+# Geogrids in the ballast layer (optional)
+# ballast_reinforcement:
+#   depths: [0.2]         # Depths of the geogrids below the base of the sleepers [m]
+#   stiffness_factor: 1.5 # Stiffness increase of the ballast in the interlocking zone of a geogrid
+{{else}}
+# Slab track parameters
+slab_track:
+  EI_rail: 1.29e7        # Rail bending stiffness [N·m^2] (two rails)
+  m_rail: 120            # Rail mass per unit length [kg/m] (two rails)
+  EI_slab: 6.40625e8     # Slab bending stiffness [N·m^2]: E * width * height^3 / 12 (30e9 * 1.25 * 0.35^3 / 12)
+  m_slab: 1093.75        # Slab mass per unit length [kg/m]: density * width * height (2500 * 1.25 * 0.35)
+  k_rail_pad: 5e8        # Railpad stiffness [N/m]
+  c_rail_pad: 2.5e5      # Railpad damping [N·s/m]
+  soil_stiffness: 0.0    # Soil (spring) stiffness [N/m]; 0 when the soil is modelled by soil_layers
+{{- if eq .TrackType "piledslab"}}
+
+# Rows of piles under the slab, smeared along the track into a spring of stiffness / spacing
+piles:
+  spacing: 2.5           # Distance between the pile rows along the track [m]
+  count: 1               # Number of piles in a row
+  young_modulus: 30e9    # Young's modulus of the piles [Pa]
+  diameter: 0.3          # Diameter of the piles [m]
+  length: 15             # Length of the piles, down to the bearing layer [m]
+  # stiffness: 1.4e8     # Vertical stiffness of the head of a pile row [N/m] (instead of the properties of the piles)
+{{- end}}
+{{end}}
+# Soil profile, from the surface down. The last layer is the halfspace (thickness .inf).
+# Young's modulus follows from the shear wave velocity: E = 2 (1 + ν) ρ Vs².
+# Alternatively, replace soil_layers by a soil_profile section to read a plain-text
+# Vs profile (e.g. a Geopsy .model file), or by a soil_cpt section to derive the
+# profile from a cone penetration test (GEF file).
+soil_layers:
+  - thickness: 5          # Thickness of the layer [m]
+    density: 1900         # Density of the layer [kg/m^3]
+    young_modulus: 2.67e7 # Young's modulus of the layer [Pa] (Vs ≈ 73 m/s)
+    poisson_ratio: 0.33   # Poisson's ratio of the layer
+  - thickness: 10
+    density: 1900
+    young_modulus: 1.14e8 # Vs ≈ 150 m/s
+    poisson_ratio: 0.33
+  - thickness: .inf       # Halfspace
+    density: 1900
+    young_modulus: 4.71e8 # Vs ≈ 305 m/s
+    poisson_ratio: 0.33
+
+# Ground improved by columns, e.g. stone columns or deep soil mixing (optional)
+# columns:
+#   young_modulus: 100e6  # Young's modulus of the column material [Pa]
+#   area_ratio: 0.2       # Area replacement ratio: column cross-section over its tributary area
+#   depth: 4              # Treated depth below the surface [m]
+
+# Embankment variants built on the soil layers, whose critical speeds are compared (optional)
+# embankment:
+#   young_modulus: 80e6   # Young's modulus of the fill [Pa]
+#   variants:
+#     - name: "raised"
+#       height: 3         # Height of the embankment [m]
+#       crest_width: 8    # Width of the crest [m]
+
+# Stiffening of the soil under the static axle load (optional)
+# stress_dependence:
+#   axle_load: 225e3      # Static axle load [N]
+#   groundwater_depth: 1  # Depth of the groundwater table below the surface [m]
+
+# Splitting of thick soil layers (optional), e.g. into sublayers no thicker than a
+# quarter of the shortest wavelength at the highest frequency
+# soil_discretization:
+#   wavelength_fraction: 0.25
+
+# Solver options (optional)
+# solver:
+#   root_finder: "polynomial" # Root finder of the track dispersion: "polynomial" (default), "brent", "ridders" or "chandrupatla"
+#   soil_root_finder: "chandrupatla" # Refine the roots of the soil dispersion: "brent", "ridders" or "chandrupatla" (default: none)
+#   fast: false           # Fast approximate mode for screening studies (critical velocity within 1%)
+#   attenuation: false    # Complex wave numbers of the damped track: spatial decay rate, written as CSV next to the result file
+#   leaky_modes: false    # Leaky soil modes above the shear wave speed of the halfspace, written as CSV next to the result file
+
+# Deflection versus speed of a moving load (optional), written as CSV next to the result file
+# moving_load:
+#   load: 1e5             # Moving load on each rail [N]
+#   speed_min: 10         # Minimum speed [m/s]
+#   speed_max: 150        # Maximum speed [m/s]
+#   speed_points: 57      # Number of speeds
+#   rail_section_modulus: 3.77e-4 # Section modulus of the rail [m^3], for the rail stress
+
+# Free-field ground vibration at the operating speed (optional), written as CSV next to the result file
+# ground_vibration:
+#   load: 1e5             # Load on each rail [N]
+#   speed: 60             # Operating speed [m/s]
+#   distances: [10, 25, 50] # Distances from the centre line of the track [m]
+
+# Operating speeds to assess (optional): Mach cones where they exceed the soil phase velocity
+# assessment:
+#   speeds: [60, 90]      # Operating speeds of the trains [m/s]
+#   spectrum: "axles.csv" # Nominal excitation spectrum (frequency [Hz], amplitude), Doppler shifted for a trackside receiver
+#   band_tolerance: 10    # Critical band where the track and soil phase velocities differ by at most 10% [%]
+
+# Wheel-rail force of the irregularity of the rail versus speed (optional), written as CSV next to the result file
+# irregularity:
+#   wheel_load: 85e3      # Static wheel load [N]
+#   psd: "german_low"     # Spectrum of the irregularity: "german_low" (default), "german_high", or a CSV file with file
+#   speed_min: 20         # Minimum speed [m/s]
+#   speed_max: 140        # Maximum speed [m/s]
+#   speed_points: 25      # Number of speeds
+
+# Train whose load harmonics are flagged near the track-soil phase velocity at the operating speeds (optional)
+# train:
+#   vehicles: 8           # Number of vehicles
+#   vehicle_length: 26.4  # Length of a vehicle over the couplings [m]
+#   bogie_spacing: 19     # Distance between the bogie centres [m]
+#   axle_spacing: 3       # Distance between the axles of a bogie [m]
+#   axle_load: 170e3      # Load of an axle [N]
+
+# Soil improvement required for a target critical speed (optional), written as CSV next to the result file
+# improvement:
+#   target_speed: 90      # Target critical speed [m/s]
+#   parameter: "stiffness" # Solved parameter: factor on the Young's modulus of the layers ("stiffness") or depth ("depth")
+#   layers: [1, 2]        # Improved layers, numbered from 1 at the surface (default all but the halfspace)
+
+# Output file configuration
+output:
+  file_name: {{printf "%q" .ResultFile}}
+  format: "json"          # Format of the result file: "json" (default), "traincritspeed", "protobuf", "xlsx" or "gob"
+  # precision: 6         # Significant digits of the numbers of the JSON result file and CSV tables (default all digits)
+`))
+
+// Scaffold generates a valid starter configuration for a track type, with
+// typical parameters and a comment describing every field, for new users to adapt
+// to their project.
+//
+// Parameters:
+//   - opts: Track type and optional frequency range and result file
+//
+// Returns:
+//   - []byte: The YAML configuration
+//   - error: An error if the track type or the frequency range is invalid
+func Scaffold(opts ScaffoldOptions) ([]byte, error) {
+	if opts.TrackType != "ballast" && opts.TrackType != "slabtrack" && opts.TrackType != "piledslab" {
+		return nil, fmt.Errorf("invalid track type: %s. Supported types are 'ballast', 'slabtrack' or 'piledslab'", opts.TrackType)
+	}
+	if opts.FrequencyMin == 0 {
+		opts.FrequencyMin = 1
+	}
+	if opts.FrequencyMax == 0 {
+		opts.FrequencyMax = 400
+	}
+	if opts.Points == 0 {
+		opts.Points = 100
+	}
+	if opts.ResultFile == "" {
+		opts.ResultFile = "dispersion_results.json"
+	}
+	if !(opts.FrequencyMin > 0) || !(opts.FrequencyMax > opts.FrequencyMin) || opts.Points < 2 {
+		return nil, fmt.Errorf("invalid frequency range: min must be positive and smaller than max, with at least 2 points")
+	}
+
+	var buffer bytes.Buffer
+	data := struct {
+		ScaffoldOptions
+		ConfigVersion int
+	}{opts, ConfigVersion}
+	if err := scaffoldTemplate.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,229 @@
+package critical_speed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// SchemaVersion is the version of the layout of the JSON result files written by
+// this version of GoTrain. It is incremented whenever the layout changes, with a
+// migration from the previous version added to migrations.
+const SchemaVersion = 3
+
+// migrations upgrade the fields of a JSON result file by one version: migrations[v]
+// converts a file of version v into version v+1. Files written before the
+// schema_version field was introduced are version 0.
+var migrations = []func(fields map[string]json.RawMessage) error{
+	// 0 -> 1: the schema_version field is added, the other fields are unchanged
+	func(fields map[string]json.RawMessage) error { return nil },
+	// 1 -> 2: the site_period and resonance_frequency fields are added, zero (unknown)
+	// for the files written before them
+	func(fields map[string]json.RawMessage) error { return nil },
+	// 2 -> 3: the optional critical band fields are added, absent from the files
+	// written before them
+	func(fields map[string]json.RawMessage) error { return nil },
+}
+
+// ResultsVersion returns the schema version of a JSON result file.
+//
+// Parameters:
+//   - data: Content of the JSON result file
+//
+// Returns:
+//   - int: The schema version (0 for files without a schema_version field)
+//   - error: An error if the data is not a JSON object or the version is invalid
+func ResultsVersion(data []byte) (int, error) {
+	data, err := decompressResults(data)
+	if err != nil {
+		return 0, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, fmt.Errorf("invalid result file: %v", err)
+	}
+	return schemaVersion(fields)
+}
+
+// schemaVersion returns the schema version of the decoded fields of a result file.
+//
+// Parameters:
+//   - fields: The top-level fields of the result file
+//
+// Returns:
+//   - int: The schema version (0 without a schema_version field)
+//   - error: An error if the version is invalid
+func schemaVersion(fields map[string]json.RawMessage) (int, error) {
+	raw, ok := fields["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid schema_version: %s", raw)
+	}
+	return version, nil
+}
+
+// hertzKeys are the keys of the frequencies of JSON result files written in Hz (see
+// Result.WriteJSON), with the keys of the angular frequencies they replace.
+var hertzKeys = [][2]string{
+	{"frequency", "omega"},
+	{"critical_frequency", "critical_omega"},
+	{"critical_frequency_min", "critical_omega_min"},
+	{"critical_frequency_max", "critical_omega_max"},
+}
+
+// UpgradeResults converts a JSON result file of any earlier schema version to
+// SchemaVersion, applying the migrations of every version in turn. The upgraded
+// file is written in the layout of the current version; files that are already
+// current are only reformatted. Files written in Hz keep their frequency fields.
+//
+// Parameters:
+//   - data: Content of the JSON result file
+//
+// Returns:
+//   - []byte: The upgraded JSON result file
+//   - int: The schema version of the original file
+//   - error: An error if the file is invalid or newer than SchemaVersion
+func UpgradeResults(data []byte) ([]byte, int, error) {
+	results, version, hertz, err := decodeResults(data)
+	if err != nil {
+		return nil, version, err
+	}
+	out, err := json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		return nil, version, err
+	}
+	if hertz {
+		for _, keys := range hertzKeys {
+			out = bytes.Replace(out, []byte("\n\t\""+keys[1]+"\": "), []byte("\n\t\""+keys[0]+"\": "), 1)
+		}
+	}
+	return out, version, nil
+}
+
+// decodeResults decodes a JSON result file of any schema version up to SchemaVersion
+// into the layout of the current version. The frequencies of files written in Hz are
+// decoded as they are, in the fields of the angular frequencies.
+//
+// Parameters:
+//   - data: Content of the JSON result file
+//
+// Returns:
+//   - DispersionResults: The decoded results
+//   - int: The schema version of the file
+//   - bool: Whether the file was written in Hz
+//   - error: An error if the file is invalid or newer than SchemaVersion
+func decodeResults(data []byte) (DispersionResults, int, bool, error) {
+	data, err := decompressResults(data)
+	if err != nil {
+		return DispersionResults{}, 0, false, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return DispersionResults{}, 0, false, fmt.Errorf("invalid result file: %v", err)
+	}
+	version, err := schemaVersion(fields)
+	if err != nil {
+		return DispersionResults{}, 0, false, err
+	}
+	if version > SchemaVersion {
+		return DispersionResults{}, version, false, fmt.Errorf("unsupported schema_version %d: this version of GoTrain supports up to %d", version, SchemaVersion)
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		if err := migrations[v](fields); err != nil {
+			return DispersionResults{}, version, false, fmt.Errorf("error upgrading from schema_version %d: %v", v, err)
+		}
+		fields["schema_version"] = json.RawMessage(fmt.Sprint(v + 1))
+	}
+
+	hertz := false
+	for _, keys := range hertzKeys {
+		if value, ok := fields[keys[0]]; ok {
+			if _, ok := fields[keys[1]]; !ok {
+				fields[keys[1]] = value
+				delete(fields, keys[0])
+				hertz = true
+			}
+		}
+	}
+
+	// Check the upgraded fields against the current layout
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return DispersionResults{}, version, false, err
+	}
+	var results DispersionResults
+	if err := json.Unmarshal(upgraded, &results); err != nil {
+		return DispersionResults{}, version, false, fmt.Errorf("invalid result file: %v", err)
+	}
+	if len(results.Omega) == 0 {
+		return DispersionResults{}, version, false, fmt.Errorf("invalid result file: no omega values")
+	}
+	return results, version, hertz, nil
+}
+
+// UnmarshalResultJSON decodes a JSON result file (see DispersionResults) of any
+// schema version up to SchemaVersion. The "NaN" strings of the soil phase velocity
+// are decoded as NaN. The frequencies of files written in Hz are converted to
+// angular frequencies, with FrequencyUnit UnitHertz.
+//
+// Parameters:
+//   - data: Content of the JSON result file
+//
+// Returns:
+//   - Result: The decoded result
+//   - error: An error if the file is invalid or newer than SchemaVersion
+func UnmarshalResultJSON(data []byte) (Result, error) {
+	results, _, hertz, err := decodeResults(data)
+	if err != nil {
+		return Result{}, err
+	}
+	if hertz {
+		for i := range results.Omega {
+			results.Omega[i] *= 2 * math.Pi
+		}
+		results.CriticalOmega *= 2 * math.Pi
+		results.CriticalOmegaMin *= 2 * math.Pi
+		results.CriticalOmegaMax *= 2 * math.Pi
+	}
+
+	result := Result{
+		Omega:              results.Omega,
+		TrackPhaseVelocity: results.TrackPhaseVelocity,
+		SoilPhaseVelocity:  make([]float64, len(results.SoilPhaseVelocity)),
+		CriticalOmega:      results.CriticalOmega,
+		CriticalVelocity:   results.CriticalVelocity,
+		CriticalBand: CriticalBand{
+			OmegaMin:    results.CriticalOmegaMin,
+			OmegaMax:    results.CriticalOmegaMax,
+			VelocityMin: results.CriticalVelocityMin,
+			VelocityMax: results.CriticalVelocityMax,
+		},
+		SitePeriod:         results.SitePeriod,
+		ResonanceFrequency: results.ResonanceFrequency,
+	}
+	if hertz {
+		result.FrequencyUnit = UnitHertz
+	}
+	for i, v := range results.SoilPhaseVelocity {
+		switch v := v.(type) {
+		case float64:
+			result.SoilPhaseVelocity[i] = v
+		case string:
+			if v != "NaN" {
+				return Result{}, fmt.Errorf("invalid result file: invalid soil phase velocity %q", v)
+			}
+			result.SoilPhaseVelocity[i] = math.NaN()
+		default:
+			return Result{}, fmt.Errorf("invalid result file: invalid soil phase velocity %v", v)
+		}
+	}
+	if len(result.TrackPhaseVelocity) != len(result.Omega) || len(result.SoilPhaseVelocity) != len(result.Omega) {
+		return Result{}, fmt.Errorf("invalid result file: the curves do not have one value per omega")
+	}
+	return result, nil
+}
@@ -0,0 +1,205 @@
+package critical_speed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	moving_load "github.com/PlatypusBytes/GoTrain/internal/moving_load"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// resultTable is a CSV table written next to the result file.
+type resultTable struct {
+	name     string                  // Description of the table, for the logs
+	fileName string                  // Path of the CSV file, or s3:// or gs:// URL
+	write    func(w io.Writer) error // Writes the table
+}
+
+// resultTables returns the CSV tables of a result: the attenuation of the track, the
+// leaky soil modes, the moving load response, the ground vibration, the Mach cones, the Doppler-shifted
+// spectrum, the load harmonics of the train, the wheel-rail force of the irregularity
+// of the rail, the improved soil profile and the critical speeds of the embankment
+// variants and soil profiles, when they are computed. With a Precision, their numbers
+// are rounded to that number of significant digits.
+//
+// Parameters:
+//   - result: The computed result
+//   - config: The configuration, with the file names of the tables
+//
+// Returns:
+//   - []resultTable: The tables to write
+func resultTables(result Result, config Config) []resultTable {
+	var tables []resultTable
+	if result.TrackDecayRate != nil {
+		tables = append(tables, resultTable{
+			name:     "track attenuation",
+			fileName: tableFileName(config, "", "_attenuation.csv"),
+			write: func(w io.Writer) error {
+				return WriteAttenuationCSV(w, result.Omega, result.TrackPhaseVelocity, result.TrackDecayRate)
+			},
+		})
+	}
+	if result.SoilLeakyVelocity != nil {
+		tables = append(tables, resultTable{
+			name:     "leaky soil modes",
+			fileName: tableFileName(config, "", "_leaky.csv"),
+			write: func(w io.Writer) error {
+				return WriteLeakyCSV(w, result.Omega, result.SoilPhaseVelocity, result.SoilLeakyVelocity)
+			},
+		})
+	}
+	if result.MovingLoad != nil {
+		tables = append(tables, resultTable{
+			name:     "moving load response",
+			fileName: tableFileName(config, config.MovingLoad.FileName, "_moving_load.csv"),
+			write: func(w io.Writer) error {
+				return moving_load.WriteCSV(w, result.MovingLoad, result.CriticalVelocity)
+			},
+		})
+	}
+	if result.GroundVibration != nil {
+		tables = append(tables, resultTable{
+			name:     "ground vibration",
+			fileName: tableFileName(config, config.GroundVibration.FileName, "_ground_vibration.csv"),
+			write: func(w io.Writer) error {
+				return moving_load.WriteVibrationCSV(w, result.GroundVibration)
+			},
+		})
+	}
+	if result.MachCones != nil {
+		tables = append(tables, resultTable{
+			name:     "Mach cones",
+			fileName: tableFileName(config, config.Assessment.FileName, "_mach.csv"),
+			write: func(w io.Writer) error {
+				return WriteMachCSV(w, result.MachCones)
+			},
+		})
+	}
+	if result.Doppler != nil {
+		tables = append(tables, resultTable{
+			name:     "Doppler-shifted spectrum",
+			fileName: tableFileName(config, config.Assessment.DopplerFileName, "_doppler.csv"),
+			write: func(w io.Writer) error {
+				return WriteDopplerCSV(w, result.Doppler)
+			},
+		})
+	}
+	if result.TrainExcitation != nil {
+		tables = append(tables, resultTable{
+			name:     "train load harmonics",
+			fileName: tableFileName(config, config.Train.FileName, "_train.csv"),
+			write: func(w io.Writer) error {
+				return WriteTrainCSV(w, result.TrainExcitation)
+			},
+		})
+	}
+	if result.Irregularity != nil {
+		tables = append(tables, resultTable{
+			name:     "irregularity response",
+			fileName: tableFileName(config, config.Irregularity.FileName, "_irregularity.csv"),
+			write: func(w io.Writer) error {
+				return WriteIrregularityCSV(w, result.Irregularity)
+			},
+		})
+	}
+	if result.Improvement != nil {
+		tables = append(tables, resultTable{
+			name:     "improved soil profile",
+			fileName: tableFileName(config, config.Improvement.FileName, "_improvement.csv"),
+			write: func(w io.Writer) error {
+				return WriteImprovementCSV(w, *result.Improvement)
+			},
+		})
+	}
+	if result.Embankments != nil {
+		tables = append(tables, resultTable{
+			name:     "embankment variants",
+			fileName: tableFileName(config, config.Embankment.FileName, "_embankment.csv"),
+			write: func(w io.Writer) error {
+				return WriteEmbankmentCSV(w, result.Embankments)
+			},
+		})
+	}
+	if result.Profiles != nil {
+		tables = append(tables, resultTable{
+			name:     "soil profiles",
+			fileName: tableFileName(config, "", "_profiles.csv"),
+			write: func(w io.Writer) error {
+				return WriteProfilesCSV(w, result.Profiles)
+			},
+		})
+	}
+	if result.Precision > 0 {
+		for i := range tables {
+			tables[i].write = roundCSV(tables[i].write, result.Precision)
+		}
+	}
+	return tables
+}
+
+// roundCSV rounds the numbers of a CSV table to a number of significant digits.
+//
+// Parameters:
+//   - write: Writes the table
+//   - digits: Significant digits of the numbers
+//
+// Returns:
+//   - func(w io.Writer) error: Writes the table with the rounded numbers
+func roundCSV(write func(w io.Writer) error, digits int) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			return err
+		}
+		reader := csv.NewReader(&buf)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			for i, field := range record {
+				if v, err := strconv.ParseFloat(field, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+					record[i] = strconv.FormatFloat(roundDigits(v, digits), 'g', -1, 64)
+				}
+			}
+		}
+		writer := csv.NewWriter(w)
+		writer.WriteAll(records)
+		return writer.Error()
+	}
+}
+
+// tableFileName returns the path of a CSV table: the configured file name, or a file
+// next to the result file with a suffix.
+func tableFileName(config Config, fileName string, suffix string) string {
+	if fileName != "" {
+		return fileName
+	}
+	resultFile := config.Output.FileName
+	return strings.TrimSuffix(resultFile, OutputExt(resultFile)) + suffix
+}
+
+// saveTable writes a CSV table to a file.
+//
+// Parameters:
+//   - fileName: Path of the CSV file, or s3:// or gs:// URL
+//   - write: Writes the table
+//
+// Returns:
+//   - error: An error if the file cannot be written
+func saveTable(fileName string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return classify(KindIO, err)
+	}
+	if err := storage.WriteFile(fileName, buf.Bytes()); err != nil {
+		return classify(KindIO, fmt.Errorf("error writing %s: %v", fileName, err))
+	}
+	return nil
+}
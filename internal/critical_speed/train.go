@@ -0,0 +1,248 @@
+package critical_speed
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// defaultTrainSpeedRatio is the ratio of the speed to the phase velocity at a load
+// harmonic above which the harmonic is flagged, when max_speed_ratio is not given.
+const defaultTrainSpeedRatio = 0.7
+
+// dominantHarmonicFraction is the fraction of the largest load harmonic above which a
+// harmonic is dominant.
+const dominantHarmonicFraction = 0.25
+
+// Train describes a train of identical vehicles, each with two bogies of two axles.
+type Train struct {
+	Vehicles      int     // Number of vehicles
+	VehicleLength float64 // Length of a vehicle over the couplings [m]
+	BogieSpacing  float64 // Distance between the bogie centres of a vehicle [m]
+	AxleSpacing   float64 // Distance between the axles of a bogie [m]
+	AxleLoad      float64 // Load of an axle [N]
+}
+
+// AxlePositions returns the positions of the axles of the train along the track,
+// from the front of the first vehicle.
+//
+// Returns:
+//   - []float64: The positions of the axles [m]
+func (t Train) AxlePositions() []float64 {
+	positions := make([]float64, 0, 4*t.Vehicles)
+	for v := range t.Vehicles {
+		centre := (float64(v) + 0.5) * t.VehicleLength
+		for _, bogie := range []float64{-t.BogieSpacing / 2, t.BogieSpacing / 2} {
+			for _, axle := range []float64{-t.AxleSpacing / 2, t.AxleSpacing / 2} {
+				positions = append(positions, centre+bogie+axle)
+			}
+		}
+	}
+	return positions
+}
+
+// LoadHarmonic is a harmonic of the quasi-static load of a train, at a speed.
+type LoadHarmonic struct {
+	Speed         float64 // Speed of the train [m/s]
+	Harmonic      int     // Order n of the harmonic of the vehicle length
+	Wavelength    float64 // Wavelength of the harmonic, L / n [m]
+	Frequency     float64 // Frequency of the harmonic at the speed, n v / L [Hz]
+	Amplitude     float64 // Amplitude of the load distribution of the harmonic [N/m]
+	PhaseVelocity float64 // Higher of the track and soil phase velocities at the frequency [m/s]
+	SpeedRatio    float64 // Speed relative to the phase velocity
+	Flagged       bool    // Whether the speed ratio exceeds the largest acceptable ratio
+}
+
+// LoadSpectrum computes the quasi-static load spectrum of a train: the Fourier series
+// of the axle loads of a vehicle over its length, whose harmonics n have the
+// wavelengths L / n,
+//
+//	q_n = 1/L |Σ P e^{-i 2π n x_j / L}|
+//
+// of a long train of identical vehicles. Harmonics are computed up to the wavelength
+// of half the axle spacing.
+//
+// Returns:
+//   - []float64: The amplitude of the harmonics n = 1, 2, ... [N/m]
+func (t Train) LoadSpectrum() []float64 {
+	vehicle := t
+	vehicle.Vehicles = 1
+	axles := vehicle.AxlePositions()
+	count := int(2 * t.VehicleLength / t.AxleSpacing)
+	amplitudes := make([]float64, count)
+	for n := 1; n <= count; n++ {
+		k := 2 * math.Pi * float64(n) / t.VehicleLength
+		var sum complex128
+		for _, x := range axles {
+			sum += cmplx.Exp(complex(0, -k*x))
+		}
+		amplitudes[n-1] = t.AxleLoad * cmplx.Abs(sum) / t.VehicleLength
+	}
+	return amplitudes
+}
+
+// TrainExcitation computes the dominant harmonics of the quasi-static load of a train
+// at the operating speeds, and flags those where the speed approaches the phase
+// velocity of the track-soil system. The harmonic of wavenumber k moves with the
+// train at the frequency ω = k v, so it resonates where the phase velocity of the
+// system at ω drops to the speed: the harmonic is flagged when the speed exceeds
+// maxSpeedRatio times the phase velocity of the system at ω. The phase velocity of
+// the system is the higher of the track and soil phase velocities: the soil carries
+// the slow track at low frequencies, and the stiff track the soil at high ones, so
+// its lowest value is the critical speed at the intersection of the curves.
+//
+// Parameters:
+//   - train: The train
+//   - omega: Angular frequencies [rad/s]
+//   - trackPhaseVelocity: Phase velocities of the track [m/s] (zero where no root is found)
+//   - soilPhaseVelocity: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - speeds: Operating speeds [m/s]
+//   - maxSpeedRatio: Largest acceptable ratio of the speed to the phase velocity
+//
+// Returns:
+//   - []LoadHarmonic: The dominant harmonics at every speed, in order of the harmonics
+func TrainExcitation(train Train, omega []float64, trackPhaseVelocity []float64, soilPhaseVelocity []float64,
+	speeds []float64, maxSpeedRatio float64) []LoadHarmonic {
+
+	spectrum := train.LoadSpectrum()
+	largest := 0.0
+	for _, q := range spectrum {
+		largest = math.Max(largest, q)
+	}
+
+	var harmonics []LoadHarmonic
+	for _, v := range speeds {
+		for i, q := range spectrum {
+			if q < dominantHarmonicFraction*largest {
+				continue
+			}
+			n := i + 1
+			frequency := float64(n) * v / train.VehicleLength
+			c := systemPhaseVelocity(omega, trackPhaseVelocity, soilPhaseVelocity, 2*math.Pi*frequency)
+			h := LoadHarmonic{
+				Speed:         v,
+				Harmonic:      n,
+				Wavelength:    train.VehicleLength / float64(n),
+				Frequency:     frequency,
+				Amplitude:     q,
+				PhaseVelocity: c,
+				SpeedRatio:    v / c,
+			}
+			h.Flagged = h.SpeedRatio > maxSpeedRatio
+			harmonics = append(harmonics, h)
+		}
+	}
+	return harmonics
+}
+
+// systemPhaseVelocity returns the higher of the track and soil phase velocities at an
+// angular frequency, interpolated linearly between the frequencies of the curves and
+// clamped to their ends. Frequencies where a curve has no root are skipped.
+//
+// Parameters:
+//   - omega: Angular frequencies [rad/s]
+//   - trackPhaseVelocity: Phase velocities of the track [m/s] (zero where no root is found)
+//   - soilPhaseVelocity: Phase velocities of the soil [m/s] (NaN where no root is found)
+//   - w: The angular frequency [rad/s]
+//
+// Returns:
+//   - float64: The phase velocity [m/s] (NaN when neither curve has a root)
+func systemPhaseVelocity(omega []float64, trackPhaseVelocity []float64, soilPhaseVelocity []float64, w float64) float64 {
+	valid := func(c float64) bool { return c > 0 && !math.IsInf(c, 0) }
+	at := func(curve []float64) float64 {
+		prev := -1
+		for i, c := range curve {
+			if !valid(c) {
+				continue
+			}
+			if omega[i] >= w {
+				if prev < 0 {
+					return c
+				}
+				t := (w - omega[prev]) / (omega[i] - omega[prev])
+				return curve[prev] + t*(c-curve[prev])
+			}
+			prev = i
+		}
+		if prev < 0 {
+			return math.NaN()
+		}
+		return curve[prev]
+	}
+	track, soil := at(trackPhaseVelocity), at(soilPhaseVelocity)
+	switch {
+	case math.IsNaN(track):
+		return soil
+	case math.IsNaN(soil):
+		return track
+	}
+	return max(track, soil)
+}
+
+// trainParameters returns the train of the train section of a configuration and the
+// largest acceptable speed ratio.
+//
+// Parameters:
+//   - config: The loaded configuration structure
+//
+// Returns:
+//   - Train: The train (no vehicles without a train section)
+//   - float64: The largest acceptable ratio of the speed to the phase velocity
+//   - error: An error if the section is invalid or no operating speeds are given
+func trainParameters(config Config) (Train, float64, error) {
+	section := config.Train
+	if section.Vehicles == 0 {
+		return Train{}, 0, nil
+	}
+	train := Train{
+		Vehicles:      section.Vehicles,
+		VehicleLength: section.VehicleLength,
+		BogieSpacing:  section.BogieSpacing,
+		AxleSpacing:   section.AxleSpacing,
+		AxleLoad:      section.AxleLoad,
+	}
+	if train.Vehicles < 0 || !(train.AxleLoad > 0) || !(train.AxleSpacing > 0) || !(train.BogieSpacing > train.AxleSpacing) ||
+		!(train.VehicleLength >= train.BogieSpacing+train.AxleSpacing) {
+		return Train{}, 0, classify(KindConfig, fmt.Errorf("invalid train: vehicles and axle_load must be positive, and "+
+			"axle_spacing < bogie_spacing <= vehicle_length - axle_spacing"))
+	}
+	if len(config.Assessment.Speeds) == 0 {
+		return Train{}, 0, classify(KindConfig, fmt.Errorf("invalid train: the excitation assessment requires operating speeds"))
+	}
+	ratio := cmp.Or(section.MaxSpeedRatio, defaultTrainSpeedRatio)
+	if !(ratio > 0) {
+		return Train{}, 0, classify(KindConfig, fmt.Errorf("invalid train: max_speed_ratio must be positive"))
+	}
+	return train, ratio, nil
+}
+
+// trainColumns are the columns of the table written by WriteTrainCSV.
+var trainColumns = []string{"speed", "harmonic", "wavelength", "frequency", "amplitude", "phase_velocity", "speed_ratio", "flagged"}
+
+// WriteTrainCSV writes the dominant load harmonics of a train as CSV, one row per
+// speed and harmonic, with the columns speed [m/s], harmonic, wavelength [m],
+// frequency [Hz], amplitude [N/m], phase_velocity [m/s], speed_ratio and flagged.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - harmonics: The load harmonics
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteTrainCSV(w io.Writer, harmonics []LoadHarmonic) error {
+	writer := csv.NewWriter(w)
+	writer.Write(trainColumns)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, h := range harmonics {
+		writer.Write([]string{format(h.Speed), strconv.Itoa(h.Harmonic), format(h.Wavelength), format(h.Frequency),
+			format(h.Amplitude), format(h.PhaseVelocity), format(h.SpeedRatio), strconv.FormatBool(h.Flagged)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,140 @@
+package critical_speed
+
+import (
+	"context"
+	"fmt"
+
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// Validate checks a configuration without computing its dispersion curves: the
+// output format, the frequency range, the track parameters, the optional sections
+// and the soil profiles, including the ranges of the properties of the soil layers,
+// are checked as the analysis checks them before solving. Soil profiles and CPTs
+// given by file are read, and CPTs given by a provider are fetched, so that a
+// configuration passing Validate only fails in the solver. Problems of a value of a
+// configuration parsed from YAML are prefixed with its line, column and path.
+//
+// Parameters:
+//   - ctx: Context used to cancel the fetching of a CPT
+//   - config: The configuration structure
+//
+// Returns:
+//   - error: The first problem found (KindConfig, or KindIO when a referenced file
+//     cannot be read), nil for a valid configuration
+func Validate(ctx context.Context, config Config) error {
+	return locate(config, validate(ctx, config))
+}
+
+// validate checks a configuration for Validate.
+//
+// Parameters:
+//   - ctx: Context used to cancel the fetching of a CPT
+//   - config: The configuration structure
+//
+// Returns:
+//   - error: The first problem found, nil for a valid configuration
+func validate(ctx context.Context, config Config) error {
+	if err := checkFormat(config.Output.Format); err != nil {
+		return err
+	}
+	if err := checkPrecision(config.Output.Precision); err != nil {
+		return err
+	}
+	if _, err := Frequencies(config); err != nil {
+		return err
+	}
+	if len(config.Frequency.Segments) == 0 && (config.Frequency.Points < 2 || !(config.Frequency.Min >= 0) || !(config.Frequency.Max > config.Frequency.Min)) {
+		return classify(KindConfig, fieldError("frequency", fmt.Errorf("invalid frequency range: at least two points and 0 <= min < max are required")))
+	}
+	if _, _, err := trackParameters(config); err != nil {
+		return err
+	}
+	if config.Solver.RootFinder != math_utils.SolverPolynomial {
+		if _, err := math_utils.RootFinderByName(config.Solver.RootFinder); err != nil {
+			return classify(KindConfig, err)
+		}
+	}
+	if err := checkSoilRootFinder(config); err != nil {
+		return err
+	}
+	if err := checkCriticalBand(config); err != nil {
+		return err
+	}
+	if err := checkAttenuation(config); err != nil {
+		return err
+	}
+
+	if _, err := movingLoadParameters(config); err != nil {
+		return err
+	}
+	if _, err := groundVibrationParameters(config); err != nil {
+		return err
+	}
+	if _, err := irregularityParameters(config); err != nil {
+		return err
+	}
+	operatingSpeeds, err := assessmentSpeeds(config)
+	if err != nil {
+		return err
+	}
+	if _, _, err := trainParameters(config); err != nil {
+		return err
+	}
+	if _, err := embankmentVariants(config); err != nil {
+		return err
+	}
+	if config.Assessment.Spectrum != "" {
+		if len(operatingSpeeds) == 0 {
+			return classify(KindConfig, fmt.Errorf("invalid assessment: the excitation spectrum requires operating speeds"))
+		}
+		if _, err := LoadSpectrum(config.Assessment.Spectrum); err != nil {
+			return err
+		}
+	}
+
+	names, profiles, err := soilProfiles(config)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return validateSoil(ctx, config)
+	}
+	for i, profile := range profiles {
+		if err := validateSoil(ctx, profile); err != nil {
+			return fmt.Errorf("soil profile %s: %w", names[i], err)
+		}
+	}
+	return nil
+}
+
+// validateSoil checks the soil profile of a configuration, as the analysis loads,
+// improves, discretizes and stiffens it.
+//
+// Parameters:
+//   - ctx: Context used to cancel the fetching of a CPT
+//   - config: The configuration structure, with a single soil profile
+//
+// Returns:
+//   - error: The first problem found, nil for a valid soil profile
+func validateSoil(ctx context.Context, config Config) error {
+	untreated, _, err := loadSoilProfile(ctx, config)
+	if err != nil {
+		return err
+	}
+	original, err := improveSoilLayers(config, untreated)
+	if err != nil {
+		return err
+	}
+	if config.Improvement.TargetSpeed != 0 {
+		if _, err := improvementParameters(config, original); err != nil {
+			return err
+		}
+	}
+	unloaded, err := discretizeSoilLayers(config, original)
+	if err != nil {
+		return err
+	}
+	_, err = stiffenSoilLayers(config, unloaded)
+	return err
+}
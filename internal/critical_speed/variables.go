@@ -0,0 +1,93 @@
+package critical_speed
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variablesKey is the key of the section of a configuration defining its variables.
+const variablesKey = "variables"
+
+// variableReference matches a reference to a variable, e.g. ${pad_stiffness}.
+var variableReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// substituteVariables replaces the references ${name} to the variables of the
+// variables section of a configuration by their values, throughout the other
+// sections, so that a family of configurations differing in a handful of numbers is
+// written as one parameterized file.
+//
+// A value that is a single reference takes the value of the variable with its type,
+// e.g. a number; references within a longer value are replaced by the text of the
+// variable, e.g. "results_${name}.json".
+//
+// Parameters:
+//   - document: The parsed YAML document of the configuration, modified in place
+//
+// Returns:
+//   - error: An error if the variables section is not a mapping of scalar values, or
+//     a reference names an undefined variable
+func substituteVariables(document *yaml.Node) error {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := document.Content[0]
+	section := mappingValue(root, variablesKey)
+	if section == nil {
+		return nil
+	}
+	if section.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: the variables section must map names to values", section.Line)
+	}
+	variables := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(section.Content); i += 2 {
+		name, value := section.Content[i], section.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: variable %s must be a number or a string", value.Line, name.Value)
+		}
+		variables[name.Value] = value
+	}
+
+	var substitute func(node *yaml.Node) error
+	substitute = func(node *yaml.Node) error {
+		switch node.Kind {
+		case yaml.MappingNode:
+			// Only the values are substituted, the keys are the names of the sections
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i+1] == section {
+					continue
+				}
+				if err := substitute(node.Content[i+1]); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for _, child := range node.Content {
+				if err := substitute(child); err != nil {
+					return err
+				}
+			}
+		case yaml.ScalarNode:
+			for _, match := range variableReference.FindAllStringSubmatch(node.Value, -1) {
+				if _, ok := variables[match[1]]; !ok {
+					return fmt.Errorf("line %d: undefined variable %s", node.Line, match[1])
+				}
+			}
+			if match := variableReference.FindStringSubmatch(node.Value); match != nil && match[0] == node.Value {
+				value := variables[match[1]]
+				node.Value, node.Tag, node.Style = value.Value, value.Tag, value.Style
+				return nil
+			}
+			replaced := variableReference.ReplaceAllStringFunc(node.Value, func(reference string) string {
+				return variables[variableReference.FindStringSubmatch(reference)[1]].Value
+			})
+			if replaced != node.Value {
+				// Resolve the type of the new value, e.g. a number, unless it is quoted
+				node.Value, node.Tag = replaced, ""
+			}
+		}
+		return nil
+	}
+	return substitute(root)
+}
@@ -0,0 +1,352 @@
+package crossval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// Curve is a dispersion curve of a reference result.
+type Curve struct {
+	Omega         []float64 // Angular frequencies [rad/s], in increasing order
+	PhaseVelocity []float64 // Phase velocities [m/s] (NaN where no root is found)
+}
+
+// Reference is a result of TrainCritSpeed.
+type Reference struct {
+	Track            Curve   // Track dispersion curve
+	Soil             Curve   // Soil dispersion curve
+	CriticalOmega    float64 // Critical angular frequency [rad/s]
+	CriticalVelocity float64 // Critical speed [m/s]
+}
+
+// Difference compares the reference and computed phase velocities at a frequency.
+type Difference struct {
+	Omega     float64 // Angular frequency [rad/s]
+	Reference float64 // Phase velocity of the reference [m/s] (NaN where no root is found)
+	Computed  float64 // Computed phase velocity [m/s] (NaN where no root is found)
+}
+
+// Deviation returns the relative difference of the computed phase velocity from the
+// reference, NaN where either has no root.
+func (d Difference) Deviation() float64 {
+	return math.Abs(d.Computed-d.Reference) / math.Abs(d.Reference)
+}
+
+// CurveReport summarizes the differences between a reference and a computed
+// dispersion curve.
+type CurveReport struct {
+	Differences  []Difference // Phase velocities at the compared frequencies
+	Compared     int          // Number of frequencies where both curves have a root
+	Missing      []float64    // Angular frequencies where only one of the curves has a root [rad/s]
+	Skipped      []float64    // Angular frequencies outside the computed curve [rad/s]
+	MaxDeviation float64      // Largest relative difference of the phase velocity
+	WorstOmega   float64      // Angular frequency of the largest difference [rad/s]
+}
+
+// Report is the cross-validation of a computed result against a reference.
+type Report struct {
+	Track                     CurveReport // Comparison of the track dispersion curves
+	Soil                      CurveReport // Comparison of the soil dispersion curves
+	ReferenceCriticalVelocity float64     // Critical speed of the reference [m/s]
+	CriticalVelocity          float64     // Computed critical speed [m/s]
+	ReferenceCriticalOmega    float64     // Critical angular frequency of the reference [rad/s]
+	CriticalOmega             float64     // Computed critical angular frequency [rad/s]
+	Deviation                 float64     // Relative deviation of the critical speed
+	Tolerance                 float64     // Largest relative difference accepted
+	Passed                    bool        // Whether the critical speed and the curves are within the tolerance
+}
+
+// LoadReference reads a reference result of TrainCritSpeed from a JSON file (see
+// ParseReference).
+//
+// Parameters:
+//   - path: Path to the JSON file, or s3:// or gs:// URL
+//
+// Returns:
+//   - Reference: The reference result
+//   - error: An error if the file cannot be read or is invalid
+func LoadReference(path string) (Reference, error) {
+	data, err := storage.ReadFile(path)
+	if err != nil {
+		return Reference{}, fmt.Errorf("failed to read reference result: %v", err)
+	}
+	reference, err := ParseReference(data)
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid reference result %s: %v", path, err)
+	}
+	return reference, nil
+}
+
+// ParseReference parses a reference result of TrainCritSpeed from JSON data. The NaN,
+// Infinity and -Infinity literals of the Python json module are read as missing
+// values.
+//
+// Parameters:
+//   - data: The JSON data
+//
+// Returns:
+//   - Reference: The reference result
+//   - error: An error if the data is invalid, a curve has fewer than two frequencies or
+//     the critical speed is missing
+func ParseReference(data []byte) (Reference, error) {
+	type curve struct {
+		Omega         []*float64 `json:"omega"`
+		PhaseVelocity []*float64 `json:"phase_velocity"`
+	}
+	var document struct {
+		Omega         []*float64 `json:"omega"`
+		Track         curve      `json:"track"`
+		Soil          curve      `json:"soil"`
+		Frequency     *float64   `json:"frequency"`
+		CriticalSpeed *float64   `json:"critical_speed"`
+	}
+	if err := json.Unmarshal(replaceLiterals(data), &document); err != nil {
+		return Reference{}, err
+	}
+	if document.Frequency == nil || document.CriticalSpeed == nil {
+		return Reference{}, fmt.Errorf("no critical speed: frequency and critical_speed are required")
+	}
+
+	var reference Reference
+	for _, c := range []struct {
+		name   string
+		source curve
+		curve  *Curve
+	}{{"track", document.Track, &reference.Track}, {"soil", document.Soil, &reference.Soil}} {
+		omega := c.source.Omega
+		if omega == nil {
+			omega = document.Omega
+		}
+		if len(omega) < 2 || len(c.source.PhaseVelocity) != len(omega) {
+			return Reference{}, fmt.Errorf("%s: at least two frequencies and one phase velocity per frequency are required", c.name)
+		}
+		c.curve.Omega = make([]float64, len(omega))
+		c.curve.PhaseVelocity = make([]float64, len(omega))
+		for i, w := range omega {
+			if w == nil || (i > 0 && !(*w > c.curve.Omega[i-1])) {
+				return Reference{}, fmt.Errorf("%s: frequencies must be increasing", c.name)
+			}
+			c.curve.Omega[i] = *w
+			c.curve.PhaseVelocity[i] = math.NaN()
+			if v := c.source.PhaseVelocity[i]; v != nil {
+				c.curve.PhaseVelocity[i] = *v
+			}
+		}
+	}
+	reference.CriticalOmega = *document.Frequency
+	reference.CriticalVelocity = *document.CriticalSpeed
+	return reference, nil
+}
+
+// ResultReference uses a result of GoTrain as the reference of a comparison, e.g. to
+// compare a result with that of an earlier version of a configuration. Frequencies
+// without a root of the track (zero phase velocity) are compared as such, so the
+// result agrees with itself.
+//
+// Parameters:
+//   - result: The reference result, e.g. read with critical_speed.UnmarshalResultJSON
+//
+// Returns:
+//   - Reference: The reference result
+//   - error: An error if the result has fewer than two frequencies, no phase velocity
+//     per frequency or frequencies that are not increasing
+func ResultReference(result critical_speed.Result) (Reference, error) {
+	omega := result.Omega
+	if len(omega) < 2 || len(result.TrackPhaseVelocity) != len(omega) || len(result.SoilPhaseVelocity) != len(omega) {
+		return Reference{}, fmt.Errorf("at least two frequencies and one phase velocity per frequency are required")
+	}
+	for i := 1; i < len(omega); i++ {
+		if !(omega[i] > omega[i-1]) {
+			return Reference{}, fmt.Errorf("frequencies must be increasing")
+		}
+	}
+	return Reference{
+		Track:            Curve{Omega: omega, PhaseVelocity: result.TrackPhaseVelocity},
+		Soil:             Curve{Omega: omega, PhaseVelocity: result.SoilPhaseVelocity},
+		CriticalOmega:    result.CriticalOmega,
+		CriticalVelocity: result.CriticalVelocity,
+	}, nil
+}
+
+// replaceLiterals replaces the NaN, Infinity and -Infinity literals of the Python json
+// module outside strings by null, so that the data can be decoded by encoding/json.
+//
+// Parameters:
+//   - data: The JSON data
+//
+// Returns:
+//   - []byte: The data without the literals
+func replaceLiterals(data []byte) []byte {
+	replaced := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			replaced = append(replaced, c)
+			switch c {
+			case '\\':
+				if i+1 < len(data) {
+					i++
+					replaced = append(replaced, data[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		literal := ""
+		for _, l := range []string{"NaN", "Infinity", "-Infinity"} {
+			if len(data)-i >= len(l) && string(data[i:i+len(l)]) == l {
+				literal = l
+				break
+			}
+		}
+		if literal != "" {
+			replaced = append(replaced, "null"...)
+			i += len(literal) - 1
+			continue
+		}
+		replaced = append(replaced, c)
+	}
+	return replaced
+}
+
+// Compare cross-validates a computed result against a reference. The computed curves
+// are interpolated linearly at the frequencies of the reference curves.
+//
+// Parameters:
+//   - reference: The reference result
+//   - result: The computed result
+//   - tolerance: Largest relative difference accepted for the critical speed and the
+//     phase velocities, e.g. 0.01
+//
+// Returns:
+//   - Report: The comparison
+//   - error: An error if the tolerance is not positive or the computed curves are invalid
+func Compare(reference Reference, result critical_speed.Result, tolerance float64) (Report, error) {
+	if !(tolerance > 0) {
+		return Report{}, fmt.Errorf("invalid tolerance %g: it must be positive", tolerance)
+	}
+	omega := result.Omega
+	if len(omega) < 2 || len(result.TrackPhaseVelocity) != len(omega) || len(result.SoilPhaseVelocity) != len(omega) {
+		return Report{}, fmt.Errorf("invalid computed result: at least two frequencies and one phase velocity per frequency are needed")
+	}
+	for i := 1; i < len(omega); i++ {
+		if !(omega[i] > omega[i-1]) {
+			return Report{}, fmt.Errorf("invalid computed result: frequencies must be increasing")
+		}
+	}
+
+	report := Report{
+		Track:                     compareCurve(reference.Track, omega, result.TrackPhaseVelocity),
+		Soil:                      compareCurve(reference.Soil, omega, result.SoilPhaseVelocity),
+		ReferenceCriticalVelocity: reference.CriticalVelocity,
+		CriticalVelocity:          result.CriticalVelocity,
+		ReferenceCriticalOmega:    reference.CriticalOmega,
+		CriticalOmega:             result.CriticalOmega,
+		Deviation:                 math.Abs(result.CriticalVelocity-reference.CriticalVelocity) / math.Abs(reference.CriticalVelocity),
+		Tolerance:                 tolerance,
+	}
+	report.Passed = report.Deviation <= tolerance && report.Track.MaxDeviation <= tolerance && report.Soil.MaxDeviation <= tolerance
+	return report, nil
+}
+
+// compareCurve compares a reference dispersion curve with a computed one.
+//
+// Parameters:
+//   - reference: The reference curve
+//   - omega: Angular frequencies of the computed curve [rad/s], in increasing order
+//   - computed: Computed phase velocities [m/s] (NaN where no root is found)
+//
+// Returns:
+//   - CurveReport: The comparison
+func compareCurve(reference Curve, omega []float64, computed []float64) CurveReport {
+	var report CurveReport
+	for i, w := range reference.Omega {
+		if w < omega[0] || w > omega[len(omega)-1] {
+			report.Skipped = append(report.Skipped, w)
+			continue
+		}
+		d := Difference{Omega: w, Reference: reference.PhaseVelocity[i], Computed: interpolate(omega, computed, w)}
+		report.Differences = append(report.Differences, d)
+		if math.IsNaN(d.Reference) != math.IsNaN(d.Computed) {
+			report.Missing = append(report.Missing, w)
+			continue
+		}
+		if deviation := d.Deviation(); !math.IsNaN(deviation) {
+			report.Compared++
+			if deviation > report.MaxDeviation {
+				report.MaxDeviation, report.WorstOmega = deviation, w
+			}
+		}
+	}
+	return report
+}
+
+// interpolate evaluates a curve linearly at a frequency within the curve.
+//
+// Parameters:
+//   - omega: Angular frequencies of the curve [rad/s], in increasing order
+//   - velocity: Phase velocities of the curve [m/s] (NaN where no root is found)
+//   - w: Angular frequency at which the curve is evaluated [rad/s]
+//
+// Returns:
+//   - float64: The phase velocity, NaN next to a NaN value
+func interpolate(omega []float64, velocity []float64, w float64) float64 {
+	i := sort.SearchFloat64s(omega, w)
+	if omega[i] == w {
+		return velocity[i]
+	}
+	t := (w - omega[i-1]) / (omega[i] - omega[i-1])
+	return velocity[i-1] + t*(velocity[i]-velocity[i-1])
+}
+
+// WriteCSV writes the differences of a comparison as CSV, one row per curve and
+// frequency, with the columns curve ("track" or "soil"), frequency [Hz], omega
+// [rad/s], reference and computed [m/s], and deviation (relative difference, empty
+// where either curve has no root).
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - report: The comparison
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"curve", "frequency", "omega", "reference", "computed", "deviation"})
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, c := range []struct {
+		name   string
+		report CurveReport
+	}{{"track", report.Track}, {"soil", report.Soil}} {
+		for _, d := range c.report.Differences {
+			writer.Write([]string{
+				c.name,
+				format(d.Omega / (2 * math.Pi)),
+				format(d.Omega),
+				format(d.Reference),
+				format(d.Computed),
+				format(d.Deviation()),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,153 @@
+package crossval
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math"
+	"testing"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// Test that the sample configuration agrees with its reference result.
+func TestCompareSample(t *testing.T) {
+	config, err := critical_speed.LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	result, err := critical_speed.Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	reference, err := LoadReference("../../testdata/crossval/reference.json")
+	if err != nil {
+		t.Fatalf("LoadReference failed: %v", err)
+	}
+
+	report, err := Compare(reference, result, 1e-6)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if !report.Passed || report.Deviation > 1e-9 || report.Track.Compared != len(result.Omega) || report.Soil.Compared != len(result.Omega) {
+		t.Errorf("expected the sample to agree with its reference: %+v", report)
+	}
+
+	// A reference slower by 2% fails a tolerance of 1%, but not of 5%
+	reference.CriticalVelocity *= 0.98
+	for i := range reference.Soil.PhaseVelocity {
+		reference.Soil.PhaseVelocity[i] *= 0.98
+	}
+	for tolerance, passed := range map[float64]bool{0.01: false, 0.05: true} {
+		report, err := Compare(reference, result, tolerance)
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if report.Passed != passed || math.Abs(report.Deviation-0.02/0.98) > 1e-9 || math.Abs(report.Soil.MaxDeviation-0.02/0.98) > 1e-9 {
+			t.Errorf("tolerance %v: unexpected report: passed %v, deviation %v, soil deviation %v",
+				tolerance, report.Passed, report.Deviation, report.Soil.MaxDeviation)
+		}
+	}
+
+	if _, err := Compare(reference, result, 0); err == nil {
+		t.Error("expected an error for a zero tolerance")
+	}
+	if _, err := LoadReference("missing.json"); err == nil {
+		t.Error("expected an error for a missing reference file")
+	}
+}
+
+// Test parsing reference results with the literals of the Python json module and
+// comparing them on a different frequency grid.
+func TestParseReference(t *testing.T) {
+	data := []byte(`{"omega": [1.0, 2.0, 3.0, 4.0],
+		"track": {"phase_velocity": [NaN, 110.0, 120.0, 130.0]},
+		"soil": {"omega": [1.5, 2.5, 5.0], "phase_velocity": [200.0, -Infinity, 180.0], "note": "NaN"},
+		"frequency": 2.5, "critical_speed": 115.0}`)
+	reference, err := ParseReference(data)
+	if err != nil {
+		t.Fatalf("ParseReference failed: %v", err)
+	}
+	if len(reference.Track.Omega) != 4 || !math.IsNaN(reference.Track.PhaseVelocity[0]) || reference.Track.PhaseVelocity[1] != 110 {
+		t.Errorf("unexpected track curve: %+v", reference.Track)
+	}
+	if len(reference.Soil.Omega) != 3 || reference.Soil.Omega[2] != 5 || !math.IsNaN(reference.Soil.PhaseVelocity[1]) {
+		t.Errorf("unexpected soil curve: %+v", reference.Soil)
+	}
+
+	// The computed curves are interpolated at the reference frequencies
+	result := critical_speed.Result{
+		Omega:              []float64{1, 3, 4.5},
+		TrackPhaseVelocity: []float64{100, 120, 135},
+		SoilPhaseVelocity:  []float64{210, 190, math.NaN()},
+		CriticalOmega:      2.5,
+		CriticalVelocity:   115,
+	}
+	report, err := Compare(reference, result, 0.01)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if report.Track.Compared != 3 || report.Track.MaxDeviation != 0 || len(report.Track.Missing) != 1 || report.Track.Missing[0] != 1 {
+		t.Errorf("unexpected track report: %+v", report.Track)
+	}
+	if report.Soil.Compared != 1 || math.Abs(report.Soil.MaxDeviation-0.025) > 1e-12 || len(report.Soil.Skipped) != 1 || report.Soil.Skipped[0] != 5 {
+		t.Errorf("unexpected soil report: %+v", report.Soil)
+	}
+	if report.Passed {
+		t.Error("expected the soil curve to fail a tolerance of 1%")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 1+4+2 || records[1][0] != "track" || records[1][3] != "" || records[6][0] != "soil" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+
+	for _, invalid := range []string{
+		`{"omega": [1, 2], "track": {"phase_velocity": [1, 2]}, "soil": {"phase_velocity": [1, 2]}}`,                                        // No critical speed
+		`{"omega": [1], "track": {"phase_velocity": [1]}, "soil": {"phase_velocity": [1]}, "frequency": 1, "critical_speed": 1}`,            // One frequency
+		`{"omega": [2, 1], "track": {"phase_velocity": [1, 2]}, "soil": {"phase_velocity": [1, 2]}, "frequency": 1, "critical_speed": 1}`,   // Decreasing frequencies
+		`{"omega": [1, 2], "track": {"phase_velocity": [1]}, "soil": {"phase_velocity": [1, 2]}, "frequency": 1, "critical_speed": 1}`,      // Missing phase velocity
+		`{"omega": [1, 2], "track": {"phase_velocity": [1, 2]}, "soil": {"phase_velocity": [1, 2]}, "frequency": 1, "critical_speed": NaN}`, // NaN critical speed
+		`[1, 2]`,
+	} {
+		if _, err := ParseReference([]byte(invalid)); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+// Test that a result compared with itself as the reference agrees within any tolerance.
+func TestResultReference(t *testing.T) {
+	config, err := critical_speed.LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	result, err := critical_speed.Compute(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	reference, err := ResultReference(result)
+	if err != nil {
+		t.Fatalf("ResultReference failed: %v", err)
+	}
+	report, err := Compare(reference, result, 1e-12)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if !report.Passed || report.Deviation != 0 || len(report.Track.Missing) != 0 || len(report.Soil.Missing) != 0 {
+		t.Errorf("expected the result to agree with itself: %+v", report)
+	}
+
+	result.Omega = result.Omega[:1]
+	if _, err := ResultReference(result); err == nil {
+		t.Error("expected an error for a single frequency")
+	}
+}
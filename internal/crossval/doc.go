@@ -0,0 +1,47 @@
+// Package crossval cross-validates GoTrain against reference results of the Python
+// TrainCritSpeed, so that users migrating from it can check that both give the same
+// dispersion curves and critical speed for their configurations.
+//
+// Reference results are JSON files in the layout of the attributes of the
+// TrainCritSpeed CriticalSpeed object, as also written by GoTrain with output.format
+// "traincritspeed" (see critical_speed.Result.WriteTrainCritSpeed):
+//
+//	{
+//		"omega": [...],
+//		"track": {"omega": [...], "phase_velocity": [...]},
+//		"soil": {"omega": [...], "phase_velocity": [...]},
+//		"frequency": 125.66,
+//		"critical_speed": 198.45
+//	}
+//
+// where frequency is the critical angular frequency [rad/s]. The omega of a curve
+// defaults to the top-level omega, and the NaN and Infinity literals written by the
+// Python json module are read as missing phase velocities.
+//
+// The computed curves are interpolated linearly at the frequencies of the reference,
+// so the comparison is point by point when the configuration has the frequency range
+// of the reference. Every curve is summarized by the largest relative difference of
+// the phase velocity, and the critical speed by its relative deviation; the
+// comparison passes when both are within the tolerance. Frequencies where only one
+// of the curves has a root, e.g. next to a cut-off frequency, are counted but do not
+// fail the comparison, and frequencies outside the computed curves are skipped.
+//
+// # Usage Example
+//
+//	reference, err := crossval.LoadReference("reference.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	result, err := critical_speed.Compute(ctx, config)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	report, err := crossval.Compare(reference, result, 0.01)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("critical speed deviation: %.2f%%, passed: %v\n", 100*report.Deviation, report.Passed)
+//
+// The crossval command runs a configuration, compares it with a reference file and
+// exits with status 1 when the comparison fails.
+package crossval
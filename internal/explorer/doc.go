@@ -0,0 +1,25 @@
+// Package explorer implements an interactive terminal explorer of result files,
+// to browse the results of a batch on machines without a display, e.g. over SSH
+// on a compute cluster.
+//
+// The explorer has three views:
+//   - List: the critical velocity and omega of every result file, with the batch
+//     statistics (count, minimum, maximum, mean and standard deviation)
+//   - Curves: the track and soil dispersion curves of a result, plotted with
+//     characters, with the values at a selected frequency
+//   - Histogram: the distribution of the critical velocities of the batch
+//
+// The Explorer holds the state of the views and is driven by key presses
+// (HandleKey) and drawn as text (Render). It is a Bubble Tea model (Init, Update
+// and View), which the explore command runs as a tea.Program.
+//
+// # Usage Example
+//
+//	entries, err := explorer.Load([]string{"results/"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	e := explorer.New(entries)
+//	e.HandleKey(explorer.KeyEnter) // show the curves of the first result
+//	fmt.Println(e.Render(80, 24))
+package explorer
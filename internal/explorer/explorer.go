@@ -0,0 +1,307 @@
+package explorer
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// Entry is a result file loaded in the explorer.
+type Entry struct {
+	Name   string                // Path of the result file
+	Result critical_speed.Result // The result
+}
+
+// Stats summarizes the critical velocities of a batch of results.
+type Stats struct {
+	Count  int     // Number of results with a critical velocity
+	Min    float64 // Smallest critical velocity [m/s]
+	Max    float64 // Largest critical velocity [m/s]
+	Mean   float64 // Mean critical velocity [m/s]
+	StdDev float64 // Standard deviation of the critical velocities [m/s]
+}
+
+// Key is a key press, named as by Bubble Tea (see tea.KeyMsg.String): a named key
+// (KeyUp, ...) or a character, e.g. "q".
+type Key string
+
+// Named keys handled by the explorer.
+const (
+	KeyUp     Key = "up"
+	KeyDown   Key = "down"
+	KeyLeft   Key = "left"
+	KeyRight  Key = "right"
+	KeyEnter  Key = "enter"
+	KeyEscape Key = "esc"
+	KeyCtrlC  Key = "ctrl+c"
+)
+
+// Views of the explorer.
+const (
+	viewList      = iota // Table of the results and batch statistics
+	viewCurves           // Dispersion curves of a result
+	viewHistogram        // Histogram of the critical velocities
+)
+
+// Default size of the terminal, until its size is known [characters, lines].
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Explorer is the state of the result explorer: the loaded results, the current
+// view and the selection. It is driven by HandleKey and drawn by Render, and is
+// the Bubble Tea model of the explore command (see Update and View).
+type Explorer struct {
+	entries []Entry // The results
+	order   []int   // Indices of the entries in display order
+	cursor  int     // Position of the selected entry in order
+	view    int     // Current view
+	sorted  bool    // True when the entries are sorted by critical velocity
+	point   int     // Selected frequency index in the curves view
+	done    bool    // True once the user has quit
+	width   int     // Width of the terminal [characters]
+	height  int     // Height of the terminal [lines]
+}
+
+// Load reads the JSON result files of a list of paths. Directories (and s3:// or
+// gs:// prefixes) are searched recursively for .json and .json.gz files.
+//
+// Parameters:
+//   - paths: Result files or directories
+//
+// Returns:
+//   - []Entry: The results, in the order of the paths
+//   - error: An error if a file cannot be read or is not a result file
+func Load(paths []string) ([]Entry, error) {
+	var entries []Entry
+	for _, path := range paths {
+		files := []string{path}
+		if !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".json"+critical_speed.CompressedSuffix) {
+			found, err := storage.List(path, ".json")
+			if err != nil {
+				return nil, fmt.Errorf("error listing result files: %v", err)
+			}
+			compressed, err := storage.List(path, ".json"+critical_speed.CompressedSuffix)
+			if err != nil {
+				return nil, fmt.Errorf("error listing result files: %v", err)
+			}
+			files = append(found, compressed...)
+		}
+		for _, file := range files {
+			data, err := storage.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read result file: %v", err)
+			}
+			result, err := critical_speed.UnmarshalResultJSON(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			entries = append(entries, Entry{Name: file, Result: result})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no result files found")
+	}
+	return entries, nil
+}
+
+// New creates an explorer showing the list of results.
+//
+// Parameters:
+//   - entries: The results (at least one)
+//
+// Returns:
+//   - *Explorer: The explorer
+func New(entries []Entry) *Explorer {
+	e := &Explorer{entries: entries, order: make([]int, len(entries)), width: defaultWidth, height: defaultHeight}
+	for i := range e.order {
+		e.order[i] = i
+	}
+	return e
+}
+
+// Done reports whether the user has quit the explorer.
+func (e *Explorer) Done() bool {
+	return e.done
+}
+
+// selected returns the selected entry.
+func (e *Explorer) selected() Entry {
+	return e.entries[e.order[e.cursor]]
+}
+
+// HandleKey updates the explorer after a key press.
+//
+// Keys of every view: q or ctrl-c quits, H shows the histogram of the critical
+// velocities, Esc or b returns to the list. In the list: up/down (or k/j) select a
+// result, Enter shows its curves, s toggles the sorting by critical velocity. In
+// the curves view: left/right (or h/l) move along the frequencies, up/down (or k/j)
+// show the previous or next result, c returns to the critical frequency.
+//
+// Parameters:
+//   - key: The key
+func (e *Explorer) HandleKey(key Key) {
+	switch key {
+	case "q", KeyCtrlC:
+		e.done = true
+		return
+	case KeyEscape, "b":
+		e.view = viewList
+		return
+	case "H":
+		e.view = viewHistogram
+		return
+	}
+
+	switch e.view {
+	case viewList:
+		switch key {
+		case KeyUp, "k":
+			e.moveCursor(-1)
+		case KeyDown, "j":
+			e.moveCursor(1)
+		case KeyEnter:
+			e.view = viewCurves
+			e.point = e.criticalPoint()
+		case "s":
+			e.toggleSort()
+		}
+	case viewCurves:
+		switch key {
+		case KeyLeft, "h":
+			e.point = max(e.point-1, 0)
+		case KeyRight, "l":
+			e.point = min(e.point+1, len(e.selected().Result.Omega)-1)
+		case KeyUp, "k":
+			e.moveCursor(-1)
+			e.point = e.criticalPoint()
+		case KeyDown, "j":
+			e.moveCursor(1)
+			e.point = e.criticalPoint()
+		case "c":
+			e.point = e.criticalPoint()
+		}
+	}
+}
+
+// Init starts the explorer as a Bubble Tea model; it has no initial command.
+func (e *Explorer) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles a Bubble Tea message: key presses are passed to HandleKey, and
+// the size of the terminal is kept for View.
+//
+// Parameters:
+//   - msg: The message
+//
+// Returns:
+//   - tea.Model: The explorer
+//   - tea.Cmd: tea.Quit once the user has quit, nil otherwise
+func (e *Explorer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		e.HandleKey(Key(msg.String()))
+		if e.done {
+			return e, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		e.width, e.height = msg.Width, msg.Height
+	}
+	return e, nil
+}
+
+// View draws the current view at the size of the terminal (see Render).
+func (e *Explorer) View() string {
+	return e.Render(e.width, e.height)
+}
+
+// moveCursor moves the selection, staying within the list.
+func (e *Explorer) moveCursor(step int) {
+	e.cursor = min(max(e.cursor+step, 0), len(e.order)-1)
+}
+
+// criticalPoint returns the frequency index of the selected result closest to its
+// critical frequency.
+func (e *Explorer) criticalPoint() int {
+	result := e.selected().Result
+	best := 0
+	for i, w := range result.Omega {
+		if math.Abs(w-result.CriticalOmega) < math.Abs(result.Omega[best]-result.CriticalOmega) {
+			best = i
+		}
+	}
+	return best
+}
+
+// toggleSort switches between the load order and the order of increasing critical
+// velocity, keeping the selected entry.
+func (e *Explorer) toggleSort() {
+	selected := e.order[e.cursor]
+	e.sorted = !e.sorted
+	for i := range e.order {
+		e.order[i] = i
+	}
+	if e.sorted {
+		sort.SliceStable(e.order, func(i, j int) bool {
+			return e.entries[e.order[i]].Result.CriticalVelocity < e.entries[e.order[j]].Result.CriticalVelocity
+		})
+	}
+	for i, index := range e.order {
+		if index == selected {
+			e.cursor = i
+		}
+	}
+}
+
+// BatchStats computes the statistics of the critical velocities of the results.
+//
+// Parameters:
+//   - entries: The results
+//
+// Returns:
+//   - Stats: The statistics (Count is zero if no result has a critical velocity)
+func BatchStats(entries []Entry) Stats {
+	var stats Stats
+	var sum, sumSquares float64
+	for _, entry := range entries {
+		v := entry.Result.CriticalVelocity
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		if stats.Count == 0 || v < stats.Min {
+			stats.Min = v
+		}
+		if stats.Count == 0 || v > stats.Max {
+			stats.Max = v
+		}
+		stats.Count++
+		sum += v
+		sumSquares += v * v
+	}
+	if stats.Count > 0 {
+		n := float64(stats.Count)
+		stats.Mean = sum / n
+		stats.StdDev = math.Sqrt(math.Max(sumSquares/n-stats.Mean*stats.Mean, 0))
+	}
+	return stats
+}
+
+// shortName returns the name of an entry shortened to a width, keeping its end.
+func shortName(name string, width int) string {
+	if len(name) <= width {
+		return name
+	}
+	if width <= 3 {
+		return filepath.Base(name)
+	}
+	return "..." + name[len(name)-width+3:]
+}
@@ -0,0 +1,150 @@
+package explorer
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// testEntries returns three results with critical velocities 90, 70 and 80 m/s.
+func testEntries() []Entry {
+	var entries []Entry
+	for i, velocity := range []float64{90, 70, 80} {
+		entries = append(entries, Entry{
+			Name: "results/run_" + string(rune('a'+i)) + ".json",
+			Result: critical_speed.Result{
+				Omega:              []float64{10, 20, 30, 40},
+				TrackPhaseVelocity: []float64{120, 100, 0, 80},
+				SoilPhaseVelocity:  []float64{60, math.NaN(), 90, 100},
+				CriticalOmega:      25,
+				CriticalVelocity:   velocity,
+			},
+		})
+	}
+	return entries
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"omega":[1,2],"track_phase_velocity":[80,70],"soil_phase_velocity":["NaN",75],"critical_omega":1.5,"critical_velocity":72.5}`
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Load([]string{dir})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Result.CriticalVelocity != 72.5 || !math.IsNaN(entries[0].Result.SoilPhaseVelocity[0]) {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if _, err := Load([]string{t.TempDir()}); err == nil {
+		t.Error("expected an error for a directory without result files")
+	}
+}
+
+func TestBatchStats(t *testing.T) {
+	stats := BatchStats(testEntries())
+	if stats.Count != 3 || stats.Min != 70 || stats.Max != 90 || stats.Mean != 80 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if want := math.Sqrt(200.0 / 3); math.Abs(stats.StdDev-want) > 1e-9 {
+		t.Errorf("expected standard deviation %v, got %v", want, stats.StdDev)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	e := New(testEntries())
+	e.Update(tea.WindowSizeMsg{Width: 60, Height: 10})
+	if lines := strings.Split(e.View(), "\n"); len(lines) > 10 {
+		t.Errorf("expected at most 10 lines, got %d", len(lines))
+	}
+
+	if _, cmd := e.Update(tea.KeyMsg{Type: tea.KeyDown}); cmd != nil || e.cursor != 1 {
+		t.Errorf("expected the second result selected, got %d", e.cursor)
+	}
+	e.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if e.view != viewCurves {
+		t.Errorf("expected the curves view, got %d", e.view)
+	}
+	_, cmd := e.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil || !reflect.DeepEqual(cmd(), tea.Quit()) || !e.Done() {
+		t.Error("expected q to quit")
+	}
+	if _, cmd := New(testEntries()).Update(tea.KeyMsg{Type: tea.KeyCtrlC}); cmd == nil {
+		t.Error("expected ctrl+c to quit")
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	e := New(testEntries())
+
+	// Sorting keeps the selection
+	e.HandleKey(KeyDown)
+	e.HandleKey("s")
+	if got := e.selected().Result.CriticalVelocity; got != 70 || e.cursor != 0 {
+		t.Errorf("expected the 70 m/s result at the top, got %v at %d", got, e.cursor)
+	}
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	defer lipgloss.SetColorProfile(profile)
+	screen := e.Render(80, 24)
+	if !strings.Contains(screen, "\x1b[7mresults/run_b.json") || !strings.Contains(screen, "sorted by critical velocity") {
+		t.Errorf("unexpected list view:\n%s", screen)
+	}
+	if !strings.Contains(screen, "n 3  min 70.00  max 90.00  mean 80.00") {
+		t.Errorf("missing batch statistics:\n%s", screen)
+	}
+
+	// The curves view starts at the critical frequency and stays within the curve
+	e.HandleKey(KeyEnter)
+	if e.point != 1 {
+		t.Errorf("expected the point closest to the critical omega, got %d", e.point)
+	}
+	e.HandleKey(KeyLeft)
+	e.HandleKey(KeyLeft)
+	if e.point != 0 {
+		t.Errorf("expected the first point, got %d", e.point)
+	}
+	screen = e.Render(80, 24)
+	if !strings.Contains(screen, "omega 10.00 rad/s: track 120.00 m/s, soil 60.00 m/s") || !strings.Contains(screen, "X") {
+		t.Errorf("unexpected curves view:\n%s", screen)
+	}
+	e.HandleKey(KeyRight)
+	if screen = e.Render(80, 24); !strings.Contains(screen, "soil - m/s") {
+		t.Errorf("expected a missing soil velocity:\n%s", screen)
+	}
+	for _, line := range strings.Split(screen, "\n") {
+		if len(line) > 80 {
+			t.Errorf("line longer than the width: %q", line)
+		}
+	}
+	if lines := strings.Count(screen, "\n") + 1; lines > 24 {
+		t.Errorf("expected at most 24 lines, got %d", lines)
+	}
+
+	e.HandleKey("H")
+	if screen = e.Render(80, 24); !strings.Contains(screen, "Critical velocities of 3 results") {
+		t.Errorf("unexpected histogram view:\n%s", screen)
+	}
+	e.HandleKey("b")
+	e.HandleKey("q")
+	if !e.Done() || e.view != viewList {
+		t.Error("expected the explorer to be done in the list view")
+	}
+
+	if summary := New(testEntries()).Summary(); strings.Contains(summary, "\x1b") || strings.Count(summary, "results/run_") != 3 {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
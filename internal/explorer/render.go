@@ -0,0 +1,280 @@
+package explorer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// selectedStyle highlights the selected row of the list.
+var selectedStyle = lipgloss.NewStyle().Reverse(true)
+
+// Render draws the current view. Lines are cut to the width, and the view fills at
+// most height lines; the selected row is highlighted with reverse video.
+//
+// Parameters:
+//   - width: Width of the terminal [characters]
+//   - height: Height of the terminal [lines]
+//
+// Returns:
+//   - string: The view, with lines separated by "\n"
+func (e *Explorer) Render(width, height int) string {
+	width, height = max(width, 40), max(height, 10)
+	var lines []string
+	selected := -1
+	switch e.view {
+	case viewCurves:
+		lines = e.renderCurves(width, height)
+	case viewHistogram:
+		lines = e.renderHistogram(width, height)
+	default:
+		lines, selected = e.renderList(width, height)
+	}
+	for i, line := range lines {
+		if i == selected {
+			lines[i] = selectedStyle.Render(fit(line, width))
+		} else {
+			lines[i] = cut(line, width)
+		}
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary returns the table of all results and the batch statistics as plain text,
+// for non-interactive use (e.g. in job logs).
+//
+// Returns:
+//   - string: The summary
+func (e *Explorer) Summary() string {
+	lines := []string{tableHeader(nameWidth(e.entries, 60))}
+	for _, index := range e.order {
+		lines = append(lines, tableRow(e.entries[index], nameWidth(e.entries, 60)))
+	}
+	lines = append(lines, "", statsLine(BatchStats(e.entries)))
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// renderList draws the table of the results, scrolled to the selection, with the
+// batch statistics below it, and returns the index of the line of the selection.
+func (e *Explorer) renderList(width, height int) ([]string, int) {
+	order := "load order"
+	if e.sorted {
+		order = "sorted by critical velocity"
+	}
+	names := nameWidth(e.entries, width-50)
+	lines := []string{
+		fmt.Sprintf("GoTrain results: %d files (%s)", len(e.entries), order),
+		"",
+		tableHeader(names),
+	}
+
+	// Rows shown: the lines left after the title, header, statistics and help
+	rows := max(height-7, 1)
+	first := min(max(e.cursor-rows/2, 0), max(len(e.order)-rows, 0))
+	selected := len(lines) + e.cursor - first
+	for i := first; i < min(first+rows, len(e.order)); i++ {
+		lines = append(lines, tableRow(e.entries[e.order[i]], names))
+	}
+	for len(lines) < rows+3 {
+		lines = append(lines, "")
+	}
+
+	return append(lines,
+		"",
+		statsLine(BatchStats(e.entries)),
+		"up/down: select  enter: curves  s: sort  H: histogram  q: quit",
+	), selected
+}
+
+// renderCurves draws the dispersion curves of the selected result, with the values
+// at the selected frequency.
+func (e *Explorer) renderCurves(width, height int) []string {
+	entry := e.selected()
+	result := entry.Result
+	name, unit, scale := result.FrequencyAxis()
+	lines := []string{
+		fmt.Sprintf("%s (%d/%d)", shortName(entry.Name, width-12), e.cursor+1, len(e.order)),
+		fmt.Sprintf("Critical velocity: %.2f m/s (%.1f km/h) at %s %.2f %s",
+			result.CriticalVelocity, result.CriticalVelocity*3.6, name, result.CriticalOmega*scale, unit),
+		"",
+	}
+	lines = append(lines, plotCurves(result, width, height-7, e.point)...)
+
+	w, track, soil := result.Omega[e.point], result.TrackPhaseVelocity[e.point], result.SoilPhaseVelocity[e.point]
+	return append(lines,
+		"",
+		fmt.Sprintf("%s %.2f %s: track %s m/s, soil %s m/s", name, w*scale, unit, formatVelocity(track, track == 0), formatVelocity(soil, math.IsNaN(soil))),
+		"* track  o soil  X critical   left/right: "+name+"  up/down: result  c: critical  b: back  q: quit",
+	)
+}
+
+// renderHistogram draws the histogram of the critical velocities of all results.
+func (e *Explorer) renderHistogram(width, height int) []string {
+	stats := BatchStats(e.entries)
+	lines := []string{fmt.Sprintf("Critical velocities of %d results", stats.Count), ""}
+	bins := min(max(height-6, 1), 20, max(stats.Count, 1))
+	if stats.Count > 0 && stats.Max == stats.Min {
+		bins = 1
+	}
+
+	counts := make([]int, bins)
+	binWidth := (stats.Max - stats.Min) / float64(bins)
+	largest := 0
+	for _, entry := range e.entries {
+		v := entry.Result.CriticalVelocity
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		bin := bins - 1
+		if binWidth > 0 {
+			bin = min(int((v-stats.Min)/binWidth), bins-1)
+		}
+		counts[bin]++
+		largest = max(largest, counts[bin])
+	}
+
+	barWidth := max(width-30, 1)
+	for i, count := range counts {
+		low := stats.Min + float64(i)*binWidth
+		bar := 0
+		if largest > 0 {
+			bar = int(math.Round(float64(count) / float64(largest) * float64(barWidth)))
+		}
+		lines = append(lines, fmt.Sprintf("%8.1f-%-8.1f %4d %s", low, low+binWidth, count, strings.Repeat("#", bar)))
+	}
+	return append(lines, "", statsLine(stats), "b: back  q: quit")
+}
+
+// plotCurves draws the track and soil dispersion curves as characters, with the
+// velocity [m/s] on the vertical axis and the frequency, in the unit of the result
+// (see critical_speed.Result.FrequencyAxis), on the horizontal axis.
+//
+// Parameters:
+//   - result: The result
+//   - width: Width of the plot, including the axis labels [characters]
+//   - height: Height of the plot, including the horizontal axis [lines]
+//   - point: Frequency index marked by a vertical line
+//
+// Returns:
+//   - []string: The lines of the plot
+func plotCurves(result critical_speed.Result, width, height, point int) []string {
+	const labelWidth = 9
+	plotWidth, plotHeight := max(width-labelWidth, 10), max(height-2, 3)
+
+	omegaMin, omegaMax := result.Omega[0], result.Omega[len(result.Omega)-1]
+	velocityMax := 0.0
+	for i := range result.Omega {
+		for _, v := range []float64{result.TrackPhaseVelocity[i], result.SoilPhaseVelocity[i]} {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				velocityMax = math.Max(velocityMax, v)
+			}
+		}
+	}
+	if velocityMax <= 0 {
+		velocityMax = 1
+	}
+	velocityMax *= 1.05
+
+	column := func(w float64) int {
+		if omegaMax == omegaMin {
+			return 0
+		}
+		return min(max(int(math.Round((w-omegaMin)/(omegaMax-omegaMin)*float64(plotWidth-1))), 0), plotWidth-1)
+	}
+	row := func(v float64) int {
+		return min(max(plotHeight-1-int(math.Round(v/velocityMax*float64(plotHeight-1))), 0), plotHeight-1)
+	}
+
+	grid := make([][]byte, plotHeight)
+	for r := range grid {
+		grid[r] = []byte(strings.Repeat(" ", plotWidth))
+	}
+	for r := range grid {
+		grid[r][column(result.Omega[point])] = '|'
+	}
+	for i, w := range result.Omega {
+		if v := result.TrackPhaseVelocity[i]; v > 0 {
+			grid[row(v)][column(w)] = '*'
+		}
+		if v := result.SoilPhaseVelocity[i]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+			grid[row(v)][column(w)] = 'o'
+		}
+	}
+	if result.CriticalVelocity > 0 {
+		grid[row(result.CriticalVelocity)][column(result.CriticalOmega)] = 'X'
+	}
+
+	lines := make([]string, 0, plotHeight+2)
+	for r, cells := range grid {
+		label := strings.Repeat(" ", labelWidth-2)
+		if r == 0 || r == plotHeight-1 || r == plotHeight/2 {
+			label = fmt.Sprintf("%*.0f", labelWidth-2, velocityMax*float64(plotHeight-1-r)/float64(plotHeight-1))
+		}
+		lines = append(lines, label+" |"+string(cells))
+	}
+	lines = append(lines, strings.Repeat(" ", labelWidth-1)+"+"+strings.Repeat("-", plotWidth))
+	_, unit, scale := result.FrequencyAxis()
+	axis := fmt.Sprintf("%-*.0f", plotWidth/2, omegaMin*scale)
+	right := fmt.Sprintf("%.0f %s", omegaMax*scale, unit)
+	axis += strings.Repeat(" ", max(plotWidth-len(axis)-len(right), 1)) + right
+	return append(lines, strings.Repeat(" ", labelWidth)+axis)
+}
+
+// nameWidth returns the width of the file name column: the longest name, limited
+// to a maximum.
+func nameWidth(entries []Entry, maxWidth int) int {
+	width := len("File")
+	for _, entry := range entries {
+		width = max(width, len(entry.Name))
+	}
+	return max(min(width, maxWidth), 10)
+}
+
+// tableHeader returns the header of the result table.
+func tableHeader(names int) string {
+	return fmt.Sprintf("%-*s  %12s  %10s  %12s", names, "File", "Vcrit [m/s]", "[km/h]", "omega [rad/s]")
+}
+
+// tableRow returns the row of a result in the result table.
+func tableRow(entry Entry, names int) string {
+	result := entry.Result
+	return fmt.Sprintf("%-*s  %12.2f  %10.1f  %12.2f", names, shortName(entry.Name, names),
+		result.CriticalVelocity, result.CriticalVelocity*3.6, result.CriticalOmega)
+}
+
+// statsLine returns the batch statistics as one line.
+func statsLine(stats Stats) string {
+	if stats.Count == 0 {
+		return "No critical velocities"
+	}
+	return fmt.Sprintf("Critical velocity [m/s]: n %d  min %.2f  max %.2f  mean %.2f  std %.2f",
+		stats.Count, stats.Min, stats.Max, stats.Mean, stats.StdDev)
+}
+
+// formatVelocity formats a phase velocity, or "-" where no root is found.
+func formatVelocity(v float64, missing bool) string {
+	if missing {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// cut shortens a line to a width.
+func cut(line string, width int) string {
+	if len(line) > width {
+		return line[:width]
+	}
+	return line
+}
+
+// fit shortens or pads a line to a width.
+func fit(line string, width int) string {
+	return fmt.Sprintf("%-*s", width, cut(line, width))
+}
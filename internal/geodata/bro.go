@@ -0,0 +1,277 @@
+package geodata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	cpt "github.com/PlatypusBytes/GoTrain/internal/cpt"
+)
+
+// DefaultBROURL is the public CPT service of the BRO.
+const DefaultBROURL = "https://publiek.broservices.nl/sr/cpt/v1"
+
+// broVoid marks missing values in the measurements of BRO CPTs.
+const broVoid = -999999
+
+// Columns of the measurements of BRO CPTs (conePenetrationTest values), which
+// always hold the 25 parameters of the BRO CPT standard in this order.
+const (
+	broPenetrationLength = 0  // Penetration length [m]
+	broDepth             = 1  // Depth corrected for the inclination [m]
+	broConeResistance    = 3  // Cone resistance qc [MPa]
+	broLocalFriction     = 18 // Sleeve friction fs [MPa]
+	broFrictionRatio     = 24 // Friction ratio Rf [%]
+	broColumns           = 25 // Number of columns
+)
+
+// broClient is the HTTP client of the BRO requests.
+var broClient = &http.Client{Timeout: 2 * time.Minute}
+
+// BRO fetches cone penetration tests from the Dutch Basisregistratie Ondergrond
+// (BRO, https://www.broloket.nl), the national register of subsurface data of the
+// Netherlands. The CPTs around a location are found with the characteristics
+// search of the CPT service, and the measurements of the closest one are
+// downloaded as BRO XML.
+type BRO struct {
+	URL    string       // Base URL of the CPT service (DefaultBROURL when empty)
+	Client *http.Client // HTTP client (a client with a two minute timeout when nil)
+}
+
+// broCharacteristics is the response of the characteristics search.
+type broCharacteristics struct {
+	RejectionReason string `xml:"rejectionReason"`
+	Documents       []struct {
+		CPT struct {
+			ID           string `xml:"broId"`
+			Deregistered string `xml:"deregistered"`
+			Location     string `xml:"standardizedLocation>Point>pos"` // "latitude longitude" (ETRS89)
+		} `xml:"CPT_C"`
+	} `xml:"dispatchDocument"`
+}
+
+// NearestCPT returns the BRO CPT closest to a location, within a radius around it.
+//
+// Parameters:
+//   - ctx: Context cancelling the requests
+//   - location: The location
+//   - radius: Search radius around the location [m]
+//
+// Returns:
+//   - Sounding: The closest CPT, with its BRO ID
+//   - error: An error if a request fails or no CPT is found within the radius
+func (b *BRO) NearestCPT(ctx context.Context, location Location, radius float64) (Sounding, error) {
+	request := map[string]any{
+		"registrationPeriod": map[string]string{
+			"beginDate": "2015-01-01",
+			"endDate":   time.Now().Format("2006-01-02"),
+		},
+		"area": map[string]any{
+			"enclosingCircle": map[string]any{
+				"center": map[string]float64{"lat": location.Latitude, "lon": location.Longitude},
+				"radius": radius / 1000, // [km]
+			},
+		},
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Sounding{}, err
+	}
+	data, err := b.do(ctx, http.MethodPost, "/characteristics/searches", body)
+	if err != nil {
+		return Sounding{}, err
+	}
+
+	var characteristics broCharacteristics
+	if err := xml.Unmarshal(data, &characteristics); err != nil {
+		return Sounding{}, fmt.Errorf("invalid BRO search response: %v", err)
+	}
+	if characteristics.RejectionReason != "" {
+		return Sounding{}, fmt.Errorf("BRO search rejected: %s", characteristics.RejectionReason)
+	}
+
+	nearest := Sounding{Distance: math.Inf(1)}
+	for _, document := range characteristics.Documents {
+		c := document.CPT
+		if c.ID == "" || c.Deregistered == "ja" {
+			continue
+		}
+		var position Location
+		if _, err := fmt.Sscan(c.Location, &position.Latitude, &position.Longitude); err != nil {
+			continue
+		}
+		if distance := Distance(location, position); distance <= radius && distance < nearest.Distance {
+			nearest = Sounding{ID: c.ID, Location: position, Distance: distance}
+		}
+	}
+	if nearest.ID == "" {
+		return Sounding{}, fmt.Errorf("no BRO CPT found within %g m of latitude %g, longitude %g", radius, location.Latitude, location.Longitude)
+	}
+
+	data, err = b.do(ctx, http.MethodGet, "/objects/"+url.PathEscape(nearest.ID), nil)
+	if err != nil {
+		return Sounding{}, err
+	}
+	if nearest.CPT, err = parseBROCPT(data); err != nil {
+		return Sounding{}, fmt.Errorf("BRO CPT %s: %v", nearest.ID, err)
+	}
+	nearest.CPT.TestID = nearest.ID
+	return nearest, nil
+}
+
+// do sends a request to the CPT service.
+//
+// Parameters:
+//   - ctx: Context cancelling the request
+//   - method: HTTP method
+//   - path: Path of the request, relative to the base URL
+//   - body: JSON body of the request (nil for none)
+//
+// Returns:
+//   - []byte: The body of the response
+//   - error: An error if the request fails or the response is not successful
+func (b *BRO) do(ctx context.Context, method string, path string, body []byte) ([]byte, error) {
+	base, client := b.URL, b.Client
+	if base == "" {
+		base = DefaultBROURL
+	}
+	if client == nil {
+		client = broClient
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/xml")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("BRO request failed: %v", err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BRO response: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("BRO request %s %s failed: %s: %s", method, path, response.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// parseBROCPT reads the measurements of a CPT in BRO XML: the values of its
+// conePenetrationTest, with the rows separated by ";" and the columns by ",".
+// The depth is the penetration length where no corrected depth is given; rows
+// without depth or cone resistance are skipped.
+//
+// Parameters:
+//   - data: The BRO XML document of the CPT
+//
+// Returns:
+//   - cpt.CPT: The measurements
+//   - error: An error if the document holds no valid measurements
+func parseBROCPT(data []byte) (cpt.CPT, error) {
+	values, err := broValues(data)
+	if err != nil {
+		return cpt.CPT{}, err
+	}
+
+	var test cpt.CPT
+	for _, row := range strings.Split(values, ";") {
+		if strings.TrimSpace(row) == "" {
+			continue
+		}
+		fields := strings.Split(row, ",")
+		if len(fields) != broColumns {
+			return test, fmt.Errorf("invalid measurement with %d values instead of %d", len(fields), broColumns)
+		}
+		column := make([]float64, broColumns)
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return test, fmt.Errorf("invalid measurement value: %s", field)
+			}
+			if v == broVoid {
+				v = math.NaN()
+			}
+			column[i] = v
+		}
+
+		depth := column[broDepth]
+		if math.IsNaN(depth) {
+			depth = column[broPenetrationLength]
+		}
+		coneResistance := column[broConeResistance]
+		if math.IsNaN(depth) || math.IsNaN(coneResistance) {
+			continue
+		}
+		sleeveFriction, frictionRatio := column[broLocalFriction], column[broFrictionRatio]
+		if math.IsNaN(sleeveFriction) {
+			sleeveFriction = frictionRatio / 100 * coneResistance
+		}
+		if math.IsNaN(frictionRatio) && coneResistance > 0 {
+			frictionRatio = 100 * sleeveFriction / coneResistance
+		}
+
+		if n := len(test.Depth); n > 0 && depth < test.Depth[n-1] {
+			return test, fmt.Errorf("depths must be increasing")
+		}
+		test.Depth = append(test.Depth, depth)
+		test.ConeResistance = append(test.ConeResistance, coneResistance)
+		test.SleeveFriction = append(test.SleeveFriction, sleeveFriction)
+		test.FrictionRatio = append(test.FrictionRatio, frictionRatio)
+	}
+	if len(test.Depth) == 0 {
+		return test, fmt.Errorf("no measurements")
+	}
+	return test, nil
+}
+
+// broValues returns the text of the values element of the conePenetrationTest of
+// a BRO XML document.
+//
+// Parameters:
+//   - data: The BRO XML document of the CPT
+//
+// Returns:
+//   - string: The measurements
+//   - error: An error if the document is invalid or has no measurements
+func broValues(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var parents []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("no conePenetrationTest values")
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid BRO XML: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "values" && len(parents) > 0 && parents[len(parents)-1] == "conePenetrationTest" {
+				var values string
+				if err := decoder.DecodeElement(&values, &t); err != nil {
+					return "", fmt.Errorf("invalid BRO XML: %v", err)
+				}
+				return values, nil
+			}
+			parents = append(parents, t.Name.Local)
+		case xml.EndElement:
+			parents = parents[:len(parents)-1]
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package geodata fetches site investigation data from geo-databases, so that the
+// soil profile of a site can be derived from the cone penetration test (CPT)
+// closest to its coordinates instead of being transcribed by hand.
+//
+// Databases are accessed through the Provider interface. The built-in provider
+// "bro" searches the Dutch Basisregistratie Ondergrond (BRO), the national
+// register of subsurface data of the Netherlands, through its public CPT service.
+// Other databases (e.g. a company archive) are plugged in by implementing
+// Provider and registering it with Register, after which they can be selected by
+// name like the built-in ones.
+//
+// The fetched CPT is a cpt.CPT, converted into soil layers with cpt.Layers.
+//
+// # BRO
+//
+// CPTs within the search radius are found with the characteristics search of the
+// CPT service (POST /characteristics/searches), using the standardized (ETRS89)
+// location of each test; deregistered tests are ignored. The measurements of the
+// closest test are then downloaded (GET /objects/{broId}) and read from the
+// values of its conePenetrationTest: the depth (or the penetration length where
+// the depth is not given), the cone resistance, and the local friction or friction
+// ratio. Missing values (-999999) are skipped.
+//
+// # Usage Example
+//
+//	sounding, err := geodata.FetchCPT(ctx, geodata.ProviderBRO, geodata.Location{
+//		Latitude:  52.0116,
+//		Longitude: 4.3571,
+//	}, 500)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("%s at %.0f m\n", sounding.ID, sounding.Distance)
+//	layers, err := cpt.Layers(sounding.CPT, cpt.LayerOptions{GroundwaterDepth: 1})
+//
+// In a configuration file, the soil_cpt section fetches the CPT instead of reading
+// a GEF file:
+//
+//	soil_cpt:
+//	  provider: "bro"
+//	  latitude: 52.0116
+//	  longitude: 4.3571
+//	  radius: 500
+//	  groundwater_depth: 1
+package geodata
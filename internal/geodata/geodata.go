@@ -0,0 +1,131 @@
+package geodata
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	cpt "github.com/PlatypusBytes/GoTrain/internal/cpt"
+)
+
+// DefaultRadius is the search radius around a location when none is given [m].
+const DefaultRadius = 500.0
+
+// earthRadius is the mean radius of the Earth, used for the distances [m].
+const earthRadius = 6371008.8
+
+// Location is a point on the surface, in WGS84 coordinates.
+type Location struct {
+	Latitude  float64 // Latitude [degrees]
+	Longitude float64 // Longitude [degrees]
+}
+
+// Sounding is a cone penetration test fetched from a geo-database.
+type Sounding struct {
+	ID       string   // Identifier of the test in the database, e.g. its BRO ID
+	Location Location // Location of the test
+	Distance float64  // Distance between the test and the requested location [m]
+	CPT      cpt.CPT  // The measurements
+}
+
+// Provider fetches site investigation data from a geo-database.
+type Provider interface {
+	// NearestCPT returns the cone penetration test closest to a location, within
+	// a radius [m] around it.
+	NearestCPT(ctx context.Context, location Location, radius float64) (Sounding, error)
+}
+
+// Names of the built-in providers.
+const (
+	ProviderBRO = "bro" // Dutch Basisregistratie Ondergrond (see BRO)
+)
+
+// providers are the providers available by name, see Register.
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		ProviderBRO: &BRO{},
+	}
+)
+
+// Register makes a provider available under a name, e.g. for the provider field
+// of the soil_cpt section of configurations. A provider registered under the name
+// of an existing one replaces it.
+//
+// Parameters:
+//   - name: Name of the provider
+//   - provider: The provider
+func Register(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = provider
+}
+
+// ProviderByName returns the provider registered under a name.
+//
+// Parameters:
+//   - name: Name of the provider, e.g. "bro"
+//
+// Returns:
+//   - Provider: The provider
+//   - error: An error if no provider is registered under the name
+func ProviderByName(name string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[name]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for n := range providers {
+			names = append(names, "'"+n+"'")
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("invalid provider: %s. Supported providers are %s", name, strings.Join(names, ", "))
+	}
+	return provider, nil
+}
+
+// FetchCPT fetches the cone penetration test closest to a location from the
+// provider registered under a name.
+//
+// Parameters:
+//   - ctx: Context cancelling the requests
+//   - name: Name of the provider
+//   - location: The location
+//   - radius: Search radius around the location [m] (DefaultRadius when <= 0)
+//
+// Returns:
+//   - Sounding: The closest test
+//   - error: An error if the provider is unknown, the request fails or no test is found
+func FetchCPT(ctx context.Context, name string, location Location, radius float64) (Sounding, error) {
+	provider, err := ProviderByName(name)
+	if err != nil {
+		return Sounding{}, err
+	}
+	if math.Abs(location.Latitude) > 90 || math.Abs(location.Longitude) > 180 {
+		return Sounding{}, fmt.Errorf("invalid location: latitude %g, longitude %g", location.Latitude, location.Longitude)
+	}
+	if radius <= 0 {
+		radius = DefaultRadius
+	}
+	return provider.NearestCPT(ctx, location, radius)
+}
+
+// Distance returns the great-circle distance between two locations (haversine
+// formula on a spherical Earth).
+//
+// Parameters:
+//   - a: The first location
+//   - b: The second location
+//
+// Returns:
+//   - float64: The distance [m]
+func Distance(a Location, b Location) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Min(math.Sqrt(h), 1))
+}
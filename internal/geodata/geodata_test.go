@@ -0,0 +1,180 @@
+package geodata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// broSearchResponse is a characteristics search response with three CPTs: one
+// close to the site, one farther away and one deregistered at the site itself.
+const broSearchResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<dispatchCharacteristicsResponse xmlns="http://www.broservices.nl/xsd/dscpt/1.1" xmlns:brocom="http://www.broservices.nl/xsd/brocommon/3.0" xmlns:gml="http://www.opengis.net/gml/3.2">
+	<dispatchDocument>
+		<CPT_C gml:id="BRO_0001">
+			<brocom:broId>CPT000000000002</brocom:broId>
+			<brocom:deregistered>nee</brocom:deregistered>
+			<brocom:standardizedLocation><gml:Point srsName="urn:ogc:def:crs:EPSG::4258"><gml:pos>52.0020 4.3571</gml:pos></gml:Point></brocom:standardizedLocation>
+		</CPT_C>
+	</dispatchDocument>
+	<dispatchDocument>
+		<CPT_C gml:id="BRO_0002">
+			<brocom:broId>CPT000000000001</brocom:broId>
+			<brocom:deregistered>nee</brocom:deregistered>
+			<brocom:standardizedLocation><gml:Point srsName="urn:ogc:def:crs:EPSG::4258"><gml:pos>52.0005 4.3571</gml:pos></gml:Point></brocom:standardizedLocation>
+		</CPT_C>
+	</dispatchDocument>
+	<dispatchDocument>
+		<CPT_C gml:id="BRO_0003">
+			<brocom:broId>CPT000000000003</brocom:broId>
+			<brocom:deregistered>ja</brocom:deregistered>
+			<brocom:standardizedLocation><gml:Point srsName="urn:ogc:def:crs:EPSG::4258"><gml:pos>52.0000 4.3571</gml:pos></gml:Point></brocom:standardizedLocation>
+		</CPT_C>
+	</dispatchDocument>
+</dispatchCharacteristicsResponse>`
+
+// broObject returns the BRO XML document of a CPT with the given rows of values.
+func broObject(rows ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<dispatchDataResponse xmlns="http://www.broservices.nl/xsd/dscpt/1.1" xmlns:cptcommon="http://www.broservices.nl/xsd/cptcommon/1.1">
+	<dispatchDocument>
+		<CPT_O>
+			<conePenetrometerSurvey>
+				<cptcommon:conePenetrationTest>
+					<cptcommon:values>` + strings.Join(rows, ";") + `;</cptcommon:values>
+				</cptcommon:conePenetrationTest>
+			</conePenetrometerSurvey>
+		</CPT_O>
+	</dispatchDocument>
+</dispatchDataResponse>`
+}
+
+// broRow returns a row of BRO CPT values with the given penetration length, depth,
+// cone resistance, local friction and friction ratio; the other values are void.
+func broRow(length, depth, qc, fs, rf float64) string {
+	values := make([]string, broColumns)
+	for i := range values {
+		values[i] = "-999999"
+	}
+	values[broPenetrationLength] = fmt.Sprint(length)
+	values[broDepth] = fmt.Sprint(depth)
+	values[broConeResistance] = fmt.Sprint(qc)
+	values[broLocalFriction] = fmt.Sprint(fs)
+	values[broFrictionRatio] = fmt.Sprint(rf)
+	return strings.Join(values, ",")
+}
+
+// Test that the closest registered CPT within the radius is fetched from the BRO.
+func TestBRONearestCPT(t *testing.T) {
+
+	var search map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/characteristics/searches":
+			if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, broSearchResponse)
+		case r.Method == http.MethodGet && r.URL.Path == "/objects/CPT000000000001":
+			fmt.Fprint(w, broObject(
+				broRow(0.5, -999999, 1.2, 0.012, 1),
+				broRow(1.0, 0.98, -999999, 0.02, 2),
+				broRow(1.5, 1.47, 4.0, -999999, 2.5),
+			))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := &BRO{URL: server.URL}
+	site := Location{Latitude: 52, Longitude: 4.3571}
+	sounding, err := provider.NearestCPT(context.Background(), site, 300)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if sounding.ID != "CPT000000000001" || sounding.CPT.TestID != sounding.ID {
+		t.Errorf("expected the closest registered CPT, got %s", sounding.ID)
+	}
+	if math.Abs(sounding.Distance-55.6) > 0.5 {
+		t.Errorf("unexpected distance: %v", sounding.Distance)
+	}
+
+	circle := search["area"].(map[string]any)["enclosingCircle"].(map[string]any)
+	if circle["radius"] != 0.3 || circle["center"].(map[string]any)["lat"] != 52.0 {
+		t.Errorf("unexpected search area: %v", circle)
+	}
+
+	// The row without cone resistance is skipped, the void depth replaced by the
+	// penetration length and the void friction derived from the friction ratio
+	test := sounding.CPT
+	if len(test.Depth) != 2 || test.Depth[0] != 0.5 || test.Depth[1] != 1.47 {
+		t.Fatalf("unexpected depths: %v", test.Depth)
+	}
+	if math.Abs(test.SleeveFriction[1]-0.1) > 1e-12 || test.FrictionRatio[0] != 1 {
+		t.Errorf("unexpected friction: %v, %v", test.SleeveFriction, test.FrictionRatio)
+	}
+
+	if _, err := provider.NearestCPT(context.Background(), site, 50); err == nil {
+		t.Errorf("expected an error without CPT within the radius")
+	}
+	if _, err := (&BRO{URL: server.URL + "/missing"}).NearestCPT(context.Background(), site, 300); err == nil {
+		t.Errorf("expected an error for a failed request")
+	}
+}
+
+// Test the parsing of invalid BRO CPT documents.
+func TestParseBROCPT(t *testing.T) {
+
+	if _, err := parseBROCPT([]byte(broObject("1,2,3"))); err == nil {
+		t.Errorf("expected an error for a row with missing values")
+	}
+	if _, err := parseBROCPT([]byte(broObject(broRow(2, 2, 1, 0.01, 1), broRow(1, 1, 1, 0.01, 1)))); err == nil {
+		t.Errorf("expected an error for decreasing depths")
+	}
+	if _, err := parseBROCPT([]byte("<dispatchDataResponse></dispatchDataResponse>")); err == nil {
+		t.Errorf("expected an error without measurements")
+	}
+}
+
+// stubProvider returns the same sounding for every location.
+type stubProvider struct {
+	sounding Sounding
+}
+
+func (p stubProvider) NearestCPT(ctx context.Context, location Location, radius float64) (Sounding, error) {
+	if radius != DefaultRadius {
+		return Sounding{}, fmt.Errorf("unexpected radius %g", radius)
+	}
+	return p.sounding, nil
+}
+
+// Test the selection of providers by name, with a registered provider.
+func TestProviders(t *testing.T) {
+
+	if _, err := ProviderByName(ProviderBRO); err != nil {
+		t.Errorf("expected the BRO provider, got: %v", err)
+	}
+	if _, err := ProviderByName("dino"); err == nil || !strings.Contains(err.Error(), "'bro'") {
+		t.Errorf("expected an error listing the providers, got: %v", err)
+	}
+
+	Register("stub", stubProvider{Sounding{ID: "S1"}})
+	sounding, err := FetchCPT(context.Background(), "stub", Location{Latitude: 52, Longitude: 4}, 0)
+	if err != nil || sounding.ID != "S1" {
+		t.Errorf("unexpected sounding %+v, error %v", sounding, err)
+	}
+	if _, err := FetchCPT(context.Background(), "stub", Location{Latitude: 95, Longitude: 4}, 0); err == nil {
+		t.Errorf("expected an error for an invalid location")
+	}
+
+	if d := Distance(Location{Latitude: 0, Longitude: 0}, Location{Latitude: 0, Longitude: 1}); math.Abs(d-111195) > 1 {
+		t.Errorf("unexpected distance of one degree along the equator: %v", d)
+	}
+}
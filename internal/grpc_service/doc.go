@@ -0,0 +1,26 @@
+// Package grpc_service implements the GoTrain gRPC service defined in
+// proto/gotrain.proto.
+//
+// The CriticalSpeed service has a single unary method, Compute, which runs the
+// analysis of a configuration with critical_speed.Compute and returns the
+// dispersion curves and critical speed as typed messages. Clients for any language
+// can be generated from proto/gotrain.proto with protoc.
+//
+// The service is served by google.golang.org/grpc, with the stubs and message types
+// generated from proto/gotrain.proto in internal/protobuf (make proto).
+// ConfigFromProto, ConfigToProto, ResultToProto and ResultFromProto convert the
+// generated messages to and from the critical_speed types. The server also
+// registers the standard health service (grpc.health.v1.Health) and server
+// reflection, and accepts gzip-compressed messages.
+//
+// The server uses unencrypted connections: clients must use insecure credentials,
+// e.g. behind a TLS-terminating proxy.
+//
+// # Usage Example
+//
+//	log.Fatal(grpc_service.ListenAndServe(":9090"))
+//
+// Calling the service with grpcurl, which finds the service by reflection:
+//
+//	grpcurl -plaintext -d @ localhost:9090 gotrain.v1.CriticalSpeed/Compute < request.json
+package grpc_service
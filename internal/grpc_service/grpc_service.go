@@ -0,0 +1,73 @@
+package grpc_service
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // accept gzip-compressed messages
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	protobuf "github.com/PlatypusBytes/GoTrain/internal/protobuf"
+)
+
+// criticalSpeedServer implements the CriticalSpeed service.
+type criticalSpeedServer struct {
+	protobuf.UnimplementedCriticalSpeedServer
+}
+
+// NewServer creates a gRPC server serving the CriticalSpeed service, with the
+// standard health service (reporting the CriticalSpeed service as serving) and the
+// server reflection service. The server uses unencrypted connections, as expected
+// by gRPC clients using insecure credentials.
+//
+// Returns:
+//   - *grpc.Server: The server, to be started with Serve
+func NewServer() *grpc.Server {
+	srv := grpc.NewServer()
+	protobuf.RegisterCriticalSpeedServer(srv, criticalSpeedServer{})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(protobuf.CriticalSpeed_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	reflection.Register(srv)
+	return srv
+}
+
+// ListenAndServe serves the CriticalSpeed service (see NewServer) on an address.
+//
+// Parameters:
+//   - addr: Address to listen on, e.g. :9090
+//
+// Returns:
+//   - error: An error if the address cannot be listened on or serving fails
+func ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return NewServer().Serve(listener)
+}
+
+// Compute runs the analysis of the configuration of a request. Failed analyses are
+// reported with the status DEADLINE_EXCEEDED or CANCELLED when the deadline of the
+// call expires or the call is cancelled, and UNKNOWN otherwise.
+func (criticalSpeedServer) Compute(ctx context.Context, request *protobuf.ComputeRequest) (*protobuf.ComputeResponse, error) {
+	result, err := critical_speed.Compute(ctx, ConfigFromProto(request.GetConfig()))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return nil, status.Error(codes.Canceled, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return ResultToProto(result), nil
+}
@@ -0,0 +1,152 @@
+package grpc_service
+
+import (
+	"context"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	protobuf "github.com/PlatypusBytes/GoTrain/internal/protobuf"
+)
+
+const TOL = 1e-3
+
+// Test that configurations and results survive a protocol buffer round trip.
+func TestMarshalRoundTrip(t *testing.T) {
+	config, err := critical_speed.LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load sample config: %v", err)
+	}
+	config.Output.FileName = ""
+	config.Positions = nil // Positions in the YAML file are not transmitted
+
+	data, err := proto.Marshal(ConfigToProto(config))
+	if err != nil {
+		t.Fatalf("failed to marshal the config: %v", err)
+	}
+	var message protobuf.Config
+	if err := proto.Unmarshal(data, &message); err != nil {
+		t.Fatalf("failed to unmarshal the config: %v", err)
+	}
+	if decoded := ConfigFromProto(&message); !reflect.DeepEqual(decoded, config) {
+		t.Errorf("config round trip mismatch:\ngot  %+v\nwant %+v", decoded, config)
+	}
+
+	result := critical_speed.Result{
+		Omega:              []float64{1, 2},
+		TrackPhaseVelocity: []float64{0, 150},
+		SoilPhaseVelocity:  []float64{math.NaN(), 120},
+		CriticalOmega:      1.5,
+		CriticalVelocity:   130,
+	}
+	data, err = proto.Marshal(ResultToProto(result))
+	if err != nil {
+		t.Fatalf("failed to marshal the result: %v", err)
+	}
+	var response protobuf.ComputeResponse
+	if err := proto.Unmarshal(data, &response); err != nil {
+		t.Fatalf("failed to unmarshal the result: %v", err)
+	}
+	decodedResult := ResultFromProto(&response)
+	if !math.IsNaN(decodedResult.SoilPhaseVelocity[0]) || decodedResult.SoilPhaseVelocity[1] != 120 ||
+		!reflect.DeepEqual(decodedResult.TrackPhaseVelocity, result.TrackPhaseVelocity) ||
+		decodedResult.CriticalVelocity != 130 {
+		t.Errorf("result round trip mismatch: %+v", decodedResult)
+	}
+}
+
+// dial starts a server on a local port and connects a client to it.
+func dial(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := NewServer()
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Test the Compute method with the sample configuration and with an invalid request.
+func TestCompute(t *testing.T) {
+	client := protobuf.NewCriticalSpeedClient(dial(t))
+	ctx := context.Background()
+
+	config, err := critical_speed.LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load sample config: %v", err)
+	}
+
+	// Compressed requests are accepted
+	response, err := client.Compute(ctx, &protobuf.ComputeRequest{Config: ConfigToProto(config)}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	result := ResultFromProto(response)
+	expectedSpeed := 78.231
+	if diff := result.CriticalVelocity - expectedSpeed; diff < -TOL || diff > TOL {
+		t.Errorf("unexpected critical_velocity: got %v, want %v (tolerance %v)", result.CriticalVelocity, expectedSpeed, TOL)
+	}
+	if len(result.Omega) != config.Frequency.Points {
+		t.Errorf("expected %d frequencies, got %d", config.Frequency.Points, len(result.Omega))
+	}
+
+	// An invalid track type is reported as a gRPC error
+	config.TrackType = "unknown"
+	_, err = client.Compute(ctx, &protobuf.ComputeRequest{Config: ConfigToProto(config)})
+	if code := status.Code(err); code != codes.Unknown {
+		t.Errorf("expected status %v for invalid track type, got %v (%v)", codes.Unknown, code, err)
+	}
+}
+
+// Test that the server reports its health and lists its services by reflection.
+func TestHealthAndReflection(t *testing.T) {
+	conn := dial(t)
+	ctx := context.Background()
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "gotrain.v1.CriticalSpeed"})
+	if err != nil || health.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected the service to be serving, got %v (%v)", health.GetStatus(), err)
+	}
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatalf("reflection failed: %v", err)
+	}
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("reflection request failed: %v", err)
+	}
+	reply, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("reflection reply failed: %v", err)
+	}
+	var services []string
+	for _, service := range reply.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	if !reflect.DeepEqual(services, []string{"gotrain.v1.CriticalSpeed", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"}) {
+		t.Errorf("unexpected services: %v", services)
+	}
+}
@@ -0,0 +1,148 @@
+package grpc_service
+
+import (
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	protobuf "github.com/PlatypusBytes/GoTrain/internal/protobuf"
+)
+
+// ConfigFromProto converts a Config message (see proto/gotrain.proto) to a configuration.
+//
+// Parameters:
+//   - message: The Config message (nil for an empty configuration)
+//
+// Returns:
+//   - critical_speed.Config: The configuration (without output section), in the
+//     layout of critical_speed.ConfigVersion
+func ConfigFromProto(message *protobuf.Config) critical_speed.Config {
+	config := critical_speed.Config{
+		ConfigVersion: critical_speed.ConfigVersion,
+		TrackType:     message.GetTrackType(),
+	}
+
+	frequency := message.GetFrequency()
+	config.Frequency.Min = frequency.GetMin()
+	config.Frequency.Max = frequency.GetMax()
+	config.Frequency.Points = int(frequency.GetPoints())
+
+	ballast, t := message.GetBallastTrack(), &config.BallastTrack
+	t.EIRail, t.MRail = ballast.GetEiRail(), ballast.GetMRail()
+	t.KRailPad, t.CRailPad = ballast.GetKRailPad(), ballast.GetCRailPad()
+	t.MSleeper, t.EBallast, t.HBallast = ballast.GetMSleeper(), ballast.GetEBallast(), ballast.GetHBallast()
+	t.WidthSleeper, t.RhoBallast = ballast.GetWidthSleeper(), ballast.GetRhoBallast()
+	t.SoilStiffness = ballast.GetSoilStiffness()
+
+	slab, s := message.GetSlabTrack(), &config.SlabTrack
+	s.EIRail, s.MRail = slab.GetEiRail(), slab.GetMRail()
+	s.EISlab, s.MSlab = slab.GetEiSlab(), slab.GetMSlab()
+	s.KRailPad, s.CRailPad = slab.GetKRailPad(), slab.GetCRailPad()
+	s.SoilStiffness = slab.GetSoilStiffness()
+
+	for _, layer := range message.GetSoilLayers() {
+		config.SoilLayers = append(config.SoilLayers, critical_speed.SoilLayer{
+			Thickness:    layer.Thickness,
+			Density:      layer.Density,
+			YoungModulus: layer.YoungModulus,
+			PoissonRatio: layer.PoissonRatio,
+		})
+	}
+	return config
+}
+
+// ConfigToProto converts a configuration to a Config message (see proto/gotrain.proto).
+// The output section of the configuration is not part of the message.
+//
+// Parameters:
+//   - config: The configuration to convert
+//
+// Returns:
+//   - *protobuf.Config: The Config message
+func ConfigToProto(config critical_speed.Config) *protobuf.Config {
+	ballast, slab := config.BallastTrack, config.SlabTrack
+	message := &protobuf.Config{
+		TrackType: config.TrackType,
+		Frequency: &protobuf.Frequency{
+			Min:    config.Frequency.Min,
+			Max:    config.Frequency.Max,
+			Points: int32(config.Frequency.Points),
+		},
+		BallastTrack: &protobuf.BallastTrack{
+			EiRail:        ballast.EIRail,
+			MRail:         ballast.MRail,
+			KRailPad:      ballast.KRailPad,
+			CRailPad:      ballast.CRailPad,
+			MSleeper:      ballast.MSleeper,
+			EBallast:      ballast.EBallast,
+			HBallast:      ballast.HBallast,
+			WidthSleeper:  ballast.WidthSleeper,
+			RhoBallast:    ballast.RhoBallast,
+			SoilStiffness: ballast.SoilStiffness,
+		},
+		SlabTrack: &protobuf.SlabTrack{
+			EiRail:        slab.EIRail,
+			MRail:         slab.MRail,
+			EiSlab:        slab.EISlab,
+			MSlab:         slab.MSlab,
+			KRailPad:      slab.KRailPad,
+			CRailPad:      slab.CRailPad,
+			SoilStiffness: slab.SoilStiffness,
+		},
+	}
+	for _, layer := range config.SoilLayers {
+		message.SoilLayers = append(message.SoilLayers, &protobuf.SoilLayer{
+			Thickness:    layer.Thickness,
+			Density:      layer.Density,
+			YoungModulus: layer.YoungModulus,
+			PoissonRatio: layer.PoissonRatio,
+		})
+	}
+	return message
+}
+
+// ResultToProto converts a result to a ComputeResponse message (see proto/gotrain.proto),
+// which has the fields of the Result message.
+//
+// Parameters:
+//   - result: The result to convert
+//
+// Returns:
+//   - *protobuf.ComputeResponse: The ComputeResponse message
+func ResultToProto(result critical_speed.Result) *protobuf.ComputeResponse {
+	return &protobuf.ComputeResponse{
+		Omega:               result.Omega,
+		TrackPhaseVelocity:  result.TrackPhaseVelocity,
+		SoilPhaseVelocity:   result.SoilPhaseVelocity,
+		CriticalOmega:       result.CriticalOmega,
+		CriticalVelocity:    result.CriticalVelocity,
+		SitePeriod:          result.SitePeriod,
+		ResonanceFrequency:  result.ResonanceFrequency,
+		CriticalOmegaMin:    result.CriticalBand.OmegaMin,
+		CriticalOmegaMax:    result.CriticalBand.OmegaMax,
+		CriticalVelocityMin: result.CriticalBand.VelocityMin,
+		CriticalVelocityMax: result.CriticalBand.VelocityMax,
+	}
+}
+
+// ResultFromProto converts a ComputeResponse message (see proto/gotrain.proto) to a result.
+//
+// Parameters:
+//   - message: The ComputeResponse message
+//
+// Returns:
+//   - critical_speed.Result: The result
+func ResultFromProto(message *protobuf.ComputeResponse) critical_speed.Result {
+	return critical_speed.Result{
+		Omega:              message.GetOmega(),
+		TrackPhaseVelocity: message.GetTrackPhaseVelocity(),
+		SoilPhaseVelocity:  message.GetSoilPhaseVelocity(),
+		CriticalOmega:      message.GetCriticalOmega(),
+		CriticalVelocity:   message.GetCriticalVelocity(),
+		SitePeriod:         message.GetSitePeriod(),
+		ResonanceFrequency: message.GetResonanceFrequency(),
+		CriticalBand: critical_speed.CriticalBand{
+			OmegaMin:    message.GetCriticalOmegaMin(),
+			OmegaMax:    message.GetCriticalOmegaMax(),
+			VelocityMin: message.GetCriticalVelocityMin(),
+			VelocityMax: message.GetCriticalVelocityMax(),
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package masw
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Band is a dispersion curve with its uncertainty on a grid of frequencies, e.g. the
+// spread of the curves of a Monte Carlo ensemble of soil profiles, to be compared
+// with a measured curve and its error bars.
+type Band struct {
+	Omega  []float64 // Angular frequencies [rad/s]
+	Mean   []float64 // Mean phase velocity [m/s] (NaN where no curve has a phase velocity)
+	StdDev []float64 // Standard deviation of the phase velocity [m/s]
+	Lower  []float64 // Lower bound of the band [m/s]
+	Upper  []float64 // Upper bound of the band [m/s]
+	Count  []int     // Number of curves with a phase velocity at the frequency
+}
+
+// Resample evaluates a computed dispersion curve on a grid of frequencies, e.g. the
+// frequencies of a measured curve, by linear interpolation.
+//
+// Parameters:
+//   - omega: Angular frequencies of the curve [rad/s], in increasing order
+//   - velocity: Phase velocities of the curve [m/s] (NaN where no root is found)
+//   - grid: Angular frequencies at which the curve is evaluated [rad/s]
+//
+// Returns:
+//   - []float64: The phase velocities on the grid [m/s] (NaN outside the curve or next to a NaN value)
+//   - error: An error if the curve is invalid
+func Resample(omega []float64, velocity []float64, grid []float64) ([]float64, error) {
+	if len(omega) < 2 || len(omega) != len(velocity) {
+		return nil, fmt.Errorf("invalid computed dispersion curve: at least two frequencies and one phase velocity per frequency are needed")
+	}
+	for i := 1; i < len(omega); i++ {
+		if !(omega[i] > omega[i-1]) {
+			return nil, fmt.Errorf("invalid computed dispersion curve: frequencies must be increasing")
+		}
+	}
+	resampled := make([]float64, len(grid))
+	for i, w := range grid {
+		resampled[i] = interpolate(omega, velocity, w)
+	}
+	return resampled, nil
+}
+
+// EnsembleBand computes the uncertainty band of an ensemble of dispersion curves
+// computed at the same frequencies, e.g. of the realizations of a random field (see
+// soil_dispersion.RandomField), on a grid of frequencies. Every curve is resampled
+// on the grid first and the statistics are taken over the resampled curves, so the
+// band is that of the ensemble at the grid frequencies, not an interpolation of
+// bands. The bounds are the percentiles enclosing the confidence level, interpolated
+// linearly between the sorted phase velocities. The curves without a phase velocity
+// at a frequency are left out of its statistics. A single curve gives a band of zero
+// width.
+//
+// Parameters:
+//   - omega: Angular frequencies of the curves [rad/s], in increasing order
+//   - curves: Phase velocities of the curves [m/s] (NaN where no root is found)
+//   - grid: Angular frequencies of the band [rad/s]
+//   - confidence: Fraction of the curves within the bounds, in (0, 1), e.g. 0.9 for the 5th to 95th percentiles
+//
+// Returns:
+//   - Band: The band of the ensemble on the grid
+//   - error: An error if there are no curves, a curve is invalid or the confidence is not within (0, 1)
+func EnsembleBand(omega []float64, curves [][]float64, grid []float64, confidence float64) (Band, error) {
+	if len(curves) == 0 {
+		return Band{}, fmt.Errorf("at least one dispersion curve is needed")
+	}
+	if !(confidence > 0 && confidence < 1) {
+		return Band{}, fmt.Errorf("invalid confidence: must be within (0, 1), got %g", confidence)
+	}
+	resampled := make([][]float64, len(curves))
+	for i, curve := range curves {
+		var err error
+		if resampled[i], err = Resample(omega, curve, grid); err != nil {
+			return Band{}, fmt.Errorf("curve %d: %w", i, err)
+		}
+	}
+
+	band := newBand(grid)
+	values := make([]float64, 0, len(curves))
+	for j := range grid {
+		values = values[:0]
+		for _, curve := range resampled {
+			if !math.IsNaN(curve[j]) {
+				values = append(values, curve[j])
+			}
+		}
+		band.Count[j] = len(values)
+		if len(values) == 0 {
+			band.Mean[j], band.StdDev[j], band.Lower[j], band.Upper[j] = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		var sum, sumSquares float64
+		for _, v := range values {
+			sum += v
+		}
+		mean := sum / float64(len(values))
+		for _, v := range values {
+			sumSquares += (v - mean) * (v - mean)
+		}
+		band.Mean[j] = mean
+		if len(values) > 1 {
+			band.StdDev[j] = math.Sqrt(sumSquares / float64(len(values)-1))
+		}
+		slices.Sort(values)
+		band.Lower[j] = percentile(values, (1-confidence)/2)
+		band.Upper[j] = percentile(values, (1+confidence)/2)
+	}
+	return band, nil
+}
+
+// Resample evaluates the band on another grid of frequencies by linear
+// interpolation of its mean, standard deviation and bounds. The phase velocities of
+// neighbouring frequencies of a dispersion curve are fully correlated, so the
+// standard deviation of the interpolated phase velocity is the interpolated standard
+// deviation, and the bounds keep their confidence. The count of a grid frequency is
+// the smaller count of its neighbours.
+//
+// Parameters:
+//   - grid: Angular frequencies at which the band is evaluated [rad/s]
+//
+// Returns:
+//   - Band: The band on the grid (NaN outside the band or next to a frequency without phase velocity)
+//   - error: An error if the band is invalid
+func (b Band) Resample(grid []float64) (Band, error) {
+	for _, values := range [][]float64{b.Mean, b.StdDev, b.Lower, b.Upper} {
+		if len(values) != len(b.Omega) {
+			return Band{}, fmt.Errorf("invalid band: one value per frequency is needed")
+		}
+	}
+	if len(b.Count) != len(b.Omega) {
+		return Band{}, fmt.Errorf("invalid band: one count per frequency is needed")
+	}
+	resampled := newBand(grid)
+	for _, field := range [][2][]float64{{b.Mean, resampled.Mean}, {b.StdDev, resampled.StdDev}, {b.Lower, resampled.Lower}, {b.Upper, resampled.Upper}} {
+		values, err := Resample(b.Omega, field[0], grid)
+		if err != nil {
+			return Band{}, fmt.Errorf("invalid band: %w", err)
+		}
+		copy(field[1], values)
+	}
+	for i, w := range grid {
+		j, found := slices.BinarySearch(b.Omega, w)
+		switch {
+		case found:
+			resampled.Count[i] = b.Count[j]
+		case j > 0 && j < len(b.Omega) && !math.IsNaN(resampled.Mean[i]):
+			resampled.Count[i] = min(b.Count[j-1], b.Count[j])
+		}
+	}
+	return resampled, nil
+}
+
+// newBand allocates a band on a grid of frequencies.
+//
+// Parameters:
+//   - grid: Angular frequencies of the band [rad/s]
+//
+// Returns:
+//   - Band: The band, with zero values
+func newBand(grid []float64) Band {
+	return Band{
+		Omega:  slices.Clone(grid),
+		Mean:   make([]float64, len(grid)),
+		StdDev: make([]float64, len(grid)),
+		Lower:  make([]float64, len(grid)),
+		Upper:  make([]float64, len(grid)),
+		Count:  make([]int, len(grid)),
+	}
+}
+
+// percentile returns a quantile of sorted values, interpolated linearly between
+// them.
+//
+// Parameters:
+//   - sorted: The values, in increasing order (at least one)
+//   - level: The quantile, within [0, 1]
+//
+// Returns:
+//   - float64: The value of the quantile
+func percentile(sorted []float64, level float64) float64 {
+	position := level * float64(len(sorted)-1)
+	i := int(position)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	t := position - float64(i)
+	return sorted[i] + t*(sorted[i+1]-sorted[i])
+}
+
+// WriteBand writes a band as CSV, with the columns frequency [Hz], omega [rad/s],
+// mean, std_dev, lower and upper [m/s] and count, for plotting the band next to a
+// measured curve and its error bars.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - band: The band
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteBand(w io.Writer, band Band) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"frequency", "omega", "mean", "std_dev", "lower", "upper", "count"})
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for i, omega := range band.Omega {
+		writer.Write([]string{
+			format(omega / (2 * math.Pi)),
+			format(omega),
+			format(band.Mean[i]),
+			format(band.StdDev[i]),
+			format(band.Lower[i]),
+			format(band.Upper[i]),
+			strconv.Itoa(band.Count[i]),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,45 @@
+// Package masw compares measured surface wave dispersion curves, e.g. from MASW
+// (Multichannel Analysis of Surface Waves) field surveys, with the dispersion
+// curves computed by GoTrain, to validate a soil model against site data.
+//
+// Measured curves are read from CSV files with a header row, with the frequency
+// in a "frequency" [Hz] or "omega" [rad/s] column and the phase velocity in a
+// "phase_velocity" [m/s] column. Lines starting with # are comments:
+//
+//	# MASW survey, line 1
+//	frequency,phase_velocity
+//	5.0,93.5
+//	7.5,83.5
+//
+// The computed curve is interpolated linearly at the measured frequencies, and the
+// misfit is summarized by the bias (mean error, computed minus measured), the mean
+// absolute error, the root mean square error, the largest error and the mean
+// absolute percentage error. Measurements outside the computed curve, or where no
+// root was computed, are skipped and reported.
+//
+// # Uncertainty Bands
+//
+// Resample evaluates a computed curve on a grid of frequencies, e.g. those of a
+// measured curve. EnsembleBand resamples every curve of an ensemble, such as the
+// curves of the realizations of a soil_dispersion.RandomField, and summarizes them
+// per frequency by their mean, standard deviation and the percentiles enclosing a
+// confidence level, so that the band is compared directly with the error bars of
+// the measurements. Band.Resample moves a band to another grid, interpolating its
+// statistics, which is consistent for the fully correlated phase velocities of
+// neighbouring frequencies. WriteBand writes a band as CSV for plotting.
+//
+// # Usage Example
+//
+//	measured, err := masw.LoadCurve("testdata/masw/measured.csv")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	misfit, err := masw.CompareSoil(context.Background(), layers, measured)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("RMSE: %.1f m/s\n", misfit.RMSE)
+//
+// The masw command compares the soil profile of a configuration file with a
+// measured curve and writes the overlay as CSV for plotting.
+package masw
@@ -0,0 +1,281 @@
+package masw
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+// Curve is a measured dispersion curve, sorted by frequency.
+type Curve struct {
+	Omega         []float64 // Angular frequencies [rad/s]
+	PhaseVelocity []float64 // Phase velocities [m/s]
+}
+
+// Residual compares the measured and computed phase velocities at a frequency.
+type Residual struct {
+	Omega    float64 // Angular frequency [rad/s]
+	Measured float64 // Measured phase velocity [m/s]
+	Computed float64 // Computed phase velocity [m/s]
+}
+
+// Misfit summarizes the differences between a measured and a computed dispersion
+// curve. Errors are computed minus measured, so a positive bias means that the
+// model is too stiff.
+type Misfit struct {
+	Points    int        // Number of measurements compared
+	Bias      float64    // Mean error [m/s]
+	MAE       float64    // Mean absolute error [m/s]
+	RMSE      float64    // Root mean square error [m/s]
+	MaxError  float64    // Largest absolute error [m/s]
+	MAPE      float64    // Mean absolute percentage error [%]
+	Residuals []Residual // Measured and computed phase velocities of the compared measurements
+	Skipped   []float64  // Angular frequencies of the measurements that could not be compared [rad/s]
+}
+
+// LoadCurve reads a measured dispersion curve from a CSV file (see ParseCurve).
+//
+// Parameters:
+//   - path: Path to the CSV file
+//
+// Returns:
+//   - Curve: The measured dispersion curve
+//   - error: An error if the file cannot be read or is invalid
+func LoadCurve(path string) (Curve, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Curve{}, fmt.Errorf("failed to read measured dispersion curve: %v", err)
+	}
+	defer file.Close()
+
+	curve, err := ParseCurve(file)
+	if err != nil {
+		return Curve{}, fmt.Errorf("invalid measured dispersion curve %s: %v", path, err)
+	}
+	return curve, nil
+}
+
+// ParseCurve parses a measured dispersion curve from CSV data. The first row is a
+// header naming the columns: the frequency, either "frequency" [Hz] or "omega"
+// [rad/s], and "phase_velocity" [m/s]. Other columns are ignored. Rows may be in
+// any order; they are sorted by frequency.
+//
+// Parameters:
+//   - r: Reader of the CSV data
+//
+// Returns:
+//   - Curve: The measured dispersion curve
+//   - error: An error if the data is invalid
+func ParseCurve(r io.Reader) (Curve, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return Curve{}, fmt.Errorf("failed to read header: %v", err)
+	}
+	frequencyColumn, omegaColumn, velocityColumn := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "frequency":
+			frequencyColumn = i
+		case "omega":
+			omegaColumn = i
+		case "phase_velocity":
+			velocityColumn = i
+		}
+	}
+	if frequencyColumn < 0 && omegaColumn < 0 {
+		return Curve{}, fmt.Errorf("no frequency or omega column")
+	}
+	if velocityColumn < 0 {
+		return Curve{}, fmt.Errorf("no phase_velocity column")
+	}
+
+	var curve Curve
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Curve{}, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		var omega float64
+		if omegaColumn >= 0 {
+			omega, err = strconv.ParseFloat(strings.TrimSpace(record[omegaColumn]), 64)
+		} else {
+			omega, err = strconv.ParseFloat(strings.TrimSpace(record[frequencyColumn]), 64)
+			omega *= 2 * math.Pi
+		}
+		if err != nil || !(omega > 0) {
+			return Curve{}, fmt.Errorf("line %d: invalid frequency", line)
+		}
+		velocity, err := strconv.ParseFloat(strings.TrimSpace(record[velocityColumn]), 64)
+		if err != nil || !(velocity > 0) {
+			return Curve{}, fmt.Errorf("line %d: invalid phase velocity", line)
+		}
+		curve.Omega = append(curve.Omega, omega)
+		curve.PhaseVelocity = append(curve.PhaseVelocity, velocity)
+	}
+	if len(curve.Omega) == 0 {
+		return Curve{}, fmt.Errorf("no measurements")
+	}
+
+	sort.Sort(byOmega(curve))
+	for i := 1; i < len(curve.Omega); i++ {
+		if curve.Omega[i] == curve.Omega[i-1] {
+			return Curve{}, fmt.Errorf("duplicate frequency: %g rad/s", curve.Omega[i])
+		}
+	}
+	return curve, nil
+}
+
+// byOmega sorts the measurements of a curve by frequency.
+type byOmega Curve
+
+func (c byOmega) Len() int           { return len(c.Omega) }
+func (c byOmega) Less(i, j int) bool { return c.Omega[i] < c.Omega[j] }
+func (c byOmega) Swap(i, j int) {
+	c.Omega[i], c.Omega[j] = c.Omega[j], c.Omega[i]
+	c.PhaseVelocity[i], c.PhaseVelocity[j] = c.PhaseVelocity[j], c.PhaseVelocity[i]
+}
+
+// Compare overlays a measured dispersion curve on a computed one and computes the
+// misfit statistics. The computed curve is interpolated linearly at the measured
+// frequencies; measurements outside the computed frequency range, or next to a
+// frequency where no root was computed (NaN), are skipped.
+//
+// Parameters:
+//   - measured: The measured dispersion curve
+//   - omega: Angular frequencies of the computed curve [rad/s], in increasing order
+//   - computed: Computed phase velocities [m/s] (NaN where no root is found)
+//
+// Returns:
+//   - Misfit: The misfit statistics and residuals
+//   - error: An error if the computed curve is invalid or no measurement can be compared
+func Compare(measured Curve, omega []float64, computed []float64) (Misfit, error) {
+	var misfit Misfit
+	if len(omega) < 2 || len(omega) != len(computed) {
+		return misfit, fmt.Errorf("invalid computed dispersion curve: at least two frequencies and one phase velocity per frequency are needed")
+	}
+	for i := 1; i < len(omega); i++ {
+		if !(omega[i] > omega[i-1]) {
+			return misfit, fmt.Errorf("invalid computed dispersion curve: frequencies must be increasing")
+		}
+	}
+
+	var sum, sumAbs, sumSquares, sumRelative float64
+	for i, w := range measured.Omega {
+		v := interpolate(omega, computed, w)
+		if math.IsNaN(v) {
+			misfit.Skipped = append(misfit.Skipped, w)
+			continue
+		}
+		m := measured.PhaseVelocity[i]
+		e := v - m
+		sum += e
+		sumAbs += math.Abs(e)
+		sumSquares += e * e
+		sumRelative += math.Abs(e) / m
+		misfit.MaxError = math.Max(misfit.MaxError, math.Abs(e))
+		misfit.Residuals = append(misfit.Residuals, Residual{Omega: w, Measured: m, Computed: v})
+	}
+
+	misfit.Points = len(misfit.Residuals)
+	if misfit.Points == 0 {
+		return misfit, fmt.Errorf("no measurement within the computed dispersion curve")
+	}
+	n := float64(misfit.Points)
+	misfit.Bias = sum / n
+	misfit.MAE = sumAbs / n
+	misfit.RMSE = math.Sqrt(sumSquares / n)
+	misfit.MAPE = 100 * sumRelative / n
+	return misfit, nil
+}
+
+// CompareSoil computes the dispersion curve of a soil profile at the measured
+// frequencies and compares it with the measured curve (see Compare).
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - layers: The soil profile, with its wave speeds computed
+//   - measured: The measured dispersion curve
+//
+// Returns:
+//   - Misfit: The misfit statistics and residuals
+//   - error: An error if the computation is cancelled or no measurement can be compared
+func CompareSoil(ctx context.Context, layers []soil_dispersion.Layer, measured Curve) (Misfit, error) {
+	if len(measured.Omega) < 2 {
+		return Misfit{}, fmt.Errorf("at least two measurements are needed")
+	}
+	computed, err := soil_dispersion.SoilDispersionContext(ctx, layers, measured.Omega)
+	if err != nil {
+		return Misfit{}, fmt.Errorf("error calculating soil dispersion: %w", err)
+	}
+	return Compare(measured, measured.Omega, computed)
+}
+
+// interpolate evaluates a curve linearly at a frequency.
+//
+// Parameters:
+//   - omega: Angular frequencies of the curve [rad/s], in increasing order
+//   - velocity: Phase velocities of the curve [m/s] (NaN where no root is found)
+//   - w: Angular frequency at which the curve is evaluated [rad/s]
+//
+// Returns:
+//   - float64: The phase velocity, or NaN outside the curve or next to a NaN value
+func interpolate(omega []float64, velocity []float64, w float64) float64 {
+	i := sort.SearchFloat64s(omega, w)
+	switch {
+	case i == len(omega):
+		return math.NaN()
+	case omega[i] == w:
+		return velocity[i]
+	case i == 0:
+		return math.NaN()
+	}
+	t := (w - omega[i-1]) / (omega[i] - omega[i-1])
+	return velocity[i-1] + t*(velocity[i]-velocity[i-1])
+}
+
+// WriteOverlay writes the residuals of a comparison as CSV, with the columns
+// frequency [Hz], omega [rad/s], measured, computed and error [m/s], for plotting
+// the measured curve over the computed one.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - misfit: The comparison
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteOverlay(w io.Writer, misfit Misfit) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"frequency", "omega", "measured", "computed", "error"})
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, r := range misfit.Residuals {
+		writer.Write([]string{
+			format(r.Omega / (2 * math.Pi)),
+			format(r.Omega),
+			format(r.Measured),
+			format(r.Computed),
+			format(r.Computed - r.Measured),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,192 @@
+package masw
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+)
+
+const TOL = 1e-9
+
+// Test parsing measured curves in Hz and rad/s, in any order.
+func TestParseCurve(t *testing.T) {
+	curve, err := ParseCurve(strings.NewReader("# survey\nphase_velocity, frequency, quality\n150, 20, good\n200, 10, good\n"))
+	if err != nil {
+		t.Fatalf("ParseCurve failed: %v", err)
+	}
+	if len(curve.Omega) != 2 || math.Abs(curve.Omega[0]-20*math.Pi) > TOL || curve.PhaseVelocity[0] != 200 {
+		t.Errorf("unexpected curve: %+v", curve)
+	}
+
+	curve, err = ParseCurve(strings.NewReader("omega,phase_velocity\n62.8,200\n"))
+	if err != nil || curve.Omega[0] != 62.8 {
+		t.Errorf("unexpected curve in rad/s: %+v, %v", curve, err)
+	}
+
+	for name, data := range map[string]string{
+		"no frequency":       "f,phase_velocity\n1,100\n",
+		"no velocity":        "frequency,v\n1,100\n",
+		"invalid frequency":  "frequency,phase_velocity\n-1,100\n",
+		"invalid velocity":   "frequency,phase_velocity\n1,x\n",
+		"duplicate":          "frequency,phase_velocity\n1,100\n1,110\n",
+		"no measurements":    "frequency,phase_velocity\n",
+		"inconsistent field": "frequency,phase_velocity\n1\n",
+	} {
+		if _, err := ParseCurve(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// Test the misfit statistics against a hand calculation.
+func TestCompare(t *testing.T) {
+	measured := Curve{Omega: []float64{5, 15, 25, 40}, PhaseVelocity: []float64{100, 100, 100, 100}}
+	omega := []float64{0, 10, 20, 30}
+	computed := []float64{110, 90, math.NaN(), 100}
+
+	// 5: error 0; 15 and 25: next to a NaN (skipped); 40: outside the curve (skipped)
+	misfit, err := Compare(measured, omega, computed)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if misfit.Points != 1 || len(misfit.Skipped) != 3 || misfit.RMSE != 0 {
+		t.Errorf("unexpected misfit: %+v", misfit)
+	}
+
+	// Errors at 5, 15, 25 and 30: 0, -15, -10 and 0
+	computed[2] = 80
+	measured.Omega[3] = 30
+	misfit, err = Compare(measured, omega, computed)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if misfit.Points != 4 || len(misfit.Skipped) != 0 {
+		t.Fatalf("unexpected number of points: %+v", misfit)
+	}
+	if math.Abs(misfit.Bias+6.25) > TOL || math.Abs(misfit.MAE-6.25) > TOL || math.Abs(misfit.MaxError-15) > TOL ||
+		math.Abs(misfit.RMSE-math.Sqrt(325.0/4)) > TOL || math.Abs(misfit.MAPE-6.25) > TOL {
+		t.Errorf("unexpected misfit: %+v", misfit)
+	}
+
+	if _, err := Compare(Curve{Omega: []float64{50}, PhaseVelocity: []float64{100}}, omega, computed); err == nil {
+		t.Error("expected an error when no measurement can be compared")
+	}
+	if _, err := Compare(measured, []float64{0, 0}, []float64{1, 1}); err == nil {
+		t.Error("expected an error for frequencies that are not increasing")
+	}
+}
+
+// Test comparing the synthetic survey with the soil profile it was generated from.
+func TestCompareSoil(t *testing.T) {
+	measured, err := LoadCurve("../../testdata/masw/measured.csv")
+	if err != nil {
+		t.Fatalf("LoadCurve failed: %v", err)
+	}
+
+	// Soil profile of testdata/sample_config.yaml
+	layers := []soil_dispersion.Layer{
+		{Density: 2000, YoungsModulus: 30e6, PoissonRatio: 0.35, Thickness: 2},
+		{Density: 2000, YoungsModulus: 40e6, PoissonRatio: 0.35, Thickness: 4},
+		{Density: 2000, YoungsModulus: 75e6, PoissonRatio: 0.40, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+
+	misfit, err := CompareSoil(context.Background(), layers, measured)
+	if err != nil {
+		t.Fatalf("CompareSoil failed: %v", err)
+	}
+
+	// The survey scatters by up to 3 % around the computed curve
+	if misfit.Points != len(measured.Omega) || misfit.MAPE > 3 || misfit.MAPE < 1 || math.Abs(misfit.Bias) > 1 {
+		t.Errorf("unexpected misfit: points %d, MAPE %v, bias %v", misfit.Points, misfit.MAPE, misfit.Bias)
+	}
+
+	// A stiffer profile is biased towards higher velocities
+	for i := range layers {
+		layers[i].YoungsModulus *= 1.5
+		layers[i].WaveSpeed()
+	}
+	stiff, err := CompareSoil(context.Background(), layers, measured)
+	if err != nil {
+		t.Fatalf("CompareSoil failed: %v", err)
+	}
+	if stiff.Bias <= 0 || stiff.RMSE <= misfit.RMSE {
+		t.Errorf("expected a positive bias and a larger error for a stiffer profile: %+v", stiff)
+	}
+}
+
+// Test writing the overlay CSV.
+func TestWriteOverlay(t *testing.T) {
+	var b strings.Builder
+	misfit := Misfit{Residuals: []Residual{{Omega: 2 * math.Pi, Measured: 100, Computed: 110}}}
+	if err := WriteOverlay(&b, misfit); err != nil {
+		t.Fatalf("WriteOverlay failed: %v", err)
+	}
+	expected := "frequency,omega,measured,computed,error\n1,6.283185307179586,100,110,10\n"
+	if b.String() != expected {
+		t.Errorf("unexpected overlay:\n%s\nwant:\n%s", b.String(), expected)
+	}
+}
+
+// Test the band of an ensemble of curves against a hand calculation.
+func TestEnsembleBand(t *testing.T) {
+	omega := []float64{10, 20, 30}
+	curves := [][]float64{
+		{100, 90, 80},
+		{110, 100, math.NaN()},
+		{120, 110, 100},
+	}
+	// 15: 95, 105 and 115; 25: 85 and 105 (the second curve is next to a NaN); 40: outside
+	band, err := EnsembleBand(omega, curves, []float64{15, 25, 40}, 0.5)
+	if err != nil {
+		t.Fatalf("EnsembleBand failed: %v", err)
+	}
+	if band.Mean[0] != 105 || math.Abs(band.StdDev[0]-10) > TOL || band.Lower[0] != 100 || band.Upper[0] != 110 || band.Count[0] != 3 {
+		t.Errorf("unexpected band at 15 rad/s: mean %v, std %v, bounds [%v, %v], count %d",
+			band.Mean[0], band.StdDev[0], band.Lower[0], band.Upper[0], band.Count[0])
+	}
+	if band.Mean[1] != 95 || band.Lower[1] != 90 || band.Upper[1] != 100 || band.Count[1] != 2 {
+		t.Errorf("unexpected band at 25 rad/s: mean %v, bounds [%v, %v], count %d", band.Mean[1], band.Lower[1], band.Upper[1], band.Count[1])
+	}
+	if !math.IsNaN(band.Mean[2]) || band.Count[2] != 0 {
+		t.Errorf("expected no phase velocity outside the curves, got %v", band.Mean[2])
+	}
+
+	// Resampling the band interpolates its statistics
+	resampled, err := band.Resample([]float64{15, 20, 25})
+	if err != nil {
+		t.Fatalf("Resample failed: %v", err)
+	}
+	if resampled.Mean[1] != 100 || resampled.Lower[1] != 95 || resampled.Upper[1] != 105 || resampled.Count[1] != 2 {
+		t.Errorf("unexpected resampled band at 20 rad/s: mean %v, bounds [%v, %v], count %d",
+			resampled.Mean[1], resampled.Lower[1], resampled.Upper[1], resampled.Count[1])
+	}
+
+	single, err := EnsembleBand(omega, curves[:1], omega, 0.9)
+	if err != nil || single.StdDev[1] != 0 || single.Lower[1] != 90 || single.Upper[1] != 90 {
+		t.Errorf("expected a band of zero width for a single curve, got %+v (%v)", single, err)
+	}
+
+	var b strings.Builder
+	if err := WriteBand(&b, single); err != nil || !strings.HasPrefix(b.String(), "frequency,omega,mean,std_dev,lower,upper,count\n") {
+		t.Errorf("unexpected band CSV:\n%s (%v)", b.String(), err)
+	}
+
+	for name, args := range map[string]struct {
+		curves     [][]float64
+		confidence float64
+	}{
+		"no curves":          {nil, 0.9},
+		"invalid confidence": {curves, 1},
+		"invalid curve":      {[][]float64{{100}}, 0.9},
+	} {
+		if _, err := EnsembleBand(omega, args.curves, omega, args.confidence); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
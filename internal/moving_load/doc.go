@@ -0,0 +1,59 @@
+// Package moving_load computes the steady-state deflection and bending moment of the
+// rail under a load moving at constant speed, as a function of the speed, with a 2.5D
+// coupled track-soil model. Where the critical speed analysis only reports the speed at
+// which the track and soil dispersion curves intersect, the deflection versus speed
+// shows the whole resonance curve: how fast the response grows towards the critical
+// speed and how it decays beyond it.
+//
+// In the frame of the moving load, the component of the deflection with wavenumber
+// k oscillates with the angular frequency ω = k v. The rail deflection is the
+// inverse Fourier transform of the receptance of the track, R(k, kv) (see
+// track_dispersion.ReceptanceTrack), computed numerically over logarithmically
+// spaced wavenumbers:
+//
+//	w(x) = P/π ∫₀^∞ Re(R(k, kv) e^{ikx}) dk
+//
+// The track rests on an equivalent dynamic stiffness of the soil: the static
+// stiffness of a strip of the track width on the layer reached by the wavelength,
+// reduced as the phase velocity v of the component approaches the soil phase
+// velocity c at its frequency, with hysteretic damping ζ:
+//
+//	K(k, ω) = K_static(k) (1 - (v/c(ω))² + 2iζ)
+//
+// so that the soil loses its stiffness when the load moves at the speed of the
+// surface waves. The amplification is the largest deflection relative to the
+// quasi-static deflection.
+//
+// The bending moment of the rail follows from its curvature, M = -EI d²w/dx², and its
+// bending stress from the section modulus of the rail, σ = M / W, so the moment
+// amplification is also the amplification of the rail stress. WriteCSV writes them
+// as the speed-amplification table required for the structural verification of
+// the rail.
+//
+// GroundVibration estimates the free-field vibration of the ground at distances from
+// the track for an operating speed: the velocity of the ground at the edge of the
+// track, -v dw/dx, is propagated per frequency as a surface wave with geometric
+// spreading and the material damping of the soil along the path, at the soil phase
+// velocity, and reported as peak velocity and velocity level (dB re 1e-9 m/s).
+//
+// IrregularityResponse computes the dynamic wheel-rail force of a wheel rolling over
+// the irregularity of the rail (see GermanIrregularity) versus speed: the unsprung
+// mass, the contact spring and the rail act in series, with the receptance of the
+// rail under a harmonic load moving with the wheel, so the force grows as the track
+// and soil soften near the critical speed.
+//
+// # Usage Example
+//
+//	points, err := moving_load.SpeedResponse(ctx, track, layers, omega, soilPhaseVelocity,
+//		moving_load.Parameters{Load: 1e5, Speeds: speeds, TrackWidth: 2.5, RailSectionModulus: 3.77e-4})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, p := range points {
+//		fmt.Printf("%.0f m/s: %.2f mm (x%.2f), %.0f MPa (x%.2f)\n", p.Speed,
+//			p.Deflection*1e3, p.Amplification, p.Stress/1e6, p.MomentAmplification)
+//	}
+//
+// The critical speed analysis computes the curve when the configuration has a
+// moving_load section, and writes it as CSV next to the result file.
+package moving_load
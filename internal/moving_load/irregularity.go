@@ -0,0 +1,173 @@
+package moving_load
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	track_dispersion "github.com/PlatypusBytes/GoTrain/internal/track_dispersion"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// Roughness coefficients A_v of the German spectra of the vertical irregularity of the
+// rail [m·rad] (see GermanIrregularity).
+const (
+	GermanLowDisturbance  = 4.032e-7 // Track of high quality, for high-speed lines
+	GermanHighDisturbance = 1.080e-6 // Track of lower quality
+)
+
+// Cut-off wavenumbers of the German spectra [rad/m].
+const (
+	germanCutoff = 0.8246 // Ω_c
+	germanRoll   = 0.0206 // Ω_r
+)
+
+// Irregularity is the power spectral density of the vertical irregularity of the rail.
+type Irregularity struct {
+	Wavenumbers []float64 // Wavenumbers Ω of the irregularity [rad/m], increasing
+	PSD         []float64 // One-sided power spectral density S(Ω) [m²/(rad/m)]
+}
+
+// GermanIrregularity returns the German spectrum of the vertical irregularity of the
+// rail between two wavelengths,
+//
+//	S(Ω) = A_v Ω_c² / ((Ω² + Ω_r²) (Ω² + Ω_c²))
+//
+// at logarithmically spaced wavenumbers.
+//
+// Parameters:
+//   - roughness: Roughness coefficient A_v [m·rad], e.g. GermanLowDisturbance
+//   - minWavelength: Shortest wavelength of the irregularity [m]
+//   - maxWavelength: Longest wavelength of the irregularity [m]
+//   - points: Number of wavenumbers
+//
+// Returns:
+//   - Irregularity: The spectrum
+func GermanIrregularity(roughness, minWavelength, maxWavelength float64, points int) Irregularity {
+	exponents := math_utils.Linspace(math.Log(2*math.Pi/maxWavelength), math.Log(2*math.Pi/minWavelength), points)
+	irregularity := Irregularity{Wavenumbers: make([]float64, points), PSD: make([]float64, points)}
+	for i, e := range exponents {
+		k := math.Exp(e)
+		irregularity.Wavenumbers[i] = k
+		irregularity.PSD[i] = roughness * germanCutoff * germanCutoff / ((k*k + germanRoll*germanRoll) * (k*k + germanCutoff*germanCutoff))
+	}
+	return irregularity
+}
+
+// Wheel describes the wheel rolling over the irregularity of the rail.
+type Wheel struct {
+	UnsprungMass     float64 // Unsprung mass on the rail: half of the mass of a wheelset [kg]
+	ContactStiffness float64 // Linearized Hertzian stiffness of the wheel-rail contact [N/m]
+}
+
+// IrregularityPoint is the dynamic wheel-rail force caused by the irregularity of the
+// rail at a speed.
+type IrregularityPoint struct {
+	Speed         float64 // Speed of the wheel [m/s]
+	RMSForce      float64 // Root mean square of the dynamic wheel-rail force [N]
+	DynamicFactor float64 // RMS force relative to the static wheel load
+	PeakOmega     float64 // Angular frequency of the largest spectral density of the force [rad/s]
+	PeakPSD       float64 // Largest spectral density of the force [N²/(rad/s)]
+}
+
+// IrregularityResponse computes the spectrum of the dynamic wheel-rail force of a wheel
+// rolling over an irregularity of the rail, for every speed of the parameters. At a
+// speed v, the irregularity of wavenumber Ω excites the contact at the angular
+// frequency ω = Ω v, where the wheel, the contact spring and the rail act in series:
+//
+//	F(ω) = r(ω) / (α_w(ω) + 1/k_H + α_r(ω, v)),    α_w = -1 / (m ω²)
+//
+// The receptance of the rail α_r is that of the coupled track-soil model under a
+// harmonic load moving with the wheel (see SpeedResponse), integrated over the
+// wavenumbers k of the track, whose components oscillate at ω ± k v:
+//
+//	α_r(ω, v) = 1/2π ∫₀^∞ R(k, ω - k v) + R(k, ω + k v) dk
+//
+// so the force includes the softening of the track and soil as the speed approaches
+// the critical speed. The spectral density of the force is S_F(ω) = S(Ω) / (v |α|²),
+// and its root mean square the square root of its integral over the irregularity.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - track: The track model
+//   - layers: The soil profile, with its wave speeds computed
+//   - omega: Angular frequencies of the soil dispersion curve [rad/s]
+//   - soilPhaseVelocity: Soil dispersion curve [m/s] (NaN where no root is found)
+//   - params: The static wheel load, the speeds, the width of the track and the damping of the soil
+//   - wheel: The wheel
+//   - irregularity: The spectrum of the irregularity of the rail
+//
+// Returns:
+//   - []IrregularityPoint: The force at every speed, in the order of params.Speeds
+//   - error: An error if the parameters are invalid, the soil dispersion curve has
+//     fewer than two points or the context is cancelled
+func IrregularityResponse(ctx context.Context, track track_dispersion.ReceptanceTrack, layers []soil_dispersion.Layer,
+	omega []float64, soilPhaseVelocity []float64, params Parameters, wheel Wheel, irregularity Irregularity) ([]IrregularityPoint, error) {
+
+	if !(wheel.UnsprungMass > 0) || !(wheel.ContactStiffness > 0) {
+		return nil, fmt.Errorf("invalid wheel: the unsprung mass and contact stiffness must be positive")
+	}
+	if len(irregularity.Wavenumbers) < 2 || len(irregularity.PSD) != len(irregularity.Wavenumbers) {
+		return nil, fmt.Errorf("invalid irregularity: at least two wavenumbers are required, each with a spectral density")
+	}
+	for i, k := range irregularity.Wavenumbers {
+		if !(k > 0) || (i > 0 && !(k > irregularity.Wavenumbers[i-1])) || !(irregularity.PSD[i] >= 0) {
+			return nil, fmt.Errorf("invalid irregularity: wavenumbers must be positive and increasing, and spectral densities not negative")
+		}
+	}
+	for _, v := range params.Speeds {
+		if !(v > 0) {
+			return nil, fmt.Errorf("invalid irregularity response: speeds must be positive, got %g", v)
+		}
+	}
+	soil, err := newSoilModel(layers, omega, soilPhaseVelocity, params)
+	if err != nil {
+		return nil, err
+	}
+	wavenumbers, weights := integrationGrid()
+
+	// Receptance of the track at negative frequencies, of a real load
+	receptance := func(k, w float64) complex128 {
+		if w < 0 {
+			return cmplx.Conj(track.Receptance(-w, k, soil.stiffness(k, -w)))
+		}
+		return track.Receptance(w, k, soil.stiffness(k, w))
+	}
+
+	points := math_utils.ParallelMap(func(speed float64) IrregularityPoint {
+		p := IrregularityPoint{Speed: speed}
+		if ctx.Err() != nil {
+			return p
+		}
+		density := make([]float64, len(irregularity.Wavenumbers))
+		for j, wavenumber := range irregularity.Wavenumbers {
+			w := wavenumber * speed
+			var rail complex128
+			for i, k := range wavenumbers {
+				rail += complex(weights[i], 0) * (receptance(k, w-k*speed) + receptance(k, w+k*speed))
+			}
+			rail /= complex(2*math.Pi, 0)
+			total := rail + complex(1/wheel.ContactStiffness-1/(wheel.UnsprungMass*w*w), 0)
+			density[j] = irregularity.PSD[j] / (real(total)*real(total) + imag(total)*imag(total))
+			if psd := density[j] / speed; psd > p.PeakPSD {
+				p.PeakOmega, p.PeakPSD = w, psd
+			}
+		}
+
+		// Integral of S_F over ω = Ω v, that is of S(Ω) / |α|² over Ω
+		variance := 0.0
+		for j := range len(density) - 1 {
+			variance += (density[j] + density[j+1]) / 2 * (irregularity.Wavenumbers[j+1] - irregularity.Wavenumbers[j])
+		}
+		p.RMSForce = math.Sqrt(variance)
+		p.DynamicFactor = p.RMSForce / math.Abs(params.Load)
+		return p
+	}, params.Speeds, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
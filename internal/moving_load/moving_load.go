@@ -0,0 +1,333 @@
+package moving_load
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	track_dispersion "github.com/PlatypusBytes/GoTrain/internal/track_dispersion"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// DefaultDamping is the hysteretic damping ratio of the soil when none is given.
+const DefaultDamping = 0.05
+
+// Integration of the response over the wavenumbers: logarithmically spaced
+// wavenumbers, fine enough to resolve the resonance of a lightly damped track.
+const (
+	minWavenumber    = 1e-3 // Smallest wavenumber [1/m]
+	maxWavenumber    = 200  // Largest wavenumber [1/m]
+	wavenumberPoints = 4000 // Number of wavenumbers
+)
+
+// quasiStaticSpeed is the speed of the quasi-static response, the reference of the
+// dynamic amplification [m/s].
+const quasiStaticSpeed = 0.01
+
+// positions are the distances behind (negative) and ahead of the load where the
+// deflection of the rail is evaluated [m]. Near the critical speed, the largest
+// deflection trails the load.
+var positions = math_utils.Linspace(-15, 15, 121)
+
+// Parameters describes the moving load and the speeds at which its response is computed.
+type Parameters struct {
+	Load       float64   // Moving load on the rail [N]
+	Speeds     []float64 // Speeds of the load [m/s]
+	TrackWidth float64   // Width of the whole track on the soil, shared by the two rails [m]
+	Damping    float64   // Hysteretic damping ratio of the soil (DefaultDamping when <= 0)
+
+	RailSectionModulus float64 // Section modulus of the rail [m³] (the stress is not computed when zero)
+}
+
+// Point is the steady-state response of the track to the moving load at a speed.
+type Point struct {
+	Speed         float64 // Speed of the load [m/s]
+	Deflection    float64 // Largest deflection of the rail [m]
+	Amplification float64 // Deflection relative to the quasi-static deflection
+
+	BendingMoment       float64 // Largest bending moment of the rail [N·m]
+	MomentAmplification float64 // Bending moment relative to the quasi-static bending moment, also the amplification of the rail stress
+	Stress              float64 // Largest bending stress of the rail [Pa] (zero without a section modulus)
+}
+
+// soilModel is the equivalent dynamic stiffness of the soil under the track.
+type soilModel struct {
+	layers        []soil_dispersion.Layer // Soil profile
+	width         float64                 // Width of the track on the soil [m]
+	damping       float64                 // Hysteretic damping ratio
+	phaseVelocity *math_utils.Interp1D    // Soil phase velocity [m/s] versus angular frequency [rad/s]
+}
+
+// SpeedResponse computes the steady-state deflection and bending moment of the rail
+// under a load moving at constant speed, for every speed of the parameters, with the
+// coupled track-soil model: the track model of the critical speed analysis resting on
+// the equivalent dynamic stiffness of the soil (see the package documentation).
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - track: The track model (track_dispersion.BallastTrackParameters or SlabTrackParameters)
+//   - layers: The soil profile, with its wave speeds computed
+//   - omega: Angular frequencies of the soil dispersion curve [rad/s]
+//   - soilPhaseVelocity: Soil dispersion curve [m/s] (NaN where no root is found)
+//   - params: The load, its speeds, the width of the track and the damping of the soil
+//
+// Returns:
+//   - []Point: The response at every speed, in the order of params.Speeds
+//   - error: An error if the parameters are invalid, the soil dispersion curve has
+//     fewer than two points or the context is cancelled
+func SpeedResponse(ctx context.Context, track track_dispersion.ReceptanceTrack, layers []soil_dispersion.Layer,
+	omega []float64, soilPhaseVelocity []float64, params Parameters) ([]Point, error) {
+
+	if params.RailSectionModulus < 0 {
+		return nil, fmt.Errorf("invalid moving load: the rail section modulus must not be negative")
+	}
+	for _, v := range params.Speeds {
+		if !(v > 0) {
+			return nil, fmt.Errorf("invalid moving load: speeds must be positive, got %g", v)
+		}
+	}
+	soil, err := newSoilModel(layers, omega, soilPhaseVelocity, params)
+	if err != nil {
+		return nil, err
+	}
+	wavenumbers, weights := integrationGrid()
+
+	// Rail deflection and bending moment of the load moving at a speed: in the frame
+	// of the load, the component of wavenumber k has the angular frequency k v
+	ei := track.RailBendingStiffness()
+	response := func(speed float64) (float64, float64) {
+		w, m := railResponse(wavenumbers, weights, ei, func(k float64) complex128 {
+			omegaK := k * speed
+			return track.Receptance(omegaK, k, soil.stiffness(k, omegaK))
+		})
+		return math.Abs(params.Load) * w, math.Abs(params.Load) * m
+	}
+
+	staticDeflection, staticMoment := response(quasiStaticSpeed)
+	points := math_utils.ParallelMap(func(speed float64) Point {
+		if ctx.Err() != nil {
+			return Point{Speed: speed}
+		}
+		w, m := response(speed)
+		p := Point{
+			Speed:               speed,
+			Deflection:          w,
+			Amplification:       w / staticDeflection,
+			BendingMoment:       m,
+			MomentAmplification: m / staticMoment,
+		}
+		if params.RailSectionModulus > 0 {
+			p.Stress = m / params.RailSectionModulus
+		}
+		return p
+	}, params.Speeds, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// newSoilModel creates the equivalent soil stiffness of a profile and its dispersion curve.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - omega: Angular frequencies of the soil dispersion curve [rad/s]
+//   - soilPhaseVelocity: Soil dispersion curve [m/s] (NaN where no root is found)
+//   - params: The load, the width of the track and the damping of the soil
+//
+// Returns:
+//   - soilModel: The soil model
+//   - error: An error if the load, the track width or the soil layers are invalid, or
+//     the dispersion curve has fewer than two points
+func newSoilModel(layers []soil_dispersion.Layer, omega []float64, soilPhaseVelocity []float64, params Parameters) (soilModel, error) {
+	if params.Load == 0 {
+		return soilModel{}, fmt.Errorf("invalid moving load: the load must not be zero")
+	}
+	if params.TrackWidth <= 0 {
+		return soilModel{}, fmt.Errorf("invalid moving load: the track width must be positive")
+	}
+	if len(layers) == 0 {
+		return soilModel{}, fmt.Errorf("invalid moving load: no soil layers")
+	}
+
+	var x, y []float64
+	for i, c := range soilPhaseVelocity {
+		if i < len(omega) && !math.IsNaN(c) && c > 0 {
+			x = append(x, omega[i])
+			y = append(y, c)
+		}
+	}
+	phaseVelocity, err := math_utils.NewInterp1D(x, y, math_utils.InterpLinear, math_utils.ExtrapolateClamp)
+	if err != nil {
+		return soilModel{}, fmt.Errorf("invalid soil dispersion curve for the moving load: %v", err)
+	}
+
+	damping := params.Damping
+	if damping <= 0 {
+		damping = DefaultDamping
+	}
+	return soilModel{layers: layers, width: params.TrackWidth, damping: damping, phaseVelocity: phaseVelocity}, nil
+}
+
+// stiffness returns the equivalent dynamic stiffness of the soil under the track for
+// a wavenumber and angular frequency. The static stiffness of a strip on the soil is
+// reduced by the ratio of the phase velocity of the component to the phase velocity
+// of the soil at its frequency, so that it vanishes when the load moves at the speed
+// of the surface waves, and hysteretic damping proportional to the static stiffness
+// is added. The track models describe one rail, which carries half of the stiffness
+// of the strip under the whole track.
+//
+// Parameters:
+//   - wavenumber: Wavenumber [1/m] (positive)
+//   - omega: Angular frequency [rad/s]
+//
+// Returns:
+//   - complex128: The stiffness per unit length of rail [N/m²]
+func (s soilModel) stiffness(wavenumber float64, omega float64) complex128 {
+	c, _ := s.phaseVelocity.At(math.Abs(omega))
+	ratio := omega / wavenumber / c
+	k := staticStiffness(s.layers, s.width, wavenumber) / 2
+	return complex(k*(1-ratio*ratio), 2*s.damping*k)
+}
+
+// staticStiffness returns the static stiffness per unit length of a strip of width B
+// loaded harmonically along its length with wavenumber k:
+//
+//	K(k) = π E* / (2 ln(1 + 4 / (k B)))
+//
+// which tends to the logarithmic stiffness of a long strip for small k B and to
+// π E* k B / 8 for short wavelengths. E* = E / (1 - ν²) is the plane strain modulus
+// of the layer at the depth of half a wavelength, which the deformation reaches.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - width: Width of the strip B [m]
+//   - wavenumber: Wavenumber k [1/m] (positive)
+//
+// Returns:
+//   - float64: The stiffness [N/m²]
+func staticStiffness(layers []soil_dispersion.Layer, width float64, wavenumber float64) float64 {
+	depth := math.Pi / wavenumber
+	layer := layers[len(layers)-1]
+	top := 0.0
+	for _, l := range layers {
+		if depth < top+l.Thickness {
+			layer = l
+			break
+		}
+		top += l.Thickness
+	}
+	modulus := layer.YoungsModulus / (1 - layer.PoissonRatio*layer.PoissonRatio)
+	return math.Pi * modulus / (2 * math.Log(1+4/(wavenumber*width)))
+}
+
+// integrationGrid returns the wavenumbers of the integration over the wavenumbers and
+// their trapezoidal weights.
+//
+// Returns:
+//   - []float64: The wavenumbers [1/m], logarithmically spaced
+//   - []float64: The weights [1/m]
+func integrationGrid() ([]float64, []float64) {
+	exponents := math_utils.Linspace(math.Log(minWavenumber), math.Log(maxWavenumber), wavenumberPoints)
+	wavenumbers := make([]float64, len(exponents))
+	for i, e := range exponents {
+		wavenumbers[i] = math.Exp(e)
+	}
+	weights := make([]float64, len(wavenumbers))
+	for i := range len(wavenumbers) - 1 {
+		h := (wavenumbers[i+1] - wavenumbers[i]) / 2
+		weights[i] += h
+		weights[i+1] += h
+	}
+	return wavenumbers, weights
+}
+
+// railResponse returns the largest displacement and bending moment of the rail along
+// the positions around a unit load, from the receptance of the track in the frame of
+// the load:
+//
+//	w(x) = 1/π ∫₀^∞ Re(R(k) e^{ikx}) dk
+//	M(x) = -EI w''(x) = EI/π ∫₀^∞ Re(k² R(k) e^{ikx}) dk
+//
+// using the symmetry R(-k) = conj(R(k)) of a real load. Beyond the largest
+// wavenumber K, the rail bends as a free beam (k² R ≈ 1 / (EI k²)): the truncated
+// tail of the moment, 1 / (π K), is added under the load, where it does not cancel
+// out by oscillation.
+//
+// Parameters:
+//   - wavenumbers: Wavenumbers of the integration [1/m]
+//   - weights: Weights of the wavenumbers [1/m]
+//   - ei: Bending stiffness of the rail [N·m²]
+//   - receptance: Receptance of the rail per wavenumber [m/N]
+//
+// Returns:
+//   - float64: The largest absolute displacement per unit force [m/N]
+//   - float64: The largest absolute bending moment per unit force [N·m/N]
+func railResponse(wavenumbers []float64, weights []float64, ei float64, receptance func(k float64) complex128) (float64, float64) {
+	values := make([]complex128, len(wavenumbers))
+	for i, k := range wavenumbers {
+		values[i] = receptance(k) * complex(weights[i], 0)
+	}
+	tail := 1 / wavenumbers[len(wavenumbers)-1]
+
+	deflection, moment := 0.0, 0.0
+	for _, x := range positions {
+		w, curvature := 0.0, 0.0
+		for i, k := range wavenumbers {
+			sin, cos := math.Sincos(k * x)
+			v := real(values[i])*cos - imag(values[i])*sin
+			w += v
+			curvature += k * k * v
+		}
+		m := ei * curvature
+		if x == 0 {
+			m += tail
+		}
+		deflection = math.Max(deflection, math.Abs(w/math.Pi))
+		moment = math.Max(moment, math.Abs(m/math.Pi))
+	}
+	return deflection, moment
+}
+
+// csvColumns are the columns of the speed-amplification table written by WriteCSV.
+var csvColumns = []string{"speed", "speed_ratio", "deflection", "amplification", "bending_moment", "moment_amplification", "stress"}
+
+// WriteCSV writes the response of the track as a speed-amplification table in CSV,
+// with the columns speed [m/s], speed_ratio (speed over the critical speed),
+// deflection [m], amplification, bending_moment [N·m], moment_amplification (also
+// the amplification of the rail stress) and stress [Pa], for plotting the resonance
+// curve and the structural verification of the rail. The speed ratio is empty
+// without a critical speed, and the stress without a rail section modulus.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - points: The response at every speed
+//   - criticalVelocity: The critical speed [m/s] (zero when unknown)
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteCSV(w io.Writer, points []Point, criticalVelocity float64) error {
+	writer := csv.NewWriter(w)
+	writer.Write(csvColumns)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, p := range points {
+		row := []string{format(p.Speed), "", format(p.Deflection), format(p.Amplification),
+			format(p.BendingMoment), format(p.MomentAmplification), ""}
+		if criticalVelocity > 0 {
+			row[1] = format(p.Speed / criticalVelocity)
+		}
+		if p.Stress != 0 {
+			row[6] = format(p.Stress)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,235 @@
+package moving_load
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	track_dispersion "github.com/PlatypusBytes/GoTrain/internal/track_dispersion"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// Test the integration over the wavenumbers against the static deflection and bending
+// moment of a beam on a Winkler foundation, w = P β / (2 k) and M = P / (4 β), with
+// β = (k / (4 EI))^(1/4).
+func TestRailResponseWinkler(t *testing.T) {
+	ei := 6.4e6
+	for _, foundation := range []float64{1e7, 5e7, 2e8} {
+		wavenumbers, weights := integrationGrid()
+		w, m := railResponse(wavenumbers, weights, ei, func(k float64) complex128 {
+			return complex(1/(ei*math.Pow(k, 4)+foundation), 0)
+		})
+
+		beta := math.Pow(foundation/(4*ei), 0.25)
+		expected := beta / (2 * foundation)
+		if math.Abs(w-expected) > 1e-3*expected {
+			t.Errorf("foundation %g: expected deflection %g, got %g", foundation, expected, w)
+		}
+		expectedMoment := 1 / (4 * beta)
+		if math.Abs(m-expectedMoment) > 1e-3*expectedMoment {
+			t.Errorf("foundation %g: expected bending moment %g, got %g", foundation, expectedMoment, m)
+		}
+	}
+}
+
+// Test that the deflection of the sample configuration resonates near its critical
+// speed (78.2 m/s) and returns to the static deflection at low speeds.
+func TestSpeedResponse(t *testing.T) {
+	layers := []soil_dispersion.Layer{
+		{Thickness: 5, Density: 1900, YoungsModulus: 2.67e7, PoissonRatio: 0.33},
+		{Thickness: 10, Density: 1900, YoungsModulus: 1.14e8, PoissonRatio: 0.33},
+		{Thickness: 15, Density: 1900, YoungsModulus: 2.63e8, PoissonRatio: 0.33},
+		{Thickness: math.Inf(1), Density: 1900, YoungsModulus: 4.71e8, PoissonRatio: 0.33},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := math_utils.Linspace(1, 400, 100)
+	soil := soil_dispersion.SoilDispersion(layers, omega)
+	track := track_dispersion.BallastTrackParameters{
+		EIRail: 6.4e6, MRail: 60.21, KRailPad: 6e8, CRailPad: 2.5e5, MSleeper: 238.5,
+		EBallast: 100e6, HBallast: 0.3, WidthSleeper: 1.25, RhoBallast: 2000,
+	}
+	params := Parameters{Load: 1e5, Speeds: math_utils.Linspace(5, 150, 30), TrackWidth: 2.5, RailSectionModulus: 3.77e-4}
+
+	points, err := SpeedResponse(context.Background(), track, layers, omega, soil, params)
+	if err != nil {
+		t.Fatalf("SpeedResponse failed: %v", err)
+	}
+	if len(points) != len(params.Speeds) {
+		t.Fatalf("expected %d points, got %d", len(params.Speeds), len(points))
+	}
+
+	peak := points[0]
+	for _, p := range points {
+		if p.Amplification > peak.Amplification {
+			peak = p
+		}
+	}
+	if peak.Speed < 65 || peak.Speed > 85 {
+		t.Errorf("expected the resonance near 78 m/s, got %g m/s", peak.Speed)
+	}
+	if peak.Amplification < 2 {
+		t.Errorf("expected a dynamic amplification above 2 at resonance, got %g", peak.Amplification)
+	}
+	if math.Abs(points[0].Amplification-1) > 0.01 {
+		t.Errorf("expected the quasi-static deflection at %g m/s, got amplification %g", points[0].Speed, points[0].Amplification)
+	}
+
+	// The bending moment is amplified as well, less than the deflection, and the
+	// stress follows from the section modulus
+	if peak.MomentAmplification < 1.2 || peak.MomentAmplification > peak.Amplification {
+		t.Errorf("unexpected moment amplification at resonance: %g (deflection %g)", peak.MomentAmplification, peak.Amplification)
+	}
+	if math.Abs(points[0].MomentAmplification-1) > 0.01 {
+		t.Errorf("expected the quasi-static bending moment at %g m/s, got amplification %g", points[0].Speed, points[0].MomentAmplification)
+	}
+	if stress := peak.BendingMoment / params.RailSectionModulus; peak.Stress != stress {
+		t.Errorf("expected stress %g, got %g", stress, peak.Stress)
+	}
+
+	// Invalid parameters
+	for name, p := range map[string]Parameters{
+		"zero load":        {Speeds: params.Speeds, TrackWidth: 2.5},
+		"zero width":       {Load: 1e5, Speeds: params.Speeds},
+		"negative speed":   {Load: 1e5, Speeds: []float64{-10}, TrackWidth: 2.5},
+		"negative modulus": {Load: 1e5, Speeds: params.Speeds, TrackWidth: 2.5, RailSectionModulus: -1},
+	} {
+		if _, err := SpeedResponse(context.Background(), track, layers, omega, soil, p); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// Test that the ground vibration decays with distance, by geometric spreading alone
+// without material damping, and that the velocity levels follow from the peak velocity.
+func TestGroundVibration(t *testing.T) {
+	layers := []soil_dispersion.Layer{
+		{Thickness: 5, Density: 1900, YoungsModulus: 2.67e7, PoissonRatio: 0.33},
+		{Thickness: math.Inf(1), Density: 1900, YoungsModulus: 4.71e8, PoissonRatio: 0.33},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := math_utils.Linspace(1, 400, 100)
+	soil := soil_dispersion.SoilDispersion(layers, omega)
+	track := track_dispersion.SlabTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 6.40625e8, MSlab: 1093.75,
+	}
+	distances := []float64{0, 10, 40}
+
+	// Without material damping, the amplitude decays as 1/√r
+	params := Parameters{Load: 1e5, TrackWidth: 2.5, Damping: 1e-9}
+	points, err := GroundVibration(context.Background(), track, layers, omega, soil, params, 60, distances)
+	if err != nil {
+		t.Fatalf("GroundVibration failed: %v", err)
+	}
+	if ratio := points[1].PeakVelocity / points[2].PeakVelocity; math.Abs(ratio-2) > 1e-6 {
+		t.Errorf("expected geometric spreading 1/sqrt(r) without damping, got ratio %g", ratio)
+	}
+	for _, p := range points {
+		if level := 20 * math.Log10(p.PeakVelocity/ReferenceVelocity); p.VelocityLevel != level {
+			t.Errorf("distance %g: expected velocity level %g, got %g", p.Distance, level, p.VelocityLevel)
+		}
+	}
+
+	// Material damping reduces the vibration away from the track only
+	params.Damping = 0.05
+	damped, err := GroundVibration(context.Background(), track, layers, omega, soil, params, 60, distances)
+	if err != nil {
+		t.Fatalf("GroundVibration failed: %v", err)
+	}
+	if damped[2].PeakVelocity >= points[2].PeakVelocity || damped[1].PeakVelocity <= damped[2].PeakVelocity {
+		t.Errorf("expected damping to reduce the vibration with distance: %+v", damped)
+	}
+
+	if _, err := GroundVibration(context.Background(), track, layers, omega, soil, params, 0, distances); err == nil {
+		t.Errorf("expected an error for a zero speed")
+	}
+	if _, err := GroundVibration(context.Background(), track, layers, omega, soil, params, 60, []float64{-1}); err == nil {
+		t.Errorf("expected an error for a negative distance")
+	}
+}
+
+// rigidTrack is a track that does not deflect, on which the wheel-rail force is that of
+// the wheel and the contact spring alone.
+type rigidTrack struct{}
+
+func (rigidTrack) Receptance(omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+	return 0
+}
+
+func (rigidTrack) RailBendingStiffness() float64 { return 6.4e6 }
+
+// Test the wheel-rail force of the irregularity against the wheel on its contact
+// spring on a rigid track, and that on the sample track it scales with the square
+// root of the roughness and grows sharply towards the critical speed (78.2 m/s).
+func TestIrregularityResponse(t *testing.T) {
+	layers := []soil_dispersion.Layer{
+		{Thickness: 5, Density: 1900, YoungsModulus: 2.67e7, PoissonRatio: 0.33},
+		{Thickness: 10, Density: 1900, YoungsModulus: 1.14e8, PoissonRatio: 0.33},
+		{Thickness: 15, Density: 1900, YoungsModulus: 2.63e8, PoissonRatio: 0.33},
+		{Thickness: math.Inf(1), Density: 1900, YoungsModulus: 4.71e8, PoissonRatio: 0.33},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := math_utils.Linspace(1, 400, 100)
+	soil := soil_dispersion.SoilDispersion(layers, omega)
+	wheel := Wheel{UnsprungMass: 900, ContactStiffness: 1.4e9}
+
+	// |F|² = S / (1/k_H - 1/(m ω²))², integrated over two wavenumbers
+	flat := Irregularity{Wavenumbers: []float64{1, 2}, PSD: []float64{1e-8, 1e-8}}
+	params := Parameters{Load: 1e5, Speeds: []float64{50}, TrackWidth: 2.5}
+	points, err := IrregularityResponse(context.Background(), rigidTrack{}, layers, omega, soil, params, wheel, flat)
+	if err != nil {
+		t.Fatalf("IrregularityResponse failed: %v", err)
+	}
+	force := func(wavenumber float64) float64 {
+		w := wavenumber * 50
+		return 1e-8 / math.Pow(1/1.4e9-1/(900*w*w), 2)
+	}
+	if expected := math.Sqrt((force(1) + force(2)) / 2); math.Abs(points[0].RMSForce-expected) > 1e-9*expected {
+		t.Errorf("expected RMS force %g on a rigid track, got %g", expected, points[0].RMSForce)
+	}
+	if points[0].PeakOmega != 100 || math.Abs(points[0].DynamicFactor-points[0].RMSForce/1e5) > 1e-12 {
+		t.Errorf("expected the peak at 100 rad/s and the dynamic factor over the wheel load, got %+v", points[0])
+	}
+
+	track := track_dispersion.BallastTrackParameters{
+		EIRail: 6.4e6, MRail: 60.21, KRailPad: 6e8, CRailPad: 2.5e5, MSleeper: 238.5,
+		EBallast: 100e6, HBallast: 0.3, WidthSleeper: 1.25, RhoBallast: 2000,
+	}
+	params.Speeds = []float64{40, 100}
+	low, err := IrregularityResponse(context.Background(), track, layers, omega, soil, params, wheel, GermanIrregularity(GermanLowDisturbance, 1, 80, 30))
+	if err != nil {
+		t.Fatalf("IrregularityResponse failed: %v", err)
+	}
+	high, err := IrregularityResponse(context.Background(), track, layers, omega, soil, params, wheel, GermanIrregularity(GermanHighDisturbance, 1, 80, 30))
+	if err != nil {
+		t.Fatalf("IrregularityResponse failed: %v", err)
+	}
+	expected := math.Sqrt(GermanHighDisturbance / GermanLowDisturbance)
+	for i := range low {
+		if ratio := high[i].RMSForce / low[i].RMSForce; math.Abs(ratio-expected) > 1e-9 {
+			t.Errorf("speed %g: expected force ratio %g between the spectra, got %g", low[i].Speed, expected, ratio)
+		}
+	}
+	if !(low[1].RMSForce > 5*low[0].RMSForce) {
+		t.Errorf("expected a much larger force beyond the critical speed, got %g at 40 m/s and %g at 100 m/s", low[0].RMSForce, low[1].RMSForce)
+	}
+
+	for name, invalid := range map[string]Irregularity{
+		"single wavenumber":     {Wavenumbers: []float64{1}, PSD: []float64{1e-8}},
+		"decreasing wavenumber": {Wavenumbers: []float64{2, 1}, PSD: []float64{1e-8, 1e-8}},
+		"negative psd":          {Wavenumbers: []float64{1, 2}, PSD: []float64{1e-8, -1}},
+	} {
+		if _, err := IrregularityResponse(context.Background(), track, layers, omega, soil, params, wheel, invalid); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := IrregularityResponse(context.Background(), track, layers, omega, soil, params, Wheel{}, flat); err == nil {
+		t.Errorf("expected an error for a wheel without mass")
+	}
+}
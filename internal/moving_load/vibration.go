@@ -0,0 +1,147 @@
+package moving_load
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	track_dispersion "github.com/PlatypusBytes/GoTrain/internal/track_dispersion"
+)
+
+// ReferenceVelocity is the reference of the vibration velocity levels [m/s] (ISO 1683).
+const ReferenceVelocity = 1e-9
+
+// VibrationPoint is the free-field vibration of the ground at a distance from the track.
+type VibrationPoint struct {
+	Distance      float64 // Distance from the centre line of the track [m]
+	PeakVelocity  float64 // Peak particle velocity of the ground surface [m/s]
+	VelocityLevel float64 // Velocity level of the peak velocity [dB re ReferenceVelocity]
+}
+
+// GroundVibration estimates the peak vibration velocity of the ground surface at
+// distances from the track, while the load passes at an operating speed.
+//
+// The ground at the edge of the track is assumed to move with the rail: in the frame
+// of the load, its particle velocity is -v dw/dx, computed from the receptance of the
+// coupled track-soil model as for SpeedResponse. Each wavenumber component, of angular
+// frequency ω = k v, travels to the distance r as a surface wave, with geometric
+// spreading and the material damping of the soil along the path:
+//
+//	A(r, ω) = √(r₀ / r) exp(-ζ ω (r - r₀) / c(ω))
+//
+// where r₀ is half of the track width, ζ the damping ratio of the soil and c(ω) the
+// soil dispersion curve, so high frequencies, which travel slowly, are damped first.
+// The travel times of the components are not included, which keeps their peaks
+// aligned: the estimate is an upper bound of the peak velocity.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - track: The track model (track_dispersion.BallastTrackParameters or SlabTrackParameters)
+//   - layers: The soil profile, with its wave speeds computed
+//   - omega: Angular frequencies of the soil dispersion curve [rad/s]
+//   - soilPhaseVelocity: Soil dispersion curve [m/s] (NaN where no root is found)
+//   - params: The load, the width of the track and the damping of the soil (Speeds is not used)
+//   - speed: Operating speed of the load [m/s]
+//   - distances: Distances from the centre line of the track [m] (within the track, the
+//     velocity at its edge is returned)
+//
+// Returns:
+//   - []VibrationPoint: The vibration at every distance, in the order of distances
+//   - error: An error if the parameters are invalid, the soil dispersion curve has
+//     fewer than two points or the context is cancelled
+func GroundVibration(ctx context.Context, track track_dispersion.ReceptanceTrack, layers []soil_dispersion.Layer,
+	omega []float64, soilPhaseVelocity []float64, params Parameters, speed float64, distances []float64) ([]VibrationPoint, error) {
+
+	if !(speed > 0) {
+		return nil, fmt.Errorf("invalid ground vibration: the speed must be positive, got %g", speed)
+	}
+	for _, r := range distances {
+		if !(r >= 0) {
+			return nil, fmt.Errorf("invalid ground vibration: distances must not be negative, got %g", r)
+		}
+	}
+	soil, err := newSoilModel(layers, omega, soilPhaseVelocity, params)
+	if err != nil {
+		return nil, err
+	}
+	wavenumbers, weights := integrationGrid()
+
+	// Particle velocity at the edge of the track per wavenumber, -v dw/dx
+	edge := make([]complex128, len(wavenumbers))
+	for i, k := range wavenumbers {
+		omegaK := k * speed
+		receptance := track.Receptance(omegaK, k, soil.stiffness(k, omegaK))
+		edge[i] = complex(0, -k*speed*params.Load*weights[i]) * receptance
+	}
+
+	source := params.TrackWidth / 2
+	points := make([]VibrationPoint, len(distances))
+	values := make([]complex128, len(wavenumbers))
+	for j, r := range distances {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r = math.Max(r, source)
+		for i, k := range wavenumbers {
+			omegaK := k * speed
+			c, _ := soil.phaseVelocity.At(omegaK)
+			attenuation := math.Sqrt(source/r) * math.Exp(-soil.damping*omegaK*(r-source)/c)
+			values[i] = edge[i] * complex(attenuation, 0)
+		}
+		peak := largestAlongPositions(wavenumbers, values)
+		points[j] = VibrationPoint{
+			Distance:      distances[j],
+			PeakVelocity:  peak,
+			VelocityLevel: 20 * math.Log10(peak/ReferenceVelocity),
+		}
+	}
+	return points, nil
+}
+
+// largestAlongPositions returns the largest absolute value along the positions of the
+// inverse transform 1/π ∫₀^∞ Re(F(k) e^{ikx}) dk of a spectrum (see railResponse).
+//
+// Parameters:
+//   - wavenumbers: Wavenumbers of the integration [1/m]
+//   - values: The spectrum at the wavenumbers, multiplied by their weights
+//
+// Returns:
+//   - float64: The largest absolute value of the transform
+func largestAlongPositions(wavenumbers []float64, values []complex128) float64 {
+	largest := 0.0
+	for _, x := range positions {
+		v := 0.0
+		for i, k := range wavenumbers {
+			sin, cos := math.Sincos(k * x)
+			v += real(values[i])*cos - imag(values[i])*sin
+		}
+		largest = math.Max(largest, math.Abs(v/math.Pi))
+	}
+	return largest
+}
+
+// WriteVibrationCSV writes the free-field ground vibration as CSV, with the columns
+// distance [m], peak_velocity [m/s] and velocity_level [dB re 1e-9 m/s].
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - points: The vibration at every distance
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func WriteVibrationCSV(w io.Writer, points []VibrationPoint) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"distance", "peak_velocity", "velocity_level"})
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	for _, p := range points {
+		writer.Write([]string{format(p.Distance), format(p.PeakVelocity), format(p.VelocityLevel)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,19 @@
+// Package profiling records the CPU and memory profiles requested with the
+// -cpuprofile and -memprofile flags of the command-line tools, so that slow
+// configurations can be analysed with the standard Go tooling:
+//
+//	gotrain run -config slow.yaml -cpuprofile cpu.out -memprofile mem.out
+//	go tool pprof -top bin/gotrain cpu.out
+//	go tool pprof -sample_index=alloc_space -top bin/gotrain mem.out
+//
+// # Usage Example
+//
+//	stop, err := profiling.Start(*cpuProfile, *memProfile)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer stop()
+//
+// The profiles are only complete once the function returned by Start has been
+// called, so programs that exit with os.Exit must call it first.
+package profiling
@@ -0,0 +1,77 @@
+package profiling
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Start starts the profiles requested on the command line: the CPU profile is
+// recorded from now on, and the memory profile is written when the returned
+// function is called. The function must be called before the program exits,
+// also on failures, or the profiles are incomplete.
+//
+// Parameters:
+//   - cpuProfile: Path of the CPU profile (empty for none)
+//   - memProfile: Path of the memory profile (empty for none)
+//
+// Returns:
+//   - func() error: Function stopping the CPU profile and writing the memory profile
+//   - error: An error if a profile file cannot be created
+func Start(cpuProfile string, memProfile string) (func() error, error) {
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		var err error
+		if cpuFile, err = os.Create(cpuProfile); err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %v", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %v", err)
+		}
+	}
+
+	stopped := false
+	stop := func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %v", err)
+			}
+		}
+		if memProfile != "" {
+			return writeMemProfile(memProfile)
+		}
+		return nil
+	}
+	return stop, nil
+}
+
+// writeMemProfile writes the allocations of the program since its start
+// (allocated and in-use space and objects) to a file.
+//
+// Parameters:
+//   - path: Path of the memory profile
+//
+// Returns:
+//   - error: An error if the profile cannot be written
+func writeMemProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %v", err)
+	}
+	runtime.GC() // Update the in-use statistics
+	if err := pprof.Lookup("allocs").WriteTo(file, 0); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write memory profile: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write memory profile: %v", err)
+	}
+	return nil
+}
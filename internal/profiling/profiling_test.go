@@ -0,0 +1,43 @@
+package profiling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test that the requested profiles are written when profiling stops.
+func TestStart(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out")
+
+	stop, err := Start(cpu, mem)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	work := make([][]float64, 0)
+	for i := 0; i < 1000; i++ {
+		work = append(work, make([]float64, 100))
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("expected a second call to do nothing, got: %v", err)
+	}
+
+	for _, path := range []string{cpu, mem} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("expected profile %s to be written", path)
+		}
+	}
+
+	// Without profiles, nothing is written
+	stop, err = Start("", "")
+	if err != nil || stop() != nil {
+		t.Errorf("expected no error without profiles")
+	}
+	if _, err := Start(filepath.Join(dir, "missing", "cpu.out"), ""); err == nil {
+		t.Errorf("expected an error for an invalid path")
+	}
+}
@@ -0,0 +1,13 @@
+// Package protobuf holds the Go code generated from proto/gotrain.proto: the message
+// types in gotrain.pb.go and the gRPC stubs of the CriticalSpeed service in
+// gotrain_grpc.pb.go. The files must not be edited; they are regenerated with
+// make proto after a change to the .proto file, so that the messages encoded by
+// GoTrain always follow the schema.
+//
+// The messages are encoded and decoded with google.golang.org/protobuf/proto:
+//
+//	data, err := proto.Marshal(&protobuf.Result{Omega: omega, CriticalVelocity: 78.2})
+//
+//	var result protobuf.Result
+//	err = proto.Unmarshal(data, &result)
+package protobuf
@@ -0,0 +1,891 @@
+// Protocol buffer definitions of the GoTrain gRPC service.
+//
+// The messages mirror the YAML configuration files and the JSON result files.
+// Clients for any language can be generated from this file with protoc; the
+// server is implemented in internal/grpc_service and served by cmd/server
+// when started with -grpc-addr. Result files written with output.format
+// "protobuf" contain a single Result message.
+//
+// The Go code in internal/protobuf is generated from this file with make proto.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/gotrain.proto
+
+package protobuf
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Frequency range of the analysis.
+type Frequency struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Min           float64                `protobuf:"fixed64,1,opt,name=min,proto3" json:"min,omitempty"`      // Minimum angular frequency [rad/s]
+	Max           float64                `protobuf:"fixed64,2,opt,name=max,proto3" json:"max,omitempty"`      // Maximum angular frequency [rad/s]
+	Points        int32                  `protobuf:"varint,3,opt,name=points,proto3" json:"points,omitempty"` // Number of angular frequency points
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frequency) Reset() {
+	*x = Frequency{}
+	mi := &file_proto_gotrain_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frequency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frequency) ProtoMessage() {}
+
+func (x *Frequency) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frequency.ProtoReflect.Descriptor instead.
+func (*Frequency) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Frequency) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Frequency) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Frequency) GetPoints() int32 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+// Ballast track parameters.
+type BallastTrack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EiRail        float64                `protobuf:"fixed64,1,opt,name=ei_rail,json=eiRail,proto3" json:"ei_rail,omitempty"`                       // Rail bending stiffness [N·m²]
+	MRail         float64                `protobuf:"fixed64,2,opt,name=m_rail,json=mRail,proto3" json:"m_rail,omitempty"`                          // Rail mass per unit length [kg/m]
+	KRailPad      float64                `protobuf:"fixed64,3,opt,name=k_rail_pad,json=kRailPad,proto3" json:"k_rail_pad,omitempty"`               // Railpad stiffness [N/m]
+	CRailPad      float64                `protobuf:"fixed64,4,opt,name=c_rail_pad,json=cRailPad,proto3" json:"c_rail_pad,omitempty"`               // Railpad damping [N·s/m]
+	MSleeper      float64                `protobuf:"fixed64,5,opt,name=m_sleeper,json=mSleeper,proto3" json:"m_sleeper,omitempty"`                 // Sleeper (distributed) mass [kg/m]
+	EBallast      float64                `protobuf:"fixed64,6,opt,name=e_ballast,json=eBallast,proto3" json:"e_ballast,omitempty"`                 // Young's modulus of ballast [Pa]
+	HBallast      float64                `protobuf:"fixed64,7,opt,name=h_ballast,json=hBallast,proto3" json:"h_ballast,omitempty"`                 // Ballast layer thickness [m]
+	WidthSleeper  float64                `protobuf:"fixed64,8,opt,name=width_sleeper,json=widthSleeper,proto3" json:"width_sleeper,omitempty"`     // Half-track width [m]
+	RhoBallast    float64                `protobuf:"fixed64,9,opt,name=rho_ballast,json=rhoBallast,proto3" json:"rho_ballast,omitempty"`           // Ballast density [kg/m³]
+	SoilStiffness float64                `protobuf:"fixed64,10,opt,name=soil_stiffness,json=soilStiffness,proto3" json:"soil_stiffness,omitempty"` // Soil spring stiffness [N/m]
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BallastTrack) Reset() {
+	*x = BallastTrack{}
+	mi := &file_proto_gotrain_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BallastTrack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BallastTrack) ProtoMessage() {}
+
+func (x *BallastTrack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BallastTrack.ProtoReflect.Descriptor instead.
+func (*BallastTrack) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BallastTrack) GetEiRail() float64 {
+	if x != nil {
+		return x.EiRail
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetMRail() float64 {
+	if x != nil {
+		return x.MRail
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetKRailPad() float64 {
+	if x != nil {
+		return x.KRailPad
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetCRailPad() float64 {
+	if x != nil {
+		return x.CRailPad
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetMSleeper() float64 {
+	if x != nil {
+		return x.MSleeper
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetEBallast() float64 {
+	if x != nil {
+		return x.EBallast
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetHBallast() float64 {
+	if x != nil {
+		return x.HBallast
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetWidthSleeper() float64 {
+	if x != nil {
+		return x.WidthSleeper
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetRhoBallast() float64 {
+	if x != nil {
+		return x.RhoBallast
+	}
+	return 0
+}
+
+func (x *BallastTrack) GetSoilStiffness() float64 {
+	if x != nil {
+		return x.SoilStiffness
+	}
+	return 0
+}
+
+// Slab track parameters.
+type SlabTrack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EiRail        float64                `protobuf:"fixed64,1,opt,name=ei_rail,json=eiRail,proto3" json:"ei_rail,omitempty"`                      // Rail bending stiffness [N·m²]
+	MRail         float64                `protobuf:"fixed64,2,opt,name=m_rail,json=mRail,proto3" json:"m_rail,omitempty"`                         // Rail mass per unit length [kg/m]
+	EiSlab        float64                `protobuf:"fixed64,3,opt,name=ei_slab,json=eiSlab,proto3" json:"ei_slab,omitempty"`                      // Slab bending stiffness [N·m²]
+	MSlab         float64                `protobuf:"fixed64,4,opt,name=m_slab,json=mSlab,proto3" json:"m_slab,omitempty"`                         // Slab mass per unit length [kg/m]
+	KRailPad      float64                `protobuf:"fixed64,5,opt,name=k_rail_pad,json=kRailPad,proto3" json:"k_rail_pad,omitempty"`              // Railpad stiffness [N/m]
+	CRailPad      float64                `protobuf:"fixed64,6,opt,name=c_rail_pad,json=cRailPad,proto3" json:"c_rail_pad,omitempty"`              // Railpad damping [N·s/m]
+	SoilStiffness float64                `protobuf:"fixed64,7,opt,name=soil_stiffness,json=soilStiffness,proto3" json:"soil_stiffness,omitempty"` // Soil spring stiffness [N/m]
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SlabTrack) Reset() {
+	*x = SlabTrack{}
+	mi := &file_proto_gotrain_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SlabTrack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SlabTrack) ProtoMessage() {}
+
+func (x *SlabTrack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SlabTrack.ProtoReflect.Descriptor instead.
+func (*SlabTrack) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SlabTrack) GetEiRail() float64 {
+	if x != nil {
+		return x.EiRail
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetMRail() float64 {
+	if x != nil {
+		return x.MRail
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetEiSlab() float64 {
+	if x != nil {
+		return x.EiSlab
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetMSlab() float64 {
+	if x != nil {
+		return x.MSlab
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetKRailPad() float64 {
+	if x != nil {
+		return x.KRailPad
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetCRailPad() float64 {
+	if x != nil {
+		return x.CRailPad
+	}
+	return 0
+}
+
+func (x *SlabTrack) GetSoilStiffness() float64 {
+	if x != nil {
+		return x.SoilStiffness
+	}
+	return 0
+}
+
+// Soil layer of the subsoil profile.
+type SoilLayer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Thickness     float64                `protobuf:"fixed64,1,opt,name=thickness,proto3" json:"thickness,omitempty"`                           // Thickness [m]
+	Density       float64                `protobuf:"fixed64,2,opt,name=density,proto3" json:"density,omitempty"`                               // Density [kg/m³]
+	YoungModulus  float64                `protobuf:"fixed64,3,opt,name=young_modulus,json=youngModulus,proto3" json:"young_modulus,omitempty"` // Young's modulus [Pa]
+	PoissonRatio  float64                `protobuf:"fixed64,4,opt,name=poisson_ratio,json=poissonRatio,proto3" json:"poisson_ratio,omitempty"` // Poisson's ratio
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SoilLayer) Reset() {
+	*x = SoilLayer{}
+	mi := &file_proto_gotrain_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SoilLayer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SoilLayer) ProtoMessage() {}
+
+func (x *SoilLayer) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SoilLayer.ProtoReflect.Descriptor instead.
+func (*SoilLayer) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SoilLayer) GetThickness() float64 {
+	if x != nil {
+		return x.Thickness
+	}
+	return 0
+}
+
+func (x *SoilLayer) GetDensity() float64 {
+	if x != nil {
+		return x.Density
+	}
+	return 0
+}
+
+func (x *SoilLayer) GetYoungModulus() float64 {
+	if x != nil {
+		return x.YoungModulus
+	}
+	return 0
+}
+
+func (x *SoilLayer) GetPoissonRatio() float64 {
+	if x != nil {
+		return x.PoissonRatio
+	}
+	return 0
+}
+
+// Configuration of an analysis, equivalent to a YAML configuration file
+// without the output section.
+type Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackType     string                 `protobuf:"bytes,1,opt,name=track_type,json=trackType,proto3" json:"track_type,omitempty"` // "ballast" or "slabtrack"
+	Frequency     *Frequency             `protobuf:"bytes,2,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	BallastTrack  *BallastTrack          `protobuf:"bytes,3,opt,name=ballast_track,json=ballastTrack,proto3" json:"ballast_track,omitempty"` // Used when track_type is "ballast"
+	SlabTrack     *SlabTrack             `protobuf:"bytes,4,opt,name=slab_track,json=slabTrack,proto3" json:"slab_track,omitempty"`          // Used when track_type is "slabtrack"
+	SoilLayers    []*SoilLayer           `protobuf:"bytes,5,rep,name=soil_layers,json=soilLayers,proto3" json:"soil_layers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_proto_gotrain_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Config) GetTrackType() string {
+	if x != nil {
+		return x.TrackType
+	}
+	return ""
+}
+
+func (x *Config) GetFrequency() *Frequency {
+	if x != nil {
+		return x.Frequency
+	}
+	return nil
+}
+
+func (x *Config) GetBallastTrack() *BallastTrack {
+	if x != nil {
+		return x.BallastTrack
+	}
+	return nil
+}
+
+func (x *Config) GetSlabTrack() *SlabTrack {
+	if x != nil {
+		return x.SlabTrack
+	}
+	return nil
+}
+
+func (x *Config) GetSoilLayers() []*SoilLayer {
+	if x != nil {
+		return x.SoilLayers
+	}
+	return nil
+}
+
+type ComputeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        *Config                `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComputeRequest) Reset() {
+	*x = ComputeRequest{}
+	mi := &file_proto_gotrain_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComputeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeRequest) ProtoMessage() {}
+
+func (x *ComputeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeRequest.ProtoReflect.Descriptor instead.
+func (*ComputeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ComputeRequest) GetConfig() *Config {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+// Result of an analysis, equivalent to a JSON result file; the content of the
+// result files written with output.format "protobuf". NaN soil phase velocities
+// are kept as NaN.
+type Result struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Omega               []float64              `protobuf:"fixed64,1,rep,packed,name=omega,proto3" json:"omega,omitempty"`                                                       // Angular frequencies [rad/s]
+	TrackPhaseVelocity  []float64              `protobuf:"fixed64,2,rep,packed,name=track_phase_velocity,json=trackPhaseVelocity,proto3" json:"track_phase_velocity,omitempty"` // Track phase velocities [m/s] (zero where no root is found)
+	SoilPhaseVelocity   []float64              `protobuf:"fixed64,3,rep,packed,name=soil_phase_velocity,json=soilPhaseVelocity,proto3" json:"soil_phase_velocity,omitempty"`    // Soil phase velocities [m/s] (NaN where no root is found)
+	CriticalOmega       float64                `protobuf:"fixed64,4,opt,name=critical_omega,json=criticalOmega,proto3" json:"critical_omega,omitempty"`                         // Critical angular frequency [rad/s]
+	CriticalVelocity    float64                `protobuf:"fixed64,5,opt,name=critical_velocity,json=criticalVelocity,proto3" json:"critical_velocity,omitempty"`                // Critical train speed [m/s]
+	SitePeriod          float64                `protobuf:"fixed64,6,opt,name=site_period,json=sitePeriod,proto3" json:"site_period,omitempty"`                                  // Fundamental period of the soil layers above the halfspace [s]
+	ResonanceFrequency  float64                `protobuf:"fixed64,7,opt,name=resonance_frequency,json=resonanceFrequency,proto3" json:"resonance_frequency,omitempty"`          // Resonance frequency of the site [Hz]
+	CriticalOmegaMin    float64                `protobuf:"fixed64,8,opt,name=critical_omega_min,json=criticalOmegaMin,proto3" json:"critical_omega_min,omitempty"`              // Lowest angular frequency of the critical band [rad/s] (zero when not computed)
+	CriticalOmegaMax    float64                `protobuf:"fixed64,9,opt,name=critical_omega_max,json=criticalOmegaMax,proto3" json:"critical_omega_max,omitempty"`              // Highest angular frequency of the critical band [rad/s] (zero when not computed)
+	CriticalVelocityMin float64                `protobuf:"fixed64,10,opt,name=critical_velocity_min,json=criticalVelocityMin,proto3" json:"critical_velocity_min,omitempty"`    // Lowest phase velocity of the critical band [m/s] (zero when not computed)
+	CriticalVelocityMax float64                `protobuf:"fixed64,11,opt,name=critical_velocity_max,json=criticalVelocityMax,proto3" json:"critical_velocity_max,omitempty"`    // Highest phase velocity of the critical band [m/s] (zero when not computed)
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_proto_gotrain_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Result) GetOmega() []float64 {
+	if x != nil {
+		return x.Omega
+	}
+	return nil
+}
+
+func (x *Result) GetTrackPhaseVelocity() []float64 {
+	if x != nil {
+		return x.TrackPhaseVelocity
+	}
+	return nil
+}
+
+func (x *Result) GetSoilPhaseVelocity() []float64 {
+	if x != nil {
+		return x.SoilPhaseVelocity
+	}
+	return nil
+}
+
+func (x *Result) GetCriticalOmega() float64 {
+	if x != nil {
+		return x.CriticalOmega
+	}
+	return 0
+}
+
+func (x *Result) GetCriticalVelocity() float64 {
+	if x != nil {
+		return x.CriticalVelocity
+	}
+	return 0
+}
+
+func (x *Result) GetSitePeriod() float64 {
+	if x != nil {
+		return x.SitePeriod
+	}
+	return 0
+}
+
+func (x *Result) GetResonanceFrequency() float64 {
+	if x != nil {
+		return x.ResonanceFrequency
+	}
+	return 0
+}
+
+func (x *Result) GetCriticalOmegaMin() float64 {
+	if x != nil {
+		return x.CriticalOmegaMin
+	}
+	return 0
+}
+
+func (x *Result) GetCriticalOmegaMax() float64 {
+	if x != nil {
+		return x.CriticalOmegaMax
+	}
+	return 0
+}
+
+func (x *Result) GetCriticalVelocityMin() float64 {
+	if x != nil {
+		return x.CriticalVelocityMin
+	}
+	return 0
+}
+
+func (x *Result) GetCriticalVelocityMax() float64 {
+	if x != nil {
+		return x.CriticalVelocityMax
+	}
+	return 0
+}
+
+// Result of an analysis, with the same fields as Result (the two messages are
+// wire-compatible).
+type ComputeResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Omega               []float64              `protobuf:"fixed64,1,rep,packed,name=omega,proto3" json:"omega,omitempty"`                                                       // Angular frequencies [rad/s]
+	TrackPhaseVelocity  []float64              `protobuf:"fixed64,2,rep,packed,name=track_phase_velocity,json=trackPhaseVelocity,proto3" json:"track_phase_velocity,omitempty"` // Track phase velocities [m/s] (zero where no root is found)
+	SoilPhaseVelocity   []float64              `protobuf:"fixed64,3,rep,packed,name=soil_phase_velocity,json=soilPhaseVelocity,proto3" json:"soil_phase_velocity,omitempty"`    // Soil phase velocities [m/s] (NaN where no root is found)
+	CriticalOmega       float64                `protobuf:"fixed64,4,opt,name=critical_omega,json=criticalOmega,proto3" json:"critical_omega,omitempty"`                         // Critical angular frequency [rad/s]
+	CriticalVelocity    float64                `protobuf:"fixed64,5,opt,name=critical_velocity,json=criticalVelocity,proto3" json:"critical_velocity,omitempty"`                // Critical train speed [m/s]
+	SitePeriod          float64                `protobuf:"fixed64,6,opt,name=site_period,json=sitePeriod,proto3" json:"site_period,omitempty"`                                  // Fundamental period of the soil layers above the halfspace [s]
+	ResonanceFrequency  float64                `protobuf:"fixed64,7,opt,name=resonance_frequency,json=resonanceFrequency,proto3" json:"resonance_frequency,omitempty"`          // Resonance frequency of the site [Hz]
+	CriticalOmegaMin    float64                `protobuf:"fixed64,8,opt,name=critical_omega_min,json=criticalOmegaMin,proto3" json:"critical_omega_min,omitempty"`              // Lowest angular frequency of the critical band [rad/s] (zero when not computed)
+	CriticalOmegaMax    float64                `protobuf:"fixed64,9,opt,name=critical_omega_max,json=criticalOmegaMax,proto3" json:"critical_omega_max,omitempty"`              // Highest angular frequency of the critical band [rad/s] (zero when not computed)
+	CriticalVelocityMin float64                `protobuf:"fixed64,10,opt,name=critical_velocity_min,json=criticalVelocityMin,proto3" json:"critical_velocity_min,omitempty"`    // Lowest phase velocity of the critical band [m/s] (zero when not computed)
+	CriticalVelocityMax float64                `protobuf:"fixed64,11,opt,name=critical_velocity_max,json=criticalVelocityMax,proto3" json:"critical_velocity_max,omitempty"`    // Highest phase velocity of the critical band [m/s] (zero when not computed)
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ComputeResponse) Reset() {
+	*x = ComputeResponse{}
+	mi := &file_proto_gotrain_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComputeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeResponse) ProtoMessage() {}
+
+func (x *ComputeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gotrain_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeResponse.ProtoReflect.Descriptor instead.
+func (*ComputeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_gotrain_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ComputeResponse) GetOmega() []float64 {
+	if x != nil {
+		return x.Omega
+	}
+	return nil
+}
+
+func (x *ComputeResponse) GetTrackPhaseVelocity() []float64 {
+	if x != nil {
+		return x.TrackPhaseVelocity
+	}
+	return nil
+}
+
+func (x *ComputeResponse) GetSoilPhaseVelocity() []float64 {
+	if x != nil {
+		return x.SoilPhaseVelocity
+	}
+	return nil
+}
+
+func (x *ComputeResponse) GetCriticalOmega() float64 {
+	if x != nil {
+		return x.CriticalOmega
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetCriticalVelocity() float64 {
+	if x != nil {
+		return x.CriticalVelocity
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetSitePeriod() float64 {
+	if x != nil {
+		return x.SitePeriod
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetResonanceFrequency() float64 {
+	if x != nil {
+		return x.ResonanceFrequency
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetCriticalOmegaMin() float64 {
+	if x != nil {
+		return x.CriticalOmegaMin
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetCriticalOmegaMax() float64 {
+	if x != nil {
+		return x.CriticalOmegaMax
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetCriticalVelocityMin() float64 {
+	if x != nil {
+		return x.CriticalVelocityMin
+	}
+	return 0
+}
+
+func (x *ComputeResponse) GetCriticalVelocityMax() float64 {
+	if x != nil {
+		return x.CriticalVelocityMax
+	}
+	return 0
+}
+
+var File_proto_gotrain_proto protoreflect.FileDescriptor
+
+const file_proto_gotrain_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/gotrain.proto\x12\n" +
+	"gotrain.v1\"G\n" +
+	"\tFrequency\x12\x10\n" +
+	"\x03min\x18\x01 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x02 \x01(\x01R\x03max\x12\x16\n" +
+	"\x06points\x18\x03 \x01(\x05R\x06points\"\xbe\x02\n" +
+	"\fBallastTrack\x12\x17\n" +
+	"\aei_rail\x18\x01 \x01(\x01R\x06eiRail\x12\x15\n" +
+	"\x06m_rail\x18\x02 \x01(\x01R\x05mRail\x12\x1c\n" +
+	"\n" +
+	"k_rail_pad\x18\x03 \x01(\x01R\bkRailPad\x12\x1c\n" +
+	"\n" +
+	"c_rail_pad\x18\x04 \x01(\x01R\bcRailPad\x12\x1b\n" +
+	"\tm_sleeper\x18\x05 \x01(\x01R\bmSleeper\x12\x1b\n" +
+	"\te_ballast\x18\x06 \x01(\x01R\beBallast\x12\x1b\n" +
+	"\th_ballast\x18\a \x01(\x01R\bhBallast\x12#\n" +
+	"\rwidth_sleeper\x18\b \x01(\x01R\fwidthSleeper\x12\x1f\n" +
+	"\vrho_ballast\x18\t \x01(\x01R\n" +
+	"rhoBallast\x12%\n" +
+	"\x0esoil_stiffness\x18\n" +
+	" \x01(\x01R\rsoilStiffness\"\xce\x01\n" +
+	"\tSlabTrack\x12\x17\n" +
+	"\aei_rail\x18\x01 \x01(\x01R\x06eiRail\x12\x15\n" +
+	"\x06m_rail\x18\x02 \x01(\x01R\x05mRail\x12\x17\n" +
+	"\aei_slab\x18\x03 \x01(\x01R\x06eiSlab\x12\x15\n" +
+	"\x06m_slab\x18\x04 \x01(\x01R\x05mSlab\x12\x1c\n" +
+	"\n" +
+	"k_rail_pad\x18\x05 \x01(\x01R\bkRailPad\x12\x1c\n" +
+	"\n" +
+	"c_rail_pad\x18\x06 \x01(\x01R\bcRailPad\x12%\n" +
+	"\x0esoil_stiffness\x18\a \x01(\x01R\rsoilStiffness\"\x8d\x01\n" +
+	"\tSoilLayer\x12\x1c\n" +
+	"\tthickness\x18\x01 \x01(\x01R\tthickness\x12\x18\n" +
+	"\adensity\x18\x02 \x01(\x01R\adensity\x12#\n" +
+	"\ryoung_modulus\x18\x03 \x01(\x01R\fyoungModulus\x12#\n" +
+	"\rpoisson_ratio\x18\x04 \x01(\x01R\fpoissonRatio\"\x89\x02\n" +
+	"\x06Config\x12\x1d\n" +
+	"\n" +
+	"track_type\x18\x01 \x01(\tR\ttrackType\x123\n" +
+	"\tfrequency\x18\x02 \x01(\v2\x15.gotrain.v1.FrequencyR\tfrequency\x12=\n" +
+	"\rballast_track\x18\x03 \x01(\v2\x18.gotrain.v1.BallastTrackR\fballastTrack\x124\n" +
+	"\n" +
+	"slab_track\x18\x04 \x01(\v2\x15.gotrain.v1.SlabTrackR\tslabTrack\x126\n" +
+	"\vsoil_layers\x18\x05 \x03(\v2\x15.gotrain.v1.SoilLayerR\n" +
+	"soilLayers\"<\n" +
+	"\x0eComputeRequest\x12*\n" +
+	"\x06config\x18\x01 \x01(\v2\x12.gotrain.v1.ConfigR\x06config\"\xea\x03\n" +
+	"\x06Result\x12\x14\n" +
+	"\x05omega\x18\x01 \x03(\x01R\x05omega\x120\n" +
+	"\x14track_phase_velocity\x18\x02 \x03(\x01R\x12trackPhaseVelocity\x12.\n" +
+	"\x13soil_phase_velocity\x18\x03 \x03(\x01R\x11soilPhaseVelocity\x12%\n" +
+	"\x0ecritical_omega\x18\x04 \x01(\x01R\rcriticalOmega\x12+\n" +
+	"\x11critical_velocity\x18\x05 \x01(\x01R\x10criticalVelocity\x12\x1f\n" +
+	"\vsite_period\x18\x06 \x01(\x01R\n" +
+	"sitePeriod\x12/\n" +
+	"\x13resonance_frequency\x18\a \x01(\x01R\x12resonanceFrequency\x12,\n" +
+	"\x12critical_omega_min\x18\b \x01(\x01R\x10criticalOmegaMin\x12,\n" +
+	"\x12critical_omega_max\x18\t \x01(\x01R\x10criticalOmegaMax\x122\n" +
+	"\x15critical_velocity_min\x18\n" +
+	" \x01(\x01R\x13criticalVelocityMin\x122\n" +
+	"\x15critical_velocity_max\x18\v \x01(\x01R\x13criticalVelocityMax\"\xf3\x03\n" +
+	"\x0fComputeResponse\x12\x14\n" +
+	"\x05omega\x18\x01 \x03(\x01R\x05omega\x120\n" +
+	"\x14track_phase_velocity\x18\x02 \x03(\x01R\x12trackPhaseVelocity\x12.\n" +
+	"\x13soil_phase_velocity\x18\x03 \x03(\x01R\x11soilPhaseVelocity\x12%\n" +
+	"\x0ecritical_omega\x18\x04 \x01(\x01R\rcriticalOmega\x12+\n" +
+	"\x11critical_velocity\x18\x05 \x01(\x01R\x10criticalVelocity\x12\x1f\n" +
+	"\vsite_period\x18\x06 \x01(\x01R\n" +
+	"sitePeriod\x12/\n" +
+	"\x13resonance_frequency\x18\a \x01(\x01R\x12resonanceFrequency\x12,\n" +
+	"\x12critical_omega_min\x18\b \x01(\x01R\x10criticalOmegaMin\x12,\n" +
+	"\x12critical_omega_max\x18\t \x01(\x01R\x10criticalOmegaMax\x122\n" +
+	"\x15critical_velocity_min\x18\n" +
+	" \x01(\x01R\x13criticalVelocityMin\x122\n" +
+	"\x15critical_velocity_max\x18\v \x01(\x01R\x13criticalVelocityMax2S\n" +
+	"\rCriticalSpeed\x12B\n" +
+	"\aCompute\x12\x1a.gotrain.v1.ComputeRequest\x1a\x1b.gotrain.v1.ComputeResponseB4Z2github.com/PlatypusBytes/GoTrain/internal/protobufb\x06proto3"
+
+var (
+	file_proto_gotrain_proto_rawDescOnce sync.Once
+	file_proto_gotrain_proto_rawDescData []byte
+)
+
+func file_proto_gotrain_proto_rawDescGZIP() []byte {
+	file_proto_gotrain_proto_rawDescOnce.Do(func() {
+		file_proto_gotrain_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_gotrain_proto_rawDesc), len(file_proto_gotrain_proto_rawDesc)))
+	})
+	return file_proto_gotrain_proto_rawDescData
+}
+
+var file_proto_gotrain_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_gotrain_proto_goTypes = []any{
+	(*Frequency)(nil),       // 0: gotrain.v1.Frequency
+	(*BallastTrack)(nil),    // 1: gotrain.v1.BallastTrack
+	(*SlabTrack)(nil),       // 2: gotrain.v1.SlabTrack
+	(*SoilLayer)(nil),       // 3: gotrain.v1.SoilLayer
+	(*Config)(nil),          // 4: gotrain.v1.Config
+	(*ComputeRequest)(nil),  // 5: gotrain.v1.ComputeRequest
+	(*Result)(nil),          // 6: gotrain.v1.Result
+	(*ComputeResponse)(nil), // 7: gotrain.v1.ComputeResponse
+}
+var file_proto_gotrain_proto_depIdxs = []int32{
+	0, // 0: gotrain.v1.Config.frequency:type_name -> gotrain.v1.Frequency
+	1, // 1: gotrain.v1.Config.ballast_track:type_name -> gotrain.v1.BallastTrack
+	2, // 2: gotrain.v1.Config.slab_track:type_name -> gotrain.v1.SlabTrack
+	3, // 3: gotrain.v1.Config.soil_layers:type_name -> gotrain.v1.SoilLayer
+	4, // 4: gotrain.v1.ComputeRequest.config:type_name -> gotrain.v1.Config
+	5, // 5: gotrain.v1.CriticalSpeed.Compute:input_type -> gotrain.v1.ComputeRequest
+	7, // 6: gotrain.v1.CriticalSpeed.Compute:output_type -> gotrain.v1.ComputeResponse
+	6, // [6:7] is the sub-list for method output_type
+	5, // [5:6] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_proto_gotrain_proto_init() }
+func file_proto_gotrain_proto_init() {
+	if File_proto_gotrain_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_gotrain_proto_rawDesc), len(file_proto_gotrain_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_gotrain_proto_goTypes,
+		DependencyIndexes: file_proto_gotrain_proto_depIdxs,
+		MessageInfos:      file_proto_gotrain_proto_msgTypes,
+	}.Build()
+	File_proto_gotrain_proto = out.File
+	file_proto_gotrain_proto_goTypes = nil
+	file_proto_gotrain_proto_depIdxs = nil
+}
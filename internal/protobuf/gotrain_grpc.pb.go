@@ -0,0 +1,137 @@
+// Protocol buffer definitions of the GoTrain gRPC service.
+//
+// The messages mirror the YAML configuration files and the JSON result files.
+// Clients for any language can be generated from this file with protoc; the
+// server is implemented in internal/grpc_service and served by cmd/server
+// when started with -grpc-addr. Result files written with output.format
+// "protobuf" contain a single Result message.
+//
+// The Go code in internal/protobuf is generated from this file with make proto.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: proto/gotrain.proto
+
+package protobuf
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CriticalSpeed_Compute_FullMethodName = "/gotrain.v1.CriticalSpeed/Compute"
+)
+
+// CriticalSpeedClient is the client API for CriticalSpeed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CriticalSpeed computes dispersion curves and the critical train speed.
+type CriticalSpeedClient interface {
+	// Compute runs the analysis of one configuration and returns its result.
+	Compute(ctx context.Context, in *ComputeRequest, opts ...grpc.CallOption) (*ComputeResponse, error)
+}
+
+type criticalSpeedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCriticalSpeedClient(cc grpc.ClientConnInterface) CriticalSpeedClient {
+	return &criticalSpeedClient{cc}
+}
+
+func (c *criticalSpeedClient) Compute(ctx context.Context, in *ComputeRequest, opts ...grpc.CallOption) (*ComputeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComputeResponse)
+	err := c.cc.Invoke(ctx, CriticalSpeed_Compute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CriticalSpeedServer is the server API for CriticalSpeed service.
+// All implementations must embed UnimplementedCriticalSpeedServer
+// for forward compatibility.
+//
+// CriticalSpeed computes dispersion curves and the critical train speed.
+type CriticalSpeedServer interface {
+	// Compute runs the analysis of one configuration and returns its result.
+	Compute(context.Context, *ComputeRequest) (*ComputeResponse, error)
+	mustEmbedUnimplementedCriticalSpeedServer()
+}
+
+// UnimplementedCriticalSpeedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCriticalSpeedServer struct{}
+
+func (UnimplementedCriticalSpeedServer) Compute(context.Context, *ComputeRequest) (*ComputeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compute not implemented")
+}
+func (UnimplementedCriticalSpeedServer) mustEmbedUnimplementedCriticalSpeedServer() {}
+func (UnimplementedCriticalSpeedServer) testEmbeddedByValue()                       {}
+
+// UnsafeCriticalSpeedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CriticalSpeedServer will
+// result in compilation errors.
+type UnsafeCriticalSpeedServer interface {
+	mustEmbedUnimplementedCriticalSpeedServer()
+}
+
+func RegisterCriticalSpeedServer(s grpc.ServiceRegistrar, srv CriticalSpeedServer) {
+	// If the following call pancis, it indicates UnimplementedCriticalSpeedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CriticalSpeed_ServiceDesc, srv)
+}
+
+func _CriticalSpeed_Compute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComputeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CriticalSpeedServer).Compute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CriticalSpeed_Compute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CriticalSpeedServer).Compute(ctx, req.(*ComputeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CriticalSpeed_ServiceDesc is the grpc.ServiceDesc for CriticalSpeed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CriticalSpeed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gotrain.v1.CriticalSpeed",
+	HandlerType: (*CriticalSpeedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Compute",
+			Handler:    _CriticalSpeed_Compute_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/gotrain.proto",
+}
@@ -0,0 +1,29 @@
+// Package queue provides a shared job queue used to distribute batch jobs over
+// several machines.
+//
+// A producer pushes messages to a named queue, and any number of workers, possibly
+// running on other machines, pop messages from it. Messages are opaque byte slices;
+// the runner package encodes jobs as JSON.
+//
+// # Backends
+//
+// Queues are opened from a URL. The supported backend is Redis, where the queue is a
+// Redis list (LPUSH by producers, BRPOP by workers):
+//
+//	redis://[[user]:password@]host:port/key
+//
+// The queue uses the go-redis client, which keeps a pool of connections with read
+// and write deadlines: connections dropped by the network are replaced, and commands
+// failing on them are retried on a new connection.
+//
+// # Usage Example
+//
+//	q, err := queue.Open("redis://localhost:6379/gotrain:jobs")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer q.Close()
+//
+//	err = q.Push(ctx, []byte("message"))
+//	message, err := q.Pop(ctx, 5*time.Second) // nil message when the queue stays empty
+package queue
@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Queue defines the interface that shared job queue backends must implement.
+// The context of each call bounds its duration, including its retries after network errors.
+type Queue interface {
+	// Push appends a message to the queue.
+	Push(ctx context.Context, message []byte) error
+	// Pop removes and returns the oldest message of the queue, waiting up to timeout
+	// for a message to arrive. It returns a nil message if the queue stays empty.
+	Pop(ctx context.Context, timeout time.Duration) ([]byte, error)
+	// PushResult appends a message to the result queue associated with the queue.
+	PushResult(ctx context.Context, message []byte) error
+	// Close releases the connection to the backend.
+	Close() error
+}
+
+// Open connects to the queue described by a URL.
+//
+// Parameters:
+//   - rawURL: URL of the queue, e.g. redis://localhost:6379/gotrain:jobs
+//
+// Returns:
+//   - Queue: The connected queue
+//   - error: An error if the URL is invalid or the connection fails
+func Open(rawURL string) (Queue, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid queue URL: %v", err)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" {
+		return nil, fmt.Errorf("queue URL must include a queue name, e.g. redis://localhost:6379/gotrain:jobs")
+	}
+
+	switch u.Scheme {
+	case "redis":
+		password, _ := u.User.Password()
+		return dialRedis(u.Host, u.User.Username(), password, key)
+	default:
+		return nil, fmt.Errorf("unsupported queue scheme: %s. Supported schemes are 'redis'", u.Scheme)
+	}
+}
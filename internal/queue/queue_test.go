@@ -0,0 +1,133 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Test that messages pushed to a Redis queue are popped in FIFO order.
+func TestRedisQueuePushPop(t *testing.T) {
+	server := miniredis.RunT(t)
+	ctx := context.Background()
+
+	q, err := Open("redis://" + server.Addr() + "/jobs")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer q.Close()
+
+	messages := []string{"first", "second\r\nwith newline", ""}
+	for _, m := range messages {
+		if err := q.Push(ctx, []byte(m)); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+	if err := q.PushResult(ctx, []byte("result")); err != nil {
+		t.Fatalf("PushResult failed: %v", err)
+	}
+
+	for _, expected := range messages {
+		message, err := q.Pop(ctx, time.Second)
+		if err != nil {
+			t.Fatalf("Pop failed: %v", err)
+		}
+		if message == nil || string(message) != expected {
+			t.Errorf("expected %q, got %q", expected, message)
+		}
+	}
+
+	message, err := q.Pop(ctx, time.Second)
+	if err != nil || message != nil {
+		t.Errorf("expected empty queue, got %q (err %v)", message, err)
+	}
+
+	if results, err := server.List("jobs:results"); err != nil || len(results) != 1 || results[0] != "result" {
+		t.Errorf("unexpected result list: %v (err %v)", results, err)
+	}
+}
+
+// Test authentication and error replies of the Redis queue.
+func TestRedisQueueAuth(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+	address := server.Addr()
+
+	if _, err := Open("redis://:wrong@" + address + "/jobs"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("expected authentication error, got: %v", err)
+	}
+
+	q, err := Open("redis://:secret@" + address + "/jobs")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer q.Close()
+	if err := q.Push(context.Background(), []byte("job")); err != nil {
+		t.Errorf("Push failed after authentication: %v", err)
+	}
+}
+
+// Test that the queue reconnects after the connection to the server is lost.
+func TestRedisQueueReconnect(t *testing.T) {
+	server := miniredis.RunT(t)
+	ctx := context.Background()
+
+	q, err := Open("redis://" + server.Addr() + "/jobs")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer q.Close()
+	if err := q.Push(ctx, []byte("before")); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	// Restarting the server drops the connections of the client
+	server.Close()
+	if err := server.Restart(); err != nil {
+		t.Fatalf("failed to restart the server: %v", err)
+	}
+	if err := q.Push(ctx, []byte("after")); err != nil {
+		t.Fatalf("expected Push to reconnect, got: %v", err)
+	}
+	if list, err := server.List("jobs"); err != nil || len(list) != 2 || list[0] != "after" {
+		t.Errorf("expected both messages in the queue, got %v (err %v)", list, err)
+	}
+}
+
+// Test that Pop returns when its context expires, before the wait for a message.
+func TestRedisQueuePopDeadline(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	q, err := Open("redis://" + server.Addr() + "/jobs")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer q.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	message, err := q.Pop(ctx, 10*time.Second)
+	if err == nil || message != nil {
+		t.Errorf("expected a deadline error, got %q (err %v)", message, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected a deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected Pop to return at the deadline, took %v", elapsed)
+	}
+}
+
+// Test that invalid queue URLs are rejected.
+func TestOpenInvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"redis://localhost:6379", "nats://localhost:4222/jobs"} {
+		if _, err := Open(rawURL); err == nil {
+			t.Errorf("expected error for %s", rawURL)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisPort = "6379"           // Port used when the queue URL does not specify one
+	redisDialTimeout = 10 * time.Second // Timeout of the connection to the Redis server
+	redisIOTimeout   = 10 * time.Second // Timeout of the reads and writes of a command, beyond the wait of BRPOP
+	redisMaxRetries  = 5                // Attempts of a command after a network error, each on a new connection
+)
+
+// redisQueue is a Queue backed by a Redis list.
+// Messages are pushed with LPUSH and popped with BRPOP, so the list behaves as a FIFO queue.
+// Results are pushed to a second list named after the queue with a ":results" suffix.
+// The client keeps a pool of connections: connections lost to network errors are
+// replaced, and failed commands are retried on a new connection.
+type redisQueue struct {
+	client *redis.Client // Client of the Redis server
+	key    string        // Name of the Redis list holding the jobs
+}
+
+// dialRedis connects to a Redis server and authenticates if a password is given.
+//
+// Parameters:
+//   - address: Host and optional port of the Redis server
+//   - username: User name for AUTH (empty for the default user)
+//   - password: Password for AUTH (empty to skip authentication)
+//   - key: Name of the Redis list holding the jobs
+//
+// Returns:
+//   - *redisQueue: The connected queue
+//   - error: An error if the connection or authentication fails
+func dialRedis(address string, username string, password string, key string) (*redisQueue, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultRedisPort)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:                  address,
+		Username:              username,
+		Password:              password,
+		DialTimeout:           redisDialTimeout,
+		ReadTimeout:           redisIOTimeout,
+		WriteTimeout:          redisIOTimeout,
+		MaxRetries:            redisMaxRetries,
+		ContextTimeoutEnabled: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisDialTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %v", address, err)
+	}
+	return &redisQueue{client: client, key: key}, nil
+}
+
+// Push appends a message to the queue.
+func (q *redisQueue) Push(ctx context.Context, message []byte) error {
+	return q.client.LPush(ctx, q.key, message).Err()
+}
+
+// PushResult appends a message to the result queue.
+func (q *redisQueue) PushResult(ctx context.Context, message []byte) error {
+	return q.client.LPush(ctx, q.key+":results", message).Err()
+}
+
+// Pop removes and returns the oldest message of the queue, waiting up to timeout.
+// Redis timeouts have a resolution of one second; shorter timeouts are rounded up.
+func (q *redisQueue) Pop(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	timeout = max(timeout.Round(time.Second), time.Second)
+	// BRPOP replies with the list name and the popped element
+	reply, err := q.client.BRPop(ctx, timeout, q.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(reply[1]), nil
+}
+
+// Close releases the connections to the Redis server.
+func (q *redisQueue) Close() error {
+	return q.client.Close()
+}
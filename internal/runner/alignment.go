@@ -0,0 +1,400 @@
+package runner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxSpeedRatio is the ratio of the line speed to the critical speed above
+// which a section is reported as speed-critical, unless the alignment sets its own.
+const DefaultMaxSpeedRatio = 0.7
+
+// AlignmentPoint is a point of the centre line of a track alignment.
+type AlignmentPoint struct {
+	Chainage  float64 `yaml:"chainage"`  // Distance along the alignment [m]
+	Longitude float64 `yaml:"longitude"` // Longitude (WGS84) [degrees]
+	Latitude  float64 `yaml:"latitude"`  // Latitude (WGS84) [degrees]
+}
+
+// AlignmentSection is a stretch of a track alignment with a single soil profile.
+// The soil is given as in a configuration file, by soil_layers, soil_profile or
+// soil_cpt; a section without any keeps the soil of the template configuration.
+type AlignmentSection struct {
+	Name          string  `yaml:"name"`           // Name of the section (optional)
+	ChainageStart float64 `yaml:"chainage_start"` // Chainage of the start of the section [m]
+	ChainageEnd   float64 `yaml:"chainage_end"`   // Chainage of the end of the section [m]
+	SoilLayers    any     `yaml:"soil_layers"`    // Soil layers of the section (replaces those of the template)
+	SoilProfile   any     `yaml:"soil_profile"`   // Profile file the soil layers of the section are read from
+	SoilCPT       any     `yaml:"soil_cpt"`       // CPT the soil layers of the section are derived from
+}
+
+// AlignmentSpec describes a track alignment: its route and its sections, each
+// computed with the template configuration and the soil of the section.
+type AlignmentSpec struct {
+	Name          string             `yaml:"name"`            // Name of the alignment (optional)
+	LineSpeed     float64            `yaml:"line_speed"`      // Design speed of the line [km/h] (optional)
+	MaxSpeedRatio float64            `yaml:"max_speed_ratio"` // Largest acceptable line speed / critical speed (defaults to DefaultMaxSpeedRatio)
+	Route         []AlignmentPoint   `yaml:"route"`           // Centre line of the alignment, by increasing chainage (optional)
+	Sections      []AlignmentSection `yaml:"sections"`        // Sections of the alignment, by increasing chainage
+}
+
+// AlignmentOutputs are the files written by RunAlignment.
+type AlignmentOutputs struct {
+	Profile string // CSV file of the critical speed along the route (printed to stdout when empty)
+	GeoJSON string // GeoJSON file with one feature per section (not written when empty)
+}
+
+// RunAlignment computes the critical speed along a track alignment: every section
+// of the alignment is computed with the template configuration and the soil of the
+// section, as RunWithOptions does. The configurations are generated in memory.
+//
+// The alignment is a YAML file listing the route (points with their chainage and
+// WGS84 coordinates) and the sections, by increasing chainage:
+//
+//	name: Line A
+//	line_speed: 200 # km/h
+//	route:
+//	  - {chainage: 0, longitude: 4.90, latitude: 52.37}
+//	  - {chainage: 2500, longitude: 4.93, latitude: 52.38}
+//	sections:
+//	  - name: peat
+//	    chainage_start: 0
+//	    chainage_end: 1200
+//	    soil_layers: [...]
+//	  - chainage_start: 1200
+//	    chainage_end: 2500
+//	    soil_cpt: {file: cpt_1200.gef}
+//
+// The result file of section i is the output file of the template with the suffix
+// _i. The profile lists the critical speed of every section; the GeoJSON file holds
+// a LineString feature per section, cut from the route at its chainages, with the
+// critical speed and, when line_speed is set, the ratio of the line speed to the
+// critical speed and whether it exceeds max_speed_ratio.
+//
+// Parameters:
+//   - templatePath: Path to the template YAML configuration file
+//   - alignmentPath: Path to the alignment YAML file
+//   - outputs: Files receiving the profile and the GeoJSON features
+//   - opts: Options controlling the batch (Order must be empty or OrderAsFound, and
+//     OnExisting must not be critical_speed.ExistingSkip, since every section is needed)
+//
+// Returns:
+//   - error: An error if the files cannot be read or written or the alignment is invalid
+func RunAlignment(templatePath string, alignmentPath string, outputs AlignmentOutputs, opts Options) error {
+	if opts.Order != "" && opts.Order != OrderAsFound {
+		return fmt.Errorf("job order %s cannot be used with an alignment", opts.Order)
+	}
+	if opts.OnExisting == critical_speed.ExistingSkip {
+		return fmt.Errorf("the %s policy for existing result files cannot be used with an alignment", critical_speed.ExistingSkip)
+	}
+
+	template, err := storage.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template config: %v", err)
+	}
+	data, err := storage.ReadFile(alignmentPath)
+	if err != nil {
+		return fmt.Errorf("failed to read alignment: %v", err)
+	}
+	var spec AlignmentSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse alignment: %v", err)
+	}
+
+	jobs, err := expandAlignment(alignmentPath, template, spec)
+	if err != nil {
+		return err
+	}
+	_, results, err := runBatch(jobs, opts, true)
+	if err != nil || results == nil {
+		return err
+	}
+
+	switch {
+	case outputs.Profile != "":
+		var profile strings.Builder
+		if err := writeProfile(&profile, spec, results); err != nil {
+			return err
+		}
+		if err := storage.WriteFile(outputs.Profile, []byte(profile.String())); err != nil {
+			return fmt.Errorf("error writing profile: %v", err)
+		}
+	case opts.Progress == nil && !opts.Quiet:
+		if err := writeProfile(os.Stdout, spec, results); err != nil {
+			return err
+		}
+	}
+	return writeGeoJSON(outputs.GeoJSON, spec, results)
+}
+
+// expandAlignment checks an alignment and generates the job of every section.
+//
+// Parameters:
+//   - alignmentPath: Path to the alignment (used to name the jobs)
+//   - template: Content of the template YAML configuration
+//   - spec: The alignment
+//
+// Returns:
+//   - []Job: One job per section, with its configuration
+//   - error: An error if the template or the alignment is invalid
+func expandAlignment(alignmentPath string, template []byte, spec AlignmentSpec) ([]Job, error) {
+	if len(spec.Sections) == 0 {
+		return nil, fmt.Errorf("alignment has no sections")
+	}
+	if len(spec.Route) == 1 {
+		return nil, fmt.Errorf("route must have at least two points")
+	}
+	for i := 1; i < len(spec.Route); i++ {
+		if spec.Route[i].Chainage <= spec.Route[i-1].Chainage {
+			return nil, fmt.Errorf("route chainages must increase: %g after %g", spec.Route[i].Chainage, spec.Route[i-1].Chainage)
+		}
+	}
+	for i, s := range spec.Sections {
+		if s.ChainageEnd <= s.ChainageStart {
+			return nil, fmt.Errorf("section %s ends before it starts", sectionLabel(s, i))
+		}
+		if i > 0 && s.ChainageStart < spec.Sections[i-1].ChainageEnd {
+			return nil, fmt.Errorf("section %s overlaps the previous section", sectionLabel(s, i))
+		}
+		if len(spec.Route) > 0 && (s.ChainageStart < spec.Route[0].Chainage || s.ChainageEnd > spec.Route[len(spec.Route)-1].Chainage) {
+			return nil, fmt.Errorf("section %s is outside the route", sectionLabel(s, i))
+		}
+	}
+
+	var document map[string]any
+	if err := yaml.Unmarshal(template, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse template config: %v", err)
+	}
+	output, _ := lookupPath(document, "output.file_name")
+	outputName, _ := output.(string)
+	extension := critical_speed.OutputExt(outputName)
+	width := len(strconv.Itoa(len(spec.Sections)))
+
+	jobs := make([]Job, 0, len(spec.Sections))
+	for i, s := range spec.Sections {
+
+		// Replace the soil of the template by the soil of the section
+		section := make(map[string]any, len(document))
+		for key, value := range document {
+			section[key] = value
+		}
+		if s.SoilLayers != nil || s.SoilProfile != nil || s.SoilCPT != nil {
+			delete(section, "soil_layers")
+			delete(section, "soil_profile")
+			delete(section, "soil_cpt")
+			if s.SoilLayers != nil {
+				section["soil_layers"] = s.SoilLayers
+			}
+			if s.SoilProfile != nil {
+				section["soil_profile"] = s.SoilProfile
+			}
+			if s.SoilCPT != nil {
+				section["soil_cpt"] = s.SoilCPT
+			}
+		}
+
+		data, err := yaml.Marshal(section)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding section configuration: %v", err)
+		}
+		config, err := critical_speed.ParseConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for section %s: %v", sectionLabel(s, i), err)
+		}
+		config.Output.FileName = fmt.Sprintf("%s_%0*d%s", strings.TrimSuffix(outputName, extension), width, i+1, extension)
+
+		path := fmt.Sprintf("%s[%s: %g-%g m]", alignmentPath, sectionLabel(s, i), s.ChainageStart, s.ChainageEnd)
+		jobs = append(jobs, Job{path: path, config: &config})
+	}
+	return jobs, nil
+}
+
+// sectionLabel returns the name of a section, or its number when it has no name.
+//
+// Parameters:
+//   - section: The section
+//   - index: Index of the section in the alignment
+//
+// Returns:
+//   - string: The label of the section
+func sectionLabel(section AlignmentSection, index int) string {
+	if section.Name != "" {
+		return section.Name
+	}
+	return strconv.Itoa(index + 1)
+}
+
+// sectionCoordinates cuts the part between two chainages out of the route, with
+// the end points interpolated linearly between the route points.
+//
+// Parameters:
+//   - route: The route, by increasing chainage (at least two points)
+//   - start: Chainage of the start of the part [m]
+//   - end: Chainage of the end of the part [m]
+//
+// Returns:
+//   - [][2]float64: The longitude and latitude of the points of the part
+func sectionCoordinates(route []AlignmentPoint, start float64, end float64) [][2]float64 {
+	at := func(chainage float64) [2]float64 {
+		i := 1
+		for i < len(route)-1 && route[i].Chainage < chainage {
+			i++
+		}
+		a, b := route[i-1], route[i]
+		t := (chainage - a.Chainage) / (b.Chainage - a.Chainage)
+		return [2]float64{a.Longitude + t*(b.Longitude-a.Longitude), a.Latitude + t*(b.Latitude-a.Latitude)}
+	}
+
+	coordinates := [][2]float64{at(start)}
+	for _, p := range route {
+		if p.Chainage > start && p.Chainage < end {
+			coordinates = append(coordinates, [2]float64{p.Longitude, p.Latitude})
+		}
+	}
+	return append(coordinates, at(end))
+}
+
+// sectionProperties returns the properties of a computed section, written to the
+// profile and the GeoJSON features. The critical values are nil for failed sections.
+//
+// Parameters:
+//   - spec: The alignment
+//   - index: Index of the section in the alignment
+//   - result: Outcome of the job of the section
+//
+// Returns:
+//   - map[string]any: The properties of the section
+func sectionProperties(spec AlignmentSpec, index int, result JobResult) map[string]any {
+	section := spec.Sections[index]
+	properties := map[string]any{
+		"section":           sectionLabel(section, index),
+		"chainage_start":    section.ChainageStart,
+		"chainage_end":      section.ChainageEnd,
+		"critical_velocity": nil,
+		"critical_speed":    nil,
+		"critical_omega":    nil,
+	}
+	if spec.LineSpeed > 0 {
+		properties["line_speed"] = spec.LineSpeed
+		properties["speed_ratio"] = nil
+		properties["speed_critical"] = nil
+	}
+	if result.Err != nil {
+		properties["error"] = result.Err.Error()
+		return properties
+	}
+
+	velocity := result.Result.CriticalVelocity
+	properties["critical_velocity"] = velocity
+	properties["critical_speed"] = velocity * 3.6
+	properties["critical_omega"] = result.Result.CriticalOmega
+	if spec.LineSpeed > 0 {
+		maxRatio := spec.MaxSpeedRatio
+		if maxRatio <= 0 {
+			maxRatio = DefaultMaxSpeedRatio
+		}
+		ratio := spec.LineSpeed / (velocity * 3.6)
+		properties["speed_ratio"] = ratio
+		properties["speed_critical"] = ratio > maxRatio
+	}
+	return properties
+}
+
+// profileColumns are the columns of the profile, in order.
+var profileColumns = []string{"section", "chainage_start", "chainage_end", "critical_velocity", "critical_speed", "critical_omega", "speed_ratio", "speed_critical", "error"}
+
+// writeProfile writes the critical speed along the alignment as CSV, one row per
+// section: critical_velocity in m/s, critical_speed in km/h and critical_omega in
+// rad/s. The speed ratio columns are empty without a line speed, and the values of
+// failed sections are empty, with their error.
+//
+// Parameters:
+//   - w: Destination of the CSV data
+//   - spec: The alignment
+//   - results: Outcome of the job of every section, in alignment order
+//
+// Returns:
+//   - error: An error if the data cannot be written
+func writeProfile(w io.Writer, spec AlignmentSpec, results []JobResult) error {
+	writer := csv.NewWriter(w)
+	writer.Write(profileColumns)
+	for i, result := range results {
+		properties := sectionProperties(spec, i, result)
+		row := make([]string, len(profileColumns))
+		for j, column := range profileColumns {
+			switch v := properties[column].(type) {
+			case float64:
+				row[j] = strconv.FormatFloat(v, 'g', -1, 64)
+			case nil:
+			default:
+				row[j] = fmt.Sprint(v)
+			}
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// geoJSONFeature is a feature of a GeoJSON FeatureCollection (RFC 7946).
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties map[string]any   `json:"properties"`
+}
+
+// geoJSONGeometry is the LineString geometry of a GeoJSON feature.
+type geoJSONGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// writeGeoJSON writes the sections of an alignment as a GeoJSON FeatureCollection,
+// with the properties of each section (see sectionProperties). Without a route,
+// the geometry of the features is null.
+//
+// Parameters:
+//   - path: Path of the GeoJSON file (nothing is written when empty)
+//   - spec: The alignment
+//   - results: Outcome of the job of every section, in alignment order
+//
+// Returns:
+//   - error: An error if the file cannot be written
+func writeGeoJSON(path string, spec AlignmentSpec, results []JobResult) error {
+	if path == "" {
+		return nil
+	}
+	features := make([]geoJSONFeature, len(results))
+	for i, result := range results {
+		features[i] = geoJSONFeature{Type: "Feature", Properties: sectionProperties(spec, i, result)}
+		if len(spec.Route) >= 2 {
+			section := spec.Sections[i]
+			features[i].Geometry = &geoJSONGeometry{
+				Type:        "LineString",
+				Coordinates: sectionCoordinates(spec.Route, section.ChainageStart, section.ChainageEnd),
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(map[string]any{
+		"type":     "FeatureCollection",
+		"name":     spec.Name,
+		"features": features,
+	}, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error encoding GeoJSON: %v", err)
+	}
+	if err := storage.WriteFile(path, data); err != nil {
+		return fmt.Errorf("error writing GeoJSON: %v", err)
+	}
+	return nil
+}
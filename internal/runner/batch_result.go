@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"slices"
+	"time"
+)
+
+// Statuses of the jobs of a BatchResult.
+const (
+	StatusSucceeded = "succeeded" // The analysis completed and its result was written
+	StatusFailed    = "failed"    // The analysis failed (see JobSummary.Error)
+	StatusSkipped   = "skipped"   // The job was not run, since its result file exists (Options.OnExisting)
+)
+
+// JobSummary is the outcome of a single job of a BatchResult, without its dispersion
+// curves, so that it can be kept for batches of any size and serialized as JSON.
+type JobSummary struct {
+	Path             string  `json:"path"`                        // Path (or identifier) of the configuration
+	Status           string  `json:"status"`                      // One of the Status constants
+	Error            string  `json:"error,omitempty"`             // Error message (empty unless failed)
+	DurationSeconds  float64 `json:"duration_s"`                  // Time spent processing the job [s]
+	CriticalOmega    float64 `json:"critical_omega,omitempty"`    // Critical angular frequency [rad/s]
+	CriticalVelocity float64 `json:"critical_velocity,omitempty"` // Critical train speed [m/s]
+}
+
+// BatchResult is the full outcome of a batch returned by RunBatch, so that calling
+// programs can react to the failures of individual jobs, which do not make the
+// batch return an error.
+type BatchResult struct {
+	Jobs            []JobSummary `json:"jobs"`       // Every job, in batch order, followed by the skipped jobs
+	Total           int          `json:"total"`      // Number of jobs, including the skipped ones
+	Succeeded       int          `json:"succeeded"`  // Number of successful jobs
+	Failed          int          `json:"failed"`     // Number of failed jobs
+	Skipped         int          `json:"skipped"`    // Number of jobs skipped since their result file exists
+	DurationSeconds float64      `json:"duration_s"` // Wall-clock time of the batch [s]
+	Stats           BatchStats   `json:"-"`          // Statistics of the critical velocities of the successful jobs
+}
+
+// OK reports whether every job of the batch that was run succeeded.
+//
+// Returns:
+//   - bool: True if no job failed
+func (b BatchResult) OK() bool {
+	return b.Failed == 0
+}
+
+// FailedJobs returns the jobs of the batch that failed.
+//
+// Returns:
+//   - []JobSummary: The failed jobs, in batch order
+func (b BatchResult) FailedJobs() []JobSummary {
+	var failed []JobSummary
+	for _, job := range b.Jobs {
+		if job.Status == StatusFailed {
+			failed = append(failed, job)
+		}
+	}
+	return failed
+}
+
+// batchOutcome collects the BatchResult of a batch while its jobs complete. It is
+// updated from the goroutine collecting the job outcomes, so it needs no
+// synchronisation.
+type batchOutcome struct {
+	result  BatchResult // The result being collected
+	indices []int       // Batch index of every processed job in result.Jobs
+	start   time.Time   // Start of the batch
+}
+
+// newBatchOutcome starts collecting the result of a batch.
+//
+// Parameters:
+//   - total: Number of jobs of the batch, including the skipped ones
+//
+// Returns:
+//   - *batchOutcome: The collector
+func newBatchOutcome(total int) *batchOutcome {
+	return &batchOutcome{result: BatchResult{Total: total}, start: time.Now()}
+}
+
+// add records the outcome of a job.
+//
+// Parameters:
+//   - outcome: The outcome of the job
+func (b *batchOutcome) add(outcome jobOutcome) {
+	job := JobSummary{Path: outcome.job.path, Status: StatusSucceeded, DurationSeconds: outcome.duration.Seconds()}
+	if outcome.err != nil {
+		job.Status = StatusFailed
+		job.Error = outcome.err.Error()
+		b.result.Failed++
+	} else {
+		job.CriticalOmega = outcome.result.CriticalOmega
+		job.CriticalVelocity = outcome.result.CriticalVelocity
+		b.result.Succeeded++
+	}
+	b.result.Jobs = append(b.result.Jobs, job)
+	b.indices = append(b.indices, outcome.job.index)
+}
+
+// finish sorts the processed jobs in batch order, appends the skipped jobs and
+// returns the result of the batch.
+//
+// Parameters:
+//   - skipped: The jobs skipped since their result file exists
+//   - stats: The statistics of the critical velocities
+//
+// Returns:
+//   - BatchResult: The result of the batch
+func (b *batchOutcome) finish(skipped []Job, stats BatchStats) BatchResult {
+	order := make([]int, len(b.result.Jobs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(i, j int) int { return b.indices[i] - b.indices[j] })
+	jobs := make([]JobSummary, 0, len(order)+len(skipped))
+	for _, i := range order {
+		jobs = append(jobs, b.result.Jobs[i])
+	}
+	for _, job := range skipped {
+		jobs = append(jobs, JobSummary{Path: job.path, Status: StatusSkipped})
+	}
+
+	result := b.result
+	result.Jobs = jobs
+	result.Skipped = len(skipped)
+	result.DurationSeconds = time.Since(b.start).Seconds()
+	result.Stats = stats
+	return result
+}
+
+// RunBatch processes the YAML configuration files in a directory as RunWithOptions
+// does, and returns the outcome of every job (status, duration, critical values or
+// error message), so that calling programs get the full outcome of the batch rather
+// than a nil error. Failed jobs are reported in the BatchResult and do not make
+// RunBatch return an error. Unlike RunWithResults, the dispersion curves are not
+// kept, so that the result of very large batches fits in memory.
+//
+// Parameters:
+//   - configDir: Directory path to search for YAML configuration files (searched recursively)
+//   - opts: Options controlling the batch
+//
+// Returns:
+//   - BatchResult: The outcome of the batch (empty with opts.DryRun)
+//   - error: An error if directory traversal fails, no YAML files are found or the
+//     batch cannot be started or its outputs written
+func RunBatch(configDir string, opts Options) (BatchResult, error) {
+
+	yamlFiles, err := findConfigs(configDir)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	if err := orderJobs(yamlFiles, opts.Order); err != nil {
+		return BatchResult{}, err
+	}
+	result, _, err := runBatch(dirJobs(yamlFiles), opts, false)
+	return result, err
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	"gopkg.in/yaml.v3"
+)
+
+// configKey returns the hash of the content of a job's configuration, without its
+// output section and the file names of the CSV tables of the other sections, which
+// do not change the result (duplicates write their own tables, see
+// duplicateOutcome). The configuration is loaded when the job was created from a
+// file, and kept in the job so that it is not read again.
+//
+// Parameters:
+//   - job: The job, updated with its loaded configuration
+//
+// Returns:
+//   - string: The hash of the configuration
+//   - bool: False if the configuration cannot be loaded (the job fails when it runs)
+func configKey(job *Job) (string, bool) {
+	if job.err != nil {
+		return "", false
+	}
+	if job.config == nil {
+		loaded, err := critical_speed.LoadConfig(job.path)
+		if err != nil {
+			return "", false
+		}
+		job.config = &loaded
+	}
+
+	resolved := *job.config
+	resolved.Output.FileName = ""
+	resolved.Output.Format = ""
+	resolved.Output.Precision = 0
+	resolved.Embankment.FileName = ""
+	resolved.MovingLoad.FileName = ""
+	resolved.GroundVibration.FileName = ""
+	resolved.Assessment.FileName = ""
+	resolved.Assessment.DopplerFileName = ""
+	resolved.Irregularity.FileName = ""
+	resolved.Train.FileName = ""
+	resolved.Improvement.FileName = ""
+	data, err := yaml.Marshal(resolved)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// dedupJobs finds the jobs of a batch whose configurations have the same content,
+// apart from their output section and table file names (see configKey), so that
+// they are computed once. The first job of every group is kept, in batch order; the
+// others are returned as duplicates of the batch index of that job. Jobs must have
+// their index set.
+//
+// Parameters:
+//   - batch: The jobs of the batch
+//
+// Returns:
+//   - []Job: The jobs to compute, in batch order
+//   - map[int][]Job: The duplicates, by index of the job computing them
+func dedupJobs(batch []Job) ([]Job, map[int][]Job) {
+	first := make(map[string]int)
+	duplicates := make(map[int][]Job)
+	unique := make([]Job, 0, len(batch))
+	for _, job := range batch {
+		key, ok := configKey(&job)
+		if ok {
+			if index, seen := first[key]; seen {
+				duplicates[index] = append(duplicates[index], job)
+				continue
+			}
+			first[key] = job.index
+		}
+		unique = append(unique, job)
+	}
+	return unique, duplicates
+}
+
+// duplicateOutcome derives the outcome of a duplicate job from the outcome of the
+// job that computed its configuration, writing its result file unless result files
+// are skipped.
+//
+// Parameters:
+//   - job: The duplicate job
+//   - computed: The outcome of the job that computed the configuration
+//   - skipResultFiles: If true, no result file is written
+//
+// Returns:
+//   - jobOutcome: The outcome of the duplicate job
+func duplicateOutcome(job Job, computed jobOutcome, skipResultFiles bool) jobOutcome {
+	outcome := jobOutcome{job: job, result: computed.result, err: computed.err}
+	if outcome.err != nil || skipResultFiles {
+		return outcome
+	}
+	config := *job.config
+	if job.output != "" {
+		config.Output.FileName = job.output
+	}
+	if err := critical_speed.SaveResults(computed.result, config); err != nil {
+		outcome.result, outcome.err = critical_speed.Result{}, err
+	}
+	return outcome
+}
@@ -10,8 +10,12 @@
 //
 //   - Recursive directory traversal to discover all YAML configuration files
 //   - Configurable worker pool for parallel processing
-//   - Real-time progress tracking with visual progress bar
-//   - Atomic counting for thread-safe progress reporting
+//   - Progress bar with throughput, failed jobs and estimated time remaining,
+//     redrawn as jobs complete
+//   - Summary of the critical velocities of the batch (minimum, maximum, mean,
+//     standard deviation, percentiles and histogram bins)
+//   - Self-contained HTML report with the results table, the summary statistics
+//     and the dispersion curves of the jobs
 //
 // # Usage
 //
@@ -24,23 +28,226 @@
 //		log.Fatal(err)
 //	}
 //
+// Applications that display their own progress can use RunWithOptions with a
+// Progress callback, which is called once per completed job instead of drawing
+// the console progress bar:
+//
+//	err := runner.RunWithOptions("/path/to/configs", runner.Options{
+//		Workers: 4,
+//		Progress: func(e runner.ProgressEvent) {
+//			fmt.Printf("%d/%d %s (%v)\n", e.Processed, e.Total, e.Path, e.Err)
+//		},
+//	})
+//
+// Go programs consuming the results directly use RunWithResults, which returns the
+// outcome of every job (path, result or error, duration) in dispatch order:
+//
+//	results, err := runner.RunWithResults("/path/to/configs", runner.Options{
+//		Workers:       4,
+//		NoResultFiles: true,
+//	})
+//	for _, r := range results {
+//		fmt.Println(r.Path, r.Result.CriticalVelocity, r.Err)
+//	}
+//
+// Statistics computes the distribution of their critical velocities, as printed in
+// the summary of a console batch:
+//
+//	stats := runner.Statistics(results)
+//	fmt.Println(stats.Min, stats.Percentiles, stats.Histogram)
+//
+// RunBatch returns the typed outcome of a batch without keeping the dispersion
+// curves: the status (succeeded, failed or skipped), duration, critical values or
+// error message of every job, with the counts and statistics of the batch:
+//
+//	batch, err := runner.RunBatch("/path/to/configs", runner.Options{Workers: 4})
+//	for _, job := range batch.FailedJobs() {
+//		fmt.Println(job.Path, job.Error)
+//	}
+//
+// To process an explicit list of jobs in a fixed order, use RunManifest with a
+// manifest file (see the -manifest flag below):
+//
+//	err := runner.RunManifest("jobs.txt", runner.Options{Workers: 4})
+//
+// Parameter studies can be run from a template configuration and a sweep
+// specification with RunSweep, without writing the configurations to disk:
+//
+//	err := runner.RunSweep("template.yaml", "sweep.yaml", runner.Options{Workers: 4})
+//
+// The critical speed along a track alignment, with the soil of each section given
+// by chainage, is computed with RunAlignment, which writes the profile along the
+// route and the sections as GeoJSON features:
+//
+//	err := runner.RunAlignment("template.yaml", "alignment.yaml", runner.AlignmentOutputs{
+//		Profile: "profile.csv",
+//		GeoJSON: "route.geojson",
+//	}, runner.Options{Workers: 4})
+//
+// Applications receiving jobs continuously, such as the HTTP server in
+// internal/server, use a long-lived Pool instead. Results are delivered to the
+// callback and no result files are written:
+//
+//	pool := runner.NewPool(runner.Options{Workers: 4}, func(r runner.JobResult) {
+//		fmt.Printf("%s: %v %v\n", r.Path, r.Result.CriticalVelocity, r.Err)
+//	})
+//	pool.Submit("job-1", config)
+//	pool.Close()
+//
 // Or via the command-line interface:
 //
-//	go run cmd/runner/main.go -dir path/to/configs -workers 4
+//	go run ./cmd/gotrain batch -dir path/to/configs -workers 4
 //
 // Using the compiled binary:
 //
-//	./bin/runner -dir path/to/configs -workers 4
+//	./bin/gotrain batch -dir path/to/configs -workers 4
 //
 // # Command-line Flags
 //
 //	-dir string
-//		Required. Directory containing YAML configuration files.
+//		Required unless -manifest is given. Directory containing YAML configuration files.
 //		The runner will recursively search for all .yaml files.
 //
+//	-manifest string
+//		Optional. File listing the jobs to run, used instead of -dir. Each line
+//		holds the path of a YAML configuration file, optionally followed by the
+//		path of its result file, which overrides the output file name of the
+//		configuration. Jobs are dispatched in the listed order; empty lines and
+//		lines starting with # are ignored.
+//
+//	-template string, -sweep string
+//		Optional. Run a parameter sweep instead of -dir. The sweep specification
+//		lists configuration parameters by path (e.g. soil_layers.0.young_modulus)
+//		and their values; every combination is applied to the template
+//		configuration in memory and computed. The result file of combination i
+//		is the output file of the template with the suffix _i.
+//
+//	-template string, -alignment string
+//		Optional. Compute the critical speed along a track alignment instead of
+//		-dir. The alignment lists the route (chainage, longitude, latitude) and
+//		its sections by chainage, each with its own soil_layers, soil_profile or
+//		soil_cpt; every section is computed with the template configuration. With line_speed, the
+//		sections whose line speed exceeds max_speed_ratio (default 0.7) times the
+//		critical speed are flagged as speed-critical.
+//
+//	-profile string
+//		Optional. CSV file receiving the critical speed of every section of the
+//		alignment (default: printed to stdout).
+//
+//	-geojson string
+//		Optional. GeoJSON file receiving one LineString feature per section of the
+//		alignment, with the critical speed as properties, for GIS mapping.
+//
 //	-workers int
 //		Optional. Number of parallel workers (default: number of logical CPUs).
 //		Controls the level of concurrency for processing configuration files.
+//		The workers share the cores with the frequencies evaluated concurrently
+//		within each job: a running job holds one core of a budget of GOMAXPROCS,
+//		and the frequency loops only use the cores left, so that -workers and the
+//		inner parallelism do not oversubscribe the CPU. With fewer workers than
+//		cores (e.g. -workers 2 on 16 cores), the idle cores speed up the jobs.
+//
+//	-job-logs
+//		Optional. Write a log file (solver warnings, timings, errors) next to
+//		each result file, e.g. results.json is accompanied by results.log.
+//
+//	-order string
+//		Optional. Order in which jobs are dispatched (default: as-found).
+//		Use largest-profile-first to start the most expensive configurations
+//		(most soil layers, over all soil profiles, and frequencies) first, which
+//		balances the load at the tail of large batches, or shuffled for a random
+//		order.
+//
+//	-job-timeout duration
+//		Optional. Maximum duration of a single job, e.g. 10m (default: no limit).
+//		Jobs exceeding the limit are cancelled and reported as failed, so a
+//		single pathological configuration cannot stall the whole batch.
+//
+//	-sqlite string
+//		Optional. Write all results to a single SQLite database instead of one
+//		JSON file per configuration. The database contains a runs table (one row
+//		per configuration with status, error and critical values) and a curves
+//		table (dispersion curves, linked to the runs table through run_id). The
+//		rows of every configuration are committed as it finishes, so an
+//		interrupted batch keeps the runs that finished.
+//
+//	-queue string
+//		Optional. URL of a shared job queue (redis://host:port/name) used to
+//		spread a batch over several machines, together with -role.
+//
+//	-role string
+//		Required with -queue. A producer pushes the configurations found in -dir
+//		to the queue; a worker processes jobs from the queue until it stays empty
+//		for -queue-idle (default: 30s). Workers write result files at the output
+//		path of each configuration and push a summary of each job to the
+//		"<name>:results" list.
+//
+//	-quiet
+//		Optional. Print nothing but failures. Without -quiet, a progress bar is
+//		shown on a terminal; when the output is redirected (e.g. in CI logs), a
+//		plain progress line is printed every 10 seconds instead.
+//
+//	-on-collision string
+//		Optional. Policy when several configurations write to the same result
+//		file, which would silently overwrite earlier results (default: fail).
+//		With fail, the batch is refused and the collisions are listed; with
+//		rename, each of these configurations writes to a name derived from its
+//		path, e.g. results.json of configs/soft/config_1.yaml becomes
+//		results_configs_soft_config_1.json.
+//
+//	-on-existing string
+//		Optional. Policy when a result file already exists, applied before the
+//		batch starts (default: overwrite). With error, the batch is refused and
+//		the existing files are listed; with skip, the configurations whose
+//		result file exists are left out, e.g. to resume an interrupted batch
+//		(not with -alignment); with version-suffix, the result is written to the
+//		first free versioned name, e.g. results_v2.json. Queue workers always
+//		overwrite.
+//
+//	-dry-run, -list
+//		Optional. Print the jobs in dispatch order with their resolved result
+//		files (after -on-collision renaming) and the number of jobs, without
+//		processing them, to check the job selection before a long run.
+//
+//	-soil-cache
+//		Optional. Compute the soil dispersion curve (the most expensive part of
+//		the analysis) only once per unique soil profile and frequency range, and
+//		share it between all jobs of the batch. Useful when many configurations
+//		differ only in their track parameters.
+//
+//	-fast
+//		Optional. Use the fast approximate mode for every job (coarse scan of the
+//		soil phase velocities with interpolated roots), for screening studies.
+//		The critical velocities are within 1% of the default solution.
+//
+//	-dedup
+//		Optional. Compute configurations whose content is identical apart from
+//		their output section only once, e.g. the exact duplicates of a generated
+//		sweep directory. The result is written to the result file of every
+//		duplicate and reported for each of them; duplicates do not write a job
+//		log.
+//
+//	-report string
+//		Optional. Write a self-contained HTML report of the batch (inline styles
+//		and SVG plots, no scripts or external resources) for reviewers who do not
+//		process the result files: the summary statistics with the histogram of
+//		the critical speeds, a table of every job (critical speed and frequency,
+//		duration, error) and the dispersion curves of the successful jobs, with
+//		their critical point.
+//
+//	-report-plots string
+//		Optional. Glob pattern matched against the path or base name of the
+//		configurations whose dispersion curves are plotted in the report, e.g.
+//		'soft_*' (default: all). At most 200 jobs are plotted.
+//
+//	-progress-file string
+//		Optional. JSON file receiving the progress of the batch (see
+//		ProgressState): the number of completed, failed and remaining jobs, the
+//		throughput, the estimated time remaining and whether the batch is done.
+//		It is written at the start, at most every 2 seconds as jobs complete and
+//		at the end, and replaced atomically, so that monitoring tools and batch
+//		schedulers can poll it. Queue workers do not know the size of the batch,
+//		so their total and remaining are 0.
 //
 // # Requirements
 //
@@ -58,5 +265,5 @@
 //
 // The runner will display a progress bar showing the processing status:
 //
-//	[==========================                        ] 50.00% (5/10)
+//	[=========================                         ] 50.00% (5/10) | 2.50 jobs/s | ETA 2s
 package runner
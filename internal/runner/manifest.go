@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// RunManifest processes the configuration files listed in a manifest file, in the
+// order in which they are listed, instead of discovering them in a directory. It lets
+// upstream tooling control exactly which jobs run and in what order.
+//
+// The manifest lists one job per line: the path of a YAML configuration file,
+// optionally followed by the path of its result file, which overrides the output
+// file name of the configuration. Fields are separated by whitespace, so paths
+// must not contain spaces. Empty lines and lines starting with # are ignored.
+// Relative paths are resolved against the working directory, as with -dir.
+//
+//	configs/soft_soil.yaml   results/soft_soil.json
+//	configs/stiff_soil.yaml
+//
+// Parameters:
+//   - manifestPath: Path to the manifest file
+//   - opts: Options controlling the batch (Order must be empty or OrderAsFound)
+//
+// Returns:
+//   - error: An error if the manifest cannot be read or lists no jobs
+func RunManifest(manifestPath string, opts Options) error {
+	if opts.Order != "" && opts.Order != OrderAsFound {
+		return fmt.Errorf("job order %s cannot be used with a manifest, which defines the order of the jobs", opts.Order)
+	}
+
+	jobs, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	_, _, err = runBatch(jobs, opts, false)
+	return err
+}
+
+// readManifest reads the jobs listed in a manifest file (see RunManifest).
+//
+// Parameters:
+//   - manifestPath: Path to the manifest file
+//
+// Returns:
+//   - []Job: The jobs, in the order in which they are listed
+//   - error: An error if the file cannot be read, a line is malformed or no jobs are listed
+func readManifest(manifestPath string) ([]Job, error) {
+	file, err := storage.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %v", err)
+	}
+	defer file.Close()
+
+	var jobs []Job
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			jobs = append(jobs, Job{path: fields[0]})
+		case 2:
+			jobs = append(jobs, Job{path: fields[0], output: fields[1]})
+		default:
+			return nil, fmt.Errorf("invalid manifest line %d: expected a config path and an optional output path", lineNumber)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no jobs found in manifest: %s", manifestPath)
+	}
+	return jobs, nil
+}
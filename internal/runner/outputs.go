@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// Policies for configurations sharing a result file, supported by Options.OnCollision.
+const (
+	CollisionFail   = "fail"   // Refuse to start the batch
+	CollisionRename = "rename" // Derive a unique result file name from each configuration path
+)
+
+// outputPath returns the result file a job writes to.
+//
+// Parameters:
+//   - job: The job
+//
+// Returns:
+//   - string: Path of the result file
+//   - bool: False if the configuration cannot be loaded (the job fails when it runs)
+func outputPath(job Job) (string, bool) {
+	if job.output != "" {
+		return job.output, true
+	}
+	if job.config != nil {
+		return job.config.Output.FileName, true
+	}
+	config, err := critical_speed.LoadConfig(job.path)
+	if err != nil {
+		return "", false
+	}
+	return config.Output.FileName, true
+}
+
+// uniqueOutputName derives a result file name from the configuration path, so that
+// configurations sharing a result file get distinct and reproducible names:
+// results.json of configs/soft/config_1.yaml becomes results_configs_soft_config_1.json.
+//
+// Parameters:
+//   - output: The shared result file
+//   - configPath: Path to the configuration file
+//
+// Returns:
+//   - string: The unique result file name, in the directory of the shared file
+func uniqueOutputName(output string, configPath string) string {
+	extension := critical_speed.OutputExt(output)
+	suffix := strings.TrimSuffix(filepath.ToSlash(filepath.Clean(configPath)), filepath.Ext(configPath))
+	suffix = strings.Trim(strings.NewReplacer("/", "_", ":", "_", ".", "_").Replace(suffix), "_")
+	return fmt.Sprintf("%s_%s%s", strings.TrimSuffix(output, extension), suffix, extension)
+}
+
+// resolveOutputs detects jobs writing to the same result file, which would silently
+// overwrite each other's results, and applies the collision policy. With
+// CollisionRename, every job of a collision gets a name derived from its
+// configuration path, so the names do not depend on the dispatch order.
+//
+// Parameters:
+//   - batch: The jobs of the batch, updated in place when renaming
+//   - policy: One of CollisionFail or CollisionRename (empty means CollisionFail)
+//
+// Returns:
+//   - int: Number of renamed result files
+//   - error: An error listing the collisions with CollisionFail, or for an unsupported policy
+func resolveOutputs(batch []Job, policy string) (int, error) {
+	if policy != "" && policy != CollisionFail && policy != CollisionRename {
+		return 0, fmt.Errorf("invalid collision policy: %s. Supported policies are '%s' or '%s'",
+			policy, CollisionFail, CollisionRename)
+	}
+
+	// Group the jobs by result file, in batch order
+	groups := make(map[string][]int)
+	var outputs []string
+	for i, job := range batch {
+		output, ok := outputPath(job)
+		if !ok {
+			continue
+		}
+		key := output
+		if !storage.IsRemote(output) {
+			abs, err := filepath.Abs(output)
+			if err != nil {
+				abs = filepath.Clean(output)
+			}
+			key = abs
+		}
+		if _, seen := groups[key]; !seen {
+			outputs = append(outputs, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var collisions []string
+	renamed := 0
+	for _, key := range outputs {
+		indices := groups[key]
+		if len(indices) < 2 {
+			continue
+		}
+		if policy == CollisionRename {
+			for _, i := range indices {
+				output, _ := outputPath(batch[i])
+				batch[i].output = uniqueOutputName(output, batch[i].path)
+				renamed++
+			}
+			continue
+		}
+		paths := make([]string, len(indices))
+		for j, i := range indices {
+			paths[j] = batch[i].path
+		}
+		slices.Sort(paths)
+		collisions = append(collisions, fmt.Sprintf("%s is written by %s", key, strings.Join(paths, ", ")))
+	}
+
+	if len(collisions) > 0 {
+		return 0, fmt.Errorf("several configurations write to the same result file (use the %s policy to derive unique names):\n%s",
+			CollisionRename, strings.Join(collisions, "\n"))
+	}
+	return renamed, nil
+}
+
+// resolveExisting applies the policy for existing result files to the jobs of a
+// batch before they are dispatched (see critical_speed.ResolveOutput). With
+// critical_speed.ExistingError, the batch is refused and all existing result files
+// are listed; skipped jobs are removed from the batch, and versioned result files
+// override the output of their jobs.
+//
+// Parameters:
+//   - batch: The jobs of the batch, updated in place when versioning
+//   - policy: One of the critical_speed.Existing constants (empty means overwrite)
+//
+// Returns:
+//   - []Job: The jobs to process, in batch order
+//   - []Job: The skipped jobs, in batch order
+//   - int: Number of versioned result files
+//   - error: An error listing the existing result files with critical_speed.ExistingError,
+//     or for an unsupported policy
+func resolveExisting(batch []Job, policy string) ([]Job, []Job, int, error) {
+	if err := critical_speed.CheckExistingPolicy(policy); err != nil {
+		return nil, nil, 0, err
+	}
+	if policy == "" || policy == critical_speed.ExistingOverwrite {
+		return batch, nil, 0, nil
+	}
+
+	kept := batch[:0:0]
+	var existing []string
+	var skipped []Job
+	versioned := 0
+	for _, job := range batch {
+		output, ok := outputPath(job)
+		if !ok {
+			kept = append(kept, job)
+			continue
+		}
+		resolved, skip, err := critical_speed.ResolveOutput(output, policy)
+		switch {
+		case errors.Is(err, critical_speed.ErrResultExists):
+			existing = append(existing, fmt.Sprintf("%s (written by %s)", output, job.path))
+			continue
+		case err != nil:
+			job.err = err
+		case skip:
+			skipped = append(skipped, job)
+			continue
+		case resolved != output:
+			job.output = resolved
+			versioned++
+		}
+		kept = append(kept, job)
+	}
+
+	if len(existing) > 0 {
+		return nil, nil, 0, fmt.Errorf("%d result files already exist (use the %s, %s or %s policy to keep them):\n%s",
+			len(existing), critical_speed.ExistingSkip, critical_speed.ExistingOverwrite, critical_speed.ExistingVersion,
+			strings.Join(existing, "\n"))
+	}
+	return kept, skipped, versioned, nil
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"sync"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// Pool is a long-lived pool of workers processing configurations submitted one
+// at a time, for applications (such as a server) that receive jobs continuously
+// instead of as a batch. It uses the same workers as Run; result and log files are
+// not written, since results are delivered to the callback.
+type Pool struct {
+	submissions chan Job       // Jobs submitted to the pool
+	done        chan struct{}  // Closed once all submitted jobs have been processed
+	closeOnce   sync.Once      // Ensures the submissions channel is closed once
+	mu          sync.RWMutex   // Guards closed
+	closed      bool           // True once Close has been called
+	pending     sync.WaitGroup // Tracks submissions not yet handed to the workers
+}
+
+// NewPool starts a pool of workers. The callback is called once per completed job,
+// never concurrently.
+//
+// Parameters:
+//   - opts: Options controlling the workers (Order, SQLitePath, JobLogs and Progress are ignored)
+//   - callback: Function called with the outcome of each job
+//
+// Returns:
+//   - *Pool: The started pool
+func NewPool(opts Options, callback func(JobResult)) *Pool {
+	p := &Pool{
+		submissions: make(chan Job),
+		done:        make(chan struct{}),
+	}
+
+	// Configurations may come from untrusted sources, so nothing is written at their output paths
+	opts.JobLogs = false
+	feed := func(jobs chan<- Job) {
+		for job := range p.submissions {
+			jobs <- job
+		}
+	}
+	handle := func(outcome jobOutcome) {
+		callback(outcome.jobResult())
+	}
+
+	go func() {
+		defer close(p.done)
+		processJobs(feed, handle, opts, true)
+	}()
+	return p
+}
+
+// Submit queues a configuration for processing. It blocks while the job queue is
+// full and returns false if the pool has been closed.
+//
+// Parameters:
+//   - id: Identifier of the job, reported as JobResult.Path
+//   - config: The configuration to analyse
+//
+// Returns:
+//   - bool: True if the job was accepted
+func (p *Pool) Submit(id string, config critical_speed.Config) bool {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return false
+	}
+	p.pending.Add(1)
+	p.mu.RUnlock()
+
+	defer p.pending.Done()
+	p.submissions <- Job{path: id, config: &config}
+	return true
+}
+
+// Close stops accepting jobs and waits until all submitted jobs have been processed.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.pending.Wait()
+	p.closeOnce.Do(func() { close(p.submissions) })
+	<-p.done
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressBarWidth is the number of characters of the progress bar.
+const progressBarWidth = 50
+
+// minRedrawInterval limits how often the progress bar is redrawn, so that batches
+// of very fast jobs do not spend their time writing to the console.
+const minRedrawInterval = 100 * time.Millisecond
+
+// plainReportInterval is the interval between plain-text progress lines, used when
+// the console is not a terminal (e.g. in CI logs).
+const plainReportInterval = 10 * time.Second
+
+// progressBar draws the console progress of a batch with its throughput, number
+// of failed jobs and estimated time remaining. It is redrawn when jobs complete,
+// from the goroutine collecting the job outcomes, so it needs no synchronisation.
+// On a terminal, a single line is redrawn in place; otherwise (plain mode) a
+// progress line is printed periodically, without control characters.
+type progressBar struct {
+	out       io.Writer // Destination of the progress bar (the console)
+	plain     bool      // If true, print periodic plain-text lines instead of redrawing a bar
+	total     int64     // Total number of jobs in the batch
+	processed int64     // Number of completed jobs
+	failed    int64     // Number of failed jobs
+	start     time.Time // Start of the batch
+	lastDraw  time.Time // Time of the last redraw
+	lastWidth int       // Length of the last line drawn, to clear leftovers
+}
+
+// newProgressBar creates a progress bar and, on a terminal, draws its initial state.
+//
+// Parameters:
+//   - out: Destination of the progress bar
+//   - total: Total number of jobs in the batch
+//   - plain: If true, print periodic plain-text lines instead of redrawing a bar
+//
+// Returns:
+//   - *progressBar: The progress bar
+func newProgressBar(out io.Writer, total int64, plain bool) *progressBar {
+	p := &progressBar{out: out, plain: plain, total: total, start: time.Now()}
+	if plain {
+		p.lastDraw = p.start
+	} else {
+		p.draw(p.start)
+	}
+	return p
+}
+
+// isTerminal reports whether a file is a terminal (character device).
+//
+// Parameters:
+//   - f: The file, e.g. os.Stdout
+//
+// Returns:
+//   - bool: True if the file is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// update records a completed job and redraws the progress bar, at most once per
+// minRedrawInterval (plainReportInterval in plain mode) except for the last job.
+//
+// Parameters:
+//   - failed: Whether the job failed
+func (p *progressBar) update(failed bool) {
+	p.processed++
+	if failed {
+		p.failed++
+	}
+
+	interval := minRedrawInterval
+	if p.plain {
+		interval = plainReportInterval
+	}
+	now := time.Now()
+	if p.processed < p.total && now.Sub(p.lastDraw) < interval {
+		return
+	}
+	p.draw(now)
+}
+
+// finish ends the progress output, moving to the next line on a terminal.
+func (p *progressBar) finish() {
+	if !p.plain {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// draw writes the progress line. On a terminal, the line is redrawn in place.
+//
+// Parameters:
+//   - now: Current time
+func (p *progressBar) draw(now time.Time) {
+	p.lastDraw = now
+
+	fraction := 0.0
+	if p.total > 0 {
+		fraction = float64(p.processed) / float64(p.total)
+	}
+	line := fmt.Sprintf("%.2f%% (%d/%d)", 100*fraction, p.processed, p.total)
+	if !p.plain {
+		bar := strings.Repeat("=", int(progressBarWidth*fraction))
+		padding := strings.Repeat(" ", progressBarWidth-len(bar))
+		line = fmt.Sprintf("[%s%s] %s", bar, padding, line)
+	}
+	if p.failed > 0 {
+		line += fmt.Sprintf(" %d failed", p.failed)
+	}
+
+	elapsed := now.Sub(p.start)
+	if p.processed > 0 && elapsed > 0 {
+		rate := float64(p.processed) / elapsed.Seconds()
+		line += fmt.Sprintf(" | %.2f jobs/s", rate)
+		if remaining := p.total - p.processed; remaining > 0 {
+			eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+			line += fmt.Sprintf(" | ETA %v", eta.Round(time.Second))
+		} else {
+			line += fmt.Sprintf(" | %v", elapsed.Round(time.Millisecond))
+		}
+	}
+
+	if p.plain {
+		fmt.Fprintf(p.out, "Progress: %s\n", line)
+		return
+	}
+
+	// Overwrite leftovers of a longer previous line
+	clear := ""
+	if len(line) < p.lastWidth {
+		clear = strings.Repeat(" ", p.lastWidth-len(line))
+	}
+	p.lastWidth = len(line)
+	fmt.Fprintf(p.out, "\r%s%s", line, clear)
+}
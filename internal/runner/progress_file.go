@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// progressFileInterval limits how often the progress file is rewritten, so that
+// batches of very fast jobs do not spend their time writing it.
+const progressFileInterval = 2 * time.Second
+
+// ProgressState is the state of a batch written to the progress file set by
+// Options.ProgressFile, so that monitoring tools and batch schedulers can poll it.
+type ProgressState struct {
+	Total          int64     `json:"total"`           // Total number of jobs in the batch (0 when unknown, e.g. in Consume)
+	Completed      int64     `json:"completed"`       // Number of jobs that succeeded
+	Failed         int64     `json:"failed"`          // Number of jobs that failed
+	Remaining      int64     `json:"remaining"`       // Number of jobs not processed yet (0 when the total is unknown)
+	ElapsedSeconds float64   `json:"elapsed_seconds"` // Time since the start of the batch [s]
+	ETASeconds     *float64  `json:"eta_seconds"`     // Estimated time remaining [s] (null until a job has completed or when the total is unknown)
+	JobsPerSecond  float64   `json:"jobs_per_second"` // Average throughput since the start of the batch
+	StartedAt      time.Time `json:"started_at"`      // Start of the batch
+	UpdatedAt      time.Time `json:"updated_at"`      // Time the file was written
+	Done           bool      `json:"done"`            // Whether the batch has finished
+}
+
+// progressFile writes the progress of a batch to a JSON file. It is updated when
+// jobs complete, from the goroutine collecting the job outcomes, so it needs no
+// synchronisation. The file is replaced atomically, so readers never see a partial
+// document.
+type progressFile struct {
+	path      string        // Path of the progress file
+	state     ProgressState // Current state of the batch
+	lastWrite time.Time     // Time of the last write
+	failing   bool          // Whether the last write failed, so that failures are logged once
+}
+
+// newProgressFile creates a progress file and writes the initial state of the batch.
+//
+// Parameters:
+//   - path: Path of the progress file (a local path)
+//   - total: Total number of jobs in the batch (0 when unknown)
+//
+// Returns:
+//   - *progressFile: The progress file
+//   - error: An error if the path is remote or the file cannot be written
+func newProgressFile(path string, total int64) (*progressFile, error) {
+	if storage.IsRemote(path) {
+		return nil, fmt.Errorf("progress file %s must be a local path", path)
+	}
+	start := time.Now()
+	p := &progressFile{path: path, state: ProgressState{Total: total, Remaining: total, StartedAt: start}}
+	if err := p.write(start); err != nil {
+		return nil, fmt.Errorf("error writing progress file: %v", err)
+	}
+	return p, nil
+}
+
+// update records a completed job and rewrites the progress file, at most once per
+// progressFileInterval. Write errors are logged, since the batch does not depend on
+// the file.
+//
+// Parameters:
+//   - failed: Whether the job failed
+func (p *progressFile) update(failed bool) {
+	if failed {
+		p.state.Failed++
+	} else {
+		p.state.Completed++
+	}
+	if p.state.Total > 0 {
+		p.state.Remaining = p.state.Total - p.state.Completed - p.state.Failed
+	}
+
+	now := time.Now()
+	if now.Sub(p.lastWrite) < progressFileInterval {
+		return
+	}
+	p.save(now)
+}
+
+// finish marks the batch as done and writes the final state of the progress file.
+func (p *progressFile) finish() {
+	p.state.Done = true
+	p.save(time.Now())
+}
+
+// save writes the progress file, logging the first of consecutive write errors.
+//
+// Parameters:
+//   - now: Time of the write
+func (p *progressFile) save(now time.Time) {
+	err := p.write(now)
+	if err != nil && !p.failing {
+		log.Printf("Failed to write progress file %s: %v\n", p.path, err)
+	}
+	p.failing = err != nil
+}
+
+// write computes the throughput and estimated time remaining, and replaces the
+// progress file with the current state through a temporary file in the same
+// directory.
+//
+// Parameters:
+//   - now: Time of the write
+//
+// Returns:
+//   - error: An error if the file cannot be written
+func (p *progressFile) write(now time.Time) error {
+	p.lastWrite = now
+	s := &p.state
+	s.UpdatedAt = now
+	elapsed := now.Sub(s.StartedAt)
+	s.ElapsedSeconds = elapsed.Seconds()
+	processed := s.Completed + s.Failed
+	s.JobsPerSecond = 0
+	s.ETASeconds = nil
+	if processed > 0 && elapsed > 0 {
+		s.JobsPerSecond = float64(processed) / elapsed.Seconds()
+		if s.Total > 0 {
+			eta := float64(s.Remaining) / s.JobsPerSecond
+			s.ETASeconds = &eta
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// CreateTemp makes the file private; the progress file is read by other tools
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	"github.com/PlatypusBytes/GoTrain/internal/queue"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// queueTimeout bounds every operation on a shared queue, beyond the wait for a job,
+// so that a worker cut off from the queue fails instead of waiting forever.
+const queueTimeout = time.Minute
+
+// QueuedJob is the message pushed to a shared queue for each configuration file.
+// The configuration is sent by content, so workers do not need access to the
+// producer's file system. Result files are written by the workers, at the output
+// path given in the configuration.
+type QueuedJob struct {
+	Path   string `json:"path"`   // Path of the configuration file on the producer
+	Config string `json:"config"` // Content of the YAML configuration file
+}
+
+// QueuedResult is the message pushed by a worker to the result queue after each job.
+type QueuedResult struct {
+	Path             string  `json:"path"`                        // Path of the configuration file on the producer
+	Worker           string  `json:"worker"`                      // Host name of the worker that processed the job
+	Error            string  `json:"error,omitempty"`             // Error message (empty on success)
+	DurationSeconds  float64 `json:"duration_s"`                  // Time spent processing the job [s]
+	CriticalOmega    float64 `json:"critical_omega,omitempty"`    // Critical angular frequency [rad/s]
+	CriticalVelocity float64 `json:"critical_velocity,omitempty"` // Critical train speed [m/s]
+}
+
+// Produce pushes all YAML configuration files in a directory to a shared queue,
+// to be processed by workers started with Consume, possibly on other machines.
+//
+// Parameters:
+//   - configDir: Directory path to search for YAML configuration files (searched recursively)
+//   - queueURL: URL of the shared queue, e.g. redis://localhost:6379/gotrain:jobs
+//   - order: Job dispatch order (see Options.Order)
+//
+// Returns:
+//   - int: Number of jobs pushed to the queue
+//   - error: An error if the files cannot be read or the queue is unavailable
+func Produce(configDir string, queueURL string, order string) (int, error) {
+
+	yamlFiles, err := findConfigs(configDir)
+	if err != nil {
+		return 0, err
+	}
+	if err := orderJobs(yamlFiles, order); err != nil {
+		return 0, err
+	}
+
+	q, err := queue.Open(queueURL)
+	if err != nil {
+		return 0, err
+	}
+	defer q.Close()
+
+	for i, path := range yamlFiles {
+		data, err := storage.ReadFile(path)
+		if err != nil {
+			return i, fmt.Errorf("failed to read config file: %v", err)
+		}
+		message, err := json.Marshal(QueuedJob{Path: path, Config: string(data)})
+		if err != nil {
+			return i, fmt.Errorf("error encoding job: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+		err = q.Push(ctx, message)
+		cancel()
+		if err != nil {
+			return i, fmt.Errorf("error pushing job to queue: %v", err)
+		}
+	}
+	return len(yamlFiles), nil
+}
+
+// Consume processes jobs from a shared queue filled by Produce, using a pool of
+// workers as Run does. It returns once the queue has been empty for idleTimeout.
+// The outcome of each job is pushed to the result queue as a QueuedResult.
+// Jobs are removed from the queue when they are taken by a worker, so jobs in
+// progress on a machine that stops are not retried.
+//
+// Parameters:
+//   - queueURL: URL of the shared queue, e.g. redis://localhost:6379/gotrain:jobs
+//   - opts: Options controlling the workers (Order, SQLitePath, Dedup and OnExisting are ignored)
+//   - idleTimeout: Time to wait for new jobs before stopping
+//
+// Returns:
+//   - error: An error if the queue is unavailable
+func Consume(queueURL string, opts Options, idleTimeout time.Duration) error {
+
+	q, err := queue.Open(queueURL)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	hostname, _ := os.Hostname()
+	opts.SQLitePath = ""
+	logFailures := opts.Progress == nil
+	consoleOutput := logFailures && !opts.Quiet
+
+	var queueErr error
+	feed := func(jobs chan<- Job) {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), idleTimeout+queueTimeout)
+			message, err := q.Pop(ctx, idleTimeout)
+			cancel()
+			if err != nil {
+				queueErr = fmt.Errorf("error popping job from queue: %v", err)
+				return
+			}
+			if message == nil {
+				return
+			}
+			jobs <- decodeJob(message)
+		}
+	}
+
+	var state *progressFile
+	if opts.ProgressFile != "" {
+		if state, err = newProgressFile(opts.ProgressFile, 0); err != nil {
+			return err
+		}
+	}
+
+	var processed, failed int64
+	var summary batchSummary
+	handle := func(outcome jobOutcome) {
+		processed++
+		summary.add(outcome.result.CriticalVelocity, outcome.err)
+		result := QueuedResult{
+			Path:            outcome.job.path,
+			Worker:          hostname,
+			DurationSeconds: outcome.duration.Seconds(),
+		}
+		if outcome.err != nil {
+			failed++
+			result.Error = outcome.err.Error()
+			if logFailures {
+				log.Printf("Failed on config %s: %v\n", outcome.job.path, outcome.err)
+			}
+		} else {
+			result.CriticalOmega = outcome.result.CriticalOmega
+			result.CriticalVelocity = outcome.result.CriticalVelocity
+		}
+
+		if message, err := json.Marshal(result); err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+			if err := q.PushResult(ctx, message); err != nil {
+				log.Printf("Failed to push result of %s: %v\n", outcome.job.path, err)
+			}
+			cancel()
+		}
+
+		if state != nil {
+			state.update(outcome.err != nil)
+		}
+		if opts.Progress != nil {
+			opts.Progress(ProgressEvent{
+				Path:      outcome.job.path,
+				Err:       outcome.err,
+				Duration:  outcome.duration,
+				Processed: processed,
+			})
+		}
+	}
+
+	if consoleOutput {
+		fmt.Printf("Waiting for jobs from %s\n", queueURL)
+	}
+	processJobs(feed, handle, opts, false)
+	if state != nil {
+		state.finish()
+	}
+
+	if consoleOutput {
+		fmt.Printf("Completed processing %d jobs (%d failed)\n", processed, failed)
+		summary.stats().write(os.Stdout)
+	}
+	return queueErr
+}
+
+// decodeJob converts a queue message into a Job. Messages that cannot be decoded
+// produce a job carrying the decoding error, so the failure is reported through
+// the normal job outcome.
+//
+// Parameters:
+//   - message: Message popped from the queue
+//
+// Returns:
+//   - Job: The job to process
+func decodeJob(message []byte) Job {
+	var queued QueuedJob
+	if err := json.Unmarshal(message, &queued); err != nil {
+		return Job{path: "<invalid message>", err: fmt.Errorf("error decoding job: %v", err)}
+	}
+
+	config, err := critical_speed.ParseConfig([]byte(queued.Config))
+	if err != nil {
+		return Job{path: queued.Path, err: fmt.Errorf("error loading configuration: %v", err)}
+	}
+	return Job{path: queued.Path, config: &config}
+}
@@ -0,0 +1,404 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+)
+
+// maxReportPlots limits the number of dispersion curves plotted in an HTML report,
+// so that the reports of large batches stay small enough to be shared.
+const maxReportPlots = 200
+
+// Size of the plots of an HTML report [px].
+const (
+	plotWidth  = 640
+	plotHeight = 360
+	plotMargin = 56
+)
+
+// reportRow is the outcome of a job in an HTML report.
+type reportRow struct {
+	Index            int           // Position of the job in the batch
+	Path             string        // Path (or identifier) of the configuration
+	Error            string        // Error of the job (empty on success)
+	Duration         time.Duration // Time spent processing the job
+	CriticalVelocity float64       // Critical train speed [m/s]
+	CriticalSpeed    float64       // Critical train speed [km/h]
+	CriticalOmega    float64       // Critical frequency, in the unit of the result
+	FrequencyUnit    string        // Unit of the critical frequency
+	Plot             template.HTML // SVG plot of the dispersion curves (empty when not plotted)
+}
+
+// batchReport collects the outcomes of the jobs of a batch for an HTML report. It is
+// updated when jobs complete, from the goroutine collecting the job outcomes, so it
+// needs no synchronisation; the curves of the plotted jobs are rendered as they
+// complete, so that the results are not kept in memory.
+type batchReport struct {
+	pattern string      // Glob pattern of the plotted configurations (empty plots all)
+	rows    []reportRow // Outcome of every job
+	plots   int         // Number of plotted jobs
+	omitted int         // Number of selected jobs not plotted beyond maxReportPlots
+}
+
+// newBatchReport creates the report of a batch.
+//
+// Parameters:
+//   - pattern: Glob pattern matched against the path or base name of the
+//     configurations whose curves are plotted, e.g. "soft_*.yaml" (empty plots all)
+//
+// Returns:
+//   - *batchReport: The report
+//   - error: An error if the pattern is malformed
+func newBatchReport(pattern string) (*batchReport, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid report plot pattern %q: %v", pattern, err)
+	}
+	return &batchReport{pattern: pattern}, nil
+}
+
+// selected reports whether the curves of a configuration are plotted.
+//
+// Parameters:
+//   - path: Path (or identifier) of the configuration
+//
+// Returns:
+//   - bool: True if the path or its base name matches the pattern
+func (r *batchReport) selected(path string) bool {
+	if r.pattern == "" {
+		return true
+	}
+	full, _ := filepath.Match(r.pattern, path)
+	base, _ := filepath.Match(r.pattern, filepath.Base(path))
+	return full || base
+}
+
+// add records the outcome of a job, plotting its dispersion curves when it succeeded
+// and is selected.
+//
+// Parameters:
+//   - outcome: The outcome of the job
+func (r *batchReport) add(outcome jobOutcome) {
+	row := reportRow{Index: outcome.job.index, Path: outcome.job.path, Duration: outcome.duration}
+	if outcome.err != nil {
+		row.Error = outcome.err.Error()
+		r.rows = append(r.rows, row)
+		return
+	}
+
+	result := outcome.result
+	_, unit, factor := result.FrequencyAxis()
+	row.CriticalVelocity = result.CriticalVelocity
+	row.CriticalSpeed = result.CriticalVelocity * 3.6
+	row.CriticalOmega = result.CriticalOmega * factor
+	row.FrequencyUnit = unit
+	if r.selected(row.Path) {
+		if r.plots < maxReportPlots {
+			row.Plot = plotDispersion(result)
+			r.plots++
+		} else {
+			r.omitted++
+		}
+	}
+	r.rows = append(r.rows, row)
+}
+
+// write renders the report as a self-contained HTML page (inline styles and SVG
+// plots, no scripts or external resources) and writes it to a file.
+//
+// Parameters:
+//   - path: Path of the HTML file, or s3:// or gs:// URL
+//   - stats: The statistics of the batch
+//
+// Returns:
+//   - error: An error if the report cannot be rendered or written
+func (r *batchReport) write(path string, stats BatchStats) error {
+	slices.SortFunc(r.rows, func(a, b reportRow) int { return a.Index - b.Index })
+	var plotted []reportRow
+	for _, row := range r.rows {
+		if row.Plot != "" {
+			plotted = append(plotted, row)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := reportTemplate.Execute(&buf, struct {
+		Generated string
+		Stats     BatchStats
+		Rows      []reportRow
+		Plotted   []reportRow
+		Omitted   int
+		Histogram template.HTML
+	}{
+		Generated: time.Now().Format(time.RFC1123),
+		Stats:     stats,
+		Rows:      r.rows,
+		Plotted:   plotted,
+		Omitted:   r.omitted,
+		Histogram: plotHistogram(stats),
+	})
+	if err != nil {
+		return fmt.Errorf("error rendering report: %v", err)
+	}
+	return storage.WriteFile(path, buf.Bytes())
+}
+
+// plotScale maps values linearly onto a range of pixels.
+type plotScale struct {
+	min, max  float64 // Range of the values
+	low, high float64 // Pixels of the minimum and maximum values
+}
+
+// at returns the pixel of a value.
+func (s plotScale) at(v float64) float64 {
+	if s.max == s.min {
+		return (s.low + s.high) / 2
+	}
+	return s.low + (v-s.min)/(s.max-s.min)*(s.high-s.low)
+}
+
+// ticks returns five evenly spaced values covering the range.
+func (s plotScale) ticks() []float64 {
+	ticks := make([]float64, 5)
+	for i := range ticks {
+		ticks[i] = s.min + float64(i)/4*(s.max-s.min)
+	}
+	return ticks
+}
+
+// plotDispersion plots the track and soil dispersion curves of a result, with the
+// critical point, as inline SVG (see dispersionSVG).
+//
+// Parameters:
+//   - result: The result
+//
+// Returns:
+//   - template.HTML: The SVG element, or a note when there are no curves to plot
+func plotDispersion(result critical_speed.Result) template.HTML {
+	svg, ok := dispersionSVG(result)
+	if !ok {
+		return template.HTML(`<p class="muted">No dispersion curves to plot.</p>`)
+	}
+	return template.HTML(svg)
+}
+
+// WriteDispersionSVG writes the track and soil dispersion curves of a result, with
+// the critical point, to a standalone SVG file, as they are plotted in the HTML
+// report of a batch.
+//
+// Parameters:
+//   - path: Path of the SVG file (local path, or s3:// or gs:// URL)
+//   - result: The result
+//
+// Returns:
+//   - error: An error if the result has no curves to plot or the file cannot be written
+func WriteDispersionSVG(path string, result critical_speed.Result) error {
+	svg, ok := dispersionSVG(result)
+	if !ok {
+		return fmt.Errorf("no dispersion curves to plot")
+	}
+	svg = strings.Replace(svg, ">", `><style>svg { font: 12px sans-serif; } text { fill: #222; }</style>`, 1)
+	return storage.WriteFile(path, []byte(svg+"\n"))
+}
+
+// dispersionSVG plots the track and soil dispersion curves of a result, with the
+// critical point, as an SVG element. Frequencies without a root (NaN, or zero for
+// the track) are left as gaps in the curves.
+//
+// Parameters:
+//   - result: The result
+//
+// Returns:
+//   - string: The SVG element
+//   - bool: False if the result has no curves to plot
+func dispersionSVG(result critical_speed.Result) (string, bool) {
+	_, unit, factor := result.FrequencyAxis()
+	valid := func(v float64) bool { return v > 0 && !math.IsInf(v, 0) }
+
+	x := plotScale{min: math.Inf(1), max: math.Inf(-1), low: plotMargin, high: plotWidth - plotMargin/2}
+	y := plotScale{min: math.Inf(1), max: math.Inf(-1), low: plotHeight - plotMargin, high: plotMargin / 2}
+	for i, w := range result.Omega {
+		x.min, x.max = math.Min(x.min, w*factor), math.Max(x.max, w*factor)
+		for _, curve := range [][]float64{result.TrackPhaseVelocity, result.SoilPhaseVelocity} {
+			if i < len(curve) && valid(curve[i]) {
+				y.min, y.max = math.Min(y.min, curve[i]), math.Max(y.max, curve[i])
+			}
+		}
+	}
+	if math.IsInf(x.min, 0) || math.IsInf(y.min, 0) {
+		return "", false
+	}
+	y.min = 0
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" role="img">`,
+		plotWidth, plotHeight, plotWidth, plotHeight)
+	plotAxes(&svg, x, y, fmt.Sprintf("Frequency [%s]", unit), "Phase velocity [m/s]")
+
+	for _, curve := range []struct {
+		values []float64
+		color  string
+	}{{result.TrackPhaseVelocity, "#1f77b4"}, {result.SoilPhaseVelocity, "#ff7f0e"}} {
+		var path strings.Builder
+		gap := true
+		for i, w := range result.Omega {
+			if i >= len(curve.values) || !valid(curve.values[i]) {
+				gap = true
+				continue
+			}
+			command := "L"
+			if gap {
+				command = "M"
+			}
+			fmt.Fprintf(&path, "%s%.1f %.1f ", command, x.at(w*factor), y.at(curve.values[i]))
+			gap = false
+		}
+		fmt.Fprintf(&svg, `<path d="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.TrimSpace(path.String()), curve.color)
+	}
+
+	if valid(result.CriticalVelocity) {
+		fmt.Fprintf(&svg, `<circle cx="%.1f" cy="%.1f" r="5" fill="#d62728"><title>Critical speed %.2f m/s</title></circle>`,
+			x.at(result.CriticalOmega*factor), y.at(result.CriticalVelocity), result.CriticalVelocity)
+	}
+	legendX := float64(plotWidth - plotMargin/2 - 150)
+	for i, entry := range []struct{ name, color string }{{"Track", "#1f77b4"}, {"Soil", "#ff7f0e"}, {"Critical point", "#d62728"}} {
+		legendY := float64(plotMargin/2 + 14 + 18*i)
+		fmt.Fprintf(&svg, `<rect x="%.0f" y="%.0f" width="12" height="12" fill="%s"/><text x="%.0f" y="%.0f">%s</text>`,
+			legendX, legendY-10, entry.color, legendX+18, legendY, entry.name)
+	}
+	svg.WriteString(`</svg>`)
+	return svg.String(), true
+}
+
+// plotHistogram plots the histogram of the critical velocities of a batch as inline SVG.
+//
+// Parameters:
+//   - stats: The statistics of the batch
+//
+// Returns:
+//   - template.HTML: The SVG element (empty without critical velocities)
+func plotHistogram(stats BatchStats) template.HTML {
+	if len(stats.Histogram) == 0 {
+		return ""
+	}
+	largest := 0
+	for _, bin := range stats.Histogram {
+		largest = max(largest, bin.Count)
+	}
+	x := plotScale{min: stats.Histogram[0].Low, max: stats.Histogram[len(stats.Histogram)-1].High, low: plotMargin, high: plotWidth - plotMargin/2}
+	y := plotScale{min: 0, max: float64(largest), low: plotHeight - plotMargin, high: plotMargin / 2}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" role="img">`,
+		plotWidth, plotHeight, plotWidth, plotHeight)
+	plotAxes(&svg, x, y, "Critical speed [m/s]", "Jobs")
+	for _, bin := range stats.Histogram {
+		left, right := x.at(bin.Low), x.at(bin.High)
+		if x.max == x.min {
+			left, right = plotMargin, plotWidth-plotMargin/2
+		}
+		top := y.at(float64(bin.Count))
+		fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#1f77b4" stroke="#fff"><title>%.2f-%.2f m/s: %d</title></rect>`,
+			left, top, math.Max(right-left, 1), y.at(0)-top, bin.Low, bin.High, bin.Count)
+	}
+	svg.WriteString(`</svg>`)
+	return template.HTML(svg.String())
+}
+
+// plotAxes draws the axes of a plot with their ticks and labels.
+//
+// Parameters:
+//   - svg: The SVG element being written
+//   - x, y: Scales of the horizontal and vertical axes
+//   - xLabel, yLabel: Labels of the axes
+func plotAxes(svg *strings.Builder, x, y plotScale, xLabel, yLabel string) {
+	fmt.Fprintf(svg, `<g stroke="#444"><line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f"/><line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f"/></g>`,
+		x.low, y.low, x.high, y.low, x.low, y.low, x.low, y.high)
+	for _, v := range x.ticks() {
+		fmt.Fprintf(svg, `<text x="%.1f" y="%.0f" text-anchor="middle">%.4g</text>`, x.at(v), y.low+18, v)
+	}
+	for _, v := range y.ticks() {
+		fmt.Fprintf(svg, `<line x1="%.0f" y1="%.1f" x2="%.0f" y2="%.1f" stroke="#ddd"/><text x="%.0f" y="%.1f" text-anchor="end">%.4g</text>`,
+			x.low, y.at(v), x.high, y.at(v), x.low-6, y.at(v)+4, v)
+	}
+	fmt.Fprintf(svg, `<text x="%.0f" y="%d" text-anchor="middle">%s</text>`, (x.low+x.high)/2, plotHeight-12, template.HTMLEscapeString(xLabel))
+	fmt.Fprintf(svg, `<text transform="translate(14 %.0f) rotate(-90)" text-anchor="middle">%s</text>`, (y.low+y.high)/2, template.HTMLEscapeString(yLabel))
+}
+
+// reportTemplate is the page of an HTML batch report.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(d time.Duration) string { return fmt.Sprintf("%.2f", d.Seconds()) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GoTrain batch report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: right; }
+th { background: #f0f0f0; }
+td.path, th.path { text-align: left; }
+tr.failed td { background: #fde8e8; }
+td.error { text-align: left; color: #a00; }
+svg { font-size: 12px; max-width: 100%; height: auto; }
+svg text { fill: #222; }
+.muted { color: #777; }
+figure { margin: 1.5em 0; }
+</style>
+</head>
+<body>
+<h1>GoTrain batch report</h1>
+<p class="muted">Generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th class="path">Jobs</th><td>{{len .Rows}}</td></tr>
+<tr><th class="path">Failed</th><td>{{.Stats.Failed}}</td></tr>
+{{- if .Stats.Count}}
+<tr><th class="path">Critical speed min [m/s]</th><td>{{printf "%.2f" .Stats.Min}}</td></tr>
+<tr><th class="path">Critical speed max [m/s]</th><td>{{printf "%.2f" .Stats.Max}}</td></tr>
+<tr><th class="path">Critical speed mean [m/s]</th><td>{{printf "%.2f" .Stats.Mean}}</td></tr>
+<tr><th class="path">Critical speed std [m/s]</th><td>{{printf "%.2f" .Stats.StdDev}}</td></tr>
+{{- range .Stats.Percentiles}}
+<tr><th class="path">P{{.Level}} [m/s]</th><td>{{printf "%.2f" .Value}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{.Histogram}}
+
+<h2>Results</h2>
+<table>
+<tr><th class="path">Configuration</th><th>Critical speed [m/s]</th><th>Critical speed [km/h]</th><th>Critical frequency</th><th>Duration [s]</th><th class="path">Error</th></tr>
+{{- range .Rows}}
+{{- if .Error}}
+<tr class="failed"><td class="path">{{.Path}}</td><td></td><td></td><td></td><td>{{seconds .Duration}}</td><td class="error">{{.Error}}</td></tr>
+{{- else}}
+<tr><td class="path">{{if .Plot}}<a href="#job-{{.Index}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}</td><td>{{printf "%.2f" .CriticalVelocity}}</td><td>{{printf "%.1f" .CriticalSpeed}}</td><td>{{printf "%.2f" .CriticalOmega}} {{.FrequencyUnit}}</td><td>{{seconds .Duration}}</td><td></td></tr>
+{{- end}}
+{{- end}}
+</table>
+
+{{- if .Plotted}}
+<h2>Dispersion curves</h2>
+{{- if .Omitted}}
+<p class="muted">{{.Omitted}} more selected configurations are not plotted.</p>
+{{- end}}
+{{- range .Plotted}}
+<figure id="job-{{.Index}}">
+<figcaption>{{.Path}}: critical speed {{printf "%.2f" .CriticalVelocity}} m/s</figcaption>
+{{.Plot}}
+</figure>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -1,76 +1,239 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/fs"
+	"io"
 	"log"
-	"path/filepath"
-	"strings"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"slices"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
 )
 
 // Job represents a single YAML configuration file to be processed.
-// It contains the file path that will be passed to the critical_speed analyzer.
+// It contains the file path that will be passed to the critical_speed analyzer,
+// or the configuration itself when it was received from a shared queue.
 type Job struct {
-	path string // Path to the YAML configuration file
+	path   string                 // Path to the YAML configuration file
+	config *critical_speed.Config // Configuration received from a queue (nil when loaded from path)
+	output string                 // Result file overriding the output of the configuration (empty to keep it)
+	index  int                    // Position of the job in the batch
+	err    error                  // Error found while preparing the job, reported without running it
+}
+
+// Job dispatch orders supported by Options.Order.
+const (
+	OrderAsFound             = "as-found"              // Dispatch jobs in directory traversal order
+	OrderShuffled            = "shuffled"              // Dispatch jobs in random order
+	OrderLargestProfileFirst = "largest-profile-first" // Dispatch the most expensive configurations first
+)
+
+// ProgressEvent describes the completion of a single job in a batch.
+// It is passed to the Progress callback of Options after every job, whether
+// the job succeeded or failed.
+type ProgressEvent struct {
+	Path      string        // Path to the YAML configuration file
+	Err       error         // Error returned by the analysis (nil on success)
+	Duration  time.Duration // Time spent processing the job
+	Processed int64         // Number of jobs completed so far, including this one
+	Total     int64         // Total number of jobs in the batch (0 when unknown, e.g. in Consume)
+}
+
+// JobResult holds the outcome of a single job.
+type JobResult struct {
+	Path     string                // Path (or identifier) of the configuration
+	Result   critical_speed.Result // Result of the analysis (empty on failure)
+	Err      error                 // Error returned by the analysis (nil on success)
+	Duration time.Duration         // Time spent processing the job
+}
+
+// Options configures a batch run started with RunWithOptions.
+type Options struct {
+	Workers       int                    // Number of worker goroutines (defaults to runtime.NumCPU() when <= 0)
+	Progress      func(ProgressEvent)    // Called after every job; replaces the console progress bar when set
+	JobLogs       bool                   // If true, each job writes a log file next to its result file
+	Order         string                 // Job dispatch order (defaults to OrderAsFound when empty)
+	JobTimeout    time.Duration          // Maximum duration of a single job (no limit when <= 0)
+	SQLitePath    string                 // If set, all results are written to this SQLite database instead of JSON files
+	NoResultFiles bool                   // If true, no JSON result files are written (e.g. when results are returned by RunWithResults)
+	Quiet         bool                   // If true, nothing is printed to the console except failures
+	OnCollision   string                 // Policy for configurations sharing a result file (defaults to CollisionFail when empty)
+	DryRun        bool                   // If true, the jobs and their result files are listed without being processed
+	SoilCache     *soil_dispersion.Cache // If set, jobs with identical soil profiles and frequencies share their soil dispersion curve
+	Fast          bool                   // If true, every job uses the fast approximate mode (see critical_speed.Options)
+	ProgressFile  string                 // If set, the progress of the batch is written periodically to this JSON file (see ProgressState)
+	Dedup         bool                   // If true, configurations with the same content (apart from their output section) are computed once
+	OnExisting    string                 // Policy for result files that already exist (see critical_speed.ResolveOutput; defaults to overwriting them)
+	Report        string                 // If set, a self-contained HTML report of the batch is written to this file
+	ReportPlots   string                 // Glob pattern of the configurations whose curves are plotted in the report (empty plots all, up to 200)
+}
+
+// jobOutcome holds the outcome of a single job, sent from a worker to the collector.
+type jobOutcome struct {
+	job      Job                   // The processed job
+	result   critical_speed.Result // Result of the analysis (empty on failure)
+	err      error                 // Error returned by the analysis (nil on success)
+	duration time.Duration         // Time spent processing the job
+}
+
+// jobResult converts the outcome of a job into the JobResult given to library callers.
+func (o jobOutcome) jobResult() JobResult {
+	return JobResult{Path: o.job.path, Result: o.result, Err: o.err, Duration: o.duration}
 }
 
 // worker processes jobs from the jobs channel concurrently.
 // It continuously reads Job items from the jobs channel, executes the critical_speed
-// analyzer on each configuration file, and increments the processed count.
-// If an error occurs during processing, it logs the error but continues with the next job.
+// analyzer on each configuration file, and sends the outcome to the outcomes channel.
+// If an error occurs during processing, it is reported in the outcome and the worker
+// continues with the next job.
 // The worker signals completion to the WaitGroup when the jobs channel is closed.
 //
 // Parameters:
-//   - id: Unique identifier for the worker goroutine (used in error logging)
 //   - jobs: Receive-only channel from which Job items are read for processing
+//   - outcomes: Send-only channel to which the outcome of each job is written
 //   - wg: WaitGroup used to signal when the worker has completed all jobs
-//   - processedCount: Atomic counter incremented for each successfully processed job
-func worker(id int, jobs <-chan Job, wg *sync.WaitGroup, processedCount *atomic.Int64) {
+//   - runOpts: Options passed to the critical_speed analyzer for every job
+//   - timeout: Maximum duration of a single job (no limit when <= 0)
+//   - budget: Concurrency budget of which a token is held while a job runs (nil to hold none)
+func worker(jobs <-chan Job, outcomes chan<- jobOutcome, wg *sync.WaitGroup, runOpts critical_speed.Options, timeout time.Duration, budget *math_utils.Budget) {
 	defer wg.Done()
 
 	for job := range jobs {
+		start := time.Now()
 
 		// Execute the critical_speed with the YAML file
-		if err := critical_speed.Run(job.path, false); err != nil {
-			log.Printf("Worker %d: Failed on config %s: %v\n", id, job.path, err)
+		if budget != nil {
+			budget.Acquire()
+		}
+		result, err := runJob(job, runOpts, timeout)
+		if budget != nil {
+			budget.Release()
 		}
 
-		processedCount.Add(1)
+		outcomes <- jobOutcome{job: job, result: result, err: err, duration: time.Since(start)}
 	}
 }
 
-// reportProgress prints the current processing progress with a visual progress bar.
-// It runs in a separate goroutine and updates the console every second with a progress bar
-// showing the percentage of completed jobs. The progress bar has a fixed width of 50 characters
-// and displays both percentage completion and absolute counts (processed/total).
-// The function terminates when a signal is received on the done channel.
+// runJob executes the critical_speed analyzer for a single job, cancelling it
+// when it exceeds the timeout.
 //
 // Parameters:
-//   - processed: Atomic counter tracking the number of processed jobs (read concurrently)
-//   - total: Total number of jobs to be processed
-//   - done: Receive-only channel that signals when progress reporting should stop
-func reportProgress(processed *atomic.Int64, total int64, done <-chan struct{}) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+//   - job: The job to process
+//   - runOpts: Options passed to the critical_speed analyzer
+//   - timeout: Maximum duration of the job (no limit when <= 0)
+//
+// Returns:
+//   - critical_speed.Result: Result of the analysis
+//   - error: An error if the analysis fails or exceeds the timeout
+func runJob(job Job, runOpts critical_speed.Options, timeout time.Duration) (critical_speed.Result, error) {
+	if job.err != nil {
+		return critical_speed.Result{}, job.err
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			count := processed.Load()
-			percent := float64(count) / float64(total) * 100
-			width := 50
-			bar := strings.Repeat("=", int(float64(width)*float64(count)/float64(total)))
-			padding := strings.Repeat(" ", width-len(bar))
-			fmt.Printf("\r[%s%s] %.2f%% (%d/%d)", bar, padding, percent, count, total)
-		case <-done:
-			return
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	config := job.config
+	if config == nil && job.output != "" {
+		loaded, err := critical_speed.LoadConfig(job.path)
+		if err != nil {
+			return critical_speed.Result{}, fmt.Errorf("error loading configuration: %v", err)
 		}
+		config = &loaded
+	}
+	if config != nil && job.output != "" {
+		overridden := *config
+		overridden.Output.FileName = job.output
+		config = &overridden
+	}
+
+	var result critical_speed.Result
+	var err error
+	if config != nil {
+		result, err = critical_speed.RunConfig(ctx, *config, job.path, runOpts)
+	} else {
+		result, err = critical_speed.RunContext(ctx, job.path, runOpts)
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return result, fmt.Errorf("job exceeded timeout of %v", timeout)
+	}
+	return result, err
+}
+
+// jobCost estimates the computational cost of a configuration file.
+// The cost of the soil dispersion dominates the analysis and scales with the number
+// of soil layers times the number of frequencies, summed over the soil profiles: the
+// layers of soil_layers, soil_profile or soil_cpt, or of every profile of
+// soil_profiles, as they are analysed (see critical_speed.SoilLayerCounts).
+// Configurations or soils that cannot be loaded get a cost of zero, since they fail
+// immediately.
+//
+// Parameters:
+//   - path: Path to the YAML configuration file
+//
+// Returns:
+//   - int: Estimated relative cost of the job
+func jobCost(path string) int {
+	config, err := critical_speed.LoadConfig(path)
+	if err != nil {
+		return 0
+	}
+	omega, err := critical_speed.Frequencies(config)
+	if err != nil {
+		return 0
+	}
+	counts, err := critical_speed.SoilLayerCounts(config)
+	if err != nil {
+		return 0
+	}
+	layers := 0
+	for _, count := range counts {
+		layers += count
+	}
+	return layers * len(omega)
+}
+
+// orderJobs sorts the configuration files in place according to the dispatch order.
+//
+// Parameters:
+//   - paths: Paths to the YAML configuration files, in directory traversal order
+//   - order: One of OrderAsFound, OrderShuffled or OrderLargestProfileFirst (empty means OrderAsFound)
+//
+// Returns:
+//   - error: An error if the order is not supported
+func orderJobs(paths []string, order string) error {
+	switch order {
+	case "", OrderAsFound:
+	case OrderShuffled:
+		rand.Shuffle(len(paths), func(i, j int) {
+			paths[i], paths[j] = paths[j], paths[i]
+		})
+	case OrderLargestProfileFirst:
+		costs := make(map[string]int, len(paths))
+		for _, path := range paths {
+			costs[path] = jobCost(path)
+		}
+		slices.SortStableFunc(paths, func(a, b string) int {
+			return costs[b] - costs[a]
+		})
+	default:
+		return fmt.Errorf("invalid job order: %s. Supported orders are '%s', '%s' or '%s'",
+			order, OrderAsFound, OrderShuffled, OrderLargestProfileFirst)
+	}
+	return nil
 }
 
 // Run orchestrates parallel processing of YAML configuration files in the specified directory.
@@ -83,55 +246,362 @@ func reportProgress(processed *atomic.Int64, total int64, done <-chan struct{})
 // Returns:
 //   - error: An error if directory traversal fails or no YAML files are found
 func Run(configDir string, numWorkers int) error {
+	return RunWithOptions(configDir, Options{Workers: numWorkers})
+}
 
-	// Create job channel
-	jobs := make(chan Job, 100)
+// RunWithOptions orchestrates parallel processing of YAML configuration files in the
+// specified directory, as Run does, with additional control over the batch.
+// When opts.Progress is set, it is called once per completed job (never concurrently)
+// and the console progress bar is not displayed, so that embedding applications can
+// drive their own user interface.
+//
+// Parameters:
+//   - configDir: Directory path to search for YAML configuration files (searched recursively)
+//   - opts: Options controlling the number of workers and progress reporting
+//
+// Returns:
+//   - error: An error if directory traversal fails or no YAML files are found
+func RunWithOptions(configDir string, opts Options) error {
 
-	var wg sync.WaitGroup
-	var processedCount atomic.Int64
-	var totalFiles atomic.Int64
+	// Collect YAML files
+	yamlFiles, err := findConfigs(configDir)
+	if err != nil {
+		return err
+	}
 
-	// Start workers
-	for i := range numWorkers {
-		wg.Add(1)
-		go worker(i, jobs, &wg, &processedCount)
+	if err := orderJobs(yamlFiles, opts.Order); err != nil {
+		return err
 	}
 
-	// Collect YAML files
-	yamlFiles := []string{}
-	err := filepath.WalkDir(configDir, func(path string, d fs.DirEntry, err error) error {
+	_, _, err = runBatch(dirJobs(yamlFiles), opts, false)
+	return err
+}
+
+// RunWithResults processes the YAML configuration files in a directory as
+// RunWithOptions does, and returns the outcome of every job, so that Go programs
+// can consume the results directly. Result files are still written, unless
+// opts.NoResultFiles or opts.SQLitePath is set. Failed jobs are reported in their
+// JobResult and do not make RunWithResults return an error; jobs skipped because
+// their result file exists (opts.OnExisting) are not returned.
+//
+// Parameters:
+//   - configDir: Directory path to search for YAML configuration files (searched recursively)
+//   - opts: Options controlling the batch
+//
+// Returns:
+//   - []JobResult: The outcome of every job, in dispatch order
+//   - error: An error if directory traversal fails or no YAML files are found
+func RunWithResults(configDir string, opts Options) ([]JobResult, error) {
+
+	yamlFiles, err := findConfigs(configDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := orderJobs(yamlFiles, opts.Order); err != nil {
+		return nil, err
+	}
+	_, results, err := runBatch(dirJobs(yamlFiles), opts, true)
+	return results, err
+}
+
+// dirJobs creates the jobs for configuration files found in a directory.
+//
+// Parameters:
+//   - paths: Paths to the YAML configuration files
+//
+// Returns:
+//   - []Job: One job per configuration file
+func dirJobs(paths []string) []Job {
+	jobs := make([]Job, len(paths))
+	for i, path := range paths {
+		jobs[i] = Job{path: path}
+	}
+	return jobs
+}
+
+// runBatch processes a list of jobs on a pool of workers, in the given order,
+// reporting progress and writing the SQLite database as set in the options.
+//
+// Parameters:
+//   - batch: The jobs to process
+//   - opts: Options controlling the workers, progress reporting and output
+//   - collect: If true, the outcome of every job is returned (otherwise nil is returned)
+//
+// Returns:
+//   - BatchResult: The outcome of every job, without the dispersion curves
+//   - []JobResult: The outcome of every job in batch order, when collect is set
+//   - error: An error if the SQLite database cannot be written
+func runBatch(batch []Job, opts Options, collect bool) (BatchResult, []JobResult, error) {
+
+	total := int64(len(batch))
+	collected := newBatchOutcome(len(batch))
+	logFailures := opts.Progress == nil
+	consoleOutput := logFailures && !opts.Quiet
+	if consoleOutput && !opts.DryRun {
+		fmt.Printf("Found %d YAML files to process\n", total)
+	}
+
+	skipResultFiles := opts.SQLitePath != "" || opts.NoResultFiles
+	var skipped []Job
+	if !skipResultFiles {
+		renamed, err := resolveOutputs(batch, opts.OnCollision)
 		if err != nil {
-			return err
+			return BatchResult{}, nil, err
+		}
+		if renamed > 0 && consoleOutput {
+			fmt.Printf("Renamed %d result files shared by several configurations\n", renamed)
+		}
+
+		var versioned int
+		batch, skipped, versioned, err = resolveExisting(batch, opts.OnExisting)
+		if err != nil {
+			return BatchResult{}, nil, err
+		}
+		total = int64(len(batch))
+		if len(skipped) > 0 && consoleOutput {
+			fmt.Printf("Skipped %d configurations whose result file already exists\n", len(skipped))
+		}
+		if versioned > 0 && consoleOutput {
+			fmt.Printf("Writing %d results to versioned files next to the existing ones\n", versioned)
+		}
+	}
+
+	if opts.DryRun {
+		listJobs(os.Stdout, batch, opts)
+		return BatchResult{}, nil, nil
+	}
+
+	var processedCount int64
+	var bar *progressBar
+	if consoleOutput {
+		bar = newProgressBar(os.Stdout, total, !isTerminal(os.Stdout))
+	}
+
+	var report *batchReport
+	if opts.Report != "" {
+		var err error
+		if report, err = newBatchReport(opts.ReportPlots); err != nil {
+			return BatchResult{}, nil, err
 		}
-		if !d.IsDir() && strings.HasSuffix(d.Name(), ".yaml") {
-			yamlFiles = append(yamlFiles, path)
-			totalFiles.Add(1)
+	}
+
+	var state *progressFile
+	if opts.ProgressFile != "" {
+		var err error
+		if state, err = newProgressFile(opts.ProgressFile, total); err != nil {
+			return BatchResult{}, nil, err
 		}
-		return nil
-	})
+	}
+
+	var sink *sqliteSink
+	if opts.SQLitePath != "" {
+		var err error
+		if sink, err = newSQLiteSink(opts.SQLitePath); err != nil {
+			return BatchResult{}, nil, fmt.Errorf("error creating SQLite database: %v", err)
+		}
+	}
+
+	var summary batchSummary
+	var results []JobResult
+	if collect {
+		results = make([]JobResult, len(batch))
+	}
+
+	for i := range batch {
+		batch[i].index = i
+	}
+	dispatched := batch
+	var duplicates map[int][]Job
+	if opts.Dedup {
+		dispatched, duplicates = dedupJobs(batch)
+		if skipped := len(batch) - len(dispatched); skipped > 0 && consoleOutput {
+			fmt.Printf("Found %d duplicate configurations, computed once\n", skipped)
+		}
+	}
+
+	feed := func(jobs chan<- Job) {
+		for _, job := range dispatched {
+			jobs <- job
+		}
+	}
+	record := func(outcome jobOutcome) {
+		processedCount++
+		count := processedCount
+		collected.add(outcome)
+		summary.add(outcome.result.CriticalVelocity, outcome.err)
+		if results != nil {
+			results[outcome.job.index] = outcome.jobResult()
+		}
+		if sink != nil {
+			sink.add(outcome)
+		}
+		if report != nil {
+			report.add(outcome)
+		}
+		if outcome.err != nil && logFailures {
+			log.Printf("Failed on config %s: %v\n", outcome.job.path, outcome.err)
+		}
+		if bar != nil {
+			bar.update(outcome.err != nil)
+		}
+		if state != nil {
+			state.update(outcome.err != nil)
+		}
+		if opts.Progress != nil {
+			opts.Progress(ProgressEvent{
+				Path:      outcome.job.path,
+				Err:       outcome.err,
+				Duration:  outcome.duration,
+				Processed: count,
+				Total:     total,
+			})
+		}
+	}
+	handle := func(outcome jobOutcome) {
+		record(outcome)
+		for _, job := range duplicates[outcome.job.index] {
+			record(duplicateOutcome(job, outcome, skipResultFiles))
+		}
+	}
+	processJobs(feed, handle, opts, skipResultFiles)
+	outcome := collected.finish(skipped, summary.stats())
+	if state != nil {
+		state.finish()
+	}
+
+	if consoleOutput {
+		bar.finish()
+		fmt.Printf("Completed processing %d YAML files\n", processedCount)
+		outcome.Stats.write(os.Stdout)
+	}
+
+	if sink != nil {
+		if err := sink.close(); err != nil {
+			return outcome, results, fmt.Errorf("error writing SQLite database: %v", err)
+		}
+		if consoleOutput {
+			fmt.Printf("Results written to %s\n", opts.SQLitePath)
+		}
+	}
+
+	if report != nil {
+		if err := report.write(opts.Report, outcome.Stats); err != nil {
+			return outcome, results, fmt.Errorf("error writing report: %v", err)
+		}
+		if consoleOutput {
+			fmt.Printf("Report written to %s\n", opts.Report)
+		}
+	}
+	return outcome, results, nil
+}
+
+// listJobs prints the jobs of a batch in dispatch order with the destination of
+// their results, followed by the number of jobs.
+//
+// Parameters:
+//   - out: Destination of the listing
+//   - batch: The jobs of the batch
+//   - opts: Options of the batch, which determine where results are written
+func listJobs(out io.Writer, batch []Job, opts Options) {
+	for _, job := range batch {
+		var destination string
+		switch {
+		case opts.SQLitePath != "":
+			destination = opts.SQLitePath
+		case opts.NoResultFiles:
+			destination = "(no result file)"
+		default:
+			output, ok := outputPath(job)
+			if !ok {
+				output = "(invalid configuration)"
+			}
+			destination = output
+		}
+		fmt.Fprintf(out, "%s -> %s\n", job.path, destination)
+	}
+	fmt.Fprintf(out, "%d jobs would be processed\n", len(batch))
+}
+
+// findConfigs recursively collects the YAML configuration files in a directory, or
+// under an s3:// or gs:// prefix.
+//
+// Parameters:
+//   - configDir: Directory path or URL to search for YAML configuration files
+//
+// Returns:
+//   - []string: Paths (or URLs) to the YAML configuration files, in directory traversal
+//     order, or sorted by key for remote prefixes
+//   - error: An error if directory traversal fails or no YAML files are found
+func findConfigs(configDir string) ([]string, error) {
+	yamlFiles, err := storage.List(configDir, ".yaml")
 	if err != nil {
-		return fmt.Errorf("error walking through config directory: %v", err)
+		return nil, fmt.Errorf("error walking through config directory: %v", err)
 	}
 	if len(yamlFiles) == 0 {
-		return fmt.Errorf("no YAML configuration files found in directory: %s", configDir)
+		return nil, fmt.Errorf("no YAML configuration files found in directory: %s", configDir)
 	}
+	return yamlFiles, nil
+}
 
-	total := totalFiles.Load()
-	fmt.Printf("Found %d YAML files to process\n", total)
+// processJobs runs jobs on a pool of workers and collects their outcomes.
+// The feed function sends the jobs and returns once all jobs have been sent.
+// The handle function is called once per outcome, always from the same goroutine,
+// so it does not need to synchronise access to its own state.
+// processJobs returns once all outcomes have been handled.
+//
+// Parameters:
+//   - feed: Function sending the jobs to the workers
+//   - handle: Function called with the outcome of each job
+//   - opts: Options controlling the number of workers and the analysis of each job
+//   - skipResultFiles: If true, the workers do not write the result file of each job
+func processJobs(feed func(jobs chan<- Job), handle func(jobOutcome), opts Options, skipResultFiles bool) {
 
-	// Start progress reporting goroutine
-	done := make(chan struct{})
-	go reportProgress(&processedCount, total, done)
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
 
-	// Send jobs to workers
-	for _, path := range yamlFiles {
-		jobs <- Job{path: path}
+	// Create job and outcome channels
+	jobs := make(chan Job, 100)
+	outcomes := make(chan jobOutcome, 100)
+
+	var wg sync.WaitGroup
+
+	// Start workers
+	runOpts := critical_speed.Options{
+		LogFile:        opts.JobLogs,
+		SkipResultFile: skipResultFiles,
+		SoilCache:      opts.SoilCache,
+		Fast:           opts.Fast,
 	}
+
+	// Every running job holds a token of the budget shared with the frequency loops
+	// of the analysis, which only use the cores left by the workers. With more
+	// workers than tokens (e.g. for jobs waiting on remote storage), the workers
+	// hold none, so that they all run, and the frequency loops use the tokens left.
+	var budget *math_utils.Budget
+	if shared := math_utils.SharedBudget(); numWorkers <= shared.Size() {
+		budget = shared
+	}
+	for range numWorkers {
+		wg.Add(1)
+		go worker(jobs, outcomes, &wg, runOpts, opts.JobTimeout, budget)
+	}
+
+	// Collect job outcomes in a single goroutine
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for outcome := range outcomes {
+			handle(outcome)
+		}
+	}()
+
+	// Send jobs to workers
+	feed(jobs)
 	close(jobs)
 
 	wg.Wait()
-	close(done)
-
-	fmt.Printf("\nCompleted processing %d YAML files\n", processedCount.Load())
-	return nil
+	close(outcomes)
+	<-collected
 }
@@ -1,11 +1,21 @@
 package runner
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	soil_dispersion "github.com/PlatypusBytes/GoTrain/internal/soil_dispersion"
 )
 
 const TOL = 1e-3
@@ -91,3 +101,934 @@ func TestRunWithYamls(t *testing.T) {
 
 	}
 }
+
+// writeConfig copies the sample configuration into dir under the given name,
+// redirecting its output to outputPath. It returns the path of the new file.
+func writeConfig(t *testing.T, dir string, name string, outputPath string) string {
+	t.Helper()
+
+	data, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+	content := strings.Replace(string(data), `file_name: "dispersion_results.json"`, "file_name: "+strconv.Quote(outputPath), 1)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+// Test that RunWithOptions reports one progress event per job, including failed jobs.
+func TestRunWithOptionsProgress(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config_a.yaml", filepath.Join(dir, "results_a.json"))
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("track_type: monorail\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var events []ProgressEvent
+	err := RunWithOptions(dir, Options{
+		Workers:  2,
+		Progress: func(e ProgressEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 progress events, got %d", len(events))
+	}
+	failed := 0
+	for i, e := range events {
+		if e.Processed != int64(i+1) || e.Total != 3 {
+			t.Errorf("event %d: unexpected counts %d/%d", i, e.Processed, e.Total)
+		}
+		if e.Err != nil {
+			failed++
+			if filepath.Base(e.Path) != "broken.yaml" {
+				t.Errorf("unexpected failure for %s: %v", e.Path, e.Err)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected 1 failed job, got %d", failed)
+	}
+}
+
+// Test that the progress file holds the final state of the batch.
+func TestRunWithOptionsProgressFile(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config_a.yaml", filepath.Join(dir, "results_a.json"))
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("track_type: monorail\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	progressPath := filepath.Join(t.TempDir(), "progress.json")
+	var initial ProgressState
+	err := RunWithOptions(dir, Options{
+		Workers:      2,
+		ProgressFile: progressPath,
+		Progress: func(e ProgressEvent) {
+			// The initial state is written before the first job completes
+			if e.Processed == 1 {
+				data, err := os.ReadFile(progressPath)
+				if err != nil || json.Unmarshal(data, &initial) != nil {
+					t.Errorf("failed to read the initial progress file: %v", err)
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if initial.Total != 3 || initial.Remaining != 3 || initial.Done || initial.ETASeconds != nil {
+		t.Errorf("unexpected initial state: %+v", initial)
+	}
+
+	data, err := os.ReadFile(progressPath)
+	if err != nil {
+		t.Fatalf("failed to read progress file: %v", err)
+	}
+	var state ProgressState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("invalid progress file: %v", err)
+	}
+	if state.Total != 3 || state.Completed != 2 || state.Failed != 1 || state.Remaining != 0 || !state.Done {
+		t.Errorf("unexpected final state: %+v", state)
+	}
+	if state.ETASeconds == nil || *state.ETASeconds != 0 || state.JobsPerSecond <= 0 || state.UpdatedAt.Before(state.StartedAt) {
+		t.Errorf("unexpected final timings: %+v", state)
+	}
+	if matches, _ := filepath.Glob(progressPath + ".*"); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	if err := RunWithOptions(dir, Options{ProgressFile: "s3://bucket/progress.json", Quiet: true}); err == nil {
+		t.Error("expected an error for a remote progress file")
+	}
+}
+
+// Test that configurations with the same content are computed once, and their
+// result written to the result file of every duplicate.
+func TestRunWithOptionsDedup(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config_a.yaml", filepath.Join(dir, "results_a.json"))
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	path := writeConfig(t, dir, "config_c.yaml", filepath.Join(dir, "results_c.json"))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	changed := strings.Replace(string(data), "m_rail: 120 ", "m_rail: 60 ", 1)
+	if err := os.WriteFile(path, []byte(changed), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Every computed configuration looks up its soil dispersion curve once
+	cache := soil_dispersion.NewCache()
+	results, err := RunWithResults(dir, Options{Workers: 2, Quiet: true, Dedup: true, SoilCache: cache})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if hits, misses := cache.Stats(); hits+misses != 2 {
+		t.Errorf("expected 2 computed configurations, got %d", hits+misses)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("unexpected failure for %s: %v", r.Path, r.Err)
+		}
+	}
+	if results[0].Result.CriticalVelocity != results[1].Result.CriticalVelocity ||
+		results[0].Result.CriticalVelocity == results[2].Result.CriticalVelocity {
+		t.Errorf("unexpected critical velocities: %v, %v, %v", results[0].Result.CriticalVelocity,
+			results[1].Result.CriticalVelocity, results[2].Result.CriticalVelocity)
+	}
+	for _, name := range []string{"results_a.json", "results_b.json", "results_c.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected result file %s: %v", name, err)
+		}
+	}
+}
+
+// Test that configurations differing only in the file names of their tables are
+// computed once, and that every job writes its own tables.
+func TestRunWithOptionsDedupTableFileNames(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		path := writeConfig(t, dir, "config_"+name+".yaml", filepath.Join(dir, "results_"+name+".json"))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+		section := "\nassessment:\n  speeds: [60]\n  file_name: " + strconv.Quote(filepath.Join(dir, "mach_"+name+".csv")) + "\n"
+		if err := os.WriteFile(path, append(data, section...), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	cache := soil_dispersion.NewCache()
+	results, err := RunWithResults(dir, Options{Workers: 2, Quiet: true, Dedup: true, SoilCache: cache})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if hits, misses := cache.Stats(); hits+misses != 1 {
+		t.Errorf("expected 1 computed configuration, got %d", hits+misses)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, name := range []string{"mach_a.csv", "mach_b.csv"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected table %s: %v", name, err)
+		}
+	}
+}
+
+// fileNameFields returns the paths (field indices and YAML keys) of the string fields
+// of a configuration section whose YAML key ends with file_name.
+func fileNameFields(section reflect.Type, index []int, key string) (indices [][]int, keys []string) {
+	for i := range section.NumField() {
+		field := section.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		path := append(append([]int(nil), index...), i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			nested, nestedKeys := fileNameFields(field.Type, path, key+name+".")
+			indices, keys = append(indices, nested...), append(keys, nestedKeys...)
+		case field.Type.Kind() == reflect.String && strings.HasSuffix(name, "file_name"):
+			indices, keys = append(indices, path), append(keys, key+name)
+		}
+	}
+	return indices, keys
+}
+
+// Test that the hash of a configuration ignores every file_name field, so that a
+// table added to the configuration must be left out of configKey.
+func TestConfigKeyFileNames(t *testing.T) {
+
+	config, err := critical_speed.LoadConfig("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	key, ok := configKey(&Job{config: &config})
+	if !ok {
+		t.Fatal("expected a key for the sample configuration")
+	}
+
+	indices, keys := fileNameFields(reflect.TypeOf(config), nil, "")
+	if len(indices) < 9 {
+		t.Fatalf("expected the file_name fields of every table, got %v", keys)
+	}
+	for i, index := range indices {
+		renamed := config
+		reflect.ValueOf(&renamed).Elem().FieldByIndex(index).SetString("renamed.csv")
+		if got, _ := configKey(&Job{config: &renamed}); got != key {
+			t.Errorf("expected %s to be left out of the key", keys[i])
+		}
+	}
+
+	changed := config
+	changed.Train.AxleLoad++
+	if got, _ := configKey(&Job{config: &changed}); got == key {
+		t.Error("expected another key for another axle load")
+	}
+}
+
+// Test the policies for result files that already exist.
+func TestRunWithOptionsOnExisting(t *testing.T) {
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "results_a.json")
+	writeConfig(t, dir, "config_a.yaml", existing)
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	if err := os.WriteFile(existing, []byte("previous"), 0644); err != nil {
+		t.Fatalf("failed to write result file: %v", err)
+	}
+
+	err := RunWithOptions(dir, Options{Quiet: true, OnExisting: critical_speed.ExistingError})
+	if err == nil || !strings.Contains(err.Error(), existing) {
+		t.Fatalf("expected an error listing %s, got %v", existing, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results_b.json")); err == nil {
+		t.Error("expected no job to run when result files exist")
+	}
+
+	results, err := RunWithResults(dir, Options{Quiet: true, OnExisting: critical_speed.ExistingSkip})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "config_b.yaml" {
+		t.Errorf("expected only config_b.yaml to run, got %v", results)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "previous" {
+		t.Error("expected the existing result file to be kept")
+	}
+
+	if err := RunWithOptions(dir, Options{Quiet: true, OnExisting: critical_speed.ExistingVersion}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, name := range []string{"results_a_v2.json", "results_b_v2.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected versioned result file %s: %v", name, err)
+		}
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "previous" {
+		t.Error("expected the existing result file to be kept")
+	}
+
+	if err := RunWithOptions(dir, Options{Quiet: true, OnExisting: "append"}); err == nil {
+		t.Error("expected an error for an unsupported policy")
+	}
+}
+
+// Test that the HTML report lists every job and plots the selected ones.
+func TestRunWithOptionsReport(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config_a.yaml", filepath.Join(dir, "results_a.json"))
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("track_type: monorail\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "report.html")
+	if err := RunWithOptions(dir, Options{Workers: 2, Quiet: true, Report: reportPath, ReportPlots: "*_b.yaml"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	page := string(data)
+	for _, want := range []string{"config_a.yaml", "config_b.yaml", "broken.yaml", "monorail", "78.23", `class="failed"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected the report to contain %q", want)
+		}
+	}
+	// The histogram and the curves of config_b.yaml only
+	if n := strings.Count(page, "<svg"); n != 2 {
+		t.Errorf("expected 2 plots, got %d", n)
+	}
+	if strings.Count(page, "<figure") != 1 || !strings.Contains(page, `href="#job-`) {
+		t.Error("expected the curves of config_b.yaml to be plotted and linked")
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "<link") || strings.Contains(page, "<img") {
+		t.Error("expected a self-contained report")
+	}
+
+	if err := RunWithOptions(dir, Options{Quiet: true, Report: reportPath, ReportPlots: "["}); err == nil {
+		t.Error("expected an error for a malformed plot pattern")
+	}
+}
+
+// Test that RunBatch returns the status of every job, including failed and skipped ones.
+func TestRunBatch(t *testing.T) {
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "results_a.json")
+	writeConfig(t, dir, "config_a.yaml", existing)
+	writeConfig(t, dir, "config_b.yaml", filepath.Join(dir, "results_b.json"))
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("track_type: monorail\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("previous"), 0644); err != nil {
+		t.Fatalf("failed to write result file: %v", err)
+	}
+
+	result, err := RunBatch(dir, Options{Workers: 2, Quiet: true, OnExisting: critical_speed.ExistingSkip})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if result.Total != 3 || result.Succeeded != 1 || result.Failed != 1 || result.Skipped != 1 || result.OK() {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	statuses := make(map[string]JobSummary)
+	for _, job := range result.Jobs {
+		statuses[filepath.Base(job.Path)] = job
+	}
+	if job := statuses["config_b.yaml"]; job.Status != StatusSucceeded || math.Abs(job.CriticalVelocity-78.23) > 0.01 {
+		t.Errorf("expected config_b.yaml to succeed, got %+v", job)
+	}
+	if job := statuses["broken.yaml"]; job.Status != StatusFailed || !strings.Contains(job.Error, "monorail") {
+		t.Errorf("expected broken.yaml to fail, got %+v", job)
+	}
+	if job := statuses["config_a.yaml"]; job.Status != StatusSkipped {
+		t.Errorf("expected config_a.yaml to be skipped, got %+v", job)
+	}
+	if result.Jobs[len(result.Jobs)-1].Status != StatusSkipped {
+		t.Error("expected the skipped jobs to be listed last")
+	}
+	if failed := result.FailedJobs(); len(failed) != 1 || failed[0].Path != statuses["broken.yaml"].Path {
+		t.Errorf("expected broken.yaml as the only failed job, got %v", failed)
+	}
+	if result.Stats.Count != 1 {
+		t.Errorf("expected statistics of 1 critical velocity, got %d", result.Stats.Count)
+	}
+}
+
+// Test that largest-profile-first dispatches the configuration with most soil layers first.
+func TestOrderJobsLargestProfileFirst(t *testing.T) {
+
+	dir := t.TempDir()
+	small := writeConfig(t, dir, "small.yaml", filepath.Join(dir, "small.json"))
+	large := "../../testdata/batch/config_0.yaml"
+
+	paths := []string{small, large}
+	if err := orderJobs(paths, OrderLargestProfileFirst); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if paths[0] != large || paths[1] != small {
+		t.Errorf("unexpected order: %v", paths)
+	}
+
+	if err := orderJobs(paths, "alphabetical"); err == nil {
+		t.Errorf("expected error for unsupported order")
+	}
+}
+
+// Test that the cost of a configuration counts the layers of every soil profile.
+func TestJobCostSoilProfiles(t *testing.T) {
+
+	dir := t.TempDir()
+	small := writeConfig(t, dir, "small.yaml", filepath.Join(dir, "small.json"))
+	data, err := os.ReadFile(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	start, end := strings.Index(content, "soil_layers:"), strings.Index(content, "# Output file configuration")
+	model, err := filepath.Abs("../../testdata/profile/sample.model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles := func(file string) string {
+		path := filepath.Join(dir, "profiles.yaml")
+		section := "soil_profiles:\n" +
+			"  - name: a\n" +
+			"    soil_layers: [{thickness: 2, density: 2000, young_modulus: 30e6, poisson_ratio: 0.35}, {thickness: .inf, density: 2000, young_modulus: 75e6, poisson_ratio: 0.4}]\n" +
+			"  - name: b\n" +
+			"    soil_profile: {file: " + strconv.Quote(file) + "}\n\n"
+		if err := os.WriteFile(path, []byte(content[:start]+section+content[end:]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 3 layers for the sample, 2 + 3 for the profiles
+	frequencies := jobCost(small) / 3
+	if frequencies == 0 {
+		t.Fatalf("expected a cost for the sample configuration")
+	}
+	if cost := jobCost(profiles(model)); cost != 5*frequencies {
+		t.Errorf("expected a cost of %d, got %d", 5*frequencies, cost)
+	}
+	if cost := jobCost(profiles(filepath.Join(dir, "missing.model"))); cost != 0 {
+		t.Errorf("expected no cost for a missing profile, got %d", cost)
+	}
+}
+
+// Test that a job exceeding the timeout is cancelled and reported as failed.
+func TestRunWithOptionsJobTimeout(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config.yaml", filepath.Join(dir, "results.json"))
+
+	var events []ProgressEvent
+	err := RunWithOptions(dir, Options{
+		Workers:    1,
+		JobTimeout: time.Nanosecond,
+		Progress:   func(e ProgressEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(events) != 1 || events[0].Err == nil {
+		t.Fatalf("expected a single failed job, got: %+v", events)
+	}
+	if !strings.Contains(events[0].Err.Error(), "exceeded timeout") {
+		t.Errorf("unexpected error: %v", events[0].Err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results.json")); err == nil {
+		t.Errorf("expected no result file for a cancelled job")
+	}
+}
+
+// Test that RunWithOptions writes results to a SQLite database instead of JSON files.
+func TestRunWithOptionsSQLite(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config.yaml", filepath.Join(dir, "results.json"))
+	dbPath := filepath.Join(dir, "out", "results.db")
+
+	err := RunWithOptions(dir, Options{
+		Workers:    1,
+		SQLitePath: dbPath,
+		Progress:   func(ProgressEvent) {},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("expected SQLite database to be written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "SQLite format 3") {
+		t.Errorf("output is not a SQLite database")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results.json")); err == nil {
+		t.Errorf("expected no JSON result file when writing to SQLite")
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("error opening the database: %v", err)
+	}
+	defer db.Close()
+	var status string
+	var points int
+	if err := db.QueryRow(`SELECT status FROM runs WHERE id = 1`).Scan(&status); err != nil || status != "ok" {
+		t.Errorf("expected one successful run, got %q (%v)", status, err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM curves WHERE run_id = 1`).Scan(&points); err != nil || points == 0 {
+		t.Errorf("expected the curves of the run, got %d rows (%v)", points, err)
+	}
+}
+
+// Test that long error messages are truncated on a character boundary.
+func TestTruncateMessage(t *testing.T) {
+
+	if got := truncateMessage("short", 10); got != "short" {
+		t.Errorf("expected a short message to be kept, got %q", got)
+	}
+	// "é" takes two bytes: cutting after 4 bytes would split the third one
+	got := truncateMessage("ééé", 5)
+	if got != "éé" || !utf8.ValidString(got) {
+		t.Errorf("expected %q, got %q", "éé", got)
+	}
+}
+
+// Test the decoding of jobs received from a shared queue.
+func TestDecodeJob(t *testing.T) {
+
+	data, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+	message, _ := json.Marshal(QueuedJob{Path: "sample_config.yaml", Config: string(data)})
+
+	job := decodeJob(message)
+	if job.err != nil || job.config == nil {
+		t.Fatalf("expected a valid job, got error: %v", job.err)
+	}
+	if job.path != "sample_config.yaml" || job.config.TrackType != "ballast" || len(job.config.SoilLayers) != 3 {
+		t.Errorf("unexpected job: %+v", job)
+	}
+
+	if job := decodeJob([]byte("not json")); job.err == nil {
+		t.Errorf("expected error for an invalid message")
+	}
+	message, _ = json.Marshal(QueuedJob{Path: "broken.yaml", Config: "soil_layers: 3"})
+	if job := decodeJob(message); job.err == nil {
+		t.Errorf("expected error for an invalid configuration")
+	}
+}
+
+// Test that a manifest runs the listed jobs in order, with per-job output paths.
+func TestRunManifest(t *testing.T) {
+
+	dir := t.TempDir()
+	first := writeConfig(t, dir, "first.yaml", filepath.Join(dir, "first.json"))
+	second := writeConfig(t, dir, "second.yaml", filepath.Join(dir, "second.json"))
+	writeConfig(t, dir, "unlisted.yaml", filepath.Join(dir, "unlisted.json"))
+	override := filepath.Join(dir, "override.json")
+
+	manifest := filepath.Join(dir, "jobs.txt")
+	content := "# jobs\n" + second + "\n\n" + first + "  " + override + "\n"
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	var paths []string
+	err := RunManifest(manifest, Options{
+		Workers:  1,
+		Progress: func(e ProgressEvent) { paths = append(paths, e.Path) },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(paths) != 2 || paths[0] != second || paths[1] != first {
+		t.Errorf("unexpected jobs or order: %v", paths)
+	}
+	for _, name := range []string{"second.json", "override.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected result file %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"first.json", "unlisted.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("unexpected result file %s", name)
+		}
+	}
+
+	if err := os.WriteFile(manifest, []byte("a.yaml b.json c\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := RunManifest(manifest, Options{Workers: 1}); err == nil {
+		t.Errorf("expected error for a malformed manifest line")
+	}
+}
+
+// Test the expansion of a template and sweep specification into the job matrix.
+func TestExpandSweep(t *testing.T) {
+
+	template, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+	spec := SweepSpec{Parameters: []SweepParameter{
+		{Path: "soil_layers.0.young_modulus", Values: []any{20e6, 50e6}},
+		{Path: "frequency.points", Values: []any{10, 20, 30}},
+	}}
+
+	jobs, err := expandSweep("sweep.yaml", template, spec)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(jobs) != 6 {
+		t.Fatalf("expected 6 jobs, got %d", len(jobs))
+	}
+
+	last := jobs[5].config
+	if last.SoilLayers[0].YoungModulus != 50e6 || last.Frequency.Points != 30 {
+		t.Errorf("unexpected parameters of the last combination: %+v", last)
+	}
+	if jobs[1].config.SoilLayers[0].YoungModulus != 20e6 || jobs[1].config.Frequency.Points != 20 {
+		t.Errorf("expected the last parameter to vary fastest")
+	}
+	if last.Output.FileName != "dispersion_results_6.json" {
+		t.Errorf("unexpected output file name: %s", last.Output.FileName)
+	}
+	if last.SoilLayers[1].YoungModulus != 40e6 || !math.IsInf(last.SoilLayers[2].Thickness, 1) {
+		t.Errorf("template values not preserved: %+v", last.SoilLayers)
+	}
+	if !strings.Contains(jobs[0].path, "soil_layers.0.young_modulus=2e+07") {
+		t.Errorf("unexpected job name: %s", jobs[0].path)
+	}
+
+	spec.Parameters[0].Path = "soil_layers.0.young_modulu"
+	if _, err := expandSweep("sweep.yaml", template, spec); err == nil {
+		t.Errorf("expected error for an unknown parameter")
+	}
+}
+
+// Test the expansion of a template and an alignment into one job per section.
+func TestExpandAlignment(t *testing.T) {
+
+	template, err := os.ReadFile("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read sample config: %v", err)
+	}
+	spec := AlignmentSpec{
+		Route: []AlignmentPoint{{Chainage: 0, Longitude: 4, Latitude: 52}, {Chainage: 1000, Longitude: 5, Latitude: 53}},
+		Sections: []AlignmentSection{
+			{Name: "soft", ChainageStart: 0, ChainageEnd: 400, SoilLayers: []any{
+				map[string]any{"thickness": math.Inf(1), "density": 1600, "young_modulus": 1e7, "poisson_ratio": 0.45},
+			}},
+			{ChainageStart: 400, ChainageEnd: 1000},
+		},
+	}
+
+	jobs, err := expandAlignment("route.yaml", template, spec)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	if layers := jobs[0].config.SoilLayers; len(layers) != 1 || layers[0].YoungModulus != 1e7 {
+		t.Errorf("unexpected soil layers of the first section: %+v", layers)
+	}
+	if layers := jobs[1].config.SoilLayers; len(layers) != 3 || layers[0].YoungModulus != 30e6 {
+		t.Errorf("expected the template soil layers in the second section: %+v", layers)
+	}
+	if jobs[1].config.Output.FileName != "dispersion_results_2.json" {
+		t.Errorf("unexpected output file name: %s", jobs[1].config.Output.FileName)
+	}
+	if jobs[0].path != "route.yaml[soft: 0-400 m]" || jobs[1].path != "route.yaml[2: 400-1000 m]" {
+		t.Errorf("unexpected job names: %s, %s", jobs[0].path, jobs[1].path)
+	}
+
+	coordinates := sectionCoordinates(spec.Route, 400, 1000)
+	if len(coordinates) != 2 || math.Abs(coordinates[0][0]-4.4) > 1e-12 || coordinates[1] != [2]float64{5, 53} {
+		t.Errorf("unexpected section coordinates: %v", coordinates)
+	}
+
+	spec.Sections[1].ChainageStart = 300
+	if _, err := expandAlignment("route.yaml", template, spec); err == nil {
+		t.Errorf("expected error for overlapping sections")
+	}
+	spec.Sections[1].ChainageStart, spec.Sections[1].ChainageEnd = 400, 1200
+	if _, err := expandAlignment("route.yaml", template, spec); err == nil {
+		t.Errorf("expected error for a section outside the route")
+	}
+}
+
+// Test the profile and GeoJSON output of an alignment, with a failed section.
+func TestAlignmentOutputs(t *testing.T) {
+
+	spec := AlignmentSpec{
+		LineSpeed: 200,
+		Route:     []AlignmentPoint{{Chainage: 0, Longitude: 4, Latitude: 52}, {Chainage: 100, Longitude: 4.1, Latitude: 52}},
+		Sections:  []AlignmentSection{{Name: "a", ChainageEnd: 50}, {ChainageStart: 50, ChainageEnd: 100}},
+	}
+	results := []JobResult{
+		{Result: critical_speed.Result{CriticalVelocity: 50, CriticalOmega: 80}},
+		{Err: errors.New("no intersection")},
+	}
+
+	var profile strings.Builder
+	if err := writeProfile(&profile, spec, results); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(profile.String()), "\n")
+	if len(lines) != 3 || lines[1] != "a,0,50,50,180,80,1.1111111111111112,true," || lines[2] != "2,50,100,,,,,,no intersection" {
+		t.Errorf("unexpected profile:\n%s", profile.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "route.geojson")
+	if err := writeGeoJSON(path, spec, results); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read GeoJSON: %v", err)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string       `json:"type"`
+				Coordinates [][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("unexpected GeoJSON: %s", data)
+	}
+	first := collection.Features[0]
+	if first.Geometry.Type != "LineString" || first.Geometry.Coordinates[1] != [2]float64{4.05, 52} {
+		t.Errorf("unexpected geometry: %+v", first.Geometry)
+	}
+	if first.Properties["critical_speed"] != 180.0 || first.Properties["speed_critical"] != true {
+		t.Errorf("unexpected properties: %v", first.Properties)
+	}
+	if failed := collection.Features[1].Properties; failed["critical_velocity"] != nil || failed["error"] != "no intersection" {
+		t.Errorf("unexpected properties of the failed section: %v", failed)
+	}
+}
+
+// Test that RunWithResults returns the outcome of every job in dispatch order.
+func TestRunWithResults(t *testing.T) {
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "config_a.yaml", filepath.Join(dir, "results_a.json"))
+	if err := os.WriteFile(filepath.Join(dir, "config_b.yaml"), []byte("track_type: unknown\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	results, err := RunWithResults(dir, Options{Workers: 2, NoResultFiles: true, Progress: func(ProgressEvent) {}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	if filepath.Base(results[0].Path) != "config_a.yaml" || results[0].Err != nil {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if diff := results[0].Result.CriticalVelocity - 78.231; diff < -1e-3 || diff > 1e-3 {
+		t.Errorf("unexpected critical velocity: %v", results[0].Result.CriticalVelocity)
+	}
+	if filepath.Base(results[1].Path) != "config_b.yaml" || results[1].Err == nil {
+		t.Errorf("expected the second job to fail: %+v", results[1])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results_a.json")); err == nil {
+		t.Errorf("expected no result file with NoResultFiles")
+	}
+}
+
+// Test the statistics of the critical velocities of a batch and their summary.
+func TestStatistics(t *testing.T) {
+
+	var results []JobResult
+	for v := 1; v <= 101; v++ {
+		results = append(results, JobResult{Result: critical_speed.Result{CriticalVelocity: float64(v)}})
+	}
+	results = append(results, JobResult{Err: errors.New("failed")}, JobResult{Result: critical_speed.Result{CriticalVelocity: math.NaN()}})
+
+	stats := Statistics(results)
+	if stats.Count != 101 || stats.Failed != 1 || stats.Min != 1 || stats.Max != 101 || stats.Mean != 51 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if diff := stats.StdDev - math.Sqrt(850); math.Abs(diff) > 1e-9 {
+		t.Errorf("unexpected standard deviation: %v", stats.StdDev)
+	}
+	expected := []Percentile{{5, 6}, {25, 26}, {50, 51}, {75, 76}, {95, 96}}
+	if len(stats.Percentiles) != len(expected) {
+		t.Fatalf("expected %d percentiles, got %v", len(expected), stats.Percentiles)
+	}
+	for i, p := range stats.Percentiles {
+		if p.Level != expected[i].Level || math.Abs(p.Value-expected[i].Value) > 1e-9 {
+			t.Errorf("expected percentile %+v, got %+v", expected[i], p)
+		}
+	}
+	if len(stats.Histogram) != 10 || stats.Histogram[0].Low != 1 || stats.Histogram[9].High != 101 {
+		t.Fatalf("unexpected histogram: %+v", stats.Histogram)
+	}
+	total := 0
+	for _, bin := range stats.Histogram {
+		total += bin.Count
+	}
+	if total != 101 || stats.Histogram[9].Count != 11 {
+		t.Errorf("expected every velocity in a bin and the maximum in the last one: %+v", stats.Histogram)
+	}
+
+	var out strings.Builder
+	stats.write(&out)
+	for _, line := range []string{"n 101  min 1.00  max 101.00  mean 51.00", "P50 51.00", "   91.00-101.00      11 ########################################"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected %q in summary:\n%s", line, out.String())
+		}
+	}
+
+	// Identical velocities fall in a single bin
+	stats = Statistics(results[:1])
+	if len(stats.Histogram) != 1 || stats.Histogram[0].Count != 1 || stats.Percentiles[4].Value != 1 {
+		t.Errorf("unexpected statistics of a single job: %+v", stats)
+	}
+	if stats = Statistics(results[101:]); stats.Count != 0 || stats.Histogram != nil {
+		t.Errorf("expected no statistics without critical velocities: %+v", stats)
+	}
+}
+
+// Test that the progress bar reports failures, throughput and the estimated time remaining.
+func TestProgressBar(t *testing.T) {
+
+	var out strings.Builder
+	bar := newProgressBar(&out, 4, false)
+	if !strings.Contains(out.String(), "0.00% (0/4)") {
+		t.Errorf("unexpected initial progress: %q", out.String())
+	}
+
+	bar.start = bar.start.Add(-2 * time.Second)
+	bar.lastDraw = time.Time{}
+	bar.update(true)
+	line := out.String()[strings.LastIndex(out.String(), "\r")+1:]
+	for _, expected := range []string{"25.00% (1/4)", "1 failed", "jobs/s", "ETA 6s"} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("expected %q in progress line %q", expected, line)
+		}
+	}
+
+	// The last job is always drawn, even within the redraw interval
+	bar.update(false)
+	bar.update(false)
+	bar.update(false)
+	line = out.String()[strings.LastIndex(out.String(), "\r")+1:]
+	if !strings.Contains(line, "100.00% (4/4)") || strings.Contains(line, "ETA") {
+		t.Errorf("unexpected final progress line %q", line)
+	}
+}
+
+// Test that plain progress mode prints lines without control characters.
+func TestProgressBarPlain(t *testing.T) {
+
+	var out strings.Builder
+	bar := newProgressBar(&out, 2, true)
+	bar.update(false)
+	bar.update(false)
+	bar.finish()
+
+	if out.String() == "" || strings.ContainsAny(out.String(), "\r[") {
+		t.Errorf("unexpected plain progress output: %q", out.String())
+	}
+	if strings.Count(out.String(), "\n") != 1 || !strings.HasPrefix(out.String(), "Progress: 100.00% (2/2)") {
+		t.Errorf("expected a single final progress line, got %q", out.String())
+	}
+}
+
+// Test that configurations sharing a result file are refused or renamed.
+func TestResolveOutputs(t *testing.T) {
+
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "results.json")
+	a := writeConfig(t, dir, "a.yaml", shared)
+	b := writeConfig(t, dir, "b.yaml", shared)
+	c := writeConfig(t, dir, "c.yaml", filepath.Join(dir, "other.json"))
+	batch := []Job{{path: a}, {path: b}, {path: c}}
+
+	if _, err := resolveOutputs(batch, CollisionFail); err == nil || !strings.Contains(err.Error(), "b.yaml") {
+		t.Errorf("expected a collision error naming both configurations, got: %v", err)
+	}
+	if _, err := resolveOutputs(batch, "overwrite"); err == nil {
+		t.Errorf("expected error for an invalid policy")
+	}
+
+	renamed, err := resolveOutputs(batch, CollisionRename)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if renamed != 2 || batch[2].output != "" {
+		t.Errorf("expected only the colliding jobs to be renamed, got %d", renamed)
+	}
+	if batch[0].output == batch[1].output || filepath.Dir(batch[0].output) != dir ||
+		!strings.HasSuffix(batch[0].output, "_a.json") {
+		t.Errorf("unexpected renamed outputs: %s, %s", batch[0].output, batch[1].output)
+	}
+	if got := uniqueOutputName("out/results.json", "configs/soft/config_1.yaml"); got != "out/results_configs_soft_config_1.json" {
+		t.Errorf("unexpected unique output name: %s", got)
+	}
+}
+
+// Test that a dry run lists the jobs and their result files without processing them.
+func TestDryRun(t *testing.T) {
+
+	dir := t.TempDir()
+	config := writeConfig(t, dir, "config.yaml", filepath.Join(dir, "results.json"))
+
+	var out strings.Builder
+	listJobs(&out, []Job{{path: config}}, Options{})
+	expected := config + " -> " + filepath.Join(dir, "results.json") + "\n1 jobs would be processed\n"
+	if out.String() != expected {
+		t.Errorf("unexpected listing:\n%s\nwant:\n%s", out.String(), expected)
+	}
+
+	results, err := RunWithResults(dir, Options{DryRun: true, Progress: func(ProgressEvent) {
+		t.Errorf("no job should be processed in a dry run")
+	}})
+	if err != nil || results != nil {
+		t.Errorf("unexpected dry run outcome: %v, %v", results, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results.json")); err == nil {
+		t.Errorf("expected no result file in a dry run")
+	}
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite" // pure Go SQLite driver, registered as "sqlite"
+)
+
+// Schemas of the tables written by sqliteSink.
+const (
+	runsTableSchema = `CREATE TABLE runs (
+	id INTEGER PRIMARY KEY,
+	config_path TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT,
+	duration_s REAL NOT NULL,
+	critical_omega REAL,
+	critical_velocity REAL
+)`
+	curvesTableSchema = `CREATE TABLE curves (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	omega REAL NOT NULL,
+	track_phase_velocity REAL,
+	soil_phase_velocity REAL
+)`
+)
+
+// maxErrorLength is the maximum length of an error message stored in the runs table [bytes].
+const maxErrorLength = 1000
+
+// sqliteSink writes the outcomes of a batch to a SQLite database as the jobs finish.
+// The runs table holds one row per job with its status and critical values; the curves
+// table holds the dispersion curves of the successful jobs, linked through run_id.
+// Soil phase velocities without a root are stored as NULL. The rows of every job are
+// inserted in a transaction of their own, so that the database holds all the jobs that
+// finished before an interruption of the batch, and no curves are kept in memory.
+type sqliteSink struct {
+	db  *sql.DB
+	err error // First error inserting the outcome of a job
+}
+
+// newSQLiteSink creates a new SQLite database with the runs and curves tables,
+// replacing any existing file.
+//
+// Parameters:
+//   - fileName: Path of the SQLite database file
+//
+// Returns:
+//   - *sqliteSink: The sink writing to the database
+//   - error: An error if the database cannot be created
+func newSQLiteSink(fileName string) (*sqliteSink, error) {
+	if dir := filepath.Dir(fileName); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("error creating directory: %v", err)
+		}
+	}
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error replacing %s: %v", fileName, err)
+	}
+
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serializes the transactions of the jobs
+	db.SetMaxOpenConns(1)
+	for _, schema := range []string{runsTableSchema, curvesTableSchema} {
+		if _, err := db.Exec(schema); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &sqliteSink{db: db}, nil
+}
+
+// add inserts the outcome of a job. After a failed insertion, the outcomes of the
+// following jobs are not inserted, and the error is reported by close.
+//
+// Parameters:
+//   - outcome: Outcome of the job
+func (s *sqliteSink) add(outcome jobOutcome) {
+	if s.err == nil {
+		s.err = s.insert(outcome)
+	}
+}
+
+// insert inserts the rows of the outcome of a job in a single transaction.
+//
+// Parameters:
+//   - outcome: Outcome of the job
+//
+// Returns:
+//   - error: An error if the rows cannot be inserted
+func (s *sqliteSink) insert(outcome jobOutcome) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	duration := outcome.duration.Seconds()
+	if outcome.err != nil {
+		_, err := tx.Exec(`INSERT INTO runs (config_path, status, error, duration_s) VALUES (?, 'failed', ?, ?)`,
+			outcome.job.path, truncateMessage(outcome.err.Error(), maxErrorLength), duration)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	result := outcome.result
+	run, err := tx.Exec(`INSERT INTO runs (config_path, status, duration_s, critical_omega, critical_velocity) VALUES (?, 'ok', ?, ?, ?)`,
+		outcome.job.path, duration, result.CriticalOmega, result.CriticalVelocity)
+	if err != nil {
+		return err
+	}
+	runID, err := run.LastInsertId()
+	if err != nil {
+		return err
+	}
+	curve, err := tx.Prepare(`INSERT INTO curves (run_id, omega, track_phase_velocity, soil_phase_velocity) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer curve.Close()
+	for i := range result.Omega {
+		var soil any
+		if v := result.SoilPhaseVelocity[i]; !math.IsNaN(v) {
+			soil = v
+		}
+		if _, err := curve.Exec(runID, result.Omega[i], result.TrackPhaseVelocity[i], soil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// close closes the database.
+//
+// Returns:
+//   - error: An error if the outcome of a job could not be inserted or the database cannot be closed
+func (s *sqliteSink) close() error {
+	err := s.db.Close()
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
+
+// truncateMessage shortens a message to at most a number of bytes, on a character
+// boundary so that it remains valid UTF-8.
+//
+// Parameters:
+//   - message: The message
+//   - length: Largest length of the message [bytes]
+//
+// Returns:
+//   - string: The message, truncated if it is longer than length
+func truncateMessage(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+	for length > 0 && !utf8.RuneStart(message[length]) {
+		length--
+	}
+	return message[:length]
+}
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// histogramBins is the largest number of bins of the histogram of a batch summary.
+const histogramBins = 10
+
+// histogramBarWidth is the number of characters of the largest histogram bar.
+const histogramBarWidth = 40
+
+// summaryPercentiles are the percentiles of the critical velocity reported in a batch
+// summary [%].
+var summaryPercentiles = []float64{5, 25, 50, 75, 95}
+
+// Percentile is a percentile of the critical velocities of a batch.
+type Percentile struct {
+	Level float64 // Percentage of the jobs with a lower critical velocity [%]
+	Value float64 // Critical velocity [m/s]
+}
+
+// HistogramBin is a bin of the histogram of the critical velocities of a batch.
+type HistogramBin struct {
+	Low   float64 // Lower edge of the bin [m/s]
+	High  float64 // Upper edge of the bin [m/s] (included in the last bin)
+	Count int     // Number of jobs with a critical velocity in the bin
+}
+
+// BatchStats summarizes the critical velocities of the successful jobs of a batch,
+// e.g. for probabilistic or route-wide studies.
+type BatchStats struct {
+	Count       int            // Number of jobs with a critical velocity
+	Failed      int            // Number of failed jobs
+	Min         float64        // Lowest critical velocity [m/s]
+	Max         float64        // Highest critical velocity [m/s]
+	Mean        float64        // Mean critical velocity [m/s]
+	StdDev      float64        // Standard deviation of the critical velocities [m/s]
+	Percentiles []Percentile   // The 5th, 25th, 50th, 75th and 95th percentiles
+	Histogram   []HistogramBin // Histogram of the critical velocities, in bins of equal width
+}
+
+// Statistics computes the statistics of the critical velocities of a batch from the
+// results returned by RunWithResults. Failed jobs are counted but not included in
+// the statistics.
+//
+// Parameters:
+//   - results: The outcome of every job
+//
+// Returns:
+//   - BatchStats: The statistics (Count is zero if no job has a critical velocity)
+func Statistics(results []JobResult) BatchStats {
+	var summary batchSummary
+	for _, r := range results {
+		summary.add(r.Result.CriticalVelocity, r.Err)
+	}
+	return summary.stats()
+}
+
+// batchSummary collects the critical velocities of a batch while its jobs complete.
+type batchSummary struct {
+	velocities []float64 // Critical velocities of the successful jobs [m/s]
+	failed     int       // Number of failed jobs
+}
+
+// add records the outcome of a job. Critical velocities that are not finite are skipped.
+//
+// Parameters:
+//   - velocity: Critical velocity of the job [m/s]
+//   - err: Error returned by the analysis (nil on success)
+func (s *batchSummary) add(velocity float64, err error) {
+	if err != nil {
+		s.failed++
+		return
+	}
+	if !math.IsNaN(velocity) && !math.IsInf(velocity, 0) {
+		s.velocities = append(s.velocities, velocity)
+	}
+}
+
+// stats computes the statistics of the collected critical velocities.
+//
+// Returns:
+//   - BatchStats: The statistics
+func (s *batchSummary) stats() BatchStats {
+	stats := BatchStats{Count: len(s.velocities), Failed: s.failed}
+	if stats.Count == 0 {
+		return stats
+	}
+	sorted := slices.Clone(s.velocities)
+	slices.Sort(sorted)
+	stats.Min, stats.Max = sorted[0], sorted[len(sorted)-1]
+
+	var sum, sumSquares float64
+	for _, v := range sorted {
+		sum += v
+		sumSquares += v * v
+	}
+	n := float64(stats.Count)
+	stats.Mean = sum / n
+	stats.StdDev = math.Sqrt(math.Max(sumSquares/n-stats.Mean*stats.Mean, 0))
+
+	for _, level := range summaryPercentiles {
+		stats.Percentiles = append(stats.Percentiles, Percentile{Level: level, Value: percentile(sorted, level)})
+	}
+
+	bins := min(histogramBins, stats.Count)
+	if stats.Max == stats.Min {
+		bins = 1
+	}
+	width := (stats.Max - stats.Min) / float64(bins)
+	stats.Histogram = make([]HistogramBin, bins)
+	for i := range stats.Histogram {
+		stats.Histogram[i] = HistogramBin{Low: stats.Min + float64(i)*width, High: stats.Min + float64(i+1)*width}
+	}
+	stats.Histogram[bins-1].High = stats.Max
+	for _, v := range sorted {
+		bin := bins - 1
+		if width > 0 {
+			bin = min(int((v-stats.Min)/width), bins-1)
+		}
+		stats.Histogram[bin].Count++
+	}
+	return stats
+}
+
+// percentile returns a percentile of sorted values, interpolated linearly between
+// the closest ranks.
+//
+// Parameters:
+//   - sorted: The values, in ascending order
+//   - level: The percentile [%]
+//
+// Returns:
+//   - float64: The value of the percentile
+func percentile(sorted []float64, level float64) float64 {
+	rank := level / 100 * float64(len(sorted)-1)
+	low := int(math.Floor(rank))
+	if low >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[low] + (rank-float64(low))*(sorted[low+1]-sorted[low])
+}
+
+// write prints the statistics as the summary of a batch, with a histogram drawn in
+// characters.
+//
+// Parameters:
+//   - out: Destination of the summary
+func (s BatchStats) write(out io.Writer) {
+	if s.Count == 0 {
+		fmt.Fprintln(out, "No critical velocities")
+		return
+	}
+	fmt.Fprintf(out, "Critical velocity [m/s]: n %d  min %.2f  max %.2f  mean %.2f  std %.2f\n",
+		s.Count, s.Min, s.Max, s.Mean, s.StdDev)
+	parts := make([]string, len(s.Percentiles))
+	for i, p := range s.Percentiles {
+		parts[i] = fmt.Sprintf("P%g %.2f", p.Level, p.Value)
+	}
+	fmt.Fprintf(out, "Percentiles [m/s]: %s\n", strings.Join(parts, "  "))
+
+	largest := 0
+	for _, bin := range s.Histogram {
+		largest = max(largest, bin.Count)
+	}
+	for _, bin := range s.Histogram {
+		bar := int(math.Round(float64(bin.Count) / float64(largest) * histogramBarWidth))
+		fmt.Fprintf(out, "%8.2f-%-8.2f %5d %s\n", bin.Low, bin.High, bin.Count, strings.Repeat("#", bar))
+	}
+}
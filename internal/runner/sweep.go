@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	storage "github.com/PlatypusBytes/GoTrain/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// SweepParameter is a configuration parameter varied in a sweep.
+type SweepParameter struct {
+	Path   string `yaml:"path"`   // Dot-separated path of the parameter in the configuration, e.g. soil_layers.0.young_modulus
+	Values []any  `yaml:"values"` // Values taken by the parameter
+}
+
+// SweepSpec describes a parameter sweep: every combination of the values of
+// its parameters is computed.
+type SweepSpec struct {
+	Parameters []SweepParameter `yaml:"parameters"` // Parameters varied in the sweep
+}
+
+// RunSweep expands a template configuration and a sweep specification into the
+// matrix of all parameter combinations and processes it as RunWithOptions does.
+// The configurations are generated in memory; no intermediate YAML files are written.
+//
+// The sweep specification is a YAML file listing the parameters to vary, by their
+// path in the configuration (list elements are addressed by their index):
+//
+//	parameters:
+//	  - path: soil_layers.0.young_modulus
+//	    values: [20e6, 30e6, 40e6]
+//	  - path: frequency.max
+//	    values: [200, 400]
+//
+// Combinations are numbered from 1, with the last parameter varying fastest.
+// The result file of combination i is the output file of the template with the
+// suffix _i, e.g. results_0003.json. Jobs are reported (in progress events, logs
+// and the SQLite runs table) as the template path followed by the combination.
+//
+// Parameters:
+//   - templatePath: Path to the template YAML configuration file
+//   - specPath: Path to the sweep specification YAML file
+//   - opts: Options controlling the batch (Order must be empty or OrderAsFound)
+//
+// Returns:
+//   - error: An error if the files cannot be read or the sweep is invalid
+func RunSweep(templatePath string, specPath string, opts Options) error {
+	if opts.Order != "" && opts.Order != OrderAsFound {
+		return fmt.Errorf("job order %s cannot be used with a sweep", opts.Order)
+	}
+
+	template, err := storage.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template config: %v", err)
+	}
+	data, err := storage.ReadFile(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to read sweep specification: %v", err)
+	}
+	var spec SweepSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse sweep specification: %v", err)
+	}
+
+	jobs, err := expandSweep(templatePath, template, spec)
+	if err != nil {
+		return err
+	}
+	_, _, err = runBatch(jobs, opts, false)
+	return err
+}
+
+// expandSweep generates the job of every parameter combination of a sweep.
+//
+// Parameters:
+//   - templatePath: Path to the template configuration (used to name the jobs)
+//   - template: Content of the template YAML configuration
+//   - spec: The sweep specification
+//
+// Returns:
+//   - []Job: One job per combination, with its configuration
+//   - error: An error if the template or the specification is invalid
+func expandSweep(templatePath string, template []byte, spec SweepSpec) ([]Job, error) {
+	if len(spec.Parameters) == 0 {
+		return nil, fmt.Errorf("sweep specification has no parameters")
+	}
+	total := 1
+	for _, p := range spec.Parameters {
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("sweep parameter %s has no values", p.Path)
+		}
+		total *= len(p.Values)
+	}
+
+	var document map[string]any
+	if err := yaml.Unmarshal(template, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse template config: %v", err)
+	}
+	output, _ := lookupPath(document, "output.file_name")
+	outputName, _ := output.(string)
+	extension := critical_speed.OutputExt(outputName)
+	width := len(strconv.Itoa(total))
+
+	jobs := make([]Job, 0, total)
+	indices := make([]int, len(spec.Parameters))
+	for number := 1; number <= total; number++ {
+
+		// Set the parameters of this combination in the template
+		labels := make([]string, len(spec.Parameters))
+		for i, p := range spec.Parameters {
+			value := p.Values[indices[i]]
+			if err := setPath(document, p.Path, value); err != nil {
+				return nil, fmt.Errorf("invalid sweep parameter %s: %v", p.Path, err)
+			}
+			labels[i] = fmt.Sprintf("%s=%v", p.Path, value)
+		}
+
+		data, err := yaml.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding sweep configuration: %v", err)
+		}
+		config, err := critical_speed.ParseConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for combination %d: %v", number, err)
+		}
+		config.Output.FileName = fmt.Sprintf("%s_%0*d%s", strings.TrimSuffix(outputName, extension), width, number, extension)
+
+		path := fmt.Sprintf("%s[%0*d: %s]", templatePath, width, number, strings.Join(labels, ", "))
+		jobs = append(jobs, Job{path: path, config: &config})
+
+		// Advance to the next combination, the last parameter varying fastest
+		for i := len(indices) - 1; i >= 0; i-- {
+			indices[i]++
+			if indices[i] < len(spec.Parameters[i].Values) {
+				break
+			}
+			indices[i] = 0
+		}
+	}
+	return jobs, nil
+}
+
+// lookupPath returns the element at a dot-separated path in a YAML document.
+//
+// Parameters:
+//   - document: The decoded YAML document
+//   - path: Dot-separated path; list elements are addressed by their index
+//
+// Returns:
+//   - any: The element at the path
+//   - error: An error if the path does not exist in the document
+func lookupPath(document any, path string) (any, error) {
+	node := document
+	for _, key := range strings.Split(path, ".") {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found", key)
+			}
+			node = child
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(n) {
+				return nil, fmt.Errorf("invalid list index %s", key)
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("cannot index %s into a value", key)
+		}
+	}
+	return node, nil
+}
+
+// setPath sets the element at a dot-separated path in a YAML document.
+// The element must already exist, so that misspelled parameters are reported.
+//
+// Parameters:
+//   - document: The decoded YAML document, modified in place
+//   - path: Dot-separated path; list elements are addressed by their index
+//   - value: The new value of the element
+//
+// Returns:
+//   - error: An error if the path does not exist in the document
+func setPath(document map[string]any, path string, value any) error {
+	parentPath, key := "", path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		parentPath, key = path[:i], path[i+1:]
+	}
+
+	var parent any = document
+	if parentPath != "" {
+		var err error
+		if parent, err = lookupPath(document, parentPath); err != nil {
+			return err
+		}
+	}
+
+	switch p := parent.(type) {
+	case map[string]any:
+		if _, ok := p[key]; !ok {
+			return fmt.Errorf("key %s not found", key)
+		}
+		p[key] = value
+	case []any:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(p) {
+			return fmt.Errorf("invalid list index %s", key)
+		}
+		p[index] = value
+	default:
+		return fmt.Errorf("cannot index %s into a value", key)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package server provides an HTTP interface for submitting critical speed analyses.
+//
+// Configurations are submitted as a YAML or JSON request body, with the same fields
+// as the YAML configuration files used by the critical_speed command. Each submission
+// is queued on a runner.Pool and processed in the background; clients poll the job
+// status and fetch the result once the job is done. Small configurations can
+// also be computed synchronously with POST /compute, which returns the result in
+// the response, e.g. for web calculators.
+//
+// Result files are never written by the server: the output section of submitted
+// configurations is ignored, and results are only available through the API.
+// Jobs are kept in memory for the lifetime of the server.
+//
+// # Endpoints
+//
+//	POST /jobs               Submit a configuration; returns {"id": ..., "status": "pending"}
+//	GET  /jobs/{id}          Status of a job: pending, done or failed (with an error message)
+//	GET  /jobs/{id}/result   Result of a finished job, in the same JSON format as the result files
+//	POST /compute            Compute a configuration synchronously; returns the result JSON
+//	GET  /openapi.json       OpenAPI specification of the endpoints (see OpenAPISpec)
+//
+// Synchronous computations run on at most one goroutine per worker and are
+// cancelled when the client disconnects or the job timeout expires.
+//
+// # Usage Example
+//
+//	srv := server.New(runner.Options{Workers: 4})
+//	defer srv.Close()
+//	log.Fatal(http.ListenAndServe(":8080", srv.Handler()))
+//
+// Submitting a configuration and fetching its result with curl:
+//
+//	curl --data-binary @configs/sample_config.yaml http://localhost:8080/jobs
+//	curl http://localhost:8080/jobs/<id>
+//	curl http://localhost:8080/jobs/<id>/result
+//
+// Computing a configuration synchronously:
+//
+//	curl --data-binary @configs/sample_config.yaml http://localhost:8080/compute
+package server
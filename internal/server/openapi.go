@@ -0,0 +1,197 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+)
+
+// openAPIVersion is the version of the OpenAPI specification served by the API.
+const openAPIVersion = "3.0.3"
+
+// OpenAPISpec returns the OpenAPI specification of the API. The schemas of the
+// configuration and the result are generated from the critical_speed.Config and
+// critical_speed.DispersionResults types, so they follow the configuration files.
+//
+// Returns:
+//   - map[string]any: The specification, to be encoded as JSON
+func OpenAPISpec() map[string]any {
+	configBody := map[string]any{
+		"required": true,
+		"description": "Configuration, with the same fields as the configuration files " +
+			"(the output section is ignored). JSON is accepted as well, as a subset of YAML.",
+		"content": map[string]any{
+			"application/yaml": map[string]any{"schema": schemaRef("Config")},
+			"application/json": map[string]any{"schema": schemaRef("Config")},
+		},
+	}
+	errorResponse := func(description string) map[string]any {
+		return jsonResponse(description, schemaRef("Error"))
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "GoTrain API",
+			"description": "Critical speed analyses of railway tracks on layered soils.",
+			"version":     "1.0.0",
+		},
+		"paths": map[string]any{
+			"/compute": map[string]any{
+				"post": map[string]any{
+					"summary":     "Compute the critical speed of a configuration synchronously",
+					"operationId": "compute",
+					"requestBody": configBody,
+					"responses": map[string]any{
+						"200": jsonResponse("Result of the analysis", schemaRef("Result")),
+						"400": errorResponse("The configuration cannot be parsed"),
+						"413": errorResponse("The configuration is too large"),
+						"422": errorResponse("The analysis failed, e.g. for an unsupported track type"),
+						"503": errorResponse("The request was cancelled while waiting for a free worker"),
+						"504": errorResponse("The analysis exceeded the job timeout"),
+					},
+				},
+			},
+			"/jobs": map[string]any{
+				"post": map[string]any{
+					"summary":     "Submit a configuration for background processing",
+					"operationId": "submitJob",
+					"requestBody": configBody,
+					"responses": map[string]any{
+						"202": jsonResponse("The job is queued", schemaRef("JobStatus")),
+						"400": errorResponse("The configuration cannot be parsed"),
+						"413": errorResponse("The configuration is too large"),
+					},
+				},
+			},
+			"/jobs/{id}": map[string]any{
+				"get": map[string]any{
+					"summary":     "Status of a job",
+					"operationId": "getJob",
+					"parameters":  []any{jobIDParameter()},
+					"responses": map[string]any{
+						"200": jsonResponse("Status of the job", schemaRef("JobStatus")),
+						"404": errorResponse("Unknown job"),
+					},
+				},
+			},
+			"/jobs/{id}/result": map[string]any{
+				"get": map[string]any{
+					"summary":     "Result of a finished job",
+					"operationId": "getJobResult",
+					"parameters":  []any{jobIDParameter()},
+					"responses": map[string]any{
+						"200": jsonResponse("Result of the analysis", schemaRef("Result")),
+						"404": errorResponse("Unknown job"),
+						"409": errorResponse("The job is not finished or failed"),
+					},
+				},
+			},
+		},
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"Config":    schemaOf(reflect.TypeFor[critical_speed.Config](), "yaml"),
+				"Result":    schemaOf(reflect.TypeFor[critical_speed.DispersionResults](), "json"),
+				"JobStatus": schemaOf(reflect.TypeFor[JobStatus](), "json"),
+				"Error": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"error": map[string]any{"type": "string"}},
+				},
+			},
+		},
+	}
+}
+
+// handleOpenAPI serves the OpenAPI specification of the API.
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPISpec())
+}
+
+// schemaRef returns a reference to a schema of the specification components.
+//
+// Parameters:
+//   - name: Name of the schema
+//
+// Returns:
+//   - map[string]any: The reference object
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonResponse returns a response object with a JSON body.
+//
+// Parameters:
+//   - description: Description of the response
+//   - schema: Schema of the body
+//
+// Returns:
+//   - map[string]any: The response object
+func jsonResponse(description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// jobIDParameter returns the path parameter identifying a job.
+//
+// Returns:
+//   - map[string]any: The parameter object
+func jobIDParameter() map[string]any {
+	return map[string]any{
+		"name":     "id",
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]any{"type": "string"},
+	}
+}
+
+// schemaOf generates the schema of a Go type from its fields and their tags.
+// Interface values only occur in the results, where they hold a number or the
+// string "NaN" (see critical_speed.DispersionResults).
+//
+// Parameters:
+//   - t: The type
+//   - tag: Struct tag naming the fields, "yaml" or "json"
+//
+// Returns:
+//   - map[string]any: The schema object
+func schemaOf(t reflect.Type, tag string) map[string]any {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), tag)}
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), tag)
+	case reflect.Interface:
+		return map[string]any{"oneOf": []any{
+			map[string]any{"type": "number"},
+			map[string]any{"type": "string", "enum": []any{"NaN"}},
+		}}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, tag)
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	critical_speed "github.com/PlatypusBytes/GoTrain/internal/critical_speed"
+	runner "github.com/PlatypusBytes/GoTrain/internal/runner"
+)
+
+// Job status values reported by the API.
+const (
+	StatusPending = "pending" // Job is queued or running
+	StatusDone    = "done"    // Job completed successfully; the result is available
+	StatusFailed  = "failed"  // Job failed; the error is reported in the status
+)
+
+// maxBodySize is the maximum size of a submitted configuration [bytes].
+const maxBodySize = 1 << 20
+
+// JobStatus is the JSON response describing a submitted job.
+type JobStatus struct {
+	ID     string `json:"id"`              // Identifier of the job
+	Status string `json:"status"`          // One of StatusPending, StatusDone or StatusFailed
+	Error  string `json:"error,omitempty"` // Error message (only for failed jobs)
+}
+
+// job holds the state of a submitted job.
+type job struct {
+	status string                 // Current status of the job
+	err    string                 // Error message (only for failed jobs)
+	result *critical_speed.Result // Result of the analysis (only for finished jobs)
+}
+
+// Server processes configurations submitted over HTTP on a pool of workers.
+type Server struct {
+	pool       *runner.Pool    // Worker pool processing the submitted jobs
+	mu         sync.Mutex      // Guards jobs
+	jobs       map[string]*job // Submitted jobs by identifier
+	compute    chan struct{}   // Limits the number of concurrent synchronous computations
+	jobTimeout time.Duration   // Maximum duration of a synchronous computation (no limit when <= 0)
+}
+
+// New creates a server and starts its worker pool.
+//
+// Parameters:
+//   - opts: Options controlling the workers (see runner.NewPool)
+//
+// Returns:
+//   - *Server: The started server
+func New(opts runner.Options) *Server {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	s := &Server{
+		jobs:       make(map[string]*job),
+		compute:    make(chan struct{}, workers),
+		jobTimeout: opts.JobTimeout,
+	}
+	s.pool = runner.NewPool(opts, s.finish)
+	return s
+}
+
+// Close stops the worker pool once all submitted jobs have been processed.
+func (s *Server) Close() {
+	s.pool.Close()
+}
+
+// Handler returns the HTTP handler serving the API.
+//
+// Returns:
+//   - http.Handler: Handler for the endpoints described in the package documentation
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.handleSubmit)
+	mux.HandleFunc("GET /jobs/{id}", s.handleStatus)
+	mux.HandleFunc("GET /jobs/{id}/result", s.handleResult)
+	mux.HandleFunc("POST /compute", s.handleCompute)
+	mux.HandleFunc("GET /openapi.json", handleOpenAPI)
+	return mux
+}
+
+// finish records the outcome of a job. It is called by the worker pool.
+//
+// Parameters:
+//   - res: Outcome of the job, with the job identifier as path
+func (s *Server) finish(res runner.JobResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[res.Path]
+	if !ok {
+		return
+	}
+	if res.Err != nil {
+		j.status = StatusFailed
+		j.err = res.Err.Error()
+		return
+	}
+	j.status = StatusDone
+	j.result = &res.Result
+}
+
+// readConfig reads and parses the configuration in a request body. On failure,
+// the error response is written.
+//
+// Parameters:
+//   - w: The response writer
+//   - r: The request
+//
+// Returns:
+//   - critical_speed.Config: The submitted configuration
+//   - bool: False if the configuration could not be read (the response has been written)
+func readConfig(w http.ResponseWriter, r *http.Request) (critical_speed.Config, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, code, fmt.Errorf("failed to read request body: %v", err))
+		return critical_speed.Config{}, false
+	}
+
+	// JSON is a subset of YAML, so both formats are accepted by the YAML parser
+	config, err := critical_speed.ParseConfig(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return critical_speed.Config{}, false
+	}
+
+	// Submitted configurations must not read files of the server
+	if config.SoilCPT.File != "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("soil_cpt cannot be used with the server: submit soil_layers instead"))
+		return critical_speed.Config{}, false
+	}
+	if config.SoilProfile.File != "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("soil_profile cannot be used with the server: submit soil_layers instead"))
+		return critical_speed.Config{}, false
+	}
+	return config, true
+}
+
+// handleSubmit parses a submitted configuration and queues it for processing.
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = &job{status: StatusPending}
+	s.mu.Unlock()
+
+	// Submit in the background, so requests do not wait while the job queue is full
+	go func() {
+		if !s.pool.Submit(id, config) {
+			s.finish(runner.JobResult{Path: id, Err: fmt.Errorf("server is shutting down")})
+		}
+	}()
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, JobStatus{ID: id, Status: StatusPending})
+}
+
+// handleStatus reports the status of a job.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	var status JobStatus
+	if ok {
+		status = JobStatus{ID: id, Status: j.status, Error: j.err}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job not found: %s", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleResult returns the result of a finished job.
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	var status string
+	var result *critical_speed.Result
+	if ok {
+		status, result = j.status, j.result
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, fmt.Errorf("job not found: %s", id))
+	case status == StatusPending:
+		writeError(w, http.StatusConflict, fmt.Errorf("job is not finished: %s", id))
+	case status == StatusFailed:
+		writeError(w, http.StatusConflict, fmt.Errorf("job failed: %s", id))
+	default:
+		writeJSON(w, http.StatusOK, result.DispersionResults())
+	}
+}
+
+// handleCompute runs the analysis of a submitted configuration synchronously and
+// returns its result. At most one computation per worker runs at a time; further
+// requests wait for a free slot. The computation is cancelled if the client
+// disconnects or the job timeout expires.
+func (s *Server) handleCompute(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if s.jobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.jobTimeout)
+		defer cancel()
+	}
+
+	select {
+	case s.compute <- struct{}{}:
+		defer func() { <-s.compute }()
+	case <-ctx.Done():
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("no computation slot available: %v", ctx.Err()))
+		return
+	}
+
+	// Configurations may come from untrusted sources, so nothing is written at their output paths
+	result, err := critical_speed.Compute(ctx, config)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, err)
+	case err != nil:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeJSON(w, http.StatusOK, result.DispersionResults())
+	}
+}
+
+// newID generates a random job identifier.
+//
+// Returns:
+//   - string: 16 random bytes, hex encoded
+//   - error: An error if the random source fails
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeJSON writes a value as a JSON response.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as a JSON response of the form {"error": "..."}.
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
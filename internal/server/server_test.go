@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	runner "github.com/PlatypusBytes/GoTrain/internal/runner"
+)
+
+const TOL = 1e-3
+
+// Test submitting the sample configuration, polling its status and fetching the result.
+func TestSubmitAndFetchResult(t *testing.T) {
+	srv := New(runner.Options{Workers: 2})
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	config, err := os.Open("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to open sample config: %v", err)
+	}
+	defer config.Close()
+
+	resp, err := http.Post(ts.URL+"/jobs", "application/yaml", config)
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	var submitted JobStatus
+	json.NewDecoder(resp.Body).Decode(&submitted)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || submitted.ID == "" || submitted.Status != StatusPending {
+		t.Fatalf("unexpected submit response: %d %+v", resp.StatusCode, submitted)
+	}
+
+	// Poll until the job is finished
+	deadline := time.Now().Add(time.Minute)
+	var status JobStatus
+	for {
+		resp, err := http.Get(ts.URL + "/jobs/" + submitted.ID)
+		if err != nil {
+			t.Fatalf("status request failed: %v", err)
+		}
+		json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if status.Status != StatusPending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish in time")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if status.Status != StatusDone {
+		t.Fatalf("unexpected job status: %+v", status)
+	}
+
+	resp, err = http.Get(ts.URL + "/jobs/" + submitted.ID + "/result")
+	if err != nil {
+		t.Fatalf("result request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var result map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	expectedSpeed := 78.231
+	if speed, ok := result["critical_velocity"].(float64); !ok {
+		t.Errorf("critical_velocity is not a float64")
+	} else if diff := speed - expectedSpeed; diff < -TOL || diff > TOL {
+		t.Errorf("unexpected critical_velocity: got %v, want %v (tolerance %v)", speed, expectedSpeed, TOL)
+	}
+
+	// The server must not write the result file named in the configuration
+	if _, err := os.Stat("dispersion_results.json"); err == nil {
+		os.Remove("dispersion_results.json")
+		t.Errorf("server wrote a result file")
+	}
+}
+
+// Test the error responses for invalid submissions and unknown jobs.
+func TestErrors(t *testing.T) {
+	srv := New(runner.Options{Workers: 1})
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/jobs", "frequency: [", http.StatusBadRequest},
+		{http.MethodPost, "/jobs", strings.Repeat("#", maxBodySize+1), http.StatusRequestEntityTooLarge},
+		{http.MethodGet, "/jobs/unknown", "", http.StatusNotFound},
+		{http.MethodGet, "/jobs/unknown/result", "", http.StatusNotFound},
+		{http.MethodGet, "/jobs", "", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, ts.URL+c.path, strings.NewReader(c.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", c.method, c.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.code {
+			t.Errorf("%s %s: got status %d, want %d", c.method, c.path, resp.StatusCode, c.code)
+		}
+	}
+}
+
+// Test computing the sample configuration synchronously.
+func TestCompute(t *testing.T) {
+	srv := New(runner.Options{Workers: 1})
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	config, err := os.Open("../../testdata/sample_config.yaml")
+	if err != nil {
+		t.Fatalf("failed to open sample config: %v", err)
+	}
+	defer config.Close()
+
+	resp, err := http.Post(ts.URL+"/compute", "application/yaml", config)
+	if err != nil {
+		t.Fatalf("compute request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+	var result map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	expectedSpeed := 78.231
+	if speed, ok := result["critical_velocity"].(float64); !ok {
+		t.Errorf("critical_velocity is not a float64")
+	} else if diff := speed - expectedSpeed; diff < -TOL || diff > TOL {
+		t.Errorf("unexpected critical_velocity: got %v, want %v (tolerance %v)", speed, expectedSpeed, TOL)
+	}
+
+	// An unsupported track type fails the analysis
+	resp, err = http.Post(ts.URL+"/compute", "application/yaml", strings.NewReader("track_type: concrete"))
+	if err != nil {
+		t.Fatalf("compute request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d for an invalid track type, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+// Test that the OpenAPI specification documents the endpoints and the schemas.
+func TestOpenAPI(t *testing.T) {
+	srv := New(runner.Options{Workers: 1})
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("openapi request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		OpenAPI    string                     `json:"openapi"`
+		Paths      map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("failed to parse specification: %v", err)
+	}
+	if spec.OpenAPI == "" {
+		t.Errorf("missing openapi version")
+	}
+	for _, path := range []string{"/compute", "/jobs", "/jobs/{id}", "/jobs/{id}/result"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("path %s is not documented", path)
+		}
+	}
+	for schema, property := range map[string]string{
+		"Config":    "soil_layers",
+		"Result":    "critical_velocity",
+		"JobStatus": "status",
+	} {
+		if _, ok := spec.Components.Schemas[schema].Properties[property]; !ok {
+			t.Errorf("schema %s has no property %s", schema, property)
+		}
+	}
+}
+
+// Test that submitted configurations cannot read CPT or profile files of the server.
+func TestRejectSoilCPT(t *testing.T) {
+	srv := New(runner.Options{Workers: 1})
+	defer srv.Close()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	for _, section := range []string{"soil_cpt", "soil_profile"} {
+		for _, path := range []string{"/jobs", "/compute"} {
+			resp, err := http.Post(ts.URL+path, "application/yaml", strings.NewReader(section+":\n  file: /etc/passwd\n"))
+			if err != nil {
+				t.Fatalf("%s request failed: %v", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s %s: got status %d, want %d", section, path, resp.StatusCode, http.StatusBadRequest)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package soil_dispersion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"slices"
+	"sync"
+)
+
+// Cache stores soil dispersion curves by soil profile and frequencies, so that
+// batches of configurations sharing a soil profile compute its dispersion curve
+// only once. It is safe for concurrent use: when several goroutines request the
+// same curve, one computes it and the others wait for its result.
+type Cache struct {
+	mu      sync.Mutex               // Guards entries, hits and misses
+	entries map[[32]byte]*cacheEntry // Cached curves by key (see cacheKey)
+	hits    int64                    // Number of curves served from the cache
+	misses  int64                    // Number of curves computed
+}
+
+// cacheEntry is a dispersion curve that is computed or being computed.
+type cacheEntry struct {
+	done  chan struct{} // Closed once the curve has been computed
+	curve []float64     // Phase velocities, valid once done is closed
+	err   error         // Error of the computation, valid once done is closed
+}
+
+// NewCache creates an empty soil dispersion cache.
+//
+// Returns:
+//   - *Cache: The cache
+func NewCache() *Cache {
+	return &Cache{entries: make(map[[32]byte]*cacheEntry)}
+}
+
+// cacheKey computes the key of a soil profile, frequencies and scan options: a
+// SHA-256 hash of the properties of the layers (wave speeds are derived from them),
+// the frequencies and the options.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - omega: The angular frequencies [rad/s]
+//   - opts: The scan options
+//
+// Returns:
+//   - [32]byte: The key
+func cacheKey(layers []Layer, omega []float64, opts ScanOptions) [32]byte {
+	step := opts.Step
+	if step <= 0 {
+		step = DefaultStep
+	}
+	data := make([]byte, 0, 8*(4+4*len(layers)+len(omega)))
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(step))
+	if opts.Interpolate {
+		data = append(data, 1)
+	} else {
+		data = append(data, 0)
+	}
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(opts.Refine)))
+	data = append(data, opts.Refine...)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(layers)))
+	for _, l := range layers {
+		for _, v := range []float64{l.Density, l.YoungsModulus, l.PoissonRatio, l.Thickness} {
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		}
+	}
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(omega)))
+	for _, w := range omega {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(w))
+	}
+	return sha256.Sum256(data)
+}
+
+// SoilDispersionContext returns the soil dispersion curve as the package function
+// SoilDispersionContext does, computing it only if the cache holds no curve for the
+// same profile and frequencies. Failed computations (e.g. cancelled) are not cached.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation (or the wait for another goroutine computing it)
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: A slice of angular frequencies [rad/s] at which to compute phase velocities.
+//
+// Returns:
+//   - A slice of phase speeds [m/s] for each frequency in omega (NaN where no solution is found).
+//   - A bool reporting whether the curve was served from the cache.
+//   - An error if the context is cancelled before the curve is available.
+func (c *Cache) SoilDispersionContext(ctx context.Context, layers []Layer, omega []float64) ([]float64, bool, error) {
+	return c.SoilDispersionScan(ctx, layers, omega, ScanOptions{})
+}
+
+// SoilDispersionScan returns the soil dispersion curve as the package function
+// SoilDispersionScan does, computing it only if the cache holds no curve for the
+// same profile, frequencies and scan options.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation (or the wait for another goroutine computing it)
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: A slice of angular frequencies [rad/s] at which to compute phase velocities.
+//   - opts: Step of the scan and location of the roots within their brackets.
+//
+// Returns:
+//   - A slice of phase speeds [m/s] for each frequency in omega (NaN where no solution is found).
+//   - A bool reporting whether the curve was served from the cache.
+//   - An error if the context is cancelled before the curve is available.
+func (c *Cache) SoilDispersionScan(ctx context.Context, layers []Layer, omega []float64, opts ScanOptions) ([]float64, bool, error) {
+	key := cacheKey(layers, omega, opts)
+
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if !ok {
+			entry = &cacheEntry{done: make(chan struct{})}
+			c.entries[key] = entry
+			c.misses++
+			c.mu.Unlock()
+
+			entry.curve, entry.err = SoilDispersionScan(ctx, layers, omega, opts)
+			if entry.err != nil {
+				c.mu.Lock()
+				delete(c.entries, key)
+				c.misses--
+				c.mu.Unlock()
+			}
+			close(entry.done)
+			return slices.Clone(entry.curve), false, entry.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		if entry.err == nil {
+			c.mu.Lock()
+			c.hits++
+			c.mu.Unlock()
+			return slices.Clone(entry.curve), true, nil
+		}
+		// The computation of another goroutine failed (e.g. it was cancelled): retry
+	}
+}
+
+// Stats returns the number of curves served from the cache and computed.
+//
+// Returns:
+//   - int64: Number of cache hits
+//   - int64: Number of curves computed (unique profiles)
+func (c *Cache) Stats() (int64, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
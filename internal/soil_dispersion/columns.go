@@ -0,0 +1,78 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+)
+
+// Columns describes ground improved by columns, e.g. stone columns or deep soil
+// mixing, from the surface down to a treated depth.
+type Columns struct {
+	YoungsModulus float64 // Young's modulus of the column material [Pa]
+	Density       float64 // Density of the column material [kg/m^3] (0 for the density of the soil)
+	PoissonRatio  float64 // Poisson's ratio of the column material (0 for that of the soil)
+	AreaRatio     float64 // Area replacement ratio: cross-section of a column over its tributary area
+	Depth         float64 // Treated depth below the surface [m]
+}
+
+// Homogenize returns the equivalent layer of a soil layer improved by columns. The
+// properties of the composite are the averages of those of the columns and the soil
+// weighted by their areas (the rule of mixtures),
+//
+//	X = a X_c + (1 - a) X_s
+//
+// with a the area replacement ratio: the stiffness of columns and soil loaded in
+// parallel, an upper bound of the stiffness of the composite.
+//
+// Parameters:
+//   - layer: The soil layer
+//
+// Returns:
+//   - Layer: The homogenized layer, with its wave speeds computed
+func (c Columns) Homogenize(layer Layer) Layer {
+	density, poissonRatio := c.Density, c.PoissonRatio
+	if density == 0 {
+		density = layer.Density
+	}
+	if poissonRatio == 0 {
+		poissonRatio = layer.PoissonRatio
+	}
+	mix := func(column, soil float64) float64 { return c.AreaRatio*column + (1-c.AreaRatio)*soil }
+	layer.YoungsModulus = mix(c.YoungsModulus, layer.YoungsModulus)
+	layer.Density = mix(density, layer.Density)
+	layer.PoissonRatio = mix(poissonRatio, layer.PoissonRatio)
+	layer.WaveSpeed()
+	return layer
+}
+
+// ImproveWithColumns inserts ground improved by columns into a soil profile: the
+// layers above the treated depth are replaced by their homogenized layers (see
+// Columns.Homogenize), and the layer at the treated depth is split at that depth.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - columns: The columns
+//
+// Returns:
+//   - []Layer: The improved profile (a copy), with its wave speeds computed
+//   - error: An error if the columns are invalid
+func ImproveWithColumns(layers []Layer, columns Columns) ([]Layer, error) {
+	if !(columns.YoungsModulus > 0) || columns.Density < 0 || columns.PoissonRatio < 0 || columns.PoissonRatio >= 0.5 {
+		return nil, fmt.Errorf("invalid columns: the Young's modulus must be positive, the density not negative and the Poisson's ratio in [0, 0.5)")
+	}
+	if !(columns.AreaRatio > 0) || columns.AreaRatio > 1 {
+		return nil, fmt.Errorf("invalid columns: the area replacement ratio must be in (0, 1], got %g", columns.AreaRatio)
+	}
+	if !(columns.Depth > 0) || math.IsInf(columns.Depth, 1) {
+		return nil, fmt.Errorf("invalid columns: the treated depth must be positive and finite, got %g", columns.Depth)
+	}
+	improved := SplitAt(layers, columns.Depth)
+	top := 0.0
+	for i, layer := range improved {
+		if top < columns.Depth {
+			improved[i] = columns.Homogenize(layer)
+		}
+		top += layer.Thickness
+	}
+	return improved, nil
+}
@@ -0,0 +1,90 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+)
+
+// Discretize splits the layers of a soil profile into sublayers of equal thickness,
+// so that no layer is thicker than maxThickness. The halfspace (the last layer) is
+// not split. The sublayers keep the properties of their layer, so the dispersion
+// relation is unchanged; thin sublayers keep the hyperbolic terms of thick
+// homogeneous strata well conditioned at high frequencies, where they grow as
+// e^{k h}, and give a finer profile to models that sample it by depth.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - maxThickness: Largest thickness of the layers [m]
+//
+// Returns:
+//   - []Layer: The discretized profile (a copy, also when no layer is split)
+//   - error: An error if maxThickness is not positive and finite
+func Discretize(layers []Layer, maxThickness float64) ([]Layer, error) {
+	if !(maxThickness > 0) || math.IsInf(maxThickness, 1) {
+		return nil, fmt.Errorf("invalid layer discretization: the maximum thickness must be positive, got %g", maxThickness)
+	}
+	discretized := make([]Layer, 0, len(layers))
+	for i, layer := range layers {
+		if i == len(layers)-1 || !(layer.Thickness > maxThickness) || math.IsInf(layer.Thickness, 1) {
+			discretized = append(discretized, layer)
+			continue
+		}
+		count := int(math.Ceil(layer.Thickness / maxThickness))
+		sublayer := layer
+		sublayer.Thickness = layer.Thickness / float64(count)
+		for range count {
+			discretized = append(discretized, sublayer)
+		}
+	}
+	return discretized, nil
+}
+
+// ShortestWavelength returns the shortest wavelength of the soil waves up to a
+// frequency: the wavelength of the slowest shear wave of the profile, an upper bound
+// of the phase velocity of its surface waves at the shortest wavelengths.
+//
+// Parameters:
+//   - layers: The soil profile, with its wave speeds computed
+//   - omegaMax: Highest angular frequency of interest [rad/s]
+//
+// Returns:
+//   - float64: The shortest wavelength [m]
+func ShortestWavelength(layers []Layer, omegaMax float64) float64 {
+	slowest := math.Inf(1)
+	for _, layer := range layers {
+		slowest = math.Min(slowest, layer.ShearWaveSpeed)
+	}
+	return 2 * math.Pi * slowest / omegaMax
+}
+
+// SplitAt splits the layer of a soil profile at a depth below the surface into two
+// layers with its properties, so that a layer boundary lies at that depth. The
+// profile is unchanged when a boundary already lies there, or the depth is not
+// positive.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - depth: Depth of the boundary below the surface [m]
+//
+// Returns:
+//   - []Layer: The split profile (a copy)
+func SplitAt(layers []Layer, depth float64) []Layer {
+	split := make([]Layer, 0, len(layers)+1)
+	top := 0.0
+	for i, layer := range layers {
+		halfspace := i == len(layers)-1 || math.IsInf(layer.Thickness, 1)
+		bottom := top + layer.Thickness
+		if top < depth && (halfspace || depth < bottom) {
+			upper := layer
+			upper.Thickness = depth - top
+			layer.Thickness -= upper.Thickness
+			split = append(split, upper)
+		}
+		split = append(split, layer)
+		if halfspace {
+			return split
+		}
+		top = bottom
+	}
+	return split
+}
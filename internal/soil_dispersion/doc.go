@@ -22,6 +22,89 @@
 // each frequency in the provided omega array by iterating over a range of compressional
 // wave speeds and uses the Fast Delta Matrix method to compute the dispersion relation.
 //
+// The same phase velocities are scanned for every frequency. The terms of the
+// dispersion relation that do not depend on the frequency (the P-wave and S-wave
+// terms of each layer and the interface coefficients) are therefore computed once per
+// phase velocity and reused for all frequencies, as long as they fit within
+// MaxMemoizedTerms; only the hyperbolic terms are evaluated per frequency.
+//
+// The phase velocities are scanned in steps of DefaultStep, and a root is taken at the
+// middle of the bracket where the dispersion relation changes sign. SoilDispersionScan
+// allows a coarser step with the root interpolated within its bracket; FastScan is
+// used by the fast approximate mode of the critical speed analysis. The roots may also
+// be refined within their brackets with a bracketing root finder of math_utils
+// (ScanOptions.Refine), such as Chandrupatla's method.
+//
+// The hyperbolic terms of the layers grow exponentially with the frequency and the
+// thickness of the layers, and overflow float64 for thick stiff layers. The recursion
+// is therefore normalized per layer: the cosh and sinh terms are divided by cosh, and
+// so are the other terms of the layer, which divides the determinant by a positive
+// factor and leaves its roots unchanged.
+//
+// For deep, many-layer profiles at high frequency, the float64 determinant may still
+// cancel, producing spurious roots. Such evaluations, where the determinant falls below
+// CancellationLimit of its largest term, are repeated in double-double arithmetic with
+// an unbounded exponent, which is slower but keeps the sign of the determinant correct.
+//
+// Nearly incompressible layers (undrained clays, Poisson's ratio close to 0.5) have
+// compressional wave speeds that grow without bound and ill-condition the recursion.
+// Their Poisson's ratio is capped at MaxPoissonRatio with their shear modulus kept,
+// which barely changes the Rayleigh wave speed; NearlyIncompressible reports the
+// capped layers, so that callers can warn about them. Poisson's ratios outside
+// (-1, 0.5] are rejected.
+//
+// LeakyDispersionScan searches the leaky modes, above the shear wave speed of the
+// halfspace, which radiate into it and have no real root: they are taken at the
+// minima of the modulus of the determinant where its terms nearly cancel
+// (LeakyTolerance), and reported separately from the guided modes.
+//
+// # Discretization
+//
+// Discretize splits the layers of a profile, but the halfspace, into sublayers no
+// thicker than a target thickness, e.g. a fraction of the ShortestWavelength of
+// interest. The dispersion relation is unchanged, while the hyperbolic terms of thick
+// homogeneous strata remain well conditioned at high frequencies. SplitAt places a
+// layer boundary at a given depth.
+//
+// # Ground Improvement
+//
+// ImproveWithColumns inserts ground improved by Columns (stone columns, deep soil
+// mixing) into a profile: the layers above the treated depth are replaced by
+// equivalent homogeneous layers, whose properties are the averages of those of the
+// columns and the soil weighted by the area replacement ratio. AddEmbankment builds
+// an Embankment of fill on a profile, as a layer whose stiffness is smeared over the
+// base width of its trapezoidal cross-section.
+//
+// # Stress Dependence
+//
+// StiffenUnderLoad raises the moduli of a profile for the stress increase under an
+// AxleLoad, spread at 2:1 with depth, relative to the EffectiveOverburden, with a
+// power law of the stiffness in the vertical stress.
+//
+// # Interpolation Between Boreholes
+//
+// InterpolateProfiles estimates the profile at a point between two boreholes, e.g.
+// to compute the critical speed between the investigation points along a route. The
+// layers of the boreholes are matched (as the same strata when they have as many
+// layers, or on the boundaries of both otherwise) and their properties are blended
+// with the fraction of the distance between the boreholes.
+//
+// # Spatial Variability
+//
+// RandomField generates realizations of a profile for stochastic (Monte Carlo)
+// analyses: the Young's modulus and the density of the layers are lognormal random
+// fields with an exponential correlation in depth over a vertical correlation length,
+// so that neighbouring layers vary together as in real deposits instead of being
+// sampled independently. Thick layers are discretized first for the field to vary
+// within them.
+//
+// # Site Period
+//
+// SitePeriod estimates the fundamental period of the layers above the halfspace by
+// the quarter-wavelength method, from the travel time of shear waves through them;
+// its inverse is the resonance frequency of the site, reported next to the
+// dispersion curve.
+//
 // # Usage Example
 //
 //	layers := []soil_dispersion.Layer{
@@ -0,0 +1,79 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+)
+
+// Embankment describes an embankment of fill with a trapezoidal cross-section, built
+// on the soil profile.
+type Embankment struct {
+	Height        float64 // Height of the embankment [m]
+	CrestWidth    float64 // Width of the crest [m]
+	SideSlope     float64 // Slope of the sides: horizontal over vertical distance, e.g. 1.5 for 2:3
+	YoungsModulus float64 // Young's modulus of the fill [Pa]
+	Density       float64 // Density of the fill [kg/m^3]
+	PoissonRatio  float64 // Poisson's ratio of the fill
+}
+
+// FillFraction returns the fraction of the rectangle of the height and base width of
+// an embankment taken by its cross-section: (b + B) / (2 B), with b the crest width
+// and B = b + 2 n h the base width. It is 1 for vertical sides, or without height.
+//
+// Returns:
+//   - float64: The fill fraction, in (0.5, 1]
+func (e Embankment) FillFraction() float64 {
+	base := e.CrestWidth + 2*e.SideSlope*e.Height
+	if !(base > 0) {
+		return 1
+	}
+	return (e.CrestWidth + base) / (2 * base)
+}
+
+// Layer returns the layer equivalent to an embankment in the layered soil model, of
+// the height of the embankment. The stiffness of the fill is smeared over the base
+// width of the embankment: its Young's modulus is weighted by the FillFraction, for
+// the sides that do not confine the fill laterally, while the density of the fill is
+// kept, for the mass under the track. A narrower crest or flatter sides thus give a
+// slower layer. This approximation of the cross-section ranks embankment variants; it
+// does not replace a model of the cross-section.
+//
+// Returns:
+//   - Layer: The embankment layer, with its wave speeds computed
+func (e Embankment) Layer() Layer {
+	layer := Layer{
+		Thickness:     e.Height,
+		Density:       e.Density,
+		YoungsModulus: e.FillFraction() * e.YoungsModulus,
+		PoissonRatio:  e.PoissonRatio,
+	}
+	layer.WaveSpeed()
+	return layer
+}
+
+// AddEmbankment builds an embankment on a soil profile: its equivalent layer (see
+// Embankment.Layer) is placed on top of the layers. An embankment of zero height
+// leaves the profile unchanged.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - embankment: The embankment
+//
+// Returns:
+//   - []Layer: The profile with the embankment (a copy)
+//   - error: An error if the embankment is invalid
+func AddEmbankment(layers []Layer, embankment Embankment) ([]Layer, error) {
+	if !(embankment.Height >= 0) || math.IsInf(embankment.Height, 1) {
+		return nil, fmt.Errorf("invalid embankment: the height must be finite and not negative, got %g", embankment.Height)
+	}
+	if embankment.Height == 0 {
+		return append([]Layer(nil), layers...), nil
+	}
+	if !(embankment.CrestWidth > 0) || !(embankment.SideSlope >= 0) {
+		return nil, fmt.Errorf("invalid embankment: the crest width must be positive and the side slope not negative")
+	}
+	if !(embankment.YoungsModulus > 0) || !(embankment.Density > 0) || !(embankment.PoissonRatio >= 0 && embankment.PoissonRatio < 0.5) {
+		return nil, fmt.Errorf("invalid embankment: the Young's modulus and density of the fill must be positive and its Poisson's ratio in [0, 0.5)")
+	}
+	return append([]Layer{embankment.Layer()}, layers...), nil
+}
@@ -0,0 +1,70 @@
+package soil_dispersion
+
+import "fmt"
+
+// MaxPoissonRatio is the largest Poisson's ratio of the layers in the computation of
+// the dispersion relation. Nearly incompressible layers (undrained clays, ν → 0.5)
+// have compressional wave speeds far above their shear wave speeds, infinite at
+// ν = 0.5: the compressional terms of the delta recursion then differ from the shear
+// terms by orders of magnitude, which ill-conditions the determinant and gives
+// jagged curves. The Poisson's ratio of these layers is capped at MaxPoissonRatio,
+// with their shear modulus kept, which changes the Rayleigh wave speed of a
+// homogeneous halfspace by about 0.01%.
+const MaxPoissonRatio = 0.499
+
+// NearlyIncompressible returns the layers of a soil profile whose Poisson's ratio is
+// capped in the computation of the dispersion relation (see MaxPoissonRatio).
+//
+// Parameters:
+//   - layers: The soil profile
+//
+// Returns:
+//   - []int: The indices of the nearly incompressible layers (nil if there are none)
+func NearlyIncompressible(layers []Layer) []int {
+	var indices []int
+	for i, layer := range layers {
+		if nearlyIncompressible(layer) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// nearlyIncompressible reports whether the Poisson's ratio of a layer is capped:
+// above MaxPoissonRatio, up to the incompressible 0.5 (larger ratios are rejected
+// by capPoissonRatio).
+func nearlyIncompressible(layer Layer) bool {
+	return layer.PoissonRatio > MaxPoissonRatio
+}
+
+// capPoissonRatio caps the Poisson's ratio of the nearly incompressible layers of a
+// soil profile at MaxPoissonRatio: their Young's modulus is reduced so that their
+// shear modulus, hence their shear wave speed, is kept, and their wave speeds are
+// computed.
+//
+// Parameters:
+//   - layers: The soil profile, with its wave speeds computed
+//
+// Returns:
+//   - []Layer: The capped profile (the profile itself when no layer is capped)
+//   - error: An error if the Poisson's ratio of a layer is not within (-1, 0.5]
+func capPoissonRatio(layers []Layer) ([]Layer, error) {
+	for i, layer := range layers {
+		if !(layer.PoissonRatio > -1 && layer.PoissonRatio <= 0.5) {
+			return nil, fmt.Errorf("invalid layer %d: the Poisson's ratio must be within (-1, 0.5], got %g", i, layer.PoissonRatio)
+		}
+	}
+	if NearlyIncompressible(layers) == nil {
+		return layers, nil
+	}
+	capped := make([]Layer, len(layers))
+	for i, layer := range layers {
+		if nearlyIncompressible(layer) {
+			layer.YoungsModulus *= (1 + MaxPoissonRatio) / (1 + layer.PoissonRatio)
+			layer.PoissonRatio = MaxPoissonRatio
+			layer.WaveSpeed()
+		}
+		capped[i] = layer
+	}
+	return capped, nil
+}
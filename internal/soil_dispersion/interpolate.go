@@ -0,0 +1,115 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// InterpolateProfiles interpolates the soil profile at a point between two boreholes
+// (or soundings), e.g. to estimate the critical speed between the investigation points
+// along a route. The layering of the profiles is matched first:
+//   - With the same number of layers, the layers are taken to be the same strata, as
+//     correlated between the boreholes: their thicknesses are interpolated, so that
+//     the boundaries move linearly from one borehole to the other.
+//   - Otherwise, both profiles are split at the layer boundaries of either of them,
+//     which gives them the same layers.
+//
+// The properties of the matched layers are then blended: the density and the
+// Poisson's ratio linearly, and the Young's modulus geometrically (linearly in its
+// logarithm), as the stiffness of soils varies over orders of magnitude. The wave
+// speeds of the interpolated profile are computed.
+//
+// Parameters:
+//   - a: The profile at the first borehole (fraction 0)
+//   - b: The profile at the second borehole (fraction 1)
+//   - fraction: Position between the boreholes, from 0 at a to 1 at b
+//
+// Returns:
+//   - []Layer: The interpolated profile
+//   - error: An error if a profile is empty or the fraction is not within [0, 1]
+func InterpolateProfiles(a []Layer, b []Layer, fraction float64) ([]Layer, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, fmt.Errorf("invalid profile interpolation: both profiles must have layers")
+	}
+	if !(fraction >= 0 && fraction <= 1) {
+		return nil, fmt.Errorf("invalid profile interpolation: the fraction must be within [0, 1], got %g", fraction)
+	}
+
+	thicknessFraction := fraction
+	if len(a) != len(b) {
+		// The common layering keeps the thicknesses of both profiles
+		boundaries := commonBoundaries(a, b)
+		a, b = splitAtBoundaries(a, boundaries), splitAtBoundaries(b, boundaries)
+		thicknessFraction = 0
+	}
+
+	blend := func(x, y float64) float64 { return (1-fraction)*x + fraction*y }
+	interpolated := make([]Layer, len(a))
+	for i := range a {
+		layer := Layer{
+			Density:       blend(a[i].Density, b[i].Density),
+			YoungsModulus: math.Exp(blend(math.Log(a[i].YoungsModulus), math.Log(b[i].YoungsModulus))),
+			PoissonRatio:  blend(a[i].PoissonRatio, b[i].PoissonRatio),
+			Thickness:     (1-thicknessFraction)*a[i].Thickness + thicknessFraction*b[i].Thickness,
+		}
+		if i == len(a)-1 {
+			layer.Thickness = a[i].Thickness
+		}
+		layer.WaveSpeed()
+		interpolated[i] = layer
+	}
+	return interpolated, nil
+}
+
+// commonBoundaries returns the depths of the layer boundaries of two soil profiles,
+// in increasing order, merging the boundaries that coincide to within rounding.
+//
+// Parameters:
+//   - a, b: The soil profiles
+//
+// Returns:
+//   - []float64: The depths of the boundaries [m]
+func commonBoundaries(a []Layer, b []Layer) []float64 {
+	var depths []float64
+	for _, layers := range [][]Layer{a, b} {
+		depth := 0.0
+		for _, layer := range layers[:len(layers)-1] {
+			depth += layer.Thickness
+			depths = append(depths, depth)
+		}
+	}
+	slices.Sort(depths)
+	return slices.CompactFunc(depths, func(x, y float64) bool {
+		return math.Abs(y-x) <= 1e-9*math.Max(1, math.Abs(y))
+	})
+}
+
+// splitAtBoundaries splits a soil profile at the given layer boundaries: the layers
+// between the boundaries take the properties of the layer at their mid-depth, and the
+// halfspace is kept below the deepest boundary.
+//
+// Parameters:
+//   - layers: The soil profile to split
+//   - boundaries: The depths of the boundaries, in increasing order [m]
+//
+// Returns:
+//   - []Layer: The split profile, with a layer between every two boundaries
+func splitAtBoundaries(layers []Layer, boundaries []float64) []Layer {
+	split := make([]Layer, 0, len(boundaries)+1)
+	top := 0.0
+	for _, depth := range boundaries {
+		middle, bottom, i := (top+depth)/2, 0.0, 0
+		for ; i < len(layers)-1; i++ {
+			bottom += layers[i].Thickness
+			if middle < bottom {
+				break
+			}
+		}
+		layer := layers[i]
+		layer.Thickness = depth - top
+		split = append(split, layer)
+		top = depth
+	}
+	return append(split, layers[len(layers)-1])
+}
@@ -0,0 +1,109 @@
+package soil_dispersion
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// LeakyVelocityRatio is the largest phase velocity of the leaky modes searched by
+// LeakyDispersionScan, relative to the shear wave speed of the halfspace. Faster
+// leaky modes radiate most of their energy into the halfspace within a wavelength
+// and do not govern the response of the ground.
+const LeakyVelocityRatio = 2
+
+// LeakyTolerance is the largest modulus of the determinant of the dispersion
+// relation at a leaky mode, relative to the largest of its terms (see
+// LeakyDispersionScan). The determinant nearly vanishes at the leaky modes that
+// radiate little energy, and its terms cancel; shallower minima are the ripples of the
+// determinant between the modes, or modes that radiate most of their energy.
+const LeakyTolerance = 0.25
+
+// LeakyDispersionScan calculates the phase velocity curve of the fundamental leaky
+// mode of a soil profile: the mode whose phase velocity exceeds the shear wave speed
+// of the halfspace, so that it radiates shear waves into the halfspace. Leaky modes
+// have complex wavenumbers, and the dispersion relation has no real root for them;
+// at real phase velocities they appear as minima of the modulus of its determinant.
+// For every frequency, the phase velocities from the shear wave speed of the
+// halfspace up to the smaller of its compressional wave speed and LeakyVelocityRatio
+// times its shear wave speed are scanned with the step of opts. The modulus is taken
+// relative to the largest term of the determinant, and the leaky mode is the first
+// local minimum below LeakyTolerance, interpolated by a parabola through the lowest
+// point and its neighbours. The guided modes, below the shear wave speed of the
+// halfspace, are computed by SoilDispersionScan.
+//
+// The leaky modes matter for soft layers over a much stiffer halfspace, where the
+// modes leave the guided regime below their cut-off frequencies. Their phase
+// velocities are approximate: the modulus is that of the normalized determinant (see
+// dispersionFastDelta), whose normalization varies slowly with the phase velocity.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation.
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: A slice of angular frequencies [rad/s] at which to compute phase velocities.
+//   - opts: Step of the scan (the location of the roots within their brackets does not apply).
+//
+// Returns:
+//   - A slice of phase speeds [m/s] of the leaky mode for each frequency in omega (NaN where none is found).
+//   - An error if the Poisson's ratio of a layer is not within (-1, 0.5], or the context is cancelled before all frequencies are processed.
+func LeakyDispersionScan(ctx context.Context, layers []Layer, omega []float64, opts ScanOptions) ([]float64, error) {
+	step := opts.Step
+	if step <= 0 {
+		step = DefaultStep
+	}
+	layers, err := capPoissonRatio(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	halfspace := layers[len(layers)-1]
+	c_min := halfspace.ShearWaveSpeed
+	c_max := math.Min(halfspace.CompressionalWaveSpeed, LeakyVelocityRatio*halfspace.ShearWaveSpeed)
+	c_list := math_utils.Linspace(c_min, c_max, max(int((c_max-c_min)/step), 3))
+	memo := memoizeVelocityTerms(layers, c_list)
+
+	phase_speed := math_utils.ParallelMap(func(omegaVal float64) float64 {
+		if ctx.Err() != nil {
+			return math.NaN()
+		}
+
+		var scratch []interfaceTerms
+		if memo == nil {
+			scratch = make([]interfaceTerms, len(layers)-1)
+		}
+		modulus := func(j int) float64 {
+			terms := memo
+			if terms == nil {
+				terms = []velocityTerms{newVelocityTerms(layers, c_list[j], scratch)}
+				j = 0
+			}
+			var sum complex128
+			largest := 0.0
+			for _, term := range terms[j].determinantTerms(layers, omegaVal, c_list[j]) {
+				sum += term
+				largest = math.Max(largest, cmplx.Abs(term))
+			}
+			return cmplx.Abs(sum) / largest
+		}
+
+		// The first interior local minimum of the modulus where the terms cancel
+		m_0, m_1 := modulus(0), modulus(1)
+		for j := 1; j < len(c_list)-1; j++ {
+			m_2 := modulus(j + 1)
+			if m_1 < m_0 && m_1 < m_2 && m_1 < LeakyTolerance {
+				// Vertex of the parabola through the three points of the equally spaced scan
+				offset := 0.5 * (m_0 - m_2) / (m_0 - 2*m_1 + m_2)
+				return c_list[j] + offset*(c_list[j+1]-c_list[j])
+			}
+			m_0, m_1 = m_1, m_2
+		}
+		return math.NaN()
+	}, omega, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return phase_speed, nil
+}
@@ -0,0 +1,294 @@
+package soil_dispersion
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// CancellationLimit is the relative size of the determinant of the dispersion
+// relation to its largest term below which its float64 evaluation is deemed to have
+// lost its significant digits to cancellation. For deep, many-layer profiles at high
+// frequency, the hyperbolic terms of the stiff layers grow exponentially with depth
+// and the terms of the determinant cancel each other (or overflow): such evaluations
+// are repeated in extended precision (see extendedFastDelta), which is slower but
+// gives the correct sign, hence the correct root.
+const CancellationLimit = 1e-12
+
+// cancelled reports whether the float64 evaluation of the determinant of the
+// dispersion relation lost its significant digits: its terms overflowed, or
+// cancelled down to less than CancellationLimit of the largest of them.
+//
+// Parameters:
+//   - terms: The terms of the determinant, which is the real part of their sum
+//
+// Returns:
+//   - bool: True if the determinant must be evaluated in extended precision
+func cancelled(terms ...complex128) bool {
+	var sum complex128
+	largest := 0.0
+	for _, term := range terms {
+		sum += term
+		largest = math.Max(largest, math.Abs(real(term))+math.Abs(imag(term)))
+	}
+	if cmplx.IsNaN(sum) || math.IsInf(largest, 0) || math.IsNaN(largest) {
+		return true
+	}
+	return math.Abs(real(sum)) < CancellationLimit*largest
+}
+
+// extendedFastDelta evaluates the normalized dispersion relation of
+// dispersionFastDelta in double-double arithmetic (about 32 significant digits, see
+// doubleDouble), with an unbounded exponent: X1 is normalized by a power of two after
+// every layer, accumulated in a separate binary exponent, so that it does not
+// overflow. The normalized hyperbolic terms are derived from the float64 exponential
+// or sine and cosine of their argument, such that tanh² + 1/cosh² = 1 and
+// cos² + sin² = 1 hold to the extended precision: the cancellation of the
+// determinant is that of these identities.
+//
+// Parameters:
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: Angular frequency [rad/s] at which to compute the dispersion relation.
+//   - c: Phase velocity [m/s].
+//
+// Returns:
+//   - The real part of the normalized determinant, rounded to float64 (±Inf beyond its range)
+//   - False if the determinant is undefined (a division by zero at a wave speed)
+func extendedFastDelta(layers []Layer, omega float64, c float64) (float64, bool) {
+	one := ddFloat(1)
+	phaseVelocity := ddFloat(c)
+	wavenumber := ddFloat(omega).div(phaseVelocity)
+
+	// sqrt(1 - (c/waveSpeed)²), real or imaginary as for waveTerm
+	wave := func(waveSpeed float64) ddComplex {
+		ratio := phaseVelocity.div(ddFloat(waveSpeed))
+		v := one.sub(ratio.mul(ratio))
+		if v.hi >= 0 {
+			return ddComplex{re: v.sqrt()}
+		}
+		return ddComplex{im: v.neg().sqrt()}
+	}
+	// cosh(k r h) and sinh(k r h) divided by cosh(k r h), and the factor 1/cosh(k r h),
+	// as for normalizedHyperbolic; i.e. cos(k |r| h) and i sin(k |r| h) for an imaginary r
+	hyperbolic := func(r ddComplex, thickness float64) (C ddComplex, S ddComplex, scale doubleDouble) {
+		argument := wavenumber.mul(ddFloat(thickness))
+		if r.im.hi == 0 {
+			e := ddFloat(math.Exp(-argument.mul(r.re).hi))
+			e2 := e.mul(e)
+			denominator := one.add(e2)
+			return ddComplex{re: one}, ddComplex{re: one.sub(e2).div(denominator)}, e.ldexp(1).div(denominator)
+		}
+		sin, cos := math.Sincos(argument.mul(r.im).hi)
+		norm := ddFloat(sin).mul(ddFloat(sin)).add(ddFloat(cos).mul(ddFloat(cos))).sqrt()
+		return ddComplex{re: ddFloat(cos).div(norm)}, ddComplex{im: ddFloat(sin).div(norm)}, one
+	}
+
+	// Same terms as newVelocityTerms and dispersionFastDelta
+	beta0 := ddFloat(layers[0].ShearWaveSpeed)
+	ratio0 := phaseVelocity.div(beta0)
+	tValue := ddFloat(2).sub(ratio0.mul(ratio0))
+	mu0 := ddFloat(layers[0].Density).mul(beta0).mul(beta0)
+	mu0Squared := mu0.mul(mu0)
+	X1 := [5]ddComplex{
+		{re: mu0Squared.mul(tValue).ldexp(1)},
+		{re: mu0Squared.mul(tValue).mul(tValue).neg()},
+		{},
+		{},
+		{re: mu0Squared.ldexp(2).neg()},
+	}
+	// The determinant is that of X1 2^exponent
+	exponent := 0
+
+	for i := 0; i < len(layers)-1; i++ {
+		current_layer := layers[i]
+		next_layer := layers[i+1]
+
+		ratio := ddFloat(current_layer.ShearWaveSpeed).div(phaseVelocity)
+		ratioNext := ddFloat(next_layer.ShearWaveSpeed).div(phaseVelocity)
+		gamma := ratio.mul(ratio)
+		gammaNext := ratioNext.mul(ratioNext)
+		r := wave(current_layer.CompressionalWaveSpeed)
+		s := wave(current_layer.ShearWaveSpeed)
+		epsilon := ddFloat(next_layer.Density).div(ddFloat(current_layer.Density))
+		eta := gamma.sub(epsilon.mul(gammaNext)).ldexp(1)
+		a := ddComplex{re: epsilon.add(eta)}
+		b := ddComplex{re: one.sub(eta)}
+		aPrime := ddComplex{re: a.re.sub(one)}
+		bPrime := ddComplex{re: b.re.sub(one)}
+		rInv := r.inv().neg()
+		sInv := s.inv()
+
+		C_alpha, S_alpha, scaleAlpha := hyperbolic(r, current_layer.Thickness)
+		C_beta, S_beta, scaleBeta := hyperbolic(s, current_layer.Thickness)
+		scale := ddComplex{re: scaleAlpha.mul(scaleBeta)}
+
+		x2, x3, x4, x5 := X1[1], X1[2], X1[3], X1[4]
+		p1 := C_beta.mul(x2).add(s.mul(S_beta).mul(x3))
+		p2 := C_beta.mul(x4).add(s.mul(S_beta).mul(x5))
+		p3 := sInv.mul(S_beta).mul(x2).add(C_beta.mul(x3))
+		p4 := sInv.mul(S_beta).mul(x4).add(C_beta.mul(x5))
+
+		q1 := C_alpha.mul(p1).sub(r.mul(S_alpha).mul(p2))
+		q2 := rInv.mul(S_alpha).mul(p3).add(C_alpha.mul(p4))
+		q3 := C_alpha.mul(p3).sub(r.mul(S_alpha).mul(p4))
+		q4 := rInv.mul(S_alpha).mul(p1).add(C_alpha.mul(p2))
+
+		// The q terms are normalized with the hyperbolic terms, so must x1 be
+		x1 := X1[0].mul(scale)
+		y1 := aPrime.mul(x1).add(a.mul(q1))
+		y2 := a.mul(x1).add(aPrime.mul(q2))
+		z1 := b.mul(x1).add(bPrime.mul(q1))
+		z2 := bPrime.mul(x1).add(b.mul(q2))
+
+		epsilonComplex := ddComplex{re: epsilon}
+		X1 = [5]ddComplex{
+			bPrime.mul(y1).add(b.mul(y2)),
+			a.mul(y1).add(aPrime.mul(y2)),
+			epsilonComplex.mul(q3),
+			epsilonComplex.mul(q4),
+			bPrime.mul(z1).add(b.mul(z2)),
+		}
+
+		// Normalize X1 by the power of two of its largest term
+		largest := 0.0
+		for _, x := range X1 {
+			largest = math.Max(largest, math.Max(math.Abs(x.re.hi), math.Abs(x.im.hi)))
+		}
+		if largest == 0 || math.IsNaN(largest) || math.IsInf(largest, 0) {
+			return 0, false
+		}
+		_, normalization := math.Frexp(largest)
+		for j := range X1 {
+			X1[j] = X1[j].ldexp(-normalization)
+		}
+		exponent += normalization
+	}
+
+	halfspace := layers[len(layers)-1]
+	r_h := wave(halfspace.CompressionalWaveSpeed)
+	s_h := wave(halfspace.ShearWaveSpeed)
+	D := X1[1].add(s_h.mul(X1[2])).sub(r_h.mul(X1[3].add(s_h.mul(X1[4]))))
+
+	delta := math.Ldexp(D.re.hi+D.re.lo, exponent)
+	if math.IsNaN(delta) {
+		return 0, false
+	}
+	return delta, true
+}
+
+// doubleDouble is a float in double-double arithmetic: the unevaluated sum of two
+// float64, hi and lo with |lo| ≤ ulp(hi)/2, giving about 106 bits of mantissa (Dekker,
+// 1971; Hida, Li and Bailey, 2001).
+type doubleDouble struct {
+	hi, lo float64
+}
+
+// ddFloat returns a float64 as a doubleDouble.
+func ddFloat(v float64) doubleDouble {
+	return doubleDouble{hi: v}
+}
+
+// twoSum returns a + b as the rounded sum and its rounding error.
+func twoSum(a, b float64) (float64, float64) {
+	s := a + b
+	bb := s - a
+	return s, (a - (s - bb)) + (b - bb)
+}
+
+// quickTwoSum returns a + b as the rounded sum and its rounding error, for |a| ≥ |b|.
+func quickTwoSum(a, b float64) (float64, float64) {
+	s := a + b
+	return s, b - (s - a)
+}
+
+// add returns x + y.
+func (x doubleDouble) add(y doubleDouble) doubleDouble {
+	s, e := twoSum(x.hi, y.hi)
+	t, f := twoSum(x.lo, y.lo)
+	s, e = quickTwoSum(s, e+t)
+	s, e = quickTwoSum(s, e+f)
+	return doubleDouble{s, e}
+}
+
+// sub returns x - y.
+func (x doubleDouble) sub(y doubleDouble) doubleDouble {
+	return x.add(y.neg())
+}
+
+// neg returns -x.
+func (x doubleDouble) neg() doubleDouble {
+	return doubleDouble{-x.hi, -x.lo}
+}
+
+// mul returns x y.
+func (x doubleDouble) mul(y doubleDouble) doubleDouble {
+	p := x.hi * y.hi
+	e := math.FMA(x.hi, y.hi, -p)
+	s, e := quickTwoSum(p, e+(x.hi*y.lo+x.lo*y.hi))
+	return doubleDouble{s, e}
+}
+
+// div returns x / y.
+func (x doubleDouble) div(y doubleDouble) doubleDouble {
+	q1 := x.hi / y.hi
+	r := x.sub(y.mul(ddFloat(q1)))
+	q2 := r.hi / y.hi
+	r = r.sub(y.mul(ddFloat(q2)))
+	q3 := r.hi / y.hi
+	s, e := quickTwoSum(q1, q2)
+	return doubleDouble{s, e}.add(ddFloat(q3))
+}
+
+// sqrt returns the square root of x ≥ 0.
+func (x doubleDouble) sqrt() doubleDouble {
+	if x.hi <= 0 {
+		return doubleDouble{}
+	}
+	y := math.Sqrt(x.hi)
+	r := x.sub(ddFloat(y).mul(ddFloat(y)))
+	s, e := quickTwoSum(y, r.hi/(2*y))
+	return doubleDouble{s, e}
+}
+
+// ldexp returns x 2^exp.
+func (x doubleDouble) ldexp(exp int) doubleDouble {
+	return doubleDouble{math.Ldexp(x.hi, exp), math.Ldexp(x.lo, exp)}
+}
+
+// ddComplex is a complex number with doubleDouble parts.
+type ddComplex struct {
+	re, im doubleDouble
+}
+
+// add returns z + w.
+func (z ddComplex) add(w ddComplex) ddComplex {
+	return ddComplex{z.re.add(w.re), z.im.add(w.im)}
+}
+
+// sub returns z - w.
+func (z ddComplex) sub(w ddComplex) ddComplex {
+	return ddComplex{z.re.sub(w.re), z.im.sub(w.im)}
+}
+
+// mul returns z w.
+func (z ddComplex) mul(w ddComplex) ddComplex {
+	return ddComplex{
+		z.re.mul(w.re).sub(z.im.mul(w.im)),
+		z.re.mul(w.im).add(z.im.mul(w.re)),
+	}
+}
+
+// neg returns -z.
+func (z ddComplex) neg() ddComplex {
+	return ddComplex{z.re.neg(), z.im.neg()}
+}
+
+// inv returns 1/z.
+func (z ddComplex) inv() ddComplex {
+	norm := z.re.mul(z.re).add(z.im.mul(z.im))
+	return ddComplex{z.re.div(norm), z.im.div(norm).neg()}
+}
+
+// ldexp returns z 2^exp.
+func (z ddComplex) ldexp(exp int) ddComplex {
+	return ddComplex{z.re.ldexp(exp), z.im.ldexp(exp)}
+}
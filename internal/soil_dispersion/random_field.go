@@ -0,0 +1,144 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// RandomField describes the spatial variability of the properties of a soil profile
+// for stochastic (Monte Carlo) analyses. The Young's modulus and the density of the
+// layers are lognormal random fields around the values of the profile, with an
+// exponential (Markov) correlation in depth:
+//
+//	ρ(Δz) = exp(-2 |Δz| / θ)
+//
+// where θ is the vertical scale of fluctuation (Vanmarcke, 1977). Neighbouring layers
+// are thus strongly correlated when they are thinner than θ, and nearly independent
+// when they are far apart, which gives physically plausible realizations instead of
+// the erratic profiles of independent sampling per layer. The layers are sampled at
+// their mid-depth (the halfspace at its top): thick layers should be discretized first
+// (see Discretize) for the field to vary within them.
+type RandomField struct {
+	ModulusCoV        float64 // Coefficient of variation of the Young's modulus
+	DensityCoV        float64 // Coefficient of variation of the density
+	CorrelationLength float64 // Vertical scale of fluctuation θ [m]
+}
+
+// Realizations generates realizations of a soil profile from the random field: the
+// Young's modulus and the density of every layer are drawn with the correlation of
+// the field, the thickness and the Poisson's ratio are kept, and the wave speeds are
+// computed. The realizations are reproducible for a given seed.
+//
+// Parameters:
+//   - layers: The soil profile, whose properties are the medians of the field
+//   - count: Number of realizations
+//   - seed: Seed of the random number generator
+//
+// Returns:
+//   - [][]Layer: The realizations of the profile
+//   - error: An error if the field is invalid
+func (f RandomField) Realizations(layers []Layer, count int, seed uint64) ([][]Layer, error) {
+	if !(f.ModulusCoV >= 0) || !(f.DensityCoV >= 0) {
+		return nil, fmt.Errorf("invalid random field: the coefficients of variation must be positive or zero")
+	}
+	if !(f.CorrelationLength > 0) || math.IsInf(f.CorrelationLength, 1) {
+		return nil, fmt.Errorf("invalid random field: the correlation length must be positive and finite, got %g", f.CorrelationLength)
+	}
+
+	// Correlation of the layers from the distance between their sampling depths
+	depths := make([]float64, len(layers))
+	top := 0.0
+	for i, layer := range layers {
+		depths[i] = top
+		if i < len(layers)-1 {
+			depths[i] += layer.Thickness / 2
+			top += layer.Thickness
+		}
+	}
+	correlation := make([][]float64, len(layers))
+	for i := range correlation {
+		correlation[i] = make([]float64, len(layers))
+		for j := range correlation[i] {
+			correlation[i][j] = math.Exp(-2 * math.Abs(depths[i]-depths[j]) / f.CorrelationLength)
+		}
+	}
+	lower, err := cholesky(correlation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Standard deviation of the logarithm of the lognormal properties
+	modulusSigma := math.Sqrt(math.Log(1 + f.ModulusCoV*f.ModulusCoV))
+	densitySigma := math.Sqrt(math.Log(1 + f.DensityCoV*f.DensityCoV))
+
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	realizations := make([][]Layer, count)
+	for r := range realizations {
+		modulus := correlatedNormal(lower, rng)
+		density := correlatedNormal(lower, rng)
+		realization := make([]Layer, len(layers))
+		for i, layer := range layers {
+			layer.YoungsModulus *= math.Exp(modulusSigma * modulus[i])
+			layer.Density *= math.Exp(densitySigma * density[i])
+			layer.WaveSpeed()
+			realization[i] = layer
+		}
+		realizations[r] = realization
+	}
+	return realizations, nil
+}
+
+// correlatedNormal draws standard normal variables with the correlation whose
+// Cholesky factor is given.
+//
+// Parameters:
+//   - lower: Lower triangular Cholesky factor of the correlation matrix
+//   - rng: The random number generator
+//
+// Returns:
+//   - []float64: The correlated standard normal variables
+func correlatedNormal(lower [][]float64, rng *rand.Rand) []float64 {
+	independent := make([]float64, len(lower))
+	for i := range independent {
+		independent[i] = rng.NormFloat64()
+	}
+	correlated := make([]float64, len(lower))
+	for i := range correlated {
+		for j := 0; j <= i; j++ {
+			correlated[i] += lower[i][j] * independent[j]
+		}
+	}
+	return correlated
+}
+
+// cholesky computes the lower triangular Cholesky factor L of a symmetric positive
+// definite matrix A = L Lᵀ.
+//
+// Parameters:
+//   - a: The matrix
+//
+// Returns:
+//   - [][]float64: The factor L
+//   - error: An error if the matrix is not positive definite (e.g. two layers at the same depth)
+func cholesky(a [][]float64) ([][]float64, error) {
+	lower := make([][]float64, len(a))
+	for i := range a {
+		lower[i] = make([]float64, len(a))
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= lower[i][k] * lower[j][k]
+			}
+			if i == j {
+				if !(sum > 0) {
+					return nil, fmt.Errorf("invalid random field: the correlation matrix of the layers is not positive definite")
+				}
+				lower[i][i] = math.Sqrt(sum)
+			} else {
+				lower[i][j] = sum / lower[j][j]
+			}
+		}
+	}
+	return lower, nil
+}
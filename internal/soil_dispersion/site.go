@@ -0,0 +1,25 @@
+package soil_dispersion
+
+// SitePeriod computes the fundamental period of the layers of a soil profile above
+// its halfspace, taken as the bedrock, by the quarter-wavelength method: the
+// deposit resonates when its depth H is a quarter of the shear wavelength, with the
+// travel-time average shear wave speed of the layers,
+//
+//	T₀ = 4 H / V̄s = 4 Σ hᵢ / Vsᵢ
+//
+// which is exact for a single layer on a rigid base and a close estimate of the
+// first peak of the transfer function of a layered deposit (Dobry et al., 1976).
+// The resonance frequency of the site is f₀ = 1 / T₀.
+//
+// Parameters:
+//   - layers: The soil profile, with its wave speeds computed
+//
+// Returns:
+//   - float64: The site period [s] (zero for a homogeneous halfspace, which has no resonance)
+func SitePeriod(layers []Layer) float64 {
+	period := 0.0
+	for i := 0; i < len(layers)-1; i++ {
+		period += 4 * layers[i].Thickness / layers[i].ShearWaveSpeed
+	}
+	return period
+}
@@ -1,6 +1,7 @@
 package soil_dispersion
 
 import (
+	"context"
 	"math"
 	"math/cmplx"
 
@@ -48,6 +49,86 @@ func (l *Layer) WaveSpeed() {
 // (density, Young's modulus, Poisson's ratio, thickness) and that the WaveSpeed method has been
 // called to compute the wave speeds for each layer.
 func SoilDispersion(layers []Layer, omega []float64) []float64 {
+	phase_speed, _ := SoilDispersionContext(context.Background(), layers, omega)
+	return phase_speed
+}
+
+// DefaultStep is the step between the phase velocities scanned for the roots of the
+// dispersion relation [m/s].
+const DefaultStep = 0.01
+
+// FastStep is the step between the scanned phase velocities of the fast approximate
+// mode [m/s] (see ScanOptions).
+const FastStep = 0.5
+
+// ScanOptions controls the scan of the phase velocities for the roots of the
+// dispersion relation, see SoilDispersionScan.
+type ScanOptions struct {
+	Step        float64 // Step between the scanned phase velocities [m/s] (DefaultStep when <= 0)
+	Interpolate bool    // If true, the root is interpolated linearly within its bracket instead of taken at its middle
+	Refine      string  // Root finder refining the root within its bracket (see math_utils.RootFinderByName), instead of interpolating it; none when empty
+}
+
+// RefineTolerance is the absolute tolerance of the roots refined within their
+// brackets (see ScanOptions.Refine) [m/s].
+const RefineTolerance = 1e-6
+
+// FastScan are the scan options of the fast approximate mode: a coarse scan, with
+// the roots interpolated within their brackets. The critical velocities of the test
+// profiles differ by less than 0.1% from the default scan.
+var FastScan = ScanOptions{Step: FastStep, Interpolate: true}
+
+// SoilDispersionContext calculates the phase velocity dispersion curve for a soil profile
+// in the same way as SoilDispersion, but stops early when the context is cancelled.
+// The frequencies are processed concurrently (see math_utils.ParallelMap) and the
+// context is checked before each frequency is processed.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation.
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: A slice of angular frequencies [rad/s] at which to compute phase velocities.
+//
+// Returns:
+//   - A slice of phase speeds [m/s] for each frequency in omega (NaN where no solution is found).
+//   - An error if the context is cancelled before all frequencies are processed.
+func SoilDispersionContext(ctx context.Context, layers []Layer, omega []float64) ([]float64, error) {
+	return SoilDispersionScan(ctx, layers, omega, ScanOptions{})
+}
+
+// SoilDispersionScan calculates the phase velocity dispersion curve for a soil profile
+// in the same way as SoilDispersionContext, with control over the scan of the phase
+// velocities. A coarser step is faster, at the risk of missing the fundamental mode
+// where two roots are closer than the step. The roots may be refined within their
+// brackets with a root finder (e.g. math_utils.Chandrupatla), which evaluates the
+// dispersion relation between the scanned phase velocities: a coarse scan then gives
+// the roots to RefineTolerance. The Poisson's ratio of nearly incompressible layers
+// is capped at MaxPoissonRatio (see NearlyIncompressible).
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation.
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: A slice of angular frequencies [rad/s] at which to compute phase velocities.
+//   - opts: Step of the scan and location of the roots within their brackets.
+//
+// Returns:
+//   - A slice of phase speeds [m/s] for each frequency in omega (NaN where no solution is found).
+//   - An error if the Poisson's ratio of a layer is not within (-1, 0.5], the context is cancelled before all frequencies are processed, or the refining root finder is not supported.
+func SoilDispersionScan(ctx context.Context, layers []Layer, omega []float64, opts ScanOptions) ([]float64, error) {
+	step := opts.Step
+	if step <= 0 {
+		step = DefaultStep
+	}
+	var refine math_utils.RootFinder
+	if opts.Refine != "" {
+		var err error
+		if refine, err = math_utils.RootFinderByName(opts.Refine); err != nil {
+			return nil, err
+		}
+	}
+	layers, err := capPoissonRatio(layers)
+	if err != nil {
+		return nil, err
+	}
 
 	// find the minimum & maximum compressional wave speed in layers
 	min_shear_wave_speed := math.Inf(1)
@@ -63,84 +144,235 @@ func SoilDispersion(layers []Layer, omega []float64) []float64 {
 
 	c_min := 0.5 * min_shear_wave_speed
 	c_max := max_shear_wave_speed
-	c_list := math_utils.Linspace(c_min, c_max, int((c_max-c_min)/0.01))
+	c_list := math_utils.Linspace(c_min, c_max, max(int((c_max-c_min)/step), 2))
 
-	phase_speed := make([]float64, len(omega))
+	// The same phase velocities are scanned for every frequency: their
+	// frequency-independent terms are computed once when they fit in memory
+	memo := memoizeVelocityTerms(layers, c_list)
 
-	for i := range omega {
-		// Initialize with nan
-		phase_speed[i] = math.NaN()
+	// The frequencies are independent: they are evaluated concurrently
+	phase_speed := math_utils.ParallelMap(func(omegaVal float64) float64 {
+		if ctx.Err() != nil {
+			return math.NaN()
+		}
 
-		d_1 := dispersionFastDelta(layers, omega[i], c_list[0])
+		// Without memoization, the terms are computed in a scratch buffer reused
+		// for all phase velocities of the frequency
+		var scratch []interfaceTerms
+		if memo == nil {
+			scratch = make([]interfaceTerms, len(layers)-1)
+		}
+		delta := func(j int) float64 {
+			if memo != nil {
+				return memo[j].dispersionFastDelta(layers, omegaVal, c_list[j])
+			}
+			terms := newVelocityTerms(layers, c_list[j], scratch)
+			return terms.dispersionFastDelta(layers, omegaVal, c_list[j])
+		}
+
+		d_1 := delta(0)
 		for j := range len(c_list) - 1 {
-			d_2 := dispersionFastDelta(layers, omega[i], c_list[j+1])
+			d_2 := delta(j + 1)
 			if d_1*d_2 < 0 {
-				// When solution is found, create a value and set it
-				value := (c_list[j] + c_list[j+1]) / 2
-				phase_speed[i] = value
-				break
+				// The dispersion relation is smooth within the bracket: the root
+				// finder evaluates it at any phase velocity
+				if refine != nil {
+					buffer := make([]interfaceTerms, len(layers)-1)
+					root, err := refine(func(c float64) float64 {
+						terms := newVelocityTerms(layers, c, buffer)
+						return terms.dispersionFastDelta(layers, omegaVal, c)
+					}, c_list[j], c_list[j+1], RefineTolerance)
+					if err == nil {
+						return root
+					}
+				}
+				if opts.Interpolate {
+					return c_list[j] - d_1*(c_list[j+1]-c_list[j])/(d_2-d_1)
+				}
+				// When solution is found, return the middle of the bracket
+				return (c_list[j] + c_list[j+1]) / 2
 			}
 			d_1 = d_2
 		}
+		return math.NaN()
+	}, omega, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return phase_speed
+	return phase_speed, nil
 }
 
-// dispersionFastDelta computes the dispersion relation for a given frequency
-// and compressional wave speed using a fast method. It calculates the determinant
-// of a matrix representing the track-soil system and returns the real part of the result.
-// This function is optimized for performance and uses complex arithmetic to handle
-// the wave propagation characteristics in the soil layers.
+// memoizeVelocityTerms computes the frequency-independent terms of the dispersion
+// relation for every phase velocity, unless they exceed MaxMemoizedTerms.
 //
 // Parameters:
 //   - layers: A slice of Layer structs representing the soil profile.
-//   - omega: Angular frequency [rad/s] at which to compute the dispersion relation.
-//   - c: Compressional wave speed [m/s] to evaluate the dispersion relation.
+//   - c_list: The phase velocities [m/s].
 //
 // Returns:
-//   - The real part of the determinant, representing the dispersion relation for the given frequency and compressional wave speed.
-func dispersionFastDelta(layers []Layer, omega float64, c float64) float64 {
+//   - The terms of every phase velocity, or nil when they are not memoized.
+func memoizeVelocityTerms(layers []Layer, c_list []float64) []velocityTerms {
+	n := len(layers) - 1
+	if len(c_list)*max(n, 1) > MaxMemoizedTerms {
+		return nil
+	}
+	// The terms of all layers are stored in a single allocation
+	interfaces := make([]interfaceTerms, len(c_list)*n)
+	memo := make([]velocityTerms, len(c_list))
+	for j, c := range c_list {
+		memo[j] = newVelocityTerms(layers, c, interfaces[j*n:(j+1)*n:(j+1)*n])
+	}
+	return memo
+}
 
-	// Calculate the wavenumber for each compressional wave speed
-	wavenumber := omega / c
+// MaxMemoizedTerms is the maximum number of layer interfaces times phase velocities
+// for which SoilDispersionContext memoizes the frequency-independent terms of the
+// dispersion relation (about 100 bytes each). The same phase velocities are scanned
+// for every frequency, so these terms are computed once and reused across all
+// frequencies; larger profiles compute them for every evaluation instead.
+const MaxMemoizedTerms = 1 << 19
+
+// interfaceTerms are the terms of the dispersion relation of a layer and its
+// interface with the next layer that depend on the phase velocity only.
+type interfaceTerms struct {
+	r, s       complex128 // P-wave and S-wave terms of the layer
+	rInv, sInv complex128 // -1/r and 1/s
+	a, a_prime float64    // Interface coefficients a and a - 1
+	b, b_prime float64    // Interface coefficients b and b - 1
+	epsilon    float64    // Density ratio of the next layer to the layer
+}
+
+// velocityTerms are the terms of the dispersion relation that depend on the phase
+// velocity only, not on the frequency.
+type velocityTerms struct {
+	X1         [5]complex128    // Initial value of X1 at the first layer
+	r_h, s_h   complex128       // P-wave and S-wave terms of the halfspace
+	interfaces []interfaceTerms // Terms of every layer but the halfspace
+}
+
+// newVelocityTerms computes the frequency-independent terms of the dispersion
+// relation for a phase velocity.
+//
+// Parameters:
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - c: Phase velocity [m/s].
+//   - interfaces: Storage for the terms of the layers, of length len(layers)-1.
+//
+// Returns:
+//   - The terms of the dispersion relation at the phase velocity.
+func newVelocityTerms(layers []Layer, c float64, interfaces []interfaceTerms) velocityTerms {
 
 	// re-compute values for the first layer
 	beta0 := layers[0].ShearWaveSpeed
 	t_value := 2 - math.Pow(c/beta0, 2)
 	mu0 := layers[0].Density * math.Pow(beta0, 2)
 
-	// Initialize X1 with complex values
-	X1 := []complex128{
-		complex(mu0*mu0*2*t_value, 0),
-		complex(mu0*mu0*-math.Pow(t_value, 2), 0),
-		complex(0, 0),
-		complex(0, 0),
-		complex(mu0*mu0*-4, 0),
+	halfspace := layers[len(layers)-1]
+	terms := velocityTerms{
+		X1: [5]complex128{
+			complex(mu0*mu0*2*t_value, 0),
+			complex(mu0*mu0*-math.Pow(t_value, 2), 0),
+			complex(0, 0),
+			complex(0, 0),
+			complex(mu0*mu0*-4, 0),
+		},
+		r_h:        waveTerm(c, halfspace.CompressionalWaveSpeed),
+		s_h:        waveTerm(c, halfspace.ShearWaveSpeed),
+		interfaces: interfaces,
 	}
 
-	// Compute the terms for the halfspace (last layer)
-	_, _, _, _, r_h, s_h := computeTerms(c, wavenumber, layers[len(layers)-1].Thickness, layers[len(layers)-1].CompressionalWaveSpeed, layers[len(layers)-1].ShearWaveSpeed)
-
-	// Process each layer except the last one
 	for i := 0; i < len(layers)-1; i++ {
 		current_layer := layers[i]
 		next_layer := layers[i+1]
 
-		// Calculate layer properties directly when needed
 		gamma := math.Pow(current_layer.ShearWaveSpeed/c, 2)
 		gamma_next := math.Pow(next_layer.ShearWaveSpeed/c, 2)
-		C_alpha, S_alpha, C_beta, S_beta, r, s := computeTerms(c, wavenumber, layers[i].Thickness, layers[i].CompressionalWaveSpeed, layers[i].ShearWaveSpeed)
+		r := waveTerm(c, current_layer.CompressionalWaveSpeed)
+		s := waveTerm(c, current_layer.ShearWaveSpeed)
 
 		epsilon := next_layer.Density / current_layer.Density
 		eta := 2 * (gamma - epsilon*gamma_next)
-
 		a := epsilon + eta
-		a_prime := a - 1
 		b := 1 - eta
-		b_prime := b - 1
 
-		// Extract X1 components
-		x1 := X1[0]
+		interfaces[i] = interfaceTerms{
+			r:       r,
+			s:       s,
+			rInv:    -1 / r,
+			sInv:    1 / s,
+			a:       a,
+			a_prime: a - 1,
+			b:       b,
+			b_prime: b - 1,
+			epsilon: epsilon,
+		}
+	}
+	return terms
+}
+
+// dispersionFastDelta computes the dispersion relation for a given frequency
+// and phase velocity using a fast method, from the frequency-independent terms of
+// the phase velocity. It calculates the determinant of a matrix representing the
+// soil system and returns the real part of the result.
+// This function is optimized for performance: X1 is a fixed-size array updated in
+// place, so the evaluation does not allocate. The hyperbolic terms are normalized per
+// layer (see computeHyperbolicTerms), so the determinant does not overflow for thick
+// stiff layers at high frequencies; it is that of the original relation divided by a
+// positive factor. Evaluations that lose their significant digits to cancellation are
+// repeated in extended precision (see CancellationLimit).
+//
+// Parameters:
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: Angular frequency [rad/s] at which to compute the dispersion relation.
+//   - c: Phase velocity [m/s] of the terms.
+//
+// Returns:
+//   - The real part of the normalized determinant, representing the dispersion relation for the given frequency and phase velocity.
+func (t *velocityTerms) dispersionFastDelta(layers []Layer, omega float64, c float64) float64 {
+	terms := t.determinantTerms(layers, omega, c)
+
+	// Repeat the evaluation in extended precision when it lost its digits
+	if cancelled(terms[:]...) {
+		if delta, ok := extendedFastDelta(layers, omega, c); ok {
+			return delta
+		}
+	}
+
+	// Return the real part as the result
+	return real(terms[0] + terms[1] + terms[2] + terms[3])
+}
+
+// determinantTerms computes the terms of the complex normalized determinant of the
+// dispersion relation for a given frequency and phase velocity, which is their sum
+// (see dispersionFastDelta).
+//
+// Parameters:
+//   - layers: A slice of Layer structs representing the soil profile.
+//   - omega: Angular frequency [rad/s] at which to compute the dispersion relation.
+//   - c: Phase velocity [m/s] of the terms.
+//
+// Returns:
+//   - The terms of the normalized determinant.
+func (t *velocityTerms) determinantTerms(layers []Layer, omega float64, c float64) [4]complex128 {
+
+	// Calculate the wavenumber for each compressional wave speed
+	wavenumber := omega / c
+
+	X1 := t.X1
+
+	// Process each layer except the last one
+	for i := 0; i < len(layers)-1; i++ {
+		terms := &t.interfaces[i]
+		r, s := terms.r, terms.s
+		C_alpha, S_alpha, C_beta, S_beta, scale := computeHyperbolicTerms(wavenumber, layers[i].Thickness, r, s)
+
+		a, a_prime := terms.a, terms.a_prime
+		b, b_prime := terms.b, terms.b_prime
+
+		// Extract X1 components; the q terms below are normalized with the hyperbolic
+		// terms, so must x1 be
+		x1 := X1[0] * complex(scale, 0)
 		x2 := X1[1]
 		x3 := X1[2]
 		x4 := X1[3]
@@ -149,13 +381,13 @@ func dispersionFastDelta(layers []Layer, omega float64, c float64) float64 {
 		// Calculate intermediate values using complex math
 		p1 := C_beta*x2 + s*S_beta*x3
 		p2 := C_beta*x4 + s*S_beta*x5
-		p3 := 1/s*S_beta*x2 + C_beta*x3
-		p4 := 1/s*S_beta*x4 + C_beta*x5
+		p3 := terms.sInv*S_beta*x2 + C_beta*x3
+		p4 := terms.sInv*S_beta*x4 + C_beta*x5
 
 		q1 := C_alpha*p1 - r*S_alpha*p2
-		q2 := -1/r*S_alpha*p3 + C_alpha*p4
+		q2 := terms.rInv*S_alpha*p3 + C_alpha*p4
 		q3 := C_alpha*p3 - r*S_alpha*p4
-		q4 := -1/r*S_alpha*p1 + C_alpha*p2
+		q4 := terms.rInv*S_alpha*p1 + C_alpha*p2
 
 		y1 := complex(a_prime, 0)*x1 + complex(a, 0)*q1
 		y2 := complex(a, 0)*x1 + complex(a_prime, 0)*q2
@@ -163,54 +395,76 @@ func dispersionFastDelta(layers []Layer, omega float64, c float64) float64 {
 		z2 := complex(b_prime, 0)*x1 + complex(b, 0)*q2
 
 		// Update X1 for next iteration
-		X1 = []complex128{
+		X1 = [5]complex128{
 			complex(b_prime, 0)*y1 + complex(b, 0)*y2,
 			complex(a, 0)*y1 + complex(a_prime, 0)*y2,
-			complex(epsilon, 0) * q3,
-			complex(epsilon, 0) * q4,
+			complex(terms.epsilon, 0) * q3,
+			complex(terms.epsilon, 0) * q4,
 			complex(b_prime, 0)*z1 + complex(b, 0)*z2,
 		}
 	}
 
 	// Calculate determinant using complex values
-	D := X1[1] + s_h*X1[2] - r_h*(X1[3]+s_h*X1[4])
+	t2, t3, t4 := t.s_h*X1[2], -t.r_h*X1[3], -t.r_h*t.s_h*X1[4]
+	return [4]complex128{X1[1], t2, t3, t4}
+}
 
-	// Return the real part as the result
-	return real(D)
+// waveTerm calculates the P-wave (r) or S-wave (s) term of the dispersion relation
+// of a layer, which depends on the phase velocity only.
+//
+// Parameters:
+//   - c: Phase velocity [m/s]
+//   - waveSpeed: Compressional (for r) or shear (for s) wave speed of the layer [m/s]
+//
+// Returns:
+//   - The term sqrt(1 - (c/waveSpeed)^2)
+func waveTerm(c float64, waveSpeed float64) complex128 {
+	return cmplx.Sqrt(complex((1 - math.Pow(c/waveSpeed, 2)), 0))
 }
 
-// computeTerms calculates the terms needed for the dispersion relation
-// based on the compressional wave speed, wavenumber, layer thickness,
-// and the compressional and shear wave speeds of the layer.
-// It returns the terms C_alpha, S_alpha, C_beta, S_beta, r, and s.
+// computeHyperbolicTerms calculates the frequency-dependent terms of the dispersion
+// relation of a layer from its P-wave and S-wave terms, normalized per layer. For a
+// real wave term, cosh and sinh grow exponentially with the thickness of the layer and
+// overflow for thick stiff layers at high frequencies: they are divided by cosh, which
+// leaves 1 and tanh, and the factor 1/cosh is returned to scale the terms of the
+// recursion that do not contain them. For an imaginary wave term, the terms are the
+// bounded cos and i sin and are left as they are. Since the scale factors are
+// positive, the normalized determinant has the roots and the signs of the original.
 //
 // Parameters:
-//   - c: Compressional wave speed [m/s]
 //   - wavenumber: Wavenumber [1/m]
 //   - thickness: Thickness of the layer [m]
-//   - compressionalWaveSpeed: Compressional wave speed of the layer [m/s]
-//   - shearWaveSpeed: Shear wave speed of the layer [m/s]
+//   - r: P-wave term of the layer (see waveTerm)
+//   - s: S-wave term of the layer (see waveTerm)
 //
 // Returns:
 //   - C_alpha: Complex term for P-wave
 //   - S_alpha: Complex term for P-wave
 //   - C_beta: Complex term for S-wave
 //   - S_beta: Complex term for S-wave
-//   - r: Real term for P-wave
-//   - s: Real term for S-wave
-func computeTerms(c float64, wavenumber float64, thickness float64, compressionalWaveSpeed float64, shearWaveSpeed float64) (complex128, complex128, complex128, complex128, complex128, complex128) {
-
-	r := cmplx.Sqrt(complex((1 - math.Pow(c/compressionalWaveSpeed, 2)), 0))
-	s := cmplx.Sqrt(complex((1 - math.Pow(c/shearWaveSpeed, 2)), 0))
-
-	complex_wavenb := complex(wavenumber, 0)
-	complex_thickness := complex(thickness, 0)
-
-	C_alpha := cmplx.Cosh(complex_wavenb * r * complex_thickness)
-	S_alpha := cmplx.Sinh(complex_wavenb * r * complex_thickness)
-
-	C_beta := cmplx.Cosh(complex_wavenb * s * complex_thickness)
-	S_beta := cmplx.Sinh(complex_wavenb * s * complex_thickness)
+//   - scale: Product of the normalization factors of the P-wave and S-wave terms
+func computeHyperbolicTerms(wavenumber float64, thickness float64, r complex128, s complex128) (complex128, complex128, complex128, complex128, float64) {
+	C_alpha, S_alpha, scaleAlpha := normalizedHyperbolic(wavenumber*thickness, r)
+	C_beta, S_beta, scaleBeta := normalizedHyperbolic(wavenumber*thickness, s)
+	return C_alpha, S_alpha, C_beta, S_beta, scaleAlpha * scaleBeta
+}
 
-	return C_alpha, S_alpha, C_beta, S_beta, r, s
+// normalizedHyperbolic calculates cosh(k w h) and sinh(k w h) of a wave term w,
+// divided by cosh(k w h) for a real w (see computeHyperbolicTerms).
+//
+// Parameters:
+//   - argument: Product of the wavenumber and the thickness of the layer
+//   - w: Wave term of the layer, real or imaginary (see waveTerm)
+//
+// Returns:
+//   - The normalized cosh term
+//   - The normalized sinh term
+//   - The normalization factor 1/cosh(k w h), 1 for an imaginary w
+func normalizedHyperbolic(argument float64, w complex128) (complex128, complex128, float64) {
+	if imag(w) == 0 {
+		x := argument * real(w)
+		return 1, complex(math.Tanh(x), 0), 1 / math.Cosh(x)
+	}
+	sin, cos := math.Sincos(argument * imag(w))
+	return complex(cos, 0), complex(0, sin), 1
 }
@@ -1,10 +1,14 @@
 package soil_dispersion
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/PlatypusBytes/GoTrain/pkg/utils"
 	"math"
+	"math/cmplx"
 	"os"
+	"slices"
+	"sync"
 	"testing"
 )
 
@@ -195,3 +199,664 @@ func ComputeElasticProperties(density, shear_wave_speed, compressional_wave_spee
 	youngs_modulus := 2 * shear_modulus * (1 + poisson_ratio)
 	return youngs_modulus, poisson_ratio
 }
+
+// Test that the cache computes a curve once per profile and returns independent copies.
+func TestCache(t *testing.T) {
+	layers := []Layer{
+		{Density: 2000, YoungsModulus: 30e6, PoissonRatio: 0.3, Thickness: 2},
+		{Density: 2000, YoungsModulus: 80e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := []float64{10, 50, 100}
+	expected := SoilDispersion(layers, omega)
+
+	cache := NewCache()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			curve, _, err := cache.SoilDispersionContext(context.Background(), layers, omega)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for i := range curve {
+				if curve[i] != expected[i] && !(math.IsNaN(curve[i]) && math.IsNaN(expected[i])) {
+					t.Errorf("cached curve differs at %d: %v != %v", i, curve[i], expected[i])
+				}
+			}
+			curve[0] = -1
+		}()
+	}
+	wg.Wait()
+
+	if hits, misses := cache.Stats(); hits != 3 || misses != 1 {
+		t.Errorf("expected 3 hits and 1 miss, got %d and %d", hits, misses)
+	}
+
+	// A different profile is computed separately
+	layers[0].Thickness = 3
+	if _, cached, _ := cache.SoilDispersionContext(context.Background(), layers, omega); cached {
+		t.Errorf("expected a cache miss for a different profile")
+	}
+
+	// The curves of another scan are cached separately
+	if _, cached, _ := cache.SoilDispersionScan(context.Background(), layers, omega, FastScan); cached {
+		t.Errorf("expected a cache miss for a different scan")
+	}
+
+	// A cancelled computation is not cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	layers[0].Thickness = 4
+	if _, _, err := cache.SoilDispersionContext(ctx, layers, omega); err == nil {
+		t.Errorf("expected error for a cancelled context")
+	}
+	if _, misses := cache.Stats(); misses != 3 {
+		t.Errorf("expected the cancelled computation not to be cached, got %d misses", misses)
+	}
+}
+
+// Test that the memoized terms of the phase velocities give the same dispersion
+// curve as computing them for every frequency.
+func TestMemoizedTerms(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.3, Thickness: 1.5},
+		{Density: 2000, YoungsModulus: 60e6, PoissonRatio: 0.3, Thickness: 4},
+		{Density: 2100, YoungsModulus: 150e6, PoissonRatio: 0.25, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	c_list := math_utils.Linspace(60, 280, 45)
+	memo := memoizeVelocityTerms(layers, c_list)
+	if len(memo) != len(c_list) {
+		t.Fatalf("expected the terms of %d phase velocities, got %d", len(c_list), len(memo))
+	}
+
+	scratch := make([]interfaceTerms, len(layers)-1)
+	for _, omega := range math_utils.Linspace(5, 300, 12) {
+		for j, c := range c_list {
+			terms := newVelocityTerms(layers, c, scratch)
+			memoized, expected := memo[j].dispersionFastDelta(layers, omega, c), terms.dispersionFastDelta(layers, omega, c)
+			if memoized != expected {
+				t.Errorf("memoized determinant differs at omega %f and c %f: %v != %v", omega, c, memoized, expected)
+			}
+		}
+	}
+
+	// Beyond MaxMemoizedTerms, the terms are computed for every evaluation
+	if memo := memoizeVelocityTerms(layers, make([]float64, MaxMemoizedTerms)); memo != nil {
+		t.Error("expected no memoization beyond MaxMemoizedTerms")
+	}
+}
+
+// Test that the roots refined within the brackets of a coarse scan match the roots
+// of the default fine scan, and that an unsupported root finder is reported.
+func TestRefinedScan(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.3, Thickness: 1.5},
+		{Density: 2000, YoungsModulus: 60e6, PoissonRatio: 0.3, Thickness: 4},
+		{Density: 2100, YoungsModulus: 150e6, PoissonRatio: 0.25, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := math_utils.Linspace(5, 300, 12)
+	expected := SoilDispersion(layers, omega)
+
+	for _, name := range []string{math_utils.SolverChandrupatla, math_utils.SolverBrent} {
+		refined, err := SoilDispersionScan(context.Background(), layers, omega, ScanOptions{Step: FastStep, Refine: name})
+		if err != nil {
+			t.Fatalf("SoilDispersionScan with %s failed: %v", name, err)
+		}
+		for i := range expected {
+			// The default scan takes the middle of brackets of 0.01 m/s
+			if math.Abs(refined[i]-expected[i]) > DefaultStep/2+RefineTolerance {
+				t.Errorf("%s: expected %f at omega %f, got %f", name, expected[i], omega[i], refined[i])
+			}
+		}
+	}
+
+	if _, err := SoilDispersionScan(context.Background(), layers, omega, ScanOptions{Refine: "newton"}); err == nil {
+		t.Error("expected an error for an unsupported root finder")
+	}
+}
+
+// deepProfile returns a profile of 20 layers of 5 m, whose shear wave velocity grows
+// from 100 to 480 m/s with depth, on a halfspace of 500 m/s: at high frequency, the
+// hyperbolic terms of the deep layers grow beyond the range of float64.
+func deepProfile() []Layer {
+	var layers []Layer
+	for i := range 20 {
+		vs := 100 + 20*float64(i)
+		layers = append(layers, Layer{Density: 1800, YoungsModulus: 2 * 1.35 * 1800 * vs * vs, PoissonRatio: 0.35, Thickness: 5})
+	}
+	layers = append(layers, Layer{Density: 2000, YoungsModulus: 2 * 1.3 * 2000 * 500 * 500, PoissonRatio: 0.3, Thickness: math.Inf(1)})
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	return layers
+}
+
+// Test that the determinant normalized per layer does not overflow for a deep profile
+// at high frequency: the phase velocity is the Rayleigh wave velocity of the top layer
+// at all frequencies, without the extended-precision fallback.
+func TestNormalizedDeterminant(t *testing.T) {
+	layers := deepProfile()
+	c_list := math_utils.Linspace(0.5*layers[0].ShearWaveSpeed, 500, int((500-0.5*layers[0].ShearWaveSpeed)/FastStep))
+	nu := 0.35
+	expected := layers[0].ShearWaveSpeed * (0.862 + 1.14*nu) / (1 + nu)
+
+	// The float64 determinant, without the fallback of dispersionFastDelta
+	scratch := make([]interfaceTerms, len(layers)-1)
+	delta := func(omega, c float64) float64 {
+		terms := newVelocityTerms(layers, c, scratch)
+		sum := terms.determinantTerms(layers, omega, c)
+		return real(sum[0] + sum[1] + sum[2] + sum[3])
+	}
+	for _, omega := range []float64{300, 2000, 20000} {
+		root := math.NaN()
+		for j := range len(c_list) - 1 {
+			if delta(omega, c_list[j])*delta(omega, c_list[j+1]) < 0 {
+				root = (c_list[j] + c_list[j+1]) / 2
+				break
+			}
+		}
+		if !(math.Abs(root-expected) <= 0.01*expected) {
+			t.Errorf("expected the Rayleigh wave velocity %f at omega %f, got %f", expected, omega, root)
+		}
+	}
+}
+
+// Test that the extended-precision evaluation of the determinant matches its float64
+// evaluation where the latter is well conditioned, and that the scan with the fallback
+// finds the Rayleigh wave velocity of the top layer of a deep profile.
+func TestExtendedPrecisionFallback(t *testing.T) {
+	layers := deepProfile()
+	for _, c := range []float64{60, 150, 410} {
+		terms := newVelocityTerms(layers, c, make([]interfaceTerms, len(layers)-1))
+		for _, omega := range []float64{10, 300, 2000} {
+			extended, ok := extendedFastDelta(layers, omega, c)
+			if !ok {
+				t.Fatalf("extended evaluation failed at omega %f and c %f", omega, c)
+			}
+			if delta := terms.dispersionFastDelta(layers, omega, c); math.Abs(extended-delta) > 1e-9*math.Abs(delta) {
+				t.Errorf("expected the determinant %g at omega %f and c %f, got %g", delta, omega, c, extended)
+			}
+		}
+	}
+
+	omega := []float64{300, 600, 2000}
+	phaseVelocity, err := SoilDispersionScan(context.Background(), layers, omega, FastScan)
+	if err != nil {
+		t.Fatalf("SoilDispersionScan failed: %v", err)
+	}
+	nu := 0.35
+	expected := layers[0].ShearWaveSpeed * (0.862 + 1.14*nu) / (1 + nu)
+	for i, v := range phaseVelocity {
+		if math.Abs(v-expected) > 0.01*expected {
+			t.Errorf("expected the phase velocity %f at omega %f, got %f", expected, omega[i], v)
+		}
+	}
+}
+
+// BenchmarkSoilDispersion measures the computation of the soil dispersion curve of
+// the three-layer profile of Mezher et al. (2016) at 100 frequencies.
+func BenchmarkSoilDispersion(b *testing.B) {
+	layers := []Layer{
+		{Density: 2000, YoungsModulus: 30e6, PoissonRatio: 0.35, Thickness: 2},
+		{Density: 2000, YoungsModulus: 40e6, PoissonRatio: 0.35, Thickness: 10},
+		{Density: 2000, YoungsModulus: 75e6, PoissonRatio: 0.4, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	omega := math_utils.Linspace(1, 50*2*math.Pi, 100)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		SoilDispersion(layers, omega)
+	}
+}
+
+// Test that discretizing a soil profile splits the thick layers but the halfspace,
+// without changing its dispersion curve.
+func TestDiscretize(t *testing.T) {
+
+	E0, nu0 := ComputeElasticProperties(1900, 100, 200)
+	E1, nu1 := ComputeElasticProperties(1900, 200, 400)
+	E2, nu2 := ComputeElasticProperties(1900, 400, 800)
+	layers := []Layer{
+		{Density: 1900, YoungsModulus: E0, PoissonRatio: nu0, Thickness: 2},
+		{Density: 1900, YoungsModulus: E1, PoissonRatio: nu1, Thickness: 10},
+		{Density: 1900, YoungsModulus: E2, PoissonRatio: nu2, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+
+	discretized, err := Discretize(layers, 3)
+	if err != nil {
+		t.Fatalf("Discretize failed: %v", err)
+	}
+	if len(discretized) != 6 || discretized[0] != layers[0] || discretized[5] != layers[2] {
+		t.Fatalf("unexpected discretized profile: %+v", discretized)
+	}
+	for _, layer := range discretized[1:5] {
+		if layer.Thickness != 2.5 || layer.ShearWaveSpeed != layers[1].ShearWaveSpeed {
+			t.Errorf("expected sublayers of 2.5 m with the properties of the second layer, got %+v", layer)
+		}
+	}
+
+	omega := math_utils.Linspace(1, 50*2*math.Pi, 50)
+	expected := SoilDispersion(layers, omega)
+	for i, c := range SoilDispersion(discretized, omega) {
+		if math.Abs(c-expected[i]) > 1e-6*expected[i] {
+			t.Errorf("omega %.2f: expected phase velocity %g, got %g", omega[i], expected[i], c)
+		}
+	}
+
+	if wavelength := ShortestWavelength(layers, 2*math.Pi*50); math.Abs(wavelength-2) > 1e-12 {
+		t.Errorf("expected the shortest wavelength 2 m at 50 Hz, got %g", wavelength)
+	}
+	for _, maxThickness := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if _, err := Discretize(layers, maxThickness); err == nil {
+			t.Errorf("expected an error for maximum thickness %g", maxThickness)
+		}
+	}
+}
+
+// Test that columns replace the layers above the treated depth by the composite of
+// the columns and the soil, splitting the layer at that depth.
+func TestImproveWithColumns(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.4, Thickness: 2},
+		{Density: 1900, YoungsModulus: 40e6, PoissonRatio: 0.35, Thickness: 4},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	if split := SplitAt(layers, 2); len(split) != 3 || split[1] != layers[1] {
+		t.Errorf("expected no split at a layer boundary, got %+v", split)
+	}
+	if split := SplitAt(layers, 8); len(split) != 4 || split[2].Thickness != 2 || !math.IsInf(split[3].Thickness, 1) {
+		t.Errorf("expected the halfspace split at 8 m, got %+v", split)
+	}
+
+	columns := Columns{YoungsModulus: 120e6, Density: 2100, AreaRatio: 0.25, Depth: 3}
+	improved, err := ImproveWithColumns(layers, columns)
+	if err != nil {
+		t.Fatalf("ImproveWithColumns failed: %v", err)
+	}
+	if len(improved) != 4 || improved[1].Thickness != 1 || improved[2].Thickness != 3 || improved[2].ShearWaveSpeed != layers[1].ShearWaveSpeed || improved[3] != layers[2] {
+		t.Fatalf("unexpected improved profile: %+v", improved)
+	}
+	first := improved[0]
+	if math.Abs(first.YoungsModulus-45e6) > 1e-6 || math.Abs(first.Density-1875) > 1e-9 || math.Abs(first.PoissonRatio-0.4) > 1e-12 {
+		t.Errorf("unexpected composite of the first layer: %+v", first)
+	}
+	if expected := math.Sqrt(45e6 / (2 * 1.4) / 1875); math.Abs(first.ShearWaveSpeed-expected) > 1e-9 {
+		t.Errorf("expected shear wave speed %g, got %g", expected, first.ShearWaveSpeed)
+	}
+	if math.Abs(improved[1].YoungsModulus-60e6) > 1e-6 || improved[1].ShearWaveSpeed <= layers[1].ShearWaveSpeed {
+		t.Errorf("unexpected composite of the second layer: %+v", improved[1])
+	}
+
+	for _, invalid := range []Columns{
+		{YoungsModulus: 0, AreaRatio: 0.25, Depth: 3},
+		{YoungsModulus: 120e6, AreaRatio: 1.5, Depth: 3},
+		{YoungsModulus: 120e6, AreaRatio: 0.25, Depth: 0},
+	} {
+		if _, err := ImproveWithColumns(layers, invalid); err == nil {
+			t.Errorf("expected an error for columns %+v", invalid)
+		}
+	}
+}
+
+// Test that an embankment is built on top of a profile as a layer of fill whose
+// stiffness is smeared over the base width of its cross-section.
+func TestAddEmbankment(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.4, Thickness: 2},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+
+	// A crest of 8 m and a height of 2 m with slopes of 1.5 give a base of 14 m
+	embankment := Embankment{Height: 2, CrestWidth: 8, SideSlope: 1.5, YoungsModulus: 80e6, Density: 2000, PoissonRatio: 0.3}
+	if fraction := embankment.FillFraction(); math.Abs(fraction-22.0/28) > 1e-12 {
+		t.Errorf("expected fill fraction %g, got %g", 22.0/28, fraction)
+	}
+	profile, err := AddEmbankment(layers, embankment)
+	if err != nil {
+		t.Fatalf("AddEmbankment failed: %v", err)
+	}
+	fill := profile[0]
+	if len(profile) != 3 || profile[1] != layers[0] || fill.Thickness != 2 || math.Abs(fill.YoungsModulus-80e6*22/28) > 1e-6 {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if expected := math.Sqrt(80e6 * 22 / 28 / (2 * 1.3) / 2000); fill.Density != 2000 || math.Abs(fill.ShearWaveSpeed-expected) > 1e-9 {
+		t.Errorf("expected the density of the fill and shear wave speed %g, got %+v", expected, fill)
+	}
+
+	embankment.Height = 0
+	if profile, err := AddEmbankment(layers, embankment); err != nil || len(profile) != 2 {
+		t.Errorf("expected the profile unchanged without embankment, got %+v (%v)", profile, err)
+	}
+	for _, invalid := range []Embankment{
+		{Height: -1, CrestWidth: 8, YoungsModulus: 80e6, Density: 2000},
+		{Height: 2, CrestWidth: 0, YoungsModulus: 80e6, Density: 2000},
+		{Height: 2, CrestWidth: 8, YoungsModulus: 0, Density: 2000},
+	} {
+		if _, err := AddEmbankment(layers, invalid); err == nil {
+			t.Errorf("expected an error for embankment %+v", invalid)
+		}
+	}
+}
+
+// Test that the moduli of a profile increase with the stress of an axle load spread
+// at 2:1 over the effective overburden, at the middle of the layers and at the top of
+// the halfspace.
+func TestStiffenUnderLoad(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.4, Thickness: 2},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	load := AxleLoad{Load: 200e3, Width: 3, Length: 3, Exponent: 0.5, GroundwaterDepth: 1}
+	if overburden := EffectiveOverburden(layers, 2, 1); math.Abs(overburden-(1800*9.81*2-1000*9.81)) > 1e-9 {
+		t.Errorf("expected effective overburden %g, got %g", 1800*9.81*2-1000*9.81, overburden)
+	}
+	stiffened, err := StiffenUnderLoad(layers, load)
+	if err != nil {
+		t.Fatalf("StiffenUnderLoad failed: %v", err)
+	}
+	expected := []float64{
+		20e6 * math.Sqrt(1+200e3/16/(1800*9.81)),
+		100e6 * math.Sqrt(1+200e3/25/(1800*9.81*2-1000*9.81)),
+	}
+	for i, layer := range stiffened {
+		if math.Abs(layer.YoungsModulus-expected[i]) > 1e-6 || !(layer.ShearWaveSpeed > layers[i].ShearWaveSpeed) {
+			t.Errorf("layer %d: expected Young's modulus %g, got %+v", i, expected[i], layer)
+		}
+	}
+	if layers[0].YoungsModulus != 20e6 {
+		t.Errorf("expected the profile unchanged, got %+v", layers[0])
+	}
+
+	load.Load = 0
+	if unloaded, err := StiffenUnderLoad(layers, load); err != nil || unloaded[0] != layers[0] || unloaded[1] != layers[1] {
+		t.Errorf("expected the profile unchanged without load, got %+v (%v)", unloaded, err)
+	}
+	for _, invalid := range []AxleLoad{
+		{Load: -1, Width: 3, Length: 3, Exponent: 0.5},
+		{Load: 200e3, Width: 0, Length: 3, Exponent: 0.5},
+		{Load: 200e3, Width: 3, Length: 3, Exponent: 1.5},
+	} {
+		if _, err := StiffenUnderLoad(layers, invalid); err == nil {
+			t.Errorf("expected an error for axle load %+v", invalid)
+		}
+	}
+}
+
+// Test the interpolation of profiles between boreholes, with the same strata (whose
+// boundaries move) and with different layerings (split at the boundaries of both).
+func TestInterpolateProfiles(t *testing.T) {
+	a := []Layer{
+		{Density: 1600, YoungsModulus: 10e6, PoissonRatio: 0.45, Thickness: 2},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	b := []Layer{
+		{Density: 1800, YoungsModulus: 40e6, PoissonRatio: 0.35, Thickness: 4},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+
+	middle, err := InterpolateProfiles(a, b, 0.5)
+	if err != nil {
+		t.Fatalf("InterpolateProfiles failed: %v", err)
+	}
+	top := middle[0]
+	if len(middle) != 2 || top.Thickness != 3 || top.Density != 1700 || math.Abs(top.YoungsModulus-20e6) > 1 || math.Abs(top.PoissonRatio-0.4) > 1e-12 {
+		t.Errorf("expected a top layer of 3 m, 1700 kg/m³, 20 MPa and 0.4, got %+v", top)
+	}
+	if !(top.ShearWaveSpeed > 0) || !math.IsInf(middle[1].Thickness, 1) {
+		t.Errorf("expected the wave speeds and the halfspace, got %+v", middle)
+	}
+	for fraction, expected := range map[float64][]Layer{0: a, 1: b} {
+		profile, _ := InterpolateProfiles(a, b, fraction)
+		if profile[0].Thickness != expected[0].Thickness || math.Abs(profile[0].YoungsModulus-expected[0].YoungsModulus) > 1e-6 {
+			t.Errorf("fraction %g: expected the profile of the borehole, got %+v", fraction, profile[0])
+		}
+	}
+
+	// A borehole with an extra stratum: both are split at 1, 2 and 4 m
+	c := []Layer{
+		{Density: 1500, YoungsModulus: 5e6, PoissonRatio: 0.45, Thickness: 1},
+		{Density: 1800, YoungsModulus: 40e6, PoissonRatio: 0.35, Thickness: 3},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	split, err := InterpolateProfiles(a, c, 0.25)
+	if err != nil {
+		t.Fatalf("InterpolateProfiles failed: %v", err)
+	}
+	thicknesses := []float64{}
+	for _, layer := range split {
+		thicknesses = append(thicknesses, layer.Thickness)
+	}
+	if !slices.Equal(thicknesses, []float64{1, 1, 2, math.Inf(1)}) {
+		t.Fatalf("expected layers of 1, 1, 2 m and the halfspace, got %v", thicknesses)
+	}
+	// Between 2 and 4 m, the halfspace of a is blended with the second layer of c
+	if expected := 0.75*2000 + 0.25*1800; math.Abs(split[2].Density-expected) > 1e-9 {
+		t.Errorf("expected the density %f between 2 and 4 m, got %f", expected, split[2].Density)
+	}
+
+	if _, err := InterpolateProfiles(a, b, 1.5); err == nil {
+		t.Error("expected an error for a fraction beyond 1")
+	}
+	if _, err := InterpolateProfiles(nil, b, 0.5); err == nil {
+		t.Error("expected an error for an empty profile")
+	}
+}
+
+// Test that the realizations of a random field are reproducible, have the median and
+// the coefficient of variation of the field, and that the correlation of neighbouring
+// layers follows the correlation length.
+func TestRandomField(t *testing.T) {
+	layers, err := Discretize([]Layer{
+		{Density: 1800, YoungsModulus: 30e6, PoissonRatio: 0.3, Thickness: 10},
+		{Density: 2000, YoungsModulus: 100e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}, 0.5)
+	if err != nil {
+		t.Fatalf("Discretize failed: %v", err)
+	}
+
+	field := RandomField{ModulusCoV: 0.3, DensityCoV: 0.05, CorrelationLength: 5}
+	realizations, err := field.Realizations(layers, 2000, 42)
+	if err != nil {
+		t.Fatalf("Realizations failed: %v", err)
+	}
+	again, _ := field.Realizations(layers, 2000, 42)
+	if realizations[7][3] != again[7][3] {
+		t.Errorf("expected the same realizations for the same seed")
+	}
+	if layer := realizations[0][0]; !(layer.ShearWaveSpeed > 0) || layer.Thickness != 0.5 {
+		t.Errorf("expected the wave speeds to be computed and the thickness kept, got %+v", layer)
+	}
+
+	// Correlation of the logarithm of the modulus of two layers
+	correlation := func(realizations [][]Layer, i, j int) float64 {
+		var sx, sy, sxx, syy, sxy float64
+		for _, r := range realizations {
+			x, y := math.Log(r[i].YoungsModulus), math.Log(r[j].YoungsModulus)
+			sx, sy, sxx, syy, sxy = sx+x, sy+y, sxx+x*x, syy+y*y, sxy+x*y
+		}
+		n := float64(len(realizations))
+		return (sxy/n - sx*sy/n/n) / math.Sqrt((sxx/n-sx*sx/n/n)*(syy/n-sy*sy/n/n))
+	}
+	if rho := correlation(realizations, 4, 5); math.Abs(rho-math.Exp(-2*0.5/5)) > 0.05 {
+		t.Errorf("expected the correlation %f of neighbouring layers, got %f", math.Exp(-2*0.5/5), rho)
+	}
+
+	var logSum, logSquares float64
+	for _, r := range realizations {
+		v := math.Log(r[4].YoungsModulus / 30e6)
+		logSum, logSquares = logSum+v, logSquares+v*v
+	}
+	mean := logSum / float64(len(realizations))
+	sigma := math.Sqrt(logSquares/float64(len(realizations)) - mean*mean)
+	if math.Abs(mean) > 0.03 || math.Abs(sigma-math.Sqrt(math.Log(1+0.09))) > 0.02 {
+		t.Errorf("expected the median 30e6 and the CoV 0.3, got log-mean %f and log-sigma %f", mean, sigma)
+	}
+
+	// A short correlation length gives nearly independent layers
+	field.CorrelationLength = 0.1
+	independent, err := field.Realizations(layers, 2000, 42)
+	if err != nil {
+		t.Fatalf("Realizations failed: %v", err)
+	}
+	if rho := correlation(independent, 4, 5); math.Abs(rho) > 0.1 {
+		t.Errorf("expected nearly independent layers, got the correlation %f", rho)
+	}
+
+	field.CorrelationLength = 0
+	if _, err := field.Realizations(layers, 1, 42); err == nil {
+		t.Error("expected an error for a zero correlation length")
+	}
+}
+
+func TestSitePeriod(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 30e6, PoissonRatio: 0.3, Thickness: 4},
+		{Density: 1900, YoungsModulus: 80e6, PoissonRatio: 0.3, Thickness: 6},
+		{Density: 2000, YoungsModulus: 500e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	expected := 4 * (4/layers[0].ShearWaveSpeed + 6/layers[1].ShearWaveSpeed)
+	if period := SitePeriod(layers); math.Abs(period-expected) > 1e-12 {
+		t.Errorf("expected the site period %f s, got %f s", expected, period)
+	}
+
+	// Discretizing the layers keeps the travel time through them
+	discretized, err := Discretize(layers, 0.5)
+	if err != nil {
+		t.Fatalf("Discretize failed: %v", err)
+	}
+	if period := SitePeriod(discretized); math.Abs(period-expected) > 1e-9 {
+		t.Errorf("expected the site period %f s of the discretized layers, got %f s", expected, period)
+	}
+
+	if period := SitePeriod(layers[2:]); period != 0 {
+		t.Errorf("expected no site period for a homogeneous halfspace, got %f s", period)
+	}
+}
+
+func TestNearlyIncompressible(t *testing.T) {
+	profile := func(nu float64) []Layer {
+		layers := []Layer{
+			{Density: 1700, YoungsModulus: 2 * (1 + nu) * 5e6, PoissonRatio: nu, Thickness: 3},
+			{Density: 1900, YoungsModulus: 60e6, PoissonRatio: 0.3, Thickness: 5},
+			{Density: 2000, YoungsModulus: 200e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+		}
+		for i := range layers {
+			layers[i].WaveSpeed()
+		}
+		return layers
+	}
+
+	layers := profile(0.5)
+	if indices := NearlyIncompressible(layers); !slices.Equal(indices, []int{0}) {
+		t.Errorf("expected the first layer to be nearly incompressible, got %v", indices)
+	}
+	capped, err := capPoissonRatio(layers)
+	if err != nil {
+		t.Fatalf("capPoissonRatio failed: %v", err)
+	}
+	if capped[0].PoissonRatio != MaxPoissonRatio || math.IsInf(capped[0].CompressionalWaveSpeed, 0) ||
+		math.Abs(capped[0].ShearWaveSpeed-layers[0].ShearWaveSpeed) > 1e-9 {
+		t.Errorf("expected the Poisson's ratio capped with the shear wave speed kept, got %+v", capped[0])
+	}
+	if layers[0].PoissonRatio != 0.5 || capped[1] != layers[1] {
+		t.Error("expected the other layers and the profile itself to be unchanged")
+	}
+
+	// The curve of an incompressible layer is that of the capped layer
+	omega := math_utils.Linspace(10, 300, 30)
+	curve, err := SoilDispersionScan(context.Background(), layers, omega, FastScan)
+	if err != nil {
+		t.Fatalf("SoilDispersionScan failed: %v", err)
+	}
+	reference, _ := SoilDispersionScan(context.Background(), profile(MaxPoissonRatio), omega, FastScan)
+	for i := range omega {
+		if math.IsNaN(curve[i]) || math.Abs(curve[i]-reference[i]) > 1e-9*reference[i] {
+			t.Errorf("omega %f: expected the phase velocity %f of the capped layer, got %f", omega[i], reference[i], curve[i])
+		}
+	}
+
+	// Poisson's ratios beyond the incompressible 0.5 are rejected
+	for _, nu := range []float64{0.7, -1} {
+		if _, err := SoilDispersionScan(context.Background(), profile(nu), omega, FastScan); err == nil {
+			t.Errorf("expected SoilDispersionScan to reject the Poisson's ratio %g", nu)
+		}
+		if _, err := LeakyDispersionScan(context.Background(), profile(nu), omega, FastScan); err == nil {
+			t.Errorf("expected LeakyDispersionScan to reject the Poisson's ratio %g", nu)
+		}
+	}
+}
+
+func TestLeakyDispersionScan(t *testing.T) {
+	layers := []Layer{
+		{Density: 1800, YoungsModulus: 20e6, PoissonRatio: 0.3, Thickness: 5},
+		{Density: 2100, YoungsModulus: 1000e6, PoissonRatio: 0.3, Thickness: math.Inf(1)},
+	}
+	for i := range layers {
+		layers[i].WaveSpeed()
+	}
+	halfspace := layers[1].ShearWaveSpeed
+
+	// The second mode leaks into the halfspace below its cut-off frequency; at high
+	// frequencies all modes are guided
+	omega := []float64{20, 200, 300}
+	leaky, err := LeakyDispersionScan(context.Background(), layers, omega, FastScan)
+	if err != nil {
+		t.Fatalf("LeakyDispersionScan failed: %v", err)
+	}
+	if !(leaky[0] > halfspace && leaky[0] < LeakyVelocityRatio*halfspace) {
+		t.Errorf("expected a leaky mode above the halfspace shear wave speed %f, got %f", halfspace, leaky[0])
+	}
+	terms := newVelocityTerms(layers, leaky[0], make([]interfaceTerms, 1))
+	var sum complex128
+	largest := 0.0
+	for _, term := range terms.determinantTerms(layers, omega[0], leaky[0]) {
+		sum += term
+		largest = math.Max(largest, cmplx.Abs(term))
+	}
+	if cmplx.Abs(sum) > LeakyTolerance*largest {
+		t.Errorf("expected the determinant to nearly vanish at the leaky mode, got %g of its largest term", cmplx.Abs(sum)/largest)
+	}
+	if !math.IsNaN(leaky[1]) || !math.IsNaN(leaky[2]) {
+		t.Errorf("expected no leaky modes at high frequencies, got %v", leaky[1:])
+	}
+
+	// The guided modes stay below the halfspace shear wave speed
+	guided, _ := SoilDispersionScan(context.Background(), layers, omega, FastScan)
+	if !(guided[0] < halfspace) {
+		t.Errorf("expected a guided fundamental mode below %f, got %f", halfspace, guided[0])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LeakyDispersionScan(ctx, layers, omega, FastScan); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
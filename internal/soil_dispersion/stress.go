@@ -0,0 +1,101 @@
+package soil_dispersion
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	gravity      = 9.81 // Gravitational acceleration [m/s²]
+	waterDensity = 1000 // Density of water [kg/m^3]
+)
+
+// AxleLoad describes the static load of an axle on the soil profile, which raises the
+// stress in the soil and, with it, the stiffness of the soil.
+type AxleLoad struct {
+	Load             float64 // Static axle load [N]
+	Width            float64 // Width of the loaded area at the surface, across the track [m]
+	Length           float64 // Length of the loaded area at the surface, along the track [m]
+	Exponent         float64 // Exponent of the power law of the stiffness in the vertical stress
+	GroundwaterDepth float64 // Depth of the groundwater table below the surface [m]
+}
+
+// StressIncrease returns the vertical stress increase under an axle load at a depth,
+// spreading the load at a slope of 2:1 (vertical over horizontal) below the loaded
+// area:
+//
+//	Δσ = P / ((B + z) (L + z))
+//
+// Parameters:
+//   - depth: Depth below the surface [m]
+//
+// Returns:
+//   - float64: The stress increase [Pa]
+func (a AxleLoad) StressIncrease(depth float64) float64 {
+	return a.Load / ((a.Width + depth) * (a.Length + depth))
+}
+
+// EffectiveOverburden returns the vertical effective stress of the weight of the soil
+// profile at a depth, with hydrostatic pore pressure below the groundwater table.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - depth: Depth below the surface [m]
+//   - groundwaterDepth: Depth of the groundwater table below the surface [m]
+//
+// Returns:
+//   - float64: The vertical effective stress [Pa]
+func EffectiveOverburden(layers []Layer, depth, groundwaterDepth float64) float64 {
+	stress, top := 0.0, 0.0
+	for _, layer := range layers {
+		if top >= depth {
+			break
+		}
+		stress += layer.Density * gravity * (math.Min(top+layer.Thickness, depth) - top)
+		top += layer.Thickness
+	}
+	return stress - waterDensity*gravity*math.Max(depth-groundwaterDepth, 0)
+}
+
+// StiffenUnderLoad adjusts the Young's moduli of a soil profile for the stress increase
+// under an axle load, with the power law
+//
+//	E = E₀ ((σ'₀ + Δσ) / σ'₀)^n
+//
+// with σ'₀ the effective overburden (see EffectiveOverburden) and Δσ the stress
+// increase (see AxleLoad.StressIncrease) at the middle of a layer, or at the top of
+// the halfspace. The moduli of the profile are those at the overburden stress; a
+// layer without effective overburden, such as a halfspace at the surface, is left
+// unchanged. Discretize the profile first so that the stiffness of thick layers
+// follows the decay of the stress increase with depth.
+//
+// Parameters:
+//   - layers: The soil profile
+//   - load: The axle load
+//
+// Returns:
+//   - []Layer: The stiffened profile (a copy), with its wave speeds computed
+//   - error: An error if the load is invalid
+func StiffenUnderLoad(layers []Layer, load AxleLoad) ([]Layer, error) {
+	if !(load.Load >= 0) || !(load.Width > 0) || !(load.Length > 0) {
+		return nil, fmt.Errorf("invalid axle load: the load must not be negative and the loaded width and length must be positive")
+	}
+	if !(load.Exponent >= 0 && load.Exponent <= 1) || !(load.GroundwaterDepth >= 0) {
+		return nil, fmt.Errorf("invalid axle load: the exponent must be in [0, 1] and the groundwater depth not negative")
+	}
+	stiffened := make([]Layer, len(layers))
+	top := 0.0
+	for i, layer := range layers {
+		depth := top
+		if !math.IsInf(layer.Thickness, 1) {
+			depth += layer.Thickness / 2
+		}
+		if overburden := EffectiveOverburden(layers, depth, load.GroundwaterDepth); overburden > 0 {
+			layer.YoungsModulus *= math.Pow(1+load.StressIncrease(depth)/overburden, load.Exponent)
+			layer.WaveSpeed()
+		}
+		stiffened[i] = layer
+		top += layer.Thickness
+	}
+	return stiffened, nil
+}
@@ -0,0 +1,48 @@
+// Package storage reads and writes files that are either local or stored in a
+// cloud object store, so that configurations and results can live in buckets.
+//
+// Paths are local file paths, or URLs of objects:
+//
+//	s3://bucket/key   Amazon S3, or an S3-compatible store (MinIO, ...)
+//	gs://bucket/key   Google Cloud Storage
+//
+// Remote reads are streamed as they are read. Remote writes are buffered and
+// uploaded in parts of 8 MiB (S3 multipart uploads, GCS resumable uploads), so large
+// results are not held in memory; an object only exists once its writer has been
+// closed without error. Smaller objects are uploaded in a single request.
+//
+// The objects are accessed with the AWS SDK for Go v2 and the Google Cloud Storage
+// client library. Their clients are created on first use and shared by all calls.
+//
+// # Credentials
+//
+// S3 requests use the default credential chain of the AWS SDK: the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, the shared
+// config and credentials files (~/.aws, with AWS_PROFILE), web identity tokens (IAM
+// roles for EKS service accounts), ECS container credentials and the EC2 instance
+// metadata service. The region is that of AWS_REGION or the profile (default
+// us-east-1). A custom endpoint (AWS_ENDPOINT_URL_S3, AWS_ENDPOINT_URL or
+// endpoint_url of the profile) selects an S3-compatible store, with path-style
+// requests.
+//
+// GCS requests use the Application Default Credentials: the service account key
+// file of GOOGLE_APPLICATION_CREDENTIALS, the credentials of "gcloud auth
+// application-default login", or the service account of the Google Cloud machine.
+// STORAGE_EMULATOR_HOST selects a GCS emulator.
+//
+// # Usage Example
+//
+//	data, err := storage.ReadFile("s3://my-bucket/configs/track.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	w, err := storage.Create("gs://my-bucket/results/track.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	_, err = w.Write(data)
+//	err = w.Close() // the object is complete once Close returns
+//
+//	configs, err := storage.List("s3://my-bucket/configs/", ".yaml")
+package storage
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// gcsBackend accesses Google Cloud Storage.
+type gcsBackend struct {
+	client *gcs.Client // Client of the store
+}
+
+// newGCSClient creates a GCS client with the Application Default Credentials:
+// the service account key file of GOOGLE_APPLICATION_CREDENTIALS, the credentials
+// of "gcloud auth application-default login", or the service account of the
+// Google Cloud machine (metadata server). STORAGE_EMULATOR_HOST selects an
+// emulator (e.g. fake-gcs-server), without authentication.
+//
+// Returns:
+//   - *gcs.Client: The client
+//   - error: An error if the client cannot be created
+func newGCSClient() (*gcs.Client, error) {
+	client, err := gcs.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the GCS client: %v", err)
+	}
+	return client, nil
+}
+
+// get opens an object for reading.
+func (b *gcsBackend) get(bucket, key string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	r, err := b.client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		cancel()
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("gs://%s/%s: %w", bucket, key, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("GET gs://%s/%s failed: %v", bucket, key, err)
+	}
+	return cancelReader{ReadCloser: r, cancel: cancel}, nil
+}
+
+// put uploads an object in a single request.
+func (b *gcsBackend) put(bucket, key string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	w := b.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	w.ChunkSize = 0 // single request
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("PUT gs://%s/%s failed: %v", bucket, key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("PUT gs://%s/%s failed: %v", bucket, key, err)
+	}
+	return nil
+}
+
+// list returns the keys of the objects starting with a prefix.
+func (b *gcsBackend) list(bucket, prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	query := &gcs.Query{Prefix: prefix}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return nil, err
+	}
+	var keys []string
+	objects := b.client.Bucket(bucket).Objects(ctx, query)
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("LIST gs://%s/%s failed: %v", bucket, prefix, err)
+		}
+		keys = append(keys, attrs.Name)
+	}
+}
+
+// gcsUpload is a GCS resumable upload, sent in chunks of partSize by the writer
+// of the client.
+type gcsUpload struct {
+	bucket string             // Bucket of the object
+	key    string             // Key of the object
+	writer *gcs.Writer        // Writer of the object
+	cancel context.CancelFunc // Cancels the upload
+}
+
+// startUpload starts a resumable upload. The upload is not bounded by
+// requestTimeout, since each chunk is retried with its own deadline.
+func (b *gcsBackend) startUpload(bucket, key string) (upload, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := b.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	w.ChunkSize = partSize
+	return &gcsUpload{bucket: bucket, key: key, writer: w, cancel: cancel}, nil
+}
+
+// part writes a part; the last one completes the upload.
+func (u *gcsUpload) part(data []byte, last bool) error {
+	if _, err := u.writer.Write(data); err != nil {
+		return fmt.Errorf("upload of gs://%s/%s failed: %v", u.bucket, u.key, err)
+	}
+	if !last {
+		return nil
+	}
+	defer u.cancel()
+	if err := u.writer.Close(); err != nil {
+		return fmt.Errorf("upload of gs://%s/%s failed: %v", u.bucket, u.key, err)
+	}
+	return nil
+}
+
+// abort cancels the upload.
+func (u *gcsUpload) abort() {
+	u.cancel()
+	u.writer.Close()
+}
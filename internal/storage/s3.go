@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3Backend accesses Amazon S3, or an S3-compatible object store (e.g. MinIO).
+type s3Backend struct {
+	client *s3.Client // Client of the store
+}
+
+// newS3Client creates an S3 client with the default configuration of the AWS SDK:
+// the credentials are resolved lazily from the standard chain (environment
+// variables, shared config and credentials files with AWS_PROFILE, web identity
+// tokens of EKS service accounts, ECS container credentials and the EC2 instance
+// metadata service), in the region of AWS_REGION or the profile (default
+// us-east-1). Custom endpoints (AWS_ENDPOINT_URL_S3, AWS_ENDPOINT_URL or
+// endpoint_url of the profile) select an S3-compatible store, with path-style
+// requests.
+//
+// Returns:
+//   - *s3.Client: The client
+//   - error: An error if the shared configuration cannot be loaded
+func newS3Client() (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithDefaultRegion("us-east-1"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS configuration: %v", err)
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = o.BaseEndpoint != nil
+		// Objects uploaded without checksums are read without warnings
+		o.DisableLogOutputChecksumValidationSkipped = true
+	}), nil
+}
+
+// get opens an object for reading (GetObject).
+func (b *s3Backend) get(bucket, key string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		cancel()
+		if noSuchKey := new(types.NoSuchKey); errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("s3://%s/%s: %w", bucket, key, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("GET s3://%s/%s failed: %v", bucket, key, err)
+	}
+	return cancelReader{ReadCloser: out.Body, cancel: cancel}, nil
+}
+
+// put uploads an object in a single request (PutObject).
+func (b *s3Backend) put(bucket, key string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("PUT s3://%s/%s failed: %v", bucket, key, err)
+	}
+	return nil
+}
+
+// list returns the keys of the objects starting with a prefix (ListObjectsV2).
+func (b *s3Backend) list(bucket, prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var keys []string
+	pages := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("LIST s3://%s/%s failed: %v", bucket, prefix, err)
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
+
+// s3Upload is an S3 multipart upload.
+type s3Upload struct {
+	backend  *s3Backend            // Backend of the upload
+	bucket   string                // Bucket of the object
+	key      string                // Key of the object
+	uploadID *string               // Identifier of the multipart upload
+	parts    []types.CompletedPart // Uploaded parts, by part number - 1
+}
+
+// startUpload starts a multipart upload (CreateMultipartUpload).
+func (b *s3Backend) startUpload(bucket, key string) (upload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	out, err := b.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("upload of s3://%s/%s failed: %v", bucket, key, err)
+	}
+	return &s3Upload{backend: b, bucket: bucket, key: key, uploadID: out.UploadId}, nil
+}
+
+// part uploads a part (UploadPart) and, after the last one, completes the upload
+// (CompleteMultipartUpload).
+func (u *s3Upload) part(data []byte, last bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	number := aws.Int32(int32(len(u.parts) + 1))
+	out, err := u.backend.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(u.bucket),
+		Key:        aws.String(u.key),
+		UploadId:   u.uploadID,
+		PartNumber: number,
+		Body:       bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("upload of s3://%s/%s failed: %v", u.bucket, u.key, err)
+	}
+	u.parts = append(u.parts, types.CompletedPart{PartNumber: number, ETag: out.ETag})
+	if !last {
+		return nil
+	}
+
+	_, err = u.backend.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.bucket),
+		Key:             aws.String(u.key),
+		UploadId:        u.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: u.parts},
+	})
+	if err != nil {
+		return fmt.Errorf("completion of s3://%s/%s failed: %v", u.bucket, u.key, err)
+	}
+	return nil
+}
+
+// abort cancels the upload (AbortMultipartUpload), discarding the uploaded parts.
+func (u *s3Upload) abort() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	u.backend.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(u.bucket),
+		Key:      aws.String(u.key),
+		UploadId: u.uploadID,
+	})
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// partSize is the size of the parts of streamed uploads [bytes]: a multiple of
+// 256 KiB (GCS resumable uploads) of at least 5 MiB (S3 multipart uploads).
+// Smaller files are uploaded in a single request.
+var partSize = 8 << 20
+
+// requestTimeout bounds the requests of the remote backends, including the
+// reading of downloaded objects.
+const requestTimeout = 10 * time.Minute
+
+// clients are the clients of the remote backends, created on first use: resolving
+// the credentials may read configuration files and query metadata services.
+var clients struct {
+	mu  sync.Mutex
+	s3  *s3.Client
+	gcs *gcs.Client
+}
+
+// backend is a remote object store.
+type backend interface {
+	// get opens an object for reading.
+	get(bucket, key string) (io.ReadCloser, error)
+	// put uploads an object in a single request.
+	put(bucket, key string, data []byte) error
+	// startUpload starts the upload of an object in parts.
+	startUpload(bucket, key string) (upload, error)
+	// list returns the keys of the objects starting with a prefix.
+	list(bucket, prefix string) ([]string, error)
+}
+
+// upload is an upload of an object in parts.
+type upload interface {
+	// part uploads the next part; the upload is complete after the last part.
+	part(data []byte, last bool) error
+	// abort cancels the upload.
+	abort()
+}
+
+// IsRemote reports whether a path is the URL of a remote object (s3:// or gs://).
+//
+// Parameters:
+//   - path: Local path or URL
+//
+// Returns:
+//   - bool: True for s3:// and gs:// URLs
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+// parseURL splits the URL of a remote object into its backend, bucket and key.
+//
+// Parameters:
+//   - rawURL: s3://bucket/key or gs://bucket/key
+//
+// Returns:
+//   - backend: The backend of the URL scheme
+//   - string: The bucket
+//   - string: The key (may be empty for a bucket)
+//   - error: An error if the URL is invalid or the backend cannot be configured
+func parseURL(rawURL string) (backend, string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid URL %s: %v", rawURL, err)
+	}
+	if u.Host == "" {
+		return nil, "", "", fmt.Errorf("invalid URL %s: missing bucket", rawURL)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+
+	clients.mu.Lock()
+	defer clients.mu.Unlock()
+	switch u.Scheme {
+	case "s3":
+		if clients.s3 == nil {
+			if clients.s3, err = newS3Client(); err != nil {
+				return nil, "", "", err
+			}
+		}
+		return &s3Backend{client: clients.s3}, u.Host, key, nil
+	case "gs":
+		if clients.gcs == nil {
+			if clients.gcs, err = newGCSClient(); err != nil {
+				return nil, "", "", err
+			}
+		}
+		return &gcsBackend{client: clients.gcs}, u.Host, key, nil
+	default:
+		return nil, "", "", fmt.Errorf("unsupported URL scheme: %s. Supported schemes are 's3' or 'gs'", u.Scheme)
+	}
+}
+
+// Open opens a file for reading. Remote objects are streamed as they are read.
+//
+// Parameters:
+//   - path: Local path, or s3:// or gs:// URL
+//
+// Returns:
+//   - io.ReadCloser: The content of the file, to be closed by the caller
+//   - error: An error if the file cannot be opened
+func Open(path string) (io.ReadCloser, error) {
+	if !IsRemote(path) {
+		return os.Open(path)
+	}
+	b, bucket, key, err := parseURL(path)
+	if err != nil {
+		return nil, err
+	}
+	return b.get(bucket, key)
+}
+
+// ReadFile reads a whole file.
+//
+// Parameters:
+//   - path: Local path, or s3:// or gs:// URL
+//
+// Returns:
+//   - []byte: The content of the file
+//   - error: An error if the file cannot be read
+func ReadFile(path string) ([]byte, error) {
+	if !IsRemote(path) {
+		return os.ReadFile(path)
+	}
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return data, nil
+}
+
+// Create creates a file for writing, creating the parent directories of local
+// files. Remote objects are uploaded in parts while they are written, and
+// completed when the writer is closed: the object only exists once Close has
+// returned without error.
+//
+// Parameters:
+//   - path: Local path, or s3:// or gs:// URL
+//
+// Returns:
+//   - io.WriteCloser: The file, to be closed by the caller
+//   - error: An error if the file cannot be created
+func Create(path string) (io.WriteCloser, error) {
+	if !IsRemote(path) {
+		if dir := filepath.Dir(path); dir != "" && dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create directory: %v", err)
+			}
+		}
+		return os.Create(path)
+	}
+	b, bucket, key, err := parseURL(path)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("invalid object URL %s: missing object name", path)
+	}
+	return &remoteWriter{backend: b, bucket: bucket, key: key}, nil
+}
+
+// WriteFile writes a whole file, creating the parent directories of local files.
+//
+// Parameters:
+//   - path: Local path, or s3:// or gs:// URL
+//   - data: The content of the file
+//
+// Returns:
+//   - error: An error if the file cannot be written
+func WriteFile(path string, data []byte) error {
+	w, err := Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Exists reports whether a file exists. Remote objects are looked up by listing
+// their key as a prefix.
+//
+// Parameters:
+//   - path: Local path, or s3:// or gs:// URL
+//
+// Returns:
+//   - bool: True if the file (or object) exists
+//   - error: An error if the existence cannot be checked
+func Exists(path string) (bool, error) {
+	if !IsRemote(path) {
+		_, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	b, bucket, key, err := parseURL(path)
+	if err != nil {
+		return false, err
+	}
+	keys, err := b.list(bucket, key)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(keys, key), nil
+}
+
+// List returns the files with a suffix in a directory and its subdirectories, or
+// the objects with a suffix under a remote prefix (e.g. s3://bucket/configs/).
+//
+// Parameters:
+//   - dir: Local directory, or s3:// or gs:// URL of a prefix
+//   - suffix: Suffix of the files, e.g. ".yaml"
+//
+// Returns:
+//   - []string: Paths (or URLs) of the files: in directory traversal order for
+//     local directories, sorted by key for remote prefixes
+//   - error: An error if the directory cannot be listed
+func List(dir string, suffix string) ([]string, error) {
+	var files []string
+	if !IsRemote(dir) {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), suffix) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		return files, err
+	}
+
+	b, bucket, prefix, err := parseURL(dir)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	keys, err := b.list(bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	scheme, _, _ := strings.Cut(dir, "://")
+	for _, key := range keys {
+		if strings.HasSuffix(key, suffix) {
+			files = append(files, fmt.Sprintf("%s://%s/%s", scheme, bucket, key))
+		}
+	}
+	return files, nil
+}
+
+// remoteWriter streams a remote object: the data is buffered up to partSize and
+// then uploaded in parts; objects smaller than a part are uploaded in a single
+// request when the writer is closed.
+type remoteWriter struct {
+	backend backend      // Backend of the object
+	bucket  string       // Bucket of the object
+	key     string       // Key of the object
+	buffer  bytes.Buffer // Data not uploaded yet
+	upload  upload       // Upload in parts (nil until the first part)
+	err     error        // First error, returned by all later calls
+	closed  bool         // True once Close has been called
+}
+
+// Write buffers data and uploads the full parts.
+func (w *remoteWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, fmt.Errorf("write to closed object %s", w.key)
+	}
+	w.buffer.Write(p)
+
+	// Keep at least one byte for the last part, which completes the upload
+	for w.buffer.Len() > partSize {
+		if w.upload == nil {
+			if w.upload, w.err = w.backend.startUpload(w.bucket, w.key); w.err != nil {
+				return 0, w.err
+			}
+		}
+		if w.err = w.upload.part(w.buffer.Next(partSize), false); w.err != nil {
+			w.upload.abort()
+			return 0, w.err
+		}
+	}
+	return len(p), nil
+}
+
+// Close uploads the remaining data and completes the object.
+func (w *remoteWriter) Close() error {
+	if w.closed || w.err != nil {
+		return w.err
+	}
+	w.closed = true
+	if w.upload == nil {
+		w.err = w.backend.put(w.bucket, w.key, w.buffer.Bytes())
+		return w.err
+	}
+	if w.err = w.upload.part(w.buffer.Bytes(), true); w.err != nil {
+		w.upload.abort()
+	}
+	return w.err
+}
+
+// cancelReader is a streamed object whose request context is released when it
+// is closed.
+type cancelReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc // Cancels the context of the request
+}
+
+// Close closes the object and releases the context of its request.
+func (r cancelReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
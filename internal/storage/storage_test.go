@@ -0,0 +1,359 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeStore is a minimal in-memory object store serving the S3 (path-style) and
+// GCS (JSON and XML) APIs used by the SDK clients, on two servers.
+type fakeStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte   // Objects by "bucket/key"
+	uploads map[string][][]byte // Parts of the uploads in progress, by upload id
+	parts   int                 // Number of parts received
+	s3      *httptest.Server
+	gcs     *httptest.Server
+}
+
+// startFakeStore starts a fake object store, and points the clients to it.
+func startFakeStore(t *testing.T) *fakeStore {
+	t.Helper()
+	store := &fakeStore{objects: map[string][]byte{}, uploads: map[string][][]byte{}}
+	store.s3 = httptest.NewServer(http.HandlerFunc(store.serveS3))
+	t.Cleanup(store.s3.Close)
+	store.gcs = httptest.NewServer(http.HandlerFunc(store.serveGCS))
+	t.Cleanup(store.gcs.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", store.s3.URL)
+	t.Setenv("STORAGE_EMULATOR_HOST", store.gcs.URL)
+	resetClients(t)
+	return store
+}
+
+// resetClients discards the cached clients, so that they are created again from
+// the environment of the test.
+func resetClients(t *testing.T) {
+	clients.mu.Lock()
+	clients.s3, clients.gcs = nil, nil
+	clients.mu.Unlock()
+	t.Cleanup(func() {
+		clients.mu.Lock()
+		clients.s3, clients.gcs = nil, nil
+		clients.mu.Unlock()
+	})
+}
+
+// serveS3 handles the S3 requests.
+func (s *fakeStore) serveS3(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		s3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		// One key per page, to exercise the continuation
+		keys := s.keys(bucket, query.Get("prefix"))
+		start := 0
+		if token := query.Get("continuation-token"); token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		fmt.Fprint(w, "<ListBucketResult>")
+		if start < len(keys) {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", keys[start])
+		}
+		if start+1 < len(keys) {
+			fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+		} else {
+			fmt.Fprint(w, "<IsTruncated>false</IsTruncated>")
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[bucket+"/"+key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.parts++
+		s.uploads[query.Get("uploadId")] = append(s.uploads[query.Get("uploadId")], body)
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%s"`, query.Get("partNumber")))
+	case r.Method == http.MethodPut:
+		s.objects[bucket+"/"+key] = body
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var complete struct {
+			Parts []struct {
+				ETag string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		parts := s.uploads[query.Get("uploadId")]
+		if err := xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) != len(parts) {
+			s3Error(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		s.objects[bucket+"/"+key] = bytes.Join(parts, nil)
+		delete(s.uploads, query.Get("uploadId"))
+		fmt.Fprint(w, "<CompleteMultipartUploadResult/>")
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusBadRequest, "NotImplemented")
+	}
+}
+
+// s3Error writes an S3 error response.
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// serveGCS handles the GCS requests.
+func (s *fakeStore) serveGCS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/session/"):
+		// Chunk of a resumable upload
+		name := strings.TrimPrefix(r.URL.Path, "/session/")
+		body, _ := io.ReadAll(r.Body)
+		s.parts++
+		s.uploads[name] = append(s.uploads[name], body)
+		size := len(bytes.Join(s.uploads[name], nil))
+		if strings.HasSuffix(r.Header.Get("Content-Range"), "/*") {
+			// Resume Incomplete, as a 200 for clients sending X-GUploader-No-308
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", size-1))
+			w.Header().Set("X-Http-Status-Code-Override", "308")
+			return
+		}
+		s.objects[name] = bytes.Join(s.uploads[name], nil)
+		delete(s.uploads, name)
+		gcsObject(w, name)
+	case strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+		bucket := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b/"), "/o")
+		var metadata struct {
+			Name string `json:"name"`
+		}
+		if query.Get("uploadType") == "resumable" {
+			json.NewDecoder(r.Body).Decode(&metadata)
+			w.Header().Set("Location", s.gcs.URL+"/session/"+bucket+"/"+metadata.Name)
+			return
+		}
+		// Multipart upload: the metadata, then the content
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		part, err := reader.NextPart()
+		if err != nil {
+			http.Error(w, "invalid upload", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(part).Decode(&metadata)
+		if part, err = reader.NextPart(); err != nil {
+			http.Error(w, "invalid upload", http.StatusBadRequest)
+			return
+		}
+		s.objects[bucket+"/"+metadata.Name], _ = io.ReadAll(part)
+		gcsObject(w, bucket+"/"+metadata.Name)
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+		bucket := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"), "/o")
+		var items []string
+		for _, key := range s.keys(bucket, query.Get("prefix")) {
+			items = append(items, fmt.Sprintf(`{"bucket":%q,"name":%q}`, bucket, key))
+		}
+		fmt.Fprintf(w, `{"kind":"storage#objects","items":[%s]}`, strings.Join(items, ","))
+	default:
+		// XML API download
+		data, ok := s.objects[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+// gcsObject writes the resource of an object.
+func gcsObject(w http.ResponseWriter, name string) {
+	bucket, key, _ := strings.Cut(name, "/")
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"kind":"storage#object","bucket":%q,"name":%q}`, bucket, key)
+}
+
+// keys returns the sorted keys of a bucket starting with a prefix.
+func (s *fakeStore) keys(bucket, prefix string) []string {
+	var keys []string
+	for name := range s.objects {
+		if b, key, _ := strings.Cut(name, "/"); b == bucket && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setPartSize lowers the part size for a test.
+func setPartSize(t *testing.T, size int) {
+	previous := partSize
+	partSize = size
+	t.Cleanup(func() { partSize = previous })
+}
+
+func TestRemoteReadWriteList(t *testing.T) {
+	store := startFakeStore(t)
+	// The smallest chunk of GCS resumable uploads
+	setPartSize(t, 256<<10)
+
+	for _, scheme := range []string{"s3", "gs"} {
+		t.Run(scheme, func(t *testing.T) {
+			base := scheme + "://bucket/configs/"
+			small := []byte("train_type: ICE\n")
+			large := bytes.Repeat([]byte("0123456789"), 60<<10)
+
+			if err := WriteFile(base+"a.yaml", small); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			parts := store.parts
+
+			// A large object is streamed in parts while it is written
+			w, err := Create(base + "sub/b.yaml")
+			if err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			for i := 0; i < len(large); i += 7000 {
+				if _, err := w.Write(large[i:min(i+7000, len(large))]); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if got := store.parts - parts; got != 3 {
+				t.Errorf("expected 3 parts, got %d", got)
+			}
+			if err := WriteFile(base+"notes.txt", small); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+
+			for path, want := range map[string][]byte{base + "a.yaml": small, base + "sub/b.yaml": large} {
+				data, err := ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile failed: %v", err)
+				}
+				if !bytes.Equal(data, want) {
+					t.Errorf("%s: expected %d bytes, got %d", path, len(want), len(data))
+				}
+			}
+
+			files, err := List(strings.TrimSuffix(base, "/"), ".yaml")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			want := []string{base + "a.yaml", base + "sub/b.yaml"}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("expected %v, got %v", want, files)
+			}
+
+			if _, err := ReadFile(base + "missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("expected a not found error, got %v", err)
+			}
+
+			// An object exists only with its exact key, not as the prefix of another key
+			for path, want := range map[string]bool{base + "a.yaml": true, base + "a.y": false, base + "missing.yaml": false} {
+				if exists, err := Exists(path); err != nil || exists != want {
+					t.Errorf("Exists(%s): expected %v, got %v (%v)", path, want, exists, err)
+				}
+			}
+		})
+	}
+}
+
+// Test that the credentials of the default chain are used: a profile of the shared
+// credentials file.
+func TestS3Profile(t *testing.T) {
+	store := startFakeStore(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	credentials := filepath.Join(t.TempDir(), "credentials")
+	profile := "[batch]\naws_access_key_id = key\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(credentials, []byte(profile), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_PROFILE", "batch")
+
+	if err := WriteFile("s3://bucket/a.json", []byte("{}")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data := store.objects["bucket/a.json"]; string(data) != "{}" {
+		t.Errorf("expected {}, got %q", data)
+	}
+}
+
+func TestLocalReadWriteList(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteFile(filepath.Join(dir, "sub", "a.yaml"), []byte("a")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadFile(filepath.Join(dir, "sub", "a.yaml"))
+	if err != nil || string(data) != "a" {
+		t.Errorf("ReadFile: expected a, got %q (%v)", data, err)
+	}
+	files, err := List(dir, ".yaml")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{filepath.Join(dir, "sub", "a.yaml")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+	for path, want := range map[string]bool{filepath.Join(dir, "b.txt"): true, filepath.Join(dir, "missing.txt"): false} {
+		if exists, err := Exists(path); err != nil || exists != want {
+			t.Errorf("Exists(%s): expected %v, got %v (%v)", path, want, exists, err)
+		}
+	}
+}
+
+func TestInvalidURLs(t *testing.T) {
+	resetClients(t)
+	for _, path := range []string{"s3:///key", "s3://bucket/", "gs://bucket/"} {
+		if _, err := Create(path); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+	if IsRemote("configs/a.yaml") || !IsRemote("gs://bucket/a.yaml") {
+		t.Error("IsRemote: unexpected result")
+	}
+}
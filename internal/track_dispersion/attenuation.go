@@ -0,0 +1,222 @@
+package track_dispersion
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
+)
+
+// DampedTrack is implemented by track models whose stiffness determinant, with the
+// damping of the railpads, is a polynomial in k⁴ with complex coefficients, so that
+// their complex wavenumbers can be found in closed form (see RailTrackAttenuation).
+type DampedTrack interface {
+	PolynomialTrack
+	// DampedPolynomial returns the coefficients of the determinant with the railpad
+	// stiffness KRailPad + iωCRailPad as a polynomial in k⁴, highest degree first.
+	DampedPolynomial(omega float64) []complex128
+}
+
+// DampedPolynomial implements the DampedTrack interface for BallastTrackParameters
+func (p BallastTrackParameters) DampedPolynomial(omega float64) []complex128 {
+	return BallastTrackDampedPolynomial(p, omega)
+}
+
+// DampedPolynomial implements the DampedTrack interface for SlabTrackParameters
+func (p SlabTrackParameters) DampedPolynomial(omega float64) []complex128 {
+	return SlabTrackDampedPolynomial(p, omega)
+}
+
+// DampedPolynomial implements the DampedTrack interface for PiledSlabTrackParameters
+func (p PiledSlabTrackParameters) DampedPolynomial(omega float64) []complex128 {
+	return SlabTrackDampedPolynomial(p.Slab(), omega)
+}
+
+// trackWave is the complex wave number of a track at a frequency, as a phase velocity
+// and a spatial decay rate.
+type trackWave struct {
+	phaseVelocity float64 // Phase velocity [m/s]
+	decayRate     float64 // Spatial decay rate [1/m]
+}
+
+// RailTrackAttenuation calculates the phase velocity and the spatial decay rate of the
+// waves of a damped railway track. With the damping of the railpads, the wave numbers
+// are complex, k = κ - iα for waves e^{i(ωt - kx)}: the phase velocity is ω/κ and the
+// amplitude decays as e^{-αx} along the track, so that 1/α is the distance over which
+// it drops by a factor e. The wave numbers are computed in closed form, on the branch
+// of RailTrackDispersionPolynomial: the damped wave number is the one closest to the
+// wave number of the undamped track. Both values are left at zero where no wave
+// propagates in the undamped track.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - parameters: Physical parameters of the track system (BallastTrackParameters or SlabTrackParameters)
+//   - omega: Array of angular frequencies [rad/s] at which to compute the waves
+//
+// Returns:
+//   - An array of phase velocities [m/s] corresponding to each input angular frequency
+//   - An array of spatial decay rates [1/m] corresponding to each input angular frequency
+//   - An error if the track has no damped determinant polynomial or the context is cancelled
+func RailTrackAttenuation(ctx context.Context, parameters TrackParameters, omega []float64) ([]float64, []float64, error) {
+	damped, ok := parameters.(DampedTrack)
+	if !ok {
+		return nil, nil, fmt.Errorf("track type %T has no damped determinant polynomial", parameters)
+	}
+
+	waves := math_utils.ParallelMap(func(omegaVal float64) trackWave {
+		if ctx.Err() != nil {
+			return trackWave{}
+		}
+		undamped, ok := polynomialWavenumber(damped.DeterminantPolynomial(omegaVal))
+		if !ok {
+			return trackWave{}
+		}
+		wavenumber, ok := dampedWavenumber(damped.DampedPolynomial(omegaVal), undamped)
+		if !ok {
+			return trackWave{}
+		}
+		return trackWave{phaseVelocity: omegaVal / real(wavenumber), decayRate: math.Abs(imag(wavenumber))}
+	}, omega, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	phaseVelocity := make([]float64, len(waves))
+	decayRate := make([]float64, len(waves))
+	for i, wave := range waves {
+		phaseVelocity[i], decayRate[i] = wave.phaseVelocity, wave.decayRate
+	}
+	return phaseVelocity, decayRate, nil
+}
+
+// dampedWavenumber finds the complex wave number of a damped track from its
+// determinant polynomial in k⁴: the root closest to the fourth power of the wave
+// number of the undamped track, which follows its branch as the damping grows.
+//
+// Parameters:
+//   - coefficients: Coefficients of the damped determinant as a polynomial in k⁴, highest degree first
+//   - undamped: Wave number of the undamped track [1/m]
+//
+// Returns:
+//   - The complex wave number [1/m], with a positive real part
+//   - False if the polynomial has no root (or the coefficients are not finite)
+func dampedWavenumber(coefficients []complex128, undamped float64) (complex128, bool) {
+	for _, c := range coefficients {
+		if cmplx.IsNaN(c) || cmplx.IsInf(c) {
+			return 0, false
+		}
+	}
+	target := complex(math.Pow(undamped, 4), 0)
+	var root complex128
+	found := false
+	for _, q := range complexRoots(coefficients) {
+		if !found || cmplx.Abs(q-target) < cmplx.Abs(root-target) {
+			root, found = q, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+
+	// The principal fourth root has the largest real part
+	wavenumber := cmplx.Pow(root, 0.25)
+	if !(real(wavenumber) > 0) {
+		return 0, false
+	}
+	return wavenumber, true
+}
+
+// complexRoots finds the roots of a linear or quadratic polynomial with complex
+// coefficients, with the formulation of math_utils.SolveQuadratic that avoids
+// cancellation.
+//
+// Parameters:
+//   - coefficients: Coefficients of the polynomial, highest degree first (leading zeros are ignored)
+//
+// Returns:
+//   - The roots (empty for a constant polynomial or a degree higher than two)
+func complexRoots(coefficients []complex128) []complex128 {
+	for len(coefficients) > 0 && coefficients[0] == 0 {
+		coefficients = coefficients[1:]
+	}
+	switch len(coefficients) {
+	case 2:
+		return []complex128{-coefficients[1] / coefficients[0]}
+	case 3:
+		a, b, c := coefficients[0], coefficients[1], coefficients[2]
+		sqrtDiscriminant := cmplx.Sqrt(b*b - 4*a*c)
+		// The sign giving the largest |b ± √Δ|
+		if real(b)*real(sqrtDiscriminant)+imag(b)*imag(sqrtDiscriminant) < 0 {
+			sqrtDiscriminant = -sqrtDiscriminant
+		}
+		q := -0.5 * (b + sqrtDiscriminant)
+		if q == 0 {
+			return []complex128{0}
+		}
+		return []complex128{q / a, c / q}
+	}
+	return nil
+}
+
+// BallastTrackDampedPolynomial computes the coefficients of the determinant of
+// BallastTrackStiffness, with the railpad stiffness KRailPad + iωCRailPad, as a
+// polynomial in k⁴. Only the rail term depends on the wavenumber, so the determinant
+// is linear in k⁴.
+//
+// Parameters:
+//   - parameters: Physical parameters of the ballast track system
+//   - omega: Angular frequency [rad/s]
+//
+// Returns:
+//   - Coefficients of the determinant in k⁴, highest degree first
+func BallastTrackDampedPolynomial(parameters BallastTrackParameters, omega float64) []complex128 {
+
+	// Same terms as BallastTrackStiffness, with the damping of the railpads
+	alpha := 0.5
+	cp := math.Sqrt(parameters.EBallast / parameters.RhoBallast)
+	tan_value := math.Tan(omega*parameters.HBallast/cp) * cp
+	sin_value := math.Sin(omega*parameters.HBallast/cp) * cp
+	rail_pad_complex_stiffness := complex(parameters.KRailPad, omega*parameters.CRailPad)
+
+	k11 := rail_pad_complex_stiffness - complex(math.Pow(omega, 2)*parameters.MRail, 0) // without EI k⁴
+	k22 := rail_pad_complex_stiffness + complex((2*omega*parameters.EBallast*parameters.WidthSleeper*alpha)/tan_value-
+		math.Pow(omega, 2)*parameters.MSleeper, 0)
+	k23 := complex(-2*omega*parameters.EBallast*parameters.WidthSleeper*alpha/sin_value, 0)
+	k33 := complex(2*omega*parameters.EBallast*parameters.WidthSleeper*alpha/tan_value+parameters.SoilStiffness, 0)
+
+	// det = (EI k⁴ + k11) (k22 k33 - k23²) - k12² k33
+	minor := k22*k33 - k23*k23
+	return []complex128{
+		complex(parameters.EIRail, 0) * minor,
+		k11*minor - rail_pad_complex_stiffness*rail_pad_complex_stiffness*k33,
+	}
+}
+
+// SlabTrackDampedPolynomial computes the coefficients of the determinant of
+// SlabTrackStiffness, with the railpad stiffness KRailPad + iωCRailPad, as a
+// polynomial in k⁴. The rail and the slab terms depend on k⁴, so the determinant is
+// quadratic in k⁴.
+//
+// Parameters:
+//   - parameters: Physical parameters of the slab track system
+//   - omega: Angular frequency [rad/s]
+//
+// Returns:
+//   - Coefficients of the determinant in k⁴, highest degree first
+func SlabTrackDampedPolynomial(parameters SlabTrackParameters, omega float64) []complex128 {
+	rail_pad_complex_stiffness := complex(parameters.KRailPad, omega*parameters.CRailPad)
+
+	// Same terms as SlabTrackStiffness, without the EI k⁴ terms
+	k11 := rail_pad_complex_stiffness - complex(math.Pow(omega, 2)*parameters.MRail, 0)
+	k22 := rail_pad_complex_stiffness + complex(parameters.SoilStiffness-math.Pow(omega, 2)*parameters.MSlab, 0)
+
+	// det = (EIrail k⁴ + k11) (EIslab k⁴ + k22) - k12²
+	eiRail, eiSlab := complex(parameters.EIRail, 0), complex(parameters.EISlab, 0)
+	return []complex128{
+		eiRail * eiSlab,
+		eiRail*k22 + eiSlab*k11,
+		k11*k22 - rail_pad_complex_stiffness*rail_pad_complex_stiffness,
+	}
+}
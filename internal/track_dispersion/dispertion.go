@@ -1,11 +1,11 @@
 package track_dispersion
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	math_utils "github.com/PlatypusBytes/GoTrain/pkg/utils"
-	"gonum.org/v1/gonum/mat"
 )
 
 // TrackParameters defines the interface that track parameter structs must implement
@@ -13,6 +13,28 @@ type TrackParameters interface {
 	CalculateStiffness(omega float64, wavenumber float64) float64
 }
 
+// PolynomialTrack is implemented by track models whose stiffness determinant is a
+// polynomial in the squared wavenumber k², so that their wavenumbers can be found in
+// closed form (see math_utils.PolynomialRoots) instead of by bracketing.
+type PolynomialTrack interface {
+	// DeterminantPolynomial returns the coefficients of the determinant as a
+	// polynomial in k², highest degree first.
+	DeterminantPolynomial(omega float64) []float64
+}
+
+// ReceptanceTrack is implemented by track models that compute the response of the
+// rail to a load that is harmonic in space and time, used for the response to a
+// moving load (see internal/moving_load).
+type ReceptanceTrack interface {
+	// Receptance returns the displacement of the rail per unit force of a load
+	// with a wavenumber [1/m] and angular frequency [rad/s], with the soil
+	// represented by a complex dynamic stiffness [N/m²] instead of SoilStiffness.
+	Receptance(omega float64, wavenumber float64, soilStiffness complex128) complex128
+	// RailBendingStiffness returns the bending stiffness of the rail [N·m²], which
+	// converts its curvature into a bending moment.
+	RailBendingStiffness() float64
+}
+
 // BallastTrackParameters holds the parameters for the ballast track model.
 // These parameters are used to define the physical properties of the railway track,
 // including rail, sleeper, railpad, ballast, and soil.
@@ -34,6 +56,21 @@ func (p BallastTrackParameters) CalculateStiffness(omega float64, wavenumber flo
 	return BallastTrackStiffness(p, omega, wavenumber)
 }
 
+// DeterminantPolynomial implements the PolynomialTrack interface for BallastTrackParameters
+func (p BallastTrackParameters) DeterminantPolynomial(omega float64) []float64 {
+	return BallastTrackPolynomial(p, omega)
+}
+
+// Receptance implements the ReceptanceTrack interface for BallastTrackParameters
+func (p BallastTrackParameters) Receptance(omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+	return BallastTrackReceptance(p, omega, wavenumber, soilStiffness)
+}
+
+// RailBendingStiffness implements the ReceptanceTrack interface for BallastTrackParameters
+func (p BallastTrackParameters) RailBendingStiffness() float64 {
+	return p.EIRail
+}
+
 // SlabTrackParameters holds the parameters for the slab track model.
 // These parameters define the physical properties of a slab track system,
 // including rail, slab, railpad, and soil.
@@ -52,6 +89,21 @@ func (p SlabTrackParameters) CalculateStiffness(omega float64, wavenumber float6
 	return SlabTrackStiffness(p, omega, wavenumber)
 }
 
+// DeterminantPolynomial implements the PolynomialTrack interface for SlabTrackParameters
+func (p SlabTrackParameters) DeterminantPolynomial(omega float64) []float64 {
+	return SlabTrackPolynomial(p, omega)
+}
+
+// Receptance implements the ReceptanceTrack interface for SlabTrackParameters
+func (p SlabTrackParameters) Receptance(omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+	return SlabTrackReceptance(p, omega, wavenumber, soilStiffness)
+}
+
+// RailBendingStiffness implements the ReceptanceTrack interface for SlabTrackParameters
+func (p SlabTrackParameters) RailBendingStiffness() float64 {
+	return p.EIRail
+}
+
 // RailTrackDispersion calculates the phase velocity dispersion curve for a railway track.
 //
 // Parameters:
@@ -59,29 +111,215 @@ func (p SlabTrackParameters) CalculateStiffness(omega float64, wavenumber float6
 //   - omega: Array of angular frequencies [rad/s] at which to compute phase velocities
 //
 // Returns:
-//   - An array of phase velocities [m/s] corresponding to each input angular frequency
+//   - An array of phase velocities [m/s] corresponding to each input angular frequency.
+//     If no wavenumber is found for a frequency, the phase velocity is left at zero.
 func RailTrackDispersion(parameters TrackParameters, omega []float64) []float64 {
+	phase_velocity, _ := RailTrackDispersionContext(context.Background(), parameters, omega)
+	return phase_velocity
+}
 
-	phase_velocity := make([]float64, len(omega))
+// RailTrackDispersionContext calculates the phase velocity dispersion curve for a railway
+// track in the same way as RailTrackDispersion, but stops early when the context is cancelled.
+// The frequencies are processed concurrently (see math_utils.ParallelMap) and the
+// context is checked before each frequency is processed.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - parameters: Physical parameters of the track system (BallastTrackParameters or SlabTrackParameters)
+//   - omega: Array of angular frequencies [rad/s] at which to compute phase velocities
+//
+// Returns:
+//   - An array of phase velocities [m/s] corresponding to each input angular frequency
+//   - An error if the context is cancelled before all frequencies are processed
+func RailTrackDispersionContext(ctx context.Context, parameters TrackParameters, omega []float64) ([]float64, error) {
+	return RailTrackDispersionSolver(ctx, parameters, omega, math_utils.Brent)
+}
 
+// RailTrackDispersionSolver calculates the phase velocity dispersion curve for a railway
+// track in the same way as RailTrackDispersionContext, finding the wave numbers with the
+// given root finder instead of Brent's method.
+//
+// The wave numbers are bracketed between 0.001 and 1000 1/m. When the determinant does
+// not change sign over that bracket (no root, or an even number of roots), the bracket
+// is scanned and widened (see expandBracket) before the frequency is given up, so that
+// a wave number outside the bracket, or between two others, is still found.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - parameters: Physical parameters of the track system (BallastTrackParameters or SlabTrackParameters)
+//   - omega: Array of angular frequencies [rad/s] at which to compute phase velocities
+//   - findRoot: Root finder used to find the wave number at each frequency (e.g. math_utils.Ridders),
+//     called concurrently
+//
+// Returns:
+//   - An array of phase velocities [m/s] corresponding to each input angular frequency
+//   - An error if the context is cancelled before all frequencies are processed
+func RailTrackDispersionSolver(ctx context.Context, parameters TrackParameters, omega []float64, findRoot math_utils.RootFinder) ([]float64, error) {
 	ini_wave_number := 0.001
 	end_wave_number := 1000.0
 
-	for i, omegaVal := range omega {
-		// Define a function for the Brent method to find the wave number
+	// The frequencies are independent: they are evaluated concurrently
+	phase_velocity := math_utils.ParallelMap(func(omegaVal float64) float64 {
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		// Define a function for the root finder to find the wave number
 		brentAuxiliar := func(wavenumber float64) float64 {
 			return parameters.CalculateStiffness(omegaVal, wavenumber)
 		}
 
-		wavenumber, err := math_utils.Brent(brentAuxiliar, ini_wave_number, end_wave_number, 1e-12)
+		wavenumber, err := findRoot(brentAuxiliar, ini_wave_number, end_wave_number, 1e-12)
 		if err != nil {
-			fmt.Println(err.Error())
-		} else {
-			// Calculate phase velocity from the found wave number
-			phase_velocity[i] = omegaVal / wavenumber
+			// Find a sign change within or beyond the bracket and retry
+			a, b, ok := expandBracket(brentAuxiliar, ini_wave_number, end_wave_number)
+			if !ok {
+				return 0
+			}
+			if wavenumber, err = findRoot(brentAuxiliar, a, b, 1e-12); err != nil {
+				return 0
+			}
 		}
+		// Calculate phase velocity from the found wave number
+		return omegaVal / wavenumber
+	}, omega, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return phase_velocity
+	return phase_velocity, nil
+}
+
+// Limits of the expansion of the wave number bracket of RailTrackDispersionSolver.
+const (
+	bracketScanPoints = 40 // Samples per decade of the scan of a bracket
+	bracketExpansion  = 10 // Factor the bounds of the bracket are widened by at each expansion
+	maxExpansions     = 3  // Largest number of expansions, e.g. to [1e-6, 1e6] 1/m
+)
+
+// expandBracket finds a bracket of a wave number where the bracket of the root finder
+// fails: the bracket is scanned logarithmically from its upper bound down for a sign
+// change of the determinant, which finds the largest wave number (the slowest mode,
+// as RailTrackDispersionPolynomial) and roots that come in pairs; without one, both
+// bounds are widened by bracketExpansion and the scan is repeated, up to
+// maxExpansions times.
+//
+// Parameters:
+//   - f: Determinant of the track as a function of the wave number
+//   - a: Lower bound of the bracket [1/m] (> 0)
+//   - b: Upper bound of the bracket [1/m]
+//
+// Returns:
+//   - The bounds of a bracket with a sign change of f
+//   - False if no sign change is found within the widest bracket
+func expandBracket(f func(float64) float64, a, b float64) (float64, float64, bool) {
+	for range maxExpansions + 1 {
+		samples := int(math.Ceil(math.Log10(b/a) * bracketScanPoints))
+		step := math.Pow(b/a, 1/float64(samples))
+		x2, f2 := b, f(b)
+		for range samples {
+			x1 := x2 / step
+			f1 := f(x1)
+			if !math.IsNaN(f1) && !math.IsNaN(f2) && f1*f2 <= 0 {
+				return x1, x2, true
+			}
+			x2, f2 = x1, f1
+		}
+		a, b = a/bracketExpansion, b*bracketExpansion
+	}
+	return 0, 0, false
+}
+
+// wavenumberStarts is the number of starting points of the search for the wave numbers
+// of all the modes of a track (see TrackWavenumbers), ten per decade of the bracket.
+const wavenumberStarts = 61
+
+// TrackWavenumbers finds the wave numbers of the modes of a track that propagate at a
+// frequency, between 0.001 and 1000 1/m, by multi-start bracketing and deflation of
+// the determinant (see math_utils.FindRootsDeflation). Unlike the dispersion curves,
+// which follow a single branch, it returns every root, including pairs of close roots
+// and branches that cross, for tracks with several modes and any TrackParameters.
+//
+// Parameters:
+//   - parameters: Physical parameters of the track system (BallastTrackParameters or SlabTrackParameters)
+//   - omega: Angular frequency [rad/s]
+//   - maxModes: Largest number of wave numbers to find
+//
+// Returns:
+//   - The wave numbers [1/m] in increasing order, i.e. from the fastest mode to the slowest
+//   - An error if maxModes is not positive or the refinement of a root fails
+func TrackWavenumbers(parameters TrackParameters, omega float64, maxModes int) ([]float64, error) {
+	determinant := func(wavenumber float64) float64 {
+		return parameters.CalculateStiffness(omega, wavenumber)
+	}
+	return math_utils.FindRootsDeflation(determinant, 0.001, 1000, wavenumberStarts, maxModes, 1e-12)
+}
+
+// RailTrackDispersionPolynomial calculates the phase velocity dispersion curve for a
+// railway track in the same way as RailTrackDispersionContext, computing the wave
+// numbers in closed form from the determinant polynomial of the track instead of by
+// bracketing. It is faster and exact, and does not depend on the convergence of a
+// root finder. It needs no bracket, and finds every wave number when several modes
+// propagate: the largest real wave number is taken, which continues the fundamental
+// branch of the curve. The phase velocity is left at zero only when no wave propagates.
+//
+// Parameters:
+//   - ctx: Context used to cancel the computation
+//   - parameters: Physical parameters of the track system (BallastTrackParameters or SlabTrackParameters)
+//   - omega: Array of angular frequencies [rad/s] at which to compute phase velocities
+//
+// Returns:
+//   - An array of phase velocities [m/s] corresponding to each input angular frequency
+//   - An error if the track has no determinant polynomial or the context is cancelled
+func RailTrackDispersionPolynomial(ctx context.Context, parameters TrackParameters, omega []float64) ([]float64, error) {
+	polynomial, ok := parameters.(PolynomialTrack)
+	if !ok {
+		return nil, fmt.Errorf("track type %T has no determinant polynomial", parameters)
+	}
+
+	phase_velocity := math_utils.ParallelMap(func(omegaVal float64) float64 {
+		if ctx.Err() != nil {
+			return 0
+		}
+		wavenumber, ok := polynomialWavenumber(polynomial.DeterminantPolynomial(omegaVal))
+		if !ok {
+			return 0
+		}
+		return omegaVal / wavenumber
+	}, omega, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return phase_velocity, nil
+}
+
+// polynomialWavenumber finds the wave number of a track in closed form from its
+// determinant polynomial in k²: the largest real wave number, i.e. the slowest of the
+// propagating modes.
+//
+// Parameters:
+//   - coefficients: Coefficients of the determinant as a polynomial in k², highest degree first
+//
+// Returns:
+//   - The wave number [1/m]
+//   - False if there is no real positive wave number (or the coefficients are not finite)
+func polynomialWavenumber(coefficients []float64) (float64, bool) {
+	for _, c := range coefficients {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return 0, false
+		}
+	}
+	roots, err := math_utils.PolynomialRoots(coefficients)
+	if err != nil {
+		return 0, false
+	}
+
+	// The roots are in increasing order: the last positive one is the largest
+	if len(roots) == 0 || !(roots[len(roots)-1] > 0) {
+		return 0, false
+	}
+	return math.Sqrt(roots[len(roots)-1]), true
 }
 
 // BallastTrackStiffness computes the determinant of the track-soil system stiffness matrix
@@ -105,32 +343,102 @@ func BallastTrackStiffness(parameters BallastTrackParameters, omega float64, wav
 
 	// auxiliar values
 	tan_value := math.Tan(omega*parameters.HBallast/cp) * cp
-	sin_value := math.Sin(omega*parameters.HBallast/cp) * cp
 
 	// railpad complex stiffness
 	// rail_pad_complex_stiffness := complex(parameters.KRailPad, omega * parameters.CRailPad)
 	rail_pad_complex_stiffness := parameters.KRailPad
 
 	// stiffness matrix
-	k11 := parameters.EIRail*math.Pow(wavenumber, 4) + rail_pad_complex_stiffness - math.Pow(omega, 2)*parameters.MRail
-	k12 := -rail_pad_complex_stiffness
-	k22 := rail_pad_complex_stiffness + (2*omega*parameters.EBallast*parameters.WidthSleeper*alpha)/tan_value -
-		math.Pow(omega, 2)*parameters.MSleeper
-	k23 := -2 * omega * parameters.EBallast * parameters.WidthSleeper * alpha / sin_value
-	k33 := 2*omega*parameters.EBallast*parameters.WidthSleeper*alpha/tan_value + parameters.SoilStiffness
-
-	stiffness := mat.NewDense(3, 3, []float64{
-		k11, k12, 0,
-		k12, k22, k23,
-		0, k23, k33,
-	})
-
-	// Calculate the determinant of the stiffness matrix
-	det := mat.Det(stiffness)
+	//	| kpad + a       -kpad            0      |
+	//	|  -kpad    kpad + b + B/tan   -B/sin    |
+	//	|    0           -B/sin      B/tan + ks  |
+	// with a = EI k⁴ - ω² m_rail, b = -ω² m_sleeper, B the ballast stiffness
+	// 2 ω E w α, tan and sin scaled by cp, and ks the soil stiffness
+	a := parameters.EIRail*math.Pow(wavenumber, 4) - math.Pow(omega, 2)*parameters.MRail
+	b := -math.Pow(omega, 2) * parameters.MSleeper
+	ballast := 2 * omega * parameters.EBallast * parameters.WidthSleeper * alpha
+	k33 := ballast/tan_value + parameters.SoilStiffness
+
+	// Determinant of the stiffness matrix, expanded along the first row. At low
+	// frequencies the railpad and ballast terms are many orders of magnitude larger than
+	// the determinant; with 1/tan² - 1/sin² = -1/cp², the terms that cancel are removed
+	// from the expansion:
+	//	minor = (kpad + b) k33 + B ks/tan - B²/cp²
+	//	det   = a minor + kpad (b k33 + B ks/tan - B²/cp²)
+	soil := ballast*parameters.SoilStiffness/tan_value - ballast*ballast/(cp*cp)
+	minor := (rail_pad_complex_stiffness+b)*k33 + soil
+	det := a*minor + rail_pad_complex_stiffness*(b*k33+soil)
 
 	return det
 }
 
+// BallastTrackReceptance computes the displacement of the rail of the ballast track
+// per unit force of a load on the rail, for a given angular frequency and wavenumber.
+// The stiffness matrix is that of BallastTrackStiffness, with the soil spring replaced
+// by a complex dynamic stiffness; the displacement is the cofactor of the rail over
+// the determinant.
+//
+// Parameters:
+//   - parameters: Physical parameters of the ballast track system
+//   - omega: Angular frequency [rad/s]
+//   - wavenumber: Spatial frequency [1/m]
+//   - soilStiffness: Dynamic stiffness of the soil under the track [N/m²]
+//
+// Returns:
+//   - Displacement of the rail per unit force [m/N]
+func BallastTrackReceptance(parameters BallastTrackParameters, omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+
+	// Same terms as BallastTrackStiffness
+	alpha := 0.5
+	cp := math.Sqrt(parameters.EBallast / parameters.RhoBallast)
+	tan_value := math.Tan(omega*parameters.HBallast/cp) * cp
+	sin_value := math.Sin(omega*parameters.HBallast/cp) * cp
+	rail_pad_complex_stiffness := parameters.KRailPad
+
+	k11 := complex(parameters.EIRail*math.Pow(wavenumber, 4)+rail_pad_complex_stiffness-math.Pow(omega, 2)*parameters.MRail, 0)
+	k12 := complex(-rail_pad_complex_stiffness, 0)
+	k22 := complex(rail_pad_complex_stiffness+(2*omega*parameters.EBallast*parameters.WidthSleeper*alpha)/tan_value-
+		math.Pow(omega, 2)*parameters.MSleeper, 0)
+	k23 := complex(-2*omega*parameters.EBallast*parameters.WidthSleeper*alpha/sin_value, 0)
+	k33 := complex(2*omega*parameters.EBallast*parameters.WidthSleeper*alpha/tan_value, 0) + soilStiffness
+
+	minor := k22*k33 - k23*k23
+	return minor / (k11*minor - k12*k12*k33)
+}
+
+// BallastTrackPolynomial computes the coefficients of the determinant of
+// BallastTrackStiffness as a polynomial in the squared wavenumber k². Only the rail
+// term depends on the wavenumber, so the determinant is quadratic in k².
+//
+// Parameters:
+//   - parameters: Physical parameters of the ballast track system
+//   - omega: Angular frequency [rad/s]
+//
+// Returns:
+//   - Coefficients of the determinant in k², highest degree first
+func BallastTrackPolynomial(parameters BallastTrackParameters, omega float64) []float64 {
+
+	// Same terms as BallastTrackStiffness
+	alpha := 0.5
+	cp := math.Sqrt(parameters.EBallast / parameters.RhoBallast)
+	tan_value := math.Tan(omega*parameters.HBallast/cp) * cp
+	rail_pad_complex_stiffness := parameters.KRailPad
+
+	a := -math.Pow(omega, 2) * parameters.MRail // without EI k⁴
+	b := -math.Pow(omega, 2) * parameters.MSleeper
+	ballast := 2 * omega * parameters.EBallast * parameters.WidthSleeper * alpha
+	k33 := ballast/tan_value + parameters.SoilStiffness
+	soil := ballast*parameters.SoilStiffness/tan_value - ballast*ballast/(cp*cp)
+
+	// det = (EI k⁴ + a) minor + kpad (b k33 + B ks/tan - B²/cp²)
+	minor := (rail_pad_complex_stiffness+b)*k33 + soil
+	return []float64{
+		parameters.EIRail * minor,
+		0,
+		a*minor + rail_pad_complex_stiffness*(b*k33+soil),
+	}
+}
+
 // SlabTrackStiffness computes the determinant of the track-soil system stiffness matrix
 // for a given angular frequency and wavenumber for slab track systems.
 //
@@ -146,17 +454,69 @@ func SlabTrackStiffness(parameters SlabTrackParameters, omega float64, wavenumbe
 	rail_pad_complex_stiffness := parameters.KRailPad
 
 	// stiffness matrix
-	k11 := parameters.EIRail*math.Pow(wavenumber, 4) + rail_pad_complex_stiffness - math.Pow(omega, 2)*parameters.MRail
-	k12 := -rail_pad_complex_stiffness
-	k22 := rail_pad_complex_stiffness + parameters.EISlab*math.Pow(wavenumber, 4) - math.Pow(omega, 2)*parameters.MSlab + parameters.SoilStiffness
-
-	stiffness := mat.NewDense(2, 2, []float64{
-		k11, k12,
-		k12, k22,
-	})
+	//	| kpad + a   -kpad   |
+	//	|  -kpad    kpad + b |
+	// with a = EI_rail k⁴ - ω² m_rail and b = EI_slab k⁴ - ω² m_slab + ks
+	a := parameters.EIRail*math.Pow(wavenumber, 4) - math.Pow(omega, 2)*parameters.MRail
+	b := parameters.EISlab*math.Pow(wavenumber, 4) - math.Pow(omega, 2)*parameters.MSlab + parameters.SoilStiffness
 
-	// Calculate the determinant of the stiffness matrix
-	det := mat.Det(stiffness)
+	// Determinant of the stiffness matrix, (kpad + a) (kpad + b) - kpad², expanded
+	// without the kpad² terms, which cancel to many digits at low frequencies; a + b is
+	// summed by term, as a and b nearly cancel at the roots
+	sum := (parameters.EIRail+parameters.EISlab)*math.Pow(wavenumber, 4) -
+		math.Pow(omega, 2)*(parameters.MRail+parameters.MSlab) + parameters.SoilStiffness
+	det := rail_pad_complex_stiffness*sum + a*b
 
 	return det
 }
+
+// SlabTrackReceptance computes the displacement of the rail of the slab track per
+// unit force of a load on the rail, for a given angular frequency and wavenumber.
+// The stiffness matrix is that of SlabTrackStiffness, with the soil spring replaced
+// by a complex dynamic stiffness.
+//
+// Parameters:
+//   - parameters: Physical parameters of the slab track system
+//   - omega: Angular frequency [rad/s]
+//   - wavenumber: Spatial frequency [1/m]
+//   - soilStiffness: Dynamic stiffness of the soil under the track [N/m²]
+//
+// Returns:
+//   - Displacement of the rail per unit force [m/N]
+func SlabTrackReceptance(parameters SlabTrackParameters, omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+	rail_pad_complex_stiffness := parameters.KRailPad
+
+	// Same terms as SlabTrackStiffness
+	k11 := complex(parameters.EIRail*math.Pow(wavenumber, 4)+rail_pad_complex_stiffness-math.Pow(omega, 2)*parameters.MRail, 0)
+	k12 := complex(-rail_pad_complex_stiffness, 0)
+	k22 := complex(rail_pad_complex_stiffness+parameters.EISlab*math.Pow(wavenumber, 4)-math.Pow(omega, 2)*parameters.MSlab, 0) + soilStiffness
+
+	return k22 / (k11*k22 - k12*k12)
+}
+
+// SlabTrackPolynomial computes the coefficients of the determinant of
+// SlabTrackStiffness as a polynomial in the squared wavenumber k². The rail and the
+// slab terms depend on k⁴, so the determinant is quartic in k².
+//
+// Parameters:
+//   - parameters: Physical parameters of the slab track system
+//   - omega: Angular frequency [rad/s]
+//
+// Returns:
+//   - Coefficients of the determinant in k², highest degree first
+func SlabTrackPolynomial(parameters SlabTrackParameters, omega float64) []float64 {
+	rail_pad_complex_stiffness := parameters.KRailPad
+
+	// Same terms as SlabTrackStiffness, without the EI k⁴ terms
+	a := -math.Pow(omega, 2) * parameters.MRail
+	b := -math.Pow(omega, 2)*parameters.MSlab + parameters.SoilStiffness
+
+	// det = kpad (EIrail k⁴ + a + EIslab k⁴ + b) + (EIrail k⁴ + a) (EIslab k⁴ + b)
+	return []float64{
+		parameters.EIRail * parameters.EISlab,
+		0,
+		parameters.EIRail*(rail_pad_complex_stiffness+b) + parameters.EISlab*(rail_pad_complex_stiffness+a),
+		0,
+		rail_pad_complex_stiffness*(-math.Pow(omega, 2)*(parameters.MRail+parameters.MSlab)+parameters.SoilStiffness) + a*b,
+	}
+}
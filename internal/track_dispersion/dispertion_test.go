@@ -1,8 +1,11 @@
 package track_dispersion
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/PlatypusBytes/GoTrain/pkg/utils"
+	"math"
+	"math/cmplx"
 	"os"
 	"testing"
 )
@@ -36,12 +39,13 @@ func TestBallastTrack(t *testing.T) {
 		t.Fatalf("Failed to unmarshal expected results: %v", err)
 	}
 
-	// Compare calculated results with expected results
+	// Compare calculated results with expected results, within the convergence of
+	// the root finder (1e-12 1/m on the wave number, about 6e-11 relative at 0.1 rad/s)
 	for i, v := range expected.Omega {
 		if v != omega[i] {
 			t.Errorf("Expected omega[%d] = %f, got %f", i, v, omega[i])
 		}
-		if expected.PhaseVelocity[i] != phaseVelocity[i] {
+		if math.Abs(expected.PhaseVelocity[i]-phaseVelocity[i]) > 1e-10*expected.PhaseVelocity[i] {
 			t.Errorf("Expected phase_velocity[%d] = %f, got %f", i, expected.PhaseVelocity[i], phaseVelocity[i])
 		}
 	}
@@ -73,12 +77,13 @@ func TestSlabTrack(t *testing.T) {
 		t.Fatalf("Failed to unmarshal expected results: %v", err)
 	}
 
-	// Compare calculated results with expected results
+	// Compare calculated results with expected results, within the convergence of
+	// the root finder (1e-12 1/m on the wave number, about 6e-11 relative at 0.1 rad/s)
 	for i, v := range expected.Omega {
 		if v != omega[i] {
 			t.Errorf("Expected omega[%d] = %f, got %f", i, v, omega[i])
 		}
-		if expected.PhaseVelocity[i] != phaseVelocity[i] {
+		if math.Abs(expected.PhaseVelocity[i]-phaseVelocity[i]) > 1e-10*expected.PhaseVelocity[i] {
 			t.Errorf("Expected phase_velocity[%d] = %f, got %f", i, expected.PhaseVelocity[i], phaseVelocity[i])
 		}
 	}
@@ -90,3 +95,293 @@ type DispersionResults struct {
 	Omega         []float64 `json:"omega"`
 	PhaseVelocity []float64 `json:"phase_velocity"`
 }
+
+// Test that the closed-form dispersion curves match the curves found by bracketing
+func TestRailTrackDispersionPolynomial(t *testing.T) {
+	tracks := map[string]TrackParameters{
+		"ballast": BallastTrackParameters{
+			EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, MSleeper: 490,
+			EBallast: 1.2e8, HBallast: 0.35, WidthSleeper: 1.25, RhoBallast: 1800.0, SoilStiffness: 0,
+		},
+		"slab": SlabTrackParameters{
+			EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490, SoilStiffness: 0,
+		},
+	}
+	omega := math_utils.Linspace(0.1, 250, 100)
+
+	for name, params := range tracks {
+		expected := RailTrackDispersion(params, omega)
+		phaseVelocity, err := RailTrackDispersionPolynomial(context.Background(), params, omega)
+		if err != nil {
+			t.Fatalf("%s: RailTrackDispersionPolynomial failed: %v", name, err)
+		}
+		for i := range omega {
+			if math.Abs(phaseVelocity[i]-expected[i]) > 1e-6*math.Max(1, expected[i]) {
+				t.Errorf("%s: expected phase_velocity[%d] = %f, got %f", name, i, expected[i], phaseVelocity[i])
+			}
+		}
+	}
+}
+
+// Test that the wave numbers continue the curve above the resonance of the rail on the
+// railpads, where a second mode propagates and the determinant no longer changes sign
+// over the bracket of the root finders: the closed form finds the largest wave number,
+// and the bracket is scanned for it.
+func TestRailTrackDispersionPolynomialModes(t *testing.T) {
+	params := SlabTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490, SoilStiffness: 1e8,
+	}
+	omega := []float64{2270, 2300}
+	bracketed := RailTrackDispersion(params, omega)
+	phaseVelocity, err := RailTrackDispersionPolynomial(context.Background(), params, omega)
+	if err != nil {
+		t.Fatalf("RailTrackDispersionPolynomial failed: %v", err)
+	}
+	for i := range omega {
+		if math.Abs(phaseVelocity[i]-bracketed[i]) > 1e-6*phaseVelocity[i] {
+			t.Errorf("expected phase velocity %f at omega %f, got %f by bracketing", phaseVelocity[i], omega[i], bracketed[i])
+		}
+	}
+	if !(phaseVelocity[1] > phaseVelocity[0]) || phaseVelocity[1]-phaseVelocity[0] > 10 {
+		t.Errorf("expected the curve to continue from %f m/s, got %f m/s", phaseVelocity[0], phaseVelocity[1])
+	}
+	if det := SlabTrackStiffness(params, omega[1], omega[1]/phaseVelocity[1]); math.Abs(det) > 1e-6*params.KRailPad*params.KRailPad {
+		t.Errorf("expected a root of the determinant, got %g", det)
+	}
+}
+
+// Test that a failed bracket is scanned and widened for a sign change, up to a cap.
+func TestExpandBracket(t *testing.T) {
+	// Root beyond the bracket
+	a, b, ok := expandBracket(func(k float64) float64 { return k - 5000 }, 0.001, 1000)
+	if !ok || !(a <= 5000 && 5000 <= b) {
+		t.Errorf("expected a bracket of 5000, got [%g, %g] (%v)", a, b, ok)
+	}
+	// Two roots within the bracket: the largest one is bracketed
+	a, b, ok = expandBracket(func(k float64) float64 { return (k - 2) * (k - 3) }, 0.001, 1000)
+	if !ok || !(a <= 3 && 3 <= b) || a <= 2 {
+		t.Errorf("expected a bracket of 3, got [%g, %g] (%v)", a, b, ok)
+	}
+	// No root up to the cap
+	if _, _, ok := expandBracket(func(k float64) float64 { return k - 1e8 }, 0.001, 1000); ok {
+		t.Error("expected no bracket beyond the widest bracket")
+	}
+}
+
+// Test that the wave numbers of all the modes of a track are found, including the
+// second mode above the resonance of the rail on the railpads, whose two roots leave
+// no sign change over the bracket of the root finders.
+func TestTrackWavenumbers(t *testing.T) {
+	params := SlabTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490, SoilStiffness: 1e8,
+	}
+	for _, omega := range []float64{500, 2300} {
+		var expected []float64
+		squared, err := math_utils.PolynomialRoots(params.DeterminantPolynomial(omega))
+		if err != nil {
+			t.Fatalf("PolynomialRoots failed: %v", err)
+		}
+		for _, k2 := range squared {
+			if k2 > 0 {
+				expected = append(expected, math.Sqrt(k2))
+			}
+		}
+
+		wavenumbers, err := TrackWavenumbers(params, omega, 4)
+		if err != nil {
+			t.Fatalf("TrackWavenumbers failed: %v", err)
+		}
+		if len(wavenumbers) != len(expected) {
+			t.Fatalf("expected the wave numbers %v at omega %f, got %v", expected, omega, wavenumbers)
+		}
+		for i, k := range expected {
+			if math.Abs(wavenumbers[i]-k) > 1e-8*k {
+				t.Errorf("expected the wave number %g at omega %f, got %g", k, omega, wavenumbers[i])
+			}
+		}
+	}
+	if _, err := TrackWavenumbers(params, 500, 0); err == nil {
+		t.Error("expected an error without modes to find")
+	}
+}
+
+// Test the complex wave numbers of damped tracks: without damping, the waves follow the
+// undamped curve without decay; with the damping of the railpads, they decay along the
+// track and solve the damped determinant.
+func TestRailTrackAttenuation(t *testing.T) {
+	ballast := BallastTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, MSleeper: 490,
+		EBallast: 1.2e8, HBallast: 0.35, WidthSleeper: 1.25, RhoBallast: 1800.0, SoilStiffness: 1e7,
+	}
+	slab := SlabTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490, SoilStiffness: 1e7,
+	}
+	omega := math_utils.Linspace(10, 250, 50)
+
+	// Without the damping of the railpads, the wave numbers are real
+	undampedBallast, undampedSlab := ballast, slab
+	undampedBallast.CRailPad, undampedSlab.CRailPad = 0, 0
+	cases := []struct {
+		params, undampedParams DampedTrack
+	}{
+		{ballast, undampedBallast},
+		{slab, undampedSlab},
+	}
+
+	for _, c := range cases {
+		params := c.params
+		undamped, err := RailTrackDispersionPolynomial(context.Background(), params.(TrackParameters), omega)
+		if err != nil {
+			t.Fatalf("RailTrackDispersionPolynomial failed: %v", err)
+		}
+
+		phaseVelocity, decayRate, err := RailTrackAttenuation(context.Background(), c.undampedParams.(TrackParameters), omega)
+		if err != nil {
+			t.Fatalf("RailTrackAttenuation failed: %v", err)
+		}
+		for i := range omega {
+			if math.Abs(phaseVelocity[i]-undamped[i]) > 1e-9*undamped[i] || decayRate[i] != 0 {
+				t.Errorf("%T: expected the undamped wave %f m/s without decay at omega %f, got %f m/s and %g 1/m",
+					params, undamped[i], omega[i], phaseVelocity[i], decayRate[i])
+			}
+		}
+
+		phaseVelocity, decayRate, err = RailTrackAttenuation(context.Background(), params.(TrackParameters), omega)
+		if err != nil {
+			t.Fatalf("RailTrackAttenuation failed: %v", err)
+		}
+		if undamped[len(omega)-1] == 0 {
+			t.Fatalf("%T: expected a wave at omega %f", params, omega[len(omega)-1])
+		}
+		for i, w := range omega {
+			if undamped[i] == 0 {
+				if phaseVelocity[i] != 0 || decayRate[i] != 0 {
+					t.Errorf("%T: expected no wave at omega %f, got %f m/s and %g 1/m", params, w, phaseVelocity[i], decayRate[i])
+				}
+				continue
+			}
+			if !(decayRate[i] > 0) || math.Abs(phaseVelocity[i]-undamped[i]) > 0.1*undamped[i] {
+				t.Errorf("%T: expected a decaying wave close to %f m/s at omega %f, got %f m/s and %g 1/m",
+					params, undamped[i], w, phaseVelocity[i], decayRate[i])
+			}
+			k := complex(w/phaseVelocity[i], -decayRate[i])
+			coefficients := params.DampedPolynomial(w)
+			det, scale := complex(0, 0), 0.0
+			for _, c := range coefficients {
+				det = det*k*k*k*k + c
+				scale = math.Max(scale, cmplx.Abs(c))
+			}
+			if cmplx.Abs(det) > 1e-6*scale {
+				t.Errorf("%T: expected a root of the damped determinant at omega %f, got %g", params, w, det)
+			}
+		}
+	}
+}
+
+// Test the equivalent modulus of a ballast layer reinforced by geogrids, with the
+// interlocking zones clipped to the layer and merged where they overlap.
+func TestBallastReinforcement(t *testing.T) {
+	params := BallastTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, MSleeper: 490,
+		EBallast: 1.2e8, HBallast: 0.35, WidthSleeper: 1.25, RhoBallast: 1800.0,
+	}
+	cases := []struct {
+		depths     []float64
+		reinforced float64
+	}{
+		{[]float64{0.2}, 0.15},        // Whole zone within the layer
+		{[]float64{0.3}, 0.125},       // Zone clipped by the formation
+		{[]float64{0.05}, 0.125},      // Zone clipped by the sleepers
+		{[]float64{0.25, 0.15}, 0.25}, // Overlapping zones
+	}
+	for _, c := range cases {
+		reinforcement := BallastReinforcement{Depths: c.depths, StiffnessFactor: 2, ZoneThickness: 0.15}
+		if thickness := reinforcement.ReinforcedThickness(params.HBallast); math.Abs(thickness-c.reinforced) > 1e-12 {
+			t.Errorf("geogrids at %v: expected reinforced thickness %g, got %g", c.depths, c.reinforced, thickness)
+		}
+		reinforced, err := params.Reinforce(reinforcement)
+		if err != nil {
+			t.Fatalf("Reinforce failed: %v", err)
+		}
+		fraction := c.reinforced / params.HBallast
+		if expected := params.EBallast / (1 - fraction/2); math.Abs(reinforced.EBallast-expected) > 1e-3 {
+			t.Errorf("geogrids at %v: expected E_ballast %g, got %g", c.depths, expected, reinforced.EBallast)
+		}
+	}
+
+	// The reinforced track is stiffer, so its phase velocities are higher
+	omega := math_utils.Linspace(10, 250, 5)
+	reinforced, _ := params.Reinforce(BallastReinforcement{Depths: []float64{0.2}, StiffnessFactor: 2, ZoneThickness: 0.15})
+	plain, stiff := RailTrackDispersion(params, omega), RailTrackDispersion(reinforced, omega)
+	for i := range omega {
+		if !(stiff[i] >= plain[i]) {
+			t.Errorf("omega %g: expected a higher phase velocity with geogrids, got %g (%g without)", omega[i], stiff[i], plain[i])
+		}
+	}
+
+	for _, invalid := range []BallastReinforcement{
+		{Depths: []float64{0.4}, StiffnessFactor: 2, ZoneThickness: 0.15},
+		{Depths: []float64{0.2}, StiffnessFactor: 0.5, ZoneThickness: 0.15},
+		{Depths: []float64{0.2}, StiffnessFactor: 2, ZoneThickness: 0},
+	} {
+		if _, err := params.Reinforce(invalid); err == nil {
+			t.Errorf("expected an error for reinforcement %+v", invalid)
+		}
+	}
+}
+
+// Test that the piles of a piled slab track act as a distributed spring under the slab,
+// which raises the phase velocities of the track.
+func TestPiledSlabTrack(t *testing.T) {
+	slab := SlabTrackParameters{
+		EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490, SoilStiffness: 0,
+	}
+	stiffness := PileRowStiffness(2, 30e9, 0.4, 12)
+	if expected := 2 * 30e9 * math.Pi * 0.04 / 12; math.Abs(stiffness-expected) > 1e-3 {
+		t.Errorf("expected pile row stiffness %g, got %g", expected, stiffness)
+	}
+	piled := PiledSlabTrackParameters{SlabTrackParameters: slab, PileStiffness: 9e6, PileSpacing: 3}
+	if support := piled.SupportStiffness(); support != 3e6 || piled.Slab().SoilStiffness != support {
+		t.Errorf("expected support stiffness 3e6, got %g (slab spring %g)", support, piled.Slab().SoilStiffness)
+	}
+
+	// Above the cut-off frequency sqrt(k / m) of the slab on the piles
+	omega := math_utils.Linspace(100, 300, 5)
+	plain, stiff := RailTrackDispersion(slab, omega), RailTrackDispersion(piled, omega)
+	for i := range omega {
+		if !(stiff[i] > plain[i]) {
+			t.Errorf("omega %g: expected a higher phase velocity with piles, got %g (%g without)", omega[i], stiff[i], plain[i])
+		}
+	}
+}
+
+// BenchmarkRailTrackDispersion measures the computation of the track dispersion
+// curves of the ballast and slab tracks of the tests at 100 frequencies, with the
+// root finder and with the closed-form polynomial solution.
+func BenchmarkRailTrackDispersion(b *testing.B) {
+	tracks := map[string]TrackParameters{
+		"ballast": BallastTrackParameters{
+			EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, MSleeper: 490,
+			EBallast: 1.2e8, HBallast: 0.35, WidthSleeper: 1.25, RhoBallast: 1800.0,
+		},
+		"slab": SlabTrackParameters{
+			EIRail: 1.29e7, MRail: 120, KRailPad: 5e8, CRailPad: 2.5e5, EISlab: 1.2e8, MSlab: 490,
+		},
+	}
+	omega := math_utils.Linspace(0.1, 250, 100)
+
+	for _, name := range []string{"ballast", "slab"} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				RailTrackDispersion(tracks[name], omega)
+			}
+		})
+		b.Run(name+"/polynomial", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				RailTrackDispersionPolynomial(context.Background(), tracks[name], omega)
+			}
+		})
+	}
+}
@@ -8,15 +8,16 @@
 //
 // # Supported Track Types
 //
-// The package supports two types of track systems:
+// The package supports three types of track systems:
 //
 //   - Ballast tracks: Modeled with rail, sleeper, railpad, ballast and soil
 //   - Slab tracks: Modeled with rail, slab, railpad, and soil
+//   - Piled slab tracks: Slab tracks supported by rows of piles through the soil
 //
 // # Track Parameters
 //
 // The TrackParameters interface defines the contract that track parameter structs
-// must implement. Two concrete implementations are provided:
+// must implement. Three concrete implementations are provided:
 //
 //   - BallastTrackParameters: Holds parameters for ballast track models including
 //     rail bending stiffness, rail mass, railpad properties, sleeper mass, ballast
@@ -26,12 +27,37 @@
 //     bending stiffness, rail mass, railpad properties, slab properties, and soil
 //     stiffness.
 //
+//   - PiledSlabTrackParameters: Extends SlabTrackParameters with the stiffness and
+//     spacing of the pile rows. The piles are smeared along the track into a
+//     distributed spring in parallel with the soil, which holds for wavelengths
+//     longer than the pile spacing; PileRowStiffness gives the stiffness of a row of
+//     end-bearing piles.
+//
+// # Ballast Reinforcement
+//
+// BallastReinforcement describes geogrids placed in the ballast layer, each
+// stiffening the ballast in an interlocking zone around it.
+// BallastTrackParameters.Reinforce replaces the Young's modulus of the ballast by the
+// equivalent modulus of the stiffened and unchanged parts of the layer in series, so
+// that the reinforcement depends on the placement of the geogrids within the layer.
+//
 // # Dispersion Calculation
 //
 // The TrackDispersion function calculates the phase velocity dispersion curve for
 // a railway track system using a numerical eigenvalue approach. It solves the
 // dynamic equilibrium equations for the track-soil system at each frequency to
-// determine the phase velocities.
+// determine the phase velocities. The bracketing root finders search the wave numbers
+// between 0.001 and 1000 1/m; when the determinant does not change sign over that
+// bracket, it is scanned and widened (up to 1e-6 to 1e6 1/m) for a sign change before
+// the phase velocity is left at zero.
+//
+// # Attenuation
+//
+// RailTrackAttenuation solves the damped track, with the railpad stiffness
+// KRailPad + iωCRailPad, for complex wave numbers k = κ - iα (see DampedTrack): it
+// returns the phase velocity ω/κ and the spatial decay rate α of the track waves, on
+// the branch of the undamped curve, which tells how far the waves travel along the
+// track.
 //
 // # Usage Example
 //
@@ -0,0 +1,65 @@
+package track_dispersion
+
+import "math"
+
+// PiledSlabTrackParameters holds the parameters of a slab track supported by rows of
+// piles through the soil, at a regular spacing along the track. The piles are stiff
+// supports in parallel with the soil under the slab; they are smeared along the track
+// into a distributed stiffness PileStiffness / PileSpacing, which holds for
+// wavelengths longer than the pile spacing.
+type PiledSlabTrackParameters struct {
+	SlabTrackParameters
+	PileStiffness float64 // Vertical stiffness of the head of a pile row [N/m]
+	PileSpacing   float64 // Distance between the pile rows along the track [m]
+}
+
+// PileRowStiffness returns the vertical stiffness of a row of end-bearing piles: the
+// axial stiffness n E A / L of the piles, with A the cross-section of a pile of
+// circular section.
+//
+// Parameters:
+//   - count: Number of piles in the row
+//   - youngsModulus: Young's modulus of the piles [Pa]
+//   - diameter: Diameter of the piles [m]
+//   - length: Length of the piles [m]
+//
+// Returns:
+//   - float64: The stiffness of the pile row [N/m]
+func PileRowStiffness(count int, youngsModulus, diameter, length float64) float64 {
+	return float64(count) * youngsModulus * math.Pi * diameter * diameter / 4 / length
+}
+
+// SupportStiffness returns the distributed stiffness of the piles under the slab.
+//
+// Returns:
+//   - float64: The stiffness of the piles per unit length of track [N/m²]
+func (p PiledSlabTrackParameters) SupportStiffness() float64 {
+	return p.PileStiffness / p.PileSpacing
+}
+
+// Slab returns the parameters of the equivalent slab track, whose soil spring
+// includes the distributed stiffness of the piles.
+//
+// Returns:
+//   - SlabTrackParameters: The parameters of the equivalent slab track
+func (p PiledSlabTrackParameters) Slab() SlabTrackParameters {
+	slab := p.SlabTrackParameters
+	slab.SoilStiffness += p.SupportStiffness()
+	return slab
+}
+
+// CalculateStiffness implements the TrackParameters interface for PiledSlabTrackParameters
+func (p PiledSlabTrackParameters) CalculateStiffness(omega float64, wavenumber float64) float64 {
+	return SlabTrackStiffness(p.Slab(), omega, wavenumber)
+}
+
+// DeterminantPolynomial implements the PolynomialTrack interface for PiledSlabTrackParameters
+func (p PiledSlabTrackParameters) DeterminantPolynomial(omega float64) []float64 {
+	return SlabTrackPolynomial(p.Slab(), omega)
+}
+
+// Receptance implements the ReceptanceTrack interface for PiledSlabTrackParameters.
+// The piles act in parallel with the dynamic stiffness of the soil.
+func (p PiledSlabTrackParameters) Receptance(omega float64, wavenumber float64, soilStiffness complex128) complex128 {
+	return SlabTrackReceptance(p.SlabTrackParameters, omega, wavenumber, soilStiffness+complex(p.SupportStiffness(), 0))
+}
@@ -0,0 +1,90 @@
+package track_dispersion
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// BallastReinforcement describes geogrids placed in the ballast layer. The aggregate
+// interlocks with a geogrid within a zone around it, in which the ballast is
+// stiffened; outside these zones the ballast is unchanged.
+type BallastReinforcement struct {
+	Depths          []float64 // Depths of the geogrids below the base of the sleepers [m]
+	StiffnessFactor float64   // Stiffness increase of the ballast in the interlocking zone of a geogrid
+	ZoneThickness   float64   // Thickness of the interlocking zone, centred on a geogrid [m]
+}
+
+// ReinforcedThickness returns the thickness of the ballast layer within the
+// interlocking zones of the geogrids. The zones are clipped to the ballast layer, so
+// that a geogrid close to the sleepers or to the formation reinforces less ballast,
+// and overlapping zones are counted once.
+//
+// Parameters:
+//   - hBallast: Ballast (layer) thickness [m]
+//
+// Returns:
+//   - float64: The reinforced thickness [m]
+func (r BallastReinforcement) ReinforcedThickness(hBallast float64) float64 {
+	depths := slices.Sorted(slices.Values(r.Depths))
+	thickness, covered := 0.0, 0.0
+	for _, depth := range depths {
+		top := math.Max(math.Max(depth-r.ZoneThickness/2, 0), covered)
+		bottom := math.Min(depth+r.ZoneThickness/2, hBallast)
+		if bottom > top {
+			thickness += bottom - top
+			covered = bottom
+		}
+	}
+	return thickness
+}
+
+// Factor returns the increase of the Young's modulus of the ballast layer by the
+// geogrids. The stiffened and unchanged parts of the layer act as springs in series,
+//
+//	1 / F = (1 - f) + f / F_z
+//
+// with f the reinforced fraction of the thickness and F_z the stiffness factor of
+// the interlocking zones.
+//
+// Parameters:
+//   - hBallast: Ballast (layer) thickness [m]
+//
+// Returns:
+//   - float64: The factor on the Young's modulus of the ballast
+//   - error: An error if a geogrid is not within the ballast layer, or the stiffness
+//     factor or zone thickness is invalid
+func (r BallastReinforcement) Factor(hBallast float64) (float64, error) {
+	if !(r.StiffnessFactor >= 1) {
+		return 0, fmt.Errorf("invalid ballast reinforcement: the stiffness factor must be at least 1, got %g", r.StiffnessFactor)
+	}
+	if !(r.ZoneThickness > 0) {
+		return 0, fmt.Errorf("invalid ballast reinforcement: the zone thickness must be positive, got %g", r.ZoneThickness)
+	}
+	for _, depth := range r.Depths {
+		if !(depth > 0 && depth < hBallast) {
+			return 0, fmt.Errorf("invalid ballast reinforcement: geogrid at %g m is not within the ballast layer of %g m", depth, hBallast)
+		}
+	}
+	fraction := r.ReinforcedThickness(hBallast) / hBallast
+	return 1 / (1 - fraction + fraction/r.StiffnessFactor), nil
+}
+
+// Reinforce returns the parameters of the ballast track with the geogrids of a
+// reinforcement, as the equivalent Young's modulus of the ballast layer (see
+// BallastReinforcement.Factor).
+//
+// Parameters:
+//   - reinforcement: The geogrids in the ballast layer
+//
+// Returns:
+//   - BallastTrackParameters: The parameters of the reinforced track
+//   - error: An error if the reinforcement is invalid
+func (p BallastTrackParameters) Reinforce(reinforcement BallastReinforcement) (BallastTrackParameters, error) {
+	factor, err := reinforcement.Factor(p.HBallast)
+	if err != nil {
+		return BallastTrackParameters{}, err
+	}
+	p.EBallast *= factor
+	return p, nil
+}
@@ -0,0 +1,165 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// FindRootsDeflation finds several roots of a function f in the interval [a, b] by
+// multi-start bracketing and deflation. The interval is sampled at the starting
+// points, and every sign change between two consecutive points is refined with
+// Brent's method, as in FindAllRoots. The roots that do not change the sign of f
+// between two points (pairs of close roots, roots where f touches zero) are then
+// searched with secant iterations from every starting point on the deflated function
+// f(x) / ∏(x - rᵢ) of the roots rᵢ found so far, which no longer vanishes at them, so
+// that every start converges to a new root. The deflation passes are repeated until a
+// pass finds no new root or maxRoots roots are found.
+//
+// The starting points are geometrically spaced for a positive interval spanning more
+// than a decade (e.g. wave numbers), and evenly spaced otherwise. A root found by the
+// secant iterations is kept only if |f| has a local minimum there, which rejects the
+// iterations that stall where f is flat.
+//
+// Parameters:
+//
+//	f        - function for which the roots are to be found
+//	a, b     - interval bounds (a < b)
+//	starts   - number of starting points (at least two)
+//	maxRoots - largest number of roots to find (at least one)
+//	tol      - tolerance of the roots (see Brent)
+//
+// Returns:
+//
+//	roots - the roots in increasing order (empty if none is found)
+//	error - an error if the inputs are invalid or a refinement fails
+func FindRootsDeflation(f func(float64) float64, a, b float64, starts, maxRoots int, tol float64) ([]float64, error) {
+	if !(a < b) {
+		return nil, fmt.Errorf("invalid interval: a must be smaller than b")
+	}
+	if starts < 2 || maxRoots < 1 {
+		return nil, fmt.Errorf("at least two starting points and one root are required")
+	}
+
+	x := Linspace(a, b, starts)
+	if a > 0 && b/a > 10 {
+		for i := range x {
+			x[i] = a * math.Pow(b/a, float64(i)/float64(starts-1))
+		}
+	}
+
+	// Multi-start bracketing of the sign changes
+	roots := []float64{}
+	x1, f1 := x[0], f(x[0])
+	for i := 1; i < len(x) && len(roots) < maxRoots; i++ {
+		x2, f2 := x[i], f(x[i])
+		switch {
+		case math.IsNaN(f1) || math.IsNaN(f2):
+		case f1 == 0:
+			roots = append(roots, x1)
+		case f1*f2 < 0:
+			root, err := Brent(f, x1, x2, tol)
+			if err != nil {
+				return nil, fmt.Errorf("refinement of root in [%g, %g] failed: %v", x1, x2, err)
+			}
+			roots = append(roots, root)
+		}
+		x1, f1 = x2, f2
+	}
+
+	// Deflation of the roots found so far
+	deflated := func(x float64) float64 {
+		value := f(x)
+		for _, r := range roots {
+			value /= x - r
+		}
+		return value
+	}
+	known := func(x float64) bool {
+		for _, r := range roots {
+			if math.Abs(x-r) <= 1e-6*math.Max(math.Abs(r), tol) {
+				return true
+			}
+		}
+		return false
+	}
+	for added := true; added && len(roots) < maxRoots; {
+		added = false
+		for _, x0 := range x {
+			root, ok := secantRoot(deflated, x0, a, b, tol)
+			if !ok || known(root) || !localMinimum(f, root) {
+				continue
+			}
+			roots = append(roots, root)
+			added = true
+			if len(roots) == maxRoots {
+				break
+			}
+		}
+	}
+
+	slices.Sort(roots)
+	return roots, nil
+}
+
+// secantRoot finds a root of f with secant iterations from a starting point, staying
+// within [a, b].
+//
+// Parameters:
+//
+//	f     - function for which the root is to be found
+//	x0    - starting point
+//	a, b  - interval the iterations must stay in
+//	tol   - absolute tolerance on the root (at least machine epsilon)
+//
+// Returns:
+//
+//	root - the root
+//	ok   - false if the iterations leave [a, b] or do not converge
+func secantRoot(f func(float64) float64, x0, a, b, tol float64) (float64, bool) {
+	eps := math.Nextafter(1.0, 2.0) - 1.0
+	tol = math.Max(tol, eps)
+
+	// The second point is a small step towards the inside of the interval
+	step := 1e-3 * math.Max(math.Abs(x0), tol)
+	if x0+step > b {
+		step = -step
+	}
+	x1 := x0 + step
+	f0, f1 := f(x0), f(x1)
+	for range 100 {
+		if f1 == 0 {
+			return x1, true
+		}
+		if f1 == f0 || math.IsNaN(f1) || math.IsInf(f1, 0) {
+			return 0, false
+		}
+		x2 := x1 - f1*(x1-x0)/(f1-f0)
+		if !(x2 >= a && x2 <= b) {
+			return 0, false
+		}
+		if math.Abs(x2-x1) <= tol+2*eps*math.Abs(x2) {
+			return x2, true
+		}
+		x0, f0 = x1, f1
+		x1, f1 = x2, f(x2)
+	}
+	return 0, false
+}
+
+// localMinimum reports whether |f| has a local minimum at x, i.e. is not larger than
+// at two points close to it on either side.
+//
+// Parameters:
+//
+//	f - the function
+//	x - the point
+//
+// Returns:
+//
+//	bool - true if |f(x)| is not larger than |f| at x ± 1e-6 |x|
+func localMinimum(f func(float64) float64, x float64) bool {
+	h := 1e-6 * math.Max(math.Abs(x), 1e-12)
+	value := math.Abs(f(x))
+	return value <= math.Abs(f(x-h)) && value <= math.Abs(f(x+h))
+}
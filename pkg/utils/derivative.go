@@ -0,0 +1,150 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// CentralDifference approximates the derivative of f at x with the second-order
+// central difference (f(x+h) - f(x-h)) / 2h.
+//
+// Parameters:
+//
+//	f - function to differentiate
+//	x - point at which the derivative is computed
+//	h - step (> 0)
+//
+// Returns:
+//
+//	derivative - the approximate derivative f'(x)
+func CentralDifference(f func(float64) float64, x, h float64) float64 {
+	return (f(x+h) - f(x-h)) / (2 * h)
+}
+
+// ForwardDifference approximates the derivative of f at x with the second-order
+// one-sided difference (-3f(x) + 4f(x+h) - f(x+2h)) / 2h, which only evaluates f
+// at and above x (e.g. at the lower bound of a frequency range).
+//
+// Parameters:
+//
+//	f - function to differentiate
+//	x - point at which the derivative is computed
+//	h - step (> 0)
+//
+// Returns:
+//
+//	derivative - the approximate derivative f'(x)
+func ForwardDifference(f func(float64) float64, x, h float64) float64 {
+	return (-3*f(x) + 4*f(x+h) - f(x+2*h)) / (2 * h)
+}
+
+// BackwardDifference approximates the derivative of f at x with the second-order
+// one-sided difference (3f(x) - 4f(x-h) + f(x-2h)) / 2h, which only evaluates f
+// at and below x (e.g. at the upper bound of a frequency range).
+//
+// Parameters:
+//
+//	f - function to differentiate
+//	x - point at which the derivative is computed
+//	h - step (> 0)
+//
+// Returns:
+//
+//	derivative - the approximate derivative f'(x)
+func BackwardDifference(f func(float64) float64, x, h float64) float64 {
+	return (3*f(x) - 4*f(x-h) + f(x-2*h)) / (2 * h)
+}
+
+// RichardsonDerivative approximates the derivative of f at x by Richardson
+// extrapolation of central differences: the step is halved at every level and the
+// truncation error of the differences is eliminated order by order.
+//
+// Parameters:
+//
+//	f      - function to differentiate
+//	x      - point at which the derivative is computed
+//	h      - initial step (> 0)
+//	levels - number of step halvings (>= 1); each level removes one more error term
+//
+// Returns:
+//
+//	derivative - the extrapolated derivative f'(x)
+//	errorEst   - estimate of the error: difference with the previous extrapolation level
+//	error      - an error if the inputs are invalid
+func RichardsonDerivative(f func(float64) float64, x, h float64, levels int) (float64, float64, error) {
+	if !(h > 0) {
+		return 0, 0, fmt.Errorf("step must be positive")
+	}
+	if levels < 1 {
+		return 0, 0, fmt.Errorf("number of levels must be at least 1")
+	}
+
+	// Extrapolation tableau, one row per step: row[j] is extrapolated j times
+	row := []float64{CentralDifference(f, x, h)}
+	var previous []float64
+	for i := 1; i <= levels; i++ {
+		previous = row
+		h /= 2
+		row = make([]float64, i+1)
+		row[0] = CentralDifference(f, x, h)
+		factor := 1.0
+		for j := 1; j <= i; j++ {
+			// The error of central differences only has even powers of h
+			factor *= 4
+			row[j] = row[j-1] + (row[j-1]-previous[j-1])/(factor-1)
+		}
+	}
+	return row[levels], math.Abs(row[levels] - previous[levels-1]), nil
+}
+
+// Gradient computes the derivative of a sampled curve y(x) at every sample, with
+// second-order central differences inside (exact for quadratic data, also on
+// unevenly spaced samples) and second-order one-sided differences at the ends,
+// like numpy.gradient with edge_order=2. Two samples give the slope of the line.
+//
+// Parameters:
+//
+//	x - sample points, strictly increasing (at least two points)
+//	y - sample values (same length as x)
+//
+// Returns:
+//
+//	dydx  - the derivative at every sample
+//	error - an error if the samples are invalid
+func Gradient(x []float64, y []float64) ([]float64, error) {
+	if len(x) < 2 {
+		return nil, fmt.Errorf("input arrays must have at least two elements")
+	}
+	if len(y) != len(x) {
+		return nil, fmt.Errorf("all input arrays must have the same length")
+	}
+	for i := 1; i < len(x); i++ {
+		if !(x[i] > x[i-1]) {
+			return nil, fmt.Errorf("x must be strictly increasing (sample %d)", i)
+		}
+	}
+
+	n := len(x)
+	dydx := make([]float64, n)
+	if n == 2 {
+		slope := (y[1] - y[0]) / (x[1] - x[0])
+		dydx[0], dydx[1] = slope, slope
+		return dydx, nil
+	}
+
+	// Derivative at x[k] of the parabola through the samples i, j, k
+	parabola := func(i, j, k int) float64 {
+		return y[i]*(x[k]-x[j])/((x[i]-x[j])*(x[i]-x[k])) +
+			y[j]*(x[k]-x[i])/((x[j]-x[i])*(x[j]-x[k])) +
+			y[k]*(2*x[k]-x[i]-x[j])/((x[k]-x[i])*(x[k]-x[j]))
+	}
+
+	for k := 1; k < n-1; k++ {
+		h1 := x[k] - x[k-1]
+		h2 := x[k+1] - x[k]
+		dydx[k] = (h1*h1*y[k+1] - h2*h2*y[k-1] + (h2*h2-h1*h1)*y[k]) / (h1 * h2 * (h1 + h2))
+	}
+	dydx[0] = parabola(1, 2, 0)
+	dydx[n-1] = parabola(n-3, n-2, n-1)
+	return dydx, nil
+}
@@ -2,9 +2,17 @@
 // used throughout the GoTrain project.
 //
 // The package implements various numerical methods including:
-//   - Root finding algorithms (Brent's method)
+//   - Root finding algorithms (Brent's, Ridders' and Chandrupatla's methods, scan for all roots of an interval,
+//     multi-start search with deflation)
 //   - Linear space generator (similar to numpy's linspace)
 //   - Line intersection calculations
+//   - Monotonicity-preserving (PCHIP) interpolation
+//   - 1-D interpolator with configurable method and extrapolation
+//   - Numerical differentiation of functions and sampled curves
+//   - Savitzky–Golay smoothing of noisy curves
+//   - NaN-aware slice helpers
+//   - Closed-form real roots of polynomials up to quartic
+//   - Bounded parallel evaluation of a function over a slice
 //
 // These utilities support the core computational needs of the dispersion analysis
 // and other mathematical operations required for railway modeling.
@@ -13,15 +21,94 @@
 //
 // The Brent function implements Brent's method for root finding, which combines
 // the bisection method, secant method, and inverse quadratic interpolation to
-// efficiently find roots of continuous functions.
+// efficiently find roots of continuous functions. BrentWithOptions takes the
+// iteration limit and the absolute and relative tolerances as a BrentOptions struct,
+// and returns BrentStats (iterations, final bracket width) to tune and log solvers.
+//
+// # Ridders' Method
+//
+// The Ridders function is a drop-in alternative to Brent: each iteration fits an
+// exponential through the bracket and its midpoint, keeping the root bracketed and
+// at least halving the bracket, so it converges where Brent's interpolation steps stall.
+//
+// # Chandrupatla's Method
+//
+// The Chandrupatla function is another drop-in alternative to Brent: it evaluates f
+// once per iteration, at an inverse quadratic interpolation whenever a simple test on
+// the last three points shows it is valid, and at the midpoint otherwise. It converges
+// in fewer evaluations than Brent's method on smooth functions such as the dispersion
+// relations. RootFinderByName selects any of the methods by name ("brent", "ridders"
+// or "chandrupatla"), as done by the solver options of the configuration.
+//
+// # Polynomial Roots
+//
+// PolynomialRoots finds the real roots of polynomials of degree up to four in closed
+// form (SolveQuadratic, SolveCubic and SolveQuartic), for dispersion relations that
+// reduce to polynomials in the squared wavenumber, such as the track models. It gives
+// exact roots without bracketing.
+//
+// # Finding All Roots
+//
+// The FindAllRoots function scans an interval with a given resolution, brackets
+// every sign change and refines each bracket with Brent's method, returning all
+// roots of the interval (e.g. for multi-mode dispersion curves).
+//
+// FindRootsDeflation adds deflation to the multi-start bracketing: secant iterations
+// from every starting point on f divided by the roots found so far find the roots
+// that do not change the sign of f between the samples, such as pairs of close roots
+// where branches cross, up to a maximum number of roots.
 //
 // # Linear Space Generation
 //
 // The Linspace function generates evenly spaced values over a specified interval,
 // similar to NumPy's linspace function, useful for frequency and wavenumber arrays.
 //
+// # PCHIP Interpolation
+//
+// NewPCHIP creates a piecewise cubic Hermite interpolator that preserves the
+// monotonicity of the data, and PchipInterpolate resamples a curve with it. It is
+// suited to resampling soil dispersion curves: cubic splines overshoot near their
+// steep low-frequency knee.
+//
+// # Interp1D
+//
+// NewInterp1D creates a reusable interpolator of a sampled curve, linear, natural
+// cubic spline or PCHIP, which clamps, extrapolates or returns an error outside the
+// samples. Features resampling curves should use it, so that they behave alike.
+//
+// # Numerical Differentiation
+//
+// CentralDifference, ForwardDifference and BackwardDifference approximate the
+// derivative of a function with second-order finite differences, and
+// RichardsonDerivative extrapolates central differences to higher order. Gradient
+// differentiates a sampled curve (e.g. a dispersion curve, for group velocities).
+//
+// # Smoothing
+//
+// The SavitzkyGolay function smooths noisy, evenly spaced samples (e.g. measured
+// dispersion curves) with a least-squares polynomial over a sliding window of
+// configurable length and order, preserving peaks better than a moving average.
+//
+// # NaN Gaps
+//
+// Dispersion curves contain NaN where no root is found. NaNIndices, CountNaN and
+// NaNRuns locate these gaps, TrimNaN strips the leading and trailing ones, and
+// InterpolateNaN fills the interior ones by linear interpolation.
+//
+// # Parallel Map
+//
+// The ParallelMap function evaluates an expensive function (e.g. a dispersion relation
+// at every frequency) over a slice with a bounded number of goroutines, keeping the
+// order of the results. The dispersion modules use it to process their frequencies.
+// Its extra goroutines take tokens of a process-wide concurrency budget (see
+// SharedBudget and Budget), which the batch runner shares with its workers, so that
+// concurrent jobs and their frequency loops never use more than GOMAXPROCS cores
+// together.
+//
 // # Line Intersection
 //
 // The LineIntersection function computes the intersection point of two line segments,
 // used in critical speed calculations where dispersion curves intersect.
+// InterceptLines returns the first intersection of two sampled curves, and
+// InterceptLinesAll returns all of them.
 package math_utils
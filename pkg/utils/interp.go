@@ -0,0 +1,199 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Interpolation methods supported by NewInterp1D.
+const (
+	InterpLinear = "linear" // Piecewise linear
+	InterpSpline = "spline" // Natural cubic spline
+	InterpPCHIP  = "pchip"  // Monotonicity-preserving cubic (see PCHIP)
+)
+
+// Extrapolation modes supported by NewInterp1D, for points outside the samples.
+const (
+	ExtrapolateClamp  = "clamp"       // Value of the nearest end sample
+	ExtrapolateExtend = "extrapolate" // Extension of the end piece of the interpolant
+	ExtrapolateError  = "error"       // Return an error
+)
+
+// Interp1D is a 1-D interpolator of a sampled curve y(x), with a configurable
+// interpolation method and behaviour outside the samples, so that every feature
+// resampling curves does it the same way.
+type Interp1D struct {
+	x      []float64 // Sample points, strictly increasing
+	y      []float64 // Sample values
+	method string    // Interpolation method (InterpLinear, InterpSpline or InterpPCHIP)
+	mode   string    // Extrapolation mode (ExtrapolateClamp, ExtrapolateExtend or ExtrapolateError)
+	pchip  *PCHIP    // Interpolant of InterpPCHIP
+	m      []float64 // Second derivatives at the samples, for InterpSpline
+}
+
+// NewInterp1D creates an interpolator through the samples (x, y).
+//
+// Parameters:
+//
+//	x      - sample points, strictly increasing (at least two points)
+//	y      - sample values, finite (same length as x)
+//	method - InterpLinear, InterpSpline or InterpPCHIP (empty means InterpLinear)
+//	mode   - ExtrapolateClamp, ExtrapolateExtend or ExtrapolateError (empty means ExtrapolateError)
+//
+// Returns:
+//
+//	interpolator - the interpolator
+//	error        - an error if the samples, the method or the mode are invalid
+func NewInterp1D(x []float64, y []float64, method string, mode string) (*Interp1D, error) {
+	if method == "" {
+		method = InterpLinear
+	}
+	if mode == "" {
+		mode = ExtrapolateError
+	}
+	if mode != ExtrapolateClamp && mode != ExtrapolateExtend && mode != ExtrapolateError {
+		return nil, fmt.Errorf("invalid extrapolation mode: %s. Supported modes are '%s', '%s' or '%s'",
+			mode, ExtrapolateClamp, ExtrapolateExtend, ExtrapolateError)
+	}
+
+	// The PCHIP constructor validates the samples for all methods
+	pchip, err := NewPCHIP(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	interp := &Interp1D{x: x, y: y, method: method, mode: mode}
+	switch method {
+	case InterpLinear:
+	case InterpPCHIP:
+		interp.pchip = pchip
+	case InterpSpline:
+		interp.m = naturalSplineSecondDerivatives(x, y)
+	default:
+		return nil, fmt.Errorf("invalid interpolation method: %s. Supported methods are '%s', '%s' or '%s'",
+			method, InterpLinear, InterpSpline, InterpPCHIP)
+	}
+	return interp, nil
+}
+
+// naturalSplineSecondDerivatives computes the second derivatives at the samples of
+// the natural cubic spline (zero second derivative at the ends) through (x, y),
+// solving the tridiagonal system of the spline with the Thomas algorithm.
+//
+// Parameters:
+//
+//	x - sample points, strictly increasing (at least two points)
+//	y - sample values (same length as x)
+//
+// Returns:
+//
+//	m - the second derivatives at the samples
+func naturalSplineSecondDerivatives(x []float64, y []float64) []float64 {
+	n := len(x)
+	m := make([]float64, n)
+	if n < 3 {
+		return m
+	}
+
+	// Unknowns m[1..n-2]; forward elimination
+	c := make([]float64, n) // Modified super-diagonal
+	d := make([]float64, n) // Modified right-hand side
+	for i := 1; i < n-1; i++ {
+		h0 := x[i] - x[i-1]
+		h1 := x[i+1] - x[i]
+		rhs := 6 * ((y[i+1]-y[i])/h1 - (y[i]-y[i-1])/h0)
+		diagonal := 2 * (h0 + h1)
+		if i > 1 {
+			diagonal -= h0 * c[i-1]
+			rhs -= h0 * d[i-1]
+		}
+		c[i] = h1 / diagonal
+		d[i] = rhs / diagonal
+	}
+
+	// Back substitution
+	m[n-2] = d[n-2]
+	for i := n - 3; i >= 1; i-- {
+		m[i] = d[i] - c[i]*m[i+1]
+	}
+	return m
+}
+
+// At evaluates the interpolant at xi.
+//
+// Parameters:
+//
+//	xi - point at which the interpolant is evaluated
+//
+// Returns:
+//
+//	yi    - the interpolated value
+//	error - an error if xi is outside the samples with ExtrapolateError, or is NaN
+func (interp *Interp1D) At(xi float64) (float64, error) {
+	n := len(interp.x)
+	if math.IsNaN(xi) {
+		return 0, fmt.Errorf("cannot interpolate at NaN")
+	}
+	if xi < interp.x[0] || xi > interp.x[n-1] {
+		switch interp.mode {
+		case ExtrapolateClamp:
+			if xi < interp.x[0] {
+				return interp.y[0], nil
+			}
+			return interp.y[n-1], nil
+		case ExtrapolateError:
+			return 0, fmt.Errorf("%g is outside the interpolation range [%g, %g]", xi, interp.x[0], interp.x[n-1])
+		}
+	}
+
+	if interp.method == InterpPCHIP {
+		return interp.pchip.At(xi), nil
+	}
+
+	// Interval [x[k], x[k+1]] containing xi (the end intervals extend outwards)
+	k := sort.SearchFloat64s(interp.x, xi) - 1
+	k = max(0, min(k, n-2))
+	x0, x1 := interp.x[k], interp.x[k+1]
+	y0, y1 := interp.y[k], interp.y[k+1]
+	h := x1 - x0
+
+	if interp.method == InterpSpline {
+		// The natural spline extends linearly: its second derivative is zero at the ends
+		m0, m1 := interp.m[k], interp.m[k+1]
+		if xi < x0 {
+			slope := (y1-y0)/h - h*(2*m0+m1)/6
+			return y0 + slope*(xi-x0), nil
+		}
+		if xi > x1 {
+			slope := (y1-y0)/h + h*(m0+2*m1)/6
+			return y1 + slope*(xi-x1), nil
+		}
+		a := (x1 - xi) / h
+		b := (xi - x0) / h
+		return a*y0 + b*y1 + ((a*a*a-a)*m0+(b*b*b-b)*m1)*h*h/6, nil
+	}
+
+	return y0 + (y1-y0)*(xi-x0)/h, nil
+}
+
+// Resample evaluates the interpolant at every point of xi.
+//
+// Parameters:
+//
+//	xi - points at which the interpolant is evaluated
+//
+// Returns:
+//
+//	yi    - the interpolated values
+//	error - an error if a point cannot be interpolated (see At)
+func (interp *Interp1D) Resample(xi []float64) ([]float64, error) {
+	yi := make([]float64, len(xi))
+	for i, v := range xi {
+		var err error
+		if yi[i], err = interp.At(v); err != nil {
+			return nil, err
+		}
+	}
+	return yi, nil
+}
@@ -23,13 +23,57 @@ import (
 //	root  - the estimated root
 //	error - an error if convergence fails or inputs are invalid
 func Brent(f func(float64) float64, a, b, tol float64) (float64, error) {
+	root, _, err := BrentWithOptions(f, a, b, BrentOptions{AbsTol: tol})
+	return root, err
+}
+
+// BrentOptions controls the convergence of BrentWithOptions.
+// Zero values select the defaults of Brent.
+type BrentOptions struct {
+	MaxIterations int     // Maximum number of iterations (default 1000)
+	AbsTol        float64 // Absolute tolerance on the root (at least machine epsilon)
+	RelTol        float64 // Tolerance relative to the root (default twice machine epsilon)
+}
+
+// BrentStats reports how BrentWithOptions converged, e.g. to log or tune solvers.
+type BrentStats struct {
+	Iterations   int     // Number of iterations performed
+	BracketWidth float64 // Width of the final bracket around the root
+}
+
+// BrentWithOptions finds a root of a function f in the interval [a, b] using Brent's
+// method, like Brent, with configurable iteration limit and tolerances. The iteration
+// stops when half the bracket is smaller than RelTol*|root| + AbsTol.
+//
+// Parameters:
+//
+//	f     - function for which the root is to be found
+//	a, b  - interval bounds (must bracket a root, i.e., f(a)*f(b) < 0)
+//	opts  - iteration limit and tolerances
+//
+// Returns:
+//
+//	root  - the estimated root
+//	stats - the number of iterations and the final bracket width (also on failure)
+//	error - an error if convergence fails or inputs are invalid
+func BrentWithOptions(f func(float64) float64, a, b float64, opts BrentOptions) (float64, BrentStats, error) {
 	// Maximum number of iterations
 	max_nb_iterations := 1000
+	if opts.MaxIterations > 0 {
+		max_nb_iterations = opts.MaxIterations
+	}
 
 	eps := math.Nextafter(1.0, 2.0) - 1.0
+	tol := opts.AbsTol
 	if tol < eps {
 		tol = eps
 	}
+	rel_tol := 2 * eps
+	if opts.RelTol > 0 {
+		rel_tol = opts.RelTol
+	}
+
+	var stats BrentStats
 
 	// Function evaluations at interval endpoints
 	fa := f(a)
@@ -37,15 +81,15 @@ func Brent(f func(float64) float64, a, b, tol float64) (float64, error) {
 
 	// Check if the interval brackets a root
 	if fa*fb >= 0 {
-		return 0, fmt.Errorf("root not bracketed: f(a) and f(b) must have opposite signs")
+		return 0, stats, fmt.Errorf("root not bracketed: f(a) and f(b) must have opposite signs")
 	}
 
 	// If one of the endpoints is the root, return it immediately
 	if fa == 0 {
-		return a, nil
+		return a, stats, nil
 	}
 	if fb == 0 {
-		return b, nil
+		return b, stats, nil
 	}
 
 	// Make sure that b is the point with the smaller function value
@@ -63,12 +107,14 @@ func Brent(f func(float64) float64, a, b, tol float64) (float64, error) {
 	// Main iteration loop
 	for iter := 0; iter < max_nb_iterations; iter++ {
 		// Convergence check
-		delta := 2*eps*math.Abs(b) + tol
+		delta := rel_tol*math.Abs(b) + tol
 		m := 0.5 * (c - b)
+		stats.Iterations = iter
+		stats.BracketWidth = math.Abs(c - b)
 
 		// Check if we've converged
 		if math.Abs(m) <= delta || fb == 0 {
-			return b, nil // Converged to the root
+			return b, stats, nil // Converged to the root
 		}
 
 		// Decide which method to use
@@ -137,7 +183,9 @@ func Brent(f func(float64) float64, a, b, tol float64) (float64, error) {
 		}
 	}
 
-	return 0, fmt.Errorf("max iterations %d reached", max_nb_iterations)
+	stats.Iterations = max_nb_iterations
+	stats.BracketWidth = math.Abs(c - b)
+	return 0, stats, fmt.Errorf("max iterations %d reached", max_nb_iterations)
 }
 
 // Linspace returns an array of n-evenly spaced values over the interval [start, end].
@@ -243,3 +291,57 @@ func InterceptLines(x []float64, y1 []float64, y2 []float64) (float64, float64,
 
 	return 0, 0, fmt.Errorf("no intersection found")
 }
+
+// InterceptLinesAll calculates every intersection point of two lines defined by
+// their x-coordinates and y-coordinates, in increasing order of the samples.
+// A crossing between two samples is located by linear interpolation, as in
+// InterceptLines; a sample where the lines touch counts as one intersection.
+// Samples where either line is NaN are skipped.
+//
+// Parameters:
+//
+//	x   - x-coordinates of the line (must have at least two points)
+//	y1  - y-coordinates of the first line (must have at least two points)
+//	y2  - y-coordinates of the second line (must have at least two points)
+//
+// Returns:
+//
+//	interceptX - x-coordinates of the intersection points (empty if the lines do not intersect)
+//	interceptY - y-coordinates of the intersection points
+//	error      - an error if the input is invalid
+func InterceptLinesAll(x []float64, y1 []float64, y2 []float64) ([]float64, []float64, error) {
+
+	// Check that input arrays have at least two elements
+	if len(x) < 2 || len(y1) < 2 || len(y2) < 2 {
+		return nil, nil, fmt.Errorf("input arrays must have at least two elements")
+	}
+
+	// Check that arrays have the same length
+	if len(y1) != len(x) || len(y2) != len(x) {
+		return nil, nil, fmt.Errorf("all input arrays must have the same length")
+	}
+
+	interceptX := []float64{}
+	interceptY := []float64{}
+	for i := range x {
+		diff1 := y1[i] - y2[i]
+
+		// Sign change between the previous point and this one
+		if i > 0 {
+			diff2 := y1[i-1] - y2[i-1]
+			if diff1*diff2 < 0 {
+				fraction := math.Abs(diff2) / (math.Abs(diff1) + math.Abs(diff2))
+				interceptX = append(interceptX, x[i-1]+fraction*(x[i]-x[i-1]))
+				interceptY = append(interceptY, y1[i-1]+fraction*(y1[i]-y1[i-1]))
+			}
+		}
+
+		// Exact match at this point
+		if diff1 == 0 {
+			interceptX = append(interceptX, x[i])
+			interceptY = append(interceptY, y1[i])
+		}
+	}
+
+	return interceptX, interceptY, nil
+}
@@ -0,0 +1,135 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// NaNRun is a run of consecutive NaN values in a slice: xs[Start:End] are NaN.
+type NaNRun struct {
+	Start int // Index of the first NaN of the run
+	End   int // Index after the last NaN of the run
+}
+
+// NaNIndices returns the indices of the NaN values of a slice.
+//
+// Parameters:
+//
+//	xs - the values
+//
+// Returns:
+//
+//	indices - indices of the NaN values, in increasing order
+func NaNIndices(xs []float64) []int {
+	indices := []int{}
+	for i, v := range xs {
+		if math.IsNaN(v) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// CountNaN returns the number of NaN values of a slice.
+//
+// Parameters:
+//
+//	xs - the values
+//
+// Returns:
+//
+//	count - number of NaN values
+func CountNaN(xs []float64) int {
+	count := 0
+	for _, v := range xs {
+		if math.IsNaN(v) {
+			count++
+		}
+	}
+	return count
+}
+
+// NaNRuns returns the runs of consecutive NaN values of a slice, e.g. the gaps of
+// a dispersion curve where no root was found.
+//
+// Parameters:
+//
+//	xs - the values
+//
+// Returns:
+//
+//	runs - the runs of NaN values, in increasing order
+func NaNRuns(xs []float64) []NaNRun {
+	runs := []NaNRun{}
+	for i := 0; i < len(xs); i++ {
+		if !math.IsNaN(xs[i]) {
+			continue
+		}
+		start := i
+		for i < len(xs) && math.IsNaN(xs[i]) {
+			i++
+		}
+		runs = append(runs, NaNRun{Start: start, End: i})
+	}
+	return runs
+}
+
+// TrimNaN returns the bounds of a slice without its leading and trailing NaN
+// values: xs[start:end] starts and ends with a number. Both bounds are zero if
+// every value is NaN.
+//
+// Parameters:
+//
+//	xs - the values
+//
+// Returns:
+//
+//	start - index of the first number
+//	end   - index after the last number
+func TrimNaN(xs []float64) (int, int) {
+	start := 0
+	for start < len(xs) && math.IsNaN(xs[start]) {
+		start++
+	}
+	if start == len(xs) {
+		return 0, 0
+	}
+	end := len(xs)
+	for math.IsNaN(xs[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+// InterpolateNaN fills the interior NaN runs of a sampled curve y(x) by linear
+// interpolation between the numbers around each run. Leading and trailing NaN
+// values, which have a single neighbour, are left unchanged (see TrimNaN).
+//
+// Parameters:
+//
+//	x - sample points, increasing
+//	y - sample values, with NaN gaps (same length as x)
+//
+// Returns:
+//
+//	filled - a copy of y with its interior gaps filled
+//	error  - an error if the arrays have different lengths
+func InterpolateNaN(x []float64, y []float64) ([]float64, error) {
+	if len(y) != len(x) {
+		return nil, fmt.Errorf("all input arrays must have the same length")
+	}
+
+	filled := make([]float64, len(y))
+	copy(filled, y)
+	for _, run := range NaNRuns(y) {
+		if run.Start == 0 || run.End == len(y) {
+			continue
+		}
+		x1, y1 := x[run.Start-1], y[run.Start-1]
+		x2, y2 := x[run.End], y[run.End]
+		for i := run.Start; i < run.End; i++ {
+			filled[i] = y1 + (y2-y1)*(x[i]-x1)/(x2-x1)
+		}
+	}
+	return filled, nil
+}
@@ -0,0 +1,126 @@
+package math_utils
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Budget is a concurrency budget: a number of tokens shared by goroutines doing
+// CPU-bound work, so that nested levels of parallelism (e.g. jobs run concurrently,
+// each evaluating its frequencies concurrently) cooperate instead of multiplying.
+// It is safe for concurrent use.
+type Budget struct {
+	tokens chan struct{}
+}
+
+// NewBudget creates a concurrency budget.
+//
+// Parameters:
+//
+//	size - number of tokens (<= 0 means runtime.GOMAXPROCS(0))
+//
+// Returns:
+//
+//	b - the budget, with all its tokens available
+func NewBudget(size int) *Budget {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	return &Budget{tokens: make(chan struct{}, size)}
+}
+
+// sharedBudget is the process-wide budget used by ParallelMap.
+var sharedBudget = NewBudget(0)
+
+// SharedBudget returns the process-wide concurrency budget, of runtime.GOMAXPROCS(0)
+// tokens at start-up, from which ParallelMap takes its extra goroutines. Callers
+// running CPU-bound work concurrently (e.g. the workers of a batch) hold a token
+// per goroutine, so that the inner parallelism only uses the remaining cores.
+//
+// Returns:
+//
+//	b - the shared budget
+func SharedBudget() *Budget {
+	return sharedBudget
+}
+
+// Size returns the number of tokens of the budget.
+func (b *Budget) Size() int {
+	return cap(b.tokens)
+}
+
+// Acquire takes a token, waiting until one is available.
+func (b *Budget) Acquire() {
+	b.tokens <- struct{}{}
+}
+
+// TryAcquire takes a token if one is available, without waiting.
+//
+// Returns:
+//
+//	ok - true if a token was taken
+func (b *Budget) TryAcquire() bool {
+	select {
+	case b.tokens <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release returns a token taken with Acquire or TryAcquire.
+func (b *Budget) Release() {
+	<-b.tokens
+}
+
+// ParallelMap evaluates f for every element of xs concurrently, with at most
+// workers goroutines, and returns the results in the order of xs.
+// f must be safe for concurrent use; it is called exactly once per element.
+//
+// The calling goroutine evaluates elements too; every additional goroutine takes a
+// token of the shared budget (see SharedBudget) when ParallelMap starts, and is not
+// started when none is available. Concurrent and nested calls therefore never run
+// more goroutines than the budget allows in total, and fall back to evaluating in
+// the calling goroutine when the cores are busy.
+//
+// Parameters:
+//
+//	f       - function to evaluate
+//	xs      - elements at which f is evaluated
+//	workers - maximum number of concurrent evaluations (<= 0 means runtime.GOMAXPROCS(0))
+//
+// Returns:
+//
+//	ys - ys[i] = f(xs[i])
+func ParallelMap[T, R any](f func(T) R, xs []T, workers int) []R {
+	ys := make([]R, len(xs))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(xs))
+
+	// The elements are taken in order by the calling goroutine and the helpers
+	var next atomic.Int64
+	evaluate := func() {
+		for i := int(next.Add(1) - 1); i < len(xs); i = int(next.Add(1) - 1) {
+			ys[i] = f(xs[i])
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range workers - 1 {
+		if !sharedBudget.TryAcquire() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sharedBudget.Release()
+			evaluate()
+		}()
+	}
+	evaluate()
+	wg.Wait()
+	return ys
+}
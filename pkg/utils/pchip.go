@@ -0,0 +1,151 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PCHIP is a piecewise cubic Hermite interpolator preserving the monotonicity of
+// the data (Fritsch–Carlson). Unlike a cubic spline, it does not overshoot where
+// the slope of the data changes abruptly, such as at the steep low-frequency knee
+// of soil dispersion curves: on every interval where the data is monotonic, so is
+// the interpolant, and local extrema of the data remain local extrema.
+type PCHIP struct {
+	x []float64 // Sample points, strictly increasing
+	y []float64 // Sample values
+	d []float64 // Derivatives at the sample points
+}
+
+// NewPCHIP creates a PCHIP interpolator through the samples (x, y).
+// The derivatives are computed as in SciPy's PchipInterpolator: weighted harmonic
+// means of the neighbouring slopes inside, a shape-preserving three-point formula
+// at the ends.
+//
+// Parameters:
+//
+//	x - sample points, strictly increasing (at least two points)
+//	y - sample values, finite (same length as x)
+//
+// Returns:
+//
+//	interpolator - the interpolator
+//	error        - an error if the samples are invalid
+func NewPCHIP(x []float64, y []float64) (*PCHIP, error) {
+	if len(x) < 2 {
+		return nil, fmt.Errorf("input arrays must have at least two elements")
+	}
+	if len(y) != len(x) {
+		return nil, fmt.Errorf("all input arrays must have the same length")
+	}
+	for i := range x {
+		if math.IsNaN(x[i]) || math.IsInf(x[i], 0) || math.IsNaN(y[i]) || math.IsInf(y[i], 0) {
+			return nil, fmt.Errorf("input arrays must be finite (sample %d)", i)
+		}
+		if i > 0 && !(x[i] > x[i-1]) {
+			return nil, fmt.Errorf("x must be strictly increasing (sample %d)", i)
+		}
+	}
+
+	n := len(x)
+	h := make([]float64, n-1)     // Interval widths
+	delta := make([]float64, n-1) // Slopes of the intervals
+	for k := range n - 1 {
+		h[k] = x[k+1] - x[k]
+		delta[k] = (y[k+1] - y[k]) / h[k]
+	}
+
+	d := make([]float64, n)
+	if n == 2 {
+		// A single interval is interpolated linearly
+		d[0], d[1] = delta[0], delta[0]
+	} else {
+		for k := 1; k < n-1; k++ {
+			// Zero derivative at local extrema and flat parts of the data
+			if delta[k-1]*delta[k] <= 0 {
+				continue
+			}
+			w1 := 2*h[k] + h[k-1]
+			w2 := h[k] + 2*h[k-1]
+			d[k] = (w1 + w2) / (w1/delta[k-1] + w2/delta[k])
+		}
+		d[0] = pchipEndDerivative(h[0], h[1], delta[0], delta[1])
+		d[n-1] = pchipEndDerivative(h[n-2], h[n-3], delta[n-2], delta[n-3])
+	}
+
+	return &PCHIP{x: x, y: y, d: d}, nil
+}
+
+// pchipEndDerivative computes the derivative at an end point with a one-sided
+// three-point formula, limited so that the interpolant stays monotonic.
+//
+// Parameters:
+//
+//	h0, h1         - widths of the end interval and of its neighbour
+//	delta0, delta1 - slopes of the end interval and of its neighbour
+//
+// Returns:
+//
+//	d - the derivative at the end point
+func pchipEndDerivative(h0, h1, delta0, delta1 float64) float64 {
+	d := ((2*h0+h1)*delta0 - h0*delta1) / (h0 + h1)
+	if math.Signbit(d) != math.Signbit(delta0) || delta0 == 0 {
+		return 0
+	}
+	if math.Signbit(delta0) != math.Signbit(delta1) && math.Abs(d) > 3*math.Abs(delta0) {
+		return 3 * delta0
+	}
+	return d
+}
+
+// At evaluates the interpolant at xi. Outside the sample range the cubic of the
+// nearest end interval is extrapolated.
+//
+// Parameters:
+//
+//	xi - point at which the interpolant is evaluated
+//
+// Returns:
+//
+//	yi - the interpolated value
+func (p *PCHIP) At(xi float64) float64 {
+	// Interval [x[k], x[k+1]] containing xi (the end intervals extend outwards)
+	k := sort.SearchFloat64s(p.x, xi) - 1
+	k = max(0, min(k, len(p.x)-2))
+
+	h := p.x[k+1] - p.x[k]
+	t := (xi - p.x[k]) / h
+
+	// Cubic Hermite basis functions
+	t2, t3 := t*t, t*t*t
+	h00 := 2*t3 - 3*t2 + 1
+	h10 := t3 - 2*t2 + t
+	h01 := -2*t3 + 3*t2
+	h11 := t3 - t2
+	return h00*p.y[k] + h10*h*p.d[k] + h01*p.y[k+1] + h11*h*p.d[k+1]
+}
+
+// PchipInterpolate resamples the samples (x, y) at the points xi with a PCHIP
+// interpolator (see NewPCHIP).
+//
+// Parameters:
+//
+//	x  - sample points, strictly increasing (at least two points)
+//	y  - sample values, finite (same length as x)
+//	xi - points at which the data is resampled
+//
+// Returns:
+//
+//	yi    - the interpolated values at xi
+//	error - an error if the samples are invalid
+func PchipInterpolate(x []float64, y []float64, xi []float64) ([]float64, error) {
+	p, err := NewPCHIP(x, y)
+	if err != nil {
+		return nil, err
+	}
+	yi := make([]float64, len(xi))
+	for i, v := range xi {
+		yi[i] = p.At(v)
+	}
+	return yi, nil
+}
@@ -0,0 +1,201 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// PolynomialRoots finds the real roots of a polynomial of degree up to four in
+// closed form, e.g. for dispersion relations that reduce to a polynomial in the
+// squared wavenumber. The roots are polished with Newton's method on the
+// polynomial, which removes most of the round-off of the closed-form expressions.
+//
+// Roots close to a multiple root may be lost to round-off (the discriminant can
+// fall on either side of zero), and a multiple root is returned once.
+//
+// Parameters:
+//
+//	coefficients - coefficients of the polynomial, highest degree first (leading zeros are ignored)
+//
+// Returns:
+//
+//	roots - the real roots in increasing order (empty if there is none)
+//	error - an error if the degree is higher than four or the polynomial is zero
+func PolynomialRoots(coefficients []float64) ([]float64, error) {
+	// Strip the leading zeros
+	for len(coefficients) > 0 && coefficients[0] == 0 {
+		coefficients = coefficients[1:]
+	}
+
+	var roots []float64
+	switch len(coefficients) {
+	case 0:
+		return nil, fmt.Errorf("the zero polynomial has no isolated roots")
+	case 1:
+		roots = []float64{}
+	case 2:
+		roots = []float64{-coefficients[1] / coefficients[0]}
+	case 3:
+		roots = SolveQuadratic(coefficients[0], coefficients[1], coefficients[2])
+	case 4:
+		roots = SolveCubic(coefficients[0], coefficients[1], coefficients[2], coefficients[3])
+	case 5:
+		roots = SolveQuartic(coefficients[0], coefficients[1], coefficients[2], coefficients[3], coefficients[4])
+	default:
+		return nil, fmt.Errorf("polynomial of degree %d: only degrees up to four are supported", len(coefficients)-1)
+	}
+	return roots, nil
+}
+
+// SolveQuadratic finds the real roots of a x² + b x + c = 0 (a ≠ 0), with the
+// formulation that avoids cancellation between b and the square root of the discriminant.
+//
+// Parameters:
+//
+//	a, b, c - coefficients of the polynomial
+//
+// Returns:
+//
+//	roots - the distinct real roots in increasing order
+func SolveQuadratic(a, b, c float64) []float64 {
+	discriminant := b*b - 4*a*c
+	switch {
+	case discriminant < 0:
+		return []float64{}
+	case discriminant == 0:
+		return []float64{-b / (2 * a)}
+	}
+
+	q := -0.5 * (b + math.Copysign(math.Sqrt(discriminant), b))
+	roots := []float64{q / a, c / q}
+	return polishRoots([]float64{a, b, c}, roots)
+}
+
+// SolveCubic finds the real roots of a x³ + b x² + c x + d = 0 (a ≠ 0) with
+// Cardano's formula (one real root) or the trigonometric method (three real roots).
+//
+// Parameters:
+//
+//	a, b, c, d - coefficients of the polynomial
+//
+// Returns:
+//
+//	roots - the distinct real roots in increasing order
+func SolveCubic(a, b, c, d float64) []float64 {
+	// Depressed cubic t³ + p t + q = 0, with x = t - b/3a
+	b, c, d = b/a, c/a, d/a
+	shift := -b / 3
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+
+	var roots []float64
+	discriminant := q*q/4 + p*p*p/27
+	switch {
+	case p == 0 && q == 0:
+		roots = []float64{shift}
+	case discriminant > 0:
+		sqrtDiscriminant := math.Sqrt(discriminant)
+		u := math.Cbrt(-q/2 + sqrtDiscriminant)
+		v := math.Cbrt(-q/2 - sqrtDiscriminant)
+		roots = []float64{u + v + shift}
+	case discriminant == 0:
+		// Double root
+		u := math.Cbrt(-q / 2)
+		roots = []float64{2*u + shift, -u + shift}
+	default:
+		// Three real roots (p < 0)
+		r := 2 * math.Sqrt(-p/3)
+		phi := math.Acos(math.Max(-1, math.Min(1, 3*q/(p*r))))
+		for k := range 3 {
+			roots = append(roots, r*math.Cos((phi-2*math.Pi*float64(k))/3)+shift)
+		}
+	}
+	return polishRoots([]float64{1, b, c, d}, roots)
+}
+
+// SolveQuartic finds the real roots of a x⁴ + b x³ + c x² + d x + e = 0 (a ≠ 0)
+// with Ferrari's method: the depressed quartic is factored into two quadratics
+// with a root of its resolvent cubic.
+//
+// Parameters:
+//
+//	a, b, c, d, e - coefficients of the polynomial
+//
+// Returns:
+//
+//	roots - the distinct real roots in increasing order
+func SolveQuartic(a, b, c, d, e float64) []float64 {
+	// Depressed quartic y⁴ + p y² + q y + r = 0, with x = y - b/4a
+	b, c, d, e = b/a, c/a, d/a, e/a
+	shift := -b / 4
+	p := c - 3*b*b/8
+	q := b*b*b/8 - b*c/2 + d
+	r := -3*b*b*b*b/256 + b*b*c/16 - b*d/4 + e
+
+	var roots []float64
+	if q == 0 {
+		// Biquadratic: y² is a root of z² + p z + r
+		for _, z := range SolveQuadratic(1, p, r) {
+			switch {
+			case z > 0:
+				roots = append(roots, math.Sqrt(z)+shift, -math.Sqrt(z)+shift)
+			case z == 0:
+				roots = append(roots, shift)
+			}
+		}
+	} else {
+		// Resolvent cubic 8m³ + 8p m² + (2p² - 8r) m - q² = 0 has a positive root
+		m := slices.Max(SolveCubic(8, 8*p, 2*p*p-8*r, -q*q))
+		s := math.Sqrt(2 * m)
+		for _, y := range SolveQuadratic(1, -s, p/2+m+q/(2*s)) {
+			roots = append(roots, y+shift)
+		}
+		for _, y := range SolveQuadratic(1, s, p/2+m-q/(2*s)) {
+			roots = append(roots, y+shift)
+		}
+	}
+	return polishRoots([]float64{1, b, c, d, e}, roots)
+}
+
+// polishRoots refines roots of a polynomial with a few Newton iterations, keeping
+// an iterate only if it reduces the residual, then sorts them and removes duplicates.
+//
+// Parameters:
+//
+//	coefficients - coefficients of the polynomial, highest degree first
+//	roots        - approximate roots
+//
+// Returns:
+//
+//	roots - the refined distinct roots in increasing order
+func polishRoots(coefficients []float64, roots []float64) []float64 {
+	// Horner evaluation of the polynomial and its derivative
+	evaluate := func(x float64) (float64, float64) {
+		value, derivative := 0.0, 0.0
+		for _, c := range coefficients {
+			derivative = derivative*x + value
+			value = value*x + c
+		}
+		return value, derivative
+	}
+
+	for i, x := range roots {
+		value, derivative := evaluate(x)
+		for range 3 {
+			if value == 0 || derivative == 0 {
+				break
+			}
+			next := x - value/derivative
+			nextValue, nextDerivative := evaluate(next)
+			if math.Abs(nextValue) >= math.Abs(value) {
+				break
+			}
+			x, value, derivative = next, nextValue, nextDerivative
+		}
+		roots[i] = x
+	}
+
+	slices.Sort(roots)
+	return slices.Compact(roots)
+}
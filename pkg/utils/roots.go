@@ -0,0 +1,265 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// FindAllRoots finds all roots of a function f in the interval [a, b].
+// The interval is scanned with the given resolution; every sign change between
+// two consecutive samples is bracketed and refined with Brent's method.
+//
+// Roots closer to each other than the resolution, and roots where f touches zero
+// without changing sign, may be missed: the resolution must be smaller than the
+// distance between the roots of interest. Samples where f is NaN are skipped.
+//
+// Parameters:
+//
+//	f          - function for which the roots are to be found
+//	a, b       - interval bounds (a < b)
+//	resolution - maximum distance between two samples of the scan (> 0)
+//	tol        - tolerance of the refinement (see Brent)
+//
+// Returns:
+//
+//	roots - the roots in increasing order (empty if there is no sign change)
+//	error - an error if the inputs are invalid or a refinement fails
+func FindAllRoots(f func(float64) float64, a, b, resolution, tol float64) ([]float64, error) {
+	if !(a < b) {
+		return nil, fmt.Errorf("invalid interval: a must be smaller than b")
+	}
+	if !(resolution > 0) {
+		return nil, fmt.Errorf("resolution must be positive")
+	}
+
+	n := int(math.Ceil((b - a) / resolution))
+	x := Linspace(a, b, n+1)
+
+	roots := []float64{}
+	x1, f1 := x[0], f(x[0])
+	for i := 1; i < len(x); i++ {
+		x2, f2 := x[i], f(x[i])
+
+		switch {
+		case math.IsNaN(f1) || math.IsNaN(f2):
+		case f1 == 0:
+			roots = append(roots, x1)
+		case f1*f2 < 0:
+			root, err := Brent(f, x1, x2, tol)
+			if err != nil {
+				return roots, fmt.Errorf("refinement of root in [%g, %g] failed: %v", x1, x2, err)
+			}
+			roots = append(roots, root)
+		}
+		x1, f1 = x2, f2
+	}
+
+	// The last sample is not the start of an interval
+	if f1 == 0 {
+		roots = append(roots, x1)
+	}
+	return roots, nil
+}
+
+// Names of the root finders supported by RootFinderByName.
+const (
+	SolverBrent        = "brent"        // Brent's method (default)
+	SolverRidders      = "ridders"      // Ridders' method
+	SolverChandrupatla = "chandrupatla" // Chandrupatla's method
+)
+
+// SolverPolynomial names the closed-form solution of dispersion relations that are
+// polynomials (see PolynomialRoots). It is selected by the callers that support it:
+// it is not a RootFinder.
+const SolverPolynomial = "polynomial"
+
+// RootFinder is a bracketing root finder with the signature of Brent: it finds a
+// root of f in [a, b], where f(a) and f(b) have opposite signs.
+type RootFinder func(f func(float64) float64, a, b, tol float64) (float64, error)
+
+// RootFinderByName returns the root finder with the given name.
+//
+// Parameters:
+//
+//	name - SolverBrent, SolverRidders or SolverChandrupatla (empty means SolverBrent)
+//
+// Returns:
+//
+//	finder - the root finder
+//	error  - an error if the name is not supported
+func RootFinderByName(name string) (RootFinder, error) {
+	switch name {
+	case "", SolverBrent:
+		return Brent, nil
+	case SolverRidders:
+		return Ridders, nil
+	case SolverChandrupatla:
+		return Chandrupatla, nil
+	default:
+		return nil, fmt.Errorf("invalid root finder: %s. Supported root finders are '%s', '%s' or '%s'",
+			name, SolverBrent, SolverRidders, SolverChandrupatla)
+	}
+}
+
+// Ridders finds a root of a function f in the interval [a, b] using Ridders' method.
+// It is a drop-in alternative to Brent: every iteration evaluates f at the midpoint
+// of the bracket and at an exponentially fitted point, and the root always stays
+// bracketed. The bracket at least halves at every iteration, so the method cannot
+// stall on functions where the interpolation steps of Brent's method converge slowly.
+//
+// Parameters:
+//
+//	f     - function for which the root is to be found
+//	a, b  - interval bounds (must bracket a root, i.e., f(a)*f(b) < 0)
+//	tol   - absolute tolerance on the root (as in Brent)
+//
+// Returns:
+//
+//	root  - the estimated root
+//	error - an error if convergence fails or inputs are invalid
+func Ridders(f func(float64) float64, a, b, tol float64) (float64, error) {
+	// Maximum number of iterations
+	max_nb_iterations := 1000
+
+	eps := math.Nextafter(1.0, 2.0) - 1.0
+	if tol < eps {
+		tol = eps
+	}
+
+	fa := f(a)
+	fb := f(b)
+	if fa == 0 {
+		return a, nil
+	}
+	if fb == 0 {
+		return b, nil
+	}
+	if fa*fb > 0 || math.IsNaN(fa) || math.IsNaN(fb) {
+		return 0, fmt.Errorf("root not bracketed: f(a) and f(b) must have opposite signs")
+	}
+
+	root := math.NaN()
+	for iter := 0; iter < max_nb_iterations; iter++ {
+		m := 0.5 * (a + b)
+		fm := f(m)
+
+		// Exponential fit through (a, fa), (m, fm), (b, fb)
+		s := math.Sqrt(fm*fm - fa*fb)
+		if s == 0 {
+			return m, nil
+		}
+		sign := 1.0
+		if fa < fb {
+			sign = -1.0
+		}
+		x := m + (m-a)*sign*fm/s
+		fx := f(x)
+
+		delta := 2*eps*math.Abs(x) + tol
+		if !math.IsNaN(root) && math.Abs(x-root) <= delta {
+			return x, nil
+		}
+		root = x
+		if fx == 0 {
+			return x, nil
+		}
+
+		// Keep the smallest bracket among a, m, x and b
+		switch {
+		case math.Signbit(fm) != math.Signbit(fx):
+			a, fa, b, fb = m, fm, x, fx
+		case math.Signbit(fa) != math.Signbit(fx):
+			b, fb = x, fx
+		default:
+			a, fa = x, fx
+		}
+		if 0.5*math.Abs(b-a) <= delta {
+			return x, nil
+		}
+	}
+
+	return 0, fmt.Errorf("maximum number of iterations reached without convergence")
+}
+
+// Chandrupatla finds a root of a function f in the interval [a, b] using
+// Chandrupatla's method (Chandrupatla, 1997). It is a drop-in alternative to Brent:
+// every iteration evaluates f once, at an inverse quadratic interpolation through the
+// last three points when the interpolation is valid, and at the midpoint of the
+// bracket otherwise. The validity test is simpler than the conditions of Brent's
+// method and rejects fewer interpolation steps, so the method converges in fewer
+// evaluations on smooth functions such as the dispersion relations, while the root
+// always stays bracketed.
+//
+// Parameters:
+//
+//	f     - function for which the root is to be found
+//	a, b  - interval bounds (must bracket a root, i.e., f(a)*f(b) < 0)
+//	tol   - absolute tolerance on the root (as in Brent)
+//
+// Returns:
+//
+//	root  - the estimated root
+//	error - an error if convergence fails or inputs are invalid
+func Chandrupatla(f func(float64) float64, a, b, tol float64) (float64, error) {
+	// Maximum number of iterations
+	max_nb_iterations := 1000
+
+	eps := math.Nextafter(1.0, 2.0) - 1.0
+	if tol < eps {
+		tol = eps
+	}
+
+	fa := f(a)
+	fb := f(b)
+	if fa == 0 {
+		return a, nil
+	}
+	if fb == 0 {
+		return b, nil
+	}
+	if fa*fb > 0 || math.IsNaN(fa) || math.IsNaN(fb) {
+		return 0, fmt.Errorf("root not bracketed: f(a) and f(b) must have opposite signs")
+	}
+
+	// The new point is at a + t (b - a), starting with bisection; c is the point
+	// dropped from the bracket at the last iteration
+	t := 0.5
+	var c, fc float64
+	for iter := 0; iter < max_nb_iterations; iter++ {
+		x := a + t*(b-a)
+		fx := f(x)
+		if math.Signbit(fx) == math.Signbit(fa) {
+			c, fc = a, fa
+		} else {
+			c, fc = b, fb
+			b, fb = a, fa
+		}
+		a, fa = x, fx
+
+		// Best estimate of the root and tolerance relative to the bracket
+		root, fRoot := a, fa
+		if math.Abs(fb) < math.Abs(fa) {
+			root, fRoot = b, fb
+		}
+		if fRoot == 0 {
+			return root, nil
+		}
+		tl := (2*eps*math.Abs(root) + 0.5*tol) / math.Abs(b-a)
+		if tl > 0.5 {
+			return root, nil
+		}
+
+		// Inverse quadratic interpolation where it is valid, i.e. the quadratic
+		// through the three points is monotonic over the bracket
+		xi := (a - b) / (c - b)
+		phi := (fa - fb) / (fc - fb)
+		if phi*phi < xi && (1-phi)*(1-phi) < 1-xi {
+			t = fa/(fb-fa)*fc/(fb-fc) + (c-a)/(b-a)*fa/(fc-fa)*fb/(fc-fb)
+		} else {
+			t = 0.5
+		}
+		t = math.Min(1-tl, math.Max(tl, t))
+	}
+
+	return 0, fmt.Errorf("maximum number of iterations reached without convergence")
+}
@@ -0,0 +1,86 @@
+package math_utils
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// SavitzkyGolay smooths evenly spaced samples with a Savitzky–Golay filter: every
+// sample is replaced by the value of the least-squares polynomial of the given
+// order through the window of samples centred on it. Unlike a moving average, the
+// filter preserves the height and width of peaks and the slope of the curve, which
+// makes it suited to noisy measured dispersion curves. Near the ends, where the
+// window cannot be centred, the polynomial of the first (last) window is evaluated,
+// as SciPy's savgol_filter does with mode="interp".
+//
+// NaN samples propagate to every smoothed sample whose window contains them.
+//
+// Parameters:
+//
+//	y      - evenly spaced samples
+//	window - number of samples of the window (odd, at most len(y))
+//	order  - order of the polynomial (smaller than window)
+//
+// Returns:
+//
+//	smoothed - the smoothed samples
+//	error    - an error if the window or the order is invalid
+func SavitzkyGolay(y []float64, window int, order int) ([]float64, error) {
+	if window < 1 || window%2 == 0 {
+		return nil, fmt.Errorf("window must be a positive odd number")
+	}
+	if window > len(y) {
+		return nil, fmt.Errorf("window (%d) must not exceed the number of samples (%d)", window, len(y))
+	}
+	if order < 0 || order >= window {
+		return nil, fmt.Errorf("polynomial order must be between 0 and window - 1")
+	}
+
+	half := window / 2
+
+	// Least-squares fit of the polynomial on the window, with coordinates centred
+	// on the window: the coefficients are coefficients.(y of the window)
+	vandermonde := mat.NewDense(window, order+1, nil)
+	for j := range window {
+		t := float64(j - half)
+		for p := range order + 1 {
+			vandermonde.Set(j, p, math.Pow(t, float64(p)))
+		}
+	}
+	identity := mat.NewDense(window, window, nil)
+	for j := range window {
+		identity.Set(j, j, 1)
+	}
+	var coefficients mat.Dense
+	if err := coefficients.Solve(vandermonde, identity); err != nil {
+		return nil, fmt.Errorf("failed to compute filter coefficients: %v", err)
+	}
+
+	// Weights evaluating the fitted polynomial at an offset from the window centre
+	weights := func(offset int) []float64 {
+		w := make([]float64, window)
+		for p := range order + 1 {
+			power := math.Pow(float64(offset), float64(p))
+			for j := range window {
+				w[j] += power * coefficients.At(p, j)
+			}
+		}
+		return w
+	}
+	centre := weights(0)
+
+	smoothed := make([]float64, len(y))
+	for i := range y {
+		start := max(0, min(i-half, len(y)-window))
+		w := centre
+		if offset := i - (start + half); offset != 0 {
+			w = weights(offset)
+		}
+		for j := range window {
+			smoothed[i] += w[j] * y[start+j]
+		}
+	}
+	return smoothed, nil
+}
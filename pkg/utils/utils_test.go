@@ -2,7 +2,10 @@ package math_utils
 
 import (
 	"math"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestBrentSimplePolynomial tests the Brent method on a simple quadratic function.
@@ -448,3 +451,737 @@ func TestInterceptLines_LargeValues(t *testing.T) {
 		t.Errorf("Expected intercept at (%e, %e), got (%e, %e)", expectedX, expectedY, interceptX, interceptY)
 	}
 }
+
+// TestFindAllRoots tests that all roots of sin(x) in [0.5, 10] are found
+func TestFindAllRoots(t *testing.T) {
+	roots, err := FindAllRoots(math.Sin, 0.5, 10, 0.1, 1e-12)
+	if err != nil {
+		t.Fatalf("FindAllRoots failed: %v", err)
+	}
+
+	expected := []float64{math.Pi, 2 * math.Pi, 3 * math.Pi}
+	if len(roots) != len(expected) {
+		t.Fatalf("Expected %d roots, got %v", len(expected), roots)
+	}
+	for i, v := range expected {
+		if math.Abs(roots[i]-v) > 1e-9 {
+			t.Errorf("Expected root %f, got %f", v, roots[i])
+		}
+	}
+}
+
+// TestFindAllRootsExactSamples tests roots falling exactly on scan samples, including the interval bounds
+func TestFindAllRootsExactSamples(t *testing.T) {
+	f := func(x float64) float64 {
+		return (x - 1) * (x - 2) * (x - 3)
+	}
+
+	roots, err := FindAllRoots(f, 1, 3, 0.5, 1e-12)
+	if err != nil {
+		t.Fatalf("FindAllRoots failed: %v", err)
+	}
+	if len(roots) != 3 || roots[0] != 1 || roots[1] != 2 || roots[2] != 3 {
+		t.Errorf("Expected roots [1 2 3], got %v", roots)
+	}
+}
+
+// TestFindAllRootsInvalidInput tests the validation of the interval and resolution
+func TestFindAllRootsInvalidInput(t *testing.T) {
+	if _, err := FindAllRoots(math.Sin, 2, 1, 0.1, 1e-12); err == nil {
+		t.Error("Expected error for an inverted interval, got nil")
+	}
+	if _, err := FindAllRoots(math.Sin, 1, 2, 0, 1e-12); err == nil {
+		t.Error("Expected error for a zero resolution, got nil")
+	}
+
+	roots, err := FindAllRoots(func(x float64) float64 { return x*x + 1 }, -1, 1, 0.1, 1e-12)
+	if err != nil || len(roots) != 0 {
+		t.Errorf("Expected no roots and no error, got %v, %v", roots, err)
+	}
+}
+
+// TestFindRootsDeflation tests that roots without a sign change between the starting
+// points, a pair of close roots and a double root, are found by deflation
+func TestFindRootsDeflation(t *testing.T) {
+	f := func(x float64) float64 {
+		return (x - 2) * (x - 2.001) * (x - 5) * (x - 30) * (x - 30)
+	}
+
+	roots, err := FindRootsDeflation(f, 0.1, 100, 20, 10, 1e-12)
+	if err != nil {
+		t.Fatalf("FindRootsDeflation failed: %v", err)
+	}
+	expected := []float64{2, 2.001, 5, 30}
+	if len(roots) != len(expected) {
+		t.Fatalf("Expected roots %v, got %v", expected, roots)
+	}
+	for i, v := range expected {
+		if math.Abs(roots[i]-v) > 1e-6*v {
+			t.Errorf("Expected root %f, got %f", v, roots[i])
+		}
+	}
+
+	// The search stops at maxRoots
+	if roots, err := FindRootsDeflation(f, 0.1, 100, 20, 2, 1e-12); err != nil || len(roots) != 2 {
+		t.Errorf("Expected two roots, got %v, %v", roots, err)
+	}
+	if _, err := FindRootsDeflation(f, 1, 0, 20, 2, 1e-12); err == nil {
+		t.Error("Expected error for an inverted interval, got nil")
+	}
+	if roots, err := FindRootsDeflation(func(x float64) float64 { return x*x + 1 }, -1, 1, 10, 2, 1e-12); err != nil || len(roots) != 0 {
+		t.Errorf("Expected no roots and no error, got %v, %v", roots, err)
+	}
+}
+
+// TestRidders tests Ridders' method on smooth functions and a function with a steep
+// region, on which the interpolation steps of Brent's method make slow progress
+func TestRidders(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(float64) float64
+		a, b     float64
+		expected float64
+	}{
+		{"polynomial", func(x float64) float64 { return x*x - 4 }, 1, 3, 2},
+		{"sine", math.Sin, 3, 4, math.Pi},
+		{"cubic near boundary", func(x float64) float64 { return x*x*x - 0.001 }, 0, 1, 0.1},
+		{"steep", func(x float64) float64 { return math.Tanh(50 * (x - 0.3)) }, -10, 10, 0.3},
+		{"reversed sign", func(x float64) float64 { return 1 - math.Exp(x-1) }, 0, 5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Ridders(tt.f, tt.a, tt.b, 1e-12)
+			if err != nil {
+				t.Fatalf("Ridders failed: %v", err)
+			}
+			if math.Abs(root-tt.expected) > 1e-9 {
+				t.Errorf("Expected root near %g, but got %g", tt.expected, root)
+			}
+		})
+	}
+
+	if _, err := Ridders(func(x float64) float64 { return x*x + 1 }, -1, 1, 1e-12); err == nil {
+		t.Error("Expected error for invalid interval, got nil")
+	}
+}
+
+// TestChandrupatla tests Chandrupatla's method on the functions of TestRidders, and
+// that it converges in fewer evaluations than bisection on a smooth function
+func TestChandrupatla(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(float64) float64
+		a, b     float64
+		expected float64
+	}{
+		{"polynomial", func(x float64) float64 { return x*x - 4 }, 1, 3, 2},
+		{"sine", math.Sin, 3, 4, math.Pi},
+		{"cubic near boundary", func(x float64) float64 { return x*x*x - 0.001 }, 0, 1, 0.1},
+		{"steep", func(x float64) float64 { return math.Tanh(50 * (x - 0.3)) }, -10, 10, 0.3},
+		{"reversed sign", func(x float64) float64 { return 1 - math.Exp(x-1) }, 0, 5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Chandrupatla(tt.f, tt.a, tt.b, 1e-12)
+			if err != nil {
+				t.Fatalf("Chandrupatla failed: %v", err)
+			}
+			if math.Abs(root-tt.expected) > 1e-9 {
+				t.Errorf("Expected root near %g, but got %g", tt.expected, root)
+			}
+		})
+	}
+
+	evaluations := 0
+	smooth := func(x float64) float64 {
+		evaluations++
+		return math.Exp(x) - 2
+	}
+	if root, err := Chandrupatla(smooth, 0, 1, 1e-12); err != nil || math.Abs(root-math.Ln2) > 1e-12 {
+		t.Errorf("Expected root near ln 2, got %g (%v)", root, err)
+	}
+	// Bisection needs about 40 evaluations for the same tolerance
+	if evaluations > 12 {
+		t.Errorf("Expected at most 12 evaluations, got %d", evaluations)
+	}
+
+	if _, err := Chandrupatla(func(x float64) float64 { return x*x + 1 }, -1, 1, 1e-12); err == nil {
+		t.Error("Expected error for invalid interval, got nil")
+	}
+}
+
+// TestRootFinderByName tests the selection of the root finders by name
+func TestRootFinderByName(t *testing.T) {
+	for _, name := range []string{"", SolverBrent, SolverRidders, SolverChandrupatla} {
+		findRoot, err := RootFinderByName(name)
+		if err != nil {
+			t.Fatalf("RootFinderByName(%q) failed: %v", name, err)
+		}
+		root, err := findRoot(math.Sin, 3, 4, 1e-12)
+		if err != nil || math.Abs(root-math.Pi) > 1e-9 {
+			t.Errorf("Root finder %q: expected root near π, got %g (%v)", name, root, err)
+		}
+	}
+
+	if _, err := RootFinderByName("newton"); err == nil {
+		t.Error("Expected error for an unsupported root finder, got nil")
+	}
+}
+
+// TestParallelMap tests that ParallelMap preserves the order of the elements and
+// bounds the number of concurrent evaluations
+func TestParallelMap(t *testing.T) {
+	xs := Linspace(0, 10, 101)
+
+	for _, workers := range []int{0, 1, 3, 500} {
+		var running, maxRunning atomic.Int64
+		ys := ParallelMap(func(x float64) float64 {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			defer running.Add(-1)
+			return x * x
+		}, xs, workers)
+
+		if len(ys) != len(xs) {
+			t.Fatalf("workers=%d: expected %d results, got %d", workers, len(xs), len(ys))
+		}
+		for i, x := range xs {
+			if ys[i] != x*x {
+				t.Errorf("workers=%d: expected ys[%d] = %g, got %g", workers, i, x*x, ys[i])
+			}
+		}
+		if workers > 0 && maxRunning.Load() > int64(workers) {
+			t.Errorf("workers=%d: %d concurrent evaluations", workers, maxRunning.Load())
+		}
+	}
+
+	if ys := ParallelMap(func(x int) int { return x }, nil, 4); len(ys) != 0 {
+		t.Errorf("expected no results for no elements, got %v", ys)
+	}
+}
+
+// TestParallelMapBudget tests that ParallelMap only starts goroutines for the free
+// tokens of the shared budget, and evaluates in the calling goroutine without any
+func TestParallelMapBudget(t *testing.T) {
+	budget := SharedBudget()
+	if budget.Size() < 1 {
+		t.Fatalf("expected a positive budget, got %d", budget.Size())
+	}
+	xs := Linspace(0, 10, 101)
+	count := func(held int) int64 {
+		for range held {
+			budget.Acquire()
+		}
+		defer func() {
+			for range held {
+				budget.Release()
+			}
+		}()
+		var running, maxRunning atomic.Int64
+		ys := ParallelMap(func(x float64) float64 {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(100 * time.Microsecond)
+			running.Add(-1)
+			return -x
+		}, xs, 0)
+		for i, x := range xs {
+			if ys[i] != -x {
+				t.Fatalf("held=%d: expected ys[%d] = %g, got %g", held, i, -x, ys[i])
+			}
+		}
+		return maxRunning.Load()
+	}
+
+	// All tokens held: the elements are evaluated in the calling goroutine
+	if n := count(budget.Size()); n != 1 {
+		t.Errorf("expected 1 concurrent evaluation without tokens, got %d", n)
+	}
+	// All tokens but one held: one helper besides the calling goroutine
+	if n := count(budget.Size() - 1); n > 2 {
+		t.Errorf("expected at most 2 concurrent evaluations with one token, got %d", n)
+	}
+
+	if !budget.TryAcquire() {
+		t.Fatal("expected a free token after ParallelMap")
+	}
+	budget.Release()
+	small := NewBudget(1)
+	if small.Size() != 1 || !small.TryAcquire() || small.TryAcquire() {
+		t.Error("expected a budget of one token")
+	}
+	small.Release()
+}
+
+// TestInterceptLinesAll tests that InterceptLinesAll returns every crossing, counting
+// samples where the lines touch once
+func TestInterceptLinesAll(t *testing.T) {
+	x := []float64{0, 1, 2, 3, 4, 5}
+	y1 := []float64{0, 2, 0, 2, 1, 2}
+	y2 := []float64{1, 1, 1, 1, 1, math.NaN()}
+
+	interceptX, interceptY, err := InterceptLinesAll(x, y1, y2)
+	if err != nil {
+		t.Fatalf("InterceptLinesAll failed: %v", err)
+	}
+
+	expectedX := []float64{0.5, 1.5, 2.5, 4}
+	if len(interceptX) != len(expectedX) || len(interceptY) != len(expectedX) {
+		t.Fatalf("Expected intersections at %v, got %v", expectedX, interceptX)
+	}
+	for i := range expectedX {
+		if math.Abs(interceptX[i]-expectedX[i]) > 1e-12 || math.Abs(interceptY[i]-1) > 1e-12 {
+			t.Errorf("Expected intersection (%g, 1), got (%g, %g)", expectedX[i], interceptX[i], interceptY[i])
+		}
+	}
+
+	// The first intersection is the one of InterceptLines
+	firstX, firstY, err := InterceptLines(x, y1, y2)
+	if err != nil || firstX != interceptX[0] || firstY != interceptY[0] {
+		t.Errorf("Expected first intersection (%g, %g), InterceptLines gave (%g, %g, %v)", interceptX[0], interceptY[0], firstX, firstY, err)
+	}
+
+	// No intersection
+	interceptX, _, err = InterceptLinesAll(x, y1, []float64{5, 5, 5, 5, 5, 5})
+	if err != nil || len(interceptX) != 0 {
+		t.Errorf("Expected no intersections and no error, got %v, %v", interceptX, err)
+	}
+
+	// Invalid input
+	if _, _, err := InterceptLinesAll([]float64{0}, []float64{0}, []float64{0}); err == nil {
+		t.Error("Expected error for short arrays, got nil")
+	}
+	if _, _, err := InterceptLinesAll(x, y1, y2[:3]); err == nil {
+		t.Error("Expected error for arrays of different lengths, got nil")
+	}
+}
+
+// TestPCHIP tests that the PCHIP interpolant goes through the samples, reproduces
+// linear data and does not overshoot steep monotonic data
+func TestPCHIP(t *testing.T) {
+	// Steep knee, as at the low frequencies of a soil dispersion curve
+	x := []float64{0, 1, 2, 3, 4, 6}
+	y := []float64{0, 0.1, 0.2, 5, 5.1, 5.2}
+	p, err := NewPCHIP(x, y)
+	if err != nil {
+		t.Fatalf("NewPCHIP failed: %v", err)
+	}
+
+	for i := range x {
+		if math.Abs(p.At(x[i])-y[i]) > 1e-12 {
+			t.Errorf("Expected %g at sample %g, got %g", y[i], x[i], p.At(x[i]))
+		}
+	}
+
+	// Monotonic between the samples, without overshoot
+	xi := Linspace(0, 6, 601)
+	yi, err := PchipInterpolate(x, y, xi)
+	if err != nil {
+		t.Fatalf("PchipInterpolate failed: %v", err)
+	}
+	for i := 1; i < len(yi); i++ {
+		if yi[i] < yi[i-1]-1e-12 {
+			t.Fatalf("Interpolant decreases at x = %g: %g < %g", xi[i], yi[i], yi[i-1])
+		}
+	}
+	if yi[0] < 0 || yi[len(yi)-1] > 5.2+1e-12 {
+		t.Errorf("Interpolant leaves the range of the data: [%g, %g]", yi[0], yi[len(yi)-1])
+	}
+
+	// Linear data is reproduced exactly, also when extrapolating
+	linear, err := NewPCHIP([]float64{0, 1, 3, 4}, []float64{1, 3, 7, 9})
+	if err != nil {
+		t.Fatalf("NewPCHIP failed: %v", err)
+	}
+	for _, v := range []float64{-1, 0.5, 2, 3.7, 5} {
+		if math.Abs(linear.At(v)-(1+2*v)) > 1e-12 {
+			t.Errorf("Expected %g at %g for linear data, got %g", 1+2*v, v, linear.At(v))
+		}
+	}
+
+	// Local extrema of the data remain local extrema
+	peak, err := NewPCHIP([]float64{0, 1, 2}, []float64{0, 1, 0})
+	if err != nil {
+		t.Fatalf("NewPCHIP failed: %v", err)
+	}
+	for _, v := range Linspace(0, 2, 21) {
+		if peak.At(v) > 1+1e-12 {
+			t.Errorf("Interpolant overshoots the maximum at %g: %g", v, peak.At(v))
+		}
+	}
+}
+
+// TestPCHIPInvalidInput tests the validation of the samples
+func TestPCHIPInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+	}{
+		{"short arrays", []float64{0}, []float64{0}},
+		{"different lengths", []float64{0, 1, 2}, []float64{0, 1}},
+		{"not increasing", []float64{0, 2, 1}, []float64{0, 1, 2}},
+		{"repeated point", []float64{0, 1, 1}, []float64{0, 1, 2}},
+		{"NaN value", []float64{0, 1, 2}, []float64{0, math.NaN(), 2}},
+	}
+	for _, tt := range tests {
+		if _, err := NewPCHIP(tt.x, tt.y); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
+
+// TestFiniteDifferences tests the central and one-sided differences and their
+// Richardson extrapolation on the exponential function
+func TestFiniteDifferences(t *testing.T) {
+	x := 0.5
+	expected := math.Exp(x)
+	h := 1e-4
+
+	tests := []struct {
+		name       string
+		derivative float64
+	}{
+		{"central", CentralDifference(math.Exp, x, h)},
+		{"forward", ForwardDifference(math.Exp, x, h)},
+		{"backward", BackwardDifference(math.Exp, x, h)},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.derivative-expected) > 1e-7 {
+			t.Errorf("%s difference: expected %g, got %g", tt.name, expected, tt.derivative)
+		}
+	}
+
+	// A large step is corrected by the extrapolation
+	derivative, errorEst, err := RichardsonDerivative(math.Exp, x, 0.5, 5)
+	if err != nil {
+		t.Fatalf("RichardsonDerivative failed: %v", err)
+	}
+	if math.Abs(derivative-expected) > 1e-11 {
+		t.Errorf("Richardson extrapolation: expected %g, got %g", expected, derivative)
+	}
+	if errorEst > 1e-8 {
+		t.Errorf("Richardson extrapolation: unexpected error estimate %g", errorEst)
+	}
+	if math.Abs(CentralDifference(math.Exp, x, 0.5)-expected) < 1e-3 {
+		t.Error("Central difference with a large step is unexpectedly accurate")
+	}
+
+	if _, _, err := RichardsonDerivative(math.Exp, x, 0, 5); err == nil {
+		t.Error("Expected error for a zero step, got nil")
+	}
+	if _, _, err := RichardsonDerivative(math.Exp, x, 0.5, 0); err == nil {
+		t.Error("Expected error for zero levels, got nil")
+	}
+}
+
+// TestGradient tests the derivative of sampled curves on unevenly spaced samples
+func TestGradient(t *testing.T) {
+	// Exact for quadratic data, including at the ends
+	x := []float64{0, 0.5, 1.5, 2, 3.5}
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = 3*v*v - 2*v + 1
+	}
+	dydx, err := Gradient(x, y)
+	if err != nil {
+		t.Fatalf("Gradient failed: %v", err)
+	}
+	for i, v := range x {
+		if math.Abs(dydx[i]-(6*v-2)) > 1e-12 {
+			t.Errorf("Expected derivative %g at %g, got %g", 6*v-2, v, dydx[i])
+		}
+	}
+
+	// Two samples give the slope of the line
+	dydx, err = Gradient([]float64{1, 3}, []float64{2, 6})
+	if err != nil || dydx[0] != 2 || dydx[1] != 2 {
+		t.Errorf("Expected slope 2 at both samples, got %v, %v", dydx, err)
+	}
+
+	if _, err := Gradient([]float64{0}, []float64{0}); err == nil {
+		t.Error("Expected error for short arrays, got nil")
+	}
+	if _, err := Gradient([]float64{0, 1, 2}, []float64{0, 1}); err == nil {
+		t.Error("Expected error for arrays of different lengths, got nil")
+	}
+	if _, err := Gradient([]float64{0, 1, 1}, []float64{0, 1, 2}); err == nil {
+		t.Error("Expected error for repeated points, got nil")
+	}
+}
+
+// TestSavitzkyGolay tests that the filter preserves polynomials up to its order,
+// reduces noise and validates its parameters
+func TestSavitzkyGolay(t *testing.T) {
+	// Quadratic data is preserved by a filter of order 2, also at the ends
+	y := make([]float64, 20)
+	for i := range y {
+		x := float64(i)
+		y[i] = 0.5*x*x - 3*x + 2
+	}
+	smoothed, err := SavitzkyGolay(y, 7, 2)
+	if err != nil {
+		t.Fatalf("SavitzkyGolay failed: %v", err)
+	}
+	for i := range y {
+		if math.Abs(smoothed[i]-y[i]) > 1e-9 {
+			t.Errorf("Expected %g at sample %d, got %g", y[i], i, smoothed[i])
+		}
+	}
+
+	// Centred 5-point quadratic filter: the classic weights (-3, 12, 17, 12, -3) / 35
+	smoothed, err = SavitzkyGolay([]float64{0, 0, 1, 0, 0}, 5, 2)
+	if err != nil {
+		t.Fatalf("SavitzkyGolay failed: %v", err)
+	}
+	if math.Abs(smoothed[2]-17.0/35) > 1e-12 {
+		t.Errorf("Expected %g at the centre, got %g", 17.0/35, smoothed[2])
+	}
+
+	// Alternating noise on a line is reduced
+	noisy := make([]float64, 50)
+	for i := range noisy {
+		noisy[i] = float64(i) + 0.1*math.Pow(-1, float64(i))
+	}
+	smoothed, err = SavitzkyGolay(noisy, 9, 1)
+	if err != nil {
+		t.Fatalf("SavitzkyGolay failed: %v", err)
+	}
+	for i := 4; i < len(noisy)-4; i++ {
+		if math.Abs(smoothed[i]-float64(i)) > 0.02 {
+			t.Errorf("Expected %d at sample %d after smoothing, got %g", i, i, smoothed[i])
+		}
+	}
+
+	invalid := []struct {
+		name          string
+		window, order int
+	}{
+		{"even window", 4, 2},
+		{"window too large", 51, 2},
+		{"order too large", 5, 5},
+		{"negative order", 5, -1},
+	}
+	for _, tt := range invalid {
+		if _, err := SavitzkyGolay(noisy, tt.window, tt.order); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
+
+// TestNaNUtilities tests finding, counting, trimming and filling NaN runs
+func TestNaNUtilities(t *testing.T) {
+	nan := math.NaN()
+	x := []float64{0, 1, 2, 3, 4, 5, 6, 7}
+	y := []float64{nan, 1, nan, nan, 4, 5, nan, nan}
+
+	if got := NaNIndices(y); !slices.Equal(got, []int{0, 2, 3, 6, 7}) {
+		t.Errorf("Unexpected NaN indices: %v", got)
+	}
+	if got := CountNaN(y); got != 5 {
+		t.Errorf("Expected 5 NaN values, got %d", got)
+	}
+	if got := NaNRuns(y); !slices.Equal(got, []NaNRun{{0, 1}, {2, 4}, {6, 8}}) {
+		t.Errorf("Unexpected NaN runs: %v", got)
+	}
+	if start, end := TrimNaN(y); start != 1 || end != 6 {
+		t.Errorf("Expected bounds [1, 6), got [%d, %d)", start, end)
+	}
+	if start, end := TrimNaN([]float64{nan, nan}); start != 0 || end != 0 {
+		t.Errorf("Expected empty bounds for NaN values only, got [%d, %d)", start, end)
+	}
+
+	filled, err := InterpolateNaN(x, y)
+	if err != nil {
+		t.Fatalf("InterpolateNaN failed: %v", err)
+	}
+	for i, expected := range []float64{nan, 1, 2, 3, 4, 5, nan, nan} {
+		if filled[i] != expected && !(math.IsNaN(expected) && math.IsNaN(filled[i])) {
+			t.Errorf("Expected %g at index %d, got %g", expected, i, filled[i])
+		}
+	}
+	if !math.IsNaN(y[2]) {
+		t.Error("InterpolateNaN modified its input")
+	}
+	if _, err := InterpolateNaN(x, y[:3]); err == nil {
+		t.Error("Expected error for arrays of different lengths, got nil")
+	}
+}
+
+// TestInterp1D tests the interpolation methods and the extrapolation modes
+func TestInterp1D(t *testing.T) {
+	x := []float64{0, 1, 2, 4}
+	y := []float64{0, 2, 4, 8}
+
+	// Every method reproduces linear data inside the samples
+	for _, method := range []string{InterpLinear, InterpSpline, InterpPCHIP} {
+		interp, err := NewInterp1D(x, y, method, ExtrapolateExtend)
+		if err != nil {
+			t.Fatalf("NewInterp1D(%s) failed: %v", method, err)
+		}
+		yi, err := interp.Resample([]float64{-1, 0.5, 3, 5})
+		if err != nil {
+			t.Fatalf("%s: Resample failed: %v", method, err)
+		}
+		for i, expected := range []float64{-2, 1, 6, 10} {
+			if math.Abs(yi[i]-expected) > 1e-12 {
+				t.Errorf("%s: expected %g, got %g", method, expected, yi[i])
+			}
+		}
+	}
+
+	// The natural spline is smooth through a peak and matches the samples
+	spline, err := NewInterp1D([]float64{0, 1, 2, 3}, []float64{0, 1, 0, 1}, InterpSpline, ExtrapolateError)
+	if err != nil {
+		t.Fatalf("NewInterp1D failed: %v", err)
+	}
+	for i, v := range []float64{0, 1, 0, 1} {
+		if got, _ := spline.At(float64(i)); math.Abs(got-v) > 1e-12 {
+			t.Errorf("spline: expected %g at sample %d, got %g", v, i, got)
+		}
+	}
+	// Second derivatives of this natural spline: m = (0, -4, 4, 0)
+	if got, _ := spline.At(0.5); math.Abs(got-0.75) > 1e-12 {
+		t.Errorf("spline: unexpected value %g at 0.5", got)
+	}
+
+	// Extrapolation modes
+	clamp, _ := NewInterp1D(x, y, InterpLinear, ExtrapolateClamp)
+	if low, _ := clamp.At(-3); low != 0 {
+		t.Errorf("clamp: expected 0 below the samples, got %g", low)
+	}
+	if high, _ := clamp.At(10); high != 8 {
+		t.Errorf("clamp: expected 8 above the samples, got %g", high)
+	}
+	strict, _ := NewInterp1D(x, y, "", "")
+	if _, err := strict.At(4.5); err == nil {
+		t.Error("error mode: expected error outside the samples, got nil")
+	}
+	if _, err := strict.Resample([]float64{1, 5}); err == nil {
+		t.Error("error mode: expected error when resampling outside the samples, got nil")
+	}
+	if got, err := strict.At(4); err != nil || got != 8 {
+		t.Errorf("error mode: expected 8 at the last sample, got %g, %v", got, err)
+	}
+
+	// Invalid parameters
+	if _, err := NewInterp1D(x, y, "quadratic", ""); err == nil {
+		t.Error("Expected error for an unsupported method, got nil")
+	}
+	if _, err := NewInterp1D(x, y, "", "wrap"); err == nil {
+		t.Error("Expected error for an unsupported mode, got nil")
+	}
+	if _, err := NewInterp1D(x, y[:2], "", ""); err == nil {
+		t.Error("Expected error for arrays of different lengths, got nil")
+	}
+}
+
+// TestPolynomialRoots tests the closed-form roots of polynomials of degree one to four
+func TestPolynomialRoots(t *testing.T) {
+	// Coefficients of the polynomial scale * (x - r1) (x - r2) ..., highest degree first
+	fromRoots := func(scale float64, roots ...float64) []float64 {
+		coefficients := []float64{scale}
+		for _, r := range roots {
+			next := make([]float64, len(coefficients)+1)
+			for i, c := range coefficients {
+				next[i] += c
+				next[i+1] -= c * r
+			}
+			coefficients = next
+		}
+		return coefficients
+	}
+
+	tests := []struct {
+		name         string
+		coefficients []float64
+		expected     []float64
+	}{
+		{"linear", []float64{2, -3}, []float64{1.5}},
+		{"quadratic", fromRoots(3, 1e-6, 1e6), []float64{1e-6, 1e6}},
+		{"quadratic without real roots", []float64{1, 0, 1}, []float64{}},
+		{"cubic with three roots", fromRoots(-2, -1, 0.5, 3), []float64{-1, 0.5, 3}},
+		{"cubic with one root", []float64{1, 0, 1, -2}, []float64{1}},
+		{"quartic", fromRoots(0.5, 1, 2, -3, 4), []float64{-3, 1, 2, 4}},
+		{"biquadratic", fromRoots(1, -2, -0.5, 0.5, 2), []float64{-2, -0.5, 0.5, 2}},
+		{"quartic with two roots", []float64{1, 0, 0, 0, -16}, []float64{-2, 2}},
+		{"quartic without real roots", []float64{1, 0, 0, 0, 1}, []float64{}},
+		{"leading zeros", []float64{0, 0, 1, -4, 3}, []float64{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, err := PolynomialRoots(tt.coefficients)
+			if err != nil {
+				t.Fatalf("PolynomialRoots failed: %v", err)
+			}
+			if len(roots) != len(tt.expected) {
+				t.Fatalf("Expected roots %v, got %v", tt.expected, roots)
+			}
+			for i := range roots {
+				if math.Abs(roots[i]-tt.expected[i]) > 1e-9*math.Max(1, math.Abs(tt.expected[i])) {
+					t.Errorf("Expected roots %v, got %v", tt.expected, roots)
+				}
+			}
+		})
+	}
+
+	if _, err := PolynomialRoots([]float64{0, 0}); err == nil {
+		t.Error("Expected error for the zero polynomial, got nil")
+	}
+	if _, err := PolynomialRoots([]float64{1, 0, 0, 0, 0, 1}); err == nil {
+		t.Error("Expected error for a quintic, got nil")
+	}
+}
+
+// TestBrentWithOptions tests the iteration limit, the tolerances and the statistics
+func TestBrentWithOptions(t *testing.T) {
+	f := func(x float64) float64 {
+		return math.Exp(x) - 2
+	}
+
+	// Defaults give the result of Brent
+	root, stats, err := BrentWithOptions(f, 0, 2, BrentOptions{AbsTol: 1e-12})
+	if err != nil {
+		t.Fatalf("BrentWithOptions failed: %v", err)
+	}
+	expected, _ := Brent(f, 0, 2, 1e-12)
+	if root != expected {
+		t.Errorf("Expected the root of Brent %v, got %v", expected, root)
+	}
+	if stats.Iterations < 1 || stats.BracketWidth > 4e-12 {
+		t.Errorf("Unexpected statistics: %+v", stats)
+	}
+
+	// A loose relative tolerance needs fewer iterations and leaves a wider bracket
+	loose, looseStats, err := BrentWithOptions(f, 0, 2, BrentOptions{RelTol: 1e-3})
+	if err != nil {
+		t.Fatalf("BrentWithOptions failed: %v", err)
+	}
+	if math.Abs(loose-math.Ln2) > 2e-3 {
+		t.Errorf("Expected root near ln 2, got %v", loose)
+	}
+	if looseStats.Iterations > stats.Iterations || looseStats.BracketWidth <= stats.BracketWidth {
+		t.Errorf("Expected fewer iterations and a wider bracket, got %+v (default %+v)", looseStats, stats)
+	}
+
+	// The iteration limit is reported
+	_, limitStats, err := BrentWithOptions(f, 0, 2, BrentOptions{MaxIterations: 2, AbsTol: 1e-15})
+	if err == nil {
+		t.Error("Expected error when the iteration limit is reached, got nil")
+	}
+	if limitStats.Iterations != 2 || limitStats.BracketWidth <= 0 {
+		t.Errorf("Unexpected statistics at the iteration limit: %+v", limitStats)
+	}
+}